@@ -4,8 +4,8 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strconv"
 
+	"github.com/cookchen233/swarm-mcp/internal/config"
 	"github.com/cookchen233/swarm-mcp/internal/mcp"
 	"github.com/cookchen233/swarm-mcp/internal/swarm"
 	"github.com/joho/godotenv"
@@ -26,14 +26,18 @@ func main() {
 	}
 	_ = godotenv.Load()
 
-	// Data root: ~/.swarm-mcp/ by default, override with SWARM_MCP_ROOT
-	root := os.Getenv("SWARM_MCP_ROOT")
-	if root == "" {
-		home, _ := os.UserHomeDir()
-		root = filepath.Join(home, ".swarm-mcp")
+	// Settings come from swarm-mcp.toml (or SWARM_MCP_CONFIG); env vars override file values.
+	cfg, err := config.Load(os.Getenv("SWARM_MCP_CONFIG"))
+	if err != nil {
+		logger.Printf("%v", err)
+		os.Exit(1)
+	}
+	for _, w := range cfg.Warnings {
+		logger.Printf("WARNING: config: %s", w)
 	}
+	cfg.ForRole("acceptor")
 
-	store := swarm.NewStore(root)
+	store := swarm.NewStore(cfg.Root)
 	store.EnsureDir()
 	store.EnsureDir("docs", "shared")
 	store.EnsureDir("issues")
@@ -44,69 +48,7 @@ func main() {
 
 	trace := swarm.NewTraceService(store)
 
-	suggestedMinTaskCount := 0
-	if v := os.Getenv("SWARM_MCP_SUGGESTED_MIN_TASK_COUNT"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			suggestedMinTaskCount = n
-		}
-	} else if v := os.Getenv("SWARM_MCP_MIN_TASK_COUNT"); v != "" {
-		// Backward compatibility
-		if n, err := strconv.Atoi(v); err == nil {
-			suggestedMinTaskCount = n
-		}
-	}
-	maxTaskCount := 0
-	if v := os.Getenv("SWARM_MCP_MAX_TASK_COUNT"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			maxTaskCount = n
-		}
-	}
-
-	issueTTLSec := 7200
-	if v := os.Getenv("SWARM_MCP_ISSUE_TTL_SEC"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			issueTTLSec = n
-		}
-	}
-	taskTTLSec := 3600
-	if v := os.Getenv("SWARM_MCP_TASK_TTL_SEC"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			taskTTLSec = n
-		}
-	}
-
-	defaultTimeoutSec := 3600
-	if v := os.Getenv("SWARM_MCP_DEFAULT_TIMEOUT_SEC"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			defaultTimeoutSec = n
-		}
-	}
-	if defaultTimeoutSec < 3600 {
-		defaultTimeoutSec = 3600
-	}
-
-	minTimeoutSec := defaultTimeoutSec
-	if v := os.Getenv("SWARM_MCP_MIN_TIMEOUT_SEC"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			minTimeoutSec = n
-		}
-	}
-	if minTimeoutSec < 1 {
-		minTimeoutSec = 1
-	}
-
-	srv := mcp.NewServer(mcp.ServerConfig{
-		Name:                  "swarm-mcp-acceptor",
-		Version:               "0.1.0",
-		Logger:                logger,
-		Role:                  "acceptor",
-		SuggestedMinTaskCount: suggestedMinTaskCount,
-		MaxTaskCount:          maxTaskCount,
-		IssueTTLSec:           issueTTLSec,
-		TaskTTLSec:            taskTTLSec,
-		DefaultTimeoutSec:     defaultTimeoutSec,
-		MinTimeoutSec:         minTimeoutSec,
-	}, store, trace)
+	srv := mcp.NewServer(cfg.ServerConfig("swarm-mcp-acceptor", "0.1.0", logger), store, trace)
 
 	if err := srv.Run(); err != nil {
 		logger.Printf("server stopped with error: %v", err)
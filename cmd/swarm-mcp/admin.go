@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cookchen233/swarm-mcp/internal/swarm"
+)
+
+const adminUsage = `usage: swarm-mcp [command]
+
+Without a command the MCP server runs on stdio. Admin commands work directly on SWARM_MCP_ROOT:
+
+  issues list [-status open|done|...] [-json]   list issues
+  task show <issue_id> <task_id>                 print a task as JSON
+  issue export <issue_id> [-o file]              write the issue as a tar.gz archive (default <issue_id>.tar.gz)
+  issue import <file> [-preserve-id]             unpack an exported issue (fresh IDs unless -preserve-id)
+  locks clean                                    remove expired leases and file locks
+  fsck [-json] [-repair]                         report inconsistencies in the store; -repair fixes what it can
+  backup [-o file]                               snapshot the whole data root, all projects included, as a tar.gz
+  restore <file> [-force]                        unpack a backup into SWARM_MCP_ROOT (stop the server first);
+                                                 -force moves existing data aside to <root>.pre-restore-<time>
+  replica push                                   upload changed issue/task/delivery files to the [s3] bucket now
+  replica pull <dir> [-force]                    download the [s3] mirror into dir (e.g. a read replica)
+`
+
+// adminCommands are the first arguments that select an admin command; anything else starts the server.
+var adminCommands = map[string]bool{
+	"issues": true, "task": true, "issue": true, "locks": true, "fsck": true,
+	"backup": true, "restore": true, "replica": true, "help": true, "-h": true, "--help": true,
+}
+
+// isAdminCommand reports whether args (without the program name) select an admin command.
+func isAdminCommand(args []string) bool {
+	return len(args) > 0 && adminCommands[args[0]]
+}
+
+// runAdmin executes an offline admin command and returns the process exit code. root is the data root;
+// store is the namespace the command works on (root itself unless a project is selected). replica is nil
+// when no S3 bucket is configured.
+func runAdmin(args []string, root, store *swarm.Store, issues *swarm.IssueService, locks *swarm.LockService, replica *swarm.S3Replica, out io.Writer) int {
+	cmd := args[0]
+	if len(args) > 1 {
+		cmd += " " + args[1]
+	}
+
+	switch {
+	case cmd == "issues list":
+		fs := flag.NewFlagSet("issues list", flag.ContinueOnError)
+		status := fs.String("status", "", "only list issues with this status")
+		asJSON := fs.Bool("json", false, "print JSON")
+		if err := fs.Parse(args[2:]); err != nil {
+			return 2
+		}
+		list, err := issues.ListIssues()
+		if err != nil {
+			return adminFail(err)
+		}
+		filtered := make([]swarm.Issue, 0, len(list))
+		for _, is := range list {
+			if *status == "" || is.Status == *status {
+				filtered = append(filtered, is)
+			}
+		}
+		if *asJSON {
+			return adminJSON(out, filtered)
+		}
+		tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tSTATUS\tUPDATED\tSUBJECT")
+		for _, is := range filtered {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", is.ID, is.Status, is.UpdatedAt, is.Subject)
+		}
+		_ = tw.Flush()
+		return 0
+
+	case cmd == "task show":
+		if len(args) != 4 {
+			fmt.Fprint(os.Stderr, adminUsage)
+			return 2
+		}
+		task, err := issues.GetTask(args[2], args[3])
+		if err != nil {
+			return adminFail(err)
+		}
+		return adminJSON(out, task)
+
+	case cmd == "issue export":
+		fs := flag.NewFlagSet("issue export", flag.ContinueOnError)
+		output := fs.String("o", "", "archive path (default <issue_id>.tar.gz)")
+		if len(args) < 3 {
+			fmt.Fprint(os.Stderr, adminUsage)
+			return 2
+		}
+		if err := fs.Parse(args[3:]); err != nil {
+			return 2
+		}
+		issueID := args[2]
+		if *output == "" {
+			*output = issueID + ".tar.gz"
+		}
+		f, err := os.Create(*output)
+		if err != nil {
+			return adminFail(err)
+		}
+		manifest, err := issues.ExportIssue(issueID, f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(*output)
+			return adminFail(err)
+		}
+		fmt.Fprintf(out, "exported %s to %s (%d files, %d tasks, %d events)\n", issueID, *output, len(manifest.Files), manifest.Tasks, manifest.Events)
+		return 0
+
+	case cmd == "issue import":
+		fs := flag.NewFlagSet("issue import", flag.ContinueOnError)
+		preserve := fs.Bool("preserve-id", false, "keep the source issue and delivery IDs")
+		if len(args) < 3 {
+			fmt.Fprint(os.Stderr, adminUsage)
+			return 2
+		}
+		if err := fs.Parse(args[3:]); err != nil {
+			return 2
+		}
+		f, err := os.Open(args[2])
+		if err != nil {
+			return adminFail(err)
+		}
+		defer f.Close()
+		res, err := issues.ImportIssue(f, *preserve, "admin")
+		if err != nil {
+			return adminFail(err)
+		}
+		fmt.Fprintf(out, "imported %s as %s (%d files, %d tasks, %d deliveries)\n", res.SourceIssueID, res.IssueID, res.Files, res.Tasks, res.Deliveries)
+		return 0
+
+	case cmd == "locks clean":
+		n, err := locks.CleanExpired()
+		if err != nil {
+			return adminFail(err)
+		}
+		fmt.Fprintf(out, "cleaned %d expired lock(s)\n", n)
+		return 0
+
+	case args[0] == "fsck":
+		fs := flag.NewFlagSet("fsck", flag.ContinueOnError)
+		asJSON := fs.Bool("json", false, "print JSON")
+		repair := fs.Bool("repair", false, "fix what can be fixed (orphans go to <root>/lost+found)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		check := swarm.Fsck
+		if *repair {
+			check = swarm.FsckRepair
+		}
+		problems, err := check(store)
+		if err != nil {
+			return adminFail(err)
+		}
+		remaining := 0
+		for _, p := range problems {
+			if !p.Repaired {
+				remaining++
+			}
+		}
+		if *asJSON {
+			if problems == nil {
+				problems = []swarm.FsckProblem{}
+			}
+			adminJSON(out, problems)
+		} else {
+			for _, p := range problems {
+				action := p.Repair
+				switch {
+				case p.Repaired:
+					action = "repaired: " + action
+				case action == "":
+					action = "manual"
+				case !*repair:
+					action = "repairable: " + action
+				}
+				fmt.Fprintf(out, "%s\t%s\t%s\t[%s]\n", p.Kind, p.Path, p.Detail, action)
+			}
+			fmt.Fprintf(out, "%d problem(s) found in %s, %d repaired\n", len(problems), store.Root, len(problems)-remaining)
+		}
+		if remaining > 0 {
+			return 1
+		}
+		return 0
+
+	case args[0] == "backup":
+		fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+		output := fs.String("o", "", "archive path (default swarm-backup-<time>.tar.gz)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		if *output == "" {
+			*output = "swarm-backup-" + time.Now().UTC().Format("20060102T150405Z") + ".tar.gz"
+		}
+		f, err := os.Create(*output)
+		if err != nil {
+			return adminFail(err)
+		}
+		manifest, err := swarm.Backup(root, f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(*output)
+			return adminFail(err)
+		}
+		fmt.Fprintf(out, "backed up %s to %s (%d files, %d bytes, %d project(s))\n", root.Root, *output, manifest.Files, manifest.Bytes, len(manifest.Projects))
+		return 0
+
+	case args[0] == "restore":
+		fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+		force := fs.Bool("force", false, "move existing data aside instead of refusing")
+		if len(args) < 2 {
+			fmt.Fprint(os.Stderr, adminUsage)
+			return 2
+		}
+		if err := fs.Parse(args[2:]); err != nil {
+			return 2
+		}
+		f, err := os.Open(args[1])
+		if err != nil {
+			return adminFail(err)
+		}
+		defer f.Close()
+		manifest, aside, err := swarm.Restore(root.Root, f, *force)
+		if err != nil {
+			return adminFail(err)
+		}
+		fmt.Fprintf(out, "restored %d files into %s from a backup taken %s\n", manifest.Files, root.Root, manifest.CreatedAt)
+		if aside != "" {
+			fmt.Fprintf(out, "previous data moved to %s\n", aside)
+		}
+		return 0
+
+	case cmd == "replica push":
+		if replica == nil {
+			return adminFail(fmt.Errorf("no S3 replica configured (set [s3] bucket or SWARM_MCP_S3_BUCKET)"))
+		}
+		res, err := replica.Sync()
+		if err != nil {
+			return adminFail(err)
+		}
+		if res.Skipped {
+			fmt.Fprintln(out, "another process is syncing the replica; try again later")
+			return 1
+		}
+		fmt.Fprintf(out, "uploaded %d, deleted %d object(s)\n", res.Uploaded, res.Deleted)
+		return 0
+
+	case cmd == "replica pull":
+		fs := flag.NewFlagSet("replica pull", flag.ContinueOnError)
+		force := fs.Bool("force", false, "overwrite files in a directory that already holds data")
+		if len(args) < 3 {
+			fmt.Fprint(os.Stderr, adminUsage)
+			return 2
+		}
+		if err := fs.Parse(args[3:]); err != nil {
+			return 2
+		}
+		if replica == nil {
+			return adminFail(fmt.Errorf("no S3 replica configured (set [s3] bucket or SWARM_MCP_S3_BUCKET)"))
+		}
+		n, err := replica.Pull(args[2], *force)
+		if err != nil {
+			return adminFail(err)
+		}
+		fmt.Fprintf(out, "downloaded %d file(s) into %s\n", n, args[2])
+		return 0
+
+	case args[0] == "help" || args[0] == "-h" || args[0] == "--help":
+		fmt.Fprint(out, adminUsage)
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, adminUsage)
+	return 2
+}
+
+func adminJSON(out io.Writer, v any) int {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return adminFail(err)
+	}
+	return 0
+}
+
+func adminFail(err error) int {
+	fmt.Fprintf(os.Stderr, "swarm-mcp: %v\n", err)
+	return 1
+}
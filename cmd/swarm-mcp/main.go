@@ -4,8 +4,8 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strconv"
 
+	"github.com/cookchen233/swarm-mcp/internal/config"
 	"github.com/cookchen233/swarm-mcp/internal/mcp"
 	"github.com/cookchen233/swarm-mcp/internal/swarm"
 	"github.com/joho/godotenv"
@@ -26,14 +26,17 @@ func main() {
 	}
 	_ = godotenv.Load()
 
-	// Data root: ~/.swarm-mcp/ by default, override with SWARM_MCP_ROOT
-	root := os.Getenv("SWARM_MCP_ROOT")
-	if root == "" {
-		home, _ := os.UserHomeDir()
-		root = filepath.Join(home, ".swarm-mcp")
+	// Settings come from swarm-mcp.toml (or SWARM_MCP_CONFIG); env vars override file values.
+	cfg, err := config.Load(os.Getenv("SWARM_MCP_CONFIG"))
+	if err != nil {
+		logger.Printf("%v", err)
+		os.Exit(1)
+	}
+	for _, w := range cfg.Warnings {
+		logger.Printf("WARNING: config: %s", w)
 	}
 
-	store := swarm.NewStore(root)
+	store := swarm.NewStore(cfg.Root)
 	store.EnsureDir()
 	store.EnsureDir("docs", "shared")
 	store.EnsureDir("issues")
@@ -44,74 +47,37 @@ func main() {
 
 	trace := swarm.NewTraceService(store)
 
-	suggestedMinTaskCount := 0
-	if v := os.Getenv("SWARM_MCP_SUGGESTED_MIN_TASK_COUNT"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			suggestedMinTaskCount = n
+	if isAdminCommand(os.Args[1:]) {
+		// Admin commands work on the default project's namespace (SWARM_MCP_PROJECT) when one is set.
+		adminStore := store.Project(cfg.Project)
+		adminTrace := trace
+		if adminStore != store {
+			adminTrace = swarm.NewTraceService(adminStore)
 		}
-	} else if v := os.Getenv("SWARM_MCP_MIN_TASK_COUNT"); v != "" {
-		// Backward compatibility
-		if n, err := strconv.Atoi(v); err == nil {
-			suggestedMinTaskCount = n
-		}
-	}
-	maxTaskCount := 0
-	if v := os.Getenv("SWARM_MCP_MAX_TASK_COUNT"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			maxTaskCount = n
+		t := cfg.Timeouts
+		issues := swarm.NewIssueService(adminStore, adminTrace, t.IssueTTLSec, t.TaskTTLSec, t.DefaultTimeoutSec, t.MinTimeoutSec)
+		locks := swarm.NewLockService(adminStore, adminTrace)
+		swarm.ShareLeaseClock(store)
+		replica, err := swarm.NewS3Replica(cfg.S3Replica(), store, logger)
+		if err != nil {
+			logger.Printf("%v", err)
+			os.Exit(1)
 		}
+		os.Exit(runAdmin(os.Args[1:], store, adminStore, issues, locks, replica, os.Stdout))
 	}
 
-	issueTTLSec := 7200
-	if v := os.Getenv("SWARM_MCP_ISSUE_TTL_SEC"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			issueTTLSec = n
-		}
-	}
-	taskTTLSec := 3600
-	if v := os.Getenv("SWARM_MCP_TASK_TTL_SEC"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			taskTTLSec = n
-		}
-	}
-
-	defaultTimeoutSec := 3600
-	if v := os.Getenv("SWARM_MCP_DEFAULT_TIMEOUT_SEC"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			defaultTimeoutSec = n
-		}
-	}
-	if defaultTimeoutSec < 3600 {
-		defaultTimeoutSec = 3600
-	}
-
-	minTimeoutSec := defaultTimeoutSec
-	if v := os.Getenv("SWARM_MCP_MIN_TIMEOUT_SEC"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			minTimeoutSec = n
-		}
-	}
-	if minTimeoutSec < 1 {
-		minTimeoutSec = 1
+	if len(os.Args) > 1 {
+		// MCP launchers sometimes pass their own flags; they do not select an admin command.
+		logger.Printf("ignoring arguments %q (not an admin command; see swarm-mcp help)", os.Args[1:])
 	}
 
-	role := os.Getenv("SWARM_MCP_ROLE")
-	if role == "" {
-		logger.Printf("WARNING: SWARM_MCP_ROLE not set; running in full-access debug mode (all tools exposed). Set SWARM_MCP_ROLE=lead|worker|acceptor for role-scoped access.")
+	if cfg.Role == "" && cfg.Profile == "" && len(cfg.Profiles) > 0 {
+		logger.Printf("no profile selected; clients pick one with initialize params {\"profile\": ...} (full access until then)")
+	} else if cfg.Role == "" && cfg.Profile == "" {
+		logger.Printf("WARNING: role not set (SWARM_MCP_ROLE or role in swarm-mcp.toml); running in full-access debug mode (all tools exposed). Set SWARM_MCP_ROLE=lead|worker|acceptor|admin for role-scoped access.")
 	}
 
-	srv := mcp.NewServer(mcp.ServerConfig{
-		Name:                  "swarm-mcp",
-		Version:               "0.1.0",
-		Logger:                logger,
-		Role:                  role,
-		SuggestedMinTaskCount: suggestedMinTaskCount,
-		MaxTaskCount:          maxTaskCount,
-		IssueTTLSec:           issueTTLSec,
-		TaskTTLSec:            taskTTLSec,
-		DefaultTimeoutSec:     defaultTimeoutSec,
-		MinTimeoutSec:         minTimeoutSec,
-	}, store, trace)
+	srv := mcp.NewServer(cfg.ServerConfig("swarm-mcp", "0.1.0", logger), store, trace)
 
 	if err := srv.Run(); err != nil {
 		logger.Printf("server stopped with error: %v", err)
@@ -2,9 +2,7 @@ module github.com/cookchen233/swarm-mcp
 
 go 1.22
 
-require github.com/joho/godotenv v1.5.1
-
 require (
-	github.com/fsnotify/fsnotify v1.9.0 // indirect
-	golang.org/x/sys v0.13.0 // indirect
+	github.com/BurntSushi/toml v1.6.0
+	github.com/joho/godotenv v1.5.1
 )
@@ -0,0 +1,967 @@
+// Package config loads the swarm-mcp server configuration: a single TOML file (optional) overlaid by
+// SWARM_MCP_* / SESSION_MCP_* environment variables, validated before the server starts.
+package config
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/cookchen233/swarm-mcp/internal/mcp"
+	"github.com/cookchen233/swarm-mcp/internal/swarm"
+)
+
+// FileName is the config file looked up next to the binary (../, ./) and in the working directory
+// when SWARM_MCP_CONFIG is not set.
+const FileName = "swarm-mcp.toml"
+
+type Config struct {
+	Root                   string `toml:"root"`
+	Role                   string `toml:"role"`
+	AcceptorID             string `toml:"acceptor_id"`
+	HealthAddr             string `toml:"health_addr"`
+	ProgressionPolicy      string `toml:"progression_policy"`
+	Webhooks               string `toml:"webhooks"`
+	ReviewChecklists       string `toml:"review_checklists"`
+	SubmissionRequirements string `toml:"submission_requirements"`
+	ValidationHooks        string `toml:"validation_hooks"`
+	ConfigDir              string `toml:"config_dir"`
+	Profile                string `toml:"profile"`
+	Project                string `toml:"project"`
+	ToolsPageSize          int    `toml:"tools_page_size"`
+
+	Timeouts  Timeouts  `toml:"timeouts"`
+	Tasks     Tasks     `toml:"tasks"`
+	RoleCodes RoleCodes `toml:"role_codes"`
+	Gateway   Gateway   `toml:"gateway"`
+	Verify    Verify    `toml:"verify"`
+	Git       Git       `toml:"git"`
+	GitHub    GitHub    `toml:"github"`
+	RateLimit RateLimit `toml:"rate_limit"`
+	Backup    Backup    `toml:"backup"`
+	S3        S3        `toml:"s3"`
+	Retention Retention `toml:"retention"`
+	Trace     Trace     `toml:"trace"`
+
+	Profiles map[string]Profile `toml:"profiles"`
+	Projects map[string]Project `toml:"projects"`
+
+	// Source is the config file that was loaded ("" when running on env/defaults only).
+	Source string `toml:"-"`
+	// Warnings lists settings that were accepted but adjusted; the binaries log them at startup.
+	Warnings []string `toml:"-"`
+}
+
+type Timeouts struct {
+	IssueTTLSec       int `toml:"issue_ttl_sec"`
+	TaskTTLSec        int `toml:"task_ttl_sec"`
+	DefaultTimeoutSec int `toml:"default_timeout_sec"`
+	MinTimeoutSec     int `toml:"min_timeout_sec"`
+	ReviewSLASec      int `toml:"review_sla_sec"`
+	LeaseWarningSec   int `toml:"lease_warning_sec"`
+
+	// How long a claimed inbox item stays with its claimer before it is served again (0 = 300).
+	LeadInboxClaimSec     int `toml:"lead_inbox_claim_sec"`
+	AcceptorInboxClaimSec int `toml:"acceptor_inbox_claim_sec"`
+
+	// 1 = long-poll tools honor allow_short=true, waiting less than min_timeout_sec (interactive tooling).
+	AllowShort int `toml:"allow_short"`
+
+	// Seconds between keepalive progress notifications on calls that carry a progressToken (0 = 15).
+	ProgressIntervalSec int `toml:"progress_interval_sec"`
+
+	// Seconds between background expiry sweeps of issues, tasks and file locks (0 = 30).
+	SweepIntervalSec int `toml:"sweep_interval_sec"`
+}
+
+type Tasks struct {
+	SuggestedMinCount   int            `toml:"suggested_min_count"`
+	MaxCount            int            `toml:"max_count"`
+	MaxClaimedPerWorker int            `toml:"max_claimed_per_worker"`
+	MaxClaimedByWorker  map[string]int `toml:"max_claimed_by_worker"`
+	SchedulerReserveSec int            `toml:"scheduler_reserve_sec"`
+	SchedulerStaleSec   int            `toml:"scheduler_worker_stale_sec"`
+	DispatchPolicy      string         `toml:"dispatch_policy"`
+	MaxRejections       int            `toml:"max_rejections"`
+	PeerReview          int            `toml:"peer_review"`
+	CriticalGate        int            `toml:"critical_gate"`
+	ShardEvents         int            `toml:"shard_events"`
+	InboxPriorities     []string       `toml:"inbox_priorities"`
+}
+
+type RoleCodes struct {
+	Shared   string `toml:"shared"`
+	Lead     string `toml:"lead"`
+	Worker   string `toml:"worker"`
+	Acceptor string `toml:"acceptor"`
+	Admin    string `toml:"admin"`
+}
+
+type Gateway struct {
+	URL           string `toml:"url"`
+	Authorization string `toml:"authorization"`
+	Token         string `toml:"token"`
+	APIKey        string `toml:"api_key"`
+	ValidateTool  string `toml:"validate_tool"`
+	TimeoutSec    int    `toml:"timeout_sec"`
+
+	CacheTTLSec         int `toml:"cache_ttl_sec"`
+	NegativeCacheTTLSec int `toml:"negative_cache_ttl_sec"`
+	GraceSec            int `toml:"grace_sec"`
+}
+
+type Verify struct {
+	Workdir    string `toml:"workdir"`
+	TimeoutSec int    `toml:"timeout_sec"`
+}
+
+type Git struct {
+	RepoPath string `toml:"repo_path"`
+	BaseRef  string `toml:"base_ref"`
+}
+
+type GitHub struct {
+	Repo    string `toml:"repo"`
+	Token   string `toml:"token"`
+	API     string `toml:"api"`
+	PollSec int    `toml:"poll_sec"`
+	CIToken string `toml:"ci_token"`
+}
+
+// RateLimit configures token buckets per session and per worker (calls per minute; 0 = unlimited) and
+// how many calls the server handles at once (0 = 256).
+type RateLimit struct {
+	SessionPerMin      int `toml:"session_per_min"`
+	SessionBurst       int `toml:"session_burst"`
+	WorkerPerMin       int `toml:"worker_per_min"`
+	WorkerBurst        int `toml:"worker_burst"`
+	MaxConcurrentCalls int `toml:"max_concurrent_calls"`
+}
+
+// Backup configures scheduled snapshots of the data root (interval 0 = off).
+type Backup struct {
+	IntervalSec int    `toml:"interval_sec"`
+	Dir         string `toml:"dir"`
+	Keep        int    `toml:"keep"`
+}
+
+// S3 configures the write-behind mirror of issues, tasks and deliveries to an S3-compatible bucket
+// (empty bucket = off).
+type S3 struct {
+	Endpoint  string `toml:"endpoint"`
+	Region    string `toml:"region"`
+	Bucket    string `toml:"bucket"`
+	Prefix    string `toml:"prefix"`
+	AccessKey string `toml:"access_key"`
+	SecretKey string `toml:"secret_key"`
+	FlushSec  int    `toml:"flush_sec"`
+}
+
+// Retention bounds the store's growth (0 = keep forever).
+type Retention struct {
+	ClosedIssueDays   int    `toml:"closed_issue_days"`
+	ClosedIssueAction string `toml:"closed_issue_action"`
+	TraceMaxMB        int    `toml:"trace_max_mb"`
+	IntervalSec       int    `toml:"interval_sec"`
+}
+
+// Trace configures trace/events.jsonl rotation (0 = never), event types left out of it, and the sinks
+// events are also sent to (empty = off).
+type Trace struct {
+	MaxMB         int      `toml:"max_mb"`
+	MaxAgeHours   int      `toml:"max_age_hours"`
+	Keep          int      `toml:"keep"`
+	DisabledTypes []string `toml:"disabled_types"`
+
+	SyslogAddr   string `toml:"syslog_addr"`
+	SyslogTag    string `toml:"syslog_tag"`
+	HTTPURL      string `toml:"http_url"`
+	HTTPHeaders  string `toml:"http_headers"`
+	OTLPEndpoint string `toml:"otlp_endpoint"`
+	OTLPHeaders  string `toml:"otlp_headers"`
+	ServiceName  string `toml:"service_name"`
+}
+
+// Profile overrides per-role settings; unset fields inherit the top-level values.
+// Role defaults to the profile name.
+type Profile struct {
+	Role                  string `toml:"role"`
+	RoleCode              string `toml:"role_code"`
+	AcceptorID            string `toml:"acceptor_id"`
+	IssueTTLSec           *int   `toml:"issue_ttl_sec"`
+	TaskTTLSec            *int   `toml:"task_ttl_sec"`
+	DefaultTimeoutSec     *int   `toml:"default_timeout_sec"`
+	MinTimeoutSec         *int   `toml:"min_timeout_sec"`
+	SuggestedMinTaskCount *int   `toml:"suggested_min_task_count"`
+	MaxTaskCount          *int   `toml:"max_task_count"`
+	MaxClaimedPerWorker   *int   `toml:"max_claimed_per_worker"`
+}
+
+// Project declares a project namespace (issues, tasks, docs and locks under <root>/projects/<key>) and
+// overrides settings for it; unset fields inherit the top-level values.
+type Project struct {
+	IssueTTLSec         *int   `toml:"issue_ttl_sec"`
+	TaskTTLSec          *int   `toml:"task_ttl_sec"`
+	MaxTaskCount        *int   `toml:"max_task_count"`
+	MaxClaimedPerWorker *int   `toml:"max_claimed_per_worker"`
+	RepoPath            string `toml:"repo_path"`
+	GitBaseRef          string `toml:"git_base_ref"`
+	VerifyWorkdir       string `toml:"verify_workdir"`
+}
+
+// Defaults returns the configuration used when neither a file nor env vars set a value.
+func Defaults() *Config {
+	root := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		root = filepath.Join(home, ".swarm-mcp")
+	}
+	return &Config{
+		Root: root,
+		Timeouts: Timeouts{
+			IssueTTLSec:       7200,
+			TaskTTLSec:        3600,
+			DefaultTimeoutSec: 3600,
+		},
+		Gateway: Gateway{
+			URL:          "http://127.0.0.1:15410",
+			ValidateTool: "validateSemanticSession",
+			TimeoutSec:   5,
+
+			CacheTTLSec:         60,
+			NegativeCacheTTLSec: 5,
+		},
+		Verify: Verify{TimeoutSec: 600},
+		GitHub: GitHub{PollSec: 60},
+		Backup: Backup{Keep: 7},
+		Retention: Retention{
+			ClosedIssueAction: swarm.RetentionArchive,
+			IntervalSec:       3600,
+		},
+		Trace: Trace{Keep: 10},
+	}
+}
+
+// ValidationError lists every problem found in the configuration.
+type ValidationError struct {
+	Source   string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	src := e.Source
+	if src == "" {
+		src = "environment"
+	}
+	return fmt.Sprintf("invalid configuration (%s):\n  - %s", src, strings.Join(e.Problems, "\n  - "))
+}
+
+// Load reads path (or the first FileName found next to the binary / in the working directory when
+// path is empty), applies env overrides and validates the result.
+func Load(path string) (*Config, error) {
+	return load(path, os.Getenv)
+}
+
+func load(path string, getenv func(string) string) (*Config, error) {
+	cfg := Defaults()
+	var problems []string
+
+	if strings.TrimSpace(path) == "" {
+		path = findConfigFile()
+	} else if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+	if path != "" {
+		meta, err := toml.DecodeFile(path, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+		cfg.Source = path
+		for _, key := range meta.Undecoded() {
+			problems = append(problems, fmt.Sprintf("%s: unknown setting", key.String()))
+		}
+	}
+
+	problems = append(problems, cfg.applyEnv(getenv)...)
+	cfg.normalize()
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Source: cfg.Source, Problems: problems}
+	}
+	return cfg, nil
+}
+
+func findConfigFile() string {
+	var candidates []string
+	if exe, err := os.Executable(); err == nil {
+		exeDir := filepath.Dir(exe)
+		candidates = append(candidates,
+			filepath.Clean(filepath.Join(exeDir, "..", FileName)),
+			filepath.Join(exeDir, FileName),
+		)
+	}
+	candidates = append(candidates, FileName)
+	for _, c := range candidates {
+		if st, err := os.Stat(c); err == nil && !st.IsDir() {
+			return c
+		}
+	}
+	return ""
+}
+
+// applyEnv overlays environment variables on top of file values. Unparsable numbers are reported
+// rather than silently ignored.
+func (c *Config) applyEnv(getenv func(string) string) []string {
+	var problems []string
+	str := func(dst *string, keys ...string) {
+		for _, k := range keys {
+			if v := strings.TrimSpace(getenv(k)); v != "" {
+				*dst = v
+				return
+			}
+		}
+	}
+	num := func(dst *int, keys ...string) {
+		for _, k := range keys {
+			v := strings.TrimSpace(getenv(k))
+			if v == "" {
+				continue
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %q is not an integer", k, v))
+			} else {
+				*dst = n
+			}
+			return
+		}
+	}
+
+	str(&c.Root, "SWARM_MCP_ROOT")
+	str(&c.Role, "SWARM_MCP_ROLE")
+	str(&c.AcceptorID, "SWARM_MCP_ACCEPTOR_ID")
+	str(&c.HealthAddr, "SWARM_MCP_HEALTH_ADDR")
+	num(&c.ToolsPageSize, "SWARM_MCP_TOOLS_PAGE_SIZE")
+	str(&c.ProgressionPolicy, "SWARM_MCP_PROGRESSION_POLICY")
+	str(&c.Webhooks, "SWARM_MCP_WEBHOOKS")
+	str(&c.ReviewChecklists, "SWARM_MCP_REVIEW_CHECKLISTS")
+	str(&c.SubmissionRequirements, "SWARM_MCP_SUBMISSION_REQUIREMENTS")
+	str(&c.ValidationHooks, "SWARM_MCP_VALIDATION_HOOKS")
+	str(&c.ConfigDir, "SWARM_MCP_CONFIG_DIR")
+	str(&c.Profile, "SWARM_MCP_PROFILE")
+	str(&c.Project, "SWARM_MCP_PROJECT")
+
+	num(&c.Timeouts.IssueTTLSec, "SWARM_MCP_ISSUE_TTL_SEC")
+	num(&c.Timeouts.TaskTTLSec, "SWARM_MCP_TASK_TTL_SEC")
+	num(&c.Timeouts.DefaultTimeoutSec, "SWARM_MCP_DEFAULT_TIMEOUT_SEC")
+	num(&c.Timeouts.MinTimeoutSec, "SWARM_MCP_MIN_TIMEOUT_SEC")
+	num(&c.Timeouts.ReviewSLASec, "SWARM_MCP_REVIEW_SLA_SEC")
+	num(&c.Timeouts.LeaseWarningSec, "SWARM_MCP_LEASE_WARNING_SEC")
+	num(&c.Timeouts.LeadInboxClaimSec, "SWARM_MCP_LEAD_INBOX_CLAIM_SEC")
+	num(&c.Timeouts.AcceptorInboxClaimSec, "SWARM_MCP_ACCEPTOR_INBOX_CLAIM_SEC")
+	num(&c.Timeouts.AllowShort, "SWARM_MCP_ALLOW_SHORT_TIMEOUTS")
+	num(&c.Timeouts.ProgressIntervalSec, "SWARM_MCP_PROGRESS_INTERVAL_SEC")
+	num(&c.Timeouts.SweepIntervalSec, "SWARM_MCP_SWEEP_INTERVAL_SEC")
+
+	// SWARM_MCP_MIN_TASK_COUNT is the legacy name.
+	num(&c.Tasks.SuggestedMinCount, "SWARM_MCP_SUGGESTED_MIN_TASK_COUNT", "SWARM_MCP_MIN_TASK_COUNT")
+	num(&c.Tasks.MaxCount, "SWARM_MCP_MAX_TASK_COUNT")
+	num(&c.Tasks.MaxClaimedPerWorker, "SWARM_MCP_MAX_CLAIMED_PER_WORKER")
+	// Per-worker caps as worker=N pairs; they win over [tasks.max_claimed_by_worker] for the same worker.
+	if v := strings.TrimSpace(getenv("SWARM_MCP_MAX_CLAIMED_BY_WORKER")); v != "" {
+		for _, pair := range strings.Split(v, ",") {
+			worker, n, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			limit, err := strconv.Atoi(strings.TrimSpace(n))
+			if worker = strings.TrimSpace(worker); !ok || worker == "" || err != nil {
+				problems = append(problems, fmt.Sprintf("SWARM_MCP_MAX_CLAIMED_BY_WORKER: %q is not worker=N", pair))
+				continue
+			}
+			if c.Tasks.MaxClaimedByWorker == nil {
+				c.Tasks.MaxClaimedByWorker = map[string]int{}
+			}
+			c.Tasks.MaxClaimedByWorker[worker] = limit
+		}
+	}
+	num(&c.Tasks.SchedulerReserveSec, "SWARM_MCP_SCHEDULER_RESERVE_SEC")
+	num(&c.Tasks.SchedulerStaleSec, "SWARM_MCP_SCHEDULER_WORKER_STALE_SEC")
+	str(&c.Tasks.DispatchPolicy, "SWARM_MCP_DISPATCH_POLICY")
+	num(&c.Tasks.MaxRejections, "SWARM_MCP_MAX_REJECTIONS")
+	num(&c.Tasks.PeerReview, "SWARM_MCP_PEER_REVIEW")
+	num(&c.Tasks.CriticalGate, "SWARM_MCP_CRITICAL_GATE")
+	num(&c.Tasks.ShardEvents, "SWARM_MCP_SHARD_TASK_EVENTS")
+	if v := strings.TrimSpace(getenv("SWARM_MCP_INBOX_PRIORITIES")); v != "" {
+		c.Tasks.InboxPriorities = strings.Split(v, ",")
+	}
+
+	str(&c.RoleCodes.Shared, "SWARM_MCP_ROLE_CODE")
+	str(&c.RoleCodes.Lead, "SWARM_MCP_ROLE_CODE_LEAD")
+	str(&c.RoleCodes.Worker, "SWARM_MCP_ROLE_CODE_WORKER")
+	str(&c.RoleCodes.Acceptor, "SWARM_MCP_ROLE_CODE_ACCEPTOR")
+	str(&c.RoleCodes.Admin, "SWARM_MCP_ROLE_CODE_ADMIN")
+
+	str(&c.Gateway.URL, "SESSION_MCP_GATEWAY_URL")
+	str(&c.Gateway.Authorization, "SESSION_MCP_GATEWAY_AUTHORIZATION")
+	// MCP_GATEWAY_TOKEN is preferred for consistency with the gateway itself.
+	str(&c.Gateway.Token, "MCP_GATEWAY_TOKEN", "SESSION_MCP_GATEWAY_TOKEN")
+	str(&c.Gateway.APIKey, "SESSION_MCP_GATEWAY_API_KEY")
+	if c.Gateway.APIKey == "" {
+		str(&c.Gateway.APIKey, "MCP_GATEWAY_TOKEN")
+	}
+	str(&c.Gateway.ValidateTool, "SESSION_MCP_VALIDATE_TOOL")
+	num(&c.Gateway.TimeoutSec, "SESSION_MCP_GATEWAY_TIMEOUT_SEC")
+	num(&c.Gateway.CacheTTLSec, "SESSION_MCP_CACHE_TTL_SEC")
+	num(&c.Gateway.NegativeCacheTTLSec, "SESSION_MCP_NEGATIVE_CACHE_TTL_SEC")
+	num(&c.Gateway.GraceSec, "SESSION_MCP_GRACE_SEC")
+
+	str(&c.Verify.Workdir, "SWARM_MCP_VERIFY_WORKDIR")
+	num(&c.Verify.TimeoutSec, "SWARM_MCP_VERIFY_TIMEOUT_SEC")
+
+	str(&c.Git.RepoPath, "SWARM_MCP_REPO_PATH")
+	str(&c.Git.BaseRef, "SWARM_MCP_GIT_BASE_REF")
+
+	str(&c.GitHub.Repo, "SWARM_MCP_GITHUB_REPO")
+	str(&c.GitHub.Token, "SWARM_MCP_GITHUB_TOKEN")
+	str(&c.GitHub.API, "SWARM_MCP_GITHUB_API")
+	num(&c.GitHub.PollSec, "SWARM_MCP_GITHUB_POLL_SEC")
+	str(&c.GitHub.CIToken, "SWARM_MCP_CI_GITHUB_TOKEN")
+
+	num(&c.RateLimit.SessionPerMin, "SWARM_MCP_RATE_LIMIT_SESSION_PER_MIN")
+	num(&c.RateLimit.SessionBurst, "SWARM_MCP_RATE_LIMIT_SESSION_BURST")
+	num(&c.RateLimit.WorkerPerMin, "SWARM_MCP_RATE_LIMIT_WORKER_PER_MIN")
+	num(&c.RateLimit.WorkerBurst, "SWARM_MCP_RATE_LIMIT_WORKER_BURST")
+	num(&c.RateLimit.MaxConcurrentCalls, "SWARM_MCP_MAX_CONCURRENT_CALLS")
+
+	num(&c.Backup.IntervalSec, "SWARM_MCP_BACKUP_INTERVAL_SEC")
+	str(&c.Backup.Dir, "SWARM_MCP_BACKUP_DIR")
+	num(&c.Backup.Keep, "SWARM_MCP_BACKUP_KEEP")
+
+	str(&c.S3.Endpoint, "SWARM_MCP_S3_ENDPOINT")
+	str(&c.S3.Region, "SWARM_MCP_S3_REGION")
+	str(&c.S3.Bucket, "SWARM_MCP_S3_BUCKET")
+	str(&c.S3.Prefix, "SWARM_MCP_S3_PREFIX")
+	str(&c.S3.AccessKey, "SWARM_MCP_S3_ACCESS_KEY")
+	str(&c.S3.SecretKey, "SWARM_MCP_S3_SECRET_KEY")
+	num(&c.S3.FlushSec, "SWARM_MCP_S3_FLUSH_SEC")
+
+	num(&c.Retention.ClosedIssueDays, "SWARM_MCP_RETENTION_CLOSED_ISSUE_DAYS")
+	str(&c.Retention.ClosedIssueAction, "SWARM_MCP_RETENTION_CLOSED_ISSUE_ACTION")
+	num(&c.Retention.TraceMaxMB, "SWARM_MCP_RETENTION_TRACE_MAX_MB")
+	num(&c.Retention.IntervalSec, "SWARM_MCP_RETENTION_INTERVAL_SEC")
+
+	num(&c.Trace.MaxMB, "SWARM_MCP_TRACE_MAX_MB")
+	num(&c.Trace.MaxAgeHours, "SWARM_MCP_TRACE_MAX_AGE_HOURS")
+	num(&c.Trace.Keep, "SWARM_MCP_TRACE_KEEP")
+	if v := strings.TrimSpace(getenv("SWARM_MCP_TRACE_DISABLED_TYPES")); v != "" {
+		c.Trace.DisabledTypes = strings.Split(v, ",")
+	}
+	str(&c.Trace.SyslogAddr, "SWARM_MCP_TRACE_SYSLOG_ADDR")
+	str(&c.Trace.SyslogTag, "SWARM_MCP_TRACE_SYSLOG_TAG")
+	str(&c.Trace.HTTPURL, "SWARM_MCP_TRACE_HTTP_URL")
+	str(&c.Trace.HTTPHeaders, "SWARM_MCP_TRACE_HTTP_HEADERS")
+	str(&c.Trace.OTLPEndpoint, "SWARM_MCP_TRACE_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_LOGS_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT")
+	str(&c.Trace.OTLPHeaders, "SWARM_MCP_TRACE_OTLP_HEADERS", "OTEL_EXPORTER_OTLP_HEADERS")
+	str(&c.Trace.ServiceName, "SWARM_MCP_TRACE_SERVICE_NAME", "OTEL_SERVICE_NAME")
+
+	return problems
+}
+
+// normalize fills derived values and applies the historical clamps.
+func (c *Config) normalize() {
+	c.Role = strings.ToLower(strings.TrimSpace(c.Role))
+	if strings.HasPrefix(c.Root, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			c.Root = filepath.Join(home, c.Root[2:])
+		}
+	}
+	// Long-poll tools never wait less than an hour by default.
+	if c.Timeouts.DefaultTimeoutSec > 0 && c.Timeouts.DefaultTimeoutSec < 3600 {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("timeouts.default_timeout_sec: %d raised to 3600, the minimum default long-poll timeout", c.Timeouts.DefaultTimeoutSec))
+		c.Timeouts.DefaultTimeoutSec = 3600
+	}
+	if c.Timeouts.MinTimeoutSec == 0 {
+		c.Timeouts.MinTimeoutSec = c.Timeouts.DefaultTimeoutSec
+	}
+	if c.GitHub.CIToken == "" {
+		c.GitHub.CIToken = c.GitHub.Token
+	}
+	c.Profile = strings.TrimSpace(c.Profile)
+	c.Project = strings.TrimSpace(c.Project)
+	for name, p := range c.Profiles {
+		p.Role = strings.ToLower(strings.TrimSpace(p.Role))
+		if p.Role == "" {
+			p.Role = strings.ToLower(name)
+		}
+		c.Profiles[name] = p
+	}
+}
+
+func (c *Config) validate() []string {
+	var problems []string
+	bad := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if strings.TrimSpace(c.Root) == "" {
+		bad("root: data directory is required (set root or SWARM_MCP_ROOT)")
+	}
+	switch c.Role {
+	case "", "lead", "worker", "acceptor":
+	case "admin":
+		if strings.TrimSpace(c.RoleCodes.Admin) == "" {
+			bad("role_codes.admin: required for the admin role (SWARM_MCP_ROLE_CODE_ADMIN)")
+		}
+	default:
+		bad("role: must be lead, worker, acceptor or admin (got %q)", c.Role)
+	}
+
+	positive := map[string]int{
+		"timeouts.issue_ttl_sec":       c.Timeouts.IssueTTLSec,
+		"timeouts.task_ttl_sec":        c.Timeouts.TaskTTLSec,
+		"timeouts.default_timeout_sec": c.Timeouts.DefaultTimeoutSec,
+		"timeouts.min_timeout_sec":     c.Timeouts.MinTimeoutSec,
+		"gateway.timeout_sec":          c.Gateway.TimeoutSec,
+		"verify.timeout_sec":           c.Verify.TimeoutSec,
+	}
+	nonNegative := map[string]int{
+		"tools_page_size":                   c.ToolsPageSize,
+		"timeouts.review_sla_sec":           c.Timeouts.ReviewSLASec,
+		"timeouts.lease_warning_sec":        c.Timeouts.LeaseWarningSec,
+		"timeouts.lead_inbox_claim_sec":     c.Timeouts.LeadInboxClaimSec,
+		"timeouts.acceptor_inbox_claim_sec": c.Timeouts.AcceptorInboxClaimSec,
+		"timeouts.allow_short":              c.Timeouts.AllowShort,
+		"timeouts.progress_interval_sec":    c.Timeouts.ProgressIntervalSec,
+		"timeouts.sweep_interval_sec":       c.Timeouts.SweepIntervalSec,
+		"tasks.suggested_min_count":         c.Tasks.SuggestedMinCount,
+		"tasks.max_count":                   c.Tasks.MaxCount,
+		"tasks.max_claimed_per_worker":      c.Tasks.MaxClaimedPerWorker,
+		"tasks.scheduler_reserve_sec":       c.Tasks.SchedulerReserveSec,
+		"tasks.scheduler_worker_stale_sec":  c.Tasks.SchedulerStaleSec,
+		"tasks.max_rejections":              c.Tasks.MaxRejections,
+		"tasks.peer_review":                 c.Tasks.PeerReview,
+		"tasks.critical_gate":               c.Tasks.CriticalGate,
+		"tasks.shard_events":                c.Tasks.ShardEvents,
+		"github.poll_sec":                   c.GitHub.PollSec,
+		"gateway.cache_ttl_sec":             c.Gateway.CacheTTLSec,
+		"gateway.negative_cache_ttl_sec":    c.Gateway.NegativeCacheTTLSec,
+		"gateway.grace_sec":                 c.Gateway.GraceSec,
+		"rate_limit.session_per_min":        c.RateLimit.SessionPerMin,
+		"rate_limit.session_burst":          c.RateLimit.SessionBurst,
+		"rate_limit.worker_per_min":         c.RateLimit.WorkerPerMin,
+		"rate_limit.worker_burst":           c.RateLimit.WorkerBurst,
+		"rate_limit.max_concurrent_calls":   c.RateLimit.MaxConcurrentCalls,
+		"backup.interval_sec":               c.Backup.IntervalSec,
+		"backup.keep":                       c.Backup.Keep,
+		"s3.flush_sec":                      c.S3.FlushSec,
+		"retention.closed_issue_days":       c.Retention.ClosedIssueDays,
+		"retention.trace_max_mb":            c.Retention.TraceMaxMB,
+		"retention.interval_sec":            c.Retention.IntervalSec,
+		"trace.max_mb":                      c.Trace.MaxMB,
+		"trace.max_age_hours":               c.Trace.MaxAgeHours,
+		"trace.keep":                        c.Trace.Keep,
+	}
+	for worker, n := range c.Tasks.MaxClaimedByWorker {
+		nonNegative["tasks.max_claimed_by_worker."+worker] = n
+	}
+	for _, key := range sortedKeys(positive) {
+		if positive[key] <= 0 {
+			bad("%s: must be > 0 (got %d)", key, positive[key])
+		}
+	}
+	for _, key := range sortedKeys(nonNegative) {
+		if nonNegative[key] < 0 {
+			bad("%s: must be >= 0 (got %d)", key, nonNegative[key])
+		}
+	}
+	for _, name := range c.profileNames() {
+		p := c.Profiles[name]
+		switch p.Role {
+		case "lead", "worker", "acceptor":
+		case "admin":
+			if strings.TrimSpace(p.RoleCode) == "" && strings.TrimSpace(c.RoleCodes.Admin) == "" {
+				bad("profiles.%s: the admin role needs its own role_code (or role_codes.admin)", name)
+			}
+		default:
+			bad("profiles.%s.role: must be lead, worker, acceptor or admin (got %q)", name, p.Role)
+		}
+		for key, v := range map[string]*int{
+			"issue_ttl_sec":       p.IssueTTLSec,
+			"task_ttl_sec":        p.TaskTTLSec,
+			"default_timeout_sec": p.DefaultTimeoutSec,
+			"min_timeout_sec":     p.MinTimeoutSec,
+		} {
+			if v != nil && *v <= 0 {
+				bad("profiles.%s.%s: must be > 0 (got %d)", name, key, *v)
+			}
+		}
+		for key, v := range map[string]*int{
+			"suggested_min_task_count": p.SuggestedMinTaskCount,
+			"max_task_count":           p.MaxTaskCount,
+			"max_claimed_per_worker":   p.MaxClaimedPerWorker,
+		} {
+			if v != nil && *v < 0 {
+				bad("profiles.%s.%s: must be >= 0 (got %d)", name, key, *v)
+			}
+		}
+	}
+	if c.Profile != "" {
+		if _, ok := c.Profiles[c.Profile]; !ok {
+			bad("profile: %q is not defined under [profiles] (available: %s)", c.Profile, strings.Join(c.profileNames(), ", "))
+		}
+	}
+
+	for _, key := range c.projectKeys() {
+		p := c.Projects[key]
+		if err := swarm.ValidateProjectKey(key); err != nil {
+			bad("projects.%s: %v", key, err)
+		}
+		for name, v := range map[string]*int{"issue_ttl_sec": p.IssueTTLSec, "task_ttl_sec": p.TaskTTLSec} {
+			if v != nil && *v <= 0 {
+				bad("projects.%s.%s: must be > 0 (got %d)", key, name, *v)
+			}
+		}
+		for name, v := range map[string]*int{"max_task_count": p.MaxTaskCount, "max_claimed_per_worker": p.MaxClaimedPerWorker} {
+			if v != nil && *v < 0 {
+				bad("projects.%s.%s: must be >= 0 (got %d)", key, name, *v)
+			}
+		}
+		for name, dir := range map[string]string{"repo_path": p.RepoPath, "verify_workdir": p.VerifyWorkdir} {
+			if dir == "" {
+				continue
+			}
+			if st, err := os.Stat(dir); err != nil || !st.IsDir() {
+				bad("projects.%s.%s: %q is not a directory", key, name, dir)
+			}
+		}
+	}
+	if c.Project != "" {
+		if _, ok := c.Projects[c.Project]; !ok {
+			bad("project: %q is not defined under [projects] (available: %s)", c.Project, strings.Join(c.projectKeys(), ", "))
+		}
+	}
+
+	if err := swarm.ValidateInboxPriorities(c.Tasks.InboxPriorities); err != nil {
+		bad("tasks.inbox_priorities: %v", err)
+	}
+	if !swarm.ValidDispatchPolicy(c.Tasks.DispatchPolicy) {
+		bad("tasks.dispatch_policy: must be %s or %s (got %q)", swarm.DispatchRoundRobin, swarm.DispatchLeastPoints, c.Tasks.DispatchPolicy)
+	}
+	if c.Tasks.MaxCount > 0 && c.Tasks.SuggestedMinCount > c.Tasks.MaxCount {
+		bad("tasks.suggested_min_count: %d exceeds tasks.max_count %d", c.Tasks.SuggestedMinCount, c.Tasks.MaxCount)
+	}
+
+	if u, err := url.Parse(c.Gateway.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		bad("gateway.url: must be an http(s) URL (got %q)", c.Gateway.URL)
+	}
+	if c.GitHub.API != "" {
+		if u, err := url.Parse(c.GitHub.API); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			bad("github.api: must be an http(s) URL (got %q)", c.GitHub.API)
+		}
+	}
+	if c.GitHub.Repo != "" {
+		if parts := strings.Split(c.GitHub.Repo, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			bad("github.repo: must be owner/name (got %q)", c.GitHub.Repo)
+		}
+	}
+	if !swarm.ValidRetentionAction(c.Retention.ClosedIssueAction) {
+		bad("retention.closed_issue_action: must be %s or %s (got %q)", swarm.RetentionArchive, swarm.RetentionDelete, c.Retention.ClosedIssueAction)
+	}
+	for key, u := range map[string]string{"trace.http_url": c.Trace.HTTPURL, "trace.otlp_endpoint": c.Trace.OTLPEndpoint} {
+		if u == "" {
+			continue
+		}
+		if pu, err := url.Parse(u); err != nil || (pu.Scheme != "http" && pu.Scheme != "https") || pu.Host == "" {
+			bad("%s: must be an http(s) URL (got %q)", key, u)
+		}
+	}
+	for key, h := range map[string]string{"trace.http_headers": c.Trace.HTTPHeaders, "trace.otlp_headers": c.Trace.OTLPHeaders} {
+		if _, err := swarm.ParseHeaderList(h); err != nil {
+			bad("%s: %v", key, err)
+		}
+	}
+	if a := c.Trace.SyslogAddr; a != "" && a != "local" {
+		if u, err := url.Parse(a); err != nil || (u.Scheme != "udp" && u.Scheme != "tcp") || u.Host == "" {
+			bad("trace.syslog_addr: must be \"local\" or udp://host:port / tcp://host:port (got %q)", a)
+		}
+	}
+	if c.S3.Endpoint != "" {
+		if u, err := url.Parse(c.S3.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			bad("s3.endpoint: must be an http(s) URL (got %q)", c.S3.Endpoint)
+		}
+	}
+	if c.S3.Bucket != "" && (c.S3.AccessKey == "" || c.S3.SecretKey == "") {
+		bad("s3.access_key and s3.secret_key: required when s3.bucket is set")
+	}
+	if c.HealthAddr != "" {
+		if _, _, err := net.SplitHostPort(c.HealthAddr); err != nil {
+			bad("health_addr: must be host:port (got %q)", c.HealthAddr)
+		}
+	}
+	for key, p := range map[string]string{"verify.workdir": c.Verify.Workdir, "git.repo_path": c.Git.RepoPath, "config_dir": c.ConfigDir} {
+		if p == "" {
+			continue
+		}
+		if st, err := os.Stat(p); err != nil || !st.IsDir() {
+			bad("%s: %q is not a directory", key, p)
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+func (c *Config) profileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for n := range c.Profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Config) projectKeys() []string {
+	keys := make([]string, 0, len(c.Projects))
+	for k := range c.Projects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ForRole pins the configuration to one role (role-specific binaries). A profile with the same name is
+// applied if defined; other profiles are dropped so a client cannot switch the binary's role.
+func (c *Config) ForRole(role string) {
+	c.Role = role
+	c.Profile = ""
+	p, ok := c.Profiles[role]
+	c.Profiles = nil
+	if ok && p.Role == role {
+		c.Profiles = map[string]Profile{role: p}
+		c.Profile = role
+	}
+}
+
+// resolveProfiles merges each profile over the top-level settings.
+func (c *Config) resolveProfiles() map[string]mcp.RoleProfile {
+	if len(c.Profiles) == 0 {
+		return nil
+	}
+	pick := func(v *int, def int) int {
+		if v != nil {
+			return *v
+		}
+		return def
+	}
+	out := make(map[string]mcp.RoleProfile, len(c.Profiles))
+	for name, p := range c.Profiles {
+		acceptorID := p.AcceptorID
+		if acceptorID == "" {
+			acceptorID = c.AcceptorID
+		}
+		defaultTimeout := pick(p.DefaultTimeoutSec, c.Timeouts.DefaultTimeoutSec)
+		if defaultTimeout < 3600 {
+			defaultTimeout = 3600
+		}
+		minTimeout := c.Timeouts.MinTimeoutSec
+		if p.MinTimeoutSec != nil {
+			minTimeout = *p.MinTimeoutSec
+		} else if p.DefaultTimeoutSec != nil && c.Timeouts.MinTimeoutSec == c.Timeouts.DefaultTimeoutSec {
+			// min_timeout_sec follows default_timeout_sec unless set explicitly.
+			minTimeout = defaultTimeout
+		}
+		out[name] = mcp.RoleProfile{
+			Role:                  p.Role,
+			RoleCode:              p.RoleCode,
+			AcceptorID:            acceptorID,
+			IssueTTLSec:           pick(p.IssueTTLSec, c.Timeouts.IssueTTLSec),
+			TaskTTLSec:            pick(p.TaskTTLSec, c.Timeouts.TaskTTLSec),
+			DefaultTimeoutSec:     defaultTimeout,
+			MinTimeoutSec:         minTimeout,
+			SuggestedMinTaskCount: pick(p.SuggestedMinTaskCount, c.Tasks.SuggestedMinCount),
+			MaxTaskCount:          pick(p.MaxTaskCount, c.Tasks.MaxCount),
+			MaxClaimedPerWorker:   pick(p.MaxClaimedPerWorker, c.Tasks.MaxClaimedPerWorker),
+		}
+	}
+	return out
+}
+
+// resolveProjects merges each project over the top-level settings.
+func (c *Config) resolveProjects() map[string]mcp.ProjectConfig {
+	if len(c.Projects) == 0 {
+		return nil
+	}
+	pick := func(v *int, def int) int {
+		if v != nil {
+			return *v
+		}
+		return def
+	}
+	orStr := func(v, def string) string {
+		if v != "" {
+			return v
+		}
+		return def
+	}
+	out := make(map[string]mcp.ProjectConfig, len(c.Projects))
+	for key, p := range c.Projects {
+		out[key] = mcp.ProjectConfig{
+			IssueTTLSec:         pick(p.IssueTTLSec, 0), // 0: the connection's (profile) TTL
+			TaskTTLSec:          pick(p.TaskTTLSec, 0),
+			MaxTaskCount:        pick(p.MaxTaskCount, c.Tasks.MaxCount),
+			MaxClaimedPerWorker: pick(p.MaxClaimedPerWorker, c.Tasks.MaxClaimedPerWorker),
+			RepoPath:            orStr(p.RepoPath, c.Git.RepoPath),
+			GitBaseRef:          orStr(p.GitBaseRef, c.Git.BaseRef),
+			VerifyWorkdir:       orStr(p.VerifyWorkdir, c.Verify.Workdir),
+		}
+	}
+	return out
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// S3Replica maps the [s3] section onto the replica settings.
+func (c *Config) S3Replica() swarm.S3ReplicaConfig {
+	return swarm.S3ReplicaConfig{
+		Endpoint:  c.S3.Endpoint,
+		Region:    c.S3.Region,
+		Bucket:    c.S3.Bucket,
+		Prefix:    c.S3.Prefix,
+		AccessKey: c.S3.AccessKey,
+		SecretKey: c.S3.SecretKey,
+		FlushSec:  c.S3.FlushSec,
+	}
+}
+
+// TraceRotation maps the [trace] section onto the trace log settings.
+func (c *Config) TraceRotation() swarm.TraceRotation {
+	return swarm.TraceRotation{
+		MaxBytes:      int64(c.Trace.MaxMB) << 20,
+		MaxAgeSec:     c.Trace.MaxAgeHours * 3600,
+		Keep:          c.Trace.Keep,
+		DisabledTypes: c.Trace.DisabledTypes,
+	}
+}
+
+// TraceSinks maps the [trace] sink settings onto the trace sink configuration.
+func (c *Config) TraceSinks() swarm.TraceSinkConfig {
+	httpHeaders, _ := swarm.ParseHeaderList(c.Trace.HTTPHeaders)
+	otlpHeaders, _ := swarm.ParseHeaderList(c.Trace.OTLPHeaders)
+	return swarm.TraceSinkConfig{
+		SyslogAddr:   c.Trace.SyslogAddr,
+		SyslogTag:    c.Trace.SyslogTag,
+		HTTPURL:      c.Trace.HTTPURL,
+		HTTPHeaders:  httpHeaders,
+		OTLPEndpoint: c.Trace.OTLPEndpoint,
+		OTLPHeaders:  otlpHeaders,
+		ServiceName:  c.Trace.ServiceName,
+	}
+}
+
+// ServerConfig maps the configuration onto the MCP server settings.
+func (c *Config) ServerConfig(name, version string, logger *log.Logger) mcp.ServerConfig {
+	roleCodes := map[string]string{
+		"":         c.RoleCodes.Shared,
+		"lead":     c.RoleCodes.Lead,
+		"worker":   c.RoleCodes.Worker,
+		"acceptor": c.RoleCodes.Acceptor,
+		"admin":    c.RoleCodes.Admin,
+	}
+	return mcp.ServerConfig{
+		Name:                       name,
+		Version:                    version,
+		Logger:                     logger,
+		Role:                       c.Role,
+		SuggestedMinTaskCount:      c.Tasks.SuggestedMinCount,
+		MaxTaskCount:               c.Tasks.MaxCount,
+		MaxClaimedPerWorker:        c.Tasks.MaxClaimedPerWorker,
+		MaxClaimedByWorker:         c.Tasks.MaxClaimedByWorker,
+		SchedulerReserveSec:        c.Tasks.SchedulerReserveSec,
+		SchedulerWorkerStaleSec:    c.Tasks.SchedulerStaleSec,
+		DispatchPolicy:             c.Tasks.DispatchPolicy,
+		MaxRejections:              c.Tasks.MaxRejections,
+		PeerReview:                 c.Tasks.PeerReview > 0,
+		CriticalGate:               c.Tasks.CriticalGate > 0,
+		ShardTaskEvents:            c.Tasks.ShardEvents > 0,
+		InboxPriorities:            c.Tasks.InboxPriorities,
+		LeadInboxClaimSec:          c.Timeouts.LeadInboxClaimSec,
+		AcceptorInboxClaimSec:      c.Timeouts.AcceptorInboxClaimSec,
+		AllowShortTimeouts:         c.Timeouts.AllowShort > 0,
+		ProgressIntervalSec:        c.Timeouts.ProgressIntervalSec,
+		SweepIntervalSec:           c.Timeouts.SweepIntervalSec,
+		MaxConcurrentCalls:         c.RateLimit.MaxConcurrentCalls,
+		ToolsPageSize:              c.ToolsPageSize,
+		S3Replica:                  c.S3Replica(),
+		TraceRotation:              c.TraceRotation(),
+		TraceSinks:                 c.TraceSinks(),
+		RetentionIntervalSec:       c.Retention.IntervalSec,
+		ProgressionPolicyPath:      c.ProgressionPolicy,
+		AcceptorID:                 c.AcceptorID,
+		VerifyWorkdir:              c.Verify.Workdir,
+		VerifyTimeoutSec:           c.Verify.TimeoutSec,
+		WebhooksPath:               c.Webhooks,
+		ReviewChecklistsPath:       c.ReviewChecklists,
+		SubmissionRequirementsPath: c.SubmissionRequirements,
+		ValidationHooksPath:        c.ValidationHooks,
+		RepoPath:                   c.Git.RepoPath,
+		GitBaseRef:                 c.Git.BaseRef,
+		CIGitHubToken:              c.GitHub.CIToken,
+		HealthAddr:                 c.HealthAddr,
+		ConfigDir:                  c.ConfigDir,
+		Profiles:                   c.resolveProfiles(),
+		Profile:                    c.Profile,
+		Projects:                   c.resolveProjects(),
+		Project:                    c.Project,
+		RoleCodes:                  roleCodes,
+		Gateway: mcp.GatewayConfig{
+			URL:           c.Gateway.URL,
+			Authorization: c.Gateway.Authorization,
+			Token:         c.Gateway.Token,
+			APIKey:        c.Gateway.APIKey,
+			ValidateTool:  c.Gateway.ValidateTool,
+			TimeoutSec:    c.Gateway.TimeoutSec,
+
+			CacheTTLSec:         c.Gateway.CacheTTLSec,
+			NegativeCacheTTLSec: c.Gateway.NegativeCacheTTLSec,
+			GraceSec:            c.Gateway.GraceSec,
+		},
+		GitHubSync: swarm.GitHubSyncConfig{
+			Repo:    c.GitHub.Repo,
+			Token:   c.GitHub.Token,
+			APIBase: c.GitHub.API,
+			PollSec: c.GitHub.PollSec,
+		},
+		RateLimit: mcp.RateLimitConfig{
+			SessionPerMin: c.RateLimit.SessionPerMin,
+			SessionBurst:  c.RateLimit.SessionBurst,
+			WorkerPerMin:  c.RateLimit.WorkerPerMin,
+			WorkerBurst:   c.RateLimit.WorkerBurst,
+		},
+		Backup: swarm.BackupConfig{
+			IntervalSec: c.Backup.IntervalSec,
+			Dir:         c.Backup.Dir,
+			Keep:        c.Backup.Keep,
+		},
+		Retention: swarm.RetentionPolicy{
+			ClosedIssueDays: c.Retention.ClosedIssueDays,
+			ClosedAction:    c.Retention.ClosedIssueAction,
+			TraceMaxBytes:   int64(c.Retention.TraceMaxMB) << 20,
+		},
+		IssueTTLSec:       c.Timeouts.IssueTTLSec,
+		TaskTTLSec:        c.Timeouts.TaskTTLSec,
+		DefaultTimeoutSec: c.Timeouts.DefaultTimeoutSec,
+		MinTimeoutSec:     c.Timeouts.MinTimeoutSec,
+		ReviewSLASec:      c.Timeouts.ReviewSLASec,
+		LeaseWarningSec:   c.Timeouts.LeaseWarningSec,
+	}
+}
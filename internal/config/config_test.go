@@ -0,0 +1,247 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func envMap(m map[string]string) func(string) string {
+	return func(k string) string { return m[k] }
+}
+
+func TestLoad_FileWithEnvOverrides(t *testing.T) {
+	path := writeConfig(t, `
+root = "/data/swarm"
+role = "worker"
+
+[timeouts]
+issue_ttl_sec = 100
+default_timeout_sec = 60
+
+[tasks]
+max_count = 8
+[tasks.max_claimed_by_worker]
+w1 = 2
+
+[gateway]
+url = "http://gw:15410"
+token = "file-token"
+
+[rate_limit]
+worker_per_min = 30
+`)
+	cfg, err := load(path, envMap(map[string]string{
+		"SWARM_MCP_ROLE":           "lead",
+		"SWARM_MCP_TASK_TTL_SEC":   "42",
+		"SWARM_MCP_MIN_TASK_COUNT": "3",
+		"SWARM_MCP_GITHUB_TOKEN":   "gh",
+
+		"SWARM_MCP_RATE_LIMIT_SESSION_PER_MIN": "120",
+	}))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Source != path || cfg.Root != "/data/swarm" || cfg.Role != "lead" {
+		t.Fatalf("unexpected top-level values: %+v", cfg)
+	}
+	if cfg.Timeouts.IssueTTLSec != 100 || cfg.Timeouts.TaskTTLSec != 42 {
+		t.Fatalf("unexpected ttls: %+v", cfg.Timeouts)
+	}
+	if cfg.Timeouts.DefaultTimeoutSec != 3600 || cfg.Timeouts.MinTimeoutSec != 3600 {
+		t.Fatalf("default timeout should be clamped to 3600: %+v", cfg.Timeouts)
+	}
+	if len(cfg.Warnings) != 1 || !strings.Contains(cfg.Warnings[0], "timeouts.default_timeout_sec: 60 raised to 3600") {
+		t.Fatalf("the clamp must be reported as a warning, got %q", cfg.Warnings)
+	}
+	if cfg.Tasks.SuggestedMinCount != 3 || cfg.Tasks.MaxCount != 8 || cfg.Tasks.MaxClaimedByWorker["w1"] != 2 {
+		t.Fatalf("unexpected task limits: %+v", cfg.Tasks)
+	}
+	if cfg.Gateway.Token != "file-token" || cfg.Gateway.TimeoutSec != 5 || cfg.Gateway.CacheTTLSec != 60 || cfg.Gateway.NegativeCacheTTLSec != 5 {
+		t.Fatalf("unexpected gateway: %+v", cfg.Gateway)
+	}
+	if cfg.GitHub.CIToken != "gh" {
+		t.Fatalf("ci token should fall back to github token, got %q", cfg.GitHub.CIToken)
+	}
+	if rl := cfg.ServerConfig("t", "0", nil).RateLimit; rl.SessionPerMin != 120 || rl.WorkerPerMin != 30 || rl.WorkerBurst != 0 {
+		t.Fatalf("unexpected rate limits: %+v", rl)
+	}
+}
+
+func TestLoad_ReportsAllProblems(t *testing.T) {
+	path := writeConfig(t, `
+role = "boss"
+colour = "blue"
+
+[timeouts]
+issue_ttl_sec = -1
+
+[github]
+repo = "not-a-repo"
+`)
+	_, err := load(path, envMap(map[string]string{
+		"SWARM_MCP_MAX_TASK_COUNT":   "ten",
+		"SESSION_MCP_GATEWAY_URL":    "gw:15410",
+		"SWARM_MCP_INBOX_PRIORITIES": "blocker,lunch",
+	}))
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	msg := verr.Error()
+	for _, want := range []string{"colour: unknown setting", `role: must be lead, worker, acceptor or admin (got "boss")`,
+		"timeouts.issue_ttl_sec: must be > 0", "github.repo", `SWARM_MCP_MAX_TASK_COUNT: "ten" is not an integer`, "gateway.url"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("expected %q in error:\n%s", want, msg)
+		}
+	}
+}
+
+func TestLoad_MaxClaimedByWorkerEnv(t *testing.T) {
+	path := writeConfig(t, `
+root = "/data/swarm"
+
+[tasks.max_claimed_by_worker]
+w1 = 2
+w2 = 3
+`)
+	cfg, err := load(path, envMap(map[string]string{"SWARM_MCP_MAX_CLAIMED_BY_WORKER": "w2=1, worker-7=0"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Tasks.MaxClaimedByWorker; len(got) != 3 || got["w1"] != 2 || got["w2"] != 1 || got["worker-7"] != 0 {
+		t.Fatalf("unexpected per-worker caps: %v", got)
+	}
+
+	_, err = load(path, envMap(map[string]string{"SWARM_MCP_MAX_CLAIMED_BY_WORKER": "w1=2,w3"}))
+	if err == nil || !strings.Contains(err.Error(), `SWARM_MCP_MAX_CLAIMED_BY_WORKER: "w3" is not worker=N`) {
+		t.Fatalf("expected a malformed pair to be reported, got %v", err)
+	}
+}
+
+func TestLoad_MissingExplicitFile(t *testing.T) {
+	if _, err := load(filepath.Join(t.TempDir(), "nope.toml"), envMap(nil)); err == nil {
+		t.Fatalf("expected error for missing explicit config file")
+	}
+}
+
+func TestLoad_ProfilesInheritTopLevel(t *testing.T) {
+	path := writeConfig(t, `
+profile = "w"
+
+[timeouts]
+task_ttl_sec = 900
+
+[tasks]
+max_claimed_per_worker = 2
+
+[profiles.lead]
+role_code = "lead-secret"
+max_task_count = 12
+
+[profiles.w]
+role = "worker"
+task_ttl_sec = 300
+max_claimed_per_worker = 0
+`)
+	cfg, err := load(path, envMap(nil))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	profiles := cfg.resolveProfiles()
+	lead, w := profiles["lead"], profiles["w"]
+	if lead.Role != "lead" || lead.RoleCode != "lead-secret" || lead.MaxTaskCount != 12 || lead.TaskTTLSec != 900 || lead.MaxClaimedPerWorker != 2 {
+		t.Fatalf("unexpected lead profile: %+v", lead)
+	}
+	if w.Role != "worker" || w.TaskTTLSec != 300 || w.MaxClaimedPerWorker != 0 || w.IssueTTLSec != 7200 {
+		t.Fatalf("unexpected worker profile: %+v", w)
+	}
+
+	cfg.ForRole("lead")
+	if cfg.Profile != "lead" || len(cfg.Profiles) != 1 {
+		t.Fatalf("ForRole should pin the lead profile, got %q %v", cfg.Profile, cfg.Profiles)
+	}
+}
+
+func TestLoad_AdminRoleNeedsOwnCode(t *testing.T) {
+	path := writeConfig(t, `
+role = "admin"
+
+[role_codes]
+shared = "shared-secret"
+
+[profiles.ops]
+role = "admin"
+`)
+	_, err := load(path, envMap(nil))
+	if err == nil || !strings.Contains(err.Error(), "role_codes.admin: required") || !strings.Contains(err.Error(), "profiles.ops: the admin role needs its own role_code") {
+		t.Fatalf("expected admin role code errors, got %v", err)
+	}
+
+	cfg, err := load(path, envMap(map[string]string{"SWARM_MCP_ROLE_CODE_ADMIN": "admin-secret"}))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := cfg.ServerConfig("swarm-mcp", "test", nil).RoleCodes["admin"]; got != "admin-secret" {
+		t.Fatalf("admin role code = %q", got)
+	}
+}
+
+func TestLoad_UnknownProfile(t *testing.T) {
+	path := writeConfig(t, `
+[profiles.ops]
+role = "root"
+`)
+	_, err := load(path, envMap(map[string]string{"SWARM_MCP_PROFILE": "lead"}))
+	if err == nil || !strings.Contains(err.Error(), `profiles.ops.role`) || !strings.Contains(err.Error(), `profile: "lead" is not defined`) {
+		t.Fatalf("expected profile validation errors, got %v", err)
+	}
+}
+
+func TestLoad_ProjectsInheritTopLevel(t *testing.T) {
+	path := writeConfig(t, `
+[tasks]
+max_count = 10
+
+[git]
+base_ref = "origin/main"
+
+[projects.web]
+
+[projects.api]
+task_ttl_sec = 600
+git_base_ref = "origin/develop"
+`)
+	cfg, err := load(path, envMap(map[string]string{"SWARM_MCP_PROJECT": "api"}))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	projects := cfg.resolveProjects()
+	web, api := projects["web"], projects["api"]
+	// Unset lease TTLs stay 0: the server applies the connection's profile values.
+	if web.MaxTaskCount != 10 || web.GitBaseRef != "origin/main" || web.TaskTTLSec != 0 {
+		t.Fatalf("unexpected web project: %+v", web)
+	}
+	if api.TaskTTLSec != 600 || api.GitBaseRef != "origin/develop" || api.MaxTaskCount != 10 {
+		t.Fatalf("unexpected api project: %+v", api)
+	}
+	if cfg.ServerConfig("t", "0", nil).Project != "api" {
+		t.Fatalf("expected default project api")
+	}
+
+	_, err = load(path, envMap(map[string]string{"SWARM_MCP_PROJECT": "Mobile App"}))
+	if err == nil || !strings.Contains(err.Error(), `project: "Mobile App" is not defined`) {
+		t.Fatalf("expected undefined project error, got %v", err)
+	}
+}
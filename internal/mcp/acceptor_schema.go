@@ -0,0 +1,60 @@
+package mcp
+
+import "strings"
+
+// Acceptor identity helpers.
+// Several acceptor instances may share one delivery queue; each one claims and reviews
+// deliveries under its own acceptor_id so ownership checks and reviewed_by stay accurate.
+
+var acceptorIdentityTools = map[string]bool{
+	"waitDeliveries":      true,
+	"claimDelivery":       true,
+	"extendDeliveryLease": true,
+	"reviewDelivery":      true,
+}
+
+func injectAcceptorIDIntoTools(role string, tools []ToolDefinition) []ToolDefinition {
+	if strings.TrimSpace(role) != "acceptor" {
+		return tools
+	}
+	out := make([]ToolDefinition, 0, len(tools))
+	for _, t := range tools {
+		if !acceptorIdentityTools[t.Name] {
+			out = append(out, t)
+			continue
+		}
+		out = append(out, ToolDefinition{
+			Name:        t.Name,
+			Description: t.Description,
+			InputSchema: injectAcceptorIDIntoSchema(t.InputSchema),
+		})
+	}
+	return out
+}
+
+func injectAcceptorIDIntoSchema(schema any) any {
+	m, ok := schema.(map[string]any)
+	if !ok {
+		return schema
+	}
+	props, ok := m["properties"].(map[string]any)
+	if !ok {
+		props = map[string]any{}
+		m["properties"] = props
+	}
+	if _, exists := props["acceptor_id"]; !exists {
+		props["acceptor_id"] = map[string]any{"type": "string", "description": "Stable acceptor identity (optional; defaults to SWARM_MCP_ACCEPTOR_ID or \"acceptor\"). Use the same value for wait/claim/extend/review."}
+	}
+	return m
+}
+
+// acceptorIDForArgs resolves the acceptor identity: explicit acceptor_id, then the configured id, then "acceptor".
+func (s *Server) acceptorIDForArgs(args map[string]any) string {
+	if v := strings.TrimSpace(str(args, "acceptor_id")); v != "" {
+		return v
+	}
+	if v := strings.TrimSpace(s.cfg.AcceptorID); v != "" {
+		return v
+	}
+	return "acceptor"
+}
@@ -0,0 +1,45 @@
+package mcp
+
+import "sync/atomic"
+
+// Backpressure on request handling. Each request runs in its own goroutine so long-polls do not block
+// other tools; callSlots caps how many run at once, so a misbehaving client cannot pile up thousands of
+// pollers. A request arriving while every slot is taken fails right away with ErrServerBusy.
+
+// defaultMaxConcurrentCalls applies when ServerConfig.MaxConcurrentCalls is 0.
+const defaultMaxConcurrentCalls = 256
+
+type callSlots struct {
+	sem      chan struct{}
+	rejected atomic.Int64
+}
+
+func newCallSlots(max int) *callSlots {
+	if max <= 0 {
+		max = defaultMaxConcurrentCalls
+	}
+	return &callSlots{sem: make(chan struct{}, max)}
+}
+
+// tryAcquire takes a slot without waiting; it reports false when all are in use.
+func (c *callSlots) tryAcquire() bool {
+	select {
+	case c.sem <- struct{}{}:
+		return true
+	default:
+		c.rejected.Add(1)
+		return false
+	}
+}
+
+func (c *callSlots) release() {
+	<-c.sem
+}
+
+// busyResponse is the error for request id when no slot is free.
+func (c *callSlots) busyResponse(id any) JSONRPCResponse {
+	return NewErrorResponse(id, ErrServerBusy, "server busy: too many concurrent calls, retry later", map[string]any{
+		"max_concurrent_calls": cap(c.sem),
+		"in_flight":            len(c.sem),
+	})
+}
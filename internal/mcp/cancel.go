@@ -0,0 +1,99 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// In-flight request tracking, so a client can cancel a long-poll with notifications/cancelled
+// ({"requestId": <id>}). The request's context is cancelled and, as MCP requires, no response is sent.
+// Run also uses it to abort and drain every call when the client disconnects.
+
+// shutdownGrace bounds how long Run waits for cancelled calls to return after the input closes.
+const shutdownGrace = 5 * time.Second
+
+// errRequestCancelled is the cancel cause of a request named by notifications/cancelled.
+var errRequestCancelled = errors.New("request cancelled by client")
+
+// requestCancelled reports whether the client cancelled the request of ctx.
+func requestCancelled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errRequestCancelled)
+}
+
+type inflightRequests struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelCauseFunc
+	running sync.WaitGroup
+}
+
+func requestKey(id any) string {
+	return fmt.Sprint(id)
+}
+
+// start returns a context for request id derived from parent, and a func to call when the request is done.
+func (r *inflightRequests) start(parent context.Context, id any) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(parent)
+	r.running.Add(1)
+	if id == nil {
+		return ctx, func() {
+			cancel(nil)
+			r.running.Done()
+		}
+	}
+	key := requestKey(id)
+	r.mu.Lock()
+	if r.cancels == nil {
+		r.cancels = map[string]context.CancelCauseFunc{}
+	}
+	r.cancels[key] = cancel
+	r.mu.Unlock()
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.cancels, key)
+		r.mu.Unlock()
+		cancel(nil)
+		r.running.Done()
+	}
+}
+
+// count is the number of requests with an id still running.
+func (r *inflightRequests) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.cancels)
+}
+
+// wait blocks until every started request is done or timeout elapses; false on timeout.
+func (r *inflightRequests) wait(timeout time.Duration) bool {
+	finished := make(chan struct{})
+	go func() {
+		r.running.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// cancel cancels the request named by the params of a notifications/cancelled message. Unknown or
+// finished requests are ignored.
+func (r *inflightRequests) cancel(params any) bool {
+	pm, _ := params.(map[string]any)
+	id, ok := pm["requestId"]
+	if !ok || id == nil {
+		return false
+	}
+	r.mu.Lock()
+	cancel, ok := r.cancels[requestKey(id)]
+	r.mu.Unlock()
+	if ok {
+		cancel(errRequestCancelled)
+	}
+	return ok
+}
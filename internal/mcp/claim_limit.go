@@ -0,0 +1,21 @@
+package mcp
+
+import "strings"
+
+// Claim limit helpers.
+// A per-worker override (ServerConfig.MaxClaimedByWorker, from [tasks.max_claimed_by_worker] or
+// SWARM_MCP_MAX_CLAIMED_BY_WORKER) wins over the project's default (ServerConfig.MaxClaimedPerWorker for
+// the root namespace). 0 means unlimited.
+
+func (s *Server) maxClaimedForWorker(p *projectScope, workerID string) int {
+	workerID = strings.TrimSpace(workerID)
+	if workerID != "" {
+		if n, ok := s.cfg.MaxClaimedByWorker[workerID]; ok && n >= 0 {
+			return n
+		}
+	}
+	if p.maxClaimedPerWorker < 0 {
+		return 0
+	}
+	return p.maxClaimedPerWorker
+}
@@ -0,0 +1,19 @@
+package mcp
+
+import "testing"
+
+func TestMaxClaimedForWorker(t *testing.T) {
+	// The per-worker cap comes from config only, not from an env var named after the worker.
+	t.Setenv("SWARM_MCP_MAX_CLAIMED_PER_WORKER_W1", "9")
+	s := &Server{cfg: ServerConfig{MaxClaimedByWorker: map[string]int{"w1": 2, "worker-7": 0}}}
+	p := &projectScope{maxClaimedPerWorker: 4}
+
+	for worker, want := range map[string]int{"w1": 2, "worker-7": 0, "w2": 4, "": 4} {
+		if got := s.maxClaimedForWorker(p, worker); got != want {
+			t.Errorf("%q: got %d, want %d", worker, got, want)
+		}
+	}
+	if got := s.maxClaimedForWorker(&projectScope{maxClaimedPerWorker: -1}, "w2"); got != 0 {
+		t.Errorf("a negative default means unlimited, got %d", got)
+	}
+}
@@ -0,0 +1,148 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cookchen233/swarm-mcp/internal/swarm"
+)
+
+// Cursor pagination for list tools. Offsets skip or repeat items when the set changes between pages;
+// a cursor instead remembers the sort position (sort key + id) of an item, and the next page starts
+// strictly after it. The cursor is opaque to clients (base64 JSON). Every paged tool uses the same
+// contract: each row carries its own cursor, the next page starts after the last row's, and a page
+// shorter than limit is the last. listIssues, listIssueTasks and listDeliveries therefore stay plain
+// arrays whether or not a cursor is passed, and getIssueTimeline's items carry cursors the same way.
+
+type pageCursor struct {
+	List      string `json:"l"` // issues|tasks|deliveries|timeline: a cursor only continues the list it came from
+	SortBy    string `json:"s"`
+	SortOrder string `json:"o"`
+	Key       string `json:"k"`
+	ID        string `json:"i"`
+}
+
+func (c pageCursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// at returns the cursor that continues the list after the item at (key, id).
+func (c pageCursor) at(key, id string) string {
+	c.Key, c.ID = key, id
+	return c.encode()
+}
+
+// cursorArg reports whether the call asked for cursor paging (a cursor argument is present, possibly
+// empty for the first page) and decodes it. sortBy/sortOrder are the call's (normalized) sort
+// arguments; a cursor from a different list or sort order is rejected.
+func cursorArg(args map[string]any, list, sortBy, sortOrder string) (cur *pageCursor, paged bool, err error) {
+	raw, ok := args["cursor"]
+	if !ok || raw == nil {
+		return nil, false, nil
+	}
+	s, _ := raw.(string)
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, true, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, true, swarm.Errorf(swarm.CodeInvalidArgument, "invalid cursor")
+	}
+	var c pageCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.List != list {
+		return nil, true, swarm.Errorf(swarm.CodeInvalidArgument, "invalid cursor")
+	}
+	if c.SortBy != sortBy || c.SortOrder != sortOrder {
+		return nil, true, swarm.Errorf(swarm.CodeInvalidArgument, "cursor was issued for sort_by=%s sort_order=%s; pass the same sort arguments or start over without a cursor", c.SortBy, c.SortOrder)
+	}
+	return &c, true, nil
+}
+
+// keyLess orders (key, id) pairs; pages are cut with the same order the items were sorted by.
+type keyLess func(ka, ida, kb, idb string) bool
+
+// byKeyThenID orders by key in the given direction and breaks ties by ascending id. Stores list
+// entries by file name, i.e. by id, so ties keep the order the list tools always returned them in.
+func byKeyThenID(desc bool) keyLess {
+	return func(ka, ida, kb, idb string) bool {
+		if ka != kb {
+			return (ka < kb) != desc
+		}
+		return ida < idb
+	}
+}
+
+// byKeyAndID orders by key and then id, both in the given direction (the timeline read backwards).
+func byKeyAndID(desc bool) keyLess {
+	return func(ka, ida, kb, idb string) bool {
+		if ka != kb {
+			return (ka < kb) != desc
+		}
+		return ida != idb && (ida < idb) != desc
+	}
+}
+
+// pageAfter returns up to limit items that sort strictly after cur (all items from the start when cur
+// is nil) and whether more items follow. items must already be sorted by less.
+func pageAfter[T any](items []T, keyOf func(T) (key, id string), less keyLess, cur *pageCursor, limit int) ([]T, bool) {
+	limit = clampLimit(limit)
+	start := 0
+	if cur != nil {
+		start = len(items)
+		for i, it := range items {
+			k, id := keyOf(it)
+			if less(cur.Key, cur.ID, k, id) {
+				start = i
+				break
+			}
+		}
+	}
+	end := min(start+limit, len(items))
+	return items[start:end], end < len(items)
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return 50
+	}
+	return min(limit, 200)
+}
+
+// normalizeSort lower-cases the sort arguments: sortBy falls back to fields[0] when unknown, and any
+// sortOrder other than asc means desc.
+func normalizeSort(sortBy, sortOrder string, fields ...string) (string, string) {
+	sortBy = strings.TrimSpace(strings.ToLower(sortBy))
+	known := false
+	for _, f := range fields {
+		known = known || f == sortBy
+	}
+	if !known {
+		sortBy = fields[0]
+	}
+	if strings.TrimSpace(strings.ToLower(sortOrder)) == "asc" {
+		return sortBy, "asc"
+	}
+	return sortBy, "desc"
+}
+
+func issueSortKey(it swarm.Issue, sortBy string) string {
+	if sortBy == "updated_at" {
+		return it.UpdatedAt
+	}
+	return it.CreatedAt
+}
+
+func taskSortKey(it swarm.IssueTask, sortBy string) string {
+	switch sortBy {
+	case "updated_at":
+		return it.UpdatedAt
+	case "points":
+		// Zero-padded so string order matches numeric order.
+		return fmt.Sprintf("%012d", it.Points)
+	}
+	return it.CreatedAt
+}
@@ -0,0 +1,162 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+)
+
+type cursorItem struct{ key, id string }
+
+func cursorItemKey(it cursorItem) (string, string) { return it.key, it.id }
+
+func TestPageAfter_TiesAreSplitByID(t *testing.T) {
+	// updated_at has second precision, so whole pages can share one key.
+	items := []cursorItem{
+		{"2026-01-02T00:00:00Z", "issue-a"},
+		{"2026-01-01T00:00:00Z", "issue-a"},
+		{"2026-01-01T00:00:00Z", "issue-b"},
+		{"2026-01-01T00:00:00Z", "issue-c"},
+		{"2026-01-01T00:00:00Z", "issue-d"},
+	}
+	less := byKeyThenID(true)
+	for i := 1; i < len(items); i++ {
+		if !less(items[i-1].key, items[i-1].id, items[i].key, items[i].id) {
+			t.Fatalf("items %d and %d are not in byKeyThenID(desc) order", i-1, i)
+		}
+	}
+
+	var seen []string
+	var cur *pageCursor
+	for range items {
+		page, more := pageAfter(items, cursorItemKey, less, cur, 2)
+		for _, it := range page {
+			seen = append(seen, it.key+"/"+it.id)
+		}
+		if !more {
+			break
+		}
+		last := page[len(page)-1]
+		cur = &pageCursor{Key: last.key, ID: last.id}
+	}
+	if len(seen) != len(items) {
+		t.Fatalf("expected every item exactly once, got %v", seen)
+	}
+	for i, it := range items {
+		if seen[i] != it.key+"/"+it.id {
+			t.Fatalf("page order differs from sort order at %d: %v", i, seen)
+		}
+	}
+}
+
+func TestPageAfter_ReversedTimelineOrder(t *testing.T) {
+	items := []cursorItem{{"t2", "b"}, {"t1", "m#reply"}, {"t1", "m"}}
+	page, more := pageAfter(items, cursorItemKey, byKeyAndID(true), &pageCursor{Key: "t1", ID: "m#reply"}, 5)
+	if more || len(page) != 1 || page[0].id != "m" {
+		t.Fatalf("expected only the entry after the cursor, got %v (more %v)", page, more)
+	}
+}
+
+func TestListTools_KeepTheirShapeWithACursor(t *testing.T) {
+	s := newProfileTestServer(t, "")
+	for _, subject := range []string{"one", "two", "three"} {
+		if _, err := s.issueSvc.CreateIssue("lead", subject, "desc", nil, nil, "user", "u", "lead", "l", nil, 0, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list := func(args map[string]any) []map[string]any {
+		t.Helper()
+		res, err := s.dispatch(context.Background(), &toolCall{}, "listIssues", args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, ok := res.([]map[string]any)
+		if !ok {
+			t.Fatalf("expected a plain array, got %T", res)
+		}
+		return rows
+	}
+
+	all := list(map[string]any{"sort_by": "updated_at"})
+	if len(all) != 3 {
+		t.Fatalf("expected 3 issues, got %d", len(all))
+	}
+	var paged []map[string]any
+	cursor := ""
+	for range all {
+		rows := list(map[string]any{"sort_by": "updated_at", "cursor": cursor, "limit": 2})
+		paged = append(paged, rows...)
+		if len(rows) < 2 {
+			break
+		}
+		cursor, _ = rows[len(rows)-1]["cursor"].(string)
+		if cursor == "" {
+			t.Fatal("rows must carry a cursor")
+		}
+	}
+	if len(paged) != len(all) {
+		t.Fatalf("expected %d rows across pages, got %d", len(all), len(paged))
+	}
+	for i := range all {
+		if paged[i]["id"] != all[i]["id"] {
+			t.Fatalf("cursor pages differ from the unpaged order at %d", i)
+		}
+	}
+
+	res, err := s.dispatch(context.Background(), &toolCall{}, "listDeliveries", map[string]any{"cursor": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := res.([]map[string]any); !ok {
+		t.Fatalf("expected listDeliveries to return a plain array with a cursor, got %T", res)
+	}
+}
+
+func TestGetIssueTimeline_PagesWithRowCursors(t *testing.T) {
+	s := newProfileTestServer(t, "")
+	issue, err := s.issueSvc.CreateIssue("lead", "subject", "desc", nil, nil, "user", "u", "lead", "l", nil, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.issueSvc.PauseIssue("lead", issue.ID, "hold", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.issueSvc.ResumeIssue("lead", issue.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	timeline := func(args map[string]any) []map[string]any {
+		t.Helper()
+		args["issue_id"] = issue.ID
+		res, err := s.dispatch(context.Background(), &toolCall{}, "getIssueTimeline", args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := res.(map[string]any)
+		if _, ok := out["next_cursor"]; ok {
+			t.Fatal("the timeline pages with row cursors like the list tools, not next_cursor")
+		}
+		return out["items"].([]map[string]any)
+	}
+
+	all := timeline(map[string]any{})
+	if len(all) < 3 {
+		t.Fatalf("expected at least 3 entries, got %d", len(all))
+	}
+	var paged []map[string]any
+	cursor := ""
+	for range all {
+		rows := timeline(map[string]any{"cursor": cursor, "limit": 2})
+		paged = append(paged, rows...)
+		if len(rows) < 2 {
+			break
+		}
+		cursor, _ = rows[len(rows)-1]["cursor"].(string)
+	}
+	if len(paged) != len(all) {
+		t.Fatalf("expected %d entries across pages, got %d", len(all), len(paged))
+	}
+	for i := range all {
+		if paged[i]["id"] != all[i]["id"] {
+			t.Fatalf("cursor pages differ from the unpaged order at %d", i)
+		}
+	}
+}
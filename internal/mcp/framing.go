@@ -0,0 +1,166 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Stdio framing. Most MCP clients send one JSON message per line, but some frame messages LSP-style with
+// a "Content-Length: N" header block, or pretty-print JSON across several lines. messageReader accepts
+// all three, detecting the framing per message; replies use Content-Length framing once the client has
+// sent a framed message, and one line per message otherwise.
+
+// maxMessageBytes bounds a single incoming message.
+const maxMessageBytes = 16 * 1024 * 1024
+
+type messageReader struct {
+	r      *bufio.Reader
+	framed atomic.Bool // the client has sent Content-Length framed messages
+}
+
+func newMessageReader(r io.Reader) *messageReader {
+	return &messageReader{r: bufio.NewReaderSize(r, 1024*1024)}
+}
+
+// next returns the next message body. Blank lines between messages are skipped. A body that is not valid
+// JSON is still returned, so the caller can answer it with a parse error; io.EOF means the input closed.
+func (m *messageReader) next() ([]byte, error) {
+	for {
+		line, err := m.readLine()
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if isFrameHeader(line) {
+			return m.readFramed(line)
+		}
+		return m.readUnframed(line, err)
+	}
+}
+
+// readLine reads one line including its newline; at the end of input it returns the rest with io.EOF.
+func (m *messageReader) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := m.r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > maxMessageBytes {
+			return nil, fmt.Errorf("message exceeds %d bytes", maxMessageBytes)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		return line, err
+	}
+}
+
+func isFrameHeader(line []byte) bool {
+	name, _, ok := strings.Cut(string(line), ":")
+	if !ok {
+		return false
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	return name == "content-length" || name == "content-type"
+}
+
+// readFramed reads the header block starting with first up to the blank line, then the body.
+func (m *messageReader) readFramed(first []byte) ([]byte, error) {
+	length := -1
+	for line := first; ; {
+		if len(bytes.TrimSpace(line)) == 0 {
+			break
+		}
+		if name, value, ok := strings.Cut(string(line), ":"); ok && strings.EqualFold(strings.TrimSpace(name), "content-length") {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid Content-Length %q", strings.TrimSpace(value))
+			}
+			length = n
+		}
+		var err error
+		if line, err = m.readLine(); err != nil {
+			return nil, fmt.Errorf("reading message headers: %w", err)
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("message headers without Content-Length")
+	}
+	if length > maxMessageBytes {
+		return nil, fmt.Errorf("message exceeds %d bytes", maxMessageBytes)
+	}
+	m.framed.Store(true)
+	body := make([]byte, length)
+	if _, err := io.ReadFull(m.r, body); err != nil {
+		return nil, fmt.Errorf("reading message body: %w", err)
+	}
+	return body, nil
+}
+
+// readUnframed returns a line-delimited message. A line that opens a JSON object or array without closing
+// it is pretty-printed JSON: lines are added until the brackets balance or the input ends.
+func (m *messageReader) readUnframed(line []byte, err error) ([]byte, error) {
+	msg := bytes.TrimSpace(line)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	for err == nil && jsonOpenDepth(msg) > 0 {
+		if line, err = m.readLine(); err != nil && err != io.EOF {
+			return nil, err
+		}
+		msg = append(append(msg, '\n'), bytes.TrimSpace(line)...)
+		if len(msg) > maxMessageBytes {
+			return nil, fmt.Errorf("message exceeds %d bytes", maxMessageBytes)
+		}
+	}
+	return msg, nil
+}
+
+// jsonOpenDepth counts the objects and arrays b opens but does not close, ignoring brackets in strings.
+func jsonOpenDepth(b []byte) int {
+	depth, inString, escaped := 0, false, false
+	for _, c := range b {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch c {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+		}
+	}
+	return depth
+}
+
+// writeMessage encodes v in the framing the client uses.
+func writeMessage(w io.Writer, framed bool, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if framed {
+		if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+			return err
+		}
+		_, err = w.Write(body)
+		return err
+	}
+	_, err = w.Write(append(body, '\n'))
+	return err
+}
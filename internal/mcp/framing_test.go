@@ -0,0 +1,89 @@
+package mcp
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestJSONOpenDepth(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want int
+	}{
+		{`{}`, 0},
+		{`{`, 1},
+		{`{"a": [1, {`, 3},
+		{`]`, -1},
+		{`{"s": "{[ not brackets"`, 1},
+		{`{"s": "quote \" and {"`, 1},
+		{`{"s": "backslash \\"}`, 0},
+		{`"unterminated {`, 0},
+	} {
+		if got := jsonOpenDepth([]byte(tc.in)); got != tc.want {
+			t.Errorf("jsonOpenDepth(%q) = %d, want %d", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestMessageReader(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		in      string
+		want    []string
+		framed  bool
+		wantErr string // error after the messages in want
+	}{
+		{name: "one per line", in: "{\"id\":1}\n\n{\"id\":2}\n", want: []string{`{"id":1}`, `{"id":2}`}},
+		{name: "last line without newline", in: `{"id":1}`, want: []string{`{"id":1}`}},
+		{name: "crlf", in: "{\"id\":1}\r\n", want: []string{`{"id":1}`}},
+		{name: "pretty printed", in: "{\n  \"id\": 1,\n  \"p\": [\n    \"}\"\n  ]\n}\n{\"id\":2}\n", want: []string{"{\n\"id\": 1,\n\"p\": [\n\"}\"\n]\n}", `{"id":2}`}},
+		{name: "invalid JSON is passed on", in: "not json\n", want: []string{"not json"}},
+		{name: "content-length", in: "Content-Length: 8\r\n\r\n{\"id\":1}Content-Length: 8\r\n\r\n{\"id\":2}", want: []string{`{"id":1}`, `{"id":2}`}, framed: true},
+		{name: "header case and content-type", in: "content-type: application/json\r\nCONTENT-LENGTH: 2\r\n\r\n{}", want: []string{`{}`}, framed: true},
+		{name: "mixed framing", in: "{\"id\":1}\nContent-Length: 2\n\n{}", want: []string{`{"id":1}`, `{}`}, framed: true},
+		{name: "missing length", in: "Content-Type: application/json\r\n\r\n{}", wantErr: "without Content-Length"},
+		{name: "bad length", in: "Content-Length: x\r\n\r\n{}", wantErr: "invalid Content-Length"},
+		{name: "short body", in: "Content-Length: 10\r\n\r\n{}", framed: true, wantErr: "reading message body"},
+		{name: "oversized length", in: "Content-Length: 999999999\r\n\r\n", wantErr: "exceeds"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newMessageReader(strings.NewReader(tc.in))
+			var got []string
+			var err error
+			for {
+				var msg []byte
+				if msg, err = r.next(); err != nil {
+					break
+				}
+				got = append(got, string(msg))
+			}
+			if strings.Join(got, "|") != strings.Join(tc.want, "|") {
+				t.Fatalf("messages = %q, want %q", got, tc.want)
+			}
+			switch {
+			case tc.wantErr == "" && err != io.EOF:
+				t.Fatalf("expected io.EOF after the messages, got %v", err)
+			case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tc.wantErr, err)
+			}
+			if r.framed.Load() != tc.framed {
+				t.Fatalf("framed = %v, want %v", r.framed.Load(), tc.framed)
+			}
+		})
+	}
+}
+
+func TestWriteMessage(t *testing.T) {
+	var line, framed bytes.Buffer
+	if err := writeMessage(&line, false, map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeMessage(&framed, true, map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if line.String() != "{\"id\":1}\n" || framed.String() != "Content-Length: 8\r\n\r\n{\"id\":1}" {
+		t.Fatalf("unexpected output %q / %q", line.String(), framed.String())
+	}
+}
@@ -0,0 +1,83 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Health check helpers.
+// Used by the health tool and, when SWARM_MCP_HEALTH_ADDR is set, by the HTTP /healthz endpoint.
+
+const healthLockTimeout = 2 * time.Second
+
+type componentHealth struct {
+	Status    string `json:"status"` // ok | error | skipped
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+func checkComponent(fn func() error) componentHealth {
+	start := time.Now()
+	err := fn()
+	h := componentHealth{Status: "ok", LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		h.Status = "error"
+		h.Error = err.Error()
+	}
+	return h
+}
+
+// healthReport checks the store root, the global lock and (for roles that validate sessions) the session gateway.
+func (s *Server) healthReport(ctx context.Context) map[string]any {
+	components := map[string]componentHealth{
+		"store": checkComponent(s.store.ProbeWritable),
+		"lock": checkComponent(func() error {
+			return s.store.ProbeLock(healthLockTimeout)
+		}),
+	}
+	switch strings.TrimSpace(s.cfg.Role) {
+	case "lead", "worker", "acceptor":
+		components["session_gateway"] = checkComponent(func() error {
+			// Any well-formed answer (valid or not) proves the gateway is reachable.
+			_, err := validateSemanticSessionViaGateway(ctx, s.cfg.Gateway, "healthz-probe")
+			return err
+		})
+	default:
+		components["session_gateway"] = componentHealth{Status: "skipped"}
+	}
+
+	status := "ok"
+	for _, c := range components {
+		if c.Status == "error" {
+			status = "error"
+		}
+	}
+	return map[string]any{
+		"status":     status,
+		"role":       strings.TrimSpace(s.cfg.Role),
+		"profile":    s.profile,
+		"version":    s.cfg.Version,
+		"protocol":   s.protocolVersion.Load(),
+		"components": components,
+		"server_now": time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+func (s *Server) serveHealth(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		report := s.healthReport(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		if report["status"] != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+	s.cfg.Logger.Printf("health endpoint listening on %s/healthz", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		s.cfg.Logger.Printf("WARNING: health endpoint stopped: %v", err)
+	}
+}
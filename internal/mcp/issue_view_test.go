@@ -0,0 +1,39 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cookchen233/swarm-mcp/internal/swarm"
+)
+
+func TestGetIssue_LeadListOnlyForLeads(t *testing.T) {
+	s := newProfileTestServer(t, "lead")
+	issue, err := s.issueSvc.CreateIssue("lead", "subject", "desc", nil, nil, "user", "u", "lead", "l", nil, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := s.dispatch(ctx, &toolCall{}, "addIssueLead", map[string]any{"session_id": "sess-owner", "issue_id": issue.ID, "lead_id": "sess-co"}); err != nil {
+		t.Fatal(err)
+	}
+	getIssue := func() map[string]any {
+		t.Helper()
+		res, err := s.dispatch(ctx, &toolCall{}, "getIssue", map[string]any{"session_id": "sess-co", "issue_id": issue.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.(map[string]any)
+	}
+
+	m := getIssue()
+	leads, _ := m["leads"].([]any)
+	if len(leads) != 2 || leads[1] != swarm.LeadKey("sess-co") || m["lead_key"] != swarm.LeadKey("sess-co") {
+		t.Fatalf("expected leads to see the lead keys and their own, got %v / %v", m["leads"], m["lead_key"])
+	}
+
+	s.cfg.Role = "worker"
+	if m := getIssue(); m["leads"] != nil || m["lead_key"] != nil {
+		t.Fatalf("expected the lead list to be hidden from workers, got %v", m)
+	}
+}
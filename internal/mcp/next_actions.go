@@ -0,0 +1,338 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Coaching text ("next_actions") configuration.
+// Files live in a config dir:
+//
+//	<config_dir>/next_action.txt          text returned once all tasks are done
+//	<config_dir>/next_actions/<key>.txt   one suggested action per non-empty line
+//
+// Action lines may reference the objects of the call they are returned from as {{name}}: a field of the
+// tool result (issue_id, task_id, delivery_id, status, ...), a nested one ({{task.id}}), or else a call
+// argument (verdict, submission_id, ...). Placeholders that resolve to nothing are left as written.
+//
+// The dir is ServerConfig.ConfigDir, or the first "config" dir with either file found next to the binary
+// (../config, ./config) or upward from the working directory. Files are cached, polled for changes while
+// the server runs and can be reloaded on demand with the admin reloadConfig tool. A lead can override any key for a single issue with
+// setIssueNextActions; those lines win over the files for calls about that issue.
+
+const (
+	defaultNextActionText    = "All tasks completed. Please proceed with delivery and testing."
+	nextActionsWatchInterval = 2 * time.Second
+)
+
+type nextActionsCache struct {
+	dir    string
+	logger *log.Logger
+
+	mu        sync.RWMutex
+	actions   map[string][]string
+	text      string
+	signature string
+	loadedAt  time.Time
+}
+
+func newNextActionsCache(configDir string, logger *log.Logger) *nextActionsCache {
+	c := &nextActionsCache{dir: resolveConfigDir(configDir, logger), logger: logger}
+	if _, err := c.reload(); err != nil {
+		logger.Printf("WARNING: %v; built-in next actions in effect", err)
+	}
+	return c
+}
+
+// resolveConfigDir returns the explicit dir, or the first "config" dir holding next_actions/ or
+// next_action.txt, searched the same way readConfigUpward looks up files. Returns "" when none exists.
+func resolveConfigDir(explicit string, logger *log.Logger) string {
+	if explicit = strings.TrimSpace(explicit); explicit != "" {
+		if abs, err := filepath.Abs(explicit); err == nil {
+			explicit = abs
+		}
+		if st, err := os.Stat(explicit); err != nil || !st.IsDir() {
+			logger.Printf("WARNING: config dir %s not found; built-in next actions in effect", explicit)
+		}
+		return explicit
+	}
+	var candidates []string
+	if exe, err := os.Executable(); err == nil {
+		exeDir := filepath.Dir(exe)
+		candidates = append(candidates, filepath.Clean(filepath.Join(exeDir, "..", "config")), filepath.Join(exeDir, "config"))
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		for dir := cwd; ; dir = filepath.Dir(dir) {
+			candidates = append(candidates, filepath.Join(dir, "config"))
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	for _, c := range candidates {
+		if st, err := os.Stat(filepath.Join(c, "next_actions")); err == nil && st.IsDir() {
+			return c
+		}
+		if st, err := os.Stat(filepath.Join(c, "next_action.txt")); err == nil && !st.IsDir() {
+			return c
+		}
+	}
+	return ""
+}
+
+// reload re-reads all files from the config dir and returns a summary of what was loaded.
+func (c *nextActionsCache) reload() (map[string]any, error) {
+	sig := c.dirSignature()
+	actions := map[string][]string{}
+	text := ""
+	var loadErr error
+	if c.dir != "" {
+		files, _ := filepath.Glob(filepath.Join(c.dir, "next_actions", "*.txt"))
+		for _, f := range files {
+			bs, err := os.ReadFile(f)
+			if err != nil {
+				loadErr = fmt.Errorf("read %s: %w", f, err)
+				continue
+			}
+			if lines := nonEmptyLines(string(bs)); len(lines) > 0 {
+				actions[strings.TrimSuffix(filepath.Base(f), ".txt")] = lines
+			}
+		}
+		if bs, err := os.ReadFile(filepath.Join(c.dir, "next_action.txt")); err == nil {
+			text = string(bs)
+		}
+	}
+
+	c.mu.Lock()
+	c.actions = actions
+	c.text = text
+	c.signature = sig
+	c.loadedAt = time.Now().UTC()
+	summary := c.summaryLocked()
+	c.mu.Unlock()
+	return summary, loadErr
+}
+
+func (c *nextActionsCache) summaryLocked() map[string]any {
+	keys := make([]string, 0, len(c.actions))
+	for k := range c.actions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return map[string]any{
+		"config_dir":       c.dir,
+		"next_action_keys": keys,
+		"has_next_action":  c.text != "",
+		"loaded_at":        c.loadedAt.Format(time.RFC3339),
+	}
+}
+
+// dirSignature fingerprints names, sizes and mtimes of the watched files.
+func (c *nextActionsCache) dirSignature() string {
+	if c.dir == "" {
+		return ""
+	}
+	var b strings.Builder
+	files, _ := filepath.Glob(filepath.Join(c.dir, "next_actions", "*.txt"))
+	files = append(files, filepath.Join(c.dir, "next_action.txt"))
+	for _, f := range files {
+		if st, err := os.Stat(f); err == nil {
+			fmt.Fprintf(&b, "%s:%d:%d;", f, st.Size(), st.ModTime().UnixNano())
+		}
+	}
+	return b.String()
+}
+
+// watch polls the config dir and reloads when a file is added, removed or modified, until stop is closed.
+func (c *nextActionsCache) watch(stop <-chan struct{}) {
+	if c.dir == "" {
+		return
+	}
+	ticker := time.NewTicker(nextActionsWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		sig := c.dirSignature()
+		c.mu.RLock()
+		changed := sig != c.signature
+		c.mu.RUnlock()
+		if !changed {
+			continue
+		}
+		if _, err := c.reload(); err != nil {
+			c.logger.Printf("WARNING: reload next actions: %v", err)
+		} else {
+			c.logger.Printf("reloaded next actions from %s", c.dir)
+		}
+	}
+}
+
+func (c *nextActionsCache) lookup(key string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.actions[key]
+}
+
+func (c *nextActionsCache) nextActionText() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.text
+}
+
+func nonEmptyLines(s string) []string {
+	lines := strings.Split(s, "\n")
+	out := make([]string, 0, len(lines))
+	for _, ln := range lines {
+		ln = strings.TrimSpace(ln)
+		if ln == "" {
+			continue
+		}
+		out = append(out, ln)
+	}
+	return out
+}
+
+// nextActionList is the next_actions of a tool result before it is resolved: the config keys to look
+// up, most specific first, and the built-in lines used when none of them has any. callTool resolves it
+// once it knows which issue the call is about, so that issue's overrides take precedence over the global
+// files; marshalled as is, it resolves against the global files only.
+type nextActionList struct {
+	keys     []string
+	fallback []string
+	cache    *nextActionsCache
+}
+
+func (s *Server) getNextActions(key string, fallback []string) nextActionList {
+	return s.getNextActionsFor([]string{key}, fallback)
+}
+
+// getNextActionsFor is getNextActions with fallback keys tried in order after the first.
+func (s *Server) getNextActionsFor(keys []string, fallback []string) nextActionList {
+	l := nextActionList{fallback: fallback, cache: s.nextActions}
+	for _, k := range keys {
+		if k = strings.TrimSpace(k); k != "" {
+			l.keys = append(l.keys, k)
+		}
+	}
+	return l
+}
+
+// resolve returns the lines of the first key with issue overrides, else of the first key with a global
+// file, else the fallback.
+func (l nextActionList) resolve(overrides map[string][]string) []string {
+	for _, k := range l.keys {
+		if out := overrides[k]; len(out) > 0 {
+			return out
+		}
+	}
+	if l.cache != nil {
+		for _, k := range l.keys {
+			if out := l.cache.lookup(k); len(out) > 0 {
+				return out
+			}
+		}
+	}
+	return l.fallback
+}
+
+func (l nextActionList) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.resolve(nil))
+}
+
+// resolveNextActions replaces an unresolved result["next_actions"] with its lines, preferring the
+// overrides of the issue the call is about (issue_id of the result, else of the arguments).
+func (s *Server) resolveNextActions(tool string, result any, args map[string]any) {
+	m, ok := result.(map[string]any)
+	if !ok {
+		return
+	}
+	l, ok := m["next_actions"].(nextActionList)
+	if !ok {
+		return
+	}
+	var overrides map[string][]string
+	issueID, ok := placeholderValue(m, "issue_id")
+	if !ok {
+		issueID = strings.TrimSpace(str(args, "issue_id"))
+	}
+	if issueID != "" {
+		if p, err := s.scopeFor(tool, args); err == nil {
+			if overrides, err = p.issueSvc.IssueNextActions(issueID); err != nil {
+				s.cfg.Logger.Printf("WARNING: %v; global next actions in effect", err)
+			}
+		}
+	}
+	m["next_actions"] = l.resolve(overrides)
+}
+
+func (s *Server) getNextActionText() string {
+	if text := s.nextActions.nextActionText(); text != "" {
+		return text
+	}
+	return defaultNextActionText
+}
+
+var nextActionPlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.]+)\s*\}\}`)
+
+// expandNextActions fills the placeholders of result["next_actions"] from result and the call's args.
+func expandNextActions(result any, args map[string]any) {
+	m, ok := result.(map[string]any)
+	if !ok {
+		return
+	}
+	actions, ok := m["next_actions"].([]string)
+	if !ok {
+		return
+	}
+	out := make([]string, len(actions))
+	for i, line := range actions {
+		out[i] = nextActionPlaceholder.ReplaceAllStringFunc(line, func(ph string) string {
+			name := nextActionPlaceholder.FindStringSubmatch(ph)[1]
+			if v, ok := placeholderValue(m, name); ok {
+				return v
+			}
+			if v, ok := placeholderValue(args, name); ok {
+				return v
+			}
+			return ph
+		})
+	}
+	m["next_actions"] = out
+}
+
+// placeholderValue resolves a dotted path to a scalar in m.
+func placeholderValue(m map[string]any, path string) (string, bool) {
+	var cur any = m
+	for _, part := range strings.Split(path, ".") {
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		if cur, ok = obj[part]; !ok {
+			return "", false
+		}
+	}
+	switch v := cur.(type) {
+	case nil, map[string]any, []any:
+		return "", false
+	case string:
+		return v, v != ""
+	case float64: // numbers in results built by toMap
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
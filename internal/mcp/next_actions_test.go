@@ -0,0 +1,72 @@
+package mcp
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResolveConfigDir_AcceptsNextActionTextAlone(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "config")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "next_action.txt"), []byte("ship it"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(sub); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	logger := log.New(io.Discard, "", 0)
+	got, _ := filepath.EvalSymlinks(resolveConfigDir("", logger))
+	want, _ := filepath.EvalSymlinks(dir)
+	if got != want {
+		t.Fatalf("config dir = %q, want %q", got, want)
+	}
+	if text := newNextActionsCache("", logger).nextActionText(); text != "ship it" {
+		t.Fatalf("next action text = %q", text)
+	}
+}
+
+func TestLoadConfigs_ReadFromConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	logger := log.New(io.Discard, "", 0)
+	if _, ok := loadReviewChecklists("", dir, logger); ok {
+		t.Fatalf("expected no checklists in an empty config dir")
+	}
+	checklists := `{"checklists":[{"name":"base","items":["tests pass"]}]}`
+	if err := os.WriteFile(filepath.Join(dir, "review_checklists.json"), []byte(checklists), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loadReviewChecklists("", dir, logger); !ok {
+		t.Fatalf("expected review_checklists.json to be read from the config dir")
+	}
+}
+
+func TestNextActionsWatch_StopsWhenClosed(t *testing.T) {
+	dir := t.TempDir()
+	c := newNextActionsCache(dir, log.New(io.Discard, "", 0))
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		c.watch(stop)
+		close(done)
+	}()
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("watch did not return after stop was closed")
+	}
+}
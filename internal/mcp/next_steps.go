@@ -0,0 +1,93 @@
+package mcp
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Machine-readable next steps. next_actions are prose for the model; next_steps carries the same advice
+// as directives an orchestrator can follow without parsing text: one {tool, args_hint, reason} per tool a
+// next_actions line names that the role may call. args_hint holds the identifiers (issue_id, task_id,
+// next_step_token, ...) the tool takes that this call's result or arguments already have; reason is the
+// line. Lines that name no tool only appear in next_actions.
+
+type nextStep struct {
+	Tool     string         `json:"tool"`
+	ArgsHint map[string]any `json:"args_hint,omitempty"`
+	Reason   string         `json:"reason"`
+}
+
+var toolNameWord = regexp.MustCompile(`[A-Za-z]+`)
+
+// toolInputProps maps every tool to the properties of its input schema.
+var toolInputProps = sync.OnceValue(func() map[string][]string {
+	out := map[string][]string{}
+	for _, t := range allTools() {
+		schema, _ := t.InputSchema.(map[string]any)
+		props, _ := schema["properties"].(map[string]any)
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		out[t.Name] = names
+	}
+	return out
+})
+
+// hintableArg reports whether an argument is an identifier worth carrying over into args_hint.
+func hintableArg(name string) bool {
+	switch name {
+	case "session_id":
+		return false
+	case "next_step_token", "project":
+		return true
+	}
+	return strings.HasSuffix(name, "_id")
+}
+
+// addNextSteps derives result["next_steps"] from the expanded result["next_actions"].
+func (s *Server) addNextSteps(result any, args map[string]any) {
+	m, ok := result.(map[string]any)
+	if !ok {
+		return
+	}
+	actions, ok := m["next_actions"].([]string)
+	if !ok {
+		return
+	}
+	props := toolInputProps()
+	var steps []nextStep
+	for _, line := range actions {
+		seen := map[string]bool{}
+		for _, word := range toolNameWord.FindAllString(line, -1) {
+			names, ok := props[word]
+			if !ok || seen[word] || !toolAllowedForRole(s.cfg.Role, word) {
+				continue
+			}
+			seen[word] = true
+			step := nextStep{Tool: word, Reason: line}
+			for _, name := range names {
+				if !hintableArg(name) {
+					continue
+				}
+				v, ok := placeholderValue(m, name)
+				if !ok {
+					v, ok = placeholderValue(args, name)
+				}
+				if ok {
+					if step.ArgsHint == nil {
+						step.ArgsHint = map[string]any{}
+					}
+					step.ArgsHint[name] = v
+				}
+			}
+			steps = append(steps, step)
+		}
+	}
+	if len(steps) > 0 {
+		m["next_steps"] = steps
+	}
+}
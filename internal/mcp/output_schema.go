@@ -0,0 +1,149 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/cookchen233/swarm-mcp/internal/swarm"
+)
+
+// Structured tool results (MCP 2025-06-18). Besides the JSON text block, a successful tools/call returns
+// the result as structuredContent, which must be an object: list results are wrapped as {"items": [...]}
+// (the shape getIssueTimeline already has) and scalars or null as {"result": ...}. tools/list then
+// declares an outputSchema for every tool: generated from the swarm type for tools that return one
+// entity or a list of them, and a plain object schema for the rest. Schemas never mark properties
+// required or forbid extra ones, since results carry additions such as now and next_actions and list
+// rows are trimmed.
+
+// entityOutputTypes maps tools that return one entity to its type.
+var entityOutputTypes = map[string]reflect.Type{
+	"getIssue":       reflect.TypeFor[swarm.Issue](),
+	"getIssueTask":   reflect.TypeFor[swarm.IssueTask](),
+	"claimIssueTask": reflect.TypeFor[swarm.IssueTask](),
+	"getDelivery":    reflect.TypeFor[swarm.Delivery](),
+	"getSwarmStats":  reflect.TypeFor[swarm.SwarmStats](),
+	"registerWorker": reflect.TypeFor[swarm.Worker](),
+	"getWorker":      reflect.TypeFor[swarm.Worker](),
+	"peekLeadInbox":  reflect.TypeFor[swarm.LeadInboxPeek](),
+}
+
+// listOutputTypes maps tools that return a list (plain or {items}) to the element type.
+var listOutputTypes = map[string]reflect.Type{
+	"listIssues":       reflect.TypeFor[swarm.Issue](),
+	"listIssueTasks":   reflect.TypeFor[swarm.IssueTask](),
+	"listDeliveries":   reflect.TypeFor[swarm.Delivery](),
+	"listWorkers":      reflect.TypeFor[swarm.Worker](),
+	"listLocks":        reflect.TypeFor[swarm.Lease](),
+	"getIssueTimeline": reflect.TypeFor[swarm.TimelineEntry](),
+}
+
+func toolOutputSchema(name string) map[string]any {
+	if t, ok := entityOutputTypes[name]; ok {
+		return jsonSchemaOf(t, map[reflect.Type]bool{})
+	}
+	if t, ok := listOutputTypes[name]; ok {
+		return map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"items": map[string]any{"type": "array", "items": jsonSchemaOf(t, map[reflect.Type]bool{})},
+			},
+			"required": []string{"items"},
+		}
+	}
+	return map[string]any{"type": "object"}
+}
+
+// injectOutputSchemas sets OutputSchema on every tool. Only used from protocol 2025-06-18 on.
+func injectOutputSchemas(tools []ToolDefinition) []ToolDefinition {
+	for i := range tools {
+		tools[i].OutputSchema = toolOutputSchema(tools[i].Name)
+	}
+	return tools
+}
+
+// jsonSchemaOf describes how encoding/json renders values of t. seen guards recursive types.
+func jsonSchemaOf(t reflect.Type, seen map[reflect.Type]bool) map[string]any {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t, nullable = t.Elem(), true
+	}
+	var m map[string]any
+	switch t.Kind() {
+	case reflect.Bool:
+		m = map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		m = map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		m = map[string]any{"type": "number"}
+	case reflect.String:
+		m = map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		m, nullable = map[string]any{"type": "array", "items": jsonSchemaOf(t.Elem(), seen)}, nullable || t.Kind() == reflect.Slice
+	case reflect.Map:
+		m, nullable = map[string]any{"type": "object", "additionalProperties": jsonSchemaOf(t.Elem(), seen)}, true
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]any{"type": "object"}
+		}
+		seen[t] = true
+		props := map[string]any{}
+		addStructFields(t, props, seen)
+		delete(seen, t)
+		m = map[string]any{"type": "object", "properties": props}
+	default:
+		return map[string]any{} // interface{} and the like: any value
+	}
+	if nullable {
+		m["type"] = []string{m["type"].(string), "null"}
+	}
+	return m
+}
+
+// addStructFields adds the JSON properties of t, inlining embedded structs as encoding/json does.
+func addStructFields(t reflect.Type, props map[string]any, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructFields(ft, props, seen)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = jsonSchemaOf(f.Type, seen)
+	}
+}
+
+// structuredResult turns a marshalled tool result into structuredContent, which must be an object.
+func structuredResult(resultJSON []byte) json.RawMessage {
+	switch {
+	case bytes.HasPrefix(resultJSON, []byte("{")):
+		return resultJSON
+	case bytes.HasPrefix(resultJSON, []byte("[")):
+		return wrapResult("items", resultJSON)
+	default:
+		return wrapResult("result", resultJSON)
+	}
+}
+
+func wrapResult(key string, raw []byte) json.RawMessage {
+	out, _ := json.Marshal(map[string]json.RawMessage{key: raw})
+	return out
+}
@@ -0,0 +1,111 @@
+package mcp
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/cookchen233/swarm-mcp/internal/swarm"
+)
+
+// Role profiles.
+// One server binary can serve every role: ServerConfig.Profiles holds resolved per-role settings and the
+// profile is picked per connection, either by ServerConfig.Profile (SWARM_MCP_PROFILE) at startup or by the
+// client's initialize request ({"profile": "worker"}). Since an MCP stdio connection is one process, the
+// selection holds for the lifetime of the connection.
+
+// RoleProfile is a fully resolved set of settings for one profile.
+type RoleProfile struct {
+	Role                  string
+	RoleCode              string
+	AcceptorID            string
+	IssueTTLSec           int
+	TaskTTLSec            int
+	DefaultTimeoutSec     int
+	MinTimeoutSec         int
+	SuggestedMinTaskCount int
+	MaxTaskCount          int
+	MaxClaimedPerWorker   int
+}
+
+// checkClientProfile vets a profile a client asks for in initialize. A server started with a role
+// (SWARM_MCP_ROLE, a role-specific binary) only lets clients pick profiles of that role, so a worker
+// connection cannot promote itself to lead or admin; one started without a role accepts any.
+func (s *Server) checkClientProfile(name string) error {
+	p, ok := s.cfg.Profiles[strings.TrimSpace(name)]
+	if !ok || s.profile != "" {
+		return nil // applyProfile reports these
+	}
+	role := strings.ToLower(strings.TrimSpace(s.cfg.Role))
+	if role != "" && !strings.EqualFold(p.Role, role) {
+		return swarm.Errorf(swarm.CodePermissionDenied, "profile %q is for role %s; this server runs as role %s", strings.TrimSpace(name), p.Role, role)
+	}
+	return nil
+}
+
+// applyProfile switches the server to the named profile. It must run before tool calls are dispatched.
+func (s *Server) applyProfile(name string) error {
+	name = strings.TrimSpace(name)
+	p, ok := s.cfg.Profiles[name]
+	if !ok {
+		return swarm.Errorf(swarm.CodeInvalidArgument, "unknown profile %q (available: %s)", name, strings.Join(s.profileNames(), ", "))
+	}
+	if s.profile != "" && s.profile != name {
+		return swarm.Errorf(swarm.CodeInvalidState, "profile %q already selected for this connection", s.profile)
+	}
+
+	s.cfg.Role = p.Role
+	if p.RoleCode != "" {
+		codes := make(map[string]string, len(s.cfg.RoleCodes)+1)
+		for k, v := range s.cfg.RoleCodes {
+			codes[k] = v
+		}
+		codes[p.Role] = p.RoleCode
+		s.cfg.RoleCodes = codes
+	}
+	if p.AcceptorID != "" {
+		s.cfg.AcceptorID = p.AcceptorID
+	}
+	s.cfg.IssueTTLSec = p.IssueTTLSec
+	s.cfg.TaskTTLSec = p.TaskTTLSec
+	s.cfg.DefaultTimeoutSec = p.DefaultTimeoutSec
+	s.cfg.MinTimeoutSec = p.MinTimeoutSec
+	if s.cfg.MinTimeoutSec <= 0 {
+		s.cfg.MinTimeoutSec = s.cfg.DefaultTimeoutSec
+	}
+	s.cfg.SuggestedMinTaskCount = p.SuggestedMinTaskCount
+	s.cfg.MaxTaskCount = p.MaxTaskCount
+	s.cfg.MaxClaimedPerWorker = p.MaxClaimedPerWorker
+	s.issueSvc.SetTimeouts(s.cfg.IssueTTLSec, s.cfg.TaskTTLSec, s.cfg.DefaultTimeoutSec, s.cfg.MinTimeoutSec)
+	// Project scopes opened before the profile was picked (e.g. by the sweeper) switch too.
+	s.projMu.Lock()
+	for key, ps := range s.projects {
+		cfg := s.projectConfig(s.cfg.Projects[key])
+		ps.issueSvc.SetTimeouts(cfg.IssueTTLSec, cfg.TaskTTLSec, cfg.DefaultTimeoutSec, cfg.MinTimeoutSec)
+	}
+	s.projMu.Unlock()
+
+	s.profile = name
+	s.cfg.Logger.Printf("using profile %q (role %s)", name, p.Role)
+	return nil
+}
+
+// profileFromInitialize reads the optional profile selection from initialize params.
+func profileFromInitialize(params any) string {
+	pm, ok := params.(map[string]any)
+	if !ok {
+		return ""
+	}
+	if v, ok := pm["profile"].(string); ok {
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+func (s *Server) profileNames() []string {
+	names := make([]string, 0, len(s.cfg.Profiles))
+	for n := range s.cfg.Profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,71 @@
+package mcp
+
+import (
+	"io"
+	"log"
+	"testing"
+
+	"github.com/cookchen233/swarm-mcp/internal/swarm"
+)
+
+func newProfileTestServer(t *testing.T, role string) *Server {
+	t.Helper()
+	store := swarm.NewStore(t.TempDir())
+	store.EnsureDir()
+	return NewServer(ServerConfig{
+		Logger:            log.New(io.Discard, "", 0),
+		Role:              role,
+		IssueTTLSec:       7200,
+		TaskTTLSec:        3600,
+		DefaultTimeoutSec: 3600,
+		Profiles: map[string]RoleProfile{
+			"worker": {Role: "worker", IssueTTLSec: 111, TaskTTLSec: 222, DefaultTimeoutSec: 60},
+			"lead":   {Role: "lead", IssueTTLSec: 7200, TaskTTLSec: 3600, DefaultTimeoutSec: 3600},
+		},
+		Projects: map[string]ProjectConfig{"web": {}, "api": {IssueTTLSec: 999}},
+	}, store, swarm.NewTraceService(store))
+}
+
+func TestInitialize_ProfileLimitedToTheConfiguredRole(t *testing.T) {
+	s := newProfileTestServer(t, "worker")
+	resp := s.handleInitialize(1, map[string]any{"profile": "lead"})
+	if resp.Error == nil || s.cfg.Role != "worker" || s.profile != "" {
+		t.Fatalf("expected a worker server to refuse the lead profile, got %+v (role %s)", resp.Error, s.cfg.Role)
+	}
+	if resp := s.handleInitialize(2, map[string]any{"profile": "worker"}); resp.Error != nil {
+		t.Fatalf("worker profile: %+v", resp.Error)
+	}
+
+	open := newProfileTestServer(t, "")
+	if resp := open.handleInitialize(1, map[string]any{"profile": "lead"}); resp.Error != nil || open.cfg.Role != "lead" {
+		t.Fatalf("a server without a role accepts any profile, got %+v", resp.Error)
+	}
+}
+
+func TestApplyProfile_TimeoutsReachProjectScopes(t *testing.T) {
+	s := newProfileTestServer(t, "")
+	before, err := s.scopeFor("createIssue", map[string]any{"project": "web"})
+	if err != nil {
+		t.Fatalf("scope: %v", err)
+	}
+	if err := s.applyProfile("worker"); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	after, err := s.scopeFor("createIssue", map[string]any{"project": "api"})
+	if err != nil {
+		t.Fatalf("scope: %v", err)
+	}
+
+	for _, tc := range []struct {
+		scope  *projectScope
+		ttlSec int64
+	}{{before, 111}, {after, 999}} {
+		issue, err := tc.scope.issueSvc.CreateIssue("lead", "s", "d", nil, nil, "user", "u", "lead", "l", nil, 0, false)
+		if err != nil {
+			t.Fatalf("create issue: %v", err)
+		}
+		if got := (issue.LeaseExpiresAtMs - swarm.LeaseNowMs() + 500) / 1000; got != tc.ttlSec {
+			t.Fatalf("expected an issue lease of %ds, got %ds", tc.ttlSec, got)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cookchen233/swarm-mcp/internal/swarm"
+)
+
+// Keepalive progress. A tools/call that carries params._meta.progressToken gets a notifications/progress
+// message every ProgressIntervalSec while it is still running, with the elapsed time and, for the waits
+// that have one, the state of the queue being waited on. Client runtimes that kill calls without output
+// as hung keep long-polls such as waitDeliveries or askIssueTask alive this way.
+
+const defaultProgressIntervalSec = 15
+
+// progressToken returns params._meta.progressToken of a tools/call, or nil.
+func progressToken(params any) any {
+	pm, _ := params.(map[string]any)
+	meta, _ := pm["_meta"].(map[string]any)
+	return meta["progressToken"]
+}
+
+// keepAlive sends progress notifications for req through send until ctx is done. Requests without a
+// progress token get none.
+func (s *Server) keepAlive(ctx context.Context, req JSONRPCRequest, send func(any)) {
+	token := progressToken(req.Params)
+	if req.Method != "tools/call" || token == nil {
+		return
+	}
+	pm, _ := req.Params.(map[string]any)
+	name, _ := pm["name"].(string)
+	args, _ := pm["arguments"].(map[string]any)
+
+	interval := time.Duration(s.cfg.ProgressIntervalSec) * time.Second
+	if interval <= 0 {
+		interval = defaultProgressIntervalSec * time.Second
+	}
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		elapsed := int(time.Since(start).Seconds())
+		msg := fmt.Sprintf("%s waiting for %ds", name, elapsed)
+		if state := s.waitQueueState(name, args); state != "" {
+			msg += "; " + state
+		}
+		send(map[string]any{
+			"jsonrpc": "2.0",
+			"method":  "notifications/progress",
+			"params": map[string]any{
+				"progressToken": token,
+				"progress":      elapsed,
+				"message":       msg,
+			},
+		})
+	}
+}
+
+// waitQueueState describes what a blocked call is waiting on, or "" when there is nothing to add.
+func (s *Server) waitQueueState(tool string, args map[string]any) string {
+	p, err := s.scopeFor(tool, args)
+	if err != nil {
+		return ""
+	}
+	switch tool {
+	case "waitDeliveries":
+		open, err := p.issueSvc.ListDeliveries(swarm.DeliveryOpen, "", "", "")
+		if err != nil {
+			return ""
+		}
+		return fmt.Sprintf("%d open deliveries", len(open))
+	case "submitDelivery":
+		open, err := p.issueSvc.ListDeliveries(swarm.DeliveryOpen, str(args, "issue_id"), "", "")
+		if err != nil {
+			return ""
+		}
+		return fmt.Sprintf("%d deliveries of this issue awaiting acceptance", len(open))
+	case "askIssueTask", "submitIssueTask", "waitIssueTaskEvents", "selectIssueInbox", "nextIssueSignal", "stepLeadInbox":
+		issueID := strings.TrimSpace(str(args, "issue_id"))
+		if issueID == "" {
+			return ""
+		}
+		peek, err := p.issueSvc.PeekLeadInbox(issueID)
+		if err != nil {
+			return ""
+		}
+		return fmt.Sprintf("lead inbox: %d pending, %d in progress", peek.Pending, peek.Processing)
+	}
+	return ""
+}
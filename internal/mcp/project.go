@@ -0,0 +1,158 @@
+package mcp
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/cookchen233/swarm-mcp/internal/swarm"
+)
+
+// ProjectConfig is a project namespace's settings, already merged over the server-wide values. The
+// lease TTLs are 0 when the project does not set them, so the connection's profile values apply.
+type ProjectConfig struct {
+	IssueTTLSec         int
+	TaskTTLSec          int
+	MaxTaskCount        int
+	MaxClaimedPerWorker int
+	RepoPath            string
+	GitBaseRef          string
+	VerifyWorkdir       string
+}
+
+// projectScope is the set of services a tool call works against: the root store, or one project's
+// sub-store (<root>/projects/<key>) so issues, tasks, docs and locks of different codebases stay apart.
+type projectScope struct {
+	key                 string
+	store               *swarm.Store
+	docsSvc             *swarm.DocsService
+	lockSvc             *swarm.LockService
+	issueSvc            *swarm.IssueService
+	maxTaskCount        int
+	maxClaimedPerWorker int
+}
+
+// projectlessTools do not touch issue/task/doc/lock data and ignore the project argument.
+var projectlessTools = map[string]bool{
+	"myProfile":      true,
+	"swarmNow":       true,
+	"health":         true,
+	"reloadConfig":   true,
+	"queryAuditLog":  true,
+	"registerWorker": true,
+	"listWorkers":    true,
+	"getWorker":      true,
+}
+
+func (s *Server) rootScope() *projectScope {
+	return &projectScope{
+		store:               s.store,
+		docsSvc:             s.docsSvc,
+		lockSvc:             s.lockSvc,
+		issueSvc:            s.issueSvc,
+		maxTaskCount:        s.cfg.MaxTaskCount,
+		maxClaimedPerWorker: s.cfg.MaxClaimedPerWorker,
+	}
+}
+
+// scopeFor resolves the project a call addresses: its project argument, else the server's default
+// project, else the root namespace. Only projects declared in the configuration are accepted.
+func (s *Server) scopeFor(tool string, args map[string]any) (*projectScope, error) {
+	if projectlessTools[tool] {
+		return s.rootScope(), nil
+	}
+	key := strings.TrimSpace(str(args, "project"))
+	if key == "" {
+		key = s.cfg.Project
+	}
+	if key == "" {
+		return s.rootScope(), nil
+	}
+	pc, ok := s.cfg.Projects[key]
+	if !ok {
+		if len(s.cfg.Projects) == 0 {
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "unknown project %q: no projects are configured", key)
+		}
+		return nil, swarm.Errorf(swarm.CodeInvalidArgument, "unknown project %q (configured: %s)", key, strings.Join(s.projectKeys(), ", "))
+	}
+
+	s.projMu.Lock()
+	defer s.projMu.Unlock()
+	if p := s.projects[key]; p != nil {
+		return p, nil
+	}
+	cfg := s.projectConfig(pc)
+	store := s.store.Project(key)
+	trace := swarm.NewTraceService(store)
+	trace.SetRotation(s.cfg.TraceRotation)
+	for _, sink := range s.traceSinks {
+		trace.AddSink(sink)
+	}
+	p := &projectScope{
+		key:                 key,
+		store:               store,
+		docsSvc:             swarm.NewDocsService(store),
+		lockSvc:             swarm.NewLockService(store, trace),
+		issueSvc:            newIssueService(cfg, store, trace),
+		maxTaskCount:        pc.MaxTaskCount,
+		maxClaimedPerWorker: pc.MaxClaimedPerWorker,
+	}
+	s.projects[key] = p
+	s.enableScheduler(p)
+	return p, nil
+}
+
+// projectConfig returns the server settings with a project's overrides applied; the timeouts not set
+// by the project are those of the connection's profile.
+func (s *Server) projectConfig(pc ProjectConfig) ServerConfig {
+	cfg := s.cfg
+	if pc.IssueTTLSec > 0 {
+		cfg.IssueTTLSec = pc.IssueTTLSec
+	}
+	if pc.TaskTTLSec > 0 {
+		cfg.TaskTTLSec = pc.TaskTTLSec
+	}
+	cfg.RepoPath = pc.RepoPath
+	cfg.GitBaseRef = pc.GitBaseRef
+	cfg.VerifyWorkdir = pc.VerifyWorkdir
+	return cfg
+}
+
+func (s *Server) projectKeys() []string {
+	keys := make([]string, 0, len(s.cfg.Projects))
+	for k := range s.cfg.Projects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// injectProjectIntoTools exposes the optional project argument on project-scoped tools.
+func injectProjectIntoTools(tools []ToolDefinition, keys []string, defaultKey string) []ToolDefinition {
+	if len(keys) == 0 {
+		return tools
+	}
+	desc := "Project namespace (omit for the root namespace)."
+	if defaultKey != "" {
+		desc = "Project namespace (default: " + defaultKey + ")."
+	}
+	for _, t := range tools {
+		if projectlessTools[t.Name] {
+			continue
+		}
+		m, ok := t.InputSchema.(map[string]any)
+		if !ok {
+			continue
+		}
+		props, ok := m["properties"].(map[string]any)
+		if !ok {
+			props = map[string]any{}
+			m["properties"] = props
+		}
+		props["project"] = map[string]any{
+			"type":        "string",
+			"enum":        keys,
+			"description": desc,
+		}
+	}
+	return tools
+}
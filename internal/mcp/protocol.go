@@ -26,6 +26,9 @@ const (
 	ErrMethodNotFound = -32601
 	ErrInvalidParams  = -32602
 	ErrInternal       = -32603
+
+	// ErrServerBusy rejects a request while the server already runs MaxConcurrentCalls calls.
+	ErrServerBusy = -32000
 )
 
 func NewResultResponse(id any, result any) JSONRPCResponse {
@@ -37,7 +40,9 @@ func NewErrorResponse(id any, code int, message string, data any) JSONRPCRespons
 }
 
 type ToolDefinition struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	InputSchema any    `json:"inputSchema"`
+	Name         string           `json:"name"`
+	Description  string           `json:"description"`
+	InputSchema  any              `json:"inputSchema"`
+	Annotations  *ToolAnnotations `json:"annotations,omitempty"`  // protocol 2025-03-26+
+	OutputSchema any              `json:"outputSchema,omitempty"` // protocol 2025-06-18+
 }
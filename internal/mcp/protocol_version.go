@@ -0,0 +1,74 @@
+package mcp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MCP protocol revisions. initialize answers with the client's protocolVersion when it is supported, else
+// with the newest supported revision not newer than it; a client asking for something newer than every
+// supported revision gets the newest, as the spec prescribes. Versions older than every supported
+// revision, or not in YYYY-MM-DD form, are rejected. Later revisions add, on top of 2024-11-05:
+//
+//	2025-03-26  tool annotations (readOnlyHint, destructiveHint, idempotentHint, openWorldHint) in tools/list
+//	2025-06-18  structuredContent in tools/call results, alongside the JSON text block, and outputSchema
+//	            in tools/list
+const (
+	protocol20241105 = "2024-11-05"
+	protocol20250326 = "2025-03-26"
+	protocol20250618 = "2025-06-18"
+)
+
+// supportedProtocolVersions lists the revisions the server speaks, newest first.
+var supportedProtocolVersions = []string{protocol20250618, protocol20250326, protocol20241105}
+
+var protocolVersionPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// UnsupportedProtocolError rejects an initialize whose protocolVersion the server cannot speak.
+type UnsupportedProtocolError struct {
+	Requested string
+}
+
+func (e *UnsupportedProtocolError) Error() string {
+	return fmt.Sprintf("unsupported protocol version %q", e.Requested)
+}
+
+func (e *UnsupportedProtocolError) ErrorDetails() any {
+	return map[string]any{"requested": e.Requested, "supported": supportedProtocolVersions}
+}
+
+// negotiateProtocolVersion picks the revision to use for a client that asked for requested. Clients that
+// send none get 2024-11-05, the revision this server spoke before negotiation existed.
+func negotiateProtocolVersion(requested string) (string, error) {
+	requested = strings.TrimSpace(requested)
+	if requested == "" {
+		return protocol20241105, nil
+	}
+	if !protocolVersionPattern.MatchString(requested) {
+		return "", &UnsupportedProtocolError{Requested: requested}
+	}
+	// YYYY-MM-DD compares chronologically as a string.
+	for _, v := range supportedProtocolVersions {
+		if v <= requested {
+			return v, nil
+		}
+	}
+	return "", &UnsupportedProtocolError{Requested: requested}
+}
+
+func protocolVersionFromInitialize(params any) string {
+	pm, _ := params.(map[string]any)
+	v, _ := pm["protocolVersion"].(string)
+	return v
+}
+
+// protocolAtLeast reports whether the negotiated revision includes the features of revision v. Before
+// initialize, the oldest revision applies.
+func (s *Server) protocolAtLeast(v string) bool {
+	negotiated, _ := s.protocolVersion.Load().(string)
+	if negotiated == "" {
+		negotiated = protocol20241105
+	}
+	return negotiated >= v
+}
@@ -0,0 +1,51 @@
+package mcp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNegotiateProtocolVersion(t *testing.T) {
+	for _, tc := range []struct {
+		requested string
+		want      string // "" = rejected
+	}{
+		{"", protocol20241105},
+		{"  ", protocol20241105},
+		{"2024-11-05", protocol20241105},
+		{"2025-03-26", protocol20250326},
+		{"2025-06-18", protocol20250618},
+		{" 2025-06-18 ", protocol20250618},
+		{"2025-01-01", protocol20241105}, // between revisions: the newest one not newer
+		{"2025-05-01", protocol20250326},
+		{"2099-12-31", protocol20250618}, // newer than all: the newest
+		{"2024-10-07", ""},               // older than all
+		{"latest", ""},
+		{"2025-6-18", ""},
+		{"2025-06-18T00:00:00Z", ""},
+	} {
+		got, err := negotiateProtocolVersion(tc.requested)
+		if tc.want == "" {
+			var unsupported *UnsupportedProtocolError
+			if !errors.As(err, &unsupported) || unsupported.Requested == "" {
+				t.Errorf("negotiateProtocolVersion(%q) = %q, %v; want UnsupportedProtocolError", tc.requested, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("negotiateProtocolVersion(%q) = %q, %v; want %q", tc.requested, got, err, tc.want)
+		}
+	}
+}
+
+func TestInitialize_RejectsUnsupportedProtocol(t *testing.T) {
+	s := newProfileTestServer(t, "")
+	resp := s.handleInitialize(1, map[string]any{"protocolVersion": "2020-01-01"})
+	if resp.Error == nil || resp.Error.Code != ErrInvalidParams {
+		t.Fatalf("expected invalid params, got %+v", resp)
+	}
+	resp = s.handleInitialize(2, map[string]any{"protocolVersion": "2025-04-01"})
+	if resp.Error != nil || !s.protocolAtLeast(protocol20250326) || s.protocolAtLeast(protocol20250618) {
+		t.Fatalf("expected 2025-03-26 negotiated, got %+v", resp)
+	}
+}
@@ -0,0 +1,141 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cookchen233/swarm-mcp/internal/swarm"
+)
+
+// Token-bucket rate limits for the connection and per worker_id. A stuck agent retrying in a loop
+// otherwise serialises everyone else behind the global store lock. The session bucket belongs to the
+// connection (each client is its own stdio server), not to the session_id argument: a caller that omits
+// or keeps changing its IDs must not get a fresh bucket.
+
+// RateLimitConfig sets calls per minute and burst size for each key kind; 0 per minute disables that
+// limit, 0 burst defaults to the per-minute rate.
+type RateLimitConfig struct {
+	SessionPerMin int
+	SessionBurst  int
+	WorkerPerMin  int
+	WorkerBurst   int
+}
+
+// RateLimitError is returned when a caller has used up its bucket.
+type RateLimitError struct {
+	Scope      string // "session" or "worker"
+	Key        string
+	PerMin     int
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded for %s '%s' (%d calls/min): retry after %dms", e.Scope, e.Key, e.PerMin, e.RetryAfter.Milliseconds())
+}
+
+func (e *RateLimitError) ErrorCode() string { return swarm.CodeRateLimited }
+
+func (e *RateLimitError) ErrorDetails() any {
+	return map[string]any{"rate_limited": map[string]any{
+		"scope":          e.Scope,
+		"key":            e.Key,
+		"limit_per_min":  e.PerMin,
+		"retry_after_ms": e.RetryAfter.Milliseconds(),
+	}}
+}
+
+const rateLimiterPruneAt = 1024
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type rateLimiter struct {
+	scope  string
+	perMin int
+	rate   float64 // tokens per second
+	burst  float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter(scope string, perMin, burst int) *rateLimiter {
+	if perMin <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = perMin
+	}
+	return &rateLimiter{
+		scope:   scope,
+		perMin:  perMin,
+		rate:    float64(perMin) / 60,
+		burst:   float64(burst),
+		buckets: map[string]*tokenBucket{},
+	}
+}
+
+// take spends one token for key, or returns a RateLimitError saying when the next token is due.
+func (l *rateLimiter) take(key string, now time.Time) error {
+	if l == nil || key == "" {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= rateLimiterPruneAt {
+			l.pruneLocked(now)
+		}
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return nil
+	}
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return &RateLimitError{Scope: l.scope, Key: key, PerMin: l.perMin, RetryAfter: wait.Round(time.Millisecond)}
+}
+
+// pruneLocked drops buckets that have refilled completely; they behave exactly like new ones.
+func (l *rateLimiter) pruneLocked(now time.Time) {
+	for k, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// connectionKey is the session bucket's key: the one client connected to this server.
+const connectionKey = "connection"
+
+type rateLimits struct {
+	session *rateLimiter
+	worker  *rateLimiter
+}
+
+func newRateLimits(cfg RateLimitConfig) *rateLimits {
+	return &rateLimits{
+		session: newRateLimiter("session", cfg.SessionPerMin, cfg.SessionBurst),
+		worker:  newRateLimiter("worker", cfg.WorkerPerMin, cfg.WorkerBurst),
+	}
+}
+
+// check charges the call to the connection's bucket and, when it names one, to its worker's.
+func (r *rateLimits) check(args map[string]any) error {
+	return r.checkAt(args, time.Now())
+}
+
+func (r *rateLimits) checkAt(args map[string]any, now time.Time) error {
+	if err := r.session.take(connectionKey, now); err != nil {
+		return err
+	}
+	return r.worker.take(strings.TrimSpace(str(args, "worker_id")), now)
+}
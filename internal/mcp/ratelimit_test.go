@@ -0,0 +1,77 @@
+package mcp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_BurstThenRefill(t *testing.T) {
+	l := newRateLimiter("worker", 60, 2) // one token per second, two at once
+	now := time.Unix(1000, 0)
+	for i := 0; i < 2; i++ {
+		if err := l.take("w1", now); err != nil {
+			t.Fatalf("take %d within burst: %v", i, err)
+		}
+	}
+	err := l.take("w1", now)
+	var rl *RateLimitError
+	if !errors.As(err, &rl) || rl.RetryAfter != time.Second || rl.Key != "w1" {
+		t.Fatalf("expected a one second retry for w1, got %v", err)
+	}
+	if err := l.take("w2", now); err != nil {
+		t.Fatalf("another key has its own bucket: %v", err)
+	}
+	if err := l.take("w1", now.Add(500*time.Millisecond)); err == nil {
+		t.Fatalf("expected no token after half a second")
+	}
+	if err := l.take("w1", now.Add(1500*time.Millisecond)); err != nil {
+		t.Fatalf("expected a refilled token: %v", err)
+	}
+}
+
+func TestRateLimiter_Disabled(t *testing.T) {
+	if l := newRateLimiter("session", 0, 5); l != nil {
+		t.Fatalf("expected no limiter for 0 calls/min")
+	}
+	var l *rateLimiter
+	if err := l.take("x", time.Now()); err != nil {
+		t.Fatalf("a nil limiter allows everything: %v", err)
+	}
+}
+
+func TestRateLimits_SessionBucketIgnoresCallerIDs(t *testing.T) {
+	r := newRateLimits(RateLimitConfig{SessionPerMin: 60, SessionBurst: 3})
+	now := time.Unix(1000, 0)
+	calls := []map[string]any{
+		{},
+		{"session_id": "s1"},
+		{"session_id": "s2", "worker_id": "w9"},
+	}
+	for i, args := range calls {
+		if err := r.checkAt(args, now); err != nil {
+			t.Fatalf("call %d within burst: %v", i, err)
+		}
+	}
+	err := r.checkAt(map[string]any{"session_id": "fresh"}, now)
+	var rl *RateLimitError
+	if !errors.As(err, &rl) || rl.Scope != "session" {
+		t.Fatalf("expected the connection's bucket to be spent whatever the session_id, got %v", err)
+	}
+}
+
+func TestRateLimits_WorkerBucket(t *testing.T) {
+	r := newRateLimits(RateLimitConfig{WorkerPerMin: 60, WorkerBurst: 1})
+	now := time.Unix(1000, 0)
+	if err := r.checkAt(map[string]any{"worker_id": "w1"}, now); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	err := r.checkAt(map[string]any{"worker_id": " w1 "}, now)
+	var rl *RateLimitError
+	if !errors.As(err, &rl) || rl.Scope != "worker" || rl.Key != "w1" {
+		t.Fatalf("expected w1's bucket to be spent, got %v", err)
+	}
+	if err := r.checkAt(map[string]any{"worker_id": "w2"}, now); err != nil {
+		t.Fatalf("w2 has its own bucket: %v", err)
+	}
+}
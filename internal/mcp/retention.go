@@ -0,0 +1,48 @@
+package mcp
+
+import "time"
+
+// Retention wiring. The rules live in each scope's issue service; this routine applies them to the root
+// namespace and every configured project every RetentionIntervalSec. Every agent connection is its own
+// process, so the routine only runs in the one holding the store's retention leader lock.
+
+// startRetention runs the maintenance routine in the background when a retention rule is set.
+func (s *Server) startRetention() {
+	if !s.cfg.Retention.Enabled() {
+		return
+	}
+	interval := s.cfg.RetentionIntervalSec
+	if interval <= 0 {
+		interval = 3600
+	}
+	leader := s.store.Leader("retention")
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+		for {
+			if leader.Held() {
+				s.applyRetention()
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// applyRetention runs one retention pass over the root namespace and every configured project.
+func (s *Server) applyRetention() {
+	scopes := []*projectScope{s.rootScope()}
+	for _, key := range s.projectKeys() {
+		if p, err := s.scopeFor("", map[string]any{"project": key}); err == nil {
+			scopes = append(scopes, p)
+		}
+	}
+	for _, p := range scopes {
+		report, err := p.issueSvc.ApplyRetention(s.cfg.Retention)
+		if err != nil {
+			s.cfg.Logger.Printf("WARNING: retention in %q: %v", p.key, err)
+		}
+		if report != nil && len(report.Archived)+len(report.Deleted) > 0 {
+			s.cfg.Logger.Printf("retention in %q: archived %v, deleted %v", p.key, report.Archived, report.Deleted)
+		}
+	}
+}
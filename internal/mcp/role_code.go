@@ -1,25 +1,25 @@
 package mcp
 
 import (
-	"os"
 	"strings"
 )
 
 // Role code injection helpers.
 // We enforce a role-specific code on all tools exposed to a role when configured.
 
-func expectedRoleCode(role string) string {
-	role = strings.TrimSpace(strings.ToUpper(role))
+// expectedRoleCode returns the code configured for the server's role, falling back to the shared code
+// (RoleCodes[""]).
+func (s *Server) expectedRoleCode() string {
+	role := strings.TrimSpace(strings.ToLower(s.cfg.Role))
 	if role != "" {
-		if v := strings.TrimSpace(os.Getenv("SWARM_MCP_ROLE_CODE_" + role)); v != "" {
+		if v := strings.TrimSpace(s.cfg.RoleCodes[role]); v != "" {
 			return v
 		}
 	}
-	return strings.TrimSpace(os.Getenv("SWARM_MCP_ROLE_CODE"))
+	return strings.TrimSpace(s.cfg.RoleCodes[""])
 }
 
-func injectRoleCodeIntoTools(role string, tools []ToolDefinition) []ToolDefinition {
-	tok := expectedRoleCode(role)
+func injectRoleCodeIntoTools(tok string, tools []ToolDefinition) []ToolDefinition {
 	if tok == "" {
 		return tools
 	}
@@ -0,0 +1,35 @@
+package mcp
+
+// Automatic assignment wiring. The scheduler lives in each scope's issue service; it reads the worker
+// registry (root store) and the claim limits from here. It runs after each approval (inside
+// reviewIssueTask) and after registerWorker, across the root and every configured project.
+
+// enableScheduler turns on automatic assignment for p when SchedulerReserveSec > 0.
+func (s *Server) enableScheduler(p *projectScope) {
+	if s.cfg.SchedulerReserveSec <= 0 {
+		return
+	}
+	key := p.key
+	p.issueSvc.SetScheduler(s.workerSvc.List, func(workerID string) int {
+		scope := s.rootScope()
+		if key != "" {
+			if ps, err := s.scopeFor("", map[string]any{"project": key}); err == nil {
+				scope = ps
+			}
+		}
+		return s.maxClaimedForWorker(scope, workerID)
+	}, s.cfg.SchedulerReserveSec, s.cfg.SchedulerWorkerStaleSec)
+}
+
+// scheduleAll runs one assignment round in the root namespace and every configured project.
+func (s *Server) scheduleAll() {
+	if s.cfg.SchedulerReserveSec <= 0 {
+		return
+	}
+	_, _ = s.issueSvc.AssignTasks()
+	for _, key := range s.projectKeys() {
+		if p, err := s.scopeFor("", map[string]any{"project": key}); err == nil {
+			_, _ = p.issueSvc.AssignTasks()
+		}
+	}
+}
@@ -1,35 +1,84 @@
 package mcp
 
 import (
-	"bufio"
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cookchen233/swarm-mcp/internal/swarm"
 )
 
 type ServerConfig struct {
-	Name                  string
-	Version               string
-	Logger                *log.Logger
-	Role                  string
-	SuggestedMinTaskCount int
-	MaxTaskCount          int
-	IssueTTLSec           int
-	TaskTTLSec            int
-	DefaultTimeoutSec     int
-	MinTimeoutSec         int
+	Name                       string
+	Version                    string
+	Logger                     *log.Logger
+	Role                       string
+	SuggestedMinTaskCount      int
+	MaxTaskCount               int
+	MaxClaimedPerWorker        int
+	ProgressionPolicyPath      string
+	AcceptorID                 string
+	VerifyWorkdir              string
+	VerifyTimeoutSec           int
+	WebhooksPath               string
+	ReviewChecklistsPath       string // review checklist file; "" = config/review_checklists.json if present
+	SubmissionRequirementsPath string // submission artifact rules; "" = config/submission_requirements.json if present
+	ValidationHooksPath        string // validation hooks run before submissions/deliveries are accepted; "" = config/validation_hooks.json if present
+	GitHubSync                 swarm.GitHubSyncConfig
+	Backup                     swarm.BackupConfig
+	S3Replica                  swarm.S3ReplicaConfig
+	TraceRotation              swarm.TraceRotation
+	TraceSinks                 swarm.TraceSinkConfig
+	Retention                  swarm.RetentionPolicy
+	RetentionIntervalSec       int
+	RepoPath                   string
+	GitBaseRef                 string
+	CIGitHubToken              string
+	HealthAddr                 string
+	ConfigDir                  string            // next_actions coaching text and default JSON configs; default: config dir searched upward
+	RoleCodes                  map[string]string // role -> required role_code; "" is the shared fallback
+	MaxClaimedByWorker         map[string]int    // worker_id -> claim limit, overrides MaxClaimedPerWorker
+	Gateway                    GatewayConfig
+	Profiles                   map[string]RoleProfile // selectable role profiles
+	Profile                    string                 // profile applied at startup; "" = use the fields above
+	RateLimit                  RateLimitConfig
+	Projects                   map[string]ProjectConfig // project key -> settings; only these keys are accepted
+	Project                    string                   // project used when a call names none; "" = root namespace
+	IssueTTLSec                int
+	TaskTTLSec                 int
+	DefaultTimeoutSec          int
+	MinTimeoutSec              int
+	ReviewSLASec               int    // submissions unreviewed this long are escalated to the lead; 0 = off
+	LeaseWarningSec            int    // warn lease owners this long before a task or file lease expires; 0 = off
+	SchedulerReserveSec        int    // > 0 turns on automatic task assignment; how long an assignment stays reserved
+	SchedulerWorkerStaleSec    int    // the scheduler skips workers not seen for this long; 0 = 600
+	DispatchPolicy             string // waitIssueTasks dispatch: "" (off), round_robin or least_points
+	MaxRejections              int    // rejected submissions per claim before the task is escalated; 0 = no limit
+	PeerReview                 bool   // submissions need a peer reviewer's approval before they reach the lead
+	CriticalGate               bool   // reviewIssueTask refuses an approval while a critical finding is still open
+	ShardTaskEvents            bool   // new issues keep each task's events in its own file
+	InboxPriorities            []string
+	LeadInboxClaimSec          int  // how long a claimed lead inbox item stays claimed; 0 = 300
+	AcceptorInboxClaimSec      int  // same for the acceptor inbox
+	AllowShortTimeouts         bool // honor allow_short=true on long-poll tools (timeouts below MinTimeoutSec)
+	ProgressIntervalSec        int  // keepalive progress notifications while a call with a progressToken runs; 0 = 15
+	SweepIntervalSec           int  // background expiry sweep of issues, tasks and locks; 0 = 30
+	ToolsPageSize              int  // tools per tools/list page (nextCursor for the rest); 0 = all on one page
+	MaxConcurrentCalls         int  // requests handled at once; more fail with a server busy error; 0 = 256
 }
 
 type Server struct {
@@ -37,15 +86,33 @@ type Server struct {
 	in  io.Reader
 	out io.Writer
 
-	encMu sync.Mutex
+	encMu    sync.Mutex
+	inflight inflightRequests
+	slots    *callSlots
 
 	sessMu   sync.Mutex
 	sessions map[string]string // session_id -> member_id
 
+	store     *swarm.Store
 	docsSvc   *swarm.DocsService
 	workerSvc *swarm.WorkerService
 	lockSvc   *swarm.LockService
 	issueSvc  *swarm.IssueService
+	audit     *swarm.AuditService
+	limits    *rateLimits
+
+	sessionCache *sessionCache
+
+	projMu   sync.Mutex
+	projects map[string]*projectScope // lazily opened project namespaces
+
+	nextActions *nextActionsCache
+	profile     string            // selected role profile ("" = none)
+	traceSinks  []swarm.TraceSink // shared by the root and project trace services
+
+	protocolVersion atomic.Value // negotiated MCP revision (string); unset before initialize
+	toolCache       toolListCache
+	notify          func(v any) // sends a notification to the client; set by Run
 }
 
 func NewServer(cfg ServerConfig, store *swarm.Store, trace *swarm.TraceService) *Server {
@@ -55,51 +122,231 @@ func NewServer(cfg ServerConfig, store *swarm.Store, trace *swarm.TraceService)
 	if cfg.MinTimeoutSec <= 0 {
 		cfg.MinTimeoutSec = cfg.DefaultTimeoutSec
 	}
-	return &Server{
+	swarm.ShareLeaseClock(store)
+	trace.SetRotation(cfg.TraceRotation)
+	traceSinks, err := swarm.NewTraceSinks(cfg.TraceSinks, cfg.Logger)
+	if err != nil {
+		cfg.Logger.Printf("WARNING: %v", err)
+	}
+	for _, sink := range traceSinks {
+		trace.AddSink(sink)
+	}
+	issueSvc := newIssueService(cfg, store, trace)
+	if gh := swarm.NewGitHubSync(cfg.GitHubSync, issueSvc, store, cfg.Logger); gh != nil {
+		issueSvc.AddEventSink(gh)
+	}
+	swarm.StartScheduledBackups(store, cfg.Backup, cfg.Logger)
+	if replica, err := swarm.NewS3Replica(cfg.S3Replica, store, cfg.Logger); err != nil {
+		cfg.Logger.Printf("WARNING: %v", err)
+	} else if replica != nil {
+		issueSvc.AddEventSink(replica)
+		replica.Start()
+	}
+	srv := &Server{
 		cfg:       cfg,
 		in:        os.Stdin,
 		out:       os.Stdout,
 		sessions:  map[string]string{},
+		store:     store,
 		docsSvc:   swarm.NewDocsService(store),
 		workerSvc: swarm.NewWorkerService(store, trace),
 		lockSvc:   swarm.NewLockService(store, trace),
-		issueSvc:  swarm.NewIssueService(store, trace, cfg.IssueTTLSec, cfg.TaskTTLSec, cfg.DefaultTimeoutSec, cfg.MinTimeoutSec),
+		issueSvc:  issueSvc,
+		audit:     swarm.NewAuditService(store),
+		limits:    newRateLimits(cfg.RateLimit),
+		slots:     newCallSlots(cfg.MaxConcurrentCalls),
+
+		sessionCache: newSessionCache(cfg.Gateway),
+		projects:     map[string]*projectScope{},
+
+		nextActions: newNextActionsCache(cfg.ConfigDir, cfg.Logger),
+		traceSinks:  traceSinks,
+	}
+	if name := strings.TrimSpace(cfg.Profile); name != "" {
+		if err := srv.applyProfile(name); err != nil {
+			cfg.Logger.Printf("WARNING: %v", err)
+		}
+	}
+	srv.enableScheduler(srv.rootScope())
+	srv.startSweeper()
+	srv.startRetention()
+	return srv
+}
+
+// newIssueService builds an issue service for store with the policy, verification, git, CI and webhook
+// settings from cfg.
+func newIssueService(cfg ServerConfig, store *swarm.Store, trace *swarm.TraceService) *swarm.IssueService {
+	issueSvc := swarm.NewIssueService(store, trace, cfg.IssueTTLSec, cfg.TaskTTLSec, cfg.DefaultTimeoutSec, cfg.MinTimeoutSec)
+	if policy, ok := loadProgressionPolicy(cfg.ProgressionPolicyPath, cfg.ConfigDir, cfg.Logger); ok {
+		issueSvc.SetProgressionPolicy(policy)
 	}
+	issueSvc.SetEvidenceRunner(cfg.VerifyWorkdir, cfg.VerifyTimeoutSec)
+	issueSvc.SetGitRepo(cfg.RepoPath, cfg.GitBaseRef)
+	issueSvc.SetReviewSLA(cfg.ReviewSLASec)
+	issueSvc.SetLeaseWarning(cfg.LeaseWarningSec)
+	issueSvc.SetInboxClaimTTL(cfg.LeadInboxClaimSec, cfg.AcceptorInboxClaimSec)
+	issueSvc.SetMaxRejections(cfg.MaxRejections)
+	issueSvc.SetPeerReview(cfg.PeerReview)
+	issueSvc.SetCriticalGate(cfg.CriticalGate)
+	issueSvc.SetShardTaskEvents(cfg.ShardTaskEvents)
+	if err := issueSvc.SetInboxPriorities(cfg.InboxPriorities); err != nil {
+		cfg.Logger.Printf("WARNING: %v", err)
+	}
+	if err := issueSvc.SetDispatchPolicy(cfg.DispatchPolicy); err != nil {
+		cfg.Logger.Printf("WARNING: %v", err)
+	}
+	issueSvc.SetCIProvider("github", &swarm.GitHubActionsCI{Token: cfg.CIGitHubToken, APIBase: cfg.GitHubSync.APIBase})
+	if checklists, ok := loadReviewChecklists(cfg.ReviewChecklistsPath, cfg.ConfigDir, cfg.Logger); ok {
+		issueSvc.SetReviewChecklists(checklists)
+	}
+	if reqs, ok := loadSubmissionRequirements(cfg.SubmissionRequirementsPath, cfg.ConfigDir, cfg.Logger); ok {
+		issueSvc.SetSubmissionRequirements(reqs)
+	}
+	if hooks, ok := loadValidationHooks(cfg.ValidationHooksPath, cfg.ConfigDir, cfg.Logger); ok {
+		issueSvc.SetValidationHooks(hooks, cfg.Logger)
+	}
+	if hooks, ok := loadWebhookConfig(cfg.WebhooksPath, cfg.ConfigDir, cfg.Logger); ok {
+		if w := swarm.NewWebhookService(hooks, cfg.Logger); w != nil {
+			issueSvc.AddEventSink(w)
+		}
+	}
+	return issueSvc
 }
 
-func (s *Server) getNextActions(key string, fallback []string) []string {
-	key = strings.TrimSpace(key)
-	if key == "" {
-		return fallback
+// loadProgressionPolicy reads the difficulty progression policy from path, or from
+// progression_policy.json in the config dir (see readConfigFile) when path is empty.
+// Returns ok=false when no policy file is found or it is invalid (defaults stay in effect).
+func loadProgressionPolicy(path, configDir string, logger *log.Logger) (swarm.ProgressionPolicy, bool) {
+	var bs []byte
+	var err error
+	if strings.TrimSpace(path) != "" {
+		bs, err = os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			logger.Printf("WARNING: cannot read progression policy %s: %v; using defaults", path, err)
+			return swarm.ProgressionPolicy{}, false
+		}
+	} else {
+		bs, err = readConfigFile(configDir, "progression_policy.json")
+		if err != nil {
+			return swarm.ProgressionPolicy{}, false
+		}
 	}
-	configPath := filepath.Join("config", "next_actions", key+".txt")
-	bs, err := readConfigUpward(configPath)
+	policy, err := swarm.ParseProgressionPolicy(bs)
 	if err != nil {
-		return fallback
+		logger.Printf("WARNING: %v; using defaults", err)
+		return swarm.ProgressionPolicy{}, false
 	}
-	lines := strings.Split(string(bs), "\n")
-	out := make([]string, 0, len(lines))
-	for _, ln := range lines {
-		ln = strings.TrimSpace(ln)
-		if ln == "" {
-			continue
+	return policy, true
+}
+
+// loadReviewChecklists reads the review checklists from path, or from review_checklists.json in the
+// config dir (see readConfigFile) when path is empty. Returns ok=false when there is no valid file (no checklists).
+func loadReviewChecklists(path, configDir string, logger *log.Logger) (swarm.ReviewChecklistConfig, bool) {
+	var bs []byte
+	var err error
+	if strings.TrimSpace(path) != "" {
+		bs, err = os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			logger.Printf("WARNING: cannot read review checklists %s: %v; checklists disabled", path, err)
+			return swarm.ReviewChecklistConfig{}, false
+		}
+	} else {
+		bs, err = readConfigFile(configDir, "review_checklists.json")
+		if err != nil {
+			return swarm.ReviewChecklistConfig{}, false
 		}
-		out = append(out, ln)
 	}
-	if len(out) == 0 {
-		return fallback
+	checklists, err := swarm.ParseReviewChecklists(bs)
+	if err != nil {
+		logger.Printf("WARNING: %v; checklists disabled", err)
+		return swarm.ReviewChecklistConfig{}, false
 	}
-	return out
+	return checklists, true
+}
+
+// loadSubmissionRequirements reads the submission artifact rules from path, or from
+// submission_requirements.json in the config dir (see readConfigFile) when path is empty. Returns ok=false when there is
+// no valid file (every task keeps the strict default).
+func loadSubmissionRequirements(path, configDir string, logger *log.Logger) (swarm.SubmissionRequirementsConfig, bool) {
+	var bs []byte
+	var err error
+	if strings.TrimSpace(path) != "" {
+		bs, err = os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			logger.Printf("WARNING: cannot read submission requirements %s: %v; using the strict default", path, err)
+			return swarm.SubmissionRequirementsConfig{}, false
+		}
+	} else {
+		bs, err = readConfigFile(configDir, "submission_requirements.json")
+		if err != nil {
+			return swarm.SubmissionRequirementsConfig{}, false
+		}
+	}
+	reqs, err := swarm.ParseSubmissionRequirements(bs)
+	if err != nil {
+		logger.Printf("WARNING: %v; using the strict default", err)
+		return swarm.SubmissionRequirementsConfig{}, false
+	}
+	return reqs, true
+}
+
+// loadValidationHooks reads the validation hooks from path, or from validation_hooks.json in the
+// config dir (see readConfigFile) when path is empty. Returns ok=false when there is no valid file (no hooks).
+func loadValidationHooks(path, configDir string, logger *log.Logger) (swarm.ValidationHookConfig, bool) {
+	var bs []byte
+	var err error
+	if strings.TrimSpace(path) != "" {
+		bs, err = os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			logger.Printf("WARNING: cannot read validation hooks %s: %v; hooks disabled", path, err)
+			return swarm.ValidationHookConfig{}, false
+		}
+	} else {
+		bs, err = readConfigFile(configDir, "validation_hooks.json")
+		if err != nil {
+			return swarm.ValidationHookConfig{}, false
+		}
+	}
+	hooks, err := swarm.ParseValidationHooks(bs)
+	if err != nil {
+		logger.Printf("WARNING: %v; hooks disabled", err)
+		return swarm.ValidationHookConfig{}, false
+	}
+	return hooks, true
 }
 
-func (s *Server) getNextActionText() string {
-	configPath := filepath.Join("config", "next_action.txt")
-	bs, err := readConfigUpward(configPath)
+// loadWebhookConfig reads the webhook config from path, or from webhooks.json in the config dir (see
+// readConfigFile) when path is empty. Returns ok=false when there is no valid file (no webhooks).
+func loadWebhookConfig(path, configDir string, logger *log.Logger) (swarm.WebhookConfig, bool) {
+	var bs []byte
+	var err error
+	if strings.TrimSpace(path) != "" {
+		bs, err = os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			logger.Printf("WARNING: cannot read webhook config %s: %v; webhooks disabled", path, err)
+			return swarm.WebhookConfig{}, false
+		}
+	} else {
+		bs, err = readConfigFile(configDir, "webhooks.json")
+		if err != nil {
+			return swarm.WebhookConfig{}, false
+		}
+	}
+	hooks, err := swarm.ParseWebhookConfig(bs)
 	if err != nil {
-		// Return default text if file doesn't exist
-		return "All tasks completed. Please proceed with delivery and testing."
+		logger.Printf("WARNING: %v; webhooks disabled", err)
+		return swarm.WebhookConfig{}, false
+	}
+	return hooks, true
+}
+
+// readConfigFile reads name from configDir when one is configured, else from config/ next to the binary
+// or upward from the working directory (readConfigUpward).
+func readConfigFile(configDir, name string) ([]byte, error) {
+	if configDir = strings.TrimSpace(configDir); configDir != "" {
+		return os.ReadFile(filepath.Join(configDir, name))
 	}
-	return string(bs)
+	return readConfigUpward(filepath.Join("config", name))
 }
 
 func readConfigUpward(relPath string) ([]byte, error) {
@@ -136,44 +383,106 @@ func readConfigUpward(relPath string) ([]byte, error) {
 
 func (s *Server) Run() error {
 	s.cfg.Logger.Printf("starting %s %s", s.cfg.Name, s.cfg.Version)
+	if addr := strings.TrimSpace(s.cfg.HealthAddr); addr != "" {
+		go s.serveHealth(addr)
+	}
+	reader := newMessageReader(s.in)
 
-	scanner := bufio.NewScanner(s.in)
-	buf := make([]byte, 0, 1024*1024)
-	scanner.Buffer(buf, 16*1024*1024)
+	// Cancelled when the client goes away (input closed or output broken), ending its long-polls.
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go s.nextActions.watch(ctx.Done())
 
-	enc := json.NewEncoder(s.out)
+	// write encodes v as one message, in the client's framing, unless skip (checked under the output lock)
+	// reports true. A failed write means the client is gone (e.g. broken pipe), so every call in flight
+	// is cancelled.
+	write := func(skip func() bool, v any) {
+		s.encMu.Lock()
+		defer s.encMu.Unlock()
+		if skip != nil && skip() {
+			return
+		}
+		if err := writeMessage(s.out, reader.framed.Load(), v); err != nil && ctx.Err() == nil {
+			s.cfg.Logger.Printf("WARNING: client output failed (%v); cancelling %d in-flight calls", err, s.inflight.count())
+			stop()
+		}
+	}
+	s.notify = func(v any) { write(nil, v) }
 
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
+	var readErr error
+	for {
+		line, err := reader.next()
+		if err != nil {
+			if err != io.EOF {
+				readErr = err
+			}
+			break
 		}
 
 		var req JSONRPCRequest
 		if err := json.Unmarshal(line, &req); err != nil {
-			s.encMu.Lock()
-			_ = enc.Encode(NewErrorResponse(nil, ErrParse, "invalid JSON", err.Error()))
-			s.encMu.Unlock()
+			write(nil, NewErrorResponse(nil, ErrParse, "invalid JSON", err.Error()))
 			continue
 		}
 
-		// IMPORTANT: handle requests concurrently so long-poll calls do not block other tools.
-		go func(req JSONRPCRequest) {
-			resp := s.handle(req)
-			if resp == nil {
-				return
+		// initialize may select a role profile; finish it before dispatching later requests.
+		if req.Method == "initialize" {
+			if resp := s.handle(ctx, req); resp != nil {
+				write(nil, resp)
 			}
+			s.checkToolsChanged()
+			continue
+		}
+
+		if req.Method == "notifications/cancelled" {
+			s.inflight.cancel(req.Params)
+			continue
+		}
 
-			s.encMu.Lock()
-			defer s.encMu.Unlock()
-			_ = enc.Encode(resp)
+		// IMPORTANT: handle requests concurrently so long-poll calls do not block other tools, up to
+		// MaxConcurrentCalls at once. Register before starting so a cancel notification right behind the
+		// request finds it.
+		if !s.slots.tryAcquire() {
+			if n := s.slots.rejected.Load(); n == 1 || n%100 == 0 {
+				s.cfg.Logger.Printf("WARNING: server busy (%d calls in flight); rejected %d request(s) so far", s.inflight.count(), n)
+			}
+			if req.ID != nil {
+				write(nil, s.slots.busyResponse(req.ID))
+			}
+			continue
+		}
+		reqCtx, done := s.inflight.start(ctx, req.ID)
+		go func(req JSONRPCRequest) {
+			defer s.slots.release()
+			defer done()
+			keepAliveCtx, stopKeepAlive := context.WithCancel(reqCtx)
+			go s.keepAlive(keepAliveCtx, req, func(v any) {
+				write(func() bool { return keepAliveCtx.Err() != nil }, v) // never after the response
+			})
+			resp := s.handle(reqCtx, req)
+			stopKeepAlive()
+			if resp != nil {
+				write(func() bool { return requestCancelled(reqCtx) }, resp) // cancelled requests get no response
+			}
+			if req.Method == "tools/call" {
+				s.checkToolsChanged()
+			}
 		}(req)
 	}
 
-	return scanner.Err()
+	// Input closed: the client is gone. Abort its waits and let them clean up (e.g. leave lock queues)
+	// before returning, so nothing keeps polling the store. Calls that finish meanwhile still answer.
+	if n := s.inflight.count(); n > 0 {
+		s.cfg.Logger.Printf("input closed; cancelling %d in-flight calls", n)
+	}
+	stop()
+	if !s.inflight.wait(shutdownGrace) {
+		s.cfg.Logger.Printf("WARNING: in-flight calls still running %s after input closed", shutdownGrace)
+	}
+	return readErr
 }
 
-func (s *Server) memberIDForArgs(toolName string, args map[string]any) (string, error) {
+func (s *Server) memberIDForArgs(ctx context.Context, call *toolCall, toolName string, args map[string]any) (string, error) {
 	if args == nil {
 		args = map[string]any{}
 	}
@@ -200,19 +509,19 @@ func (s *Server) memberIDForArgs(toolName string, args map[string]any) (string,
 		return "anon:" + strings.TrimSpace(s.cfg.Role), nil
 	}
 	if sessionID == "" {
-		return "", fmt.Errorf("session_id is required")
+		return "", swarm.Errorf(swarm.CodeInvalidArgument, "session_id is required")
 	}
-	valid, err := validateSemanticSessionViaGateway(sessionID)
+	valid, err := s.validateSession(ctx, call, sessionID)
 	if err != nil {
 		return "", err
 	}
 	if !valid {
-		baseURL, tool := sessionMcpGatewayConfig()
-		return "", fmt.Errorf(
+		gw := s.cfg.Gateway.withDefaults()
+		return "", swarm.Errorf(swarm.CodePermissionDenied,
 			"invalid session: please call session-mcp.upsertSemanticSession (session_id=%s gateway_url=%s validate_tool=%s)",
 			sessionID,
-			baseURL,
-			tool,
+			gw.URL,
+			gw.ValidateTool,
 		)
 	}
 	s.sessMu.Lock()
@@ -225,22 +534,38 @@ func (s *Server) memberIDForArgs(toolName string, args map[string]any) (string,
 	return mid, nil
 }
 
-func sessionMcpGatewayConfig() (baseURL string, validateTool string) {
-	baseURL = strings.TrimSpace(os.Getenv("SESSION_MCP_GATEWAY_URL"))
-	if baseURL == "" {
-		baseURL = "http://127.0.0.1:15410"
-	}
-	baseURL = strings.TrimRight(baseURL, "/")
+// GatewayConfig describes how session ids are validated against session-mcp through the MCP gateway.
+type GatewayConfig struct {
+	URL           string // default http://127.0.0.1:15410
+	Authorization string // full Authorization header; wins over Token
+	Token         string // sent as "Bearer <token>" when Authorization is empty
+	APIKey        string // X-API-Key header
+	ValidateTool  string // default validateSemanticSession
+	TimeoutSec    int    // default 5
+
+	CacheTTLSec         int // cache valid sessions this long (0 = validate on every call)
+	NegativeCacheTTLSec int // cache invalid sessions this long (0 = don't cache)
+	GraceSec            int // accept sessions validated this recently while the gateway is failing (0 = off)
+}
 
-	validateTool = strings.TrimSpace(os.Getenv("SESSION_MCP_VALIDATE_TOOL"))
-	if validateTool == "" {
-		validateTool = "validateSemanticSession"
+func (g GatewayConfig) withDefaults() GatewayConfig {
+	g.URL = strings.TrimRight(strings.TrimSpace(g.URL), "/")
+	if g.URL == "" {
+		g.URL = "http://127.0.0.1:15410"
 	}
-	return baseURL, validateTool
+	g.ValidateTool = strings.TrimSpace(g.ValidateTool)
+	if g.ValidateTool == "" {
+		g.ValidateTool = "validateSemanticSession"
+	}
+	if g.TimeoutSec <= 0 {
+		g.TimeoutSec = 5
+	}
+	return g
 }
 
-func validateSemanticSessionViaGateway(sessionID string) (bool, error) {
-	baseURL, tool := sessionMcpGatewayConfig()
+func validateSemanticSessionViaGateway(ctx context.Context, gw GatewayConfig, sessionID string) (bool, error) {
+	gw = gw.withDefaults()
+	baseURL, tool := gw.URL, gw.ValidateTool
 
 	// NOTE: we use gateway direct RPC: /mcps/session-mcp
 	// This assumes session-mcp can validate semantic sessions without relying on in-memory only state.
@@ -264,53 +589,34 @@ func validateSemanticSessionViaGateway(sessionID string) (bool, error) {
 		return false, err
 	}
 
-	hreq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(b))
+	hreq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
 	if err != nil {
 		return false, err
 	}
 	hreq.Header.Set("Content-Type", "application/json")
 
 	// If gateway auth is enabled, forward the same token.
-	// Prefer MCP_GATEWAY_TOKEN for consistency with gateway itself.
-	authorization := strings.TrimSpace(os.Getenv("SESSION_MCP_GATEWAY_AUTHORIZATION"))
-	if authorization != "" {
+	if authorization := strings.TrimSpace(gw.Authorization); authorization != "" {
 		hreq.Header.Set("Authorization", authorization)
-	} else {
-		token := strings.TrimSpace(os.Getenv("MCP_GATEWAY_TOKEN"))
-		if token == "" {
-			token = strings.TrimSpace(os.Getenv("SESSION_MCP_GATEWAY_TOKEN"))
-		}
-		if token != "" {
-			hreq.Header.Set("Authorization", "Bearer "+token)
-		}
-	}
-
-	apiKey := strings.TrimSpace(os.Getenv("SESSION_MCP_GATEWAY_API_KEY"))
-	if apiKey == "" {
-		apiKey = strings.TrimSpace(os.Getenv("MCP_GATEWAY_TOKEN"))
+	} else if token := strings.TrimSpace(gw.Token); token != "" {
+		hreq.Header.Set("Authorization", "Bearer "+token)
 	}
-	if apiKey != "" {
+	if apiKey := strings.TrimSpace(gw.APIKey); apiKey != "" {
 		hreq.Header.Set("X-API-Key", apiKey)
 	}
 
-	timeoutSec := 5
-	if v := strings.TrimSpace(os.Getenv("SESSION_MCP_GATEWAY_TIMEOUT_SEC")); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n > 0 {
-			timeoutSec = n
-		}
-	}
-	client := &http.Client{Timeout: time.Duration(timeoutSec) * time.Second}
+	client := &http.Client{Timeout: time.Duration(gw.TimeoutSec) * time.Second}
 	resp, err := client.Do(hreq)
 	if err != nil {
-		return false, fmt.Errorf("session-mcp validation failed: %w", err)
+		return false, swarm.Errorf(swarm.CodeUnavailable, "session-mcp validation failed: %w", err)
 	}
 	defer resp.Body.Close()
 	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
 	if err != nil {
-		return false, fmt.Errorf("session-mcp validation failed: read response: %w", err)
+		return false, swarm.Errorf(swarm.CodeUnavailable, "session-mcp validation failed: read response: %w", err)
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return false, fmt.Errorf("session-mcp validation failed: http %d: %s", resp.StatusCode, string(bytes.TrimSpace(body)))
+		return false, swarm.Errorf(swarm.CodeUnavailable, "session-mcp validation failed: http %d: %s", resp.StatusCode, string(bytes.TrimSpace(body)))
 	}
 
 	// Parse MCP JSON-RPC response.
@@ -319,19 +625,19 @@ func validateSemanticSessionViaGateway(sessionID string) (bool, error) {
 		Error  any            `json:"error"`
 	}
 	if err := json.Unmarshal(body, &rpcResp); err != nil {
-		return false, fmt.Errorf("session-mcp validation failed: invalid rpc response: %w", err)
+		return false, swarm.Errorf(swarm.CodeUnavailable, "session-mcp validation failed: invalid rpc response: %w", err)
 	}
 	if rpcResp.Error != nil {
-		return false, fmt.Errorf("session-mcp validation failed: %v", rpcResp.Error)
+		return false, swarm.Errorf(swarm.CodeUnavailable, "session-mcp validation failed: %v", rpcResp.Error)
 	}
 	content, _ := rpcResp.Result["content"].([]any)
 	if len(content) == 0 {
-		return false, fmt.Errorf("session-mcp validation failed: empty content")
+		return false, swarm.Errorf(swarm.CodeUnavailable, "session-mcp validation failed: empty content")
 	}
 	first, _ := content[0].(map[string]any)
 	text, _ := first["text"].(string)
 	if strings.TrimSpace(text) == "" {
-		return false, fmt.Errorf("session-mcp validation failed: empty text")
+		return false, swarm.Errorf(swarm.CodeUnavailable, "session-mcp validation failed: empty text")
 	}
 
 	// session-mcp wraps tool result as textified JSON.
@@ -339,19 +645,19 @@ func validateSemanticSessionViaGateway(sessionID string) (bool, error) {
 		Valid bool `json:"valid"`
 	}
 	if err := json.Unmarshal([]byte(text), &toolRes); err != nil {
-		return false, fmt.Errorf("session-mcp validation failed: invalid tool result: %w", err)
+		return false, swarm.Errorf(swarm.CodeUnavailable, "session-mcp validation failed: invalid tool result: %w", err)
 	}
 	return toolRes.Valid, nil
 }
 
-func (s *Server) handle(req JSONRPCRequest) *JSONRPCResponse {
+func (s *Server) handle(ctx context.Context, req JSONRPCRequest) *JSONRPCResponse {
 	if req.ID == nil {
 		return nil
 	}
 
 	switch req.Method {
 	case "initialize":
-		resp := s.handleInitialize(req.ID)
+		resp := s.handleInitialize(req.ID, req.Params)
 		return &resp
 	case "prompts/list":
 		resp := NewResultResponse(req.ID, map[string]any{"prompts": []any{}})
@@ -360,44 +666,10 @@ func (s *Server) handle(req JSONRPCRequest) *JSONRPCResponse {
 		resp := NewResultResponse(req.ID, map[string]any{"resources": []any{}})
 		return &resp
 	case "tools/list":
-		tools := allToolsForRole(s.cfg.Role)
-		disabled := map[string]struct{}{}
-		if pm, ok := req.Params.(map[string]any); ok {
-			if v, ok2 := pm["disabledTools"]; ok2 && v != nil {
-				switch xs := v.(type) {
-				case []any:
-					for _, it := range xs {
-						n := strings.TrimSpace(fmt.Sprint(it))
-						if n != "" {
-							disabled[n] = struct{}{}
-						}
-					}
-				case []string:
-					for _, it := range xs {
-						n := strings.TrimSpace(it)
-						if n != "" {
-							disabled[n] = struct{}{}
-						}
-					}
-				default:
-					// ignore invalid types
-				}
-			}
-		}
-		if len(disabled) > 0 {
-			filtered := make([]ToolDefinition, 0, len(tools))
-			for _, t := range tools {
-				if _, ok := disabled[t.Name]; ok {
-					continue
-				}
-				filtered = append(filtered, t)
-			}
-			tools = filtered
-		}
-		resp := NewResultResponse(req.ID, map[string]any{"tools": tools})
+		resp := s.handleToolsList(req.ID, req.Params)
 		return &resp
 	case "tools/call":
-		resp := s.handleToolsCall(req.ID, req.Params)
+		resp := s.handleToolsCall(ctx, req.ID, req.Params)
 		return &resp
 	default:
 		resp := NewErrorResponse(req.ID, ErrMethodNotFound, "method not found", req.Method)
@@ -405,22 +677,39 @@ func (s *Server) handle(req JSONRPCRequest) *JSONRPCResponse {
 	}
 }
 
-func (s *Server) handleInitialize(id any) JSONRPCResponse {
+func (s *Server) handleInitialize(id any, params any) JSONRPCResponse {
+	version, err := negotiateProtocolVersion(protocolVersionFromInitialize(params))
+	if err != nil {
+		var unsupported *UnsupportedProtocolError
+		errors.As(err, &unsupported)
+		return NewErrorResponse(id, ErrInvalidParams, err.Error(), unsupported.ErrorDetails())
+	}
+	if name := profileFromInitialize(params); name != "" {
+		if err := s.checkClientProfile(name); err != nil {
+			return NewErrorResponse(id, ErrInvalidParams, err.Error(), nil)
+		}
+		if err := s.applyProfile(name); err != nil {
+			return NewErrorResponse(id, ErrInvalidParams, err.Error(), nil)
+		}
+	}
+	s.protocolVersion.Store(version)
 	return NewResultResponse(id, map[string]any{
-		"protocolVersion": "2024-11-05",
+		"protocolVersion": version,
 		"capabilities": map[string]any{
 			"resources": map[string]any{},
 			"prompts":   map[string]any{},
-			"tools":     map[string]any{},
+			"tools":     map[string]any{"listChanged": true},
 		},
 		"serverInfo": map[string]any{
 			"name":    s.cfg.Name,
 			"version": s.cfg.Version,
+			"role":    s.cfg.Role,
+			"profile": s.profile,
 		},
 	})
 }
 
-func (s *Server) handleToolsCall(id any, params any) JSONRPCResponse {
+func (s *Server) handleToolsCall(ctx context.Context, id any, params any) JSONRPCResponse {
 	paramsMap, ok := params.(map[string]any)
 	if !ok {
 		return NewErrorResponse(id, ErrInvalidParams, "invalid params", nil)
@@ -432,7 +721,37 @@ func (s *Server) handleToolsCall(id any, params any) JSONRPCResponse {
 		args = a
 	}
 
-	tok := expectedRoleCode(s.cfg.Role)
+	start := time.Now()
+	call := &toolCall{}
+	resp, status, callErr := s.callTool(swarm.WithRequestID(ctx, requestKey(id)), call, id, name, args)
+	entry := swarm.AuditEntry{
+		Tool:      name,
+		Role:      strings.TrimSpace(s.cfg.Role),
+		MemberID:  call.MemberID,
+		WorkerID:  strings.TrimSpace(str(args, "worker_id")),
+		IssueID:   strings.TrimSpace(str(args, "issue_id")),
+		TaskID:    strings.TrimSpace(str(args, "task_id")),
+		ArgsHash:  swarm.HashArgs(args, "role_code"),
+		Status:    status,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	entry.Actor = entry.WorkerID
+	if entry.Actor == "" {
+		entry.Actor = entry.MemberID
+	}
+	if callErr != nil {
+		entry.Error = callErr.Error()
+		entry.ErrorCode = swarm.ErrorCode(callErr)
+	}
+	entry.Degraded = call.Degraded
+	entry.Project = call.Project
+	s.audit.Record(entry)
+	return resp
+}
+
+// callTool checks the role code and runs the tool, reporting the audit status alongside the response.
+func (s *Server) callTool(ctx context.Context, call *toolCall, id any, name string, args map[string]any) (JSONRPCResponse, string, error) {
+	tok := s.expectedRoleCode()
 	if tok != "" {
 		provided, ok := args["role_code"].(string)
 		if !ok {
@@ -445,41 +764,134 @@ func (s *Server) handleToolsCall(id any, params any) JSONRPCResponse {
 		}
 		provided = strings.TrimSpace(provided)
 		if provided == "" {
-			return NewErrorResponse(id, ErrInvalidParams, "missing role_code for role '"+strings.TrimSpace(s.cfg.Role)+"'", nil)
+			err := swarm.Errorf(swarm.CodePermissionDenied, "missing role_code for role '%s'", strings.TrimSpace(s.cfg.Role))
+			return NewErrorResponse(id, ErrInvalidParams, err.Error(), nil), swarm.AuditStatusDenied, err
 		}
 		if provided != tok {
-			return NewErrorResponse(id, ErrInvalidParams, "invalid role_code for role '"+strings.TrimSpace(s.cfg.Role)+"'", nil)
+			err := swarm.Errorf(swarm.CodePermissionDenied, "invalid role_code for role '%s'", strings.TrimSpace(s.cfg.Role))
+			return NewErrorResponse(id, ErrInvalidParams, err.Error(), nil), swarm.AuditStatusDenied, err
 		}
 	}
 
-	result, err := s.dispatch(name, args)
+	result, err := s.dispatch(ctx, call, name, args)
 	if err != nil {
-		return NewResultResponse(id, map[string]any{
-			"content": []map[string]any{{"type": "text", "text": fmt.Sprintf("ERROR: %v", err)}},
+		if ctx.Err() != nil {
+			s.cfg.Logger.Printf("request %s (%s, actor %s) ended early: %v", swarm.RequestIDFromContext(ctx), name, call.MemberID, err)
+		}
+		block := errorBlock(err)
+		detailsJSON, _ := json.MarshalIndent(block, "", "  ")
+		content := []map[string]any{
+			{"type": "text", "text": fmt.Sprintf("ERROR: %v", err)},
+			{"type": "text", "text": string(detailsJSON)},
+		}
+		status := swarm.AuditStatusError
+		var limited *RateLimitError
+		if name != "" && !toolAllowedForRole(s.cfg.Role, name) {
+			status = swarm.AuditStatusDenied
+		} else if errors.As(err, &limited) {
+			status = swarm.AuditStatusRateLimited
+		}
+		out := map[string]any{
+			"content": content,
 			"isError": true,
-		})
+		}
+		if s.protocolAtLeast(protocol20250618) {
+			out["structuredContent"] = block
+		}
+		return NewResultResponse(id, out), status, err
 	}
 
+	s.resolveNextActions(name, result, args)
+	expandNextActions(result, args)
+	s.addNextSteps(result, args)
 	resultJSON, _ := json.MarshalIndent(result, "", "  ")
-	return NewResultResponse(id, map[string]any{
+	out := map[string]any{
 		"content": []map[string]any{{"type": "text", "text": string(resultJSON)}},
-	})
+	}
+	// From 2025-06-18 the result is also sent as structuredContent; the text block stays for clients
+	// that only read content.
+	if s.protocolAtLeast(protocol20250618) {
+		out["structuredContent"] = structuredResult(resultJSON)
+	}
+	return NewResultResponse(id, out), swarm.AuditStatusOK, nil
+}
+
+// toolCall collects per-call facts for the audit log while a tools/call is handled.
+type toolCall struct {
+	MemberID string // resolved member id ("" if the call failed before session resolution)
+	Degraded string // set when the call was let through in a degraded mode (e.g. session grace window)
+	Project  string // project namespace the call resolved to ("" = root)
+}
+
+// detailedError is implemented by errors that carry structured data (e.g. lock conflicts).
+type detailedError interface {
+	error
+	ErrorDetails() any
 }
 
-func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
+// errorBlock is the JSON content block returned next to the ERROR text: {"error": {code, message,
+// retryable}} plus the error's details, e.g. {"lock_conflict": {...}}.
+func errorBlock(err error) map[string]any {
+	block := map[string]any{}
+	var detailed detailedError
+	if errors.As(err, &detailed) {
+		switch d := detailed.ErrorDetails().(type) {
+		case nil:
+		case map[string]any:
+			for k, v := range d {
+				block[k] = v
+			}
+		default:
+			block["details"] = d
+		}
+	}
+	block["error"] = map[string]any{
+		"code":      swarm.ErrorCode(err),
+		"message":   err.Error(),
+		"retryable": swarm.ErrorRetryable(err),
+	}
+	return block
+}
+
+func (s *Server) dispatch(ctx context.Context, call *toolCall, tool string, args map[string]any) (any, error) {
 	if tool == "" {
-		return nil, fmt.Errorf("tool name is required")
+		return nil, swarm.Errorf(swarm.CodeInvalidArgument, "tool name is required")
 	}
 	if !toolAllowedForRole(s.cfg.Role, tool) {
-		return nil, fmt.Errorf("tool '%s' is not allowed for role '%s'", tool, strings.TrimSpace(s.cfg.Role))
+		return nil, swarm.Errorf(swarm.CodePermissionDenied, "tool '%s' is not allowed for role '%s'", tool, strings.TrimSpace(s.cfg.Role))
+	}
+	if err := s.limits.check(args); err != nil {
+		return nil, err
 	}
 
-	memberID, err := s.memberIDForArgs(tool, args)
+	memberID, err := s.memberIDForArgs(ctx, call, tool, args)
+	if err != nil {
+		return nil, err
+	}
+	call.MemberID = memberID
+	actor := strings.TrimSpace(str(args, "worker_id"))
+	if actor == "" {
+		actor = memberID
+	}
+	ctx = swarm.WithActor(ctx, actor)
+	if role := strings.ToLower(strings.TrimSpace(s.cfg.Role)); role == "" || role == "worker" {
+		// Keep the worker's last_seen_at fresh, also while it blocks in a long-poll, so the scheduler
+		// only assigns to live workers.
+		if workerID := strings.TrimSpace(str(args, "worker_id")); workerID != "" {
+			defer s.workerSvc.KeepSeen(workerID)()
+		}
+	}
+	if s.allowShort(args) {
+		ctx = swarm.WithShortTimeouts(ctx)
+	}
+	p, err := s.scopeFor(tool, args)
 	if err != nil {
 		return nil, err
 	}
-	nowMs := time.Now().UnixMilli()
-	nowStr := time.Now().UTC().Format(time.RFC3339)
+	call.Project = p.key
+	// Lease times are on the lease clock (swarm.LeaseNowMs), which ignores wall clock steps.
+	nowMs := swarm.LeaseNowMs()
+	nowStr := time.UnixMilli(nowMs).UTC().Format(time.RFC3339)
 	toMap := func(v any) (map[string]any, error) {
 		b, err := json.Marshal(v)
 		if err != nil {
@@ -496,18 +908,72 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		m["server_now"] = nowStr
 		return m
 	}
+	// addClockSkew warns lease holders while the wall clock disagrees with the lease clock, i.e. it was
+	// stepped and lease times are still catching up. Agents should rely on lease_remaining_ms then.
+	addClockSkew := func(m map[string]any) map[string]any {
+		if skew := swarm.ClockSkewMs(); skew > swarm.ClockSkewWarnMs || skew < -swarm.ClockSkewWarnMs {
+			m["clock_skew_ms"] = skew
+			m["clock_skew_warning"] = fmt.Sprintf("server wall clock is %s off its lease clock (clock stepped?); lease times follow server_now, use lease_remaining_ms", time.Duration(skew)*time.Millisecond)
+		}
+		return m
+	}
 	addLeaseExpiresAt := func(m map[string]any) map[string]any {
 		if v, ok := m["lease_expires_at_ms"].(float64); ok {
 			ms := int64(v)
 			if ms > 0 {
 				m["lease_expires_at"] = time.UnixMilli(ms).UTC().Format(time.RFC3339)
+				m["lease_remaining_ms"] = max(ms-swarm.LeaseNowMs(), 0)
 			} else {
 				m["lease_expires_at"] = ""
 			}
 		}
+		return addClockSkew(m)
+	}
+	// leaseMap renders a file lock lease with its lease_remaining_ms.
+	leaseMap := func(l *swarm.Lease) (map[string]any, error) {
+		m, err := toMap(l)
+		if err != nil {
+			return nil, err
+		}
+		if exp, err := time.Parse(time.RFC3339, l.ExpiresAt); err == nil {
+			m["lease_remaining_ms"] = max(exp.UnixMilli()-swarm.LeaseNowMs(), 0)
+		}
+		return m, nil
+	}
+	// leaseResult renders a file lock lease with the same clock fields as addLeaseExpiresAt.
+	leaseResult := func(l *swarm.Lease, err error) (any, error) {
+		if err != nil {
+			return nil, err
+		}
+		m, err := leaseMap(l)
+		if err != nil {
+			return nil, err
+		}
+		return addClockSkew(addNow(m)), nil
+	}
+	// issueView keeps the lead list of an issue to leads, who also get the lead key of their own session
+	// (what a co-lead hands the owner for addIssueLead instead of its session id).
+	issueView := func(m map[string]any) map[string]any {
+		if strings.TrimSpace(s.cfg.Role) != "lead" {
+			delete(m, "leads")
+		} else if sess := strings.TrimSpace(str(args, "session_id")); sess != "" {
+			m["lead_key"] = swarm.LeadKey(sess)
+		}
 		return m
 	}
 
+	// leadInboxCaller names the lead acting on a lead inbox item by the session id its claim was made with
+	// (waitIssueTaskEvents falls back to "lead" without one). Admins repair items regardless of claims.
+	leadInboxCaller := func() string {
+		if strings.TrimSpace(s.cfg.Role) == "admin" {
+			return ""
+		}
+		if sess := strings.TrimSpace(str(args, "session_id")); sess != "" {
+			return sess
+		}
+		return "lead"
+	}
+
 	filterIssues := func(issues []swarm.Issue, status, subjectContains string) []swarm.Issue {
 		out := make([]swarm.Issue, 0, len(issues))
 		status = strings.TrimSpace(strings.ToLower(status))
@@ -532,28 +998,11 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		return out
 	}
 
+	// sortIssues expects sortBy/sortOrder normalized by normalizeSort.
 	sortIssues := func(issues []swarm.Issue, sortBy, sortOrder string) {
-		sortBy = strings.TrimSpace(strings.ToLower(sortBy))
-		if sortBy == "" {
-			sortBy = "created_at"
-		}
-		sortOrder = strings.TrimSpace(strings.ToLower(sortOrder))
-		if sortOrder == "" {
-			sortOrder = "desc"
-		}
 		less := func(i, j int) bool {
-			var a, b string
-			switch sortBy {
-			case "updated_at":
-				a, b = issues[i].UpdatedAt, issues[j].UpdatedAt
-			default:
-				a, b = issues[i].CreatedAt, issues[j].CreatedAt
-			}
 			// RFC3339 lexicographic compares correctly
-			if sortOrder == "asc" {
-				return a < b
-			}
-			return a > b
+			return byKeyThenID(sortOrder == "desc")(issueSortKey(issues[i], sortBy), issues[i].ID, issueSortKey(issues[j], sortBy), issues[j].ID)
 		}
 		sort.SliceStable(issues, less)
 	}
@@ -614,33 +1063,10 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		return out
 	}
 
+	// sortTasks expects sortBy/sortOrder normalized by normalizeSort.
 	sortTasks := func(tasks []swarm.IssueTask, sortBy, sortOrder string) {
-		sortBy = strings.TrimSpace(strings.ToLower(sortBy))
-		if sortBy == "" {
-			sortBy = "created_at"
-		}
-		sortOrder = strings.TrimSpace(strings.ToLower(sortOrder))
-		if sortOrder == "" {
-			sortOrder = "desc"
-		}
 		less := func(i, j int) bool {
-			switch sortBy {
-			case "updated_at":
-				if sortOrder == "asc" {
-					return tasks[i].UpdatedAt < tasks[j].UpdatedAt
-				}
-				return tasks[i].UpdatedAt > tasks[j].UpdatedAt
-			case "points":
-				if sortOrder == "asc" {
-					return tasks[i].Points < tasks[j].Points
-				}
-				return tasks[i].Points > tasks[j].Points
-			default:
-				if sortOrder == "asc" {
-					return tasks[i].CreatedAt < tasks[j].CreatedAt
-				}
-				return tasks[i].CreatedAt > tasks[j].CreatedAt
-			}
+			return byKeyThenID(sortOrder == "desc")(taskSortKey(tasks[i], sortBy), tasks[i].ID, taskSortKey(tasks[j], sortBy), tasks[j].ID)
 		}
 		sort.SliceStable(tasks, less)
 	}
@@ -668,17 +1094,26 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 	case "myProfile":
 		return map[string]any{"member_id": memberID}, nil
 	case "swarmNow":
-		return map[string]any{"now_ms": nowMs, "now": nowStr}, nil
+		return addClockSkew(map[string]any{"now_ms": nowMs, "now": nowStr}), nil
 
 	// === Issue pool ===
 	case "listIssues":
-		issues, err := s.issueSvc.ListIssues()
+		issues, err := p.issueSvc.ListIssues()
 		if err != nil {
 			return nil, err
 		}
 		issues = filterIssues(issues, str(args, "status"), str(args, "subject_contains"))
-		sortIssues(issues, str(args, "sort_by"), str(args, "sort_order"))
-		issues = paginateIssues(issues, intVal(args, "offset"), intVal(args, "limit"))
+		sortBy, sortOrder := normalizeSort(str(args, "sort_by"), str(args, "sort_order"), "created_at", "updated_at")
+		sortIssues(issues, sortBy, sortOrder)
+		cur, paged, err := cursorArg(args, "issues", sortBy, sortOrder)
+		if err != nil {
+			return nil, err
+		}
+		if paged {
+			issues, _ = pageAfter(issues, func(it swarm.Issue) (string, string) { return issueSortKey(it, sortBy), it.ID }, byKeyThenID(sortOrder == "desc"), cur, intVal(args, "limit"))
+		} else {
+			issues = paginateIssues(issues, intVal(args, "offset"), intVal(args, "limit"))
+		}
 		out := make([]map[string]any, 0, len(issues))
 		for _, it := range issues {
 			m := map[string]any{
@@ -688,12 +1123,17 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 				"lease_expires_at_ms": it.LeaseExpiresAtMs,
 				"created_at":          it.CreatedAt,
 				"updated_at":          it.UpdatedAt,
+				"cursor":              pageCursor{List: "issues", SortBy: sortBy, SortOrder: sortOrder}.at(issueSortKey(it, sortBy), it.ID),
+			}
+			if it.PausedAt != "" {
+				m["paused_at"] = it.PausedAt
+				m["pause_reason"] = it.PauseReason
 			}
 			out = append(out, addLeaseExpiresAt(m))
 		}
 		return out, nil
 	case "listOpenedIssues":
-		issues, err := s.issueSvc.ListIssues()
+		issues, err := p.issueSvc.ListIssues()
 		if err != nil {
 			return nil, err
 		}
@@ -717,7 +1157,7 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		if strings.TrimSpace(status) == "" {
 			status = swarm.IssueOpen
 		}
-		issues, err := s.issueSvc.WaitIssues(status, timeoutWithMin(intVal(args, "timeout_sec"), s.cfg.MinTimeoutSec, s.cfg.DefaultTimeoutSec), intVal(args, "limit"))
+		issues, err := p.issueSvc.WaitIssues(ctx, status, s.waitTimeout(args), intVal(args, "limit"))
 		if err != nil {
 			return nil, err
 		}
@@ -727,7 +1167,7 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 			if err != nil {
 				return nil, err
 			}
-			out = append(out, addLeaseExpiresAt(addNow(m)))
+			out = append(out, addLeaseExpiresAt(addNow(issueView(m))))
 		}
 		return map[string]any{"issues": out, "count": len(issues), "server_now_ms": nowMs, "server_now": nowStr}, nil
 	case "waitIssueTasks":
@@ -735,7 +1175,33 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		if strings.TrimSpace(status) == "" {
 			status = swarm.IssueTaskOpen
 		}
-		tasks, err := s.issueSvc.WaitIssueTasks(str(args, "issue_id"), status, timeoutWithMin(intVal(args, "timeout_sec"), s.cfg.MinTimeoutSec, s.cfg.DefaultTimeoutSec), intVal(args, "limit"))
+		filter := swarm.TaskFilter{
+			Labels:     strSlice(args, "labels"),
+			Difficulty: str(args, "difficulty"),
+			MinPoints:  intVal(args, "min_points"),
+			MaxPoints:  intVal(args, "max_points"),
+		}
+		if wid := strings.TrimSpace(str(args, "worker_id")); wid != "" && status == swarm.IssueTaskOpen && p.issueSvc.DispatchPolicy() != "" {
+			task, err := p.issueSvc.WaitDispatchedTask(ctx, str(args, "issue_id"), wid, filter, s.waitTimeout(args))
+			if err != nil {
+				return nil, err
+			}
+			resp := map[string]any{"tasks": []map[string]any{}, "count": 0, "dispatch_policy": p.issueSvc.DispatchPolicy(), "server_now_ms": nowMs, "server_now": nowStr}
+			if task == nil {
+				resp["next_actions"] = s.getNextActions("worker_after_wait_issue_tasks_empty", []string{"Next: keep waiting for available tasks (waitIssueTasks)."})
+				return resp, nil
+			}
+			m, err := toMap(task)
+			if err != nil {
+				return nil, err
+			}
+			resp["tasks"] = []map[string]any{addLeaseExpiresAt(addNow(m))}
+			resp["count"] = 1
+			resp["next_step_token"] = task.ReservedToken
+			resp["next_actions"] = s.getNextActions("worker_after_wait_issue_tasks_dispatched", []string{"Next: claim the dispatched task (claimIssueTask with next_step_token) before reserved_until_ms."})
+			return resp, nil
+		}
+		tasks, err := p.issueSvc.WaitIssueTasks(ctx, str(args, "issue_id"), status, filter, s.waitTimeout(args), intVal(args, "limit"))
 		if err != nil {
 			return nil, err
 		}
@@ -754,8 +1220,32 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 			resp["next_actions"] = s.getNextActions("worker_after_wait_issue_tasks_has_tasks", []string{"Next: claim an open task (claimIssueTask)."})
 		}
 		return resp, nil
+	case "waitAssignment":
+		wid := strings.TrimSpace(str(args, "worker_id"))
+		if wid == "" {
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
+		}
+		if !p.issueSvc.SchedulerEnabled() {
+			return nil, swarm.Errorf(swarm.CodeUnsupported, "automatic assignment is off (set SWARM_MCP_SCHEDULER_RESERVE_SEC); use waitIssueTasks")
+		}
+		a, err := p.issueSvc.WaitAssignment(ctx, wid, s.waitTimeout(args))
+		if err != nil {
+			return nil, err
+		}
+		resp := map[string]any{"assignment": nil, "server_now_ms": nowMs, "server_now": nowStr}
+		if a == nil {
+			resp["next_actions"] = s.getNextActions("worker_after_wait_assignment_empty", []string{"Next: keep waiting for an assignment (waitAssignment)."})
+			return resp, nil
+		}
+		m, err := toMap(a)
+		if err != nil {
+			return nil, err
+		}
+		resp["assignment"] = m
+		resp["next_actions"] = s.getNextActions("worker_after_wait_assignment", []string{"Next: claim the assigned task (claimIssueTask with next_step_token) before reserved_until_ms."})
+		return resp, nil
 	case "getIssue":
-		issue, err := s.issueSvc.GetIssue(str(args, "issue_id"))
+		issue, err := p.issueSvc.GetIssue(str(args, "issue_id"))
 		if err != nil {
 			return nil, err
 		}
@@ -763,9 +1253,66 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		if err != nil {
 			return nil, err
 		}
-		return addLeaseExpiresAt(addNow(m)), nil
+		return addLeaseExpiresAt(addNow(issueView(m))), nil
+	case "getDifficultyCalibration":
+		return p.issueSvc.GetDifficultyCalibration()
+	case "getIssueMetrics":
+		metrics, err := p.issueSvc.GetIssueMetrics(str(args, "issue_id"))
+		if err != nil {
+			return nil, err
+		}
+		m, err := toMap(metrics)
+		if err != nil {
+			return nil, err
+		}
+		return addNow(m), nil
+	case "getIssueTimeline":
+		entries, err := p.issueSvc.GetIssueTimeline(str(args, "issue_id"), strings.TrimSpace(str(args, "task_id")))
+		if err != nil {
+			return nil, err
+		}
+		if kind := strings.TrimSpace(str(args, "kind")); kind != "" {
+			kept := entries[:0]
+			for _, e := range entries {
+				if e.Kind == kind {
+					kept = append(kept, e)
+				}
+			}
+			entries = kept
+		}
+		sortOrder := "asc"
+		if strings.EqualFold(strings.TrimSpace(str(args, "sort_order")), "desc") {
+			sortOrder = "desc"
+			slices.Reverse(entries)
+		}
+		cur, _, err := cursorArg(args, "timeline", "at", sortOrder)
+		if err != nil {
+			return nil, err
+		}
+		page, _ := pageAfter(entries, func(e swarm.TimelineEntry) (string, string) { return e.At, e.ID }, byKeyAndID(sortOrder == "desc"), cur, intVal(args, "limit"))
+		items := make([]map[string]any, 0, len(page))
+		for _, e := range page {
+			m, err := toMap(e)
+			if err != nil {
+				return nil, err
+			}
+			m["cursor"] = pageCursor{List: "timeline", SortBy: "at", SortOrder: sortOrder}.at(e.At, e.ID)
+			items = append(items, m)
+		}
+		out := map[string]any{"items": items, "total": len(entries)}
+		return addNow(out), nil
+	case "getSwarmStats":
+		stats, err := p.issueSvc.GetSwarmStats(s.workerSvc, p.lockSvc)
+		if err != nil {
+			return nil, err
+		}
+		m, err := toMap(stats)
+		if err != nil {
+			return nil, err
+		}
+		return addNow(m), nil
 	case "extendIssueLease":
-		issue, err := s.issueSvc.ExtendIssueLease(memberID, str(args, "issue_id"), intVal(args, "extend_sec"))
+		issue, err := p.issueSvc.ExtendIssueLease(memberID, str(args, "issue_id"), intVal(args, "extend_sec"))
 		if err != nil {
 			return nil, err
 		}
@@ -779,11 +1326,11 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		if strings.TrimSpace(s.cfg.Role) == "worker" {
 			wid := strings.TrimSpace(str(args, "worker_id"))
 			if wid == "" {
-				return nil, fmt.Errorf("worker_id is required")
+				return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
 			}
 			actor = wid
 		}
-		task, err := s.issueSvc.ExtendIssueTaskLease(actor, str(args, "issue_id"), str(args, "task_id"), intVal(args, "extend_sec"))
+		task, err := p.issueSvc.ExtendIssueTaskLease(actor, str(args, "issue_id"), str(args, "task_id"), intVal(args, "extend_sec"))
 		if err != nil {
 			return nil, err
 		}
@@ -793,7 +1340,7 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		}
 		return addLeaseExpiresAt(addNow(m)), nil
 	case "closeIssue":
-		issue, err := s.issueSvc.CloseIssue(memberID, str(args, "issue_id"), str(args, "summary"))
+		issue, err := p.issueSvc.CloseIssue(memberID, str(args, "issue_id"), str(args, "summary"), int64(intVal(args, "expected_rev")))
 		if err != nil {
 			return nil, err
 		}
@@ -803,7 +1350,7 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		}
 		return addLeaseExpiresAt(addNow(m)), nil
 	case "reopenIssue":
-		issue, err := s.issueSvc.ReopenIssue(memberID, str(args, "issue_id"), str(args, "summary"))
+		issue, err := p.issueSvc.ReopenIssue(memberID, str(args, "issue_id"), str(args, "summary"), int64(intVal(args, "expected_rev")))
 		if err != nil {
 			return nil, err
 		}
@@ -812,40 +1359,109 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 			return nil, err
 		}
 		return addLeaseExpiresAt(addNow(m)), nil
-	case "submitDelivery":
-		art := objMap(args, "artifacts")
-		e := objMap(args, "test_evidence")
-		out, err := s.issueSvc.SubmitDelivery(
-			str(args, "worker_id"),
-			str(args, "issue_id"),
-			str(args, "summary"),
-			str(args, "refs"),
-			swarm.DeliveryArtifacts{
-				TestResult:   str(art, "test_result"),
-				TestCases:    strSlice(art, "test_cases"),
-				ChangedFiles: strSlice(art, "changed_files"),
-				ReviewedRefs: strSlice(art, "reviewed_refs"),
-				TestOutput:   str(art, "test_output"),
-				KnownRisks:   str(art, "known_risks"),
-			},
-			swarm.TestEvidence{
-				ScriptPath:   str(e, "script_path"),
-				ScriptCmd:    str(e, "script_cmd"),
-				ScriptPassed: boolVal(e, "script_passed"),
+	case "pauseIssue", "resumeIssue":
+		var issue *swarm.Issue
+		var err error
+		if tool == "pauseIssue" {
+			issue, err = p.issueSvc.PauseIssue(memberID, str(args, "issue_id"), str(args, "reason"), int64(intVal(args, "expected_rev")))
+		} else {
+			issue, err = p.issueSvc.ResumeIssue(memberID, str(args, "issue_id"), int64(intVal(args, "expected_rev")))
+		}
+		if err != nil {
+			return nil, err
+		}
+		m, err := toMap(issue)
+		if err != nil {
+			return nil, err
+		}
+		return addLeaseExpiresAt(addNow(m)), nil
+	case "addIssueLead", "removeIssueLead":
+		// Leads are identified by the key of their session id, like the lead inbox claims, since member ids
+		// change on restart.
+		caller := strings.TrimSpace(str(args, "session_id"))
+		var issue *swarm.Issue
+		var err error
+		if tool == "addIssueLead" {
+			issue, err = p.issueSvc.AddIssueLead(memberID, str(args, "issue_id"), caller, str(args, "lead_id"), int64(intVal(args, "expected_rev")))
+		} else {
+			issue, err = p.issueSvc.RemoveIssueLead(memberID, str(args, "issue_id"), caller, str(args, "lead_id"), int64(intVal(args, "expected_rev")))
+		}
+		if err != nil {
+			return nil, err
+		}
+		m, err := toMap(issue)
+		if err != nil {
+			return nil, err
+		}
+		return addLeaseExpiresAt(addNow(issueView(m))), nil
+	case "setIssueClaimChecks":
+		var checks []swarm.ClaimCheck
+		for _, c := range mapSlice(args, "checks") {
+			checks = append(checks, swarm.ClaimCheck{Validator: str(c, "validator"), Doc: str(c, "doc"), Text: str(c, "text")})
+		}
+		issue, err := p.issueSvc.SetIssueClaimChecks(memberID, str(args, "issue_id"), checks, int64(intVal(args, "expected_rev")))
+		if err != nil {
+			return nil, err
+		}
+		m, err := toMap(issue)
+		if err != nil {
+			return nil, err
+		}
+		return addLeaseExpiresAt(addNow(m)), nil
+	case "setIssueNextActions", "getIssueNextActions":
+		issueID := str(args, "issue_id")
+		var overrides map[string][]string
+		var err error
+		if tool == "setIssueNextActions" {
+			overrides, err = p.issueSvc.SetIssueNextActions(issueID, str(args, "key"), strSlice(args, "actions"))
+		} else {
+			if _, err = p.issueSvc.GetIssue(issueID); err == nil {
+				overrides, err = p.issueSvc.IssueNextActions(issueID)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return addNow(map[string]any{"issue_id": issueID, "overrides": overrides}), nil
+	case "submitDelivery":
+		art := objMap(args, "artifacts")
+		e := objMap(args, "test_evidence")
+		out, err := p.issueSvc.SubmitDelivery(ctx,
+			str(args, "worker_id"),
+			str(args, "issue_id"),
+			swarm.DeliveryScope{
+				Milestone: str(args, "milestone"),
+				TaskIDs:   strSlice(args, "task_ids"),
+			},
+			str(args, "summary"),
+			str(args, "refs"),
+			swarm.DeliveryArtifacts{
+				TestResult:   str(art, "test_result"),
+				TestCases:    strSlice(art, "test_cases"),
+				ChangedFiles: strSlice(art, "changed_files"),
+				ReviewedRefs: strSlice(art, "reviewed_refs"),
+				TestOutput:   str(art, "test_output"),
+				KnownRisks:   str(art, "known_risks"),
+				Links:        strSlice(art, "links"),
+			},
+			swarm.TestEvidence{
+				ScriptPath:   str(e, "script_path"),
+				ScriptCmd:    str(e, "script_cmd"),
+				ScriptPassed: boolVal(e, "script_passed"),
 				ScriptResult: str(e, "script_result"),
 				DocPath:      str(e, "doc_path"),
 				DocCommands:  strSlice(e, "doc_commands"),
 				DocResults:   commandResultSlice(e, "doc_results"),
 				DocPassed:    boolVal(e, "doc_passed"),
 			},
-			timeoutWithMin(intVal(args, "timeout_sec"), s.cfg.MinTimeoutSec, s.cfg.DefaultTimeoutSec),
+			s.waitTimeout(args),
 		)
 		if err != nil {
 			return nil, err
 		}
 		return addNow(out), nil
 	case "claimDelivery":
-		d, err := s.issueSvc.ClaimDelivery("acceptor", str(args, "delivery_id"), intVal(args, "extend_sec"))
+		d, err := p.issueSvc.ClaimDelivery(s.acceptorIDForArgs(args), str(args, "delivery_id"), intVal(args, "extend_sec"))
 		if err != nil {
 			return nil, err
 		}
@@ -853,9 +1469,9 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		if err != nil {
 			return nil, err
 		}
-		return addNow(out), nil
+		return addLeaseExpiresAt(addNow(out)), nil
 	case "extendDeliveryLease":
-		d, err := s.issueSvc.ExtendDeliveryLease("acceptor", str(args, "delivery_id"), intVal(args, "extend_sec"))
+		d, err := p.issueSvc.ExtendDeliveryLease(s.acceptorIDForArgs(args), str(args, "delivery_id"), intVal(args, "extend_sec"))
 		if err != nil {
 			return nil, err
 		}
@@ -863,11 +1479,11 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		if err != nil {
 			return nil, err
 		}
-		return addNow(out), nil
+		return addLeaseExpiresAt(addNow(out)), nil
 	case "reviewDelivery":
 		v := objMap(args, "verification")
-		d, err := s.issueSvc.ReviewDelivery(
-			"acceptor",
+		d, err := p.issueSvc.ReviewDelivery(ctx,
+			s.acceptorIDForArgs(args),
 			str(args, "delivery_id"),
 			str(args, "verdict"),
 			str(args, "feedback"),
@@ -877,6 +1493,7 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 				ScriptResult: str(v, "script_result"),
 				DocPassed:    boolVal(v, "doc_passed"),
 				DocResults:   commandResultSlice(v, "doc_results"),
+				CI:           ciCheckArg(v, "ci"),
 			},
 		)
 		if err != nil {
@@ -889,7 +1506,7 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		m["next_actions"] = s.getNextActions("acceptor_after_review", []string{"Next: wait for next delivery (waitDeliveries)."})
 		return addNow(m), nil
 	case "getDelivery":
-		d, err := s.issueSvc.GetDelivery(str(args, "delivery_id"))
+		d, err := p.issueSvc.GetDelivery(str(args, "delivery_id"))
 		if err != nil {
 			return nil, err
 		}
@@ -898,8 +1515,22 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 			return nil, err
 		}
 		return addNow(m), nil
+	case "getDeliveryHistory":
+		revs, err := p.issueSvc.GetDeliveryHistory(str(args, "delivery_id"), str(args, "issue_id"))
+		if err != nil {
+			return nil, err
+		}
+		out := make([]map[string]any, 0, len(revs))
+		for _, it := range revs {
+			m, err := toMap(it)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, m)
+		}
+		return addNow(map[string]any{"revisions": out}), nil
 	case "listDeliveries":
-		ds, err := s.issueSvc.ListDeliveries(
+		ds, err := p.issueSvc.ListDeliveries(
 			str(args, "status"),
 			str(args, "issue_id"),
 			str(args, "delivered_by"),
@@ -908,36 +1539,46 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		if err != nil {
 			return nil, err
 		}
-		offset := intVal(args, "offset")
-		limit := intVal(args, "limit")
-		if offset < 0 {
-			offset = 0
-		}
-		if limit <= 0 {
-			limit = 50
-		}
-		if limit > 200 {
-			limit = 200
-		}
-		if offset > len(ds) {
-			offset = len(ds)
+		// ListDeliveries returns the newest delivery first, ties in id order: the order cursors page by.
+		cur, paged, err := cursorArg(args, "deliveries", "delivered_at", "desc")
+		if err != nil {
+			return nil, err
 		}
-		end := offset + limit
-		if end > len(ds) {
-			end = len(ds)
+		if paged {
+			ds, _ = pageAfter(ds, func(d swarm.Delivery) (string, string) { return d.DeliveredAt, d.ID }, byKeyThenID(true), cur, intVal(args, "limit"))
+		} else {
+			offset := intVal(args, "offset")
+			limit := intVal(args, "limit")
+			if offset < 0 {
+				offset = 0
+			}
+			if limit <= 0 {
+				limit = 50
+			}
+			if limit > 200 {
+				limit = 200
+			}
+			if offset > len(ds) {
+				offset = len(ds)
+			}
+			end := offset + limit
+			if end > len(ds) {
+				end = len(ds)
+			}
+			ds = ds[offset:end]
 		}
-		ds = ds[offset:end]
 		out := make([]map[string]any, 0, len(ds))
 		for _, it := range ds {
 			m, err := toMap(it)
 			if err != nil {
 				return nil, err
 			}
+			m["cursor"] = pageCursor{List: "deliveries", SortBy: "delivered_at", SortOrder: "desc"}.at(it.DeliveredAt, it.ID)
 			out = append(out, addNow(m))
 		}
 		return out, nil
 	case "listOpenedDeliveries":
-		ds, err := s.issueSvc.ListDeliveries(swarm.DeliveryOpen, "", "", "")
+		ds, err := p.issueSvc.ListDeliveries(swarm.DeliveryOpen, "", "", "")
 		if err != nil {
 			return nil, err
 		}
@@ -955,8 +1596,8 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		if strings.TrimSpace(status) == "" {
 			status = swarm.DeliveryOpen
 		}
-		timeoutSec := timeoutWithMin(intVal(args, "timeout_sec"), s.cfg.MinTimeoutSec, s.cfg.DefaultTimeoutSec)
-		ds, err := s.issueSvc.WaitDeliveries(status, timeoutSec, intVal(args, "limit"))
+		timeoutSec := s.waitTimeout(args)
+		ds, err := p.issueSvc.WaitDeliveries(ctx, s.acceptorIDForArgs(args), status, timeoutSec, intVal(args, "limit"))
 		if err != nil {
 			return nil, err
 		}
@@ -975,13 +1616,23 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 			resp["next_actions"] = s.getNextActions("acceptor_after_wait_has_delivery", []string{"Next: review the claimed delivery (reviewDelivery)."})
 		}
 		return resp, nil
+	case "waitEscalations":
+		msg, err := p.issueSvc.WaitAcceptorEscalation(ctx, s.waitTimeout(args))
+		if err != nil {
+			return nil, err
+		}
+		resp := map[string]any{"escalation": msg, "server_now_ms": nowMs, "server_now": nowStr}
+		if msg == nil {
+			resp["next_actions"] = s.getNextActions("acceptor_after_wait_escalations_empty", []string{"Next: keep waiting for deliveries (waitDeliveries) or escalations (waitEscalations)."})
+		}
+		return resp, nil
 	case "getIssueAcceptanceBundle":
 		issueID := str(args, "issue_id")
-		issue, err := s.issueSvc.GetIssue(issueID)
+		issue, err := p.issueSvc.GetIssue(issueID)
 		if err != nil {
 			return nil, err
 		}
-		tasks, err := s.issueSvc.ListTasks(issueID, "")
+		tasks, err := p.issueSvc.ListTasks(issueID, "")
 		if err != nil {
 			return nil, err
 		}
@@ -989,7 +1640,7 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		if err != nil {
 			return nil, err
 		}
-		issueMap = addLeaseExpiresAt(addNow(issueMap))
+		issueMap = addLeaseExpiresAt(addNow(issueView(issueMap)))
 		// Build a compact delivery-focused summary for lead/acceptor.
 		taskSummaries := make([]map[string]any, 0, len(tasks))
 		changedFilesSet := map[string]struct{}{}
@@ -1089,7 +1740,7 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		userName, userContent := docObj(args, "user_issue_doc")
 		leadName, leadContent := docObj(args, "lead_issue_doc")
 		otherDocs := docObjSlice(args, "user_other_docs")
-		issue, err := s.issueSvc.CreateIssue(
+		issue, err := p.issueSvc.CreateIssue(
 			memberID,
 			str(args, "subject"),
 			str(args, "description"),
@@ -1100,6 +1751,8 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 			leadName,
 			leadContent,
 			otherDocs,
+			intVal(args, "points_budget"),
+			boolVal(args, "budget_strict"),
 		)
 		if err != nil {
 			return nil, err
@@ -1109,12 +1762,70 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 			return nil, err
 		}
 		return addLeaseExpiresAt(addNow(m)), nil
+	case "cloneIssue":
+		issue, tasks, err := p.issueSvc.CloneIssue(memberID, str(args, "issue_id"), str(args, "subject"))
+		if err != nil {
+			return nil, err
+		}
+		m, err := toMap(issue)
+		if err != nil {
+			return nil, err
+		}
+		m["tasks"] = tasks
+		m["cloned_from"] = str(args, "issue_id")
+		return addLeaseExpiresAt(addNow(m)), nil
+	case "exportIssue":
+		path, manifest, err := p.issueSvc.ExportIssueToFile(str(args, "issue_id"))
+		if err != nil {
+			return nil, err
+		}
+		resp := map[string]any{"path": path, "manifest": manifest}
+		if boolVal(args, "include_data") {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			if len(data) > swarm.MaxArtifactBytes {
+				return nil, swarm.Errorf(swarm.CodeLimitExceeded, "archive is %d bytes (max %d for include_data); read it from %s instead", len(data), swarm.MaxArtifactBytes, path)
+			}
+			resp["data_base64"] = base64.StdEncoding.EncodeToString(data)
+		}
+		return addNow(resp), nil
+	case "importIssue":
+		var archive io.Reader
+		switch {
+		case strings.TrimSpace(str(args, "path")) != "":
+			f, err := os.Open(strings.TrimSpace(str(args, "path")))
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			archive = f
+		case strings.TrimSpace(str(args, "data_base64")) != "":
+			data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(str(args, "data_base64")))
+			if err != nil {
+				return nil, swarm.Errorf(swarm.CodeInvalidArgument, "data_base64 is not valid base64: %w", err)
+			}
+			archive = bytes.NewReader(data)
+		default:
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "path or data_base64 is required")
+		}
+		res, err := p.issueSvc.ImportIssue(archive, boolVal(args, "preserve_id"), memberID)
+		if err != nil {
+			return nil, err
+		}
+		m, err := toMap(res)
+		if err != nil {
+			return nil, err
+		}
+		return addNow(m), nil
 	case "updateIssueDocPaths":
-		issue, err := s.issueSvc.UpdateIssueDocPaths(
+		issue, err := p.issueSvc.UpdateIssueDocPaths(
 			memberID,
 			str(args, "issue_id"),
 			strSlice(args, "shared_doc_paths"),
 			strSlice(args, "project_doc_paths"),
+			int64(intVal(args, "expected_rev")),
 		)
 		if err != nil {
 			return nil, err
@@ -1125,22 +1836,26 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		}
 		return addLeaseExpiresAt(addNow(m)), nil
 	case "createIssueTask":
-		if s.cfg.MaxTaskCount > 0 {
-			cnt, err := s.issueSvc.CountTasks(str(args, "issue_id"))
+		if p.maxTaskCount > 0 {
+			cnt, err := p.issueSvc.CountTasks(str(args, "issue_id"))
 			if err != nil {
 				return nil, err
 			}
-			if cnt >= s.cfg.MaxTaskCount {
-				return nil, fmt.Errorf("max_task_count exceeded: %d", s.cfg.MaxTaskCount)
+			if cnt >= p.maxTaskCount {
+				return nil, swarm.Errorf(swarm.CodeLimitExceeded, "max_task_count exceeded: %d", p.maxTaskCount)
 			}
 		}
 		spec := objMap(args, "spec")
-		task, err := s.issueSvc.CreateTask(
+		difficulty, calibration, err := p.issueSvc.CalibrateDifficulty(str(args, "difficulty"), boolVal(args, "calibrate_difficulty"))
+		if err != nil {
+			return nil, err
+		}
+		task, err := p.issueSvc.CreateTask(
 			memberID,
 			str(args, "issue_id"),
 			str(args, "subject"),
 			str(args, "description"),
-			str(args, "difficulty"),
+			difficulty,
 			strSlice(args, "suggested_files"),
 			strSlice(args, "labels"),
 			strSlice(args, "doc_paths"),
@@ -1164,16 +1879,25 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		if err != nil {
 			return nil, err
 		}
+		if calibration != "" {
+			m["difficulty_calibration"] = calibration
+		}
+		if b, err := p.issueSvc.GetPointsBudget(task.IssueID); err == nil && b.Budget > 0 {
+			m["points_budget"] = b
+			if b.Exceeded {
+				m["warning"] = fmt.Sprintf("points budget exceeded: tasks use %d of %d points", b.Used, b.Budget)
+			}
+		}
 		return addLeaseExpiresAt(addNow(m)), nil
 	case "claimIssueTask":
 		wid := strings.TrimSpace(str(args, "worker_id"))
 		if wid == "" {
-			return nil, fmt.Errorf("worker_id is required")
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
 		}
 		if !s.workerSvc.Exists(wid) {
-			return nil, fmt.Errorf("unknown worker_id: please call registerWorker to obtain a new worker_id")
+			return nil, swarm.Errorf(swarm.CodeNotFound, "unknown worker_id: please call registerWorker to obtain a new worker_id")
 		}
-		task, err := s.issueSvc.ClaimTask(str(args, "issue_id"), str(args, "task_id"), wid, str(args, "next_step_token"))
+		task, err := p.issueSvc.ClaimTask(str(args, "issue_id"), str(args, "task_id"), wid, str(args, "next_step_token"), s.maxClaimedForWorker(p, wid))
 		if err != nil {
 			return nil, err
 		}
@@ -1183,13 +1907,47 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		}
 		m["next_actions"] = s.getNextActions("worker_after_claim", []string{"Next: implement the task, run tests, then submitIssueTask."})
 		return addLeaseExpiresAt(addNow(m)), nil
+	case "claimNextIssueTask":
+		wid := strings.TrimSpace(str(args, "worker_id"))
+		if wid == "" {
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
+		}
+		if !s.workerSvc.Exists(wid) {
+			return nil, swarm.Errorf(swarm.CodeNotFound, "unknown worker_id: please call registerWorker to obtain a new worker_id")
+		}
+		filter := swarm.TaskFilter{
+			Labels:     strSlice(args, "labels"),
+			Difficulty: str(args, "difficulty"),
+			MinPoints:  intVal(args, "min_points"),
+			MaxPoints:  intVal(args, "max_points"),
+		}
+		task, err := p.issueSvc.ClaimNextTask(ctx, str(args, "issue_id"), wid, filter, s.maxClaimedForWorker(p, wid), s.waitTimeout(args))
+		if err != nil {
+			return nil, err
+		}
+		if task == nil {
+			return map[string]any{
+				"claimed":       false,
+				"issue_id":      str(args, "issue_id"),
+				"server_now_ms": nowMs,
+				"server_now":    nowStr,
+				"next_actions":  s.getNextActions("worker_after_claim_next_empty", []string{"Next: no matching task became claimable; call claimNextIssueTask again to keep waiting."}),
+			}, nil
+		}
+		m, err := toMap(task)
+		if err != nil {
+			return nil, err
+		}
+		m["claimed"] = true
+		m["next_actions"] = s.getNextActions("worker_after_claim", []string{"Next: implement the task, run tests, then submitIssueTask."})
+		return addLeaseExpiresAt(addNow(m)), nil
 	case "submitIssueTask":
 		art := objMap(args, "artifacts")
 		wid := strings.TrimSpace(str(args, "worker_id"))
 		if wid == "" {
-			return nil, fmt.Errorf("worker_id is required")
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
 		}
-		task, err := s.issueSvc.SubmitTask(
+		task, err := p.issueSvc.SubmitTask(ctx,
 			str(args, "issue_id"),
 			str(args, "task_id"),
 			wid,
@@ -1201,6 +1959,7 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 				TestCases:    strSlice(art, "test_cases"),
 				TestResult:   str(art, "test_result"),
 				TestOutput:   str(art, "test_output"),
+				BaseRef:      str(art, "base_ref"),
 			},
 		)
 		if err != nil {
@@ -1210,6 +1969,19 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		if err != nil {
 			return nil, err
 		}
+		comments, err := p.issueSvc.PendingSubmissionComments(task.IssueID, task.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(comments) > 0 {
+			m["submission_comments"] = comments
+			m["next_actions"] = s.getNextActions("worker_after_submit_comment", []string{
+				"Next: the lead commented on your submission before deciding (submission_comments).",
+				"Answer with addSubmissionComment (same submission_id, parent_id = the comment you answer); it waits for the lead's next comment or verdict.",
+				"If the comment asks for changes, make them and submitIssueTask again instead.",
+			})
+			return addLeaseExpiresAt(addNow(m)), nil
+		}
 		key := "worker_after_submit"
 		switch strings.TrimSpace(task.Verdict) {
 		case swarm.VerdictApproved:
@@ -1217,29 +1989,151 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		case swarm.VerdictRejected:
 			key = "worker_after_submit_rejected"
 		}
-		m["next_actions"] = s.getNextActions(key, s.getNextActions("worker_after_submit", []string{
+		m["next_actions"] = s.getNextActionsFor([]string{key, "worker_after_submit"}, []string{
 			"Next: interpret the lead review result included in this response.",
 			"If approved: follow the lead's next-step instructions (if any) or finish/stand by for further work.",
 			"If rejected: follow feedback, adjust code/tests, and submitIssueTask again.",
 			"If you need clarification: askIssueTask.",
-		}))
+		})
 		return addLeaseExpiresAt(addNow(m)), nil
+	case "addSubmissionComment":
+		role := strings.TrimSpace(s.cfg.Role)
+		actor := memberID
+		if role == "worker" {
+			actor = strings.TrimSpace(str(args, "worker_id"))
+			if actor == "" {
+				return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
+			}
+		}
+		issueID, submissionID := str(args, "issue_id"), str(args, "submission_id")
+		c, err := p.issueSvc.AddSubmissionComment(issueID, submissionID, actor, role, str(args, "parent_id"), str(args, "file_path"), str(args, "line_range"), str(args, "content"))
+		if err != nil {
+			return nil, err
+		}
+		m := map[string]any{"comment": c}
+		if role != "worker" {
+			m["next_actions"] = s.getNextActions("lead_after_submission_comment", []string{"Next: wait for the worker's answer (waitIssueTaskEvents), or reviewIssueTask once the finding is settled."})
+			return addNow(m), nil
+		}
+		sub, err := p.issueSvc.WaitSubmissionAnswer(ctx, issueID, submissionID)
+		if err != nil {
+			return nil, err
+		}
+		m["submission_status"] = sub.Status
+		if sub.Status == swarm.SubmissionOpen {
+			comments, err := p.issueSvc.PendingSubmissionComments(issueID, sub.TaskID)
+			if err != nil {
+				return nil, err
+			}
+			m["submission_comments"] = comments
+			m["next_actions"] = s.getNextActions("worker_after_submission_comment", []string{"Next: the lead answered (submission_comments); reply with addSubmissionComment, or make the requested changes and submitIssueTask again."})
+			return addNow(m), nil
+		}
+		task, err := p.issueSvc.GetTask(issueID, sub.TaskID)
+		if err != nil {
+			return nil, err
+		}
+		m["task"] = task
+		key := "worker_after_submit_rejected"
+		if sub.Status == swarm.SubmissionApproved {
+			key = "worker_after_submit_approved"
+		}
+		m["next_actions"] = s.getNextActionsFor([]string{key, "worker_after_submit"}, []string{"Next: the lead reviewed your submission (task.verdict, task.feedback); follow the review as after submitIssueTask."})
+		return addNow(m), nil
+	case "listSubmissionComments":
+		comments, err := p.issueSvc.ListSubmissionComments(str(args, "issue_id"), str(args, "submission_id"))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"comments": comments}, nil
+	case "assignPeerReviewer":
+		if !p.issueSvc.PeerReviewEnabled() {
+			return nil, swarm.Errorf(swarm.CodeUnsupported, "peer review is off (set SWARM_MCP_PEER_REVIEW=1)")
+		}
+		t, err := p.issueSvc.AssignPeerReviewer(str(args, "issue_id"), str(args, "task_id"), str(args, "reviewer_id"), memberID)
+		if err != nil {
+			return nil, err
+		}
+		m, err := toMap(t)
+		if err != nil {
+			return nil, err
+		}
+		return addNow(m), nil
+	case "waitPeerReviews":
+		wid := strings.TrimSpace(str(args, "worker_id"))
+		if wid == "" {
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
+		}
+		sub, err := p.issueSvc.WaitPeerReviews(ctx, wid, s.waitTimeout(args))
+		if err != nil {
+			return nil, err
+		}
+		resp := map[string]any{"submission": sub, "server_now_ms": nowMs, "server_now": nowStr}
+		if sub == nil {
+			resp["next_actions"] = s.getNextActions("worker_after_wait_peer_reviews_empty", []string{"Next: keep waiting for peer reviews (waitPeerReviews) or go back to your own tasks."})
+		} else {
+			resp["next_actions"] = s.getNextActions("worker_after_wait_peer_reviews_has_submission", []string{"Next: check the submission's artifacts, then peerReviewSubmission."})
+		}
+		return resp, nil
+	case "getWorkerInbox":
+		wid := strings.TrimSpace(str(args, "worker_id"))
+		if wid == "" {
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
+		}
+		items, err := p.issueSvc.GetWorkerInbox(wid, str(args, "issue_id"), boolVal(args, "include_done"), boolVal(args, "ack"))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"items": items, "server_now_ms": nowMs, "server_now": nowStr}, nil
+	case "waitWorkerInbox":
+		wid := strings.TrimSpace(str(args, "worker_id"))
+		if wid == "" {
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
+		}
+		item, err := p.issueSvc.WaitWorkerInbox(ctx, wid, str(args, "issue_id"), s.waitTimeout(args))
+		if err != nil {
+			return nil, err
+		}
+		resp := map[string]any{"item": item, "server_now_ms": nowMs, "server_now": nowStr}
+		if item == nil {
+			resp["next_actions"] = s.getNextActions("worker_after_wait_worker_inbox_empty", []string{"Next: keep waiting (waitWorkerInbox) or go back to your task."})
+		} else {
+			resp["next_actions"] = s.getNextActions("worker_after_wait_worker_inbox", []string{"Next: act on the review result or reply (fix and resubmit, or continue with next_step_token)."})
+		}
+		return resp, nil
+	case "peerReviewSubmission":
+		wid := strings.TrimSpace(str(args, "worker_id"))
+		if wid == "" {
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
+		}
+		return p.issueSvc.PeerReviewSubmission(str(args, "issue_id"), str(args, "submission_id"), wid, str(args, "verdict"), str(args, "comment"))
 	case "reviewIssueTask":
 		art := objMap(args, "artifacts")
 		fds := mapSlice(args, "feedback_details")
 		feedbackDetails := make([]swarm.FeedbackDetail, 0, len(fds))
 		for _, fd := range fds {
 			feedbackDetails = append(feedbackDetails, swarm.FeedbackDetail{
-				Dimension:  str(fd, "dimension"),
-				Severity:   str(fd, "severity"),
-				FilePath:   str(fd, "file_path"),
-				LineRange:  str(fd, "line_range"),
-				Content:    str(fd, "content"),
-				Suggestion: str(fd, "suggestion"),
+				Dimension:     str(fd, "dimension"),
+				Severity:      str(fd, "severity"),
+				FilePath:      str(fd, "file_path"),
+				LineRange:     str(fd, "line_range"),
+				Content:       str(fd, "content"),
+				Suggestion:    str(fd, "suggestion"),
+				Status:        str(fd, "status"),
+				Justification: str(fd, "justification"),
+			})
+		}
+		var checklist []swarm.ChecklistResult
+		for _, r := range mapSlice(art, "checklist") {
+			checklist = append(checklist, swarm.ChecklistResult{
+				Checklist: str(r, "checklist"),
+				Item:      str(r, "item"),
+				Result:    str(r, "result"),
+				Note:      str(r, "note"),
 			})
 		}
 		verdict := str(args, "verdict")
-		task, err := s.issueSvc.ReviewTask(
+		task, err := p.issueSvc.ReviewTask(
 			memberID,
 			str(args, "issue_id"),
 			str(args, "task_id"),
@@ -1250,9 +2144,11 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 			swarm.ReviewArtifacts{
 				ReviewSummary: str(art, "review_summary"),
 				ReviewedRefs:  strSlice(art, "reviewed_refs"),
+				Checklist:     checklist,
 			},
 			feedbackDetails,
 			str(args, "next_step_token"),
+			int64(intVal(args, "expected_rev")),
 		)
 		if err != nil {
 			return nil, err
@@ -1263,13 +2159,15 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		}
 		if verdict == swarm.VerdictApproved {
 			m["next_actions"] = s.getNextActions("lead_after_review_approved", []string{"Next: wait for next worker signal (use nextIssueSignal/selectIssueInbox)."})
+		} else if verdict == swarm.VerdictRejected && task.EscalatedAt != "" {
+			m["next_actions"] = s.getNextActions("lead_after_review_escalated", []string{"Task hit the rejection limit and is blocked: read the escalation (feedback of every round), then have an admin resetIssueTask to reassign it, reworking the spec first if it was unclear."})
 		} else if verdict == swarm.VerdictRejected {
 			m["next_actions"] = s.getNextActions("lead_after_review_rejected", []string{"Next: wait for worker follow-up (question or resubmission)."})
 		} else {
 			m["next_actions"] = s.getNextActions("lead_after_review", []string{"Next: wait for next worker signal (use nextIssueSignal/selectIssueInbox)."})
 		}
 		if verdict == swarm.VerdictApproved {
-			tasks, err := s.issueSvc.ListTasks(task.IssueID, "")
+			tasks, err := p.issueSvc.ListTasks(task.IssueID, "")
 			if err == nil {
 				allDone := len(tasks) > 0
 				for _, t := range tasks {
@@ -1289,7 +2187,7 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		}
 		return addLeaseExpiresAt(addNow(m)), nil
 	case "resetIssueTask":
-		task, err := s.issueSvc.ResetTask(memberID, str(args, "issue_id"), str(args, "task_id"), str(args, "reason"))
+		task, err := p.issueSvc.ResetTask(memberID, str(args, "issue_id"), str(args, "task_id"), str(args, "reason"), int64(intVal(args, "expected_rev")))
 		if err != nil {
 			return nil, err
 		}
@@ -1298,16 +2196,72 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 			return nil, err
 		}
 		return addLeaseExpiresAt(addNow(m)), nil
+	case "reopenIssueTask":
+		task, err := p.issueSvc.ReopenTask(memberID, str(args, "issue_id"), str(args, "task_id"), str(args, "reason"), int64(intVal(args, "expected_rev")))
+		if err != nil {
+			return nil, err
+		}
+		m, err := toMap(task)
+		if err != nil {
+			return nil, err
+		}
+		return addLeaseExpiresAt(addNow(m)), nil
+	case "updateIssueTask":
+		upd := swarm.TaskUpdate{
+			Subject:        strPtr(args, "subject"),
+			Description:    strPtr(args, "description"),
+			Points:         intPtr(args, "points"),
+			Difficulty:     strPtr(args, "difficulty"),
+			Labels:         strSlice(args, "labels"),
+			SuggestedFiles: strSlice(args, "suggested_files"),
+		}
+		task, err := p.issueSvc.UpdateTask(memberID, str(args, "issue_id"), str(args, "task_id"), upd, int64(intVal(args, "expected_rev")))
+		if err != nil {
+			return nil, err
+		}
+		m, err := toMap(task)
+		if err != nil {
+			return nil, err
+		}
+		return addLeaseExpiresAt(addNow(m)), nil
+	case "amendTaskSpec":
+		a, err := p.issueSvc.AmendTaskSpec(memberID, str(args, "issue_id"), str(args, "task_id"), str(args, "section"), str(args, "content"))
+		if err != nil {
+			return nil, err
+		}
+		m, err := toMap(a)
+		if err != nil {
+			return nil, err
+		}
+		return addNow(m), nil
 	case "getNextStepToken":
-		return s.issueSvc.GetNextStepToken(
+		return p.issueSvc.GetNextStepToken(
 			str(args, "issue_id"),
 			memberID,
 			str(args, "task_id"),
 			str(args, "worker_id"),
 			intVal(args, "completion_score"),
 		)
+	case "reserveIssueTask":
+		wid := strings.TrimSpace(str(args, "worker_id"))
+		if wid == "" {
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
+		}
+		if !s.workerSvc.Exists(wid) {
+			return nil, swarm.Errorf(swarm.CodeNotFound, "unknown worker_id '%s'", wid)
+		}
+		a, err := p.issueSvc.ReserveTask(memberID, str(args, "issue_id"), str(args, "task_id"), wid, intVal(args, "ttl_sec"))
+		if err != nil {
+			return nil, err
+		}
+		m, err := toMap(a)
+		if err != nil {
+			return nil, err
+		}
+		m["next_actions"] = s.getNextActions("lead_after_reserve", []string{"The worker finds the assignment in getWorkerInbox (or waitAssignment) and must claimIssueTask with this next_step_token before reserved_until_ms; pass the token on if it is waiting elsewhere."})
+		return addNow(m), nil
 	case "getIssueTask":
-		task, err := s.issueSvc.GetTask(str(args, "issue_id"), str(args, "task_id"))
+		task, err := p.issueSvc.GetTask(str(args, "issue_id"), str(args, "task_id"))
 		if err != nil {
 			return nil, err
 		}
@@ -1315,15 +2269,40 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		if err != nil {
 			return nil, err
 		}
+		if items := p.issueSvc.ReviewChecklistFor(task); len(items) > 0 {
+			m["review_checklist"] = items
+		}
+		if p.issueSvc.HasSubmissionRequirements() {
+			m["required_artifacts"] = p.issueSvc.RequiredSubmissionArtifacts(task)
+		}
 		return addLeaseExpiresAt(addNow(m)), nil
+	case "previewIssueTask":
+		preview, err := p.issueSvc.PreviewTask(str(args, "issue_id"), str(args, "task_id"))
+		if err != nil {
+			return nil, err
+		}
+		m, err := toMap(preview)
+		if err != nil {
+			return nil, err
+		}
+		return addNow(m), nil
 	case "listIssueTasks":
-		tasks, err := s.issueSvc.ListTasks(str(args, "issue_id"), "")
+		tasks, err := p.issueSvc.ListTasks(str(args, "issue_id"), "")
 		if err != nil {
 			return nil, err
 		}
 		tasks = filterTasks(tasks, str(args, "status"), str(args, "subject_contains"), str(args, "claimed_by"), str(args, "submitter"))
-		sortTasks(tasks, str(args, "sort_by"), str(args, "sort_order"))
-		tasks = paginateTasks(tasks, intVal(args, "offset"), intVal(args, "limit"))
+		sortBy, sortOrder := normalizeSort(str(args, "sort_by"), str(args, "sort_order"), "created_at", "updated_at", "points")
+		sortTasks(tasks, sortBy, sortOrder)
+		cur, paged, err := cursorArg(args, "tasks", sortBy, sortOrder)
+		if err != nil {
+			return nil, err
+		}
+		if paged {
+			tasks, _ = pageAfter(tasks, func(it swarm.IssueTask) (string, string) { return taskSortKey(it, sortBy), it.ID }, byKeyThenID(sortOrder == "desc"), cur, intVal(args, "limit"))
+		} else {
+			tasks = paginateTasks(tasks, intVal(args, "offset"), intVal(args, "limit"))
+		}
 		out := make([]map[string]any, 0, len(tasks))
 		for _, it := range tasks {
 			m := map[string]any{
@@ -1339,12 +2318,13 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 				"claimed_by":          it.ClaimedBy,
 				"created_at":          it.CreatedAt,
 				"updated_at":          it.UpdatedAt,
+				"cursor":              pageCursor{List: "tasks", SortBy: sortBy, SortOrder: sortOrder}.at(taskSortKey(it, sortBy), it.ID),
 			}
 			out = append(out, addLeaseExpiresAt(m))
 		}
 		return out, nil
 	case "listIssueOpenedTasks":
-		tasks, err := s.issueSvc.ListTasks(str(args, "issue_id"), swarm.IssueTaskOpen)
+		tasks, err := p.issueSvc.ListTasks(str(args, "issue_id"), swarm.IssueTaskOpen)
 		if err != nil {
 			return nil, err
 		}
@@ -1368,6 +2348,49 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 			out = append(out, addLeaseExpiresAt(m))
 		}
 		return out, nil
+	case "health":
+		return s.healthReport(ctx), nil
+	case "reloadConfig":
+		return s.nextActions.reload()
+	case "queryAuditLog":
+		entries, err := s.audit.Query(swarm.AuditQuery{
+			Actor:  strings.TrimSpace(str(args, "actor")),
+			Tool:   strings.TrimSpace(str(args, "tool")),
+			Status: strings.TrimSpace(str(args, "status")),
+			Since:  str(args, "since"),
+			Until:  str(args, "until"),
+			Limit:  intVal(args, "limit"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"entries": entries}, nil
+	case "queryTrace":
+		events, err := swarm.NewTraceService(p.store).Query(swarm.TraceQuery{
+			Type:    strings.TrimSpace(str(args, "type")),
+			Actor:   strings.TrimSpace(str(args, "actor")),
+			Subject: strings.TrimSpace(str(args, "subject")),
+			Since:   str(args, "since"),
+			Until:   str(args, "until"),
+			Limit:   intVal(args, "limit"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"events": events}, nil
+	case "subscribeIssueEvents":
+		events, nextSeq, err := p.issueSvc.SubscribeIssueEvents(ctx,
+			str(args, "issue_id"),
+			strSlice(args, "types"),
+			str(args, "task_id"),
+			int64(intVal(args, "after_seq")),
+			s.waitTimeout(args),
+			intVal(args, "limit"),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return addNow(map[string]any{"events": events, "next_after_seq": nextSeq}), nil
 	case "waitIssueTaskEvents", "selectIssueInbox", "nextIssueSignal", "stepLeadInbox":
 		// Lead passive mode: only issue_id is honored.
 		// Cursor auto-resumes per (issue_id, session_id).
@@ -1382,7 +2405,7 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 		after := int64(-1)
 		timeoutSec := s.cfg.DefaultTimeoutSec
 		limit := 50
-		events, nextSeq, err := s.issueSvc.WaitIssueTaskEvents(
+		events, nextSeq, err := p.issueSvc.WaitIssueTaskEvents(ctx,
 			str(args, "issue_id"),
 			sessActor,
 			after,
@@ -1406,23 +2429,54 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 			out["next_actions"] = s.getNextActions("lead_after_wait_message", []string{"Next: replyIssueTaskMessage, then wait for next signal."})
 		case swarm.EventSubmissionCreated:
 			out["next_actions"] = s.getNextActions("lead_after_wait_submission", []string{"Next: reviewIssueTask, then wait for next signal."})
+		case swarm.EventPeerReviewNeeded:
+			out["next_actions"] = s.getNextActions("lead_after_wait_peer_review_needed", []string{"Next: assignPeerReviewer (a worker other than the submitter), then wait for next signal."})
+		case swarm.EventSubmissionComment:
+			out["next_actions"] = s.getNextActions("lead_after_wait_submission_comment", []string{"Next: answer with addSubmissionComment (parent_id = comment_id) or settle it with reviewIssueTask, then wait for next signal."})
 		default:
 			out["next_actions"] = s.getNextActions("lead_after_wait_other", []string{"Next: handle this signal, then wait for next signal."})
 		}
 		return out, nil
+	case "peekLeadInbox":
+		peek, err := p.issueSvc.PeekLeadInbox(str(args, "issue_id"))
+		if err != nil {
+			return nil, err
+		}
+		m, err := toMap(peek)
+		if err != nil {
+			return nil, err
+		}
+		if peek.Pending == 0 {
+			m["next_actions"] = s.getNextActions("lead_after_peek_empty", []string{"Next: nothing pending; do other work or enter a long wait (waitIssueTaskEvents)."})
+		} else {
+			m["next_actions"] = s.getNextActions("lead_after_peek_pending", []string{"Next: call waitIssueTaskEvents to claim the head item; it returns immediately."})
+		}
+		return addNow(m), nil
+	case "ackInboxItem":
+		wid := strings.TrimSpace(str(args, "worker_id"))
+		if wid == "" && strings.TrimSpace(s.cfg.Role) == "worker" {
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
+		}
+		return p.issueSvc.AckInboxItem(str(args, "issue_id"), wid, str(args, "inbox_id"), leadInboxCaller())
+	case "nackInboxItem":
+		return p.issueSvc.NackInboxItem(str(args, "issue_id"), str(args, "inbox_id"), leadInboxCaller())
+	case "extendInboxClaim":
+		return p.issueSvc.ExtendInboxClaim(str(args, "issue_id"), str(args, "inbox_id"), leadInboxCaller(), intVal(args, "extend_sec"))
+	case "requeueInboxItem":
+		return p.issueSvc.RequeueInboxItem(str(args, "issue_id"), str(args, "worker_id"), str(args, "inbox_id"), str(args, "to_worker_id"), memberID)
 	case "askIssueTask":
 		wid := strings.TrimSpace(str(args, "worker_id"))
 		if wid == "" {
-			return nil, fmt.Errorf("worker_id is required")
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
 		}
-		resp, err := s.issueSvc.AskIssueTask(
+		resp, err := p.issueSvc.AskIssueTask(ctx,
 			str(args, "issue_id"),
 			str(args, "task_id"),
 			wid,
 			str(args, "kind"),
 			str(args, "content"),
 			str(args, "refs"),
-			timeoutWithMin(intVal(args, "timeout_sec"), s.cfg.MinTimeoutSec, s.cfg.DefaultTimeoutSec),
+			s.waitTimeout(args),
 		)
 		if err != nil {
 			return nil, err
@@ -1431,9 +2485,9 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 	case "postIssueTaskMessage":
 		wid := strings.TrimSpace(str(args, "worker_id"))
 		if wid == "" {
-			return nil, fmt.Errorf("worker_id is required")
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
 		}
-		return s.issueSvc.PostTaskMessage(
+		return p.issueSvc.PostTaskMessage(
 			str(args, "issue_id"),
 			str(args, "task_id"),
 			wid,
@@ -1441,8 +2495,31 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 			str(args, "content"),
 			str(args, "refs"),
 		)
+	case "escalateIssueTask":
+		wid := strings.TrimSpace(str(args, "worker_id"))
+		if wid == "" {
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
+		}
+		ev, err := p.issueSvc.EscalateTask(
+			str(args, "issue_id"),
+			str(args, "task_id"),
+			wid,
+			str(args, "reason"),
+			str(args, "content"),
+			str(args, "refs"),
+			boolVal(args, "notify_acceptor"),
+		)
+		if err != nil {
+			return nil, err
+		}
+		m, err := toMap(ev)
+		if err != nil {
+			return nil, err
+		}
+		m["next_actions"] = s.getNextActions("worker_after_escalate", []string{"Next: wait for the lead's reply; the task returns to in_progress (getIssueTask) and the lease does not run down meanwhile."})
+		return m, nil
 	case "replyIssueTaskMessage":
-		ev, err := s.issueSvc.ReplyTaskMessage(
+		ev, err := p.issueSvc.ReplyTaskMessage(
 			str(args, "issue_id"),
 			str(args, "task_id"),
 			memberID,
@@ -1462,7 +2539,12 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 
 	// === Workers ===
 	case "registerWorker":
-		return s.workerSvc.Register("")
+		w, err := s.workerSvc.Register("", strSlice(args, "skills"))
+		if err != nil {
+			return nil, err
+		}
+		s.scheduleAll()
+		return w, nil
 	case "listWorkers":
 		return s.workerSvc.List()
 	case "getWorker":
@@ -1470,97 +2552,219 @@ func (s *Server) dispatch(tool string, args map[string]any) (any, error) {
 
 	// === Docs ===
 	case "writeSharedDoc":
-		return s.docsSvc.WriteSharedDoc(str(args, "name"), str(args, "content"))
+		return p.docsSvc.WriteSharedDoc(str(args, "name"), str(args, "content"), intVal(args, "base_version"))
 	case "readSharedDoc":
-		return s.docsSvc.ReadSharedDoc(str(args, "name"))
+		return p.docsSvc.ReadSharedDoc(str(args, "name"))
 	case "listSharedDocs":
-		return s.docsSvc.ListSharedDocs()
+		return p.docsSvc.ListSharedDocs()
 	case "writeIssueDoc":
-		return s.docsSvc.WriteIssueDoc(str(args, "issue_id"), str(args, "name"), str(args, "content"))
+		return p.docsSvc.WriteIssueDoc(str(args, "issue_id"), str(args, "name"), str(args, "content"), intVal(args, "base_version"))
 	case "readIssueDoc":
-		return s.docsSvc.ReadIssueDoc(str(args, "issue_id"), str(args, "name"))
+		return p.docsSvc.ReadIssueDoc(str(args, "issue_id"), str(args, "name"))
 	case "listIssueDocs":
-		return s.docsSvc.ListIssueDocs(str(args, "issue_id"))
+		return p.docsSvc.ListIssueDocs(str(args, "issue_id"))
 	case "writeTaskDoc":
-		return s.docsSvc.WriteTaskDoc(str(args, "issue_id"), str(args, "task_id"), str(args, "name"), str(args, "content"))
+		return p.docsSvc.WriteTaskDoc(str(args, "issue_id"), str(args, "task_id"), str(args, "name"), str(args, "content"), intVal(args, "base_version"))
 	case "readTaskDoc":
-		return s.docsSvc.ReadTaskDoc(str(args, "issue_id"), str(args, "task_id"), str(args, "name"))
+		return p.docsSvc.ReadTaskDoc(str(args, "issue_id"), str(args, "task_id"), str(args, "name"))
 	case "listTaskDocs":
-		return s.docsSvc.ListTaskDocs(str(args, "issue_id"), str(args, "task_id"))
+		return p.docsSvc.ListTaskDocs(str(args, "issue_id"), str(args, "task_id"))
+	case "attachArtifact":
+		actor := memberID
+		if wid := strings.TrimSpace(str(args, "worker_id")); wid != "" {
+			actor = wid
+		}
+		return p.issueSvc.AttachArtifact(
+			actor,
+			str(args, "issue_id"),
+			str(args, "task_id"),
+			str(args, "name"),
+			str(args, "content_type"),
+			str(args, "description"),
+			str(args, "data_base64"),
+		)
+	case "getArtifact":
+		issueID := str(args, "issue_id")
+		artifactID := strings.TrimPrefix(str(args, "artifact_id"), swarm.ArtifactRefPrefix+issueID+"/")
+		withData := true
+		if _, ok := args["include_data"]; ok {
+			withData = boolVal(args, "include_data")
+		}
+		a, data, err := p.issueSvc.GetArtifact(issueID, artifactID, withData)
+		if err != nil {
+			return nil, err
+		}
+		m, err := toMap(a)
+		if err != nil {
+			return nil, err
+		}
+		if withData {
+			m["data_base64"] = data
+		}
+		return m, nil
+	case "listArtifacts":
+		artifacts, err := p.issueSvc.ListArtifacts(str(args, "issue_id"), str(args, "task_id"))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"artifacts": artifacts}, nil
+	case "searchDocs":
+		hits, err := p.docsSvc.SearchDocs(ctx, str(args, "scope"), str(args, "issue_id"), str(args, "task_id"), str(args, "query"), intVal(args, "limit"))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"docs": hits}, nil
+	case "listDocVersions":
+		versions, err := p.docsSvc.ListDocVersions(docTargetArg(args))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"versions": versions}, nil
+	case "readDocVersion":
+		return p.docsSvc.ReadDocVersion(docTargetArg(args), intVal(args, "version"))
+	case "diffDocs":
+		from, to := objMap(args, "from"), objMap(args, "to")
+		return p.docsSvc.DiffDocs(docTargetArg(from), intVal(from, "version"), docTargetArg(to), intVal(to, "version"), intVal(args, "context_lines"))
+	case "rollbackDoc":
+		target := docTargetArg(args)
+		if !docScopeWritable(s.cfg.Role, target.Scope) {
+			return nil, swarm.Errorf(swarm.CodePermissionDenied, "role '%s' cannot write %s docs", s.cfg.Role, target.Scope)
+		}
+		return p.docsSvc.RollbackDoc(target, intVal(args, "version"), intVal(args, "base_version"))
 
 	// Lock
 	case "lockFiles":
 		wid := strings.TrimSpace(str(args, "worker_id"))
 		if wid == "" {
-			return nil, fmt.Errorf("worker_id is required")
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
 		}
 		issueID := strings.TrimSpace(str(args, "issue_id"))
 		taskID := strings.TrimSpace(str(args, "task_id"))
 		if taskID != "" {
 			if issueID == "" {
-				return nil, fmt.Errorf("issue_id is required when task_id is provided")
+				return nil, swarm.Errorf(swarm.CodeInvalidArgument, "issue_id is required when task_id is provided")
 			}
-			task, err := s.issueSvc.GetTask(issueID, taskID)
+			task, err := p.issueSvc.GetTask(issueID, taskID)
 			if err != nil {
 				return nil, err
 			}
 			if strings.TrimSpace(task.ClaimedBy) != wid {
-				return nil, fmt.Errorf("task '%s' is not claimed by worker_id", taskID)
+				return nil, swarm.Errorf(swarm.CodeNotOwner, "task '%s' is not claimed by worker_id", taskID)
 			}
 		}
-		return s.lockSvc.LockFiles(
+		return leaseResult(p.lockSvc.LockFiles(ctx,
 			taskID,
 			wid,
+			str(args, "scope"),
 			strSlice(args, "files"),
 			intVal(args, "ttl_sec"),
 			intVal(args, "wait_sec"),
-		)
+		))
 	case "heartbeat":
 		wid := strings.TrimSpace(str(args, "worker_id"))
 		if wid == "" {
-			return nil, fmt.Errorf("worker_id is required")
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
 		}
 		leaseID := strings.TrimSpace(str(args, "lease_id"))
-		lease, err := s.lockSvc.GetLease(leaseID)
+		lease, err := p.lockSvc.GetLease(leaseID)
 		if err != nil {
 			return nil, err
 		}
 		if strings.TrimSpace(lease.Owner) != wid {
-			return nil, fmt.Errorf("lease '%s' is not owned by worker_id", leaseID)
+			return nil, swarm.Errorf(swarm.CodeNotOwner, "lease '%s' is not owned by worker_id", leaseID)
 		}
-		return s.lockSvc.Heartbeat(leaseID, intVal(args, "extend_sec"))
+		return leaseResult(p.lockSvc.Heartbeat(leaseID, intVal(args, "extend_sec")))
+	case "heartbeatAll":
+		wid := strings.TrimSpace(str(args, "worker_id"))
+		if wid == "" {
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
+		}
+		leases, err := p.lockSvc.HeartbeatAll(wid, intVal(args, "extend_sec"))
+		if err != nil {
+			return nil, err
+		}
+		out := make([]map[string]any, 0, len(leases))
+		for i := range leases {
+			m, err := leaseMap(&leases[i])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, m)
+		}
+		return addClockSkew(addNow(map[string]any{"leases": out, "count": len(out)})), nil
 	case "unlock":
 		wid := strings.TrimSpace(str(args, "worker_id"))
 		if wid == "" {
-			return nil, fmt.Errorf("worker_id is required")
+			return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
 		}
 		leaseID := strings.TrimSpace(str(args, "lease_id"))
-		lease, err := s.lockSvc.GetLease(leaseID)
+		lease, err := p.lockSvc.GetLease(leaseID)
 		if err != nil {
 			return nil, err
 		}
 		if strings.TrimSpace(lease.Owner) != wid {
-			return nil, fmt.Errorf("lease '%s' is not owned by worker_id", leaseID)
+			return nil, swarm.Errorf(swarm.CodeNotOwner, "lease '%s' is not owned by worker_id", leaseID)
 		}
-		return nil, s.lockSvc.Unlock(leaseID)
+		return nil, p.lockSvc.Unlock(leaseID)
 	case "listLocks":
 		owner := strings.TrimSpace(str(args, "owner"))
 		if strings.TrimSpace(s.cfg.Role) == "worker" {
 			wid := strings.TrimSpace(str(args, "worker_id"))
 			if wid == "" {
-				return nil, fmt.Errorf("worker_id is required")
+				return nil, swarm.Errorf(swarm.CodeInvalidArgument, "worker_id is required")
 			}
 			// Default to self to avoid leaking global lock state.
 			if owner == "" {
 				owner = wid
 			}
 		}
-		return s.lockSvc.ListLocks(owner, strSlice(args, "files"))
+		return p.lockSvc.ListLocks(owner, str(args, "scope"), strSlice(args, "files"))
+	case "listLockWaiters":
+		queues, err := p.lockSvc.ListLockWaiters(str(args, "scope"), strSlice(args, "files"))
+		if err != nil {
+			return nil, err
+		}
+		return addNow(map[string]any{"queues": queues}), nil
 	case "forceUnlock":
-		return nil, s.lockSvc.ForceUnlock(str(args, "lease_id"), str(args, "reason"))
+		return nil, p.lockSvc.ForceUnlock(str(args, "lease_id"), str(args, "reason"))
+	case "sweepExpired":
+		p.issueSvc.SweepExpired()
+		n, err := p.lockSvc.CleanExpired()
+		if err != nil {
+			return nil, err
+		}
+		return addNow(map[string]any{"locks_cleaned": n}), nil
+	case "runRetention":
+		if !s.cfg.Retention.Enabled() {
+			return nil, swarm.Errorf(swarm.CodeInvalidState, "no retention rule is configured (set [retention] closed_issue_days or trace_max_mb)")
+		}
+		report, err := p.issueSvc.ApplyRetention(s.cfg.Retention)
+		if err != nil {
+			return nil, err
+		}
+		return report, nil
+	case "fsckStore":
+		check := swarm.Fsck
+		if boolVal(args, "repair") {
+			check = swarm.FsckRepair
+		}
+		problems, err := check(p.store)
+		if err != nil {
+			return nil, err
+		}
+		remaining := 0
+		for _, pr := range problems {
+			if !pr.Repaired {
+				remaining++
+			}
+		}
+		if problems == nil {
+			problems = []swarm.FsckProblem{}
+		}
+		return addNow(map[string]any{"problems": problems, "remaining": remaining}), nil
 
 	default:
-		return nil, fmt.Errorf("unknown tool: %s", tool)
+		return nil, swarm.Errorf(swarm.CodeInvalidArgument, "unknown tool: %s", tool)
 	}
 }
 
@@ -1596,6 +2800,42 @@ func boolVal(args map[string]any, key string) bool {
 	return v
 }
 
+func docTargetArg(args map[string]any) swarm.DocTarget {
+	return swarm.DocTarget{
+		Scope:   str(args, "scope"),
+		IssueID: str(args, "issue_id"),
+		TaskID:  str(args, "task_id"),
+		Name:    str(args, "name"),
+	}
+}
+
+// docScopeWritable mirrors the write tools each role is given (writeIssueDoc for lead, writeTaskDoc for worker).
+func docScopeWritable(role, scope string) bool {
+	switch strings.TrimSpace(role) {
+	case "lead":
+		return scope == swarm.DocScopeIssue
+	case "worker":
+		return scope == swarm.DocScopeTask
+	case "acceptor":
+		return false
+	default:
+		return true
+	}
+}
+
+func ciCheckArg(args map[string]any, key string) *swarm.CICheck {
+	m := objMap(args, key)
+	if len(m) == 0 {
+		return nil
+	}
+	return &swarm.CICheck{
+		Provider:   str(m, "provider"),
+		RunURL:     str(m, "run_url"),
+		CommitSHA:  str(m, "commit_sha"),
+		TimeoutSec: intVal(m, "timeout_sec"),
+	}
+}
+
 func commandResultSlice(args map[string]any, key string) []swarm.CommandResult {
 	raw, ok := args[key].([]any)
 	if !ok {
@@ -1657,6 +2897,25 @@ func int64Val(args map[string]any, key string) int64 {
 	}
 }
 
+// strPtr returns args[key] if it is a string, else nil, so callers can tell an omitted field from "".
+func strPtr(args map[string]any, key string) *string {
+	v, ok := args[key].(string)
+	if !ok {
+		return nil
+	}
+	return &v
+}
+
+// intPtr returns args[key] if it is a number, else nil.
+func intPtr(args map[string]any, key string) *int {
+	switch args[key].(type) {
+	case float64, int:
+		v := intVal(args, key)
+		return &v
+	}
+	return nil
+}
+
 func timeoutWithMin(timeoutSec int, minTimeoutSec int, defaultTimeoutSec int) int {
 	if defaultTimeoutSec <= 0 {
 		defaultTimeoutSec = 3600
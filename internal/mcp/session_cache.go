@@ -0,0 +1,106 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// sessionCache remembers gateway validation results so session-gated calls do not pay an HTTP round
+// trip each time. Valid results live for the positive TTL, invalid ones for the (shorter) negative TTL;
+// gateway errors are never cached. When the gateway is unreachable, a session validated within the
+// grace window is still accepted (degraded mode).
+type sessionCache struct {
+	positiveTTL time.Duration
+	negativeTTL time.Duration
+	grace       time.Duration
+
+	mu      sync.Mutex
+	entries map[string]sessionCacheEntry
+}
+
+type sessionCacheEntry struct {
+	valid     bool
+	checkedAt time.Time
+}
+
+const sessionCachePruneAt = 1024
+
+func newSessionCache(gw GatewayConfig) *sessionCache {
+	return &sessionCache{
+		positiveTTL: time.Duration(gw.CacheTTLSec) * time.Second,
+		negativeTTL: time.Duration(gw.NegativeCacheTTLSec) * time.Second,
+		grace:       time.Duration(gw.GraceSec) * time.Second,
+		entries:     map[string]sessionCacheEntry{},
+	}
+}
+
+// keep is how long an entry is useful: its TTL, or the grace window for valid sessions.
+func (c *sessionCache) keep(e sessionCacheEntry) time.Duration {
+	if e.valid {
+		return max(c.positiveTTL, c.grace)
+	}
+	return c.negativeTTL
+}
+
+// get returns the cached result for sessionID if it has not expired.
+func (c *sessionCache) get(sessionID string, now time.Time) (valid, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, found := c.entries[sessionID]
+	ttl := c.negativeTTL
+	if e.valid {
+		ttl = c.positiveTTL
+	}
+	if !found || now.Sub(e.checkedAt) >= ttl {
+		return false, false
+	}
+	return e.valid, true
+}
+
+// withinGrace reports whether sessionID was validated by the gateway less than the grace window ago.
+func (c *sessionCache) withinGrace(sessionID string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, found := c.entries[sessionID]
+	return found && e.valid && now.Sub(e.checkedAt) < c.grace
+}
+
+func (c *sessionCache) put(sessionID string, valid bool, now time.Time) {
+	e := sessionCacheEntry{valid: valid, checkedAt: now}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.keep(e) <= 0 {
+		delete(c.entries, sessionID)
+		return
+	}
+	if len(c.entries) >= sessionCachePruneAt {
+		for k, old := range c.entries {
+			if now.Sub(old.checkedAt) >= c.keep(old) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	c.entries[sessionID] = e
+}
+
+// validateSession checks sessionID against the gateway, consulting the cache first. If the gateway fails
+// and the session is within the grace window it is accepted, and the degradation is noted on call.
+func (s *Server) validateSession(ctx context.Context, call *toolCall, sessionID string) (bool, error) {
+	now := time.Now()
+	if valid, ok := s.sessionCache.get(sessionID, now); ok {
+		return valid, nil
+	}
+	valid, err := validateSemanticSessionViaGateway(ctx, s.cfg.Gateway, sessionID)
+	if err != nil {
+		if !s.sessionCache.withinGrace(sessionID, now) {
+			return false, err
+		}
+		call.Degraded = fmt.Sprintf("session_grace: accepted previously validated session while gateway failed: %v", err)
+		s.cfg.Logger.Printf("WARNING: %s", call.Degraded)
+		return true, nil
+	}
+	s.sessionCache.put(sessionID, valid, now)
+	return valid, nil
+}
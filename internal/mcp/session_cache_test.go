@@ -0,0 +1,78 @@
+package mcp
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSessionCache_GraceOutlivesTheCacheTTL(t *testing.T) {
+	c := newSessionCache(GatewayConfig{GraceSec: 60})
+	now := time.Now()
+	c.put("valid", true, now)
+	c.put("invalid", false, now)
+
+	if _, ok := c.get("valid", now.Add(time.Second)); ok {
+		t.Fatal("without a cache TTL every call must go to the gateway")
+	}
+	if !c.withinGrace("valid", now.Add(59*time.Second)) {
+		t.Fatal("a session validated 59s ago is within a 60s grace window")
+	}
+	if c.withinGrace("valid", now.Add(60*time.Second)) {
+		t.Fatal("the grace window must end")
+	}
+	if c.withinGrace("invalid", now) || c.withinGrace("unknown", now) {
+		t.Fatal("only sessions the gateway validated get the grace window")
+	}
+}
+
+func TestValidateSession_FallsBackToGraceWhileTheGatewayFails(t *testing.T) {
+	var down atomic.Bool
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "gateway down", http.StatusBadGateway)
+			return
+		}
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"{\"valid\":true}"}]}}`)
+	}))
+	defer gateway.Close()
+
+	newServer := func(graceSec int) *Server {
+		gw := GatewayConfig{URL: gateway.URL, GraceSec: graceSec}
+		return &Server{cfg: ServerConfig{Logger: log.New(io.Discard, "", 0), Gateway: gw}, sessionCache: newSessionCache(gw)}
+	}
+	ctx := context.Background()
+
+	s := newServer(60)
+	down.Store(false)
+	if ok, err := s.validateSession(ctx, &toolCall{}, "sess-1"); err != nil || !ok {
+		t.Fatalf("gateway up: %v %v", ok, err)
+	}
+	down.Store(true)
+	call := &toolCall{}
+	if ok, err := s.validateSession(ctx, call, "sess-1"); err != nil || !ok {
+		t.Fatalf("expected the recently validated session to be accepted, got %v %v", ok, err)
+	}
+	if !strings.HasPrefix(call.Degraded, "session_grace:") {
+		t.Fatalf("the degraded call must be noted for the audit log, got %q", call.Degraded)
+	}
+	if _, err := s.validateSession(ctx, &toolCall{}, "sess-never-seen"); err == nil {
+		t.Fatal("a session the gateway never validated must be rejected while it is down")
+	}
+
+	closed := newServer(0)
+	down.Store(false)
+	if _, err := closed.validateSession(ctx, &toolCall{}, "sess-1"); err != nil {
+		t.Fatal(err)
+	}
+	down.Store(true)
+	if _, err := closed.validateSession(ctx, &toolCall{}, "sess-1"); err == nil {
+		t.Fatal("without a grace window a gateway failure must fail the call")
+	}
+}
@@ -20,12 +20,16 @@ func toolRequiresSession(role string, tool string) bool {
 		// From claim task and after.
 		switch tool {
 		case "claimIssueTask",
+			"claimNextIssueTask",
 			"extendIssueTaskLease",
 			"lockFiles",
 			"heartbeat",
+			"heartbeatAll",
 			"unlock",
 			"askIssueTask",
+			"escalateIssueTask",
 			"submitIssueTask",
+			"addSubmissionComment",
 			"listTaskDocs",
 			"readTaskDoc",
 			"writeTaskDoc",
@@ -0,0 +1,41 @@
+package mcp
+
+// Short waits. Long-poll timeouts are raised to MinTimeoutSec so agents cannot end a session early with a
+// short poll. When the server runs with AllowShortTimeouts (for interactive tooling), a call that passes
+// allow_short=true gets the timeout_sec it asked for.
+
+const allowShortArg = "allow_short"
+
+// injectAllowShortIntoTools adds the allow_short flag to every tool that takes a timeout_sec. Only used
+// when AllowShortTimeouts is on.
+func injectAllowShortIntoTools(tools []ToolDefinition) []ToolDefinition {
+	for _, t := range tools {
+		m, ok := t.InputSchema.(map[string]any)
+		if !ok {
+			continue
+		}
+		props, ok := m["properties"].(map[string]any)
+		if !ok || props["timeout_sec"] == nil {
+			continue
+		}
+		props[allowShortArg] = map[string]any{
+			"type":        "boolean",
+			"description": "Honor a timeout_sec below the server minimum instead of raising it (for interactive tooling).",
+		}
+	}
+	return tools
+}
+
+// allowShort reports whether the call asked for, and may have, a short timeout.
+func (s *Server) allowShort(args map[string]any) bool {
+	return s.cfg.AllowShortTimeouts && boolVal(args, allowShortArg)
+}
+
+// waitTimeout is the long-poll timeout for a call: timeout_sec as given when allowShort, otherwise
+// clamped by timeoutWithMin.
+func (s *Server) waitTimeout(args map[string]any) int {
+	if t := intVal(args, "timeout_sec"); t > 0 && s.allowShort(args) {
+		return t
+	}
+	return timeoutWithMin(intVal(args, "timeout_sec"), s.cfg.MinTimeoutSec, s.cfg.DefaultTimeoutSec)
+}
@@ -0,0 +1,40 @@
+package mcp
+
+import "time"
+
+// Expiry sweeper. Issue, task and delivery expiry used to be swept only as a side effect of tool calls,
+// so an idle server let expired claims and leases sit until the next call. This routine sweeps the root
+// namespace and every configured project every SweepIntervalSec; sweepExpired still forces a pass.
+
+const defaultSweepIntervalSec = 30
+
+// startSweeper runs the expiry sweep in the background.
+func (s *Server) startSweeper() {
+	interval := s.cfg.SweepIntervalSec
+	if interval <= 0 {
+		interval = defaultSweepIntervalSec
+	}
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.sweepExpired()
+		}
+	}()
+}
+
+// sweepExpired runs one expiry pass over the root namespace and every configured project.
+func (s *Server) sweepExpired() {
+	scopes := []*projectScope{s.rootScope()}
+	for _, key := range s.projectKeys() {
+		if p, err := s.scopeFor("", map[string]any{"project": key}); err == nil {
+			scopes = append(scopes, p)
+		}
+	}
+	for _, p := range scopes {
+		p.issueSvc.SweepExpired()
+		if _, err := p.lockSvc.CleanExpired(); err != nil {
+			s.cfg.Logger.Printf("WARNING: lock sweep in %q: %v", p.key, err)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tool behaviour hints (MCP 2025-03-26 annotations), so client harnesses can auto-approve reads and ask
+// before destructive calls. They are hints only: the server enforces nothing from them.
+//
+// readOnlyHint    the tool only reads swarm state. Waits are not read-only: they claim or consume items.
+// destructiveHint the tool discards work or another member's state that the store cannot give back.
+// idempotentHint  repeating the call with the same arguments has no further effect.
+// openWorldHint   the tool may reach outside the swarm store (GitHub mirroring and CI checks).
+
+// ToolAnnotations are the hints sent with a tool in tools/list. The four hints are always sent because the
+// spec defaults destructiveHint and openWorldHint to true.
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    bool   `json:"readOnlyHint"`
+	DestructiveHint bool   `json:"destructiveHint"`
+	IdempotentHint  bool   `json:"idempotentHint"`
+	OpenWorldHint   bool   `json:"openWorldHint"`
+}
+
+// readOnlyToolPrefixes name tools that only read swarm state.
+var readOnlyToolPrefixes = []string{"get", "list", "peek", "search", "query", "read", "preview", "diff"}
+
+// notReadOnlyTools are exceptions to readOnlyToolPrefixes.
+var notReadOnlyTools = map[string]bool{
+	"getNextStepToken": true, // mints a token and reserves a task
+	"getWorkerInbox":   true, // ack=true marks items done
+	"listDocVersions":  true, // records the current doc as v1 on first use
+	"readDocVersion":   true, // same
+}
+
+var readOnlyTools = map[string]bool{"health": true, "swarmNow": true, "myProfile": true}
+
+var destructiveTools = map[string]bool{
+	"forceUnlock":      true, // breaks another worker's lease
+	"resetIssueTask":   true, // clears the worker's progress and artifacts
+	"runRetention":     true, // removes closed issues from the store
+	"fsckStore":        true, // repair=true quarantines or removes records
+	"requeueInboxItem": true, // drops the claim of whoever is working on the item
+	"removeIssueLead":  true, // unregisters a co-lead and takes back its inbox items
+}
+
+var idempotentTools = map[string]bool{
+	"ackInboxItem":         true,
+	"nackInboxItem":        true,
+	"heartbeat":            true,
+	"heartbeatAll":         true,
+	"unlock":               true,
+	"pauseIssue":           true,
+	"resumeIssue":          true,
+	"reloadConfig":         true,
+	"sweepExpired":         true,
+	"setIssueNextActions":  true,
+	"addIssueLead":         true,
+	"setIssueClaimChecks":  true,
+	"updateIssueDocPaths":  true,
+	"extendIssueLease":     true, // leases are renewed to now + TTL, not extended cumulatively
+	"extendIssueTaskLease": true,
+	"extendDeliveryLease":  true,
+	"extendInboxClaim":     true,
+	"listDocVersions":      true,
+	"readDocVersion":       true,
+}
+
+// openWorldTools are mirrored to GitHub Issues when configured, or check GitHub Actions runs.
+var openWorldTools = map[string]bool{
+	"createIssue":     true,
+	"closeIssue":      true,
+	"reopenIssue":     true,
+	"reviewIssueTask": true,
+	"reviewDelivery":  true,
+}
+
+func toolAnnotations(name string) *ToolAnnotations {
+	readOnly := readOnlyTools[name]
+	if !notReadOnlyTools[name] {
+		for _, p := range readOnlyToolPrefixes {
+			if strings.HasPrefix(name, p) {
+				readOnly = true
+				break
+			}
+		}
+	}
+	return &ToolAnnotations{
+		Title:           toolTitle(name),
+		ReadOnlyHint:    readOnly,
+		DestructiveHint: !readOnly && destructiveTools[name],
+		IdempotentHint:  readOnly || idempotentTools[name],
+		OpenWorldHint:   openWorldTools[name],
+	}
+}
+
+// toolTitle spells a camelCase tool name as words: "listIssueTasks" -> "List issue tasks".
+func toolTitle(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case i == 0:
+			b.WriteRune(unicode.ToUpper(r))
+		case unicode.IsUpper(r):
+			b.WriteByte(' ')
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// annotateTools sets Annotations on every tool. Only used from protocol 2025-03-26 on.
+func annotateTools(tools []ToolDefinition) []ToolDefinition {
+	for i := range tools {
+		tools[i].Annotations = toolAnnotations(tools[i].Name)
+	}
+	return tools
+}
@@ -0,0 +1,25 @@
+package mcp
+
+import "testing"
+
+func TestToolAnnotations(t *testing.T) {
+	cases := []struct {
+		tool                              string
+		readOnly, destructive, idempotent bool
+	}{
+		{"listIssues", true, false, true},
+		{"getWorkerInbox", false, false, false},
+		{"listDocVersions", false, false, true},
+		{"readDocVersion", false, false, true},
+		{"forceUnlock", false, true, false},
+		{"requeueInboxItem", false, true, false},
+		{"removeIssueLead", false, true, false},
+		{"heartbeat", false, false, true},
+	}
+	for _, c := range cases {
+		a := toolAnnotations(c.tool)
+		if a.ReadOnlyHint != c.readOnly || a.DestructiveHint != c.destructive || a.IdempotentHint != c.idempotent {
+			t.Errorf("%s: got readOnly=%v destructive=%v idempotent=%v", c.tool, a.ReadOnlyHint, a.DestructiveHint, a.IdempotentHint)
+		}
+	}
+}
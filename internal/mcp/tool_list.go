@@ -0,0 +1,162 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// tools/list serving. The role's tool definitions only change with the settings that shape them (role,
+// role code, projects, allow_short, completion scores, protocol revision), so they are built and
+// marshalled once per combination of those and reused. Clients may page with the MCP cursor; with
+// ToolsPageSize 0 every tool fits on one page. When the settings behind a list the client already fetched
+// change, it is sent notifications/tools/list_changed.
+
+type toolListCache struct {
+	mu     sync.Mutex
+	key    string
+	names  []string
+	tools  []json.RawMessage
+	listed string // key of the last list sent to the client ("" = none since the last notification)
+}
+
+// toolListKey fingerprints the settings the tool definitions are built from.
+func (s *Server) toolListKey() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s|%s|%s|%s|%t|%t|%t", s.cfg.Role, s.expectedRoleCode(), strings.Join(s.projectKeys(), ","), s.cfg.Project,
+		s.cfg.AllowShortTimeouts, s.protocolAtLeast(protocol20250326), s.protocolAtLeast(protocol20250618))
+	for _, c := range s.issueSvc.CompletionScores() {
+		fmt.Fprintf(&b, "|%d=%s", c.Value, c.Label)
+	}
+	return b.String()
+}
+
+// buildToolList computes the role's tool definitions.
+func (s *Server) buildToolList() []ToolDefinition {
+	tools := injectProjectIntoTools(allToolsForRole(s.cfg.Role, s.expectedRoleCode()), s.projectKeys(), s.cfg.Project)
+	if s.cfg.AllowShortTimeouts {
+		tools = injectAllowShortIntoTools(tools)
+	}
+	tools = injectCompletionScores(tools, s.issueSvc.CompletionScores())
+	if s.protocolAtLeast(protocol20250326) {
+		tools = annotateTools(tools)
+	}
+	if s.protocolAtLeast(protocol20250618) {
+		tools = injectOutputSchemas(tools)
+	}
+	return tools
+}
+
+// toolList returns the cached tool names and definitions, rebuilding them when the settings changed, and
+// records that the client has seen this list.
+func (s *Server) toolList() ([]string, []json.RawMessage) {
+	key := s.toolListKey()
+	c := &s.toolCache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.key != key || c.tools == nil {
+		defs := s.buildToolList()
+		c.names = make([]string, 0, len(defs))
+		c.tools = make([]json.RawMessage, 0, len(defs))
+		for _, t := range defs {
+			raw, err := json.Marshal(t)
+			if err != nil {
+				continue
+			}
+			c.names = append(c.names, t.Name)
+			c.tools = append(c.tools, raw)
+		}
+		c.key = key
+	}
+	c.listed = key
+	return c.names, c.tools
+}
+
+// checkToolsChanged sends notifications/tools/list_changed once when the client fetched the tool list and
+// the settings behind it have changed since.
+func (s *Server) checkToolsChanged() {
+	c := &s.toolCache
+	c.mu.Lock()
+	changed := c.listed != "" && c.listed != s.toolListKey()
+	if changed {
+		c.listed = ""
+	}
+	c.mu.Unlock()
+	if changed && s.notify != nil {
+		s.cfg.Logger.Printf("tool list changed; notifying client")
+		s.notify(map[string]any{"jsonrpc": "2.0", "method": "notifications/tools/list_changed"})
+	}
+}
+
+// handleToolsList serves one page of tools/list, honoring params.cursor and params.disabledTools.
+func (s *Server) handleToolsList(id any, params any) JSONRPCResponse {
+	pm, _ := params.(map[string]any)
+	disabled := map[string]struct{}{}
+	if v, ok := pm["disabledTools"]; ok && v != nil {
+		switch xs := v.(type) {
+		case []any:
+			for _, it := range xs {
+				n := strings.TrimSpace(fmt.Sprint(it))
+				if n != "" {
+					disabled[n] = struct{}{}
+				}
+			}
+		case []string:
+			for _, it := range xs {
+				n := strings.TrimSpace(it)
+				if n != "" {
+					disabled[n] = struct{}{}
+				}
+			}
+		default:
+			// ignore invalid types
+		}
+	}
+	start := 0
+	if cur, _ := pm["cursor"].(string); cur != "" {
+		n, ok := decodeToolsCursor(cur)
+		if !ok {
+			return NewErrorResponse(id, ErrInvalidParams, "invalid cursor", cur)
+		}
+		start = n
+	}
+
+	names, raw := s.toolList()
+	tools := make([]json.RawMessage, 0, len(raw))
+	for i, t := range raw {
+		if _, ok := disabled[names[i]]; !ok {
+			tools = append(tools, t)
+		}
+	}
+	start = min(start, len(tools))
+	end := len(tools)
+	if size := s.cfg.ToolsPageSize; size > 0 {
+		end = min(start+size, len(tools))
+	}
+	result := map[string]any{"tools": tools[start:end]}
+	if end < len(tools) {
+		result["nextCursor"] = encodeToolsCursor(end)
+	}
+	return NewResultResponse(id, result)
+}
+
+// Cursors are opaque to clients; they encode the offset of the next page.
+func encodeToolsCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("tools:" + strconv.Itoa(offset)))
+}
+
+func decodeToolsCursor(cur string) (int, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(cur)
+	if err != nil {
+		return 0, false
+	}
+	rest, ok := strings.CutPrefix(string(b), "tools:")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(rest)
+	return n, err == nil && n >= 0
+}
@@ -1,6 +1,12 @@
 package mcp
 
-import "strings"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cookchen233/swarm-mcp/internal/swarm"
+)
 
 func allTools() []ToolDefinition {
 	return []ToolDefinition{
@@ -26,7 +32,8 @@ func allTools() []ToolDefinition {
 				prop("session_id", "string", "Optional session id (cookie-like)."),
 				prop("status", "string", "Filter by issue status: open|in_progress|done|canceled|all (default all)."),
 				prop("subject_contains", "string", "Case-insensitive substring filter on subject."),
-				prop("offset", "integer", "Offset for pagination (default 0)."),
+				prop("offset", "integer", "Offset for pagination (default 0). Prefer cursor: offsets skip or repeat items when the list changes between pages."),
+				prop("cursor", "string", "Cursor pagination: omit or pass \"\" for the first page, then the cursor of the last row received; a page shorter than limit is the last. The result is the same array either way; offset is ignored with a cursor. Keep the same filters and sort arguments across pages."),
 				prop("limit", "integer", "Limit for pagination (default 50; max 200)."),
 				prop("sort_by", "string", "Sort field: created_at|updated_at (default created_at)."),
 				prop("sort_order", "string", "Sort order: asc|desc (default desc)."),
@@ -51,16 +58,77 @@ func allTools() []ToolDefinition {
 		},
 		{
 			Name:        "waitIssueTasks",
-			Description: "Block until at least one task matching status exists under an issue. Returns immediately if tasks exist, otherwise waits.",
+			Description: "Block until at least one task matching status (and the optional labels/difficulty/points filters) exists under an issue. Returns immediately if such tasks exist, otherwise waits; tasks outside the filters do not wake the wait.",
 			InputSchema: obj(
 				prop("session_id", "string", "Optional session id (cookie-like)."),
 				prop("issue_id", "string", "Issue ID"),
 				prop("status", "string", "Filter by status: open|in_progress|done|blocked|canceled (default open)."),
+				prop("worker_id", "string", "Optional worker ID. When the server has a dispatch policy, an open-task wait returns one task matching the filters below, reserved for this worker (with next_step_token), instead of the shared list."),
+				prop("labels", "array", "Only tasks carrying at least one of these labels (case-insensitive)."),
+				propEnum("difficulty", []string{"easy", "medium", "focus"}, "Only tasks of this difficulty."),
+				prop("min_points", "integer", "Only tasks worth at least this many points."),
+				prop("max_points", "integer", "Only tasks worth at most this many points (0 = no limit)."),
 				prop("timeout_sec", "integer", "Long-poll timeout seconds (default 3600)."),
 				prop("limit", "integer", "Max tasks to return (default 50)."),
 				required("session_id", "issue_id"),
 			),
 		},
+		{
+			Name:        "waitAssignment",
+			Description: "Block until the scheduler assigns this worker a task (requires automatic assignment to be on). Returns the issue/task and a next_step_token to pass to claimIssueTask; assignment is null on timeout.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("worker_id", "string", "Worker employee ID (required)."),
+				prop("timeout_sec", "integer", "Long-poll timeout seconds (default 3600)."),
+				required("session_id", "worker_id"),
+			),
+		},
+		{
+			Name:        "waitPeerReviews",
+			Description: "Block until a submission is waiting for this worker's peer review (peer review mode). Returns the submission (artifacts included); submission is null on timeout. Answer it with peerReviewSubmission.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("worker_id", "string", "Worker employee ID (required)."),
+				prop("timeout_sec", "integer", "Long-poll timeout seconds (default 3600)."),
+				required("session_id", "worker_id"),
+			),
+		},
+		{
+			Name:        "getWorkerInbox",
+			Description: "List this worker's inbox: review results (verdict, feedback, completion score, next_step_token), replies to its questions, spec amendments of its task, assignments and peer review requests, oldest first. Use after reconnecting to find verdicts and replies you missed.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("worker_id", "string", "Worker employee ID (required)."),
+				prop("issue_id", "string", "Only this issue's inbox (default all issues)."),
+				prop("include_done", "boolean", "Also list items already handled (default false)."),
+				prop("ack", "boolean", "Mark the returned review results and replies as handled (default false)."),
+				required("session_id", "worker_id"),
+			),
+		},
+		{
+			Name:        "waitWorkerInbox",
+			Description: "Block until a review result, reply or spec amendment lands in this worker's inbox, mark it handled and return it; item is null on timeout. Assignments and peer review requests are left for waitAssignment/waitPeerReviews.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("worker_id", "string", "Worker employee ID (required)."),
+				prop("issue_id", "string", "Only this issue's inbox (default all issues)."),
+				prop("timeout_sec", "integer", "Long-poll timeout seconds (default 3600)."),
+				required("session_id", "worker_id"),
+			),
+		},
+		{
+			Name:        "peerReviewSubmission",
+			Description: "Peer reviewer's verdict on a submission. approved passes it on to the lead inbox; rejected sends it back to the submitting worker (status peer_rejected) without counting as a lead rejection.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("worker_id", "string", "Worker employee ID (required). Must be the assigned peer reviewer."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("submission_id", "string", "Submission ID from waitPeerReviews"),
+				propEnum("verdict", []string{swarm.VerdictApproved, swarm.VerdictRejected}, "approved|rejected"),
+				prop("comment", "string", "Review comment (required when rejecting)"),
+				required("session_id", "worker_id", "issue_id", "submission_id", "verdict"),
+			),
+		},
 		{
 			Name:        "getIssue",
 			Description: "Get an issue by id.",
@@ -70,6 +138,43 @@ func allTools() []ToolDefinition {
 				required("session_id", "issue_id"),
 			),
 		},
+		{
+			Name:        "getIssueMetrics",
+			Description: "Lead reads cycle-time metrics for an issue: per task the claim/submit/review timestamps, cycle time (claim to approval), mean review latency, and rework (submissions and rejections), plus issue-wide averages and pending reviews. Use it to spot bottlenecks.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				required("issue_id"),
+			),
+		},
+		{
+			Name:        "getIssueTimeline",
+			Description: "One chronological view of what happened on an issue: event log entries, task messages and replies, submissions and their reviews, deliveries and their reviews, each with actor, task, status, a short summary and an artifact summary. Paginated with a cursor.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID."),
+				prop("task_id", "string", "Only entries about this task (plus deliveries covering it)."),
+				propEnum("kind", []string{"event", "message", "submission", "delivery"}, "Only entries of this kind."),
+				prop("sort_order", "string", "asc (oldest first, default) or desc."),
+				prop("cursor", "string", "Cursor pagination: omit or pass \"\" for the first page, then the cursor of the last item received; a page shorter than limit is the last."),
+				prop("limit", "integer", "Page size (default 50; max 200)."),
+				required("issue_id"),
+			),
+		},
+		{
+			Name:        "getSwarmStats",
+			Description: "Swarm-wide summary in one call: issues, tasks and deliveries by status, registered workers, active leases and locked files, lead/worker/acceptor inbox backlogs, pending submissions and the age of the oldest one waiting for review.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+			),
+		},
+		{
+			Name:        "getDifficultyCalibration",
+			Description: "Lead reads how each difficulty fares across all issues: reviewed submissions, approval rate and mean completion score. Difficulties whose tasks are rejected too often are flagged under_rated with a suggestion.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+			),
+		},
 		{
 			Name:        "closeIssue",
 			Description: "Close an issue (sets status=done). Requires all tasks under the issue to be done.",
@@ -77,6 +182,7 @@ func allTools() []ToolDefinition {
 				prop("session_id", "string", "Optional session id (cookie-like)."),
 				prop("issue_id", "string", "Issue ID"),
 				prop("summary", "string", "Optional close summary"),
+				prop("expected_rev", "integer", "Optional: issue rev this update is based on (from getIssue). Rejected with a rev_conflict if the issue changed since."),
 				required("session_id", "issue_id"),
 			),
 		},
@@ -87,15 +193,101 @@ func allTools() []ToolDefinition {
 				prop("session_id", "string", "Optional session id (cookie-like)."),
 				prop("issue_id", "string", "Issue ID"),
 				prop("summary", "string", "Optional reopen summary"),
+				prop("expected_rev", "integer", "Optional: issue rev this update is based on (from getIssue). Rejected with a rev_conflict if the issue changed since."),
+				required("session_id", "issue_id"),
+			),
+		},
+		{
+			Name:        "pauseIssue",
+			Description: "Freeze an open issue (e.g. during a production incident) without canceling it: its tasks disappear from waitIssueTasks/waitIssues, new claims are refused and lease expiry is suspended. Claimed work is kept.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("reason", "string", "Why the issue is paused (optional)"),
+				prop("expected_rev", "integer", "Optional: issue rev this update is based on (from getIssue). Rejected with a rev_conflict if the issue changed since."),
+				required("session_id", "issue_id"),
+			),
+		},
+		{
+			Name:        "resumeIssue",
+			Description: "Resume a paused issue. Issue and claimed-task leases are extended by the time spent paused.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("expected_rev", "integer", "Optional: issue rev this update is based on (from getIssue). Rejected with a rev_conflict if the issue changed since."),
+				required("session_id", "issue_id"),
+			),
+		},
+		{
+			Name:        "addIssueLead",
+			Description: "Register a co-lead for an issue. Once an issue has leads, only they are served its lead inbox (waitIssueTaskEvents) and may change the list. The first call registers you (your session_id) as owner too. Leads are identified by a lead_key derived from the session_id they call with; getIssue shows the list (leads) and your own lead_key to leads only.",
+			InputSchema: obj(
+				prop("session_id", "string", "Your session id; becomes the owner when the issue has no leads yet."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("lead_id", "string", "The co-lead's lead_key (from its getIssue), or its session_id"),
+				prop("expected_rev", "integer", "Optional: issue rev this update is based on (from getIssue). Rejected with a rev_conflict if the issue changed since."),
+				required("session_id", "issue_id", "lead_id"),
+			),
+		},
+		{
+			Name:        "removeIssueLead",
+			Description: "Unregister a co-lead; the lead inbox items it holds go back to pending. The owner can only be removed as the last lead, which opens the issue to any lead again.",
+			InputSchema: obj(
+				prop("session_id", "string", "Your session id (must be a registered lead)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("lead_id", "string", "The lead's lead_key (as listed in leads), or its session_id"),
+				prop("expected_rev", "integer", "Optional: issue rev this update is based on (from getIssue). Rejected with a rev_conflict if the issue changed since."),
+				required("session_id", "issue_id", "lead_id"),
+			),
+		},
+		{
+			Name:        "setIssueClaimChecks",
+			Description: "Set the readiness checks a task of this issue must pass before it can be claimed (claimIssueTask, claimNextIssueTask, automatic assignment), on top of its required docs. Replaces the list; pass no checks to clear it. previewIssueTask reports a failing check as not_ready.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				propArrayOfObject(
+					"checks",
+					"Checks run in order; the first failing one blocks the claim.",
+					obj(
+						prop("validator", "string", "context_tasks_done (every context_task_ids task is done), issue_doc_contains (issue doc `doc` contains `text`, e.g. a sign-off line), required_docs, or a validator the server registered."),
+						prop("doc", "string", "issue_doc_contains: issue doc name"),
+						prop("text", "string", "issue_doc_contains: text the doc must contain"),
+						required("validator"),
+					),
+				),
+				prop("expected_rev", "integer", "Optional: issue rev this update is based on (from getIssue). Rejected with a rev_conflict if the issue changed since."),
+				required("session_id", "issue_id", "checks"),
+			),
+		},
+		{
+			Name:        "setIssueNextActions",
+			Description: "Override the next_actions coaching lines of one key (e.g. worker_after_claim, lead_after_review_rejected) for this issue only. Calls about the issue return these lines instead of the server-wide config files; pass no actions to remove the override.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("key", "string", "next_actions key, as the file name under <config_dir>/next_actions without .txt"),
+				prop("actions", "array", "Suggested actions, one per entry; {{placeholders}} work as in the config files. Empty removes the override."),
+				required("session_id", "issue_id", "key"),
+			),
+		},
+		{
+			Name:        "getIssueNextActions",
+			Description: "List the issue's next_actions overrides (overrides: key -> lines) set with setIssueNextActions.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
 				required("session_id", "issue_id"),
 			),
 		},
 		{
 			Name:        "submitDelivery",
-			Description: "Lead submits a delivery for an issue and blocks until an acceptor reviews it (approved/rejected).",
+			Description: "Lead submits a delivery for an issue and blocks until an acceptor reviews it (approved/rejected). Pass task_ids (and optionally milestone) to deliver a subset of tasks; closeIssue requires every task to be covered by an approved delivery. Refused with lock_conflict while workers still hold file locks for the covered tasks.",
 			InputSchema: obj(
 				prop("session_id", "string", "Optional session id (cookie-like)."),
 				prop("issue_id", "string", "Issue ID"),
+				prop("milestone", "string", "Optional milestone name for a scoped delivery (requires task_ids)."),
+				prop("task_ids", "array", "Optional task IDs covered by this delivery; only these tasks must be done. Default: all tasks in the issue."),
 				prop("summary", "string", "Delivery summary (required)."),
 				propObject(
 					"artifacts",
@@ -107,6 +299,7 @@ func allTools() []ToolDefinition {
 						prop("reviewed_refs", "array", "Key refs the acceptor should review (required)."),
 						prop("test_output", "string", "Trimmed test output summary (optional). Keep this short (key lines only); do NOT paste full logs."),
 						prop("known_risks", "string", "Known risks/boundaries (optional)."),
+						prop("links", "array", "Optional links; artifact://<issue_id>/<artifact_id> refs from attachArtifact must exist."),
 						required("test_result", "test_cases", "changed_files", "reviewed_refs"),
 					),
 				),
@@ -187,6 +380,17 @@ func allTools() []ToolDefinition {
 								required("command", "passed", "exit_code", "output"),
 							),
 						),
+						propObject(
+							"ci",
+							"Optional CI run to gate on. With verdict=approved the call blocks until the run completes and fails unless it succeeded; the result is recorded here.",
+							obj(
+								propEnum("provider", []string{"github"}, "CI provider."),
+								prop("run_url", "string", "Run URL, e.g. https://github.com/<owner>/<repo>/actions/runs/<id>."),
+								prop("commit_sha", "string", "Expected commit SHA (optional; an approval needs the run's head commit to match, a rejection only records a mismatch)."),
+								prop("timeout_sec", "integer", "Max seconds to wait for the run to finish (default 600)."),
+								required("provider", "run_url"),
+							),
+						),
 						required("script_passed", "script_result", "doc_passed", "doc_results"),
 					),
 				),
@@ -202,6 +406,70 @@ func allTools() []ToolDefinition {
 				required("session_id", "delivery_id"),
 			),
 		},
+		{
+			Name:        "health",
+			Description: "Health/readiness check: verifies the store root is writable, the global lock is acquirable within 2s and the session gateway is reachable. Returns per-component statuses.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+			),
+		},
+		{
+			Name:        "reloadConfig",
+			Description: "Admin: reload next_actions coaching text (<config_dir>/next_actions/*.txt and next_action.txt) without restarting. Files are also watched and reloaded automatically within a few seconds. Returns the config dir and loaded keys.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+			),
+		},
+		{
+			Name:        "queryAuditLog",
+			Description: "Query the tool-call audit log (every tools/call: tool, role, actor, member_id, worker_id, args_hash, status ok|error|denied|rate_limited, latency_ms), newest first. For post-incident forensics.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("actor", "string", "Only calls by this actor (matches actor, member_id or worker_id)."),
+				prop("tool", "string", "Only calls of this tool."),
+				propEnum("status", []string{"ok", "error", "denied", "rate_limited"}, "Only calls with this status."),
+				prop("since", "string", "RFC3339 lower bound (inclusive)."),
+				prop("until", "string", "RFC3339 upper bound (inclusive)."),
+				prop("limit", "integer", "Max entries (default 100, max 1000)."),
+			),
+		},
+		{
+			Name:        "queryTrace",
+			Description: "Query the trace log of domain events (lock_acquired, lock_forced, lock_expired, worker_registered, issue_archived, ...), newest first. Answers questions like which worker force-unlocked leases last night.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("type", "string", "Only events of this type (e.g. lock_forced)."),
+				prop("actor", "string", "Only events by this actor (worker_id, lead, retention, ...)."),
+				prop("subject", "string", "Only events about this subject (lease id, file, worker or issue id)."),
+				prop("since", "string", "RFC3339 lower bound (inclusive)."),
+				prop("until", "string", "RFC3339 upper bound (inclusive)."),
+				prop("limit", "integer", "Max events (default 100, max 1000)."),
+			),
+		},
+		{
+			Name:        "subscribeIssueEvents",
+			Description: "Follow an issue's event log without consuming the lead inbox (safe for dashboards/metrics collectors). Blocks until events with seq > after_seq match the filters, then returns a batch plus next_after_seq to pass on the next call.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("types", "array", "Event types to include (e.g. issue_task_resolved, submission_created). Default: all."),
+				prop("task_id", "string", "Only events for this task (optional)."),
+				prop("after_seq", "integer", "Return events with seq greater than this (default 0 = from the start)."),
+				prop("timeout_sec", "integer", "Max seconds to wait for a batch."),
+				prop("limit", "integer", "Max events per batch (default 50, max 200)."),
+				required("session_id", "issue_id"),
+			),
+		},
+		{
+			Name:        "getDeliveryHistory",
+			Description: "Get the revision chain of a delivery (oldest first). Each revision after the first includes changes_from_previous (previous verdict/feedback, added/removed changed files and test cases) so reviewers can see what changed since the last rejection. An approved delivery ends its chain.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("delivery_id", "string", "Delivery ID; the chain ending at this delivery is returned."),
+				prop("issue_id", "string", "Issue ID; used when delivery_id is empty to return the chain ending at the issue's latest delivery."),
+				required("session_id"),
+			),
+		},
 		{
 			Name:        "listDeliveries",
 			Description: "List deliveries, with optional filters/pagination/sorting.",
@@ -211,7 +479,8 @@ func allTools() []ToolDefinition {
 				prop("issue_id", "string", "Filter by issue_id (exact match)."),
 				prop("delivered_by", "string", "Filter by delivered_by (exact match)."),
 				prop("reviewed_by", "string", "Filter by reviewed_by (exact match)."),
-				prop("offset", "integer", "Offset for pagination (default 0)."),
+				prop("offset", "integer", "Offset for pagination (default 0). Prefer cursor: offsets skip or repeat items when the list changes between pages."),
+				prop("cursor", "string", "Cursor pagination: omit or pass \"\" for the first page, then the cursor of the last row received; a page shorter than limit is the last. The result is the same array either way; offset is ignored with a cursor. Keep the same filters and sort arguments across pages."),
 				prop("limit", "integer", "Limit for pagination (default 50; max 200)."),
 			),
 		},
@@ -229,7 +498,7 @@ func allTools() []ToolDefinition {
 				prop("session_id", "string", "Optional session id (cookie-like)."),
 				prop("status", "string", "Filter by status: open|in_review|approved|rejected (default open)."),
 				prop("timeout_sec", "integer", "Long-poll timeout seconds (default 3600)."),
-				prop("limit", "integer", "Max deliveries to return (default 50)."),
+				prop("limit", "integer", "Max deliveries to claim and return (default 1, so other acceptors can share the queue)."),
 			),
 		},
 		{
@@ -269,6 +538,8 @@ func allTools() []ToolDefinition {
 				prop("session_id", "string", "Optional session id (cookie-like)."),
 				prop("subject", "string", "Issue title"),
 				prop("description", "string", "Issue background / goal"),
+				prop("points_budget", "integer", "Optional cap on the summed points of the issue's tasks (0 = none)."),
+				prop("budget_strict", "boolean", "Reject createIssueTask over the points budget instead of returning a warning (default false)."),
 				propObject(
 					"user_issue_doc",
 					"User-provided issue document (required).",
@@ -301,6 +572,36 @@ func allTools() []ToolDefinition {
 				required("session_id", "subject", "user_issue_doc", "lead_issue_doc"),
 			),
 		},
+		{
+			Name:        "cloneIssue",
+			Description: "Clone an issue into a fresh one (new IDs): copies the issue docs and doc paths plus every task not yet done or canceled with its spec, reset to open/unclaimed (context/split references to tasks left behind are dropped). Use it to repeat the same work on another service or branch.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue to clone"),
+				prop("subject", "string", "Optional subject for the clone (default: the source subject)"),
+				required("session_id", "issue_id"),
+			),
+		},
+		{
+			Name:        "exportIssue",
+			Description: "Export an issue as a portable tar.gz: the issue directory (issue, tasks, docs, submissions, messages, events, artifacts), its deliveries, and a manifest.json with a sha256 per file. The archive is saved under <root>/exports/; use it to share a reproduction or attach it to a postmortem.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue to export"),
+				prop("include_data", "boolean", "Also return the archive as base64 (default false; refused above 5 MB, fetch the file instead)"),
+				required("issue_id"),
+			),
+		},
+		{
+			Name:        "importIssue",
+			Description: "Import an issue archive written by exportIssue (or `swarm-mcp issue export`). By default the issue and its deliveries get fresh IDs and every reference is rewritten; preserve_id keeps the original IDs (fails if they exist). Checksums are verified and older schema versions migrated.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("path", "string", "Archive path on the server (e.g. the path returned by exportIssue)"),
+				prop("data_base64", "string", "Archive content as base64 (alternative to path)"),
+				prop("preserve_id", "boolean", "Keep the source issue and delivery IDs (default false: fresh IDs)"),
+			),
+		},
 		{
 			Name:        "updateIssueDocPaths",
 			Description: "Update issue doc paths (shared_doc_paths / project_doc_paths) after issue creation.",
@@ -309,6 +610,7 @@ func allTools() []ToolDefinition {
 				prop("issue_id", "string", "Issue ID"),
 				prop("shared_doc_paths", "array", "Shared docs paths"),
 				prop("project_doc_paths", "array", "Project docs paths"),
+				prop("expected_rev", "integer", "Optional: issue rev this update is based on (from getIssue). Rejected with a rev_conflict if the issue changed since."),
 				required("session_id", "issue_id"),
 			),
 		},
@@ -321,6 +623,7 @@ func allTools() []ToolDefinition {
 				prop("subject", "string", "Task title"),
 				prop("description", "string", "Task description / requirements"),
 				propEnum("difficulty", []string{"easy", "medium", "focus"}, "Task difficulty (required)."),
+				prop("calibrate_difficulty", "boolean", "Raise the difficulty one level when tasks of the requested difficulty are rejected too often (see getDifficultyCalibration). Default false: only a difficulty_calibration note is returned."),
 				prop("context_task_ids", "array", "Optional context task IDs for additional background."),
 				prop("suggested_files", "array", "Files likely to be modified"),
 				prop("labels", "array", "Labels"),
@@ -364,9 +667,24 @@ func allTools() []ToolDefinition {
 				required("session_id", "worker_id", "issue_id", "task_id"),
 			),
 		},
+		{
+			Name:        "claimNextIssueTask",
+			Description: "Wait for an open task under an issue that matches the optional filters and claim it in one step, so workers woken by the same task do not race each other to claimIssueTask. Tasks the scheduler reserved for you are claimed first; tasks reserved for others are skipped. Returns the claimed task (claimed=true), or claimed=false on timeout; if matching tasks were skipped because their claim was refused as invalid (e.g. required docs missing), the timeout fails with invalid_argument naming them.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("worker_id", "string", "Worker employee ID (required). Used to bind task ownership."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("labels", "array", "Only tasks carrying at least one of these labels (case-insensitive)."),
+				propEnum("difficulty", []string{"easy", "medium", "focus"}, "Only tasks of this difficulty."),
+				prop("min_points", "integer", "Only tasks worth at least this many points."),
+				prop("max_points", "integer", "Only tasks worth at most this many points (0 = no limit)."),
+				prop("timeout_sec", "integer", "Long-poll timeout seconds (default 3600)."),
+				required("session_id", "worker_id", "issue_id"),
+			),
+		},
 		{
 			Name:        "submitIssueTask",
-			Description: "Submit work result for a task (creates a Submission entity) and block until lead reviews/resolves it (or timeout).",
+			Description: "Submit work result for a task (creates a Submission entity) and block until lead reviews/resolves it (or timeout). Also returns early when the lead comments on the submission (submission_comments); answer with addSubmissionComment.",
 			InputSchema: obj(
 				prop("session_id", "string", "Optional session id (cookie-like)."),
 				prop("worker_id", "string", "Worker employee ID (required). Must match task claimed_by."),
@@ -377,18 +695,54 @@ func allTools() []ToolDefinition {
 					"Worker submission artifacts (required). Heavy, structured data for lead review.",
 					obj(
 						prop("summary", "string", "Short submission summary"),
-						prop("changed_files", "array", "Changed files (relative paths)"),
+						prop("changed_files", "array", "Changed files (relative paths). Required unless the task's required_artifacts (getIssueTask) leave it out."),
 						prop("diff", "string", "Optional unified diff or patch excerpt"),
-						prop("links", "array", "Optional links (PR, diff, docs, logs)"),
-						prop("test_cases", "array", "Test cases/commands executed (required)."),
-						prop("test_result", "string", "Test result summary (required)."),
-						prop("test_output", "string", "Raw/trimmed test output content (required)."),
-						required("summary", "changed_files", "test_cases", "test_result", "test_output"),
+						prop("links", "array", "Optional links (PR, diff, docs, logs). Reference uploaded binaries (screenshots, coverage) by their attachArtifact ref: artifact://<issue_id>/<artifact_id>."),
+						prop("test_cases", "array", "Test cases/commands executed (required unless required_artifacts leave it out)."),
+						prop("test_result", "string", "Test result summary (required unless required_artifacts leave it out)."),
+						prop("test_output", "string", "Raw/trimmed test output content (required unless required_artifacts leave it out)."),
+						prop("base_ref", "string", "Git ref your changes are based on (optional). When the server runs in git mode, every changed_files entry must appear in git diff against this ref (default: server setting, HEAD)."),
+						required("summary"),
 					),
 				),
 				required("session_id", "worker_id", "issue_id", "task_id", "artifacts"),
 			),
 		},
+		{
+			Name:        "addSubmissionComment",
+			Description: "Comment on an open submission to discuss a specific finding before the verdict. The lead's comment ends the worker's submitIssueTask wait; the worker (submitter only) answers with this tool, which then blocks until the lead comments again or reviews. Pass parent_id to reply in a thread. Bounded per submission; comments close with the review.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("submission_id", "string", "Submission ID"),
+				prop("parent_id", "string", "Optional: ID of the comment this one replies to."),
+				prop("file_path", "string", "Optional: file the finding is about."),
+				prop("line_range", "string", "Optional: line range in file_path, e.g. 10-12."),
+				prop("content", "string", "Comment text (markdown)."),
+				required("issue_id", "submission_id", "content"),
+			),
+		},
+		{
+			Name:        "listSubmissionComments",
+			Description: "List the comments on a submission, oldest first (parent_id links replies).",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("submission_id", "string", "Submission ID"),
+				required("issue_id", "submission_id"),
+			),
+		},
+		{
+			Name:        "assignPeerReviewer",
+			Description: "Pair a second worker with a task as its peer reviewer (peer review mode). The reviewer approves each submission before it reaches the lead inbox; a submission already waiting for a reviewer is handed over at once. The reviewer cannot be the worker holding the task.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("task_id", "string", "Task ID"),
+				prop("reviewer_id", "string", "Worker ID of the peer reviewer"),
+				required("issue_id", "task_id", "reviewer_id"),
+			),
+		},
 		{
 			Name:        "reviewIssueTask",
 			Description: "Lead reviews a task. verdict=approved|rejected. If rejected, task goes back to in_progress. Worker submit creates a Submission entity; optionally pass submission_id from waitIssueTaskEvents for precise targeting.",
@@ -406,6 +760,17 @@ func allTools() []ToolDefinition {
 					obj(
 						prop("review_summary", "string", "Review summary"),
 						prop("reviewed_refs", "array", "Reviewed refs (paths/links/hashes)"),
+						propArrayOfObject(
+							"checklist",
+							"One result per item of the task's review checklists (getIssueTask review_checklist), when the operator configured any. Approval is refused while an item fails.",
+							obj(
+								prop("checklist", "string", "Checklist name"),
+								prop("item", "string", "Checklist item"),
+								propEnum("result", []string{"pass", "fail"}, "Result for this item"),
+								prop("note", "string", "Optional note"),
+								required("checklist", "item", "result"),
+							),
+						),
 						required("review_summary", "reviewed_refs"),
 					),
 				),
@@ -419,24 +784,69 @@ func allTools() []ToolDefinition {
 						prop("line_range", "string", "Optional line range (e.g. 45-50)"),
 						prop("content", "string", "Feedback content"),
 						prop("suggestion", "string", "Optional suggestion"),
+						propEnum("status", []string{"open", "resolved", "waived"}, "Optional finding status (default open). With the critical gate on, an approval needs every critical finding resolved or waived."),
+						prop("justification", "string", "Why the finding is waived (required with status=waived)"),
 						required("dimension", "severity", "content"),
 					),
 				),
 				prop("next_step_token", "string", "Token returned by getNextStepToken; must be provided to bind review -> next_step."),
+				prop("expected_rev", "integer", "Optional: task rev this update is based on (from getIssueTask). Rejected with a rev_conflict if the task changed since."),
 				required("session_id", "issue_id", "task_id", "verdict", "completion_score", "artifacts", "feedback_details", "next_step_token"),
 			),
 		},
 		{
 			Name:        "resetIssueTask",
-			Description: "Lead resets a task back to open and clears all worker progress/artifacts so a new worker can redo it.",
+			Description: "Admin: reset a task back to open and clear all worker progress/artifacts so a new worker can redo it.",
 			InputSchema: obj(
 				prop("session_id", "string", "Optional session id (cookie-like)."),
 				prop("issue_id", "string", "Issue ID"),
 				prop("task_id", "string", "Task ID"),
 				prop("reason", "string", "Reset reason (optional)."),
+				prop("expected_rev", "integer", "Optional: task rev this update is based on (from getIssueTask). Rejected with a rev_conflict if the task changed since."),
+				required("issue_id", "task_id"),
+			),
+		},
+		{
+			Name:        "reopenIssueTask",
+			Description: "Lead reopens a done task for follow-up work (status back to open, claim cleared). Unlike resetIssueTask, submissions, reviews, messages and docs are kept as history. Deliveries approved before the reopen no longer cover the task.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("task_id", "string", "Task ID"),
+				prop("reason", "string", "What the follow-up is about (optional)."),
+				prop("expected_rev", "integer", "Optional: task rev this update is based on (from getIssueTask). Rejected with a rev_conflict if the task changed since."),
 				required("issue_id", "task_id"),
 			),
 		},
+		{
+			Name:        "updateIssueTask",
+			Description: "Lead adjusts an open task after creation (e.g. after a worker's question shows the spec needs refining). Only the fields passed change; labels/suggested_files replace the whole list ([] clears it). Logs an issue_task_updated event.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("task_id", "string", "Task ID (must be open)"),
+				prop("subject", "string", "New task title"),
+				prop("description", "string", "New task description / requirements"),
+				prop("points", "integer", "New difficulty points (checked against a strict points budget)"),
+				propEnum("difficulty", []string{"easy", "medium", "focus"}, "New task difficulty"),
+				prop("labels", "array", "New labels"),
+				prop("suggested_files", "array", "New list of files likely to be modified"),
+				prop("expected_rev", "integer", "Optional: task rev this update is based on (from getIssueTask). Rejected with a rev_conflict if the task changed since."),
+				required("issue_id", "task_id"),
+			),
+		},
+		{
+			Name:        "amendTaskSpec",
+			Description: "Lead appends a timestamped clarification to a task's spec doc (under a Changelog heading, as a new doc version) instead of rewriting it, logs an issue_task_spec_amended event and pushes the amendment to the worker holding the task.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("task_id", "string", "Task ID (not done or canceled)"),
+				prop("section", "string", "Spec section the amendment clarifies, e.g. Goal, Rules, Constraints, Acceptance Criteria"),
+				prop("content", "string", "The clarification (markdown)"),
+				required("issue_id", "task_id", "section", "content"),
+			),
+		},
 		{
 			Name:        "getNextStepToken",
 			Description: "Compute and mint a next_step_token for a specific worker based on issue points + completion score, then reserve the chosen task (if any).",
@@ -449,6 +859,18 @@ func allTools() []ToolDefinition {
 				required("session_id", "issue_id", "task_id", "worker_id", "completion_score"),
 			),
 		},
+		{
+			Name:        "reserveIssueTask",
+			Description: "Reserve an open task for a specific worker outside the review flow (e.g. a worker joining mid-issue). Mints a claim_task next_step_token only that worker can claim with and puts an assigned item in its inbox.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("task_id", "string", "Open, unreserved task to hold"),
+				prop("worker_id", "string", "Worker the task is held for"),
+				prop("ttl_sec", "integer", "How long the reservation lasts (default: the scheduler's reservation window, else 600)"),
+				required("issue_id", "task_id", "worker_id"),
+			),
+		},
 		{
 			Name:        "getIssueTask",
 			Description: "Get a task under an issue.",
@@ -459,6 +881,15 @@ func allTools() []ToolDefinition {
 				required("session_id", "issue_id", "task_id"),
 			),
 		},
+		{
+			Name:        "previewIssueTask",
+			Description: "Read a task's spec and the content of its required issue/task docs WITHOUT claiming it (no lease, no session needed). Use it to judge whether a task fits before claimIssueTask; claimable tells whether it can be claimed right now.",
+			InputSchema: obj(
+				prop("issue_id", "string", "Issue ID"),
+				prop("task_id", "string", "Task ID"),
+				required("issue_id", "task_id"),
+			),
+		},
 		{
 			Name:        "listIssueTasks",
 			Description: "List tasks under an issue, with optional filters/pagination/sorting.",
@@ -469,7 +900,8 @@ func allTools() []ToolDefinition {
 				prop("subject_contains", "string", "Case-insensitive substring filter on subject."),
 				prop("claimed_by", "string", "Filter by claimed_by (exact match)."),
 				prop("submitter", "string", "Filter by submitter (exact match)."),
-				prop("offset", "integer", "Offset for pagination (default 0)."),
+				prop("offset", "integer", "Offset for pagination (default 0). Prefer cursor: offsets skip or repeat items when the list changes between pages."),
+				prop("cursor", "string", "Cursor pagination: omit or pass \"\" for the first page, then the cursor of the last row received; a page shorter than limit is the last. The result is the same array either way; offset is ignored with a cursor. Keep the same filters and sort arguments across pages."),
 				prop("limit", "integer", "Limit for pagination (default 50; max 200)."),
 				prop("sort_by", "string", "Sort field: created_at|updated_at|points (default created_at)."),
 				prop("sort_order", "string", "Sort order: asc|desc (default desc)."),
@@ -521,6 +953,59 @@ func allTools() []ToolDefinition {
 				required("session_id", "issue_id"),
 			),
 		},
+		{
+			Name:        "peekLeadInbox",
+			Description: "Non-blocking look at the lead inbox: pending and processing counts, pending counts by type, and the item the next waitIssueTaskEvents would serve (not claimed). Use it to decide between a long wait and other work.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				required("session_id", "issue_id"),
+			),
+		},
+		{
+			Name:        "ackInboxItem",
+			Description: "Mark an inbox item handled. Lead: an item served by waitIssueTaskEvents (its inbox_id) that was handled or deliberately dropped; only the lead holding its claim (and, once the issue has leads, a registered lead) may ack it. Worker (worker_id set): an item from getWorkerInbox, e.g. a review_result.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("inbox_id", "string", "Inbox item ID (inbox_id of the event or inbox item)."),
+				prop("worker_id", "string", "Worker employee ID: ack in this worker's inbox instead of the lead inbox."),
+				required("session_id", "issue_id", "inbox_id"),
+			),
+		},
+		{
+			Name:        "nackInboxItem",
+			Description: "Release a lead inbox item served by waitIssueTaskEvents back to pending right away (instead of after the claim TTL), to defer it; the next wait serves it again. Only the lead holding the claim may release it.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("inbox_id", "string", "Inbox item ID (inbox_id of the event)."),
+				required("session_id", "issue_id", "inbox_id"),
+			),
+		},
+		{
+			Name:        "extendInboxClaim",
+			Description: "Renew the claim on a lead inbox item served by waitIssueTaskEvents while still working on it (e.g. a long review), so it is not reset to pending and served to another lead when the claim TTL lapses. Only the lead holding the claim (same session_id) may renew it.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("inbox_id", "string", "Inbox item ID (inbox_id of the event)."),
+				prop("extend_sec", "integer", "New claim lifetime from now in seconds (default: the lead claim TTL)."),
+				required("session_id", "issue_id", "inbox_id"),
+			),
+		},
+		{
+			Name:        "requeueInboxItem",
+			Description: "Admin fix for a stuck inbox item: put a lead inbox item (or, with worker_id, an item in that worker's inbox) back to pending, dropping its claim. With to_worker_id a worker item moves to another worker's inbox; a pending assignment is re-reserved for the new worker.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("inbox_id", "string", "Inbox item ID"),
+				prop("worker_id", "string", "Worker whose inbox holds the item (default: the lead inbox)."),
+				prop("to_worker_id", "string", "Move the worker item to this worker's inbox."),
+				required("session_id", "issue_id", "inbox_id"),
+			),
+		},
 		{
 			Name:        "askIssueTask",
 			Description: "Worker asks a question/blocker for a task and blocks until lead replies (kind=reply) or timeout.",
@@ -536,6 +1021,29 @@ func allTools() []ToolDefinition {
 				required("session_id", "worker_id", "issue_id", "task_id", "content"),
 			),
 		},
+		{
+			Name:        "escalateIssueTask",
+			Description: "Escalate a claimed task to the lead when a question or blocker does not fit: the spec conflicts, access is missing, or the scope is too large. The lead sees it ahead of other inbox items; the task is blocked and its lease clock frozen until the lead replies (replyIssueTaskMessage).",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("worker_id", "string", "Worker employee ID (required). Must match task claimed_by."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("task_id", "string", "Task ID"),
+				propEnum("reason", []string{swarm.EscalationSpecConflict, swarm.EscalationMissingAccess, swarm.EscalationScopeTooLarge, swarm.EscalationOther}, "Why the task cannot proceed"),
+				prop("content", "string", "What is wrong and what the worker needs"),
+				prop("refs", "string", "Optional references"),
+				prop("notify_acceptor", "boolean", "Also copy the escalation to the acceptor inbox (default false)"),
+				required("session_id", "worker_id", "issue_id", "task_id", "reason", "content"),
+			),
+		},
+		{
+			Name:        "waitEscalations",
+			Description: "Block until a worker escalation copied to the acceptor (escalateIssueTask with notify_acceptor) arrives, and return it. Escalations the lead already answered are skipped.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("timeout_sec", "integer", "Long-poll timeout seconds (default 3600)."),
+			),
+		},
 		{
 			Name:        "postIssueTaskMessage",
 			Description: "Post a task message event for lead to review (e.g. question/blocker/feedback). Will be returned by waitIssueTaskEvents.",
@@ -569,6 +1077,7 @@ func allTools() []ToolDefinition {
 			Description: "Register a new worker identity and return the worker record.",
 			InputSchema: obj(
 				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("skills", "array", "Optional skills; the scheduler prefers tasks whose labels overlap them."),
 			),
 		},
 		{
@@ -590,11 +1099,12 @@ func allTools() []ToolDefinition {
 		// === Docs Library ===
 		{
 			Name:        "writeSharedDoc",
-			Description: "Write a shared doc into docs library (shared across all issues).",
+			Description: "Write a shared doc into docs library (shared across all issues). Every write is kept as a version; returns {name, version}.",
 			InputSchema: obj(
 				prop("session_id", "string", "Optional session id (cookie-like)."),
 				prop("name", "string", "Doc name (without extension)"),
 				prop("content", "string", "Doc content (markdown)"),
+				prop("base_version", "integer", "Optional: version this edit is based on (from a previous write or listDocVersions). The write is rejected with a doc_conflict if the doc changed since."),
 				required("session_id", "name"),
 			),
 		},
@@ -616,12 +1126,13 @@ func allTools() []ToolDefinition {
 		},
 		{
 			Name:        "writeIssueDoc",
-			Description: "Write a doc under an issue.",
+			Description: "Write a doc under an issue. Every write is kept as a version; returns {name, version}.",
 			InputSchema: obj(
 				prop("session_id", "string", "Optional session id (cookie-like)."),
 				prop("issue_id", "string", "Issue ID"),
 				prop("name", "string", "Doc name (without extension)"),
 				prop("content", "string", "Doc content (markdown)"),
+				prop("base_version", "integer", "Optional: version this edit is based on (from a previous write or listDocVersions). The write is rejected with a doc_conflict if the doc changed since."),
 				required("session_id", "issue_id", "name"),
 			),
 		},
@@ -646,13 +1157,14 @@ func allTools() []ToolDefinition {
 		},
 		{
 			Name:        "writeTaskDoc",
-			Description: "Write a doc under a task.",
+			Description: "Write a doc under a task. Every write is kept as a version; returns {name, version}.",
 			InputSchema: obj(
 				prop("session_id", "string", "Optional session id (cookie-like)."),
 				prop("issue_id", "string", "Issue ID"),
 				prop("task_id", "string", "Task ID"),
 				prop("name", "string", "Doc name (without extension)"),
 				prop("content", "string", "Doc content (markdown)"),
+				prop("base_version", "integer", "Optional: version this edit is based on (from a previous write or listDocVersions). The write is rejected with a doc_conflict if the doc changed since."),
 				required("session_id", "issue_id", "task_id", "name"),
 			),
 		},
@@ -677,17 +1189,116 @@ func allTools() []ToolDefinition {
 				required("session_id", "issue_id", "task_id"),
 			),
 		},
+		{
+			Name:        "attachArtifact",
+			Description: "Upload a small binary artifact (screenshot, coverage report, ...; max 5MB) as base64 and store it under the issue. Returns metadata including ref (artifact://<issue_id>/<artifact_id>) to put into submission/delivery artifacts.links.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("task_id", "string", "Optional task the artifact belongs to"),
+				prop("name", "string", "File name, e.g. coverage.html"),
+				prop("content_type", "string", "Optional MIME type (default: detected from content or data URL)"),
+				prop("description", "string", "Optional description"),
+				prop("data_base64", "string", "File content, base64 (a data: URL is also accepted)"),
+				required("session_id", "issue_id", "name", "data_base64"),
+			),
+		},
+		{
+			Name:        "getArtifact",
+			Description: "Get an artifact's metadata and (unless include_data=false) its base64 content.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("artifact_id", "string", "Artifact ID (or the full artifact:// ref)"),
+				prop("include_data", "boolean", "Return data_base64 (default true)"),
+				required("session_id", "issue_id", "artifact_id"),
+			),
+		},
+		{
+			Name:        "listArtifacts",
+			Description: "List artifact metadata of an issue (optionally one task), oldest first.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("issue_id", "string", "Issue ID"),
+				prop("task_id", "string", "Optional task filter"),
+				required("session_id", "issue_id"),
+			),
+		},
+		{
+			Name:        "searchDocs",
+			Description: "Search doc contents (case-insensitive substring) and return matching docs with line snippets, most matches first. scope=all searches shared docs plus, when issue_id is given, the issue's docs and all its task docs.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("query", "string", "Text to search for"),
+				propEnum("scope", []string{"all", "shared", "issue", "task"}, "Doc library to search (default all)"),
+				prop("issue_id", "string", "Issue ID (scope issue/task; narrows scope all)"),
+				prop("task_id", "string", "Task ID (scope task)"),
+				prop("limit", "integer", "Max docs to return (default 20, max 100)"),
+				required("session_id", "query"),
+			),
+		},
+		{
+			Name:        "listDocVersions",
+			Description: "List the version history of a shared/issue/task doc (oldest first): version, bytes, written_at, rolled_back_from.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				propEnum("scope", []string{"shared", "issue", "task"}, "Doc library"),
+				prop("issue_id", "string", "Issue ID (scope issue/task)"),
+				prop("task_id", "string", "Task ID (scope task)"),
+				prop("name", "string", "Doc name (without extension)"),
+				required("session_id", "scope", "name"),
+			),
+		},
+		{
+			Name:        "readDocVersion",
+			Description: "Read the content of one version of a doc.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				propEnum("scope", []string{"shared", "issue", "task"}, "Doc library"),
+				prop("issue_id", "string", "Issue ID (scope issue/task)"),
+				prop("task_id", "string", "Task ID (scope task)"),
+				prop("name", "string", "Doc name (without extension)"),
+				prop("version", "integer", "Version number"),
+				required("session_id", "scope", "name", "version"),
+			),
+		},
+		{
+			Name:        "rollbackDoc",
+			Description: "Restore an earlier version of a doc. The old content is written as a new version (history is never rewritten); returns {name, version}.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				propEnum("scope", []string{"shared", "issue", "task"}, "Doc library"),
+				prop("issue_id", "string", "Issue ID (scope issue/task)"),
+				prop("task_id", "string", "Task ID (scope task)"),
+				prop("name", "string", "Doc name (without extension)"),
+				prop("version", "integer", "Version to restore"),
+				prop("base_version", "integer", "Optional: expected current version; rejected with doc_conflict if the doc changed since."),
+				required("session_id", "scope", "name", "version"),
+			),
+		},
+		{
+			Name:        "diffDocs",
+			Description: "Unified diff between two docs or two versions of one doc (e.g. the user's requirement doc vs the lead's issue doc). Returns {from, to, identical, diff}.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				propObject("from", "Old side.", docRefSchema()),
+				propObject("to", "New side.", docRefSchema()),
+				prop("context_lines", "integer", "Unchanged lines shown around each change (default 3)."),
+				required("session_id", "from", "to"),
+			),
+		},
 		// === File Lock ===
 		{
 			Name:        "lockFiles",
-			Description: "Acquire lease-based locks on one or more files. MUST be called before modifying any file. Files are locked atomically (all-or-nothing). If a file is already locked by another owner, waits up to wait_sec then fails. Heartbeat must be called every 30s to keep the lock alive.",
+			Description: "Acquire lease-based locks on one or more files. MUST be called before modifying any file. Files are locked atomically (all-or-nothing). If a file is already locked by another owner, queues (first-come-first-served) for up to wait_sec then fails. On failure the error includes a JSON lock_conflict block (reason, holder owner/task_id/acquired_at/expires_at, waiters). Heartbeat must be called every 30s to keep the lock alive.",
 			InputSchema: obj(
 				prop("session_id", "string", "Optional session id (cookie-like)."),
 				prop("worker_id", "string", "Worker employee ID (required). Used as lock owner; must match task claimed_by when task_id is provided."),
 				prop("issue_id", "string", "Issue ID (required when task_id is provided)."),
 				prop("task_id", "string", "Associated task ID"),
 				prop("owner", "string", "Lock owner (optional; defaults to current connection member_id)"),
-				prop("files", "array", "List of file paths to lock (relative to repo root)"),
+				prop("files", "array", "List of file paths to lock (relative to repo root). Directories (\"src/payments/\") and glob patterns (\"src/payments/**\", \"cmd/*.go\") are allowed and conflict with any overlapping path or pattern."),
+				prop("scope", "string", "Optional lock namespace (e.g. issue_id or a project key). Locks only conflict with locks of the same scope; use it when issues work in different repos with same-named files. Default: global."),
 				prop("ttl_sec", "integer", "Lock TTL in seconds (default 120)"),
 				prop("wait_sec", "integer", "Max wait time if lock is held (default 60)"),
 				required("session_id", "worker_id", "files"),
@@ -704,6 +1315,16 @@ func allTools() []ToolDefinition {
 				required("session_id", "worker_id", "lease_id"),
 			),
 		},
+		{
+			Name:        "heartbeatAll",
+			Description: "Extend every active lease owned by worker_id in one call, instead of one heartbeat per lease_id. Returns the leases with their new expiries; expired leases are not revived.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("worker_id", "string", "Worker employee ID (required). Leases owned by it are extended."),
+				prop("extend_sec", "integer", "Seconds to extend each lease (default 120)"),
+				required("session_id", "worker_id"),
+			),
+		},
 		{
 			Name:        "unlock",
 			Description: "Release a lease and all its file locks. MUST be called after finishing file modifications.",
@@ -716,16 +1337,26 @@ func allTools() []ToolDefinition {
 		},
 		{
 			Name:        "listLocks",
-			Description: "List active file locks, optionally filtered by owner or files.",
+			Description: "List active file locks, optionally filtered by owner, scope or files.",
 			InputSchema: obj(
 				prop("session_id", "string", "Optional session id (cookie-like)."),
 				prop("owner", "string", "Filter by owner"),
+				prop("scope", "string", "Filter by lock scope (issue_id or project key)"),
 				prop("files", "array", "Filter by file paths"),
 			),
 		},
+		{
+			Name:        "listLockWaiters",
+			Description: "List queued lockFiles waiters per file (FIFO order), optionally filtered by scope or files. Use with a lockFiles conflict to judge how long a wait would be.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("scope", "string", "Filter by lock scope"),
+				prop("files", "array", "Filter by file paths or patterns"),
+			),
+		},
 		{
 			Name:        "forceUnlock",
-			Description: "Forcefully release a lease (Leader only). Use when a lock is stuck or owner is unresponsive.",
+			Description: "Admin: forcefully release a lease. Use when a lock is stuck or owner is unresponsive.",
 			InputSchema: obj(
 				prop("session_id", "string", "Optional session id (cookie-like)."),
 				prop("lease_id", "string", "Lease ID to force release"),
@@ -733,19 +1364,45 @@ func allTools() []ToolDefinition {
 				required("session_id", "lease_id", "reason"),
 			),
 		},
+		{
+			Name:        "sweepExpired",
+			Description: "Admin: run the expiry sweep now instead of waiting for the server's next timed sweep (SWARM_MCP_SWEEP_INTERVAL_SEC). Expired issue and task leases are released and expired file locks removed.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				required("session_id"),
+			),
+		},
+		{
+			Name:        "runRetention",
+			Description: "Admin: apply the configured [retention] rules now: archive or delete closed issues past closed_issue_days and rotate an oversized trace log. Returns what was removed.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				required("session_id"),
+			),
+		},
+		{
+			Name:        "fsckStore",
+			Description: "Admin: check the store for unreadable records, leftover temp files and dangling references (like `swarm-mcp fsck`). With repair=true, fixes what it can; orphans are moved to <root>/lost+found.",
+			InputSchema: obj(
+				prop("session_id", "string", "Optional session id (cookie-like)."),
+				prop("repair", "boolean", "Fix what can be fixed (default false: report only)."),
+				required("session_id"),
+			),
+		},
 	}
 }
 
-// allToolsForRole returns a role-scoped tool list.
+// allToolsForRole returns a role-scoped tool list; roleCode (if set) is added as a required argument.
 // It does NOT delete tools from the codebase; it only controls exposure for a specific MCP binary.
-func allToolsForRole(role string) []ToolDefinition {
+func allToolsForRole(role, roleCode string) []ToolDefinition {
 	base := allTools()
 	// Order matters:
-	// 1) role-specific required fields (worker_id)
+	// 1) role-specific identity fields (worker_id, acceptor_id)
 	// 2) role code
 	// 3) session schema finalization (oneOf branches must include all required fields)
 	base = injectWorkerIDIntoTools(role, base)
-	base = injectRoleCodeIntoTools(role, base)
+	base = injectAcceptorIDIntoTools(role, base)
+	base = injectRoleCodeIntoTools(roleCode, base)
 	base = injectSessionIntoTools(role, base)
 	allowed := toolAllowSetForRole(role)
 	if allowed == nil {
@@ -775,6 +1432,7 @@ func toolAllowSetForRole(role string) map[string]bool {
 	common := map[string]bool{
 		"myProfile": true,
 		"swarmNow":  true,
+		"health":    true,
 
 		// Docs read/list are safe defaults for context recovery.
 		"readSharedDoc":  true,
@@ -783,6 +1441,13 @@ func toolAllowSetForRole(role string) map[string]bool {
 		"listIssueDocs":  true,
 		"readTaskDoc":    true,
 		"listTaskDocs":   true,
+
+		"searchDocs":      true,
+		"getArtifact":     true,
+		"listArtifacts":   true,
+		"listDocVersions": true,
+		"readDocVersion":  true,
+		"diffDocs":        true,
 	}
 
 	switch strings.TrimSpace(role) {
@@ -794,39 +1459,71 @@ func toolAllowSetForRole(role string) map[string]bool {
 		allowed["listIssues"] = true
 		allowed["listOpenedIssues"] = true
 		allowed["getIssue"] = true
+		allowed["getIssueMetrics"] = true
+		allowed["getDifficultyCalibration"] = true
 		allowed["closeIssue"] = true
 		allowed["reopenIssue"] = true
+		allowed["pauseIssue"] = true
+		allowed["resumeIssue"] = true
+		allowed["setIssueNextActions"] = true
+		allowed["addIssueLead"] = true
+		allowed["removeIssueLead"] = true
+		allowed["setIssueClaimChecks"] = true
+		allowed["getIssueNextActions"] = true
 		allowed["extendIssueLease"] = true
 
 		// Issue doc management
 		allowed["writeIssueDoc"] = true
+		allowed["rollbackDoc"] = true
+		allowed["attachArtifact"] = true
 		allowed["updateIssueDocPaths"] = true
 
 		// Task management
 		allowed["createIssue"] = true
+		allowed["cloneIssue"] = true
+		allowed["exportIssue"] = true
+		allowed["importIssue"] = true
 		allowed["createIssueTask"] = true
 		allowed["getIssueTask"] = true
 		allowed["listIssueTasks"] = true
 		allowed["listIssueOpenedTasks"] = true
-		allowed["resetIssueTask"] = true
+		allowed["reopenIssueTask"] = true
+		allowed["updateIssueTask"] = true
+		allowed["amendTaskSpec"] = true
+		allowed["addSubmissionComment"] = true
+		allowed["listSubmissionComments"] = true
 		allowed["reviewIssueTask"] = true
+		allowed["assignPeerReviewer"] = true
 		allowed["getNextStepToken"] = true
+		allowed["reserveIssueTask"] = true
 
 		// Lead event loop
 		allowed["waitIssueTaskEvents"] = true
 		allowed["selectIssueInbox"] = true
 		allowed["nextIssueSignal"] = true
 		allowed["stepLeadInbox"] = true
+		allowed["peekLeadInbox"] = true
+		allowed["ackInboxItem"] = true
+		allowed["nackInboxItem"] = true
+		allowed["extendInboxClaim"] = true
 		allowed["replyIssueTaskMessage"] = true
 
 		// Worker directory (lead needs worker_id for getNextStepToken)
 		allowed["listWorkers"] = true
 
-		// Lock admin (lead can force-unlock stuck worker locks)
-		allowed["forceUnlock"] = true
+		// Lock waits (forceUnlock is admin-only)
+		allowed["listLockWaiters"] = true
+
+		// Forensics
+		allowed["queryAuditLog"] = true
+		allowed["queryTrace"] = true
+		allowed["getSwarmStats"] = true
+		allowed["getIssueTimeline"] = true
 
 		// Delivery submission (lead submits; acceptor reviews).
 		allowed["submitDelivery"] = true
+		allowed["getDeliveryHistory"] = true
+		allowed["subscribeIssueEvents"] = true
 		return allowed
 	case "worker":
 		allowed := cloneAllowSet(common)
@@ -838,6 +1535,8 @@ func toolAllowSetForRole(role string) map[string]bool {
 		allowed["listIssueTasks"] = true
 		allowed["listIssueOpenedTasks"] = true
 		allowed["waitIssueTasks"] = true
+		allowed["previewIssueTask"] = true
+		allowed["waitAssignment"] = true
 
 		// Worker operates on explicit issue_id/task_id once claimed.
 		allowed["getIssue"] = true
@@ -846,21 +1545,34 @@ func toolAllowSetForRole(role string) map[string]bool {
 
 		// Docs write (worker may attach task deliverables as docs)
 		allowed["writeTaskDoc"] = true
+		allowed["rollbackDoc"] = true
+		allowed["attachArtifact"] = true
 
 		// Locks (worker edits code)
 		allowed["lockFiles"] = true
 		allowed["heartbeat"] = true
+		allowed["heartbeatAll"] = true
 		allowed["unlock"] = true
 		allowed["listLocks"] = true
+		allowed["listLockWaiters"] = true
 
 		// Worker directory
 		allowed["registerWorker"] = true
 
 		// Core worker actions
 		allowed["claimIssueTask"] = true
+		allowed["claimNextIssueTask"] = true
 		allowed["submitIssueTask"] = true
+		allowed["addSubmissionComment"] = true
+		allowed["listSubmissionComments"] = true
 		allowed["askIssueTask"] = true
 		allowed["postIssueTaskMessage"] = true
+		allowed["escalateIssueTask"] = true
+		allowed["waitPeerReviews"] = true
+		allowed["peerReviewSubmission"] = true
+		allowed["getWorkerInbox"] = true
+		allowed["waitWorkerInbox"] = true
+		allowed["ackInboxItem"] = true
 		return allowed
 	case "acceptor":
 		allowed := cloneAllowSet(common)
@@ -869,15 +1581,54 @@ func toolAllowSetForRole(role string) map[string]bool {
 		allowed["getIssue"] = true
 		allowed["getIssueTask"] = true
 		allowed["getIssueAcceptanceBundle"] = true
+		allowed["exportIssue"] = true
 
 		// Delivery / acceptance
 		allowed["getDelivery"] = true
+		allowed["getDeliveryHistory"] = true
+		allowed["subscribeIssueEvents"] = true
+		allowed["queryAuditLog"] = true
+		allowed["queryTrace"] = true
+		allowed["getSwarmStats"] = true
+		allowed["getIssueTimeline"] = true
 		allowed["listDeliveries"] = true
 		allowed["listOpenedDeliveries"] = true
 		allowed["waitDeliveries"] = true
 		allowed["claimDelivery"] = true
 		allowed["extendDeliveryLease"] = true
 		allowed["reviewDelivery"] = true
+		allowed["waitEscalations"] = true
+		return allowed
+	case "admin":
+		allowed := cloneAllowSet(common)
+
+		// Maintenance: kept out of the other roles so these need the admin role code.
+		allowed["sweepExpired"] = true
+		allowed["runRetention"] = true
+		allowed["fsckStore"] = true
+		allowed["health"] = true
+		allowed["reloadConfig"] = true
+
+		// Repairs of stuck work
+		allowed["forceUnlock"] = true
+		allowed["resetIssueTask"] = true
+		allowed["requeueInboxItem"] = true
+		allowed["ackInboxItem"] = true
+		allowed["nackInboxItem"] = true
+
+		// Read access to find what needs repair
+		allowed["listIssues"] = true
+		allowed["getIssue"] = true
+		allowed["listIssueTasks"] = true
+		allowed["getIssueTask"] = true
+		allowed["getIssueTimeline"] = true
+		allowed["peekLeadInbox"] = true
+		allowed["listWorkers"] = true
+		allowed["listLocks"] = true
+		allowed["listLockWaiters"] = true
+		allowed["queryAuditLog"] = true
+		allowed["queryTrace"] = true
+		allowed["getSwarmStats"] = true
 		return allowed
 	default:
 		return nil
@@ -923,6 +1674,18 @@ func propObject(name, desc string, schema map[string]any) map[string]any {
 	return map[string]any{name: s}
 }
 
+// docRefSchema describes one side of diffDocs.
+func docRefSchema() map[string]any {
+	return obj(
+		propEnum("scope", []string{"shared", "issue", "task"}, "Doc library"),
+		prop("issue_id", "string", "Issue ID (scope issue/task)"),
+		prop("task_id", "string", "Task ID (scope task)"),
+		prop("name", "string", "Doc name (without extension)"),
+		prop("version", "integer", "Optional version (default: current content)"),
+		required("scope", "name"),
+	)
+}
+
 func propArrayOfObject(name, desc string, itemSchema map[string]any) map[string]any {
 	s := map[string]any{"type": "array", "description": desc}
 	s["items"] = itemSchema
@@ -941,6 +1704,37 @@ func propIntEnum(name string, values []int, desc string) map[string]any {
 	return map[string]any{name: map[string]any{"type": "integer", "enum": arr, "description": desc}}
 }
 
+// injectCompletionScores replaces the default 1|2|5 completion_score enum with the configured scale.
+func injectCompletionScores(tools []ToolDefinition, scores []swarm.CompletionScore) []ToolDefinition {
+	if len(scores) == 0 {
+		return tools
+	}
+	values := make([]any, 0, len(scores))
+	labels := make([]string, 0, len(scores))
+	for _, c := range scores {
+		values = append(values, c.Value)
+		if c.Label != "" {
+			labels = append(labels, fmt.Sprintf("%d=%s", c.Value, c.Label))
+		} else {
+			labels = append(labels, strconv.Itoa(c.Value))
+		}
+	}
+	for _, t := range tools {
+		m, ok := t.InputSchema.(map[string]any)
+		if !ok {
+			continue
+		}
+		props, _ := m["properties"].(map[string]any)
+		p, ok := props["completion_score"].(map[string]any)
+		if !ok {
+			continue
+		}
+		p["enum"] = values
+		p["description"] = "Completion score (required): " + strings.Join(labels, " | ")
+	}
+	return tools
+}
+
 func required(names ...string) map[string]any {
 	return map[string]any{"__required": names}
 }
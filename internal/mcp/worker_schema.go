@@ -13,11 +13,20 @@ func injectWorkerIDIntoTools(role string, tools []ToolDefinition) []ToolDefiniti
 	workerRequired := map[string]bool{
 		"waitIssueTasks":       true,
 		"claimIssueTask":       true,
+		"claimNextIssueTask":   true,
 		"submitIssueTask":      true,
+		"addSubmissionComment": true,
 		"askIssueTask":         true,
 		"postIssueTaskMessage": true,
+		"escalateIssueTask":    true,
+		"waitPeerReviews":      true,
+		"peerReviewSubmission": true,
+		"getWorkerInbox":       true,
+		"waitWorkerInbox":      true,
+		"ackInboxItem":         true,
 		"lockFiles":            true,
 		"heartbeat":            true,
+		"heartbeatAll":         true,
 		"unlock":               true,
 		"listLocks":            true,
 	}
@@ -0,0 +1,181 @@
+package swarm
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Binary artifacts (screenshots, coverage reports, ...) attached to an issue.
+// Stored as issues/{issue_id}/artifacts/{artifact_id}.bin with metadata in {artifact_id}.json, and
+// referenced from SubmissionArtifacts.Links / DeliveryArtifacts.Links as "artifact://{issue_id}/{artifact_id}".
+
+const (
+	MaxArtifactBytes  = 5 << 20
+	ArtifactRefPrefix = "artifact://"
+)
+
+type Artifact struct {
+	ID          string `json:"id"`
+	IssueID     string `json:"issue_id"`
+	TaskID      string `json:"task_id,omitempty"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+	SHA256      string `json:"sha256"`
+	Description string `json:"description,omitempty"`
+	UploadedBy  string `json:"uploaded_by"`
+	Ref         string `json:"ref"`
+	CreatedAt   string `json:"created_at"`
+}
+
+func ArtifactRef(issueID, artifactID string) string {
+	return ArtifactRefPrefix + issueID + "/" + artifactID
+}
+
+func (s *IssueService) artifactPath(issueID, artifactID, ext string) string {
+	return s.store.Path("issues", issueID, "artifacts", artifactID+ext)
+}
+
+// AttachArtifact decodes base64 data and stores it as an artifact of the issue (optionally tied to a task).
+func (s *IssueService) AttachArtifact(actor, issueID, taskID, name, contentType, description, dataBase64 string) (*Artifact, error) {
+	issueID = strings.TrimSpace(issueID)
+	if issueID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
+	}
+	name = filepath.Base(strings.TrimSpace(name))
+	if name == "" || name == "." || name == "/" {
+		return nil, Errorf(CodeInvalidArgument, "name is required")
+	}
+	dataBase64 = strings.TrimSpace(dataBase64)
+	// Accept data URLs ("data:image/png;base64,....").
+	if strings.HasPrefix(dataBase64, "data:") {
+		if i := strings.Index(dataBase64, ","); i >= 0 {
+			if contentType == "" {
+				contentType = strings.TrimSuffix(strings.TrimPrefix(dataBase64[:i], "data:"), ";base64")
+			}
+			dataBase64 = dataBase64[i+1:]
+		}
+	}
+	if dataBase64 == "" {
+		return nil, Errorf(CodeInvalidArgument, "data_base64 is required")
+	}
+	if base64.StdEncoding.DecodedLen(len(dataBase64)) > MaxArtifactBytes+3 {
+		return nil, Errorf(CodeLimitExceeded, "artifact too large: max %d bytes", MaxArtifactBytes)
+	}
+	data, err := base64.StdEncoding.DecodeString(dataBase64)
+	if err != nil {
+		return nil, Errorf(CodeInvalidArgument, "data_base64 is not valid base64: %w", err)
+	}
+	if len(data) > MaxArtifactBytes {
+		return nil, Errorf(CodeLimitExceeded, "artifact too large: %d bytes (max %d)", len(data), MaxArtifactBytes)
+	}
+	if strings.TrimSpace(contentType) == "" {
+		contentType = http.DetectContentType(data)
+	}
+	sum := sha256.Sum256(data)
+
+	var result *Artifact
+	err = s.store.WithLock(func() error {
+		if !s.store.Exists("issues", issueID, "issue.json") {
+			return Errorf(CodeNotFound, "issue '%s' not found", issueID)
+		}
+		if taskID = strings.TrimSpace(taskID); taskID != "" {
+			if _, err := s.loadTaskLocked(issueID, taskID); err != nil {
+				return err
+			}
+		}
+		a := &Artifact{
+			ID:          GenID("art"),
+			IssueID:     issueID,
+			TaskID:      taskID,
+			Name:        name,
+			ContentType: strings.TrimSpace(contentType),
+			Size:        len(data),
+			SHA256:      hex.EncodeToString(sum[:]),
+			Description: strings.TrimSpace(description),
+			UploadedBy:  actor,
+			CreatedAt:   NowStr(),
+		}
+		a.Ref = ArtifactRef(issueID, a.ID)
+		s.store.EnsureDir("issues", issueID, "artifacts")
+		if err := os.WriteFile(s.artifactPath(issueID, a.ID, ".bin"), data, 0644); err != nil {
+			return err
+		}
+		if err := s.store.WriteJSON(s.artifactPath(issueID, a.ID, ".json"), a); err != nil {
+			return err
+		}
+		result = a
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.bump(issueID)
+	return result, nil
+}
+
+// GetArtifact returns artifact metadata and, if withData, its content as base64.
+func (s *IssueService) GetArtifact(issueID, artifactID string, withData bool) (*Artifact, string, error) {
+	if issueID == "" || artifactID == "" {
+		return nil, "", Errorf(CodeInvalidArgument, "issue_id and artifact_id are required")
+	}
+	if strings.ContainsAny(artifactID, `/\`) {
+		return nil, "", Errorf(CodeInvalidArgument, "invalid artifact_id '%s'", artifactID)
+	}
+	var a Artifact
+	if err := s.store.ReadJSON(s.artifactPath(issueID, artifactID, ".json"), &a); err != nil {
+		return nil, "", Errorf(CodeNotFound, "artifact '%s' not found in issue '%s'", artifactID, issueID)
+	}
+	if !withData {
+		return &a, "", nil
+	}
+	data, err := os.ReadFile(s.artifactPath(issueID, artifactID, ".bin"))
+	if err != nil {
+		return nil, "", err
+	}
+	return &a, base64.StdEncoding.EncodeToString(data), nil
+}
+
+// ListArtifacts lists an issue's artifacts (optionally only those of taskID), oldest first.
+func (s *IssueService) ListArtifacts(issueID, taskID string) ([]Artifact, error) {
+	if issueID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
+	}
+	out := []Artifact{}
+	for _, f := range listJSONOrEmpty(s.store, s.store.Path("issues", issueID, "artifacts")) {
+		var a Artifact
+		if err := s.store.ReadJSON(f, &a); err != nil {
+			continue
+		}
+		if taskID != "" && a.TaskID != taskID {
+			continue
+		}
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt < out[j].CreatedAt })
+	return out, nil
+}
+
+// checkArtifactLinksLocked rejects artifact:// links that do not point at an artifact of issueID.
+func (s *IssueService) checkArtifactLinksLocked(issueID string, links []string) error {
+	for _, l := range links {
+		l = strings.TrimSpace(l)
+		if !strings.HasPrefix(l, ArtifactRefPrefix) {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(l, ArtifactRefPrefix), "/", 2)
+		if len(parts) != 2 || parts[0] != issueID || parts[1] == "" || strings.ContainsAny(parts[1], `/\`) {
+			return Errorf(CodeInvalidArgument, "link '%s' must reference an artifact of issue '%s'", l, issueID)
+		}
+		if _, err := os.Stat(s.artifactPath(issueID, parts[1], ".json")); err != nil {
+			return Errorf(CodeNotFound, "link '%s': artifact not found; upload it with attachArtifact first", l)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package swarm
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestAttachArtifact_StoresAndValidatesLinks(t *testing.T) {
+	store := NewStore(t.TempDir())
+	trace := NewTraceService(store)
+	svc := NewIssueService(store, trace, 7200, 3600, 3600, 3600)
+	if err := store.WriteJSON(store.Path("issues", "i1", "issue.json"), Issue{ID: "i1", Status: IssueInProgress}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+	a, err := svc.AttachArtifact("w1", "i1", "", "shot.png", "", "", base64.StdEncoding.EncodeToString(png))
+	if err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	if a.ContentType != "image/png" || a.Size != len(png) || a.Ref != "artifact://i1/"+a.ID {
+		t.Fatalf("unexpected artifact: %+v", a)
+	}
+	_, data, err := svc.GetArtifact("i1", a.ID, true)
+	if err != nil || data != base64.StdEncoding.EncodeToString(png) {
+		t.Fatalf("get: %q %v", data, err)
+	}
+	if list, _ := svc.ListArtifacts("i1", ""); len(list) != 1 {
+		t.Fatalf("list: %+v", list)
+	}
+
+	if _, err := svc.AttachArtifact("w1", "i1", "", "x.bin", "", "", "not base64!"); err == nil {
+		t.Fatalf("expected base64 error")
+	}
+
+	err = store.WithLock(func() error {
+		if err := svc.checkArtifactLinksLocked("i1", []string{"https://example.com/pr/1", a.Ref}); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("valid links rejected: %v", err)
+	}
+	err = store.WithLock(func() error {
+		return svc.checkArtifactLinksLocked("i1", []string{"artifact://i1/art-missing"})
+	})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected missing artifact error, got %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+package swarm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Audit log of tool calls, kept apart from the trace (which records domain events).
+// One JSON line per call in audit/tool_calls.jsonl; the file is only ever appended to. Every connection
+// is its own process, so appends and rotation are serialized across processes by the flock
+// audit/.audit.lock. Once the log is larger than auditMaxBytes it is moved to
+// audit/tool_calls-<time>.jsonl and compressed in the background; the newest auditKeepSegments
+// segments are kept, so Query never reads more than that much.
+
+const (
+	AuditStatusOK          = "ok"
+	AuditStatusError       = "error"
+	AuditStatusDenied      = "denied"
+	AuditStatusRateLimited = "rate_limited"
+
+	auditDefaultLimit = 100
+	auditMaxLimit     = 1000
+
+	auditMaxBytes      = 32 << 20
+	auditKeepSegments  = 4
+	auditSegmentPrefix = "tool_calls-"
+)
+
+type AuditEntry struct {
+	ID        string `json:"id"`
+	Timestamp string `json:"timestamp"`
+	Tool      string `json:"tool"`
+	Role      string `json:"role"`
+	Actor     string `json:"actor"`
+	MemberID  string `json:"member_id,omitempty"`
+	WorkerID  string `json:"worker_id,omitempty"`
+	Project   string `json:"project,omitempty"`
+	IssueID   string `json:"issue_id,omitempty"`
+	TaskID    string `json:"task_id,omitempty"`
+	ArgsHash  string `json:"args_hash"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	ErrorCode string `json:"error_code,omitempty"` // Code* taxonomy of Error
+	Degraded  string `json:"degraded,omitempty"`   // why the call was let through in a degraded mode
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// AuditQuery filters Query. Since/Until are RFC3339 and inclusive; empty fields match everything.
+type AuditQuery struct {
+	Actor  string
+	Tool   string
+	Status string
+	Since  string
+	Until  string
+	Limit  int
+}
+
+type AuditService struct {
+	store    *Store
+	mu       sync.Mutex
+	maxBytes int64
+	keep     int
+}
+
+func NewAuditService(store *Store) *AuditService {
+	return &AuditService{store: store, maxBytes: auditMaxBytes, keep: auditKeepSegments}
+}
+
+func (a *AuditService) path() string {
+	return a.store.Path("audit", "tool_calls.jsonl")
+}
+
+// HashArgs fingerprints tool arguments without storing them. Keys listed in omit (secrets) are left out.
+func HashArgs(args map[string]any, omit ...string) string {
+	clean := make(map[string]any, len(args))
+	for k, v := range args {
+		clean[k] = v
+	}
+	for _, k := range omit {
+		delete(clean, k)
+	}
+	b, _ := json.Marshal(clean) // map keys are sorted, so equal args hash equally
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Record appends one entry. Failures are ignored: auditing must never break a tool call.
+func (a *AuditService) Record(e AuditEntry) {
+	if e.ID == "" {
+		e.ID = GenID("aud")
+	}
+	if e.Timestamp == "" {
+		e.Timestamp = NowStr()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	dir := a.store.EnsureDir("audit")
+	unlock, err := lockAuditLog(dir, syscall.LOCK_EX)
+	if err != nil {
+		return
+	}
+	defer unlock()
+	f, err := os.OpenFile(a.path(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	_, werr := f.Write(append(data, '\n'))
+	info, serr := f.Stat()
+	f.Close()
+	if werr == nil && serr == nil && a.maxBytes > 0 && info.Size() > a.maxBytes {
+		a.rotateLocked(dir)
+	}
+}
+
+// lockAuditLog takes the audit log flock (LOCK_EX to append or rotate, LOCK_SH to pick the files to
+// read) and returns its release.
+func lockAuditLog(dir string, how int) (func(), error) {
+	f, err := openLockFile(filepath.Join(dir, ".audit.lock"))
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("flock: %w", err)
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// rotateLocked moves the log into a new segment; compressing it and pruning old segments happen in the
+// background, outside the tool call. Called with the audit log flock held.
+func (a *AuditService) rotateLocked(dir string) {
+	// The full ULID keeps segments rotated within the same second in order.
+	seg := filepath.Join(dir, auditSegmentPrefix+time.Now().UTC().Format("20060102T150405Z")+"-"+strings.TrimPrefix(GenID("seg"), "seg_")+".jsonl")
+	if os.Rename(a.path(), seg) != nil {
+		return
+	}
+	go func() {
+		_, _ = gzipFile(seg)
+		segs := logSegments(dir, auditSegmentPrefix)
+		for a.keep > 0 && len(segs) > a.keep {
+			_ = os.Remove(segs[0])
+			segs = segs[1:]
+		}
+	}()
+}
+
+func normalizeAuditTime(field, v string) (string, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "", nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return "", Errorf(CodeInvalidArgument, "%s must be RFC3339 (e.g. 2024-01-02T15:04:05Z)", field)
+	}
+	return t.UTC().Format(time.RFC3339), nil
+}
+
+// Query returns matching entries, newest first.
+func (a *AuditService) Query(q AuditQuery) ([]AuditEntry, error) {
+	since, err := normalizeAuditTime("since", q.Since)
+	if err != nil {
+		return nil, err
+	}
+	until, err := normalizeAuditTime("until", q.Until)
+	if err != nil {
+		return nil, err
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = auditDefaultLimit
+	}
+	if limit > auditMaxLimit {
+		limit = auditMaxLimit
+	}
+
+	// Pick the files under the lock so a concurrent rotation cannot make the log slip between them;
+	// reading needs no lock, since a renamed or compressed file keeps its content.
+	dir := a.store.EnsureDir("audit")
+	unlock, err := lockAuditLog(dir, syscall.LOCK_SH)
+	if err != nil {
+		return nil, err
+	}
+	files := append(logSegments(dir, auditSegmentPrefix), a.path())
+	unlock()
+
+	// Newest file first; each is streamed keeping only its newest matches still needed, so memory is
+	// bounded by limit and older segments are not read once the page is full.
+	out := make([]AuditEntry, 0, min(limit, auditDefaultLimit))
+	for i := len(files) - 1; i >= 0 && len(out) < limit; i-- {
+		need := limit - len(out)
+		var matched []AuditEntry
+		scan := func(path string) error {
+			matched = matched[:0]
+			return scanLogSegment(path, func(line []byte) {
+				var e AuditEntry
+				if json.Unmarshal(line, &e) != nil {
+					return
+				}
+				if (q.Actor != "" && e.Actor != q.Actor && e.MemberID != q.Actor && e.WorkerID != q.Actor) ||
+					(q.Tool != "" && e.Tool != q.Tool) ||
+					(q.Status != "" && e.Status != q.Status) ||
+					(since != "" && e.Timestamp < since) ||
+					(until != "" && e.Timestamp > until) {
+					return
+				}
+				matched = append(matched, e)
+				if len(matched) > need {
+					matched = matched[1:]
+				}
+			})
+		}
+		err := scan(files[i])
+		if errors.Is(err, os.ErrNotExist) && i < len(files)-1 && !strings.HasSuffix(files[i], ".gz") {
+			err = scan(files[i] + ".gz") // compressed since it was listed
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		for j := len(matched) - 1; j >= 0; j-- {
+			out = append(out, matched[j])
+		}
+	}
+	return out, nil
+}
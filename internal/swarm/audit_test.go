@@ -0,0 +1,83 @@
+package swarm
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditService_RecordAndQuery(t *testing.T) {
+	store := NewStore(t.TempDir())
+	audit := NewAuditService(store)
+
+	if got, err := audit.Query(AuditQuery{}); err != nil || len(got) != 0 {
+		t.Fatalf("empty log: %v %v", got, err)
+	}
+
+	audit.Record(AuditEntry{Tool: "claimIssueTask", Actor: "w1", WorkerID: "w1", Status: AuditStatusOK, Timestamp: "2024-01-01T00:00:00Z"})
+	audit.Record(AuditEntry{Tool: "submitIssueTask", Actor: "w1", WorkerID: "w1", Status: AuditStatusError, Timestamp: "2024-01-02T00:00:00Z"})
+	audit.Record(AuditEntry{Tool: "createIssue", Actor: "m_1", MemberID: "m_1", Status: AuditStatusOK, Timestamp: "2024-01-03T00:00:00Z"})
+
+	got, err := audit.Query(AuditQuery{Actor: "w1"})
+	if err != nil || len(got) != 2 || got[0].Tool != "submitIssueTask" {
+		t.Fatalf("by actor (newest first): %+v %v", got, err)
+	}
+	got, _ = audit.Query(AuditQuery{Since: "2024-01-02T00:00:00Z", Until: "2024-01-02T23:00:00+00:00"})
+	if len(got) != 1 || got[0].Tool != "submitIssueTask" {
+		t.Fatalf("by time: %+v", got)
+	}
+	got, _ = audit.Query(AuditQuery{Status: AuditStatusOK, Limit: 1})
+	if len(got) != 1 || got[0].Tool != "createIssue" {
+		t.Fatalf("by status with limit: %+v", got)
+	}
+	if _, err := audit.Query(AuditQuery{Since: "yesterday"}); err == nil {
+		t.Fatalf("expected error for non-RFC3339 since")
+	}
+
+	if HashArgs(map[string]any{"a": 1, "role_code": "x"}, "role_code") != HashArgs(map[string]any{"a": 1}) {
+		t.Fatalf("omitted keys must not affect the hash")
+	}
+}
+
+func TestAuditService_RotatesAndQueriesAcrossSegments(t *testing.T) {
+	store := NewStore(t.TempDir())
+	audit := NewAuditService(store)
+	audit.maxBytes = 600
+	audit.keep = 2
+
+	for i := 0; i < 20; i++ {
+		audit.Record(AuditEntry{Tool: fmt.Sprintf("tool%02d", i), Actor: "w1", Status: AuditStatusOK, Timestamp: fmt.Sprintf("2024-01-01T00:00:%02dZ", i)})
+	}
+	dir := store.Path("audit")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		segs := logSegments(dir, auditSegmentPrefix)
+		done := len(segs) <= 2
+		for _, seg := range segs {
+			done = done && strings.HasSuffix(seg, ".gz")
+		}
+		if done || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	segs := logSegments(dir, auditSegmentPrefix)
+	if len(segs) == 0 || len(segs) > 2 {
+		t.Fatalf("expected 1-2 kept segments, got %v", segs)
+	}
+	if info, err := os.Stat(audit.path()); err == nil && info.Size() > 600+400 {
+		t.Fatalf("current log was not rotated: %d bytes", info.Size())
+	}
+
+	got, err := audit.Query(AuditQuery{Limit: 6})
+	if err != nil || len(got) != 6 {
+		t.Fatalf("query across segments: %+v %v", got, err)
+	}
+	for i, e := range got {
+		if want := fmt.Sprintf("tool%02d", 19-i); e.Tool != want {
+			t.Fatalf("entry %d: got %s, want %s (newest first)", i, e.Tool, want)
+		}
+	}
+}
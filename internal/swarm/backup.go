@@ -0,0 +1,326 @@
+package swarm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackupFormat identifies the archives written by Backup.
+const BackupFormat = "swarm-mcp-backup/1"
+
+// backupDirName is the default directory, under the data root, for scheduled backups. Backup skips it so
+// archives do not nest.
+const backupDirName = "backups"
+
+// BackupManifest is written as backup.json at the root of a backup archive.
+type BackupManifest struct {
+	Format    string   `json:"format"`
+	CreatedAt string   `json:"created_at"`
+	Files     int      `json:"files"`
+	Bytes     int64    `json:"bytes"`
+	Projects  []string `json:"projects,omitempty"`
+}
+
+// Backup writes a gzipped tar of the whole data root (every project namespace included) to w. It holds
+// the global lock of the root and of each project store while reading, so no write lands halfway through
+// the snapshot. Lock files, temp files and <root>/backups are left out.
+func Backup(store *Store, w io.Writer) (*BackupManifest, error) {
+	m := &BackupManifest{Format: BackupFormat, CreatedAt: NowStr()}
+	stores := []*Store{store}
+	if entries, err := os.ReadDir(store.Path("projects")); err == nil {
+		for _, e := range entries {
+			if e.IsDir() {
+				m.Projects = append(m.Projects, e.Name())
+				stores = append(stores, store.Project(e.Name()))
+			}
+		}
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	err := withStoreLocks(stores, func() error {
+		var files []string
+		err := filepath.Walk(store.Root, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(store.Root, p)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if info.IsDir() {
+				if rel == backupDirName {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() || strings.HasSuffix(rel, ".lock") || strings.HasSuffix(rel, ".tmp") {
+				return nil
+			}
+			files = append(files, rel)
+			m.Bytes += info.Size()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		sort.Strings(files)
+		m.Files = len(files)
+		mb, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeTarFile(tw, "backup.json", mb, 0o644, time.Now()); err != nil {
+			return err
+		}
+		for _, rel := range files {
+			p := filepath.Join(store.Root, filepath.FromSlash(rel))
+			info, err := os.Stat(p)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			if err := writeTarFile(tw, "data/"+rel, data, info.Mode().Perm(), info.ModTime()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func withStoreLocks(stores []*Store, fn func() error) error {
+	if len(stores) == 0 {
+		return fn()
+	}
+	return stores[0].WithLock(func() error { return withStoreLocks(stores[1:], fn) })
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte, mode os.FileMode, mod time.Time) error {
+	hdr := &tar.Header{Name: name, Mode: int64(mode), Size: int64(len(data)), ModTime: mod, Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// Restore unpacks a Backup archive into root. The server must not be running. A root that already holds
+// data is refused unless force is set; then it is moved aside to <root>.pre-restore-<timestamp> (returned
+// as the second value) rather than deleted. The archive is unpacked next to root first, so a bad archive
+// leaves root untouched.
+func Restore(root string, r io.Reader, force bool) (*BackupManifest, string, error) {
+	root = filepath.Clean(root)
+	hasData, err := dirHasFiles(root)
+	if err != nil {
+		return nil, "", err
+	}
+	if hasData && !force {
+		return nil, "", Errorf(CodeAlreadyExists, "%s already holds data; restore with force to move it aside", root)
+	}
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	staging := root + ".restore-" + stamp
+	m, err := unpackBackup(staging, r)
+	if err != nil {
+		_ = os.RemoveAll(staging)
+		return nil, "", err
+	}
+	aside := ""
+	if _, err := os.Stat(root); err == nil {
+		aside = root + ".pre-restore-" + stamp
+		if !hasData {
+			aside = ""
+			if err := os.RemoveAll(root); err != nil {
+				return nil, "", err
+			}
+		} else if err := os.Rename(root, aside); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := os.Rename(staging, root); err != nil {
+		return nil, "", err
+	}
+	return m, aside, nil
+}
+
+func unpackBackup(dir string, r io.Reader) (*BackupManifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, Errorf(CodeInvalidArgument, "not a gzip archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	var m *BackupManifest
+	files := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, Errorf(CodeInvalidArgument, "read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if hdr.Name == "backup.json" {
+			m = &BackupManifest{}
+			if err := json.NewDecoder(tr).Decode(m); err != nil {
+				return nil, fmt.Errorf("backup.json: %w", err)
+			}
+			if m.Format != BackupFormat {
+				return nil, Errorf(CodeUnsupported, "unsupported backup format %q (want %s)", m.Format, BackupFormat)
+			}
+			continue
+		}
+		rel, ok := strings.CutPrefix(hdr.Name, "data/")
+		if !ok || rel == "" || path.Clean(rel) != rel || strings.HasPrefix(rel, "../") || path.IsAbs(rel) {
+			return nil, Errorf(CodeInvalidArgument, "unexpected archive entry %q", hdr.Name)
+		}
+		dst := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode).Perm()|0o600)
+		if err != nil {
+			return nil, err
+		}
+		_, cerr := io.Copy(f, tr)
+		if err := f.Close(); cerr == nil {
+			cerr = err
+		}
+		if cerr != nil {
+			return nil, cerr
+		}
+		files++
+	}
+	if m == nil {
+		return nil, Errorf(CodeInvalidArgument, "archive has no backup.json")
+	}
+	if files != m.Files {
+		return nil, Errorf(CodeInvalidArgument, "archive has %d files but backup.json lists %d", files, m.Files)
+	}
+	return m, nil
+}
+
+// dirHasFiles reports whether dir contains any regular file other than lock files. The empty directory
+// skeleton the server creates on start does not count as data.
+func dirHasFiles(dir string) (bool, error) {
+	found := false
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.Mode().IsRegular() && !strings.HasSuffix(info.Name(), ".lock") {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found, err
+}
+
+// BackupToDir writes a timestamped backup of store into dir (default <root>/backups) and keeps only the
+// newest keep archives there (0 = keep all). Returns the archive path.
+func BackupToDir(store *Store, dir string, keep int) (string, error) {
+	if strings.TrimSpace(dir) == "" {
+		dir = store.Path(backupDirName)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	name := filepath.Join(dir, "swarm-backup-"+time.Now().UTC().Format("20060102T150405Z")+".tar.gz")
+	f, err := os.Create(name + ".tmp")
+	if err != nil {
+		return "", err
+	}
+	_, err = Backup(store, f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(name+".tmp", name)
+	}
+	if err != nil {
+		_ = os.Remove(name + ".tmp")
+		return "", err
+	}
+	if keep > 0 {
+		old, _ := filepath.Glob(filepath.Join(dir, "swarm-backup-*.tar.gz"))
+		sort.Strings(old)
+		for len(old) > keep {
+			_ = os.Remove(old[0])
+			old = old[1:]
+		}
+	}
+	return name, nil
+}
+
+// BackupConfig configures scheduled backups of the data root.
+type BackupConfig struct {
+	IntervalSec int    // 0 = no scheduled backups
+	Dir         string // "" = <root>/backups
+	Keep        int    // archives kept in Dir; 0 = keep all
+}
+
+// StartScheduledBackups runs BackupToDir every cfg.IntervalSec seconds in the background, in whichever
+// process sharing the store holds the backup leader lock, so N agent connections make one backup (and
+// prune once). It does nothing when the interval is not set.
+func StartScheduledBackups(store *Store, cfg BackupConfig, logger *log.Logger) {
+	if cfg.IntervalSec <= 0 {
+		return
+	}
+	leader := store.Leader("backup")
+	go func() {
+		ticker := time.NewTicker(time.Duration(cfg.IntervalSec) * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			scheduledBackup(store, cfg, leader, logger)
+		}
+	}()
+}
+
+// scheduledBackup makes one scheduled backup if this process is the backup leader and reports whether
+// it tried.
+func scheduledBackup(store *Store, cfg BackupConfig, leader *LeaderLock, logger *log.Logger) bool {
+	if !leader.Held() {
+		return false
+	}
+	p, err := BackupToDir(store, cfg.Dir, cfg.Keep)
+	if logger == nil {
+		return true
+	}
+	if err != nil {
+		logger.Printf("WARNING: scheduled backup: %v", err)
+	} else {
+		logger.Printf("scheduled backup written to %s", p)
+	}
+	return true
+}
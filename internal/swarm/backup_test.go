@@ -0,0 +1,106 @@
+package swarm
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupRestore_RoundTrip(t *testing.T) {
+	store := NewStore(t.TempDir())
+	if err := store.WriteJSON(store.Path("issues", "issue-1", "issue.json"), &Issue{ID: "issue-1", Subject: "Fix login"}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	web := store.Project("web")
+	if err := web.WriteJSON(web.Path("issues", "issue-2", "issue.json"), &Issue{ID: "issue-2"}); err != nil {
+		t.Fatalf("write project issue: %v", err)
+	}
+	store.EnsureDir(backupDirName)
+	if err := os.WriteFile(store.Path(backupDirName, "old.tar.gz"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("write old backup: %v", err)
+	}
+
+	var buf bytes.Buffer
+	m, err := Backup(store, &buf)
+	if err != nil {
+		t.Fatalf("backup: %v", err)
+	}
+	if m.Files != 2 || len(m.Projects) != 1 || m.Projects[0] != "web" {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+
+	root := filepath.Join(t.TempDir(), "restored")
+	if _, _, err := Restore(root, bytes.NewReader(buf.Bytes()), false); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	restored := NewStore(root)
+	var is Issue
+	if err := restored.ReadJSON(restored.Path("issues", "issue-1", "issue.json"), &is); err != nil || is.Subject != "Fix login" {
+		t.Fatalf("expected the issue back, got %+v %v", is, err)
+	}
+	if !restored.Project("web").Exists("issues", "issue-2", "issue.json") {
+		t.Fatalf("expected the project namespace to be restored")
+	}
+
+	// A root with data is only replaced with force, and the old data is kept aside.
+	if _, _, err := Restore(root, bytes.NewReader(buf.Bytes()), false); err == nil {
+		t.Fatalf("expected restore over existing data to fail without force")
+	}
+	_, aside, err := Restore(root, bytes.NewReader(buf.Bytes()), true)
+	if err != nil || aside == "" {
+		t.Fatalf("forced restore: %q %v", aside, err)
+	}
+	if _, err := os.Stat(filepath.Join(aside, "issues", "issue-1", "issue.json")); err != nil {
+		t.Fatalf("expected the previous data under %s: %v", aside, err)
+	}
+
+	if _, _, err := Restore(filepath.Join(t.TempDir(), "bad"), bytes.NewReader([]byte("garbage")), false); err == nil {
+		t.Fatalf("expected garbage input to fail")
+	}
+}
+
+func TestBackupToDir_PrunesOldArchives(t *testing.T) {
+	store := NewStore(t.TempDir())
+	if err := store.WriteJSON(store.Path("issues", "issue-1", "issue.json"), &Issue{ID: "issue-1"}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"swarm-backup-20200101T000000Z.tar.gz", "swarm-backup-20200102T000000Z.tar.gz"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("old"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	p, err := BackupToDir(store, dir, 2)
+	if err != nil {
+		t.Fatalf("backup: %v", err)
+	}
+	left, _ := filepath.Glob(filepath.Join(dir, "swarm-backup-*.tar.gz"))
+	if len(left) != 2 || left[1] != p || filepath.Base(left[0]) != "swarm-backup-20200102T000000Z.tar.gz" {
+		t.Fatalf("expected the oldest archive pruned, got %v", left)
+	}
+}
+
+func TestScheduledBackup_OnlyTheLeaderBacksUp(t *testing.T) {
+	store := NewStore(t.TempDir())
+	if err := store.WriteJSON(store.Path("issues", "issue-1", "issue.json"), &Issue{ID: "issue-1"}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	dir := filepath.Join(t.TempDir(), "backups")
+	cfg := BackupConfig{IntervalSec: 60, Dir: dir}
+	// Two server processes on the same store.
+	first, second := store.Leader("backup"), store.Leader("backup")
+	if !scheduledBackup(store, cfg, first, nil) {
+		t.Fatalf("expected the first process to back up")
+	}
+	if scheduledBackup(store, cfg, second, nil) {
+		t.Fatalf("expected the second process to skip while the first leads")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("expected one backup, got %d", len(entries))
+	}
+	first.Release()
+	if !scheduledBackup(store, cfg, second, nil) {
+		t.Fatalf("expected the second process to take over")
+	}
+}
@@ -0,0 +1,180 @@
+package swarm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// CICheck references a CI pipeline run in reviewDelivery.verification.ci.
+// Provider/RunURL/CommitSHA are supplied by the acceptor; the remaining fields are filled in by the server.
+type CICheck struct {
+	Provider   string `json:"provider"`
+	RunURL     string `json:"run_url"`
+	CommitSHA  string `json:"commit_sha"`
+	TimeoutSec int    `json:"timeout_sec,omitempty"`
+
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HeadSHA    string `json:"head_sha"`
+	Passed     bool   `json:"passed"`
+	CheckedAt  string `json:"checked_at"`
+	Error      string `json:"error,omitempty"`
+}
+
+// CIRunStatus is a provider's view of a pipeline run.
+type CIRunStatus struct {
+	Completed  bool
+	Status     string
+	Conclusion string
+	HeadSHA    string
+}
+
+// CIProvider fetches the current state of a referenced CI run.
+type CIProvider interface {
+	RunStatus(ctx context.Context, ci CICheck) (CIRunStatus, error)
+}
+
+// ciPollInterval is how often checkCI polls a running pipeline (a var so tests can shorten it).
+var ciPollInterval = 10 * time.Second
+
+// SetCIProvider registers provider under name (e.g. "github"). A nil provider removes it.
+func (s *IssueService) SetCIProvider(name string, p CIProvider) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if p == nil {
+		delete(s.ciProviders, name)
+		return
+	}
+	if s.ciProviders == nil {
+		s.ciProviders = map[string]CIProvider{}
+	}
+	s.ciProviders[name] = p
+}
+
+// checkCI records the referenced CI run into ci. When waitGreen is set (an approval) it polls until the
+// run completes (or ci.TimeoutSec elapses) and returns an error unless the run succeeded on ci.CommitSHA;
+// before each wait it calls keepAlive with how long polling may still take, so the caller can hold on to
+// its claim, and stops if that fails. A rejection only records the run, a commit mismatch included.
+// Must NOT be called under store lock.
+func (s *IssueService) checkCI(ctx context.Context, ci *CICheck, waitGreen bool, keepAlive func(time.Duration) error) error {
+	ci.Provider = strings.ToLower(strings.TrimSpace(ci.Provider))
+	ci.RunURL = strings.TrimSpace(ci.RunURL)
+	ci.CommitSHA = strings.TrimSpace(ci.CommitSHA)
+	p, ok := s.ciProviders[ci.Provider]
+	if !ok {
+		return Errorf(CodeInvalidArgument, "verification.ci.provider '%s' is not configured", ci.Provider)
+	}
+	if ci.TimeoutSec <= 0 {
+		ci.TimeoutSec = 600
+	}
+
+	deadline := time.Now().Add(time.Duration(ci.TimeoutSec) * time.Second)
+	for {
+		st, err := p.RunStatus(ctx, *ci)
+		ci.CheckedAt = NowStr()
+		if err != nil {
+			ci.Error = err.Error()
+			if waitGreen {
+				return Errorf(CodeUnavailable, "cannot verify CI run: %w", err)
+			}
+			return nil
+		}
+		ci.Error = ""
+		ci.Status = st.Status
+		ci.Conclusion = st.Conclusion
+		ci.HeadSHA = st.HeadSHA
+		ci.Passed = st.Completed && st.Conclusion == "success"
+		if ci.CommitSHA != "" && st.HeadSHA != "" && !strings.HasPrefix(st.HeadSHA, ci.CommitSHA) {
+			ci.Passed = false
+			if !waitGreen {
+				ci.Error = fmt.Sprintf("CI run is for commit %s, not %s", st.HeadSHA, ci.CommitSHA)
+				return nil
+			}
+			return Errorf(CodeInvalidArgument, "CI run is for commit %s, not %s", st.HeadSHA, ci.CommitSHA)
+		}
+		if !waitGreen {
+			return nil
+		}
+		if st.Completed {
+			if !ci.Passed {
+				return Errorf(CodeInvalidState, "cannot approve: CI run concluded '%s'", st.Conclusion)
+			}
+			return nil
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return Errorf(CodeInvalidState, "cannot approve: CI run still '%s' after %ds", st.Status, ci.TimeoutSec)
+		}
+		if keepAlive != nil {
+			if err := keepAlive(remaining + ciPollInterval); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(min(remaining, ciPollInterval)):
+		}
+	}
+}
+
+var githubRunURL = regexp.MustCompile(`^https?://[^/]+/([^/]+)/([^/]+)/actions/runs/(\d+)`)
+
+// GitHubActionsCI reads workflow runs from the GitHub REST API. RunURL must be a run page URL
+// such as https://github.com/owner/repo/actions/runs/123.
+type GitHubActionsCI struct {
+	Token   string
+	APIBase string
+	Client  *http.Client
+}
+
+func (g *GitHubActionsCI) RunStatus(ctx context.Context, ci CICheck) (CIRunStatus, error) {
+	m := githubRunURL.FindStringSubmatch(ci.RunURL)
+	if m == nil {
+		return CIRunStatus{}, Errorf(CodeInvalidArgument, "run_url must look like https://github.com/<owner>/<repo>/actions/runs/<id>")
+	}
+	base := strings.TrimRight(strings.TrimSpace(g.APIBase), "/")
+	if base == "" {
+		base = "https://api.github.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/repos/%s/%s/actions/runs/%s", base, m[1], m[2], m[3]), nil)
+	if err != nil {
+		return CIRunStatus{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if g.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+	client := g.Client
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return CIRunStatus{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return CIRunStatus{}, Errorf(CodeUnavailable, "github: status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	var run struct {
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+		HeadSHA    string `json:"head_sha"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&run); err != nil {
+		return CIRunStatus{}, err
+	}
+	return CIRunStatus{
+		Completed:  run.Status == "completed",
+		Status:     run.Status,
+		Conclusion: run.Conclusion,
+		HeadSHA:    run.HeadSHA,
+	}, nil
+}
@@ -0,0 +1,160 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeCI struct {
+	st     CIRunStatus
+	onPoll func() // called on every poll but the first
+	polls  int
+}
+
+func (f *fakeCI) RunStatus(context.Context, CICheck) (CIRunStatus, error) {
+	f.polls++
+	if f.polls > 1 && f.onPoll != nil {
+		f.onPoll()
+	}
+	return f.st, nil
+}
+
+// newCIGateDelivery creates an issue and a delivery claimed by "acceptor".
+func newCIGateDelivery(t *testing.T, svc *IssueService, store *Store) *Delivery {
+	t.Helper()
+	issueID := "issue-1"
+	store.EnsureDir("issues", issueID)
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{
+		ID:        issueID,
+		Subject:   "s",
+		Status:    IssueOpen,
+		CreatedAt: NowStr(),
+		UpdatedAt: NowStr(),
+	}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	d, err := svc.CreateDelivery("lead", issueID, "sum", "", DeliveryArtifacts{
+		TestResult:   "passed",
+		TestCases:    []string{"go test ./..."},
+		ChangedFiles: []string{"a.go"},
+		ReviewedRefs: []string{"a.go"},
+	}, TestEvidence{
+		ScriptPath:   "scripts/test-issue-1.sh",
+		ScriptCmd:    "bash scripts/test-issue-1.sh",
+		ScriptPassed: true,
+		ScriptResult: "ok",
+		DocPath:      "docs/issue-1-test-steps.md",
+		DocCommands:  []string{"echo hi"},
+		DocResults: []CommandResult{
+			{Command: "echo hi", Passed: true, ExitCode: 0, Output: "hi"},
+		},
+		DocPassed: true,
+	})
+	if err != nil {
+		t.Fatalf("create delivery: %v", err)
+	}
+	if _, err := svc.ClaimDelivery("acceptor", d.ID, 0); err != nil {
+		t.Fatalf("claim delivery: %v", err)
+	}
+	return d
+}
+
+func ciVerification(commit string) Verification {
+	return Verification{
+		ScriptPassed: true,
+		ScriptResult: "ok",
+		DocPassed:    true,
+		DocResults: []CommandResult{
+			{Command: "echo hi", Passed: true, ExitCode: 0, Output: "hi"},
+		},
+		CI: &CICheck{Provider: "github", RunURL: "https://github.com/o/r/actions/runs/1", CommitSHA: commit},
+	}
+}
+
+func TestReviewDelivery_CIGateBlocksApprovalUntilGreen(t *testing.T) {
+	root := t.TempDir()
+	store := NewStore(root)
+	store.EnsureDir()
+	store.EnsureDir("issues")
+	store.EnsureDir("deliveries")
+
+	trace := NewTraceService(store)
+	svc := NewIssueService(store, trace, 7200, 3600, 3600, 3600)
+	ci := &fakeCI{st: CIRunStatus{Completed: true, Status: "completed", Conclusion: "failure", HeadSHA: "abc123"}}
+	svc.SetCIProvider("github", ci)
+	d := newCIGateDelivery(t, svc, store)
+
+	if _, err := svc.ReviewDelivery(context.Background(), "acceptor", d.ID, DeliveryApproved, "", "", ciVerification("abc")); err == nil {
+		t.Fatalf("expected approval to be blocked by failed CI")
+	}
+
+	ci.st.Conclusion = "success"
+	out, err := svc.ReviewDelivery(context.Background(), "acceptor", d.ID, DeliveryApproved, "", "", ciVerification("abc"))
+	if err != nil {
+		t.Fatalf("review delivery: %v", err)
+	}
+	if out.Verification.CI == nil || !out.Verification.CI.Passed || out.Verification.CI.HeadSHA != "abc123" {
+		t.Fatalf("unexpected CI record: %+v", out.Verification.CI)
+	}
+}
+
+func TestReviewDelivery_CICommitMismatchOnlyBlocksApproval(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+	svc.SetCIProvider("github", &fakeCI{st: CIRunStatus{Completed: true, Status: "completed", Conclusion: "success", HeadSHA: "fff999"}})
+	d := newCIGateDelivery(t, svc, store)
+
+	if _, err := svc.ReviewDelivery(context.Background(), "acceptor", d.ID, DeliveryApproved, "", "", ciVerification("abc")); err == nil {
+		t.Fatalf("expected approval on a run for another commit to be refused")
+	}
+	out, err := svc.ReviewDelivery(context.Background(), "acceptor", d.ID, DeliveryRejected, "wrong build", "", ciVerification("abc"))
+	if err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+	if out.Status != DeliveryRejected || out.Verification.CI == nil || out.Verification.CI.Passed || out.Verification.CI.Error == "" {
+		t.Fatalf("expected a rejection recording the mismatched run, got %+v", out.Verification.CI)
+	}
+}
+
+func TestReviewDelivery_CIWaitKeepsTheClaim(t *testing.T) {
+	defer func(d time.Duration) { ciPollInterval = d }(ciPollInterval)
+	ciPollInterval = 10 * time.Millisecond
+
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+	ci := &fakeCI{st: CIRunStatus{Status: "in_progress", HeadSHA: "abc123"}}
+	svc.SetCIProvider("github", ci)
+	d := newCIGateDelivery(t, svc, store)
+
+	// Shorten the claim so it would lapse while the run is still going.
+	if err := store.WithLock(func() error {
+		var dl Delivery
+		if err := store.ReadJSON(store.Path("deliveries", d.ID+".json"), &dl); err != nil {
+			return err
+		}
+		dl.LeaseExpiresAtMs = LeaseNowMs() + 1000
+		return store.WriteJSON(store.Path("deliveries", d.ID+".json"), &dl)
+	}); err != nil {
+		t.Fatalf("shorten lease: %v", err)
+	}
+	var leaseDuringWait int64
+	ci.onPoll = func() {
+		got, err := svc.GetDelivery(d.ID)
+		if err != nil {
+			t.Errorf("get delivery: %v", err)
+			return
+		}
+		leaseDuringWait = got.LeaseExpiresAtMs
+		ci.st = CIRunStatus{Completed: true, Status: "completed", Conclusion: "success", HeadSHA: "abc123"}
+	}
+
+	v := ciVerification("abc")
+	v.CI.TimeoutSec = 120
+	if _, err := svc.ReviewDelivery(context.Background(), "acceptor", d.ID, DeliveryApproved, "", "", v); err != nil {
+		t.Fatalf("review: %v", err)
+	}
+	if want := LeaseNowMs() + 100_000; leaseDuringWait < want {
+		t.Fatalf("expected the claim held for the CI timeout while polling, lease ends at %d (< %d)", leaseDuringWait, want)
+	}
+}
@@ -0,0 +1,201 @@
+package swarm
+
+import (
+	"os"
+	"strings"
+)
+
+// Claim readiness checks. Before a task can be claimed (claimIssueTask, claimNextIssueTask, automatic
+// assignment and dispatch) its required issue and task docs must exist; on top of that an issue can list
+// claim_checks naming further validators. Built in are context_tasks_done (every context task is done,
+// i.e. approved) and issue_doc_contains (an issue doc contains a marker such as "Status: signed off").
+// Embedders can plug in their own with RegisterClaimValidator.
+
+const (
+	ClaimCheckRequiredDocs     = "required_docs"
+	ClaimCheckContextTasksDone = "context_tasks_done"
+	ClaimCheckIssueDocContains = "issue_doc_contains"
+)
+
+// ClaimCheck names a validator and its arguments.
+type ClaimCheck struct {
+	Validator string `json:"validator"`
+	Doc       string `json:"doc,omitempty"`  // issue_doc_contains: issue doc name
+	Text      string `json:"text,omitempty"` // issue_doc_contains: text the doc must contain
+}
+
+// ClaimValidator reports why task is not ready to be claimed, or nil when it is. It runs under the store
+// lock, so it must not call IssueService methods that take the lock.
+type ClaimValidator func(issueID string, task *IssueTask, check ClaimCheck) error
+
+// RegisterClaimValidator adds a validator that issues can list in claim_checks; a nil validator removes
+// it. Built-in names cannot be replaced.
+func (s *IssueService) RegisterClaimValidator(name string, v ClaimValidator) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Errorf(CodeInvalidArgument, "validator name is required")
+	}
+	if s.builtinClaimValidator(name) != nil {
+		return Errorf(CodeAlreadyExists, "claim validator '%s' is built in", name)
+	}
+	if v == nil {
+		delete(s.claimValidators, name)
+		return nil
+	}
+	if s.claimValidators == nil {
+		s.claimValidators = map[string]ClaimValidator{}
+	}
+	s.claimValidators[name] = v
+	return nil
+}
+
+// SetIssueClaimChecks replaces the readiness checks of an issue; an empty list leaves only the
+// required-docs check.
+func (s *IssueService) SetIssueClaimChecks(actor, issueID string, checks []ClaimCheck, expectedRev int64) (*Issue, error) {
+	if issueID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
+	}
+	if actor == "" {
+		actor = "lead"
+	}
+	for i := range checks {
+		c := &checks[i]
+		c.Validator, c.Doc, c.Text = strings.TrimSpace(c.Validator), strings.TrimSpace(c.Doc), strings.TrimSpace(c.Text)
+		if s.claimValidator(c.Validator) == nil {
+			return nil, Errorf(CodeInvalidArgument, "checks[%d]: unknown claim validator '%s'", i, c.Validator).
+				With("validators", s.ClaimValidatorNames())
+		}
+		if c.Validator == ClaimCheckIssueDocContains && (c.Doc == "" || c.Text == "") {
+			return nil, Errorf(CodeInvalidArgument, "checks[%d]: %s needs doc and text", i, c.Validator)
+		}
+	}
+
+	var result *Issue
+	err := s.store.WithLock(func() error {
+		var issue Issue
+		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+			return Errorf(CodeNotFound, "issue '%s' not found", issueID)
+		}
+		if err := checkRev("issue", issueID, expectedRev, issue.Rev); err != nil {
+			return err
+		}
+		issue.ClaimChecks = checks
+		issue.UpdatedAt = NowStr()
+		if err := s.saveIssueLocked(&issue); err != nil {
+			return err
+		}
+		result = &issue
+		names := make([]string, 0, len(checks))
+		for _, c := range checks {
+			names = append(names, c.Validator)
+		}
+		return s.appendEventLocked(issueID, IssueEvent{Type: EventIssueClaimChecks, IssueID: issueID, Actor: actor, Detail: strings.Join(names, ","), Timestamp: issue.UpdatedAt})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.bump(issueID)
+	return result, nil
+}
+
+// ClaimValidatorNames lists the validators claim_checks can name.
+func (s *IssueService) ClaimValidatorNames() []string {
+	names := []string{ClaimCheckRequiredDocs, ClaimCheckContextTasksDone, ClaimCheckIssueDocContains}
+	for n := range s.claimValidators {
+		names = append(names, n)
+	}
+	return names
+}
+
+func (s *IssueService) claimValidator(name string) ClaimValidator {
+	if v := s.builtinClaimValidator(name); v != nil {
+		return v
+	}
+	return s.claimValidators[name]
+}
+
+func (s *IssueService) builtinClaimValidator(name string) ClaimValidator {
+	switch name {
+	case ClaimCheckRequiredDocs:
+		return s.requiredDocsPresent
+	case ClaimCheckContextTasksDone:
+		return s.contextTasksDone
+	case ClaimCheckIssueDocContains:
+		return s.issueDocContains
+	}
+	return nil
+}
+
+// checkClaimReadyLocked runs the required-docs check and the issue's claim_checks against task. Must be
+// called under store lock.
+func (s *IssueService) checkClaimReadyLocked(issueID string, task *IssueTask) error {
+	if err := s.requiredDocsPresent(issueID, task, ClaimCheck{}); err != nil {
+		return err
+	}
+	var issue Issue
+	if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+		return nil
+	}
+	for _, c := range issue.ClaimChecks {
+		v := s.claimValidator(c.Validator)
+		if v == nil {
+			return Errorf(CodeInvalidState, "task '%s' is not ready to claim: claim validator '%s' is not registered", task.ID, c.Validator).
+				With("claim_check", c.Validator)
+		}
+		if err := v(issueID, task, c); err != nil {
+			if ErrorCode(err) != CodeInternal {
+				return err
+			}
+			return Errorf(CodeInvalidState, "task '%s' is not ready to claim: %s: %v", task.ID, c.Validator, err).
+				With("claim_check", c.Validator)
+		}
+	}
+	return nil
+}
+
+// readyToClaimLocked reports whether checkClaimReadyLocked passes. Must be called under store lock.
+func (s *IssueService) readyToClaimLocked(issueID string, task *IssueTask) bool {
+	return s.checkClaimReadyLocked(issueID, task) == nil
+}
+
+func (s *IssueService) requiredDocsPresent(issueID string, task *IssueTask, _ ClaimCheck) error {
+	for _, n := range task.RequiredIssueDocs {
+		if !s.store.Exists("issues", issueID, "docs", n+".md") {
+			return Errorf(CodeInvalidArgument, "missing required issue doc: %s", n)
+		}
+	}
+	for _, n := range task.RequiredTaskDocs {
+		if !s.store.Exists("issues", issueID, "tasks", task.ID+".docs", n+".md") {
+			return Errorf(CodeInvalidArgument, "missing required task doc: %s", n)
+		}
+	}
+	return nil
+}
+
+func (s *IssueService) contextTasksDone(issueID string, task *IssueTask, _ ClaimCheck) error {
+	var pending []string
+	for _, id := range task.ContextTaskIDs {
+		t, err := s.loadTaskLocked(issueID, id)
+		if err != nil {
+			pending = append(pending, id+":missing")
+			continue
+		}
+		if t.Status != IssueTaskDone {
+			pending = append(pending, id+":"+t.Status)
+		}
+	}
+	if len(pending) > 0 {
+		return Errorf(CodeInvalidState, "task '%s' is not ready to claim: context tasks not done: %s", task.ID, strings.Join(pending, ", ")).
+			With("claim_check", ClaimCheckContextTasksDone)
+	}
+	return nil
+}
+
+func (s *IssueService) issueDocContains(issueID string, task *IssueTask, c ClaimCheck) error {
+	b, err := os.ReadFile(s.store.Path("issues", issueID, "docs", c.Doc+".md"))
+	if err != nil || !strings.Contains(string(b), c.Text) {
+		return Errorf(CodeInvalidState, "task '%s' is not ready to claim: issue doc '%s' does not contain %q", task.ID, c.Doc, c.Text).
+			With("claim_check", ClaimCheckIssueDocContains)
+	}
+	return nil
+}
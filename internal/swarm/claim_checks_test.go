@@ -0,0 +1,83 @@
+package swarm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClaimChecks(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 3}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	dep := &IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskInProgress, ClaimedBy: "w1"}
+	for _, task := range []*IssueTask{dep, {ID: "task-2", IssueID: issueID, Status: IssueTaskOpen, ContextTaskIDs: []string{"task-1"}}} {
+		if err := svc.saveTaskLocked(issueID, task); err != nil {
+			t.Fatalf("write task: %v", err)
+		}
+	}
+
+	if _, err := svc.SetIssueClaimChecks("lead", issueID, []ClaimCheck{{Validator: "owner_approved"}}, 0); ErrorCode(err) != CodeInvalidArgument {
+		t.Fatalf("expected an unknown validator to be rejected, got %v", err)
+	}
+	if _, err := svc.SetIssueClaimChecks("lead", issueID, []ClaimCheck{{Validator: ClaimCheckIssueDocContains, Doc: "design"}}, 0); ErrorCode(err) != CodeInvalidArgument {
+		t.Fatalf("expected issue_doc_contains without text to be rejected, got %v", err)
+	}
+	if err := svc.RegisterClaimValidator(ClaimCheckRequiredDocs, nil); ErrorCode(err) != CodeAlreadyExists {
+		t.Fatalf("expected a built-in validator to stay, got %v", err)
+	}
+	frozen := true
+	if err := svc.RegisterClaimValidator("not_frozen", func(issueID string, task *IssueTask, _ ClaimCheck) error {
+		if frozen {
+			return errors.New("code freeze")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	issue, err := svc.SetIssueClaimChecks("lead", issueID, []ClaimCheck{
+		{Validator: ClaimCheckContextTasksDone},
+		{Validator: ClaimCheckIssueDocContains, Doc: "design", Text: "Status: signed off"},
+		{Validator: "not_frozen"},
+	}, 0)
+	if err != nil || len(issue.ClaimChecks) != 3 {
+		t.Fatalf("set checks: %+v (%v)", issue, err)
+	}
+
+	claim := func() error {
+		_, err := svc.ClaimTask(issueID, "task-2", "w2", "", 0)
+		return err
+	}
+	if err := claim(); ErrorCode(err) != CodeInvalidState {
+		t.Fatalf("expected the unfinished context task to block the claim, got %v", err)
+	}
+	if p, err := svc.PreviewTask(issueID, "task-2"); err != nil || p.Claimable || p.NotReady == "" {
+		t.Fatalf("expected the preview to report the failing check, got %+v (%v)", p, err)
+	}
+	dep.Status = IssueTaskDone
+	if err := svc.saveTaskLocked(issueID, dep); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	if err := writeDocFile(store.Path("issues", issueID, "docs"), "design.md", "# Design\n\nStatus: draft\n"); err != nil {
+		t.Fatalf("write doc: %v", err)
+	}
+	if err := claim(); ErrorCode(err) != CodeInvalidState {
+		t.Fatalf("expected the unsigned design doc to block the claim, got %v", err)
+	}
+	if err := writeDocFile(store.Path("issues", issueID, "docs"), "design.md", "# Design\n\nStatus: signed off\n"); err != nil {
+		t.Fatalf("write doc: %v", err)
+	}
+	if err := claim(); ErrorCode(err) != CodeInvalidState {
+		t.Fatalf("expected the custom validator to block the claim, got %v", err)
+	}
+	frozen = false
+	if err := claim(); err != nil {
+		t.Fatalf("expected the claim to pass every check, got %v", err)
+	}
+}
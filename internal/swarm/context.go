@@ -0,0 +1,48 @@
+package swarm
+
+import "context"
+
+// Request-scoped values carried by the contexts the MCP layer passes into the services, so code that
+// logs on behalf of a tool call can say which request and actor it belongs to.
+
+type ctxKey int
+
+const (
+	ctxKeyRequestID ctxKey = iota
+	ctxKeyActor
+)
+
+// WithRequestID returns ctx carrying the JSON-RPC request id.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, ctxKeyRequestID, requestID)
+}
+
+// WithActor returns ctx carrying the acting member or worker id.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, ctxKeyActor, actor)
+}
+
+// RequestIDFromContext returns the request id set by WithRequestID, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	v, _ := ctx.Value(ctxKeyRequestID).(string)
+	return v
+}
+
+// ActorFromContext returns the actor set by WithActor, or "".
+func ActorFromContext(ctx context.Context) string {
+	v, _ := ctx.Value(ctxKeyActor).(string)
+	return v
+}
+
+type shortTimeoutsKey struct{}
+
+// WithShortTimeouts returns ctx under which long-poll timeouts below the service's minimum are honored
+// as given, for callers that explicitly asked for a short wait.
+func WithShortTimeouts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, shortTimeoutsKey{}, true)
+}
+
+func shortTimeoutsAllowed(ctx context.Context) bool {
+	v, _ := ctx.Value(shortTimeoutsKey{}).(bool)
+	return v
+}
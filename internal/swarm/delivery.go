@@ -1,7 +1,7 @@
 package swarm
 
 import (
-	"fmt"
+	"context"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -27,28 +27,28 @@ func validateTestEvidence(e TestEvidence) error {
 	docPath := filepath.Base(e.DocPath)
 	matched, err := regexp.MatchString(`^issue-[a-zA-Z0-9-]+-test-steps\.md$`, docPath)
 	if err != nil {
-		return fmt.Errorf("test_evidence.doc_path regex validation error: %v", err)
+		return Errorf(CodeInvalidArgument, "test_evidence.doc_path regex validation error: %v", err)
 	}
 	if !matched {
-		return fmt.Errorf("test_evidence.doc_path must match format 'issue-xxx-test-steps.md' where xxx contains letters, numbers, or hyphens (got: %s)", docPath)
+		return Errorf(CodeInvalidArgument, "test_evidence.doc_path must match format 'issue-xxx-test-steps.md' where xxx contains letters, numbers, or hyphens (got: %s)", docPath)
 	}
 	if len(e.DocCommands) == 0 {
-		return fmt.Errorf("test_evidence.doc_commands is required")
+		return Errorf(CodeInvalidArgument, "test_evidence.doc_commands is required")
 	}
 	for i, c := range e.DocCommands {
 		if strings.TrimSpace(c) == "" {
-			return fmt.Errorf("test_evidence.doc_commands[%d] is empty", i)
+			return Errorf(CodeInvalidArgument, "test_evidence.doc_commands[%d] is empty", i)
 		}
 	}
 	if len(e.DocResults) != len(e.DocCommands) {
-		return fmt.Errorf("test_evidence.doc_results must align with doc_commands")
+		return Errorf(CodeInvalidArgument, "test_evidence.doc_results must align with doc_commands")
 	}
 	for i, r := range e.DocResults {
 		if strings.TrimSpace(r.Command) == "" {
-			return fmt.Errorf("test_evidence.doc_results[%d].command is required", i)
+			return Errorf(CodeInvalidArgument, "test_evidence.doc_results[%d].command is required", i)
 		}
 		if strings.TrimSpace(r.Output) == "" {
-			return fmt.Errorf("test_evidence.doc_results[%d].output is required", i)
+			return Errorf(CodeInvalidArgument, "test_evidence.doc_results[%d].output is required", i)
 		}
 	}
 	return nil
@@ -59,25 +59,60 @@ func validateVerification(v Verification, e TestEvidence) error {
 		return err
 	}
 	if len(e.DocCommands) == 0 {
-		return fmt.Errorf("delivery test_evidence.doc_commands is empty")
+		return Errorf(CodeInvalidArgument, "delivery test_evidence.doc_commands is empty")
 	}
 	if len(v.DocResults) != len(e.DocCommands) {
-		return fmt.Errorf("verification.doc_results must align with delivery test_evidence.doc_commands")
+		return Errorf(CodeInvalidArgument, "verification.doc_results must align with delivery test_evidence.doc_commands")
 	}
 	for i, r := range v.DocResults {
 		if strings.TrimSpace(r.Command) == "" {
-			return fmt.Errorf("verification.doc_results[%d].command is required", i)
+			return Errorf(CodeInvalidArgument, "verification.doc_results[%d].command is required", i)
 		}
 		if strings.TrimSpace(r.Output) == "" {
-			return fmt.Errorf("verification.doc_results[%d].output is required", i)
+			return Errorf(CodeInvalidArgument, "verification.doc_results[%d].output is required", i)
 		}
 	}
 	return nil
 }
 
+// taskLocksHeldLocked returns the unexpired file lock leases taken for tasks: leases naming one of the
+// tasks and scoped to the issue, or unscoped and owned by the task's claimer or submitter (task IDs
+// repeat across issues). Must be called under store lock.
+func (s *IssueService) taskLocksHeldLocked(issueID string, tasks []IssueTask) []Lease {
+	byID := make(map[string]IssueTask, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+	now := leaseNow()
+	var held []Lease
+	for _, f := range listJSONOrEmpty(s.store, s.store.Path("locks", "leases")) {
+		var l Lease
+		if err := s.store.ReadJSON(f, &l); err != nil {
+			continue
+		}
+		if exp, err := time.Parse(time.RFC3339, l.ExpiresAt); err == nil && now.After(exp) {
+			continue
+		}
+		t, ok := byID[l.TaskID]
+		if !ok {
+			continue
+		}
+		if l.Scope == issueID || (l.Scope == "" && (l.Owner == t.ClaimedBy || l.Owner == t.Submitter)) {
+			held = append(held, l)
+		}
+	}
+	return held
+}
+
 func (s *IssueService) CreateDelivery(actor, issueID, summary, refs string, artifacts DeliveryArtifacts, evidence TestEvidence) (*Delivery, error) {
+	return s.CreateScopedDelivery(actor, issueID, DeliveryScope{}, summary, refs, artifacts, evidence)
+}
+
+// CreateScopedDelivery submits a delivery covering scope.TaskIDs only (a milestone); an empty scope covers all tasks.
+// Only the covered tasks must be done.
+func (s *IssueService) CreateScopedDelivery(actor, issueID string, scope DeliveryScope, summary, refs string, artifacts DeliveryArtifacts, evidence TestEvidence) (*Delivery, error) {
 	if issueID == "" {
-		return nil, fmt.Errorf("issue_id is required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
 	}
 	if actor == "" {
 		actor = "lead"
@@ -89,23 +124,58 @@ func (s *IssueService) CreateDelivery(actor, issueID, summary, refs string, arti
 		return nil, err
 	}
 	if artifacts.TestResult != "passed" && artifacts.TestResult != "failed" {
-		return nil, fmt.Errorf("artifacts.test_result must be 'passed' or 'failed'")
+		return nil, Errorf(CodeInvalidArgument, "artifacts.test_result must be 'passed' or 'failed'")
 	}
 	if len(artifacts.TestCases) == 0 {
-		return nil, fmt.Errorf("artifacts.test_cases is required")
+		return nil, Errorf(CodeInvalidArgument, "artifacts.test_cases is required")
 	}
 	if len(artifacts.ChangedFiles) == 0 {
-		return nil, fmt.Errorf("artifacts.changed_files is required")
+		return nil, Errorf(CodeInvalidArgument, "artifacts.changed_files is required")
 	}
 	if len(artifacts.ReviewedRefs) == 0 {
-		return nil, fmt.Errorf("artifacts.reviewed_refs is required")
+		return nil, Errorf(CodeInvalidArgument, "artifacts.reviewed_refs is required")
+	}
+
+	milestone := strings.TrimSpace(scope.Milestone)
+	var scopeIDs []string
+	for _, id := range scope.TaskIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			scopeIDs = append(scopeIDs, id)
+		}
+	}
+	if milestone != "" && len(scopeIDs) == 0 {
+		return nil, Errorf(CodeInvalidArgument, "task_ids is required when milestone is set")
 	}
 
-	// Validate all tasks are done before delivery.
+	// Validate all covered tasks are done before delivery.
 	tasks, err := s.ListTasks(issueID, "")
 	if err != nil {
 		return nil, err
 	}
+	if len(scopeIDs) > 0 {
+		byID := make(map[string]IssueTask, len(tasks))
+		for _, t := range tasks {
+			byID[t.ID] = t
+		}
+		scoped := make([]IssueTask, 0, len(scopeIDs))
+		seen := map[string]bool{}
+		for _, id := range scopeIDs {
+			t, ok := byID[id]
+			if !ok {
+				return nil, Errorf(CodeNotFound, "task '%s' not found in issue '%s'", id, issueID)
+			}
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			scoped = append(scoped, t)
+		}
+		tasks = scoped
+	}
+	taskIDs := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		taskIDs = append(taskIDs, t.ID)
+	}
 	var notDone []string
 	for _, t := range tasks {
 		if t.Status != IssueTaskDone {
@@ -113,7 +183,7 @@ func (s *IssueService) CreateDelivery(actor, issueID, summary, refs string, arti
 		}
 	}
 	if len(notDone) > 0 {
-		return nil, fmt.Errorf("cannot deliver issue: tasks not done: %s", strings.Join(notDone, ", "))
+		return nil, Errorf(CodeInvalidState, "cannot deliver issue: tasks not done: %s", strings.Join(notDone, ", "))
 	}
 
 	changedUnion := map[string]struct{}{}
@@ -126,32 +196,67 @@ func (s *IssueService) CreateDelivery(actor, issueID, summary, refs string, arti
 		}
 	}
 	if len(artifacts.ChangedFiles) < len(changedUnion) {
-		return nil, fmt.Errorf("artifacts.changed_files is insufficient; please review and include all changed files")
+		return nil, Errorf(CodeInvalidArgument, "artifacts.changed_files is insufficient; please review and include all changed files")
 	}
 
-	s.SweepExpired()
-
-	var result *Delivery
-	err = s.store.WithLock(func() error {
-		if !s.store.Exists("issues", issueID, "issue.json") {
-			return fmt.Errorf("issue '%s' not found", issueID)
-		}
+	if err := s.runValidationHooks(context.Background(), ValidationPayload{Event: HookOnDelivery, IssueID: issueID, Actor: actor, Summary: summary, Artifacts: artifacts}); err != nil {
+		return nil, err
+	}
 
+	// checkDeliverableLocked rejects the delivery while the issue is closed, an artifact link is dangling
+	// or a worker still holds file locks for the tasks. Call under store lock.
+	checkDeliverableLocked := func() error {
 		var issue Issue
 		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+			if os.IsNotExist(err) {
+				return Errorf(CodeNotFound, "issue '%s' not found", issueID)
+			}
 			return err
 		}
 		if issue.Status != IssueOpen && issue.Status != IssueInProgress {
-			return fmt.Errorf("cannot submit delivery: issue status is '%s', must be 'open' or 'in_progress'", issue.Status)
+			return Errorf(CodeInvalidState, "cannot submit delivery: issue status is '%s', must be 'open' or 'in_progress'", issue.Status)
+		}
+		if err := s.checkArtifactLinksLocked(issueID, artifacts.Links); err != nil {
+			return err
+		}
+		if held := s.taskLocksHeldLocked(issueID, tasks); len(held) > 0 {
+			ids := make([]string, 0, len(held))
+			for _, l := range held {
+				ids = append(ids, l.LeaseID+" ("+l.Owner+", "+l.TaskID+")")
+			}
+			return Errorf(CodeLockConflict, "cannot deliver issue: file locks still held for its tasks: %s; wait for the workers to unlock or have an admin forceUnlock them", strings.Join(ids, ", ")).
+				With("leases", held)
+		}
+		return nil
+	}
+	// Check first, so the test script never runs for a delivery that is then refused, and check again
+	// below since the issue may change while it runs.
+	if err := s.store.WithLock(checkDeliverableLocked); err != nil {
+		return nil, err
+	}
+
+	// Re-run the reported test script outside the lock so acceptors can compare it with the pasted output.
+	serverRun := s.runEvidence(evidence)
+
+	var result *Delivery
+	err = s.store.WithLock(func() error {
+		if err := checkDeliverableLocked(); err != nil {
+			return err
 		}
 
+		prev := s.previousRevisionLocked(issueID, milestone)
+
 		d := &Delivery{
 			ID:               GenID("delivery"),
 			IssueID:          issueID,
+			Revision:         1,
+			Milestone:        milestone,
+			TaskIDs:          taskIDs,
 			Summary:          strings.TrimSpace(summary),
 			Refs:             strings.TrimSpace(refs),
 			Artifacts:        artifacts,
 			TestEvidence:     evidence,
+			ServerRun:        serverRun,
 			Verification:     Verification{},
 			Status:           DeliveryOpen,
 			DeliveredBy:      actor,
@@ -164,6 +269,10 @@ func (s *IssueService) CreateDelivery(actor, issueID, summary, refs string, arti
 			LeaseExpiresAtMs: 0,
 			UpdatedAt:        NowStr(),
 		}
+		if prev != nil {
+			d.PreviousDeliveryID = prev.ID
+			d.Revision = deliveryRevision(prev) + 1
+		}
 		if err := s.store.WriteJSON(s.store.Path("deliveries", d.ID+".json"), d); err != nil {
 			return err
 		}
@@ -172,6 +281,7 @@ func (s *IssueService) CreateDelivery(actor, issueID, summary, refs string, arti
 			return err
 		}
 		result = d
+		s.notify(EventDeliveryCreated, issueID, d)
 		return nil
 	})
 	if err != nil {
@@ -182,21 +292,63 @@ func (s *IssueService) CreateDelivery(actor, issueID, summary, refs string, arti
 	return result, nil
 }
 
+// tasksWithoutApprovedDeliveryLocked returns the IDs of tasks not covered by any approved delivery of issueID.
+// Approved deliveries without task_ids (submitted before scoping existed) cover every task.
+// Must be called under store lock.
+func (s *IssueService) tasksWithoutApprovedDeliveryLocked(issueID string, tasks []IssueTask) []string {
+	// approvedAt[task] is the latest approval covering the task; "*" holds whole-issue deliveries.
+	approvedAt := map[string]string{}
+	for _, f := range listJSONOrEmpty(s.store, s.store.Path("deliveries")) {
+		var d Delivery
+		if err := s.store.ReadJSON(f, &d); err != nil {
+			continue
+		}
+		if d.IssueID != issueID || d.Status != DeliveryApproved {
+			continue
+		}
+		ids := d.TaskIDs
+		if len(ids) == 0 {
+			ids = []string{"*"}
+		}
+		for _, id := range ids {
+			if cur, ok := approvedAt[id]; !ok || d.ReviewedAt > cur {
+				approvedAt[id] = d.ReviewedAt
+			}
+		}
+	}
+	var out []string
+	for _, t := range tasks {
+		at, ok := approvedAt[t.ID]
+		if whole, wok := approvedAt["*"]; wok && (!ok || whole > at) {
+			at, ok = whole, true
+		}
+		// A delivery approved before the task was reopened does not cover the follow-up work.
+		if !ok || (t.ReopenedAt != "" && at < t.ReopenedAt) {
+			out = append(out, t.ID)
+		}
+	}
+	return out
+}
+
 // WaitDeliveries blocks until at least one open delivery is available for review.
-// It uses acceptor inbox claim semantics (single-consumer). Returned deliveries are already claimed (status=in_review).
+// It uses acceptor inbox claim semantics (single-consumer). Returned deliveries are already claimed (status=in_review)
+// by actor, so several acceptor instances can share one queue; limit defaults to 1 so each call takes a single
+// delivery and the remaining ones stay available to other acceptors.
 // status is kept for backward compatibility; only "open" is supported in v2.
-func (s *IssueService) WaitDeliveries(status string, timeoutSec, limit int) ([]Delivery, error) {
-	s.SweepExpired()
+func (s *IssueService) WaitDeliveries(ctx context.Context, actor, status string, timeoutSec, limit int) ([]Delivery, error) {
+	if strings.TrimSpace(actor) == "" {
+		actor = "acceptor"
+	}
 	status = strings.TrimSpace(strings.ToLower(status))
 	if status == "" {
 		status = DeliveryOpen
 	}
 	if status != DeliveryOpen {
-		return nil, fmt.Errorf("only status '%s' is supported", DeliveryOpen)
+		return nil, Errorf(CodeInvalidArgument, "only status '%s' is supported", DeliveryOpen)
 	}
-	timeoutSec = s.normalizeTimeoutSec(timeoutSec)
+	timeoutSec = s.normalizeTimeoutSec(ctx, timeoutSec)
 	if limit <= 0 {
-		limit = 50
+		limit = 1
 	}
 	if limit > 200 {
 		limit = 200
@@ -211,19 +363,24 @@ func (s *IssueService) WaitDeliveries(status string, timeoutSec, limit int) ([]D
 
 		// Backfill/compat: deliveries may exist in open status but have no inbox item (legacy data).
 		// In that case, claim directly instead of blocking forever on inbox.
-		s.SweepExpired()
 		existing, err := s.ListDeliveries(DeliveryOpen, "", "", "")
 		if err != nil {
 			return nil, err
 		}
-		for _, cand := range existing {
+		// ListDeliveries is newest-first; claim oldest-first so deliveries are served in FIFO order.
+		for i := len(existing) - 1; i >= 0; i-- {
+			cand := existing[i]
 			if timeExpired(deadline) {
 				break
 			}
-			d, err := s.ClaimDelivery("acceptor", cand.ID, 0)
+			d, err := s.ClaimDelivery(actor, cand.ID, 0)
 			if err != nil {
 				continue // Try next delivery
 			}
+			_ = s.store.WithLock(func() error {
+				s.ackAcceptorInboxByDeliveryLocked(d.ID)
+				return nil
+			})
 			out = append(out, *d)
 			if len(out) >= limit {
 				break
@@ -233,7 +390,7 @@ func (s *IssueService) WaitDeliveries(status string, timeoutSec, limit int) ([]D
 			break
 		}
 
-		item, err := s.claimAcceptorDeliveryInboxBlocking("acceptor", int(time.Until(deadline).Seconds()))
+		item, err := s.claimAcceptorDeliveryInboxBlocking(ctx, actor, int(time.Until(deadline).Seconds()))
 		if err != nil {
 			return nil, err
 		}
@@ -242,7 +399,7 @@ func (s *IssueService) WaitDeliveries(status string, timeoutSec, limit int) ([]D
 		}
 
 		// Claim the delivery (atomically transitions to in_review).
-		d, err := s.ClaimDelivery("acceptor", item.RefID, 0)
+		d, err := s.ClaimDelivery(actor, item.RefID, 0)
 		if err != nil {
 			// If claim fails (already claimed/reviewed), mark inbox done to prevent reprocessing.
 			_ = s.store.WithLock(func() error {
@@ -262,7 +419,7 @@ func (s *IssueService) WaitDeliveries(status string, timeoutSec, limit int) ([]D
 
 func (s *IssueService) GetDelivery(deliveryID string) (*Delivery, error) {
 	if deliveryID == "" {
-		return nil, fmt.Errorf("delivery_id is required")
+		return nil, Errorf(CodeInvalidArgument, "delivery_id is required")
 	}
 	var d Delivery
 	if err := s.store.ReadJSON(s.store.Path("deliveries", deliveryID+".json"), &d); err != nil {
@@ -272,8 +429,6 @@ func (s *IssueService) GetDelivery(deliveryID string) (*Delivery, error) {
 }
 
 func (s *IssueService) ListDeliveries(status, issueID, deliveredBy, reviewedBy string) ([]Delivery, error) {
-	s.SweepExpired()
-
 	status = strings.TrimSpace(strings.ToLower(status))
 	if status == "" {
 		status = "all"
@@ -320,21 +475,23 @@ func (s *IssueService) ListDeliveries(status, issueID, deliveredBy, reviewedBy s
 		out = append(out, d)
 	}
 
+	// Newest first; ties in id order, as the directory listing returned them.
 	sort.SliceStable(out, func(i, j int) bool {
-		return out[i].DeliveredAt > out[j].DeliveredAt
+		if out[i].DeliveredAt != out[j].DeliveredAt {
+			return out[i].DeliveredAt > out[j].DeliveredAt
+		}
+		return out[i].ID < out[j].ID
 	})
 	return out, nil
 }
 
 func (s *IssueService) ClaimDelivery(actor, deliveryID string, extendSec int) (*Delivery, error) {
 	if deliveryID == "" {
-		return nil, fmt.Errorf("delivery_id is required")
+		return nil, Errorf(CodeInvalidArgument, "delivery_id is required")
 	}
 	if actor == "" {
 		actor = "acceptor"
 	}
-	s.SweepExpired()
-
 	var result *Delivery
 	err := s.store.WithLock(func() error {
 		var d Delivery
@@ -342,7 +499,7 @@ func (s *IssueService) ClaimDelivery(actor, deliveryID string, extendSec int) (*
 			return err
 		}
 		if d.Status != DeliveryOpen {
-			return fmt.Errorf("delivery '%s' is not open (status: %s)", deliveryID, d.Status)
+			return Errorf(CodeInvalidState, "delivery '%s' is not open (status: %s)", deliveryID, d.Status)
 		}
 		d.Status = DeliveryInReview
 		d.ClaimedBy = actor
@@ -357,11 +514,12 @@ func (s *IssueService) ClaimDelivery(actor, deliveryID string, extendSec int) (*
 		if ttlSec < s.defaultTimeoutSec {
 			ttlSec = s.defaultTimeoutSec
 		}
-		d.LeaseExpiresAtMs = time.Now().UnixMilli() + int64(ttlSec)*1000
+		d.LeaseExpiresAtMs = LeaseNowMs() + int64(ttlSec)*1000
 		d.UpdatedAt = NowStr()
 		if err := s.store.WriteJSON(s.store.Path("deliveries", deliveryID+".json"), &d); err != nil {
 			return err
 		}
+		s.trackDeliveryExpiry(&d)
 		result = &d
 		return nil
 	})
@@ -374,13 +532,11 @@ func (s *IssueService) ClaimDelivery(actor, deliveryID string, extendSec int) (*
 
 func (s *IssueService) ExtendDeliveryLease(actor, deliveryID string, extendSec int) (*Delivery, error) {
 	if deliveryID == "" {
-		return nil, fmt.Errorf("delivery_id is required")
+		return nil, Errorf(CodeInvalidArgument, "delivery_id is required")
 	}
 	if actor == "" {
 		actor = "acceptor"
 	}
-	s.SweepExpired()
-
 	var result *Delivery
 	err := s.store.WithLock(func() error {
 		var d Delivery
@@ -388,10 +544,10 @@ func (s *IssueService) ExtendDeliveryLease(actor, deliveryID string, extendSec i
 			return err
 		}
 		if d.Status != DeliveryInReview {
-			return fmt.Errorf("delivery '%s' is not in_review (status: %s)", deliveryID, d.Status)
+			return Errorf(CodeInvalidState, "delivery '%s' is not in_review (status: %s)", deliveryID, d.Status)
 		}
 		if d.ClaimedBy != actor {
-			return fmt.Errorf("delivery '%s' is not claimed by actor", deliveryID)
+			return Errorf(CodeNotOwner, "delivery '%s' is not claimed by actor", deliveryID)
 		}
 		ttlSec := extendSec
 		if ttlSec <= 0 {
@@ -403,11 +559,12 @@ func (s *IssueService) ExtendDeliveryLease(actor, deliveryID string, extendSec i
 		if ttlSec < s.defaultTimeoutSec {
 			ttlSec = s.defaultTimeoutSec
 		}
-		d.LeaseExpiresAtMs = time.Now().UnixMilli() + int64(ttlSec)*1000
+		d.LeaseExpiresAtMs = LeaseNowMs() + int64(ttlSec)*1000
 		d.UpdatedAt = NowStr()
 		if err := s.store.WriteJSON(s.store.Path("deliveries", deliveryID+".json"), &d); err != nil {
 			return err
 		}
+		s.trackDeliveryExpiry(&d)
 		result = &d
 		return nil
 	})
@@ -418,13 +575,38 @@ func (s *IssueService) ExtendDeliveryLease(actor, deliveryID string, extendSec i
 	return result, nil
 }
 
-func (s *IssueService) ReviewDelivery(actor, deliveryID, verdict, feedback, refs string, verification Verification) (*Delivery, error) {
+// holdDeliveryClaim keeps the actor's review claim on a delivery for at least d more, so a long CI wait
+// does not let the lease lapse and hand the delivery to another acceptor. A longer lease is left alone.
+func (s *IssueService) holdDeliveryClaim(actor, deliveryID string, d time.Duration) error {
+	return s.store.WithLock(func() error {
+		var dl Delivery
+		if err := s.store.ReadJSON(s.store.Path("deliveries", deliveryID+".json"), &dl); err != nil {
+			return err
+		}
+		if dl.Status != DeliveryInReview || dl.ClaimedBy != actor {
+			return Errorf(CodeInvalidState, "delivery '%s' is no longer in_review and claimed by actor", deliveryID)
+		}
+		until := LeaseNowMs() + d.Milliseconds()
+		if dl.LeaseExpiresAtMs >= until {
+			return nil
+		}
+		dl.LeaseExpiresAtMs = until
+		dl.UpdatedAt = NowStr()
+		if err := s.store.WriteJSON(s.store.Path("deliveries", deliveryID+".json"), &dl); err != nil {
+			return err
+		}
+		s.trackDeliveryExpiry(&dl)
+		return nil
+	})
+}
+
+func (s *IssueService) ReviewDelivery(ctx context.Context, actor, deliveryID, verdict, feedback, refs string, verification Verification) (*Delivery, error) {
 	if deliveryID == "" {
-		return nil, fmt.Errorf("delivery_id is required")
+		return nil, Errorf(CodeInvalidArgument, "delivery_id is required")
 	}
 	verdict = strings.TrimSpace(strings.ToLower(verdict))
 	if verdict != DeliveryApproved && verdict != DeliveryRejected {
-		return nil, fmt.Errorf("invalid verdict: %s", verdict)
+		return nil, Errorf(CodeInvalidArgument, "invalid verdict: %s", verdict)
 	}
 	if actor == "" {
 		actor = "acceptor"
@@ -432,7 +614,23 @@ func (s *IssueService) ReviewDelivery(actor, deliveryID, verdict, feedback, refs
 	feedback = strings.TrimSpace(feedback)
 	refs = strings.TrimSpace(refs)
 
-	s.SweepExpired()
+	// CI gate: an approval referencing a CI run waits (outside the lock) until the run is green.
+	if verification.CI != nil {
+		d, err := s.GetDelivery(deliveryID)
+		if err != nil {
+			return nil, err
+		}
+		if d.Status != DeliveryInReview || d.ClaimedBy != actor {
+			return nil, Errorf(CodeInvalidState, "delivery '%s' is not in_review and claimed by actor", deliveryID)
+		}
+		ci := *verification.CI
+		if err := s.checkCI(ctx, &ci, verdict == DeliveryApproved, func(wait time.Duration) error {
+			return s.holdDeliveryClaim(actor, deliveryID, wait)
+		}); err != nil {
+			return nil, err
+		}
+		verification.CI = &ci
+	}
 
 	var result *Delivery
 	err := s.store.WithLock(func() error {
@@ -441,10 +639,10 @@ func (s *IssueService) ReviewDelivery(actor, deliveryID, verdict, feedback, refs
 			return err
 		}
 		if d.Status != DeliveryInReview {
-			return fmt.Errorf("delivery '%s' is not in_review (status: %s)", deliveryID, d.Status)
+			return Errorf(CodeInvalidState, "delivery '%s' is not in_review (status: %s)", deliveryID, d.Status)
 		}
 		if d.ClaimedBy != actor {
-			return fmt.Errorf("delivery '%s' is not claimed by actor", deliveryID)
+			return Errorf(CodeNotOwner, "delivery '%s' is not claimed by actor", deliveryID)
 		}
 		if err := validateVerification(verification, d.TestEvidence); err != nil {
 			return err
@@ -464,6 +662,7 @@ func (s *IssueService) ReviewDelivery(actor, deliveryID, verdict, feedback, refs
 			return err
 		}
 		result = &d
+		s.notify(EventDeliveryReviewed, d.IssueID, &d)
 		return nil
 	})
 	if err != nil {
@@ -474,16 +673,15 @@ func (s *IssueService) ReviewDelivery(actor, deliveryID, verdict, feedback, refs
 	return result, nil
 }
 
-func (s *IssueService) WaitDeliveryReviewed(deliveryID string, timeoutSec int) (*Delivery, error) {
+func (s *IssueService) WaitDeliveryReviewed(ctx context.Context, deliveryID string, timeoutSec int) (*Delivery, error) {
 	if deliveryID == "" {
-		return nil, fmt.Errorf("delivery_id is required")
+		return nil, Errorf(CodeInvalidArgument, "delivery_id is required")
 	}
-	timeoutSec = s.normalizeTimeoutSec(timeoutSec)
+	timeoutSec = s.normalizeTimeoutSec(ctx, timeoutSec)
 
 	deadline := time.Now().Add(time.Duration(timeoutSec) * time.Second)
-	poll := 200 * time.Millisecond
 	for {
-		s.SweepExpired()
+		since := s.changeGen()
 		d, err := s.GetDelivery(deliveryID)
 		if err != nil {
 			return nil, err
@@ -493,23 +691,21 @@ func (s *IssueService) WaitDeliveryReviewed(deliveryID string, timeoutSec int) (
 		}
 		remaining := time.Until(deadline)
 		if remaining <= 0 {
-			return nil, fmt.Errorf("timeout waiting for delivery review")
+			return nil, Errorf(CodeTimeout, "timeout waiting for delivery review")
 		}
-		if remaining < poll {
-			time.Sleep(remaining)
-		} else {
-			time.Sleep(poll)
+		if err := s.waitChange(ctx, since, min(remaining, pollInterval)); err != nil {
+			return nil, err
 		}
 	}
 }
 
-func (s *IssueService) SubmitDelivery(actor, issueID, summary, refs string, artifacts DeliveryArtifacts, evidence TestEvidence, timeoutSec int) (map[string]any, error) {
-	timeoutSec = s.normalizeTimeoutSec(timeoutSec)
-	d, err := s.CreateDelivery(actor, issueID, summary, refs, artifacts, evidence)
+func (s *IssueService) SubmitDelivery(ctx context.Context, actor, issueID string, scope DeliveryScope, summary, refs string, artifacts DeliveryArtifacts, evidence TestEvidence, timeoutSec int) (map[string]any, error) {
+	timeoutSec = s.normalizeTimeoutSec(ctx, timeoutSec)
+	d, err := s.CreateScopedDelivery(actor, issueID, scope, summary, refs, artifacts, evidence)
 	if err != nil {
 		return nil, err
 	}
-	reviewed, err := s.WaitDeliveryReviewed(d.ID, timeoutSec)
+	reviewed, err := s.WaitDeliveryReviewed(ctx, d.ID, timeoutSec)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package swarm
 
 import (
+	"context"
 	"testing"
 )
 
@@ -161,7 +162,7 @@ func TestReviewDelivery_RequiresVerificationAlignedWithEvidence(t *testing.T) {
 		t.Fatalf("claim delivery: %v", err)
 	}
 
-	_, err = svc.ReviewDelivery("acceptor", d.ID, DeliveryApproved, "", "", Verification{
+	_, err = svc.ReviewDelivery(context.Background(), "acceptor", d.ID, DeliveryApproved, "", "", Verification{
 		ScriptPassed: true,
 		ScriptResult: "ok",
 		DocPassed:    true,
@@ -171,7 +172,7 @@ func TestReviewDelivery_RequiresVerificationAlignedWithEvidence(t *testing.T) {
 		t.Fatalf("expected error")
 	}
 
-	out, err := svc.ReviewDelivery("acceptor", d.ID, DeliveryApproved, "", "", Verification{
+	out, err := svc.ReviewDelivery(context.Background(), "acceptor", d.ID, DeliveryApproved, "", "", Verification{
 		ScriptPassed: true,
 		ScriptResult: "ok",
 		DocPassed:    true,
@@ -186,3 +187,55 @@ func TestReviewDelivery_RequiresVerificationAlignedWithEvidence(t *testing.T) {
 		t.Fatalf("unexpected status: %s", out.Status)
 	}
 }
+
+func TestCreateDelivery_ServerRunRecordsActualScriptResult(t *testing.T) {
+	root := t.TempDir()
+	store := NewStore(root)
+	store.EnsureDir()
+	store.EnsureDir("issues")
+	store.EnsureDir("deliveries")
+
+	trace := NewTraceService(store)
+	svc := NewIssueService(store, trace, 7200, 3600, 3600, 3600)
+	svc.SetEvidenceRunner(t.TempDir(), 10)
+
+	issueID := "issue-1"
+	store.EnsureDir("issues", issueID)
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{
+		ID:        issueID,
+		Subject:   "s",
+		Status:    IssueOpen,
+		CreatedAt: NowStr(),
+		UpdatedAt: NowStr(),
+	}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+
+	d, err := svc.CreateDelivery("lead", issueID, "sum", "", DeliveryArtifacts{
+		TestResult:   "passed",
+		TestCases:    []string{"go test ./..."},
+		ChangedFiles: []string{"a.go"},
+		ReviewedRefs: []string{"a.go"},
+	}, TestEvidence{
+		ScriptPath:   "scripts/test-issue-1.sh",
+		ScriptCmd:    "echo boom; exit 3",
+		ScriptPassed: true,
+		ScriptResult: "ok",
+		DocPath:      "docs/issue-1-test-steps.md",
+		DocCommands:  []string{"echo hi"},
+		DocResults: []CommandResult{
+			{Command: "echo hi", Passed: true, ExitCode: 0, Output: "hi"},
+		},
+		DocPassed: true,
+	})
+	if err != nil {
+		t.Fatalf("create delivery: %v", err)
+	}
+	run := d.ServerRun
+	if run == nil {
+		t.Fatalf("expected server run")
+	}
+	if run.Passed || run.ExitCode != 3 || run.MatchesReported || run.Output != "boom\n" {
+		t.Fatalf("unexpected server run: %+v", run)
+	}
+}
@@ -0,0 +1,150 @@
+package swarm
+
+import (
+	"strings"
+)
+
+// DeliveryRevision is one attempt in a delivery revision chain, with what changed since the previous attempt.
+type DeliveryRevision struct {
+	Delivery Delivery              `json:"delivery"`
+	Changes  *DeliveryRevisionDiff `json:"changes_from_previous,omitempty"`
+}
+
+type DeliveryRevisionDiff struct {
+	PreviousDeliveryID  string   `json:"previous_delivery_id"`
+	PreviousStatus      string   `json:"previous_status"`
+	PreviousFeedback    string   `json:"previous_feedback"`
+	SummaryChanged      bool     `json:"summary_changed"`
+	AddedChangedFiles   []string `json:"added_changed_files"`
+	RemovedChangedFiles []string `json:"removed_changed_files"`
+	AddedTestCases      []string `json:"added_test_cases"`
+	RemovedTestCases    []string `json:"removed_test_cases"`
+	TestResultChanged   bool     `json:"test_result_changed"`
+}
+
+// deliveryRevision treats legacy deliveries (no revision recorded) as revision 1.
+func deliveryRevision(d *Delivery) int {
+	if d.Revision <= 0 {
+		return 1
+	}
+	return d.Revision
+}
+
+// previousRevisionLocked returns the delivery that a new delivery for issueID and milestone revises: the
+// latest one, unless it was approved. An approved delivery closes its chain, so the next one starts a new
+// chain at revision 1. Must be called under store lock.
+func (s *IssueService) previousRevisionLocked(issueID, milestone string) *Delivery {
+	prev := s.latestDeliveryLocked(issueID, func(d *Delivery) bool { return d.Milestone == milestone })
+	if prev == nil || prev.Status == DeliveryApproved {
+		return nil
+	}
+	return prev
+}
+
+// latestDeliveryLocked returns the most recently delivered delivery for issueID accepted by match (nil matches all).
+// Must be called under store lock.
+func (s *IssueService) latestDeliveryLocked(issueID string, match func(*Delivery) bool) *Delivery {
+	var latest *Delivery
+	for _, f := range listJSONOrEmpty(s.store, s.store.Path("deliveries")) {
+		var d Delivery
+		if err := s.store.ReadJSON(f, &d); err != nil {
+			continue
+		}
+		if d.IssueID != issueID || (match != nil && !match(&d)) {
+			continue
+		}
+		if latest == nil || d.DeliveredAt > latest.DeliveredAt ||
+			(d.DeliveredAt == latest.DeliveredAt && deliveryRevision(&d) > deliveryRevision(latest)) {
+			dc := d
+			latest = &dc
+		}
+	}
+	return latest
+}
+
+// GetDeliveryHistory returns the revision chain ending at deliveryID (oldest first).
+// If deliveryID is empty, the chain ending at the latest delivery of issueID is returned.
+func (s *IssueService) GetDeliveryHistory(deliveryID, issueID string) ([]DeliveryRevision, error) {
+	deliveryID = strings.TrimSpace(deliveryID)
+	issueID = strings.TrimSpace(issueID)
+	if deliveryID == "" && issueID == "" {
+		return nil, Errorf(CodeInvalidArgument, "delivery_id or issue_id is required")
+	}
+
+	var chain []Delivery
+	err := s.store.WithLock(func() error {
+		if deliveryID == "" {
+			latest := s.latestDeliveryLocked(issueID, nil)
+			if latest == nil {
+				return nil
+			}
+			deliveryID = latest.ID
+		}
+		seen := map[string]bool{}
+		for id := deliveryID; id != "" && !seen[id]; {
+			seen[id] = true
+			var d Delivery
+			if err := s.store.ReadJSON(s.store.Path("deliveries", id+".json"), &d); err != nil {
+				if len(chain) == 0 {
+					return Errorf(CodeNotFound, "delivery '%s' not found", id)
+				}
+				break // older revision was removed; return what remains
+			}
+			chain = append(chain, d)
+			id = d.PreviousDeliveryID
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]DeliveryRevision, 0, len(chain))
+	for i := len(chain) - 1; i >= 0; i-- {
+		rev := DeliveryRevision{Delivery: chain[i]}
+		if i+1 < len(chain) {
+			rev.Changes = diffDeliveries(&chain[i+1], &chain[i])
+		}
+		out = append(out, rev)
+	}
+	return out, nil
+}
+
+func diffDeliveries(prev, cur *Delivery) *DeliveryRevisionDiff {
+	addedFiles, removedFiles := diffStringSets(prev.Artifacts.ChangedFiles, cur.Artifacts.ChangedFiles)
+	addedCases, removedCases := diffStringSets(prev.Artifacts.TestCases, cur.Artifacts.TestCases)
+	return &DeliveryRevisionDiff{
+		PreviousDeliveryID:  prev.ID,
+		PreviousStatus:      prev.Status,
+		PreviousFeedback:    prev.Feedback,
+		SummaryChanged:      strings.TrimSpace(prev.Summary) != strings.TrimSpace(cur.Summary),
+		AddedChangedFiles:   addedFiles,
+		RemovedChangedFiles: removedFiles,
+		AddedTestCases:      addedCases,
+		RemovedTestCases:    removedCases,
+		TestResultChanged:   prev.Artifacts.TestResult != cur.Artifacts.TestResult,
+	}
+}
+
+// diffStringSets returns values only in cur (added) and only in prev (removed), preserving input order.
+func diffStringSets(prev, cur []string) (added, removed []string) {
+	inPrev := map[string]bool{}
+	for _, v := range prev {
+		inPrev[strings.TrimSpace(v)] = true
+	}
+	inCur := map[string]bool{}
+	for _, v := range cur {
+		v = strings.TrimSpace(v)
+		inCur[v] = true
+		if v != "" && !inPrev[v] {
+			added = append(added, v)
+		}
+	}
+	for _, v := range prev {
+		v = strings.TrimSpace(v)
+		if v != "" && !inCur[v] {
+			removed = append(removed, v)
+		}
+	}
+	return added, removed
+}
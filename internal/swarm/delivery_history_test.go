@@ -0,0 +1,211 @@
+package swarm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCreateDelivery_LinksResubmissionToRejectedDelivery(t *testing.T) {
+	root := t.TempDir()
+	store := NewStore(root)
+	store.EnsureDir()
+	store.EnsureDir("issues")
+	store.EnsureDir("deliveries")
+
+	trace := NewTraceService(store)
+	svc := NewIssueService(store, trace, 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	store.EnsureDir("issues", issueID)
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{
+		ID:        issueID,
+		Subject:   "s",
+		Status:    IssueOpen,
+		CreatedAt: NowStr(),
+		UpdatedAt: NowStr(),
+	}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+
+	evidence := TestEvidence{
+		ScriptPath:   "scripts/test-issue-1.sh",
+		ScriptCmd:    "bash scripts/test-issue-1.sh",
+		ScriptPassed: true,
+		ScriptResult: "ok",
+		DocPath:      "docs/issue-1-test-steps.md",
+		DocCommands:  []string{"echo hi"},
+		DocResults: []CommandResult{
+			{Command: "echo hi", Passed: true, ExitCode: 0, Output: "hi"},
+		},
+		DocPassed: true,
+	}
+	verification := Verification{
+		ScriptPassed: true,
+		ScriptResult: "ok",
+		DocPassed:    true,
+		DocResults: []CommandResult{
+			{Command: "echo hi", Passed: true, ExitCode: 0, Output: "hi"},
+		},
+	}
+
+	first, err := svc.CreateDelivery("lead", issueID, "sum", "", DeliveryArtifacts{
+		TestResult:   "passed",
+		TestCases:    []string{"go test ./..."},
+		ChangedFiles: []string{"a.go"},
+		ReviewedRefs: []string{"a.go"},
+		TestOutput:   "ok",
+	}, evidence)
+	if err != nil {
+		t.Fatalf("create delivery: %v", err)
+	}
+	if first.Revision != 1 || first.PreviousDeliveryID != "" {
+		t.Fatalf("unexpected first revision: %d prev=%q", first.Revision, first.PreviousDeliveryID)
+	}
+	if _, err := svc.ClaimDelivery("acceptor", first.ID, 0); err != nil {
+		t.Fatalf("claim delivery: %v", err)
+	}
+	if _, err := svc.ReviewDelivery(context.Background(), "acceptor", first.ID, DeliveryRejected, "missing b.go", "", verification); err != nil {
+		t.Fatalf("reject delivery: %v", err)
+	}
+
+	second, err := svc.CreateDelivery("lead", issueID, "sum v2", "", DeliveryArtifacts{
+		TestResult:   "passed",
+		TestCases:    []string{"go test ./..."},
+		ChangedFiles: []string{"b.go"},
+		ReviewedRefs: []string{"b.go"},
+		TestOutput:   "ok",
+	}, evidence)
+	if err != nil {
+		t.Fatalf("resubmit delivery: %v", err)
+	}
+	if second.Revision != 2 || second.PreviousDeliveryID != first.ID {
+		t.Fatalf("unexpected second revision: %d prev=%q", second.Revision, second.PreviousDeliveryID)
+	}
+
+	revs, err := svc.GetDeliveryHistory("", issueID)
+	if err != nil {
+		t.Fatalf("get history: %v", err)
+	}
+	if len(revs) != 2 || revs[0].Delivery.ID != first.ID || revs[1].Delivery.ID != second.ID {
+		t.Fatalf("unexpected chain: %+v", revs)
+	}
+	if revs[0].Changes != nil {
+		t.Fatalf("first revision should have no diff")
+	}
+	c := revs[1].Changes
+	if c == nil || c.PreviousStatus != DeliveryRejected || c.PreviousFeedback != "missing b.go" || !c.SummaryChanged {
+		t.Fatalf("unexpected diff: %+v", c)
+	}
+	if len(c.AddedChangedFiles) != 1 || c.AddedChangedFiles[0] != "b.go" ||
+		len(c.RemovedChangedFiles) != 1 || c.RemovedChangedFiles[0] != "a.go" {
+		t.Fatalf("unexpected file diff: %+v", c)
+	}
+
+	// An approved delivery closes the chain: the next delivery does not revise it.
+	if _, err := svc.ClaimDelivery("acceptor", second.ID, 0); err != nil {
+		t.Fatalf("claim delivery: %v", err)
+	}
+	if _, err := svc.ReviewDelivery(context.Background(), "acceptor", second.ID, DeliveryApproved, "", "", verification); err != nil {
+		t.Fatalf("approve delivery: %v", err)
+	}
+	third, err := svc.CreateDelivery("lead", issueID, "follow-up", "", DeliveryArtifacts{
+		TestResult:   "passed",
+		TestCases:    []string{"go test ./..."},
+		ChangedFiles: []string{"c.go"},
+		ReviewedRefs: []string{"c.go"},
+		TestOutput:   "ok",
+	}, evidence)
+	if err != nil {
+		t.Fatalf("deliver after approval: %v", err)
+	}
+	if third.Revision != 1 || third.PreviousDeliveryID != "" {
+		t.Fatalf("a delivery after an approved one must start a new chain: %d prev=%q", third.Revision, third.PreviousDeliveryID)
+	}
+	if revs, _ := svc.GetDeliveryHistory(third.ID, ""); len(revs) != 1 {
+		t.Fatalf("unexpected chain after approval: %+v", revs)
+	}
+}
+
+func TestCloseIssue_RequiresEveryTaskInApprovedDelivery(t *testing.T) {
+	root := t.TempDir()
+	store := NewStore(root)
+	store.EnsureDir()
+	store.EnsureDir("issues")
+	store.EnsureDir("deliveries")
+
+	trace := NewTraceService(store)
+	svc := NewIssueService(store, trace, 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	store.EnsureDir("issues", issueID, "tasks")
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{
+		ID:        issueID,
+		Subject:   "s",
+		Status:    IssueInProgress,
+		CreatedAt: NowStr(),
+		UpdatedAt: NowStr(),
+	}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	for _, id := range []string{"task-a", "task-b"} {
+		if err := store.WriteJSON(store.Path("issues", issueID, "tasks", id+".json"), &IssueTask{
+			ID:        id,
+			IssueID:   issueID,
+			Subject:   id,
+			Status:    IssueTaskDone,
+			CreatedAt: NowStr(),
+			UpdatedAt: NowStr(),
+		}); err != nil {
+			t.Fatalf("write task: %v", err)
+		}
+	}
+
+	evidence := TestEvidence{
+		ScriptPath:   "scripts/test-issue-1.sh",
+		ScriptCmd:    "bash scripts/test-issue-1.sh",
+		ScriptPassed: true,
+		ScriptResult: "ok",
+		DocPath:      "docs/issue-1-test-steps.md",
+		DocCommands:  []string{"echo hi"},
+		DocResults: []CommandResult{
+			{Command: "echo hi", Passed: true, ExitCode: 0, Output: "hi"},
+		},
+		DocPassed: true,
+	}
+	artifacts := DeliveryArtifacts{
+		TestResult:   "passed",
+		TestCases:    []string{"go test ./..."},
+		ChangedFiles: []string{"a.go"},
+		ReviewedRefs: []string{"a.go"},
+	}
+
+	d, err := svc.CreateScopedDelivery("lead", issueID, DeliveryScope{Milestone: "m1", TaskIDs: []string{"task-a"}}, "sum", "", artifacts, evidence)
+	if err != nil {
+		t.Fatalf("create scoped delivery: %v", err)
+	}
+	if len(d.TaskIDs) != 1 || d.TaskIDs[0] != "task-a" || d.Milestone != "m1" {
+		t.Fatalf("unexpected scope: %+v", d)
+	}
+	if _, err := svc.ClaimDelivery("acceptor", d.ID, 0); err != nil {
+		t.Fatalf("claim delivery: %v", err)
+	}
+	if _, err := svc.ReviewDelivery(context.Background(), "acceptor", d.ID, DeliveryApproved, "", "", Verification{
+		ScriptPassed: true,
+		ScriptResult: "ok",
+		DocPassed:    true,
+		DocResults: []CommandResult{
+			{Command: "echo hi", Passed: true, ExitCode: 0, Output: "hi"},
+		},
+	}); err != nil {
+		t.Fatalf("approve delivery: %v", err)
+	}
+
+	if _, err := svc.CloseIssue("lead", issueID, "done", 0); err == nil || !strings.Contains(err.Error(), "task-b") {
+		t.Fatalf("expected close to fail while task-b is not delivered, got %v", err)
+	}
+
+	if _, err := svc.CreateScopedDelivery("lead", issueID, DeliveryScope{TaskIDs: []string{"task-x"}}, "sum", "", artifacts, evidence); err == nil {
+		t.Fatalf("expected unknown task to be rejected")
+	}
+}
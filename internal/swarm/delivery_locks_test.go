@@ -0,0 +1,76 @@
+package swarm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateDelivery_BlockedByTaskLocks(t *testing.T) {
+	store := NewStore(t.TempDir())
+	trace := NewTraceService(store)
+	svc := NewIssueService(store, trace, 7200, 3600, 3600, 3600)
+	locks := NewLockService(store, trace)
+	workDir := t.TempDir()
+	svc.SetEvidenceRunner(workDir, 10)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Subject: "s", Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 2}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	if err := svc.saveTaskLocked(issueID, &IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskDone, ClaimedBy: "w1"}); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+
+	ctx := context.Background()
+	// Another issue's task-1 and an unscoped lock of someone else do not count.
+	if _, err := locks.LockFiles(ctx, "task-1", "w1", "issue-2", []string{"b.go"}, 600, 0); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if _, err := locks.LockFiles(ctx, "task-1", "w9", "", []string{"c.go"}, 600, 0); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	lease, err := locks.LockFiles(ctx, "task-1", "w1", "", []string{"a.go"}, 600, 0)
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+
+	deliver := func() error {
+		_, err := svc.CreateDelivery("lead", issueID, "sum", "", DeliveryArtifacts{
+			TestResult:   "passed",
+			TestCases:    []string{"go test ./..."},
+			ChangedFiles: []string{"a.go"},
+			ReviewedRefs: []string{"a.go"},
+			TestOutput:   "ok",
+		}, TestEvidence{
+			ScriptPath:   "scripts/test-issue-1.sh",
+			ScriptCmd:    "touch ran",
+			ScriptPassed: true,
+			ScriptResult: "ok",
+			DocPath:      "docs/issue-1-test-steps.md",
+			DocCommands:  []string{"echo hi"},
+			DocResults:   []CommandResult{{Command: "echo hi", Passed: true, Output: "hi"}},
+			DocPassed:    true,
+		})
+		return err
+	}
+	if err := deliver(); ErrorCode(err) != CodeLockConflict {
+		t.Fatalf("expected the worker's lock to block the delivery, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "ran")); !os.IsNotExist(err) {
+		t.Fatalf("the test script must not run for a refused delivery (stat: %v)", err)
+	}
+	if err := locks.Unlock(lease.LeaseID); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if err := deliver(); err != nil {
+		t.Fatalf("expected the delivery once the lock is released, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "ran")); err != nil {
+		t.Fatalf("expected the test script to run for the accepted delivery: %v", err)
+	}
+}
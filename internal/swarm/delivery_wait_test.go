@@ -0,0 +1,30 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWaitDeliveries_TakesOneDeliveryByDefault(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	for _, d := range []*Delivery{
+		{ID: "delivery-1", IssueID: "issue-1", Status: DeliveryOpen, DeliveredAt: "2024-01-01T00:00:00Z"},
+		{ID: "delivery-2", IssueID: "issue-2", Status: DeliveryOpen, DeliveredAt: "2024-01-01T00:00:01Z"},
+	} {
+		if err := store.WriteJSON(store.Path("deliveries", d.ID+".json"), d); err != nil {
+			t.Fatalf("write delivery: %v", err)
+		}
+	}
+
+	short := WithShortTimeouts(context.Background())
+	first, err := svc.WaitDeliveries(short, "acceptor-1", "", 1, 0)
+	if err != nil || len(first) != 1 || first[0].ID != "delivery-1" || first[0].Status != DeliveryInReview {
+		t.Fatalf("expected acceptor-1 to claim only the oldest delivery, got %+v %v", first, err)
+	}
+	second, err := svc.WaitDeliveries(short, "acceptor-2", "", 1, 0)
+	if err != nil || len(second) != 1 || second[0].ID != "delivery-2" {
+		t.Fatalf("expected the other delivery to be left for acceptor-2, got %+v %v", second, err)
+	}
+}
@@ -0,0 +1,165 @@
+package swarm
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Calibration thresholds: a difficulty with at least calibrationMinReviews reviewed submissions whose
+// rejection rate reaches calibrationRejectRate is considered under-rated.
+const (
+	calibrationMinReviews = 5
+	calibrationRejectRate = 0.4
+)
+
+// calibrationCacheTTL is how long CalibrateDifficulty reuses a calibration before scanning the store
+// again. The thresholds need several reviews to move, so a few minutes of lag do not matter, while a
+// scan reads every task and submission file.
+const calibrationCacheTTL = 5 * time.Minute
+
+type calibrationCache struct {
+	mu  sync.Mutex
+	c   *DifficultyCalibration
+	at  time.Time
+	now func() time.Time // for tests; nil = time.Now
+}
+
+// DifficultyStats is the review history of tasks created with one difficulty.
+type DifficultyStats struct {
+	Difficulty         string  `json:"difficulty"`
+	Tasks              int     `json:"tasks"`
+	Reviews            int     `json:"reviews"`
+	Approved           int     `json:"approved"`
+	Rejected           int     `json:"rejected"`
+	ApprovalRate       float64 `json:"approval_rate"`
+	AvgCompletionScore float64 `json:"avg_completion_score"` // over reviewed submissions
+	UnderRated         bool    `json:"under_rated,omitempty"`
+	Suggestion         string  `json:"suggestion,omitempty"`
+}
+
+// DifficultyCalibration reports per-difficulty outcomes across all issues.
+type DifficultyCalibration struct {
+	Difficulties []DifficultyStats `json:"difficulties"`
+	MinReviews   int               `json:"min_reviews"`
+	RejectRate   float64           `json:"reject_rate"`
+	GeneratedAt  string            `json:"generated_at"`
+}
+
+// GetDifficultyCalibration aggregates reviewed submissions by the difficulty of their task across every
+// issue: approval rate and mean completion score. A difficulty whose tasks are rejected too often is
+// flagged under_rated with a suggestion to rate such tasks one level harder. The scan reads the files
+// without the store lock (they are replaced atomically), so it does not stall writers; the result also
+// refreshes the cache CalibrateDifficulty uses.
+func (s *IssueService) GetDifficultyCalibration() (*DifficultyCalibration, error) {
+	stats := map[string]*DifficultyStats{}
+	scores := map[string]int{}
+	entries, _ := os.ReadDir(s.store.Path("issues"))
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		issueID := e.Name()
+		for _, f := range listJSONOrEmpty(s.store, s.store.Path("issues", issueID, "tasks")) {
+			var t IssueTask
+			if err := s.store.ReadJSON(f, &t); err != nil || t.Difficulty == "" || t.Status == IssueTaskCanceled {
+				continue
+			}
+			st := stats[t.Difficulty]
+			if st == nil {
+				st = &DifficultyStats{Difficulty: t.Difficulty}
+				stats[t.Difficulty] = st
+			}
+			st.Tasks++
+			for _, sf := range listJSONOrEmpty(s.store, s.store.Path("issues", issueID, "submissions", t.ID)) {
+				var sub Submission
+				if err := s.store.ReadJSON(sf, &sub); err != nil {
+					continue
+				}
+				switch sub.Status {
+				case SubmissionApproved:
+					st.Approved++
+				case SubmissionRejected:
+					st.Rejected++
+				default:
+					continue
+				}
+				st.Reviews++
+				scores[t.Difficulty] += sub.CompletionScore
+			}
+		}
+	}
+
+	out := &DifficultyCalibration{Difficulties: []DifficultyStats{}, MinReviews: calibrationMinReviews, RejectRate: calibrationRejectRate, GeneratedAt: NowStr()}
+	for _, d := range []string{"easy", "medium", "focus"} {
+		st := stats[d]
+		if st == nil {
+			st = &DifficultyStats{Difficulty: d}
+		}
+		if st.Reviews > 0 {
+			st.ApprovalRate = float64(st.Approved) / float64(st.Reviews)
+			st.AvgCompletionScore = float64(scores[d]) / float64(st.Reviews)
+		}
+		if st.Reviews >= calibrationMinReviews && float64(st.Rejected)/float64(st.Reviews) >= calibrationRejectRate {
+			st.UnderRated = true
+			st.Suggestion = fmt.Sprintf("'%s' tasks are rejected %.0f%% of the time", d, 100*float64(st.Rejected)/float64(st.Reviews))
+			if d != "focus" {
+				st.Suggestion += fmt.Sprintf("; consider rating them '%s'", upgradeDifficulty(d))
+			} else {
+				st.Suggestion += "; consider splitting them into smaller tasks"
+			}
+		}
+		out.Difficulties = append(out.Difficulties, *st)
+	}
+	s.calibration.store(out)
+	return out, nil
+}
+
+func (c *calibrationCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func (c *calibrationCache) store(cal *DifficultyCalibration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c, c.at = cal, c.clock()
+}
+
+// cachedCalibration returns a calibration at most calibrationCacheTTL old, scanning the store only when
+// the cached one is older.
+func (s *IssueService) cachedCalibration() (*DifficultyCalibration, error) {
+	c := &s.calibration
+	c.mu.Lock()
+	cal, fresh := c.c, c.c != nil && c.clock().Sub(c.at) < calibrationCacheTTL
+	c.mu.Unlock()
+	if fresh {
+		return cal, nil
+	}
+	return s.GetDifficultyCalibration()
+}
+
+// CalibrateDifficulty checks a difficulty the lead requested against history. For an under-rated
+// difficulty it returns the calibration suggestion and, when adjust is set, the next harder difficulty
+// ("focus" has none and stays). Otherwise it returns requested and an empty note. It runs on every task
+// creation, so it reads a cached calibration (see cachedCalibration) instead of scanning the store.
+func (s *IssueService) CalibrateDifficulty(requested string, adjust bool) (string, string, error) {
+	c, err := s.cachedCalibration()
+	if err != nil {
+		return requested, "", err
+	}
+	for _, st := range c.Difficulties {
+		if st.Difficulty != requested || !st.UnderRated {
+			continue
+		}
+		if !adjust || requested == "focus" {
+			return requested, st.Suggestion, nil
+		}
+		next := upgradeDifficulty(requested)
+		return next, fmt.Sprintf("'%s' tasks are rejected %.0f%% of the time; difficulty adjusted to '%s'", requested, 100*(1-st.ApprovalRate), next), nil
+	}
+	return requested, "", nil
+}
@@ -0,0 +1,74 @@
+package swarm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalibrateDifficulty_RaisesUnderRatedDifficulty(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	// Five reviewed easy submissions, two of them rejected: 40% rejections.
+	for i, status := range []string{SubmissionApproved, SubmissionRejected, SubmissionApproved, SubmissionRejected, SubmissionApproved} {
+		issueID := fmt.Sprintf("issue-%d", i%2)
+		taskID := fmt.Sprintf("task-%d", i)
+		if err := svc.saveTaskLocked(issueID, &IssueTask{ID: taskID, IssueID: issueID, Difficulty: "easy", Status: IssueTaskInProgress}); err != nil {
+			t.Fatalf("write task: %v", err)
+		}
+		sub := Submission{ID: "sub-" + taskID, IssueID: issueID, TaskID: taskID, Status: status, CompletionScore: 2}
+		if err := store.WriteJSON(store.Path("issues", issueID, "submissions", taskID, sub.ID+".json"), &sub); err != nil {
+			t.Fatalf("write submission: %v", err)
+		}
+	}
+
+	c, err := svc.GetDifficultyCalibration()
+	if err != nil {
+		t.Fatalf("calibration: %v", err)
+	}
+	easy := c.Difficulties[0]
+	if easy.Difficulty != "easy" || easy.Reviews != 5 || easy.Rejected != 2 || !easy.UnderRated || easy.AvgCompletionScore != 2 {
+		t.Fatalf("expected easy flagged under-rated from 5 reviews, got %+v", easy)
+	}
+
+	if d, note, _ := svc.CalibrateDifficulty("easy", false); d != "easy" || !strings.Contains(note, "40%") {
+		t.Fatalf("expected a note without adjustment, got %q %q", d, note)
+	}
+	if d, note, _ := svc.CalibrateDifficulty("easy", true); d != "medium" || !strings.Contains(note, "adjusted to 'medium'") {
+		t.Fatalf("expected easy raised to medium, got %q %q", d, note)
+	}
+	if d, note, _ := svc.CalibrateDifficulty("medium", true); d != "medium" || note != "" {
+		t.Fatalf("expected medium untouched without history, got %q %q", d, note)
+	}
+}
+
+func TestCalibrateDifficulty_ReusesTheCalibrationUntilItExpires(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+	now := time.Unix(1000, 0)
+	svc.calibration.now = func() time.Time { return now }
+
+	if d, note, _ := svc.CalibrateDifficulty("easy", true); d != "easy" || note != "" {
+		t.Fatalf("expected no history yet, got %q %q", d, note)
+	}
+	for i := 0; i < calibrationMinReviews; i++ {
+		taskID := fmt.Sprintf("task-%d", i)
+		if err := svc.saveTaskLocked("issue-1", &IssueTask{ID: taskID, IssueID: "issue-1", Difficulty: "easy", Status: IssueTaskInProgress}); err != nil {
+			t.Fatalf("write task: %v", err)
+		}
+		sub := Submission{ID: "sub-" + taskID, IssueID: "issue-1", TaskID: taskID, Status: SubmissionRejected}
+		if err := store.WriteJSON(store.Path("issues", "issue-1", "submissions", taskID, sub.ID+".json"), &sub); err != nil {
+			t.Fatalf("write submission: %v", err)
+		}
+	}
+
+	if d, _, _ := svc.CalibrateDifficulty("easy", true); d != "easy" {
+		t.Fatalf("expected the cached calibration within the TTL, got %q", d)
+	}
+	now = now.Add(calibrationCacheTTL)
+	if d, _, _ := svc.CalibrateDifficulty("easy", true); d != "medium" {
+		t.Fatalf("expected a fresh scan after the TTL to raise easy, got %q", d)
+	}
+}
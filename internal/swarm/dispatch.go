@@ -0,0 +1,247 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Dispatch policies for waitIssueTasks. Without one, every waiter gets the same open task list and the
+// workers race on claimIssueTask; with one, each waiter is handed a distinct task reserved for it.
+const (
+	DispatchRoundRobin  = "round_robin"  // waiters are served in the order they started waiting
+	DispatchLeastPoints = "least_points" // the waiter with the fewest points in the issue goes first
+)
+
+// dispatchReserveMs is how long a dispatched task stays reserved for its waiter (same window as
+// getNextStepToken).
+const dispatchReserveMs = int64(2 * 60 * 1000)
+
+// dispatchWaiterTTLMs is how long a waiter record outlives its last refresh; a waiter whose process died
+// stops counting after that. Live waiters refresh it every dispatchWaiterTTLMs/2.
+const dispatchWaiterTTLMs = int64(10 * 1000)
+
+type dispatcher struct {
+	policy string
+}
+
+// dispatchWaiter is a worker waiting in WaitDispatchedTask, stored in issues/<id>/dispatch/<id>.json so
+// waiters in every process sharing the store (each worker is its own process) are ranked together. The
+// ULID in ID orders them by arrival; Filter is the part of the open tasks the waiter takes.
+type dispatchWaiter struct {
+	ID          string     `json:"id"`
+	WorkerID    string     `json:"worker_id"`
+	Filter      TaskFilter `json:"filter"`
+	ExpiresAtMs int64      `json:"expires_at_ms"`
+}
+
+// ValidDispatchPolicy reports whether policy is "" (off) or a known dispatch policy.
+func ValidDispatchPolicy(policy string) bool {
+	switch policy {
+	case "", DispatchRoundRobin, DispatchLeastPoints:
+		return true
+	}
+	return false
+}
+
+// SetDispatchPolicy sets how WaitDispatchedTask orders concurrent waiters; "" turns dispatch off.
+func (s *IssueService) SetDispatchPolicy(policy string) error {
+	policy = strings.TrimSpace(policy)
+	if !ValidDispatchPolicy(policy) {
+		return Errorf(CodeInvalidArgument, "unknown dispatch policy %q (want %s or %s)", policy, DispatchRoundRobin, DispatchLeastPoints)
+	}
+	if policy == "" {
+		s.dispatch = nil
+		return nil
+	}
+	s.dispatch = &dispatcher{policy: policy}
+	return nil
+}
+
+// DispatchPolicy returns the configured dispatch policy ("" = off).
+func (s *IssueService) DispatchPolicy() string {
+	if s.dispatch == nil {
+		return ""
+	}
+	return s.dispatch.policy
+}
+
+// WaitDispatchedTask is waitIssueTasks with server-side dispatch: it blocks until an open task matching
+// filter can be handed to workerID alone, reserves it for the worker with a next_step_token (returned in
+// task.ReservedToken) and returns it; (nil, nil) on timeout. Among workers waiting on the same issue,
+// the dispatch policy decides who is served first, so concurrent waiters never get the same task; a
+// waiter ahead only holds back tasks its own filter matches.
+func (s *IssueService) WaitDispatchedTask(ctx context.Context, issueID, workerID string, filter TaskFilter, timeoutSec int) (*IssueTask, error) {
+	d := s.dispatch
+	if d == nil {
+		return nil, Errorf(CodeUnsupported, "dispatch is off")
+	}
+	workerID = strings.TrimSpace(workerID)
+	if issueID == "" || workerID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id and worker_id are required")
+	}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+
+	w := &dispatchWaiter{ID: GenID("wait"), WorkerID: workerID, Filter: filter}
+	if err := s.store.WithLock(func() error { return s.saveDispatchWaiterLocked(issueID, w, LeaseNowMs()) }); err != nil {
+		return nil, err
+	}
+	defer s.leaveDispatch(issueID, w)
+
+	deadline := s.deadline(s.normalizeTimeoutSec(ctx, timeoutSec))
+	for {
+		since := s.changeGen()
+		var got *IssueTask
+		err := s.store.WithLock(func() error {
+			var issue Issue
+			if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+				return Errorf(CodeNotFound, "issue '%s' not found", issueID)
+			}
+			if issue.PausedAt != "" {
+				return nil
+			}
+			nowMs := LeaseNowMs()
+			if w.ExpiresAtMs-nowMs < dispatchWaiterTTLMs/2 {
+				if err := s.saveDispatchWaiterLocked(issueID, w, nowMs); err != nil {
+					return err
+				}
+			}
+			var free []IssueTask
+			for _, f := range listJSONOrEmpty(s.store, s.store.Path("issues", issueID, "tasks")) {
+				var t IssueTask
+				if err := s.store.ReadJSON(f, &t); err != nil || t.Status != IssueTaskOpen {
+					continue
+				}
+				if t.ReservedToken != "" && (t.ReservedUntilMs == 0 || nowMs <= t.ReservedUntilMs) {
+					continue
+				}
+				if s.readyToClaimLocked(issueID, &t) {
+					free = append(free, t)
+				}
+			}
+			// Waiters ahead of this one take the oldest tasks their filters match first; it only takes one
+			// when a matching task is left over.
+			sort.SliceStable(free, func(i, j int) bool { return free[i].CreatedAt < free[j].CreatedAt })
+			pick := d.allot(s.dispatchWaitersLocked(issueID, nowMs), w, free, s.dispatchPointsLocked(issueID))
+			if pick == nil {
+				return nil
+			}
+			live, err := s.reserveForWorkerLocked(issueID, pick.ID, workerID, "dispatch",
+				fmt.Sprintf("dispatched to %s (%s)", workerID, d.policy), nowMs+dispatchReserveMs)
+			if err != nil {
+				return err
+			}
+			got = live
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if got != nil {
+			s.leaveDispatch(issueID, w) // the next waiter may take the next task right away
+			s.bump(issueID)
+			return got, nil
+		}
+		if timeExpired(deadline) {
+			return nil, nil
+		}
+		if err := s.waitChange(ctx, since, pollInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// dispatchPointsLocked returns a worker's points in the issue for the least_points policy.
+func (s *IssueService) dispatchPointsLocked(issueID string) func(workerID string) int {
+	return func(workerID string) int {
+		st, _ := s.loadIssueWorkerStateLocked(issueID, workerID)
+		return st.TotalPoints
+	}
+}
+
+func (s *IssueService) saveDispatchWaiterLocked(issueID string, w *dispatchWaiter, nowMs int64) error {
+	w.ExpiresAtMs = nowMs + dispatchWaiterTTLMs
+	return s.store.WriteJSON(s.store.Path("issues", issueID, "dispatch", w.ID+".json"), w)
+}
+
+// leaveDispatch removes the waiter record; calling it again is harmless.
+func (s *IssueService) leaveDispatch(issueID string, w *dispatchWaiter) {
+	_ = s.store.WithLock(func() error {
+		err := os.Remove(s.store.Path("issues", issueID, "dispatch", w.ID+".json"))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	})
+}
+
+// dispatchWaitersLocked returns the issue's live waiters, removing the records of expired ones. Must be
+// called under store lock.
+func (s *IssueService) dispatchWaitersLocked(issueID string, nowMs int64) []dispatchWaiter {
+	var out []dispatchWaiter
+	for _, f := range listJSONOrEmpty(s.store, s.store.Path("issues", issueID, "dispatch")) {
+		var w dispatchWaiter
+		if err := s.store.ReadJSON(f, &w); err != nil {
+			continue
+		}
+		if w.ExpiresAtMs < nowMs {
+			_ = os.Remove(f)
+			continue
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+// allot hands out the free tasks (oldest first) to the waiters in policy order, each taking the first one
+// left that its filter matches, and returns w's share; nil when nothing is left for it.
+func (d *dispatcher) allot(ws []dispatchWaiter, w *dispatchWaiter, free []IssueTask, points func(workerID string) int) *IssueTask {
+	taken := make(map[string]bool, len(ws))
+	for _, x := range d.order(ws, points) {
+		i := slices.IndexFunc(free, func(t IssueTask) bool { return !taken[t.ID] && x.Filter.match(&t) })
+		if x.ID == w.ID {
+			if i < 0 {
+				return nil
+			}
+			return &free[i]
+		}
+		if i >= 0 {
+			taken[free[i].ID] = true
+		}
+	}
+	return nil
+}
+
+// rank is w's position among the waiters under the policy (0 = served first).
+func (d *dispatcher) rank(ws []dispatchWaiter, w *dispatchWaiter, points func(workerID string) int) int {
+	for i, x := range d.order(ws, points) {
+		if x.ID == w.ID {
+			return i
+		}
+	}
+	return len(ws)
+}
+
+// order sorts the waiters into the order the policy serves them in.
+func (d *dispatcher) order(ws []dispatchWaiter, points func(workerID string) int) []dispatchWaiter {
+	pts := map[string]int{}
+	if d.policy == DispatchLeastPoints {
+		for _, x := range ws {
+			if _, ok := pts[x.WorkerID]; !ok {
+				pts[x.WorkerID] = points(x.WorkerID)
+			}
+		}
+	}
+	sort.SliceStable(ws, func(i, j int) bool {
+		if pts[ws[i].WorkerID] != pts[ws[j].WorkerID] {
+			return pts[ws[i].WorkerID] < pts[ws[j].WorkerID]
+		}
+		return idLess(ws[i].ID, ws[j].ID)
+	})
+	return ws
+}
@@ -0,0 +1,157 @@
+package swarm
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestWaitDispatchedTask_GivesConcurrentWaitersDistinctTasks(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 1, 1)
+	if err := svc.SetDispatchPolicy(DispatchRoundRobin); err != nil {
+		t.Fatalf("set policy: %v", err)
+	}
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 3}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	for _, id := range []string{"task-1", "task-2"} {
+		if err := svc.saveTaskLocked(issueID, &IssueTask{ID: id, IssueID: issueID, Status: IssueTaskOpen}); err != nil {
+			t.Fatalf("write task: %v", err)
+		}
+	}
+
+	workers := []string{"w1", "w2", "w3"}
+	got := make([]*IssueTask, len(workers))
+	var wg sync.WaitGroup
+	for i, w := range workers {
+		wg.Add(1)
+		go func(i int, w string) {
+			defer wg.Done()
+			task, err := svc.WaitDispatchedTask(context.Background(), issueID, w, TaskFilter{}, 1)
+			if err != nil {
+				t.Errorf("%s: %v", w, err)
+			}
+			got[i] = task
+		}(i, w)
+	}
+	wg.Wait()
+
+	seen := map[string]string{}
+	for i, task := range got {
+		if task == nil {
+			continue
+		}
+		if other, dup := seen[task.ID]; dup {
+			t.Fatalf("%s and %s were both dispatched %s", other, workers[i], task.ID)
+		}
+		seen[task.ID] = workers[i]
+		if _, err := svc.ClaimTask(issueID, task.ID, workers[i], task.ReservedToken, 0); err != nil {
+			t.Fatalf("%s claiming its dispatched task: %v", workers[i], err)
+		}
+	}
+	if len(seen) != 2 {
+		t.Fatalf("expected both tasks dispatched to distinct waiters (one waiter timing out), got %+v", seen)
+	}
+}
+
+func TestDispatcherRank_LeastPointsFirst(t *testing.T) {
+	d := &dispatcher{policy: DispatchLeastPoints}
+	rich := dispatchWaiter{ID: GenID("wait"), WorkerID: "rich"}
+	poor := dispatchWaiter{ID: GenID("wait"), WorkerID: "poor"}
+	ws := func() []dispatchWaiter { return []dispatchWaiter{rich, poor} }
+	points := func(w string) int { return map[string]int{"rich": 10, "poor": 1}[w] }
+	if d.rank(ws(), &poor, points) != 0 || d.rank(ws(), &rich, points) != 1 {
+		t.Fatalf("expected the worker with fewer points to be served first")
+	}
+	d.policy = DispatchRoundRobin
+	if d.rank(ws(), &rich, points) != 0 {
+		t.Fatalf("expected round_robin to serve the earliest waiter first")
+	}
+}
+
+func TestWaitDispatchedTask_RanksWaitersOfOtherProcesses(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 1, 1)
+	if err := svc.SetDispatchPolicy(DispatchRoundRobin); err != nil {
+		t.Fatalf("set policy: %v", err)
+	}
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 2}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	if err := svc.saveTaskLocked(issueID, &IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskOpen}); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+
+	// A worker in another process started waiting first; this one must not jump the queue.
+	other := &dispatchWaiter{ID: GenID("wait"), WorkerID: "w-other"}
+	if err := svc.saveDispatchWaiterLocked(issueID, other, LeaseNowMs()); err != nil {
+		t.Fatalf("write waiter: %v", err)
+	}
+	task, err := svc.WaitDispatchedTask(context.Background(), issueID, "w-here", TaskFilter{}, 1)
+	if err != nil || task != nil {
+		t.Fatalf("expected no task while an earlier waiter is queued, got %+v %v", task, err)
+	}
+
+	// Once that waiter's record expires (its process died), the task is dispatched here.
+	other.ExpiresAtMs = LeaseNowMs() - 1
+	if err := store.WriteJSON(store.Path("issues", issueID, "dispatch", other.ID+".json"), other); err != nil {
+		t.Fatalf("write waiter: %v", err)
+	}
+	task, err = svc.WaitDispatchedTask(context.Background(), issueID, "w-here", TaskFilter{}, 1)
+	if err != nil || task == nil || task.ID != "task-1" {
+		t.Fatalf("expected task-1 dispatched, got %+v %v", task, err)
+	}
+	if left := listJSONOrEmpty(store, store.Path("issues", issueID, "dispatch")); len(left) != 0 {
+		t.Fatalf("expected waiter records cleaned up, got %v", left)
+	}
+}
+
+func TestWaitDispatchedTask_AppliesTheWaitersFilters(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 1, 1)
+	if err := svc.SetDispatchPolicy(DispatchRoundRobin); err != nil {
+		t.Fatalf("set policy: %v", err)
+	}
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 3}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	for _, task := range []IssueTask{
+		{ID: "task-1", IssueID: issueID, Status: IssueTaskOpen, Labels: []string{"backend"}, CreatedAt: "2026-01-01T00:00:00Z"},
+		{ID: "task-2", IssueID: issueID, Status: IssueTaskOpen, Labels: []string{"frontend"}, CreatedAt: "2026-01-01T00:00:01Z"},
+	} {
+		if err := svc.saveTaskLocked(issueID, &task); err != nil {
+			t.Fatalf("write task: %v", err)
+		}
+	}
+
+	// A backend worker queued first only holds back backend tasks.
+	backend := &dispatchWaiter{ID: GenID("wait"), WorkerID: "w-backend", Filter: TaskFilter{Labels: []string{"backend"}}}
+	if err := svc.saveDispatchWaiterLocked(issueID, backend, LeaseNowMs()); err != nil {
+		t.Fatalf("write waiter: %v", err)
+	}
+	task, err := svc.WaitDispatchedTask(context.Background(), issueID, "w-frontend", TaskFilter{Labels: []string{"frontend"}}, 1)
+	if err != nil || task == nil || task.ID != "task-2" {
+		t.Fatalf("expected the frontend task for the frontend worker, got %+v %v", task, err)
+	}
+	task, err = svc.WaitDispatchedTask(context.Background(), issueID, "w-frontend-2", TaskFilter{Labels: []string{"frontend"}}, 1)
+	if err != nil || task != nil {
+		t.Fatalf("expected no backend task for a frontend worker, got %+v %v", task, err)
+	}
+	if _, err := svc.WaitDispatchedTask(context.Background(), issueID, "w", TaskFilter{MinPoints: 5, MaxPoints: 1}, 1); ErrorCode(err) != CodeInvalidArgument {
+		t.Fatalf("expected an invalid filter to be rejected, got %v", err)
+	}
+}
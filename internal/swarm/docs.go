@@ -1,7 +1,6 @@
 package swarm
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
@@ -22,22 +21,18 @@ func NewDocsService(store *Store) *DocsService {
 // - issues/{issue_id}/tasks/{task_id}.docs/{name}.md
 //
 // Note: name can include subdirectories; it will be cleaned.
+// Writes are versioned (see docs_history.go); baseVersion > 0 rejects the write if the doc has changed since.
 
-func (d *DocsService) WriteSharedDoc(name, content string) (string, error) {
+func (d *DocsService) WriteSharedDoc(name, content string, baseVersion int) (*DocWriteResult, error) {
 	if name == "" {
-		return "", fmt.Errorf("name is required")
+		return nil, Errorf(CodeInvalidArgument, "name is required")
 	}
-	p := d.store.Path("docs", "shared", filepath.Clean(name)+".md")
-	d.store.EnsureDir("docs", "shared", filepath.Dir(filepath.Clean(name)))
-	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
-		return "", err
-	}
-	return name, nil
+	return d.writeVersioned(d.store.Path("docs", "shared"), name, content, baseVersion, 0)
 }
 
 func (d *DocsService) ReadSharedDoc(name string) (string, error) {
 	if name == "" {
-		return "", fmt.Errorf("name is required")
+		return "", Errorf(CodeInvalidArgument, "name is required")
 	}
 	p := d.store.Path("docs", "shared", filepath.Clean(name)+".md")
 	b, err := os.ReadFile(p)
@@ -69,21 +64,16 @@ func (d *DocsService) ListSharedDocs() ([]string, error) {
 	return out, nil
 }
 
-func (d *DocsService) WriteIssueDoc(issueID, name, content string) (string, error) {
+func (d *DocsService) WriteIssueDoc(issueID, name, content string, baseVersion int) (*DocWriteResult, error) {
 	if issueID == "" || name == "" {
-		return "", fmt.Errorf("issue_id and name are required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id and name are required")
 	}
-	p := d.store.Path("issues", issueID, "docs", filepath.Clean(name)+".md")
-	d.store.EnsureDir("issues", issueID, "docs", filepath.Dir(filepath.Clean(name)))
-	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
-		return "", err
-	}
-	return name, nil
+	return d.writeVersioned(d.store.Path("issues", issueID, "docs"), name, content, baseVersion, 0)
 }
 
 func (d *DocsService) ReadIssueDoc(issueID, name string) (string, error) {
 	if issueID == "" || name == "" {
-		return "", fmt.Errorf("issue_id and name are required")
+		return "", Errorf(CodeInvalidArgument, "issue_id and name are required")
 	}
 	p := d.store.Path("issues", issueID, "docs", filepath.Clean(name)+".md")
 	b, err := os.ReadFile(p)
@@ -95,7 +85,7 @@ func (d *DocsService) ReadIssueDoc(issueID, name string) (string, error) {
 
 func (d *DocsService) ListIssueDocs(issueID string) ([]string, error) {
 	if issueID == "" {
-		return nil, fmt.Errorf("issue_id is required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
 	}
 	dir := d.store.Path("issues", issueID, "docs")
 	entries, err := os.ReadDir(dir)
@@ -118,21 +108,16 @@ func (d *DocsService) ListIssueDocs(issueID string) ([]string, error) {
 	return out, nil
 }
 
-func (d *DocsService) WriteTaskDoc(issueID, taskID, name, content string) (string, error) {
+func (d *DocsService) WriteTaskDoc(issueID, taskID, name, content string, baseVersion int) (*DocWriteResult, error) {
 	if issueID == "" || taskID == "" || name == "" {
-		return "", fmt.Errorf("issue_id, task_id and name are required")
-	}
-	p := d.store.Path("issues", issueID, "tasks", taskID+".docs", filepath.Clean(name)+".md")
-	d.store.EnsureDir("issues", issueID, "tasks", taskID+".docs", filepath.Dir(filepath.Clean(name)))
-	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
-		return "", err
+		return nil, Errorf(CodeInvalidArgument, "issue_id, task_id and name are required")
 	}
-	return name, nil
+	return d.writeVersioned(d.store.Path("issues", issueID, "tasks", taskID+".docs"), name, content, baseVersion, 0)
 }
 
 func (d *DocsService) ReadTaskDoc(issueID, taskID, name string) (string, error) {
 	if issueID == "" || taskID == "" || name == "" {
-		return "", fmt.Errorf("issue_id, task_id and name are required")
+		return "", Errorf(CodeInvalidArgument, "issue_id, task_id and name are required")
 	}
 	p := d.store.Path("issues", issueID, "tasks", taskID+".docs", filepath.Clean(name)+".md")
 	b, err := os.ReadFile(p)
@@ -144,7 +129,7 @@ func (d *DocsService) ReadTaskDoc(issueID, taskID, name string) (string, error)
 
 func (d *DocsService) ListTaskDocs(issueID, taskID string) ([]string, error) {
 	if issueID == "" || taskID == "" {
-		return nil, fmt.Errorf("issue_id and task_id are required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id and task_id are required")
 	}
 	dir := d.store.Path("issues", issueID, "tasks", taskID+".docs")
 	entries, err := os.ReadDir(dir)
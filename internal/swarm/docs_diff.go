@@ -0,0 +1,77 @@
+package swarm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const docDiffDefaultContext = 3
+
+// DocDiff is the result of DiffDocs. Diff is a unified diff from From to To ("" when identical).
+type DocDiff struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Identical bool   `json:"identical"`
+	Diff      string `json:"diff"`
+}
+
+// docLabel names a doc (and version) in diff headers, e.g. "issue/<issue_id>/spec@v3".
+func docLabel(t DocTarget, version int) string {
+	parts := []string{t.Scope}
+	if t.IssueID != "" && t.Scope != DocScopeShared {
+		parts = append(parts, t.IssueID)
+	}
+	if t.TaskID != "" && t.Scope == DocScopeTask {
+		parts = append(parts, t.TaskID)
+	}
+	label := strings.Join(append(parts, filepath.Clean(t.Name)), "/")
+	if version > 0 {
+		label += "@v" + strconv.Itoa(version)
+	}
+	return label
+}
+
+// readDocAt returns the content of a doc at version, or its current content when version is 0.
+func (d *DocsService) readDocAt(t DocTarget, version int) (string, error) {
+	if strings.TrimSpace(t.Name) == "" {
+		return "", Errorf(CodeInvalidArgument, "name is required")
+	}
+	if version > 0 {
+		return d.ReadDocVersion(t, version)
+	}
+	dir, err := d.docDir(t)
+	if err != nil {
+		return "", err
+	}
+	b, err := os.ReadFile(filepath.Join(dir, filepath.Clean(t.Name)+".md"))
+	if err != nil {
+		return "", Errorf(CodeNotFound, "doc not found: %s", docLabel(t, 0))
+	}
+	return string(b), nil
+}
+
+// DiffDocs returns a unified diff between two docs, or two versions of one doc. A version of 0 means the
+// current content; contextLines <= 0 uses 3.
+func (d *DocsService) DiffDocs(from DocTarget, fromVersion int, to DocTarget, toVersion int, contextLines int) (*DocDiff, error) {
+	if contextLines <= 0 {
+		contextLines = docDiffDefaultContext
+	}
+	a, err := d.readDocAt(from, fromVersion)
+	if err != nil {
+		return nil, fmt.Errorf("from: %w", err)
+	}
+	b, err := d.readDocAt(to, toVersion)
+	if err != nil {
+		return nil, fmt.Errorf("to: %w", err)
+	}
+	out := &DocDiff{From: docLabel(from, fromVersion), To: docLabel(to, toVersion)}
+	out.Diff, err = UnifiedDiff(out.From, out.To, a, b, contextLines)
+	if err != nil {
+		return nil, err
+	}
+	out.Identical = out.Diff == ""
+	return out, nil
+}
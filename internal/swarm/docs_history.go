@@ -0,0 +1,230 @@
+package swarm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Doc versioning.
+// Every write keeps a copy next to the doc:
+//
+//	<docs dir>/.history/<name>/v<N>.md    content of version N
+//	<docs dir>/.history/<name>/v<N>.json  DocVersion metadata
+//
+// Docs written before versioning existed get their current content recorded as v1 on the next write.
+
+const (
+	DocScopeShared = "shared"
+	DocScopeIssue  = "issue"
+	DocScopeTask   = "task"
+)
+
+// DocTarget identifies one doc in the shared, issue or task library.
+type DocTarget struct {
+	Scope   string
+	IssueID string
+	TaskID  string
+	Name    string
+}
+
+type DocVersion struct {
+	Version        int    `json:"version"`
+	Bytes          int    `json:"bytes"`
+	WrittenAt      string `json:"written_at"`
+	RolledBackFrom int    `json:"rolled_back_from,omitempty"`
+}
+
+type DocWriteResult struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+// DocVersionConflictError is returned when base_version does not match the current version, i.e. somebody
+// else wrote the doc since the caller read it.
+type DocVersionConflictError struct {
+	Name           string
+	BaseVersion    int
+	CurrentVersion int
+}
+
+func (e *DocVersionConflictError) Error() string {
+	return fmt.Sprintf("doc '%s' changed: base_version %d but current version is %d; re-read and merge before writing", e.Name, e.BaseVersion, e.CurrentVersion)
+}
+
+func (e *DocVersionConflictError) ErrorDetails() any {
+	return map[string]any{"doc_conflict": map[string]any{
+		"name":            e.Name,
+		"base_version":    e.BaseVersion,
+		"current_version": e.CurrentVersion,
+	}}
+}
+
+func (d *DocsService) docDir(t DocTarget) (string, error) {
+	switch t.Scope {
+	case DocScopeShared:
+		return d.store.Path("docs", "shared"), nil
+	case DocScopeIssue:
+		if t.IssueID == "" {
+			return "", Errorf(CodeInvalidArgument, "issue_id is required")
+		}
+		return d.store.Path("issues", t.IssueID, "docs"), nil
+	case DocScopeTask:
+		if t.IssueID == "" || t.TaskID == "" {
+			return "", Errorf(CodeInvalidArgument, "issue_id and task_id are required")
+		}
+		return d.store.Path("issues", t.IssueID, "tasks", t.TaskID+".docs"), nil
+	default:
+		return "", Errorf(CodeInvalidArgument, "invalid scope '%s' (shared|issue|task)", t.Scope)
+	}
+}
+
+func docHistoryDir(dir, name string) string {
+	return filepath.Join(dir, ".history", filepath.Clean(name))
+}
+
+// docVersionsLocked returns the recorded versions, oldest first.
+func (d *DocsService) docVersionsLocked(dir, name string) []DocVersion {
+	files, _ := filepath.Glob(filepath.Join(docHistoryDir(dir, name), "v*.json"))
+	out := make([]DocVersion, 0, len(files))
+	for _, f := range files {
+		var v DocVersion
+		if err := d.store.ReadJSON(f, &v); err == nil && v.Version > 0 {
+			out = append(out, v)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out
+}
+
+func writeDocVersionLocked(store *Store, dir, name string, v DocVersion, content []byte) error {
+	hdir := docHistoryDir(dir, name)
+	if err := os.MkdirAll(hdir, 0755); err != nil {
+		return err
+	}
+	base := "v" + strconv.Itoa(v.Version)
+	if err := os.WriteFile(filepath.Join(hdir, base+".md"), content, 0644); err != nil {
+		return err
+	}
+	return store.WriteJSON(filepath.Join(hdir, base+".json"), v)
+}
+
+// currentVersionLocked returns the latest version, recording an unversioned existing doc as v1 first.
+func (d *DocsService) currentVersionLocked(dir, name string) (int, error) {
+	if versions := d.docVersionsLocked(dir, name); len(versions) > 0 {
+		return versions[len(versions)-1].Version, nil
+	}
+	p := filepath.Join(dir, filepath.Clean(name)+".md")
+	st, err := os.Stat(p)
+	if err != nil {
+		return 0, nil
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return 0, err
+	}
+	v := DocVersion{Version: 1, Bytes: len(b), WrittenAt: st.ModTime().UTC().Format(time.RFC3339)}
+	if err := writeDocVersionLocked(d.store, dir, name, v, b); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// writeVersioned writes content as the next version of the doc. baseVersion > 0 makes the write conditional
+// on the doc still being at that version.
+func (d *DocsService) writeVersioned(dir, name, content string, baseVersion, rolledBackFrom int) (*DocWriteResult, error) {
+	var result *DocWriteResult
+	err := d.store.WithLock(func() error {
+		var err error
+		result, err = d.writeVersionedLocked(dir, name, content, baseVersion, rolledBackFrom)
+		return err
+	})
+	return result, err
+}
+
+func (d *DocsService) writeVersionedLocked(dir, name, content string, baseVersion, rolledBackFrom int) (*DocWriteResult, error) {
+	name = filepath.Clean(name)
+	current, err := d.currentVersionLocked(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	if baseVersion > 0 && baseVersion != current {
+		return nil, &DocVersionConflictError{Name: name, BaseVersion: baseVersion, CurrentVersion: current}
+	}
+	v := DocVersion{Version: current + 1, Bytes: len(content), WrittenAt: NowStr(), RolledBackFrom: rolledBackFrom}
+	if err := writeDocVersionLocked(d.store, dir, name, v, []byte(content)); err != nil {
+		return nil, err
+	}
+	p := filepath.Join(dir, name+".md")
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		return nil, err
+	}
+	return &DocWriteResult{Name: name, Version: v.Version}, nil
+}
+
+// ListDocVersions returns the version history of a doc, oldest first.
+func (d *DocsService) ListDocVersions(t DocTarget) ([]DocVersion, error) {
+	if strings.TrimSpace(t.Name) == "" {
+		return nil, Errorf(CodeInvalidArgument, "name is required")
+	}
+	dir, err := d.docDir(t)
+	if err != nil {
+		return nil, err
+	}
+	var out []DocVersion
+	err = d.store.WithLock(func() error {
+		if _, err := d.currentVersionLocked(dir, t.Name); err != nil {
+			return err
+		}
+		out = d.docVersionsLocked(dir, t.Name)
+		return nil
+	})
+	if err == nil && len(out) == 0 {
+		return nil, Errorf(CodeNotFound, "doc not found: %s", t.Name)
+	}
+	return out, err
+}
+
+// ReadDocVersion returns the content of one recorded version.
+func (d *DocsService) ReadDocVersion(t DocTarget, version int) (string, error) {
+	if strings.TrimSpace(t.Name) == "" || version <= 0 {
+		return "", Errorf(CodeInvalidArgument, "name and version are required")
+	}
+	dir, err := d.docDir(t)
+	if err != nil {
+		return "", err
+	}
+	var content string
+	err = d.store.WithLock(func() error {
+		if _, err := d.currentVersionLocked(dir, t.Name); err != nil {
+			return err
+		}
+		b, err := os.ReadFile(filepath.Join(docHistoryDir(dir, t.Name), "v"+strconv.Itoa(version)+".md"))
+		if err != nil {
+			return Errorf(CodeNotFound, "version %d of doc '%s' not found", version, t.Name)
+		}
+		content = string(b)
+		return nil
+	})
+	return content, err
+}
+
+// RollbackDoc restores an earlier version by writing its content as a new version.
+func (d *DocsService) RollbackDoc(t DocTarget, version, baseVersion int) (*DocWriteResult, error) {
+	content, err := d.ReadDocVersion(t, version)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := d.docDir(t)
+	if err != nil {
+		return nil, err
+	}
+	return d.writeVersioned(dir, t.Name, content, baseVersion, version)
+}
@@ -0,0 +1,81 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestDocsService_VersionsAndRollback(t *testing.T) {
+	store := NewStore(t.TempDir())
+	docs := NewDocsService(store)
+
+	// A doc written before versioning existed becomes v1.
+	store.EnsureDir("issues", "i1", "docs")
+	if err := os.WriteFile(store.Path("issues", "i1", "docs", "plan.md"), []byte("legacy"), 0644); err != nil {
+		t.Fatalf("seed doc: %v", err)
+	}
+
+	res, err := docs.WriteIssueDoc("i1", "plan", "second", 0)
+	if err != nil || res.Version != 2 {
+		t.Fatalf("write: %+v %v", res, err)
+	}
+	if _, err := docs.WriteIssueDoc("i1", "plan", "stale edit", 1); err == nil {
+		t.Fatalf("expected conflict for stale base_version")
+	} else {
+		var conflict *DocVersionConflictError
+		if !errors.As(err, &conflict) || conflict.CurrentVersion != 2 {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	target := DocTarget{Scope: DocScopeIssue, IssueID: "i1", Name: "plan"}
+	if content, err := docs.ReadDocVersion(target, 1); err != nil || content != "legacy" {
+		t.Fatalf("read v1: %q %v", content, err)
+	}
+	res, err = docs.RollbackDoc(target, 1, 2)
+	if err != nil || res.Version != 3 {
+		t.Fatalf("rollback: %+v %v", res, err)
+	}
+	if content, _ := docs.ReadIssueDoc("i1", "plan"); content != "legacy" {
+		t.Fatalf("current content after rollback = %q", content)
+	}
+
+	versions, err := docs.ListDocVersions(target)
+	if err != nil || len(versions) != 3 || versions[2].RolledBackFrom != 1 {
+		t.Fatalf("versions: %+v %v", versions, err)
+	}
+	if names, _ := docs.ListIssueDocs("i1"); len(names) != 1 {
+		t.Fatalf("history must not show up as docs: %v", names)
+	}
+}
+
+func TestDocsService_SearchDocs(t *testing.T) {
+	store := NewStore(t.TempDir())
+	docs := NewDocsService(store)
+	if _, err := docs.WriteSharedDoc("conventions/api", "# API\nUse cursor Pagination.\nNo offsets.\n", 0); err != nil {
+		t.Fatalf("write shared: %v", err)
+	}
+	if _, err := docs.WriteIssueDoc("i1", "spec", "pagination: 50 per page\nsecond pagination line\n", 0); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if _, err := docs.WriteTaskDoc("i1", "t1", "notes", "nothing here", 0); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	// Old versions must not be searched.
+	if _, err := docs.WriteTaskDoc("i1", "t1", "notes", "still nothing", 0); err != nil {
+		t.Fatalf("rewrite task: %v", err)
+	}
+
+	hits, err := docs.SearchDocs(context.Background(), "all", "i1", "", "pagination", 0)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(hits) != 2 || hits[0].Name != "spec" || hits[0].MatchCount != 2 || hits[1].Name != "conventions/api" || hits[1].Matches[0].Line != 2 {
+		t.Fatalf("unexpected hits: %+v", hits)
+	}
+	if hits, _ := docs.SearchDocs(context.Background(), "shared", "", "", "pagination", 0); len(hits) != 1 || hits[0].Scope != DocScopeShared {
+		t.Fatalf("unexpected shared hits: %+v", hits)
+	}
+}
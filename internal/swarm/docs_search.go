@@ -0,0 +1,146 @@
+package swarm
+
+import (
+	"bufio"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	docSearchMaxLineLen     = 240
+	docSearchMatchesPerDoc  = 5
+	docSearchDefaultMaxDocs = 20
+	docSearchMaxDocsHardCap = 100
+	DocScopeAll             = "all"
+)
+
+type DocSearchLine struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+type DocSearchHit struct {
+	Scope      string          `json:"scope"`
+	IssueID    string          `json:"issue_id,omitempty"`
+	TaskID     string          `json:"task_id,omitempty"`
+	Name       string          `json:"name"`
+	MatchCount int             `json:"match_count"`
+	Matches    []DocSearchLine `json:"matches"`
+}
+
+// SearchDocs greps docs for query (case-insensitive substring) and returns matching docs with line snippets.
+// scope: shared | issue (issue docs of issue_id) | task (docs of task_id) | all (shared docs plus, when
+// issue_id is given, the issue's docs and all of its task docs).
+func (d *DocsService) SearchDocs(ctx context.Context, scope, issueID, taskID, query string, maxDocs int) ([]DocSearchHit, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, Errorf(CodeInvalidArgument, "query is required")
+	}
+	if maxDocs <= 0 {
+		maxDocs = docSearchDefaultMaxDocs
+	}
+	if maxDocs > docSearchMaxDocsHardCap {
+		maxDocs = docSearchMaxDocsHardCap
+	}
+	scope = strings.TrimSpace(scope)
+	if scope == "" {
+		scope = DocScopeAll
+	}
+
+	var targets []DocTarget
+	switch scope {
+	case DocScopeShared, DocScopeIssue, DocScopeTask:
+		targets = append(targets, DocTarget{Scope: scope, IssueID: issueID, TaskID: taskID})
+	case DocScopeAll:
+		targets = append(targets, DocTarget{Scope: DocScopeShared})
+		if issueID != "" {
+			targets = append(targets, DocTarget{Scope: DocScopeIssue, IssueID: issueID})
+			taskDirs, _ := filepath.Glob(d.store.Path("issues", issueID, "tasks", "*.docs"))
+			for _, td := range taskDirs {
+				targets = append(targets, DocTarget{Scope: DocScopeTask, IssueID: issueID, TaskID: strings.TrimSuffix(filepath.Base(td), ".docs")})
+			}
+		}
+	default:
+		return nil, Errorf(CodeInvalidArgument, "invalid scope '%s' (shared|issue|task|all)", scope)
+	}
+
+	needle := strings.ToLower(query)
+	hits := []DocSearchHit{}
+	for _, t := range targets {
+		dir, err := d.docDir(t)
+		if err != nil {
+			return nil, err
+		}
+		var files []string
+		_ = filepath.WalkDir(dir, func(path string, e fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if e.IsDir() {
+				if e.Name() == ".history" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(e.Name(), ".md") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		sort.Strings(files)
+		for _, f := range files {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			hit, ok := grepDoc(f, needle)
+			if !ok {
+				continue
+			}
+			rel, _ := filepath.Rel(dir, f)
+			hit.Scope = t.Scope
+			hit.IssueID = t.IssueID
+			hit.TaskID = t.TaskID
+			hit.Name = strings.TrimSuffix(filepath.ToSlash(rel), ".md")
+			hits = append(hits, hit)
+		}
+	}
+
+	// Docs with the most matching lines first.
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].MatchCount > hits[j].MatchCount })
+	if len(hits) > maxDocs {
+		hits = hits[:maxDocs]
+	}
+	return hits, nil
+}
+
+func grepDoc(path, needle string) (DocSearchHit, bool) {
+	var hit DocSearchHit
+	f, err := os.Open(path)
+	if err != nil {
+		return hit, false
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		text := sc.Text()
+		if !strings.Contains(strings.ToLower(text), needle) {
+			continue
+		}
+		hit.MatchCount++
+		if len(hit.Matches) < docSearchMatchesPerDoc {
+			text = strings.TrimSpace(text)
+			if r := []rune(text); len(r) > docSearchMaxLineLen {
+				text = string(r[:docSearchMaxLineLen]) + "..."
+			}
+			hit.Matches = append(hit.Matches, DocSearchLine{Line: lineNo, Text: text})
+		}
+	}
+	return hit, hit.MatchCount > 0
+}
@@ -0,0 +1,104 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Error codes. Every error a tool returns is classified under one of these, so agents can branch on the
+// code instead of matching message text. Retryable codes may succeed if the same call is repeated later,
+// possibly after re-reading state; the others need different arguments or a different action.
+const (
+	CodeInvalidArgument  = "invalid_argument"  // a required argument is missing or malformed
+	CodeNotFound         = "not_found"         // the issue, task, doc, lease, ... does not exist
+	CodeAlreadyExists    = "already_exists"    // the entity to create exists already
+	CodeInvalidState     = "invalid_state"     // the entity's status does not allow the call (e.g. task not open)
+	CodeReserved         = "reserved"          // the task is reserved for another worker or token
+	CodeNotOwner         = "not_owner"         // the caller does not hold the claim, lease or assignment
+	CodeLimitExceeded    = "limit_exceeded"    // a configured limit (claims per worker, task count) is reached
+	CodePermissionDenied = "permission_denied" // the role or role_code does not allow the call
+	CodeLockConflict     = "lock_conflict"     // files are locked or queued for by another owner
+	CodeVersionConflict  = "version_conflict"  // a doc or entity changed since the caller read it
+	CodeUnsupported      = "unsupported"       // data written by a newer binary, or an unsupported format
+	CodeTimeout          = "timeout"           // a wait ended without the awaited event
+	CodeRateLimited      = "rate_limited"      // the caller's rate limit is used up
+	CodeCancelled        = "cancelled"         // the request was cancelled or the client went away
+	CodeUnavailable      = "unavailable"       // a dependency (gateway, GitHub, CI) could not be reached
+	CodeInternal         = "internal"          // anything else, e.g. storage failures
+)
+
+var retryableCodes = map[string]bool{
+	CodeLockConflict:    true,
+	CodeVersionConflict: true,
+	CodeTimeout:         true,
+	CodeRateLimited:     true,
+	CodeUnavailable:     true,
+}
+
+// Error is an error with a code from the taxonomy above and optional structured details.
+type Error struct {
+	Code    string
+	Details map[string]any
+	err     error
+}
+
+// Errorf formats an error like fmt.Errorf (including %w) and tags it with code.
+func Errorf(code, format string, args ...any) *Error {
+	return &Error{Code: code, err: fmt.Errorf(format, args...)}
+}
+
+// With adds a detail field and returns e.
+func (e *Error) With(key string, value any) *Error {
+	if e.Details == nil {
+		e.Details = map[string]any{}
+	}
+	e.Details[key] = value
+	return e
+}
+
+func (e *Error) Error() string { return e.err.Error() }
+
+func (e *Error) Unwrap() error { return errors.Unwrap(e.err) }
+
+func (e *Error) ErrorCode() string { return e.Code }
+
+func (e *Error) ErrorDetails() any {
+	if len(e.Details) == 0 {
+		return nil
+	}
+	return e.Details
+}
+
+// ErrorCode classifies err: the code of the first error in its chain that carries one, else a code
+// derived from well-known causes, else CodeInternal.
+func ErrorCode(err error) string {
+	var coded interface{ ErrorCode() string }
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &coded):
+		return coded.ErrorCode()
+	case errors.Is(err, context.Canceled):
+		return CodeCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout
+	case errors.Is(err, os.ErrNotExist):
+		return CodeNotFound
+	}
+	return CodeInternal
+}
+
+// ErrorRetryable reports whether repeating the call that failed with err may succeed.
+func ErrorRetryable(err error) bool {
+	return retryableCodes[ErrorCode(err)]
+}
+
+func (e *LockConflictError) ErrorCode() string { return CodeLockConflict }
+
+func (e *DocVersionConflictError) ErrorCode() string { return CodeVersionConflict }
+
+func (e *RevisionConflictError) ErrorCode() string { return CodeVersionConflict }
+
+func (e *SchemaVersionError) ErrorCode() string { return CodeUnsupported }
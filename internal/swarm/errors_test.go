@@ -0,0 +1,49 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestErrorCode_ClassifiesServiceErrors(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 1, 1)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	reserved := &IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskOpen, ReservedToken: "tok", ReservedUntilMs: time.Now().Add(time.Hour).UnixMilli()}
+	if err := svc.saveTaskLocked(issueID, reserved); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+
+	_, err := svc.GetIssue("issue-missing")
+	if code := ErrorCode(err); code != CodeNotFound {
+		t.Fatalf("missing issue: expected %s, got %s (%v)", CodeNotFound, code, err)
+	}
+	_, err = svc.ClaimTask(issueID, "task-1", "w2", "", 0)
+	if code := ErrorCode(err); code != CodeReserved {
+		t.Fatalf("reserved task: expected %s, got %s (%v)", CodeReserved, code, err)
+	}
+	if ErrorRetryable(err) {
+		t.Fatalf("reserved task should not be retryable")
+	}
+	_, err = svc.ClaimTask("", "task-1", "w2", "", 0)
+	if code := ErrorCode(err); code != CodeInvalidArgument {
+		t.Fatalf("missing issue_id: expected %s, got %s (%v)", CodeInvalidArgument, code, err)
+	}
+
+	conflict := fmt.Errorf("lock: %w", &LockConflictError{Reason: LockConflictHeld, File: "a.go"})
+	if code := ErrorCode(conflict); code != CodeLockConflict || !ErrorRetryable(conflict) {
+		t.Fatalf("lock conflict: expected retryable %s, got %s", CodeLockConflict, code)
+	}
+	if code := ErrorCode(context.Canceled); code != CodeCancelled {
+		t.Fatalf("cancelled: got %s", code)
+	}
+	if code := ErrorCode(fmt.Errorf("disk full")); code != CodeInternal {
+		t.Fatalf("untyped: got %s", code)
+	}
+}
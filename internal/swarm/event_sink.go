@@ -0,0 +1,21 @@
+package swarm
+
+// EventSink receives issue events and delivery lifecycle changes (webhooks, external trackers).
+// Notify is called under store lock, so implementations must not block.
+type EventSink interface {
+	Notify(event, issueID string, data any)
+}
+
+// AddEventSink registers sink. Nil sinks are ignored. Call before serving requests.
+func (s *IssueService) AddEventSink(sink EventSink) {
+	if sink == nil {
+		return
+	}
+	s.sinks = append(s.sinks, sink)
+}
+
+func (s *IssueService) notify(event, issueID string, data any) {
+	for _, sink := range s.sinks {
+		sink.Notify(event, issueID, data)
+	}
+}
@@ -0,0 +1,92 @@
+package swarm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// maxEvidenceOutputBytes caps the stored output of a server-side evidence run (the tail is kept).
+const maxEvidenceOutputBytes = 16 * 1024
+
+// EvidenceRun is the result of the server re-running test_evidence.script_cmd.
+type EvidenceRun struct {
+	Command         string `json:"command"`
+	Workdir         string `json:"workdir"`
+	ExitCode        int    `json:"exit_code"`
+	Passed          bool   `json:"passed"`
+	TimedOut        bool   `json:"timed_out"`
+	Output          string `json:"output"`
+	Error           string `json:"error,omitempty"`
+	MatchesReported bool   `json:"matches_reported"`
+	StartedAt       string `json:"started_at"`
+	DurationMs      int64  `json:"duration_ms"`
+}
+
+type evidenceRunner struct {
+	workdir string
+	timeout time.Duration
+}
+
+// SetEvidenceRunner enables server-side execution of test_evidence.script_cmd on delivery submission.
+// Commands run via "sh -c" in workdir; an empty workdir disables the runner.
+func (s *IssueService) SetEvidenceRunner(workdir string, timeoutSec int) {
+	workdir = strings.TrimSpace(workdir)
+	if workdir == "" {
+		s.runner = nil
+		return
+	}
+	if timeoutSec <= 0 {
+		timeoutSec = 600
+	}
+	s.runner = &evidenceRunner{workdir: workdir, timeout: time.Duration(timeoutSec) * time.Second}
+}
+
+// runEvidence executes evidence.ScriptCmd if a runner is configured. Returns nil when disabled.
+// Must NOT be called under store lock (commands may run for minutes).
+func (s *IssueService) runEvidence(evidence TestEvidence) *EvidenceRun {
+	r := s.runner
+	cmdStr := strings.TrimSpace(evidence.ScriptCmd)
+	if r == nil || cmdStr == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	var buf bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", cmdStr)
+	cmd.Dir = r.workdir
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+
+	start := time.Now()
+	run := &EvidenceRun{Command: cmdStr, Workdir: r.workdir, StartedAt: NowStr()}
+	err := cmd.Run()
+	run.DurationMs = time.Since(start).Milliseconds()
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		run.TimedOut = true
+		run.ExitCode = -1
+		run.Error = "timeout after " + r.timeout.String()
+	case errors.As(err, &exitErr):
+		run.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		run.ExitCode = -1
+		run.Error = err.Error()
+	}
+	run.Passed = err == nil
+	run.MatchesReported = run.Passed == evidence.ScriptPassed
+
+	out := buf.Bytes()
+	if len(out) > maxEvidenceOutputBytes {
+		out = out[len(out)-maxEvidenceOutputBytes:]
+	}
+	run.Output = string(out)
+	return run
+}
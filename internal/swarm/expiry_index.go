@@ -0,0 +1,231 @@
+package swarm
+
+import (
+	"container/heap"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Expiry index. Rather than reading every issue, task, delivery and file lock lease on each sweep, the
+// service keeps a min-heap of upcoming deadlines (issue, task and delivery leases, review SLA deadlines,
+// lease expiry warnings), fed by the writes that set them. A sweep only pops what is due and re-checks it against the file: an extended
+// lease is pushed back with its new deadline, a released one is dropped. Leases set by other processes
+// sharing the store (each agent connection is its own process) never reach this heap, so every sweep
+// also rescans the store cheaply: it stats the files and only reads those whose mtime or size changed
+// since it last read them.
+
+const (
+	expiryIssue    = "issue"
+	expiryTask     = "task"
+	expiryReview   = "review" // review SLA of a task's open submissions
+	expiryDelivery = "delivery"
+	expiryWarning  = "warning"      // lease expiry warning to a task's owner
+	expiryLockWarn = "lock_warning" // lease expiry warning to a file lock's owner
+)
+
+type expiryEntry struct {
+	DueMs   int64
+	Kind    string
+	IssueID string
+	ID      string // task or delivery ID
+}
+
+func (e expiryEntry) key() string {
+	return e.Kind + "/" + e.IssueID + "/" + e.ID
+}
+
+type expiryHeap []expiryEntry
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].DueMs < h[j].DueMs }
+func (h expiryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *expiryHeap) Push(x any)        { *h = append(*h, x.(expiryEntry)) }
+func (h *expiryHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// expiryIndex holds the deadlines. due maps each tracked object to its current deadline; heap entries
+// that no longer match it are stale and skipped.
+type expiryIndex struct {
+	mu   sync.Mutex
+	heap expiryHeap
+	due  map[string]int64
+	seen map[string]fileStamp // file -> stamp when the rescan last read it
+}
+
+// fileStamp identifies one version of a file for the rescan. Every write replaces the file by rename, so
+// a new version also gets a new inode, which tells apart two writes within one mtime tick.
+type fileStamp struct {
+	mod  time.Time
+	size int64
+	ino  uint64
+}
+
+// track records (or moves) the deadline of an object.
+func (x *expiryIndex) track(e expiryEntry) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.due == nil {
+		x.due = map[string]int64{}
+	}
+	k := e.key()
+	if d, ok := x.due[k]; ok && d == e.DueMs {
+		return
+	}
+	x.due[k] = e.DueMs
+	heap.Push(&x.heap, e)
+}
+
+// forget stops tracking an object.
+func (x *expiryIndex) forget(kind, issueID, id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.due, expiryEntry{Kind: kind, IssueID: issueID, ID: id}.key())
+}
+
+// popDue removes and returns the next entry due before nowMs, skipping stale ones.
+func (x *expiryIndex) popDue(nowMs int64) (expiryEntry, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for len(x.heap) > 0 && x.heap[0].DueMs < nowMs {
+		e := heap.Pop(&x.heap).(expiryEntry)
+		k := e.key()
+		if d, ok := x.due[k]; !ok || d != e.DueMs {
+			continue
+		}
+		delete(x.due, k)
+		return e, true
+	}
+	return expiryEntry{}, false
+}
+
+// changed reports whether the file was written since the last rescan read it and records its stamp.
+// seen is only touched by the rescan, which runs under the store lock.
+func (x *expiryIndex) changed(path string, info os.FileInfo, scanned map[string]fileStamp) bool {
+	st := fileStamp{mod: info.ModTime(), size: info.Size()}
+	if sys, ok := info.Sys().(*syscall.Stat_t); ok {
+		st.ino = uint64(sys.Ino)
+	}
+	scanned[path] = st
+	return x.seen[path] != st
+}
+
+// Len reports how many objects are tracked.
+func (x *expiryIndex) Len() int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return len(x.due)
+}
+
+// trackIssueExpiry indexes the lease of an open or in-progress issue.
+func (s *IssueService) trackIssueExpiry(issue *Issue) {
+	if (issue.Status == IssueOpen || issue.Status == IssueInProgress) && issue.LeaseExpiresAtMs > 0 {
+		s.expiry.track(expiryEntry{DueMs: issue.LeaseExpiresAtMs, Kind: expiryIssue, IssueID: issue.ID})
+		return
+	}
+	s.expiry.forget(expiryIssue, issue.ID, "")
+}
+
+// trackTaskExpiry indexes the lease of a claimed task, the warning due before it (SetLeaseWarning) and,
+// with a review SLA, queues a check of its open submissions.
+func (s *IssueService) trackTaskExpiry(issueID string, task *IssueTask) {
+	claimed := task.Status == IssueTaskInProgress || task.Status == IssueTaskBlocked
+	if claimed && task.LeaseExpiresAtMs > 0 && task.LeaseFrozenAt == "" {
+		s.expiry.track(expiryEntry{DueMs: task.LeaseExpiresAtMs, Kind: expiryTask, IssueID: issueID, ID: task.ID})
+	} else {
+		s.expiry.forget(expiryTask, issueID, task.ID)
+	}
+	if claimed && s.leaseWarnSec > 0 && task.LeaseExpiresAtMs > 0 && task.LeaseFrozenAt == "" && task.LeaseWarnedForMs != task.LeaseExpiresAtMs {
+		s.expiry.track(expiryEntry{DueMs: task.LeaseExpiresAtMs - int64(s.leaseWarnSec)*1000, Kind: expiryWarning, IssueID: issueID, ID: task.ID})
+	} else {
+		s.expiry.forget(expiryWarning, issueID, task.ID)
+	}
+	if !claimed {
+		s.expiry.forget(expiryReview, issueID, task.ID)
+	}
+}
+
+// trackReviewDeadline queues a review SLA check of the task's open submissions at dueMs.
+func (s *IssueService) trackReviewDeadline(issueID, taskID string, dueMs int64) {
+	if s.reviewSLASec > 0 {
+		s.expiry.track(expiryEntry{DueMs: dueMs, Kind: expiryReview, IssueID: issueID, ID: taskID})
+	}
+}
+
+// trackDeliveryExpiry indexes the review lease of a claimed delivery.
+func (s *IssueService) trackDeliveryExpiry(d *Delivery) {
+	if d.Status == DeliveryInReview && d.LeaseExpiresAtMs > 0 {
+		s.expiry.track(expiryEntry{DueMs: d.LeaseExpiresAtMs, Kind: expiryDelivery, ID: d.ID})
+		return
+	}
+	s.expiry.forget(expiryDelivery, "", d.ID)
+}
+
+// scanExpiryLocked indexes every issue, task, delivery and file lock lease written since the previous
+// scan, by this process or another one; the first scan reads them all. Must be called under store lock.
+func (s *IssueService) scanExpiryLocked() {
+	x := &s.expiry
+	scanned := make(map[string]fileStamp, len(x.seen))
+	read := func(dir string, fn func(path string)) {
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			if x.changed(path, info, scanned) {
+				fn(path)
+			}
+		}
+	}
+	issues, _ := os.ReadDir(s.store.Path("issues"))
+	for _, e := range issues {
+		if !e.IsDir() {
+			continue
+		}
+		issueID := e.Name()
+		issuePath := s.store.Path("issues", issueID, "issue.json")
+		if info, err := os.Stat(issuePath); err == nil && x.changed(issuePath, info, scanned) {
+			var issue Issue
+			if err := s.store.ReadJSON(issuePath, &issue); err == nil {
+				s.trackIssueExpiry(&issue)
+			}
+		}
+		read(s.store.Path("issues", issueID, "tasks"), func(path string) {
+			var task IssueTask
+			if err := s.store.ReadJSON(path, &task); err != nil {
+				return
+			}
+			s.trackTaskExpiry(issueID, &task)
+			if task.Status == IssueTaskInProgress || task.Status == IssueTaskBlocked {
+				s.trackReviewDeadline(issueID, task.ID, 0)
+			}
+		})
+	}
+	read(s.store.Path("deliveries"), func(path string) {
+		var d Delivery
+		if err := s.store.ReadJSON(path, &d); err == nil {
+			s.trackDeliveryExpiry(&d)
+		}
+	})
+	if s.leaseWarnSec > 0 {
+		read(s.store.Path("locks", "leases"), func(path string) {
+			var l Lease
+			if err := s.store.ReadJSON(path, &l); err == nil {
+				s.trackLockWarning(&l)
+			}
+		})
+	}
+	x.seen = scanned
+}
@@ -0,0 +1,57 @@
+package swarm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSweepExpired_OnlyTouchesIndexedDeadlines(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 3}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	future := time.Now().Add(time.Hour).UnixMilli()
+	if err := svc.saveTaskLocked(issueID, &IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskInProgress, ClaimedBy: "w1", LeaseExpiresAtMs: future}); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+
+	// The first sweep scans the store and indexes task-1's lease.
+	svc.SweepExpired()
+	if n := svc.expiry.Len(); n != 1 {
+		t.Fatalf("expected one tracked deadline, got %d", n)
+	}
+
+	// A lease written by another process sharing the store is picked up by the next sweep's rescan.
+	past := time.Now().Add(-time.Minute).UnixMilli()
+	other := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+	if err := other.saveTaskLocked(issueID, &IssueTask{ID: "task-2", IssueID: issueID, Status: IssueTaskInProgress, ClaimedBy: "w2", LeaseExpiresAtMs: past}); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	svc.SweepExpired()
+	if task, _ := svc.loadTaskLocked(issueID, "task-2"); task.Status != IssueTaskOpen {
+		t.Fatalf("expected task-2 reopened by the next sweep, got %s", task.Status)
+	}
+
+	// Saving task-1 with a lapsed lease moves its deadline; the sweep reopens it.
+	task, err := svc.loadTaskLocked(issueID, "task-1")
+	if err != nil {
+		t.Fatalf("load task: %v", err)
+	}
+	task.LeaseExpiresAtMs = past
+	if err := svc.saveTaskLocked(issueID, task); err != nil {
+		t.Fatalf("save task: %v", err)
+	}
+	svc.SweepExpired()
+	if task, _ := svc.loadTaskLocked(issueID, "task-1"); task.Status != IssueTaskOpen || task.ClaimedBy != "" {
+		t.Fatalf("expected task-1 reopened, got %+v", task)
+	}
+	if n := svc.expiry.Len(); n != 0 {
+		t.Fatalf("expected no tracked deadlines, got %d", n)
+	}
+}
@@ -0,0 +1,338 @@
+package swarm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Fsck problem kinds.
+const (
+	FsckInvalidJSON      = "invalid_json"
+	FsckStaleTmp         = "stale_tmp"
+	FsckMissingIssue     = "missing_issue"
+	FsckTaskMismatch     = "task_mismatch"
+	FsckOrphanLock       = "orphan_lock"
+	FsckLeaseMissing     = "lease_missing_lock"
+	FsckExpiredLock      = "expired_lock"
+	FsckOrphanDelivery   = "orphan_delivery"
+	FsckOrphanSubmission = "orphan_submission"
+	FsckOrphanMessage    = "orphan_message"
+	FsckOrphanInbox      = "orphan_inbox_item"
+	FsckDanglingNextStep = "dangling_next_step"
+	FsckDanglingReserved = "dangling_reservation"
+)
+
+// fsckQuarantineDirName holds records FsckRepair moved out of the way.
+const fsckQuarantineDirName = "lost+found"
+
+// FsckProblem is one inconsistency found in the store. Repair describes what FsckRepair did (or would
+// do); it is empty for problems that need a human, such as unreadable JSON.
+type FsckProblem struct {
+	Kind     string `json:"kind"`
+	Path     string `json:"path"`
+	Detail   string `json:"detail"`
+	Repair   string `json:"repair,omitempty"`
+	Repaired bool   `json:"repaired,omitempty"`
+}
+
+// Fsck checks the store for unreadable JSON, leftover temp files and dangling references between
+// issues, tasks, submissions, messages, inbox items, next-step tokens, deliveries, leases and file locks.
+// It only reads; nothing is repaired.
+func Fsck(store *Store) ([]FsckProblem, error) {
+	return fsck(store, false)
+}
+
+// FsckRepair runs the same checks and fixes what can be fixed mechanically: temp files, orphan locks and
+// broken leases are removed, dangling reservations are cleared, and orphaned records are moved to
+// <root>/lost+found/<timestamp>/ rather than deleted.
+func FsckRepair(store *Store) ([]FsckProblem, error) {
+	return fsck(store, true)
+}
+
+func fsck(store *Store, repair bool) ([]FsckProblem, error) {
+	var problems []FsckProblem
+	var fixes []func() error
+	quarantineRoot := store.Path(fsckQuarantineDirName, time.Now().UTC().Format("20060102T150405Z"))
+
+	rel := func(path string) string {
+		r, err := filepath.Rel(store.Root, path)
+		if err != nil {
+			return path
+		}
+		return r
+	}
+	add := func(kind, path, detail, action string, fix func() error) {
+		problems = append(problems, FsckProblem{Kind: kind, Path: rel(path), Detail: detail, Repair: action})
+		fixes = append(fixes, fix)
+	}
+	remove := func(path string) func() error {
+		return func() error { return os.RemoveAll(path) }
+	}
+	quarantine := func(path string) func() error {
+		return func() error {
+			dst := filepath.Join(quarantineRoot, rel(path))
+			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+				return err
+			}
+			return os.Rename(path, dst)
+		}
+	}
+	const (
+		actRemove     = "remove"
+		actQuarantine = "move to " + fsckQuarantineDirName
+	)
+
+	err := store.WithLock(func() error {
+		walkErr := filepath.WalkDir(store.Root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				// Project namespaces are separate stores and are checked on their own.
+				if path == store.Path(fsckQuarantineDirName) || path == store.Path("projects") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			switch {
+			case strings.HasSuffix(path, ".json.tmp"):
+				add(FsckStaleTmp, path, "interrupted write", actRemove, remove(path))
+			case strings.HasSuffix(path, ".json"):
+				data, err := os.ReadFile(path)
+				if err != nil {
+					add(FsckInvalidJSON, path, err.Error(), "", nil)
+				} else if !json.Valid(data) {
+					add(FsckInvalidJSON, path, "not valid JSON", "", nil)
+				}
+			}
+			return nil
+		})
+		if walkErr != nil && !os.IsNotExist(walkErr) {
+			return walkErr
+		}
+
+		issues := map[string]bool{}
+		entries, _ := os.ReadDir(store.Path("issues"))
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			issueID := e.Name()
+			var issue Issue
+			if err := store.ReadJSON(store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+				dir := store.Path("issues", issueID)
+				if store.Exists("issues", issueID, "issue.json") {
+					// Unreadable issue.json is already reported as invalid_json; leave the directory for a human.
+					add(FsckMissingIssue, dir, "issue.json is unreadable; tasks and records under it are stranded", "", nil)
+				} else {
+					add(FsckMissingIssue, dir, "issue directory without issue.json; tasks and records under it are stranded", actQuarantine, quarantine(dir))
+				}
+				continue
+			}
+			issues[issueID] = true
+
+			tasks := map[string]*IssueTask{}
+			for _, f := range listJSONOrEmpty(store, store.Path("issues", issueID, "tasks")) {
+				var task IssueTask
+				if err := store.ReadJSON(f, &task); err != nil {
+					continue
+				}
+				want := strings.TrimSuffix(filepath.Base(f), ".json")
+				if task.ID != want || task.IssueID != issueID {
+					add(FsckTaskMismatch, f, "task "+task.ID+" of issue "+task.IssueID+" stored under "+issueID+"/"+want, actQuarantine, quarantine(f))
+					continue
+				}
+				t := task
+				tasks[task.ID] = &t
+			}
+
+			submissions := map[string]bool{}
+			subDirs, _ := os.ReadDir(store.Path("issues", issueID, "submissions"))
+			for _, sd := range subDirs {
+				if !sd.IsDir() {
+					continue
+				}
+				dir := store.Path("issues", issueID, "submissions", sd.Name())
+				if tasks[sd.Name()] == nil {
+					add(FsckOrphanSubmission, dir, "submissions for unknown task "+sd.Name(), actQuarantine, quarantine(dir))
+					continue
+				}
+				for _, f := range listJSONOrEmpty(store, dir) {
+					submissions[strings.TrimSuffix(filepath.Base(f), ".json")] = true
+				}
+			}
+
+			messages := map[string]bool{}
+			for _, f := range listJSONOrEmpty(store, store.Path("issues", issueID, "messages")) {
+				var msg TaskMessage
+				if err := store.ReadJSON(f, &msg); err != nil {
+					continue
+				}
+				if msg.TaskID != "" && tasks[msg.TaskID] == nil {
+					add(FsckOrphanMessage, f, "message for unknown task "+msg.TaskID, actQuarantine, quarantine(f))
+					continue
+				}
+				messages[msg.ID] = true
+			}
+
+			amendments := map[string]bool{}
+			for _, f := range listJSONOrEmpty(store, store.Path("issues", issueID, "amendments")) {
+				amendments[strings.TrimSuffix(filepath.Base(f), ".json")] = true
+			}
+			comments := map[string]bool{}
+			commentFiles, _ := filepath.Glob(store.Path("issues", issueID, "submission_comments", "*", "*.json"))
+			for _, f := range commentFiles {
+				comments[strings.TrimSuffix(filepath.Base(f), ".json")] = true
+			}
+
+			inboxFiles := listJSONOrEmpty(store, store.Path("issues", issueID, "inbox", "lead"))
+			workerDirs, _ := os.ReadDir(store.Path("issues", issueID, "inbox", "workers"))
+			for _, wd := range workerDirs {
+				if wd.IsDir() {
+					inboxFiles = append(inboxFiles, listJSONOrEmpty(store, store.Path("issues", issueID, "inbox", "workers", wd.Name()))...)
+				}
+			}
+			for _, f := range inboxFiles {
+				var item InboxItem
+				if err := store.ReadJSON(f, &item); err != nil {
+					continue
+				}
+				detail := ""
+				switch {
+				case item.TaskID != "" && tasks[item.TaskID] == nil:
+					detail = "inbox item for unknown task " + item.TaskID
+				case (item.Type == InboxTypeSubmission || item.Type == InboxTypeReviewResult || item.Type == InboxTypeReviewOverdue || item.Type == InboxTypeEscalation || item.Type == InboxTypePeerReview || item.Type == InboxTypePeerReviewNeeded) && !submissions[item.RefID]:
+					detail = item.Type + " inbox item references missing submission " + item.RefID
+				case (item.Type == InboxTypeQuestion || item.Type == InboxTypeBlocker || item.Type == InboxTypeWorkerEscalation || item.Type == InboxTypeReply) && !messages[item.RefID]:
+					detail = item.Type + " inbox item references missing message " + item.RefID
+				case item.Type == InboxTypeSpecAmended && !amendments[item.RefID]:
+					detail = item.Type + " inbox item references missing amendment " + item.RefID
+				case item.Type == InboxTypeSubmissionComment && !comments[item.RefID]:
+					detail = item.Type + " inbox item references missing comment " + item.RefID
+				}
+				if detail != "" {
+					add(FsckOrphanInbox, f, detail, actQuarantine, quarantine(f))
+				}
+			}
+
+			tokens := map[string]bool{}
+			for _, f := range listJSONOrEmpty(store, store.Path("issues", issueID, "next_steps")) {
+				var tok NextStepToken
+				if err := store.ReadJSON(f, &tok); err != nil {
+					continue
+				}
+				if tok.NextStep.TaskID != "" && tasks[tok.NextStep.TaskID] == nil {
+					add(FsckDanglingNextStep, f, "next_step token "+tok.Token+" points at unknown task "+tok.NextStep.TaskID, actQuarantine, quarantine(f))
+					continue
+				}
+				tokens[tok.Token] = true
+			}
+			for _, task := range tasks {
+				if task.ReservedToken == "" || tokens[task.ReservedToken] {
+					continue
+				}
+				task := task
+				path := store.Path("issues", issueID, "tasks", task.ID+".json")
+				add(FsckDanglingReserved, path, "task reserved by missing next_step token "+task.ReservedToken, "clear reservation", func() error {
+					task.ReservedToken = ""
+					task.ReservedUntilMs = 0
+					task.Rev++
+					task.UpdatedAt = NowStr()
+					return store.WriteJSON(path, task)
+				})
+			}
+		}
+
+		deliveries := map[string]bool{}
+		for _, f := range listJSONOrEmpty(store, store.Path("deliveries")) {
+			var d Delivery
+			if err := store.ReadJSON(f, &d); err != nil {
+				continue
+			}
+			if !issues[d.IssueID] {
+				add(FsckOrphanDelivery, f, "delivery for unknown issue "+d.IssueID, actQuarantine, quarantine(f))
+				continue
+			}
+			deliveries[d.ID] = true
+		}
+		for _, f := range listJSONOrEmpty(store, store.Path("deliveries", "inbox", "acceptor")) {
+			var item InboxItem
+			if err := store.ReadJSON(f, &item); err != nil {
+				continue
+			}
+			if item.Type == InboxTypeDelivery && !deliveries[item.RefID] {
+				add(FsckOrphanInbox, f, "delivery inbox item references missing delivery "+item.RefID, actQuarantine, quarantine(f))
+			}
+		}
+
+		now := time.Now().UTC()
+		leases := map[string]Lease{}
+		for _, f := range listJSONOrEmpty(store, store.Path("locks", "leases")) {
+			var lease Lease
+			if err := store.ReadJSON(f, &lease); err != nil {
+				continue
+			}
+			leases[lease.LeaseID] = lease
+			var missing []string
+			for _, file := range lease.Files {
+				lockPath := store.Path("locks", "files", LockKey(lease.Scope, file)+".json")
+				var lock FileLock
+				if err := store.ReadJSON(lockPath, &lock); err != nil || lock.LeaseID != lease.LeaseID {
+					missing = append(missing, file)
+				}
+			}
+			if len(missing) > 0 {
+				lease := lease
+				leasePath := f
+				add(FsckLeaseMissing, f, "lease "+lease.LeaseID+" does not hold "+strings.Join(missing, ", "), "release lease", func() error {
+					// A half-acquired lease is worthless: drop it together with the locks it still holds.
+					for _, file := range lease.Files {
+						lockPath := store.Path("locks", "files", LockKey(lease.Scope, file)+".json")
+						var lock FileLock
+						if err := store.ReadJSON(lockPath, &lock); err == nil && lock.LeaseID == lease.LeaseID {
+							if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+								return err
+							}
+						}
+					}
+					return os.Remove(leasePath)
+				})
+			}
+		}
+		for _, f := range listJSONOrEmpty(store, store.Path("locks", "files")) {
+			var lock FileLock
+			if err := store.ReadJSON(f, &lock); err != nil {
+				continue
+			}
+			if _, ok := leases[lock.LeaseID]; !ok {
+				add(FsckOrphanLock, f, "lock on "+lock.File+" references missing lease "+lock.LeaseID, actRemove, remove(f))
+				continue
+			}
+			if exp, err := time.Parse(time.RFC3339, lock.ExpiresAt); err == nil && now.After(exp) {
+				// Expired locks are reclaimed by the next locker or `locks clean`; nothing is broken.
+				add(FsckExpiredLock, f, "lock on "+lock.File+" expired at "+lock.ExpiresAt, "", nil)
+			}
+		}
+
+		if !repair {
+			return nil
+		}
+		for i, fix := range fixes {
+			if fix == nil {
+				continue
+			}
+			if err := fix(); err != nil && !os.IsNotExist(err) {
+				problems[i].Detail += fmt.Sprintf(" (repair failed: %v)", err)
+				continue
+			}
+			problems[i].Repaired = true
+		}
+		return nil
+	})
+	return problems, err
+}
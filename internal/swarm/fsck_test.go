@@ -0,0 +1,113 @@
+package swarm
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFsck_ReportsDanglingReferences(t *testing.T) {
+	store := NewStore(t.TempDir())
+	if err := store.WriteJSON(store.Path("issues", "i1", "issue.json"), Issue{ID: "i1", Status: "open"}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", "i1", "tasks", "t1.json"), IssueTask{ID: "t1", IssueID: "i1"}); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", "i1", "tasks", "t2.json"), IssueTask{ID: "t3", IssueID: "i1"}); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("deliveries", "d1.json"), Delivery{ID: "d1", IssueID: "gone"}); err != nil {
+		t.Fatalf("write delivery: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("locks", "files", LockKey("", "a.go")+".json"), FileLock{LeaseID: "l-missing", File: "a.go"}); err != nil {
+		t.Fatalf("write lock: %v", err)
+	}
+	if err := os.WriteFile(store.Path("issues", "i1", "meta.json"), []byte("{broken"), 0644); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+
+	problems, err := Fsck(store)
+	if err != nil {
+		t.Fatalf("fsck: %v", err)
+	}
+	kinds := map[string]int{}
+	for _, p := range problems {
+		kinds[p.Kind]++
+	}
+	for _, want := range []string{FsckInvalidJSON, FsckTaskMismatch, FsckOrphanDelivery, FsckOrphanLock} {
+		if kinds[want] != 1 {
+			t.Fatalf("expected one %s problem, got %+v", want, problems)
+		}
+	}
+	if len(problems) != 4 {
+		t.Fatalf("unexpected problems: %+v", problems)
+	}
+}
+
+func TestFsckRepair_QuarantinesOrphansAndClearsReservations(t *testing.T) {
+	store := NewStore(t.TempDir())
+	if err := store.WriteJSON(store.Path("issues", "i1", "issue.json"), Issue{ID: "i1", Status: "open"}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", "i1", "tasks", "t1.json"), IssueTask{ID: "t1", IssueID: "i1", ReservedToken: "ns-gone", ReservedUntilMs: 1}); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", "i1", "messages", "m1.json"), TaskMessage{ID: "m1", IssueID: "i1", TaskID: "t9"}); err != nil {
+		t.Fatalf("write message: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", "i1", "inbox", "lead", "in1.json"), InboxItem{ID: "in1", IssueID: "i1", TaskID: "t1", Type: InboxTypeSubmission, RefID: "sub-gone"}); err != nil {
+		t.Fatalf("write inbox: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", "i1", "next_steps", "ns1.json"), NextStepToken{Token: "ns1", IssueID: "i1", NextStep: NextStep{TaskID: "t9"}}); err != nil {
+		t.Fatalf("write token: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", "i2", "tasks", "t1.json"), IssueTask{ID: "t1", IssueID: "i2"}); err != nil {
+		t.Fatalf("write stranded task: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("locks", "files", LockKey("", "a.go")+".json"), FileLock{LeaseID: "l-missing", File: "a.go"}); err != nil {
+		t.Fatalf("write lock: %v", err)
+	}
+
+	problems, err := FsckRepair(store)
+	if err != nil {
+		t.Fatalf("fsck repair: %v", err)
+	}
+	kinds := map[string]int{}
+	for _, p := range problems {
+		kinds[p.Kind]++
+		if !p.Repaired {
+			t.Fatalf("expected %s at %s to be repaired", p.Kind, p.Path)
+		}
+	}
+	for _, want := range []string{FsckOrphanMessage, FsckOrphanInbox, FsckDanglingNextStep, FsckDanglingReserved, FsckMissingIssue, FsckOrphanLock} {
+		if kinds[want] != 1 {
+			t.Fatalf("expected one %s problem, got %+v", want, problems)
+		}
+	}
+
+	var task IssueTask
+	if err := store.ReadJSON(store.Path("issues", "i1", "tasks", "t1.json"), &task); err != nil {
+		t.Fatalf("read task: %v", err)
+	}
+	if task.ReservedToken != "" || task.ReservedUntilMs != 0 {
+		t.Fatalf("expected reservation cleared, got %+v", task)
+	}
+	if store.Exists("issues", "i2") || store.Exists("issues", "i1", "messages", "m1.json") {
+		t.Fatalf("expected orphans moved out of the store")
+	}
+	lost, err := os.ReadDir(store.Path(fsckQuarantineDirName))
+	if err != nil || len(lost) != 1 {
+		t.Fatalf("expected one quarantine batch, got %v (%v)", lost, err)
+	}
+	if _, err := os.Stat(store.Path(fsckQuarantineDirName, lost[0].Name(), "issues", "i2", "tasks", "t1.json")); err != nil {
+		t.Fatalf("expected stranded task in quarantine: %v", err)
+	}
+
+	again, err := Fsck(store)
+	if err != nil {
+		t.Fatalf("fsck: %v", err)
+	}
+	if len(again) != 0 {
+		t.Fatalf("expected clean store after repair, got %+v", again)
+	}
+}
@@ -0,0 +1,105 @@
+package swarm
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type gitVerifier struct {
+	repoPath string
+	baseRef  string
+}
+
+// SetGitRepo enables git verification of submitted changed_files against repoPath.
+// baseRef is used when a submission does not declare artifacts.base_ref (default "HEAD").
+// An empty repoPath disables verification.
+func (s *IssueService) SetGitRepo(repoPath, baseRef string) {
+	repoPath = strings.TrimSpace(repoPath)
+	if repoPath == "" {
+		s.git = nil
+		return
+	}
+	baseRef = strings.TrimSpace(baseRef)
+	if baseRef == "" {
+		baseRef = "HEAD"
+	}
+	s.git = &gitVerifier{repoPath: repoPath, baseRef: baseRef}
+}
+
+// verifyChangedFiles rejects submissions declaring files that git does not see as changed since the base ref.
+// Changes present in git but not declared are tolerated: the worktree is shared with other workers, so they
+// cannot be attributed to this submission.
+func (s *IssueService) verifyChangedFiles(artifacts SubmissionArtifacts) error {
+	g := s.git
+	if g == nil {
+		return nil
+	}
+	baseRef := strings.TrimSpace(artifacts.BaseRef)
+	if baseRef == "" {
+		baseRef = g.baseRef
+	}
+	if strings.HasPrefix(baseRef, "-") {
+		return Errorf(CodeInvalidArgument, "invalid artifacts.base_ref: %s", baseRef)
+	}
+
+	actual, err := g.changedFiles(baseRef)
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, f := range artifacts.ChangedFiles {
+		rel := g.normalize(f)
+		if rel == "" {
+			continue
+		}
+		if !actual[rel] {
+			missing = append(missing, rel)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return Errorf(CodeInvalidArgument, "artifacts.changed_files do not match git diff against %s; not changed: %s", baseRef, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// changedFiles returns tracked files differing from baseRef plus untracked, non-ignored files.
+func (g *gitVerifier) changedFiles(baseRef string) (map[string]bool, error) {
+	out := map[string]bool{}
+	for _, args := range [][]string{
+		{"diff", "--name-only", baseRef, "--"},
+		{"ls-files", "--others", "--exclude-standard"},
+	} {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.Command("git", append([]string{"-C", g.repoPath}, args...)...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+		}
+		for _, line := range strings.Split(stdout.String(), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				out[line] = true
+			}
+		}
+	}
+	return out, nil
+}
+
+// normalize converts a declared path to the repo-relative, slash-separated form git prints.
+func (g *gitVerifier) normalize(p string) string {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return ""
+	}
+	if filepath.IsAbs(p) {
+		if rel, err := filepath.Rel(g.repoPath, p); err == nil {
+			p = rel
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(p))
+}
@@ -0,0 +1,46 @@
+package swarm
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyChangedFiles_RejectsFilesMissingFromGitDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=t", "-c", "user.email=t@t"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	run("init", "-q")
+	write("a.go", "package a\n")
+	write("b.go", "package b\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "init")
+
+	write("a.go", "package a\n\nvar X = 1\n")
+	write("c.go", "package c\n")
+
+	root := t.TempDir()
+	store := NewStore(root)
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+	svc.SetGitRepo(repo, "")
+
+	if err := svc.verifyChangedFiles(SubmissionArtifacts{ChangedFiles: []string{"a.go", "./c.go"}}); err != nil {
+		t.Fatalf("expected match, got %v", err)
+	}
+	if err := svc.verifyChangedFiles(SubmissionArtifacts{ChangedFiles: []string{"a.go", "b.go"}}); err == nil {
+		t.Fatalf("expected b.go to be rejected")
+	}
+}
@@ -0,0 +1,334 @@
+package swarm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const EventIssueGitHubComment = "issue_github_comment"
+
+// githubMarker tags comments posted by the mirror so the inbound poll does not import them back.
+const githubMarker = "<!-- swarm-mcp -->"
+
+// GitHubSyncConfig configures the GitHub Issues mirror. Repo is "owner/name".
+type GitHubSyncConfig struct {
+	Repo    string
+	Token   string
+	APIBase string
+	PollSec int
+}
+
+// githubLink maps a swarm issue to its mirrored GitHub issue (stored at github/issues/<issue_id>.json).
+type githubLink struct {
+	IssueID       string `json:"issue_id"`
+	Number        int    `json:"number"`
+	URL           string `json:"url"`
+	LastCommentID int64  `json:"last_comment_id"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+type githubEvent struct {
+	event   string
+	issueID string
+	data    any
+}
+
+// GitHubSync mirrors swarm issues into GitHub issues: createIssue opens one, resolved tasks and
+// delivery reviews are posted as comments, and closeIssue/reopenIssue update its state. When PollSec > 0,
+// comments made on GitHub are imported back as issue_github_comment events.
+type GitHubSync struct {
+	cfg    GitHubSyncConfig
+	issues *IssueService
+	store  *Store
+	logger *log.Logger
+	client *http.Client
+	queue  chan githubEvent
+}
+
+// NewGitHubSync starts the mirror goroutines. Returns nil when repo or token is missing.
+func NewGitHubSync(cfg GitHubSyncConfig, issues *IssueService, store *Store, logger *log.Logger) *GitHubSync {
+	cfg.Repo = strings.Trim(strings.TrimSpace(cfg.Repo), "/")
+	cfg.Token = strings.TrimSpace(cfg.Token)
+	if cfg.Repo == "" || cfg.Token == "" {
+		return nil
+	}
+	if strings.TrimSpace(cfg.APIBase) == "" {
+		cfg.APIBase = "https://api.github.com"
+	}
+	cfg.APIBase = strings.TrimRight(strings.TrimSpace(cfg.APIBase), "/")
+	g := &GitHubSync{
+		cfg:    cfg,
+		issues: issues,
+		store:  store,
+		logger: logger,
+		client: &http.Client{Timeout: 15 * time.Second},
+		queue:  make(chan githubEvent, webhookQueueSize),
+	}
+	go g.loop()
+	if cfg.PollSec > 0 {
+		go g.pollLoop()
+	}
+	return g
+}
+
+// Notify enqueues an event without blocking (implements EventSink).
+func (g *GitHubSync) Notify(event, issueID string, data any) {
+	switch event {
+	case EventIssueCreated, EventIssueClosed, EventIssueReopened, EventIssueTaskResolved, EventDeliveryReviewed:
+	default:
+		return
+	}
+	select {
+	case g.queue <- githubEvent{event: event, issueID: issueID, data: data}:
+	default:
+		g.logf("WARNING: github sync queue full; dropping %s for %s", event, issueID)
+	}
+}
+
+func (g *GitHubSync) loop() {
+	for ev := range g.queue {
+		if err := g.handle(ev); err != nil {
+			g.logf("WARNING: github sync %s for %s: %v", ev.event, ev.issueID, err)
+		}
+	}
+}
+
+func (g *GitHubSync) handle(ev githubEvent) error {
+	if ev.event == EventIssueCreated {
+		return g.createIssue(ev.issueID)
+	}
+	link, err := g.readLink(ev.issueID)
+	if err != nil {
+		return nil // issue was not mirrored (created before sync was enabled)
+	}
+	path := fmt.Sprintf("/repos/%s/issues/%d", g.cfg.Repo, link.Number)
+	switch ev.event {
+	case EventIssueClosed:
+		return g.do(http.MethodPatch, path, map[string]any{"state": "closed"}, nil)
+	case EventIssueReopened:
+		return g.do(http.MethodPatch, path, map[string]any{"state": "open"}, nil)
+	case EventIssueTaskResolved:
+		e, _ := ev.data.(IssueEvent)
+		subject := e.TaskID
+		var task IssueTask
+		if err := g.store.ReadJSON(g.store.Path("issues", ev.issueID, "tasks", e.TaskID+".json"), &task); err == nil && task.Subject != "" {
+			subject = task.Subject
+		}
+		return g.comment(path, fmt.Sprintf("Task `%s` resolved: %s", e.TaskID, subject))
+	case EventDeliveryReviewed:
+		d, _ := ev.data.(*Delivery)
+		if d == nil {
+			return nil
+		}
+		body := fmt.Sprintf("Delivery `%s` (revision %d) %s by %s.", d.ID, deliveryRevision(d), d.Status, d.ReviewedBy)
+		if strings.TrimSpace(d.Feedback) != "" {
+			body += "\n\n" + d.Feedback
+		}
+		return g.comment(path, body)
+	}
+	return nil
+}
+
+func (g *GitHubSync) createIssue(issueID string) error {
+	if _, err := g.readLink(issueID); err == nil {
+		return nil // already mirrored by another server process
+	}
+	var issue Issue
+	if err := g.store.ReadJSON(g.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+		return err
+	}
+	var out struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	body := strings.TrimSpace(issue.Description) + "\n\n_swarm issue: `" + issueID + "`_\n" + githubMarker
+	if err := g.do(http.MethodPost, "/repos/"+g.cfg.Repo+"/issues", map[string]any{"title": issue.Subject, "body": body}, &out); err != nil {
+		return err
+	}
+	return g.store.WithLock(func() error {
+		g.store.EnsureDir("github", "issues")
+		return g.store.WriteJSON(g.store.Path("github", "issues", issueID+".json"), &githubLink{
+			IssueID:   issueID,
+			Number:    out.Number,
+			URL:       out.HTMLURL,
+			UpdatedAt: NowStr(),
+		})
+	})
+}
+
+func (g *GitHubSync) comment(issuePath, body string) error {
+	return g.do(http.MethodPost, issuePath+"/comments", map[string]any{"body": body + "\n\n" + githubMarker}, nil)
+}
+
+func (g *GitHubSync) pollLoop() {
+	// One process per store polls, so comments are not imported once per agent connection.
+	leader := g.store.Leader("github-poll")
+	ticker := time.NewTicker(time.Duration(g.cfg.PollSec) * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !leader.Held() {
+			continue
+		}
+		for _, f := range listJSONOrEmpty(g.store, g.store.Path("github", "issues")) {
+			var link githubLink
+			if err := g.store.ReadJSON(f, &link); err != nil {
+				continue
+			}
+			if err := g.pullComments(link); err != nil {
+				g.logf("WARNING: github sync poll %s: %v", link.IssueID, err)
+			}
+		}
+	}
+}
+
+// pullComments imports GitHub comments newer than link.LastCommentID as issue events.
+func (g *GitHubSync) pullComments(link githubLink) error {
+	var issue Issue
+	if err := g.store.ReadJSON(g.store.Path("issues", link.IssueID, "issue.json"), &issue); err != nil {
+		return err
+	}
+	if issue.Status != IssueOpen && issue.Status != IssueInProgress {
+		return nil
+	}
+	type comment struct {
+		ID   int64  `json:"id"`
+		Body string `json:"body"`
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		HTMLURL string `json:"html_url"`
+	}
+	var comments []comment
+	url := g.cfg.APIBase + fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=100", g.cfg.Repo, link.Number)
+	for pages := 0; url != ""; pages++ {
+		if pages == githubMaxCommentPages {
+			return fmt.Errorf("github issue %d: more than %d pages of comments", link.Number, githubMaxCommentPages)
+		}
+		var page []comment
+		hdr, err := g.request(http.MethodGet, url, nil, &page)
+		if err != nil {
+			return err
+		}
+		comments = append(comments, page...)
+		url = g.nextPage(hdr.Get("Link"))
+	}
+
+	imported := false
+	err := g.store.WithLock(func() error {
+		// Re-read under lock: several server processes may poll the same issue.
+		linkPath := g.store.Path("github", "issues", link.IssueID+".json")
+		var cur githubLink
+		if err := g.store.ReadJSON(linkPath, &cur); err != nil {
+			return err
+		}
+		for _, c := range comments {
+			if c.ID <= cur.LastCommentID {
+				continue
+			}
+			cur.LastCommentID = c.ID
+			if strings.Contains(c.Body, githubMarker) {
+				continue
+			}
+			if err := g.issues.appendGitHubCommentLocked(link.IssueID, c.User.Login, c.Body, c.HTMLURL); err != nil {
+				return err
+			}
+			imported = true
+		}
+		cur.UpdatedAt = NowStr()
+		return g.store.WriteJSON(linkPath, &cur)
+	})
+	if err != nil {
+		return err
+	}
+	if imported {
+		g.issues.bump(link.IssueID)
+	}
+	return nil
+}
+
+// appendGitHubCommentLocked records an imported comment. Must be called under store lock.
+func (s *IssueService) appendGitHubCommentLocked(issueID, login, body, url string) error {
+	return s.appendEventLocked(issueID, IssueEvent{
+		Type:      EventIssueGitHubComment,
+		IssueID:   issueID,
+		Actor:     "github:" + login,
+		Detail:    body,
+		Refs:      url,
+		Timestamp: NowStr(),
+	})
+}
+
+func (g *GitHubSync) readLink(issueID string) (*githubLink, error) {
+	var link githubLink
+	if err := g.store.ReadJSON(g.store.Path("github", "issues", issueID+".json"), &link); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// githubMaxCommentPages bounds how many pages of comments one poll follows.
+const githubMaxCommentPages = 50
+
+var githubNextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// nextPage returns the rel="next" URL of a Link header, or "" on the last page. Only URLs on the
+// configured API base are followed, so the token is never sent elsewhere.
+func (g *GitHubSync) nextPage(linkHeader string) string {
+	m := githubNextLink.FindStringSubmatch(linkHeader)
+	if m == nil || !strings.HasPrefix(m[1], g.cfg.APIBase+"/") {
+		return ""
+	}
+	return m[1]
+}
+
+func (g *GitHubSync) do(method, path string, in, out any) error {
+	_, err := g.request(method, g.cfg.APIBase+path, in, out)
+	return err
+}
+
+// request calls the API at url and decodes the response into out; it returns the response headers.
+func (g *GitHubSync) request(method, url string, in, out any) (http.Header, error) {
+	var body io.Reader
+	if in != nil {
+		bs, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(bs)
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+g.cfg.Token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s %s: status %d: %s", method, strings.TrimPrefix(url, g.cfg.APIBase), resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return resp.Header, nil
+	}
+	return resp.Header, json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (g *GitHubSync) logf(format string, args ...any) {
+	if g.logger != nil {
+		g.logger.Printf(format, args...)
+	}
+}
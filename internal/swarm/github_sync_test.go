@@ -0,0 +1,89 @@
+package swarm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGitHubSync_MirrorsIssueAndImportsComments(t *testing.T) {
+	created := make(chan map[string]any, 1)
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/o/r/issues":
+			var in map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&in)
+			created <- in
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"number":7,"html_url":"https://github.com/o/r/issues/7"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/o/r/issues/7/comments" && r.URL.Query().Get("page") == "":
+			w.Header().Set("Link", `<`+srv.URL+`/repos/o/r/issues/7/comments?per_page=100&page=2>; rel="next", <`+srv.URL+`/repos/o/r/issues/7/comments?per_page=100&page=2>; rel="last"`)
+			_, _ = w.Write([]byte(`[
+				{"id":1,"body":"mirrored\n\n` + githubMarker + `","user":{"login":"bot"}},
+				{"id":2,"body":"please also handle X","user":{"login":"alice"}}
+			]`))
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/o/r/issues/7/comments":
+			_, _ = w.Write([]byte(`[{"id":3,"body":"and Y","user":{"login":"bob"}}]`))
+		default:
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+
+	root := t.TempDir()
+	store := NewStore(root)
+	store.EnsureDir()
+	store.EnsureDir("issues")
+
+	trace := NewTraceService(store)
+	svc := NewIssueService(store, trace, 7200, 3600, 3600, 3600)
+	gh := NewGitHubSync(GitHubSyncConfig{Repo: "o/r", Token: "t", APIBase: srv.URL}, svc, store, nil)
+	svc.AddEventSink(gh)
+
+	issue, err := svc.CreateIssue("lead", "subject", "desc", nil, nil, "user", "u", "lead", "l", nil, 0, false)
+	if err != nil {
+		t.Fatalf("create issue: %v", err)
+	}
+	select {
+	case in := <-created:
+		if in["title"] != "subject" {
+			t.Fatalf("unexpected title: %v", in["title"])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for github issue creation")
+	}
+
+	var link *githubLink
+	for i := 0; i < 50; i++ {
+		if link, err = gh.readLink(issue.ID); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if link == nil || link.Number != 7 {
+		t.Fatalf("expected link to github issue 7, got %+v (%v)", link, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := gh.pullComments(*link); err != nil {
+			t.Fatalf("pull comments: %v", err)
+		}
+	}
+	events, err := svc.ReadAllEvents(issue.ID)
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	var imported []IssueEvent
+	for _, ev := range events {
+		if ev.Type == EventIssueGitHubComment {
+			imported = append(imported, ev)
+		}
+	}
+	if len(imported) != 2 || imported[0].Actor != "github:alice" || imported[1].Actor != "github:bob" {
+		t.Fatalf("unexpected imported comments: %+v", imported)
+	}
+}
@@ -0,0 +1,126 @@
+package swarm
+
+import (
+	"crypto/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Identifiers. GenID returns "<prefix>_<ULID>": 48 bits of unix milliseconds and 80 random bits in
+// Crockford base32, so IDs sort lexically by creation time. IDs made within the same millisecond
+// increment the random part instead of drawing a new one, so they stay unique and ordered inside one
+// process. Stores written before ULIDs hold "<prefix>_<unix ms>_<hex>" IDs; IDTime and idLess read both.
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var ulidGen struct {
+	mu     sync.Mutex
+	lastMs int64
+	rand   [10]byte
+}
+
+// idPattern matches GenID output in ULID or legacy form: a lowercase prefix, then letters, digits and
+// underscores only, so an ID is always a safe single path component.
+var idPattern = regexp.MustCompile(`^[a-z]+_[0-9A-Za-z_]{1,64}$`)
+
+// ValidID reports whether id is well-formed and carries the given prefix. IDs that come from outside
+// (archives, requests) must pass it before they name a file.
+func ValidID(prefix, id string) bool {
+	return strings.HasPrefix(id, prefix+"_") && idPattern.MatchString(id)
+}
+
+// GenID returns a new identifier with the given prefix.
+func GenID(prefix string) string {
+	return prefix + "_" + newULID(time.Now())
+}
+
+func newULID(now time.Time) string {
+	ulidGen.mu.Lock()
+	ms := now.UnixMilli()
+	if ms <= ulidGen.lastMs {
+		// Same (or an earlier, after a clock step) millisecond: keep the last timestamp and count up.
+		ms = ulidGen.lastMs
+		incrementULIDRandom(&ulidGen.rand)
+	} else {
+		ulidGen.lastMs = ms
+		_, _ = rand.Read(ulidGen.rand[:])
+	}
+	var b [16]byte
+	for i := 0; i < 6; i++ {
+		b[i] = byte(ms >> (40 - 8*i))
+	}
+	copy(b[6:], ulidGen.rand[:])
+	ulidGen.mu.Unlock()
+	return encodeULID(b)
+}
+
+// incrementULIDRandom adds one to the 80-bit random part; on overflow it starts over from fresh
+// randomness rather than wrapping to a smaller value.
+func incrementULIDRandom(r *[10]byte) {
+	for i := len(r) - 1; i >= 0; i-- {
+		r[i]++
+		if r[i] != 0 {
+			return
+		}
+	}
+	_, _ = rand.Read(r[:])
+}
+
+// encodeULID writes the 128 bits as 26 base32 characters, 5 bits each, the first holding only 3.
+func encodeULID(b [16]byte) string {
+	var out [26]byte
+	var acc uint32
+	bits := 2 // 130 encoded bits for 128 data bits: two zero bits up front
+	n := 0
+	for _, c := range b {
+		acc = acc<<8 | uint32(c)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out[n] = crockford[(acc>>bits)&0x1f]
+			n++
+		}
+	}
+	return string(out[:])
+}
+
+// IDTime returns the creation time embedded in an ID made by GenID, ULID or legacy.
+func IDTime(id string) (time.Time, bool) {
+	_, rest, ok := strings.Cut(id, "_")
+	if !ok {
+		return time.Time{}, false
+	}
+	if len(rest) == 26 {
+		var ms int64
+		for i := 0; i < 10; i++ {
+			v := strings.IndexByte(crockford, rest[i])
+			if v < 0 {
+				return time.Time{}, false
+			}
+			ms = ms<<5 | int64(v)
+		}
+		return time.UnixMilli(ms), true
+	}
+	msStr, _, ok := strings.Cut(rest, "_")
+	if !ok {
+		return time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(msStr, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms), true
+}
+
+// idLess orders IDs by creation time, so legacy and ULID IDs interleave correctly, then lexically.
+func idLess(a, b string) bool {
+	ta, okA := IDTime(a)
+	tb, okB := IDTime(b)
+	if okA && okB && !ta.Equal(tb) {
+		return ta.Before(tb)
+	}
+	return a < b
+}
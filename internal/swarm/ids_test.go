@@ -0,0 +1,59 @@
+package swarm
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGenID_UniqueAndOrderedUnderConcurrency(t *testing.T) {
+	const n = 2000
+	ids := make([]string, n)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids[i] = GenID("inb")
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if len(id) != len("inb_")+26 {
+			t.Fatalf("unexpected id %q", id)
+		}
+		if seen[id] {
+			t.Fatalf("duplicate id %q", id)
+		}
+		seen[id] = true
+	}
+
+	a := GenID("t")
+	b := GenID("t")
+	if !(a < b) || !idLess(a, b) {
+		t.Fatalf("expected %q to sort before %q", a, b)
+	}
+}
+
+func TestIDTime_ReadsULIDAndLegacyIDs(t *testing.T) {
+	now := time.UnixMilli(time.Now().UnixMilli())
+	if got, ok := IDTime("sub_" + newULID(now)); !ok || !got.Equal(now) {
+		t.Fatalf("ulid time: got %v ok=%v, want %v", got, ok, now)
+	}
+	if got, ok := IDTime("inb_1700000000123_0a1f"); !ok || got.UnixMilli() != 1700000000123 {
+		t.Fatalf("legacy time: got %v ok=%v", got, ok)
+	}
+	if _, ok := IDTime("task-3"); ok {
+		t.Fatalf("task-3 has no embedded time")
+	}
+
+	// A legacy ID from 2023 sorts before a ULID minted now, although "1" > "0" lexically.
+	ids := []string{"inb_" + newULID(now), "inb_1700000000123_0a1f"}
+	sort.Slice(ids, func(i, j int) bool { return idLess(ids[i], ids[j]) })
+	if ids[0] != "inb_1700000000123_0a1f" {
+		t.Fatalf("legacy id should come first, got %v", ids)
+	}
+}
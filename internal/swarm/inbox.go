@@ -1,13 +1,31 @@
 package swarm
 
 import (
+	"context"
 	"os"
 	"sort"
 	"strings"
-	"time"
 )
 
-const inboxClaimTTLSec = 300 // 5 min: if lead claims but doesn't process, item resets to pending
+const defaultInboxClaimTTLSec = 300 // 5 min: if lead claims but doesn't process, item resets to pending
+
+// SetInboxClaimTTL sets how long a claimed lead / acceptor inbox item stays with its claimer before it
+// is reset to pending and served again; 0 keeps the 5-minute default. extendInboxClaim renews a claim.
+func (s *IssueService) SetInboxClaimTTL(leadSec, acceptorSec int) {
+	s.leadClaimTTLSec, s.acceptorClaimTTLSec = max(leadSec, 0), max(acceptorSec, 0)
+}
+
+// inboxClaimTTLMs is the claim TTL for the lead (target "lead") or acceptor inbox, in milliseconds.
+func (s *IssueService) inboxClaimTTLMs(target string) int64 {
+	sec := s.leadClaimTTLSec
+	if target == "acceptor" {
+		sec = s.acceptorClaimTTLSec
+	}
+	if sec <= 0 {
+		sec = defaultInboxClaimTTLSec
+	}
+	return int64(sec) * 1000
+}
 
 // pushToLeadInbox adds a pending item to the issue's lead inbox. Call under store lock.
 func (s *IssueService) pushToLeadInboxLocked(issueID, taskID, itemType, refID, senderID string) (*InboxItem, error) {
@@ -88,9 +106,9 @@ func (s *IssueService) claimAcceptorDeliveryInboxItemLocked(claimedBy string) (*
 		return nil, err
 	}
 
-	// Collect and sort by creation time (newest first)
+	// Collect and sort by creation time (oldest first) so concurrent acceptors drain the queue fairly.
 	var items []*InboxItem
-	nowMs := time.Now().UnixMilli()
+	nowMs := LeaseNowMs()
 	for _, f := range files {
 		var item InboxItem
 		if err := s.store.ReadJSON(f, &item); err != nil {
@@ -113,13 +131,13 @@ func (s *IssueService) claimAcceptorDeliveryInboxItemLocked(claimedBy string) (*
 	}
 
 	sort.Slice(items, func(i, j int) bool {
-		return items[i].CreatedAt > items[j].CreatedAt
+		return items[i].CreatedAt < items[j].CreatedAt
 	})
 
 	for _, item := range items {
 		item.Status = InboxProcessing
 		item.ClaimedBy = claimedBy
-		item.ClaimExpiresAtMs = nowMs + int64(inboxClaimTTLSec)*1000
+		item.ClaimExpiresAtMs = nowMs + s.inboxClaimTTLMs("acceptor")
 		item.UpdatedAt = NowStr()
 		if err := s.store.WriteJSON(s.store.Path("deliveries", "inbox", "acceptor", item.ID+".json"), item); err != nil {
 			return nil, err
@@ -130,9 +148,10 @@ func (s *IssueService) claimAcceptorDeliveryInboxItemLocked(claimedBy string) (*
 }
 
 // claimAcceptorDeliveryInboxBlocking blocks until a delivery inbox item is available or timeout.
-func (s *IssueService) claimAcceptorDeliveryInboxBlocking(claimedBy string, timeoutSec int) (*InboxItem, error) {
+func (s *IssueService) claimAcceptorDeliveryInboxBlocking(ctx context.Context, claimedBy string, timeoutSec int) (*InboxItem, error) {
 	deadline := s.deadline(timeoutSec)
 	for {
+		since := s.changeGen()
 		var item *InboxItem
 		err := s.store.WithLock(func() error {
 			it, err := s.claimAcceptorDeliveryInboxItemLocked(claimedBy)
@@ -151,7 +170,9 @@ func (s *IssueService) claimAcceptorDeliveryInboxBlocking(claimedBy string, time
 		if timeExpired(deadline) {
 			return nil, nil
 		}
-		sleepPoll()
+		if err := s.waitChange(ctx, since, pollInterval); err != nil {
+			return nil, err
+		}
 	}
 }
 
@@ -197,10 +218,17 @@ func (s *IssueService) ackLeadInboxByRefLocked(issueID, refID string) {
 
 // claimLeadInboxItem atomically claims one pending item for the lead.
 // Returns (item, nil) if found, (nil, nil) if nothing pending, (nil, err) on error.
-// Items in "processing" with expired claims are reset to "pending" first.
+// Items in "processing" with expired claims are reset to "pending" first. Only registered leads may
+// claim when the issue has a lead list. The item records the claimer by lead key, not by session id.
 func (s *IssueService) claimLeadInboxItem(issueID, claimedBy string) (*InboxItem, error) {
 	var result *InboxItem
 	err := s.store.WithLock(func() error {
+		var issue Issue
+		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err == nil {
+			if err := checkIssueLead(&issue, claimedBy); err != nil {
+				return err
+			}
+		}
 		dir := s.store.Path("issues", issueID, "inbox", "lead")
 		files, err := s.store.ListJSONFiles(dir)
 		if err != nil {
@@ -209,7 +237,9 @@ func (s *IssueService) claimLeadInboxItem(issueID, claimedBy string) (*InboxItem
 			}
 			return err
 		}
-		nowMs := time.Now().UnixMilli()
+		nowMs := LeaseNowMs()
+		var pick *InboxItem
+		var pickPath string
 		for _, f := range files {
 			var item InboxItem
 			if err := s.store.ReadJSON(f, &item); err != nil {
@@ -223,23 +253,74 @@ func (s *IssueService) claimLeadInboxItem(issueID, claimedBy string) (*InboxItem
 				item.UpdatedAt = NowStr()
 				_ = s.store.WriteJSON(f, &item)
 			}
-			if item.Status == InboxPending && result == nil {
-				item.Status = InboxProcessing
-				item.ClaimedBy = claimedBy
-				item.ClaimExpiresAtMs = nowMs + int64(inboxClaimTTLSec)*1000
-				item.UpdatedAt = NowStr()
-				if err := s.store.WriteJSON(f, &item); err != nil {
-					return err
-				}
+			// High-priority items (worker escalations) jump the queue, then by type priority and age.
+			if item.Status == InboxPending && (pick == nil || s.servedBefore(&item, pick)) {
 				itemCopy := item
-				result = &itemCopy
+				pick, pickPath = &itemCopy, f
 			}
 		}
+		if pick == nil {
+			return nil
+		}
+		pick.Status = InboxProcessing
+		pick.ClaimedBy = LeadKey(claimedBy)
+		pick.ClaimExpiresAtMs = nowMs + s.inboxClaimTTLMs("lead")
+		pick.UpdatedAt = NowStr()
+		if err := s.store.WriteJSON(pickPath, pick); err != nil {
+			return err
+		}
+		result = pick
 		return nil
 	})
 	return result, err
 }
 
+// LeadInboxPeek summarizes an issue's lead inbox without claiming anything.
+type LeadInboxPeek struct {
+	Pending    int            `json:"pending"`
+	Processing int            `json:"processing"`
+	ByType     map[string]int `json:"by_type"`        // pending items per InboxType*
+	Head       map[string]any `json:"head,omitempty"` // the item the next wait would serve
+}
+
+// PeekLeadInbox counts pending and claimed lead inbox items and materializes the one claimLeadInboxItem
+// would serve next, leaving every item as it is. Claims past their TTL count as pending.
+func (s *IssueService) PeekLeadInbox(issueID string) (*LeadInboxPeek, error) {
+	if issueID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
+	}
+	if !s.store.Exists("issues", issueID, "issue.json") {
+		return nil, Errorf(CodeNotFound, "issue '%s' not found", issueID)
+	}
+	peek := &LeadInboxPeek{ByType: map[string]int{}}
+	nowMs := LeaseNowMs()
+	var head *InboxItem
+	for _, f := range listJSONOrEmpty(s.store, s.store.Path("issues", issueID, "inbox", "lead")) {
+		var item InboxItem
+		if err := s.store.ReadJSON(f, &item); err != nil {
+			continue
+		}
+		if item.Status == InboxProcessing && item.ClaimExpiresAtMs > 0 && nowMs > item.ClaimExpiresAtMs {
+			item.Status = InboxPending
+		}
+		switch item.Status {
+		case InboxProcessing:
+			peek.Processing++
+		case InboxPending:
+			peek.Pending++
+			peek.ByType[item.Type]++
+			if head == nil || s.servedBefore(&item, head) {
+				itemCopy := item
+				head = &itemCopy
+			}
+		}
+	}
+	if head != nil {
+		peek.Head = s.materializeInboxItem(issueID, head)
+	}
+	return peek, nil
+}
+
 // deleteInboxForTaskLocked removes all inbox items (lead + worker) for a task. Call under store lock.
 func (s *IssueService) deleteInboxForTaskLocked(issueID, taskID string) {
 	// Lead inbox
@@ -277,9 +358,10 @@ func (s *IssueService) deleteInboxForTaskLocked(issueID, taskID string) {
 }
 
 // claimLeadInboxBlocking polls until a lead inbox item is available or timeout.
-func (s *IssueService) claimLeadInboxBlocking(issueID, claimedBy string, timeoutSec int) (*InboxItem, error) {
+func (s *IssueService) claimLeadInboxBlocking(ctx context.Context, issueID, claimedBy string, timeoutSec int) (*InboxItem, error) {
 	deadline := s.deadline(timeoutSec)
 	for {
+		since := s.changeGen()
 		item, err := s.claimLeadInboxItem(issueID, claimedBy)
 		if err != nil {
 			return nil, err
@@ -290,7 +372,9 @@ func (s *IssueService) claimLeadInboxBlocking(issueID, claimedBy string, timeout
 		if timeExpired(deadline) {
 			return nil, nil // timeout, no items — caller returns empty
 		}
-		sleepPoll()
+		if err := s.waitChange(ctx, since, pollInterval); err != nil {
+			return nil, err
+		}
 	}
 }
 
@@ -307,7 +391,7 @@ func (s *IssueService) materializeInboxItem(issueID string, item *InboxItem) map
 		"inbox_id":  item.ID,
 	}
 	switch item.Type {
-	case InboxTypeQuestion, InboxTypeBlocker:
+	case InboxTypeQuestion, InboxTypeBlocker, InboxTypeWorkerEscalation:
 		base["type"] = EventIssueTaskMessage
 		base["kind"] = item.Type
 		base["message_id"] = item.RefID
@@ -318,11 +402,25 @@ func (s *IssueService) materializeInboxItem(issueID string, item *InboxItem) map
 			base["detail"] = msg.Content
 			base["refs"] = msg.Refs
 			base["timestamp"] = msg.CreatedAt
+			if msg.Reason != "" {
+				base["reason"] = msg.Reason
+			}
+		}
+		if item.Priority != "" {
+			base["priority"] = item.Priority
 		}
-	case InboxTypeSubmission:
+	case InboxTypeSubmission, InboxTypeReviewOverdue, InboxTypePeerReviewNeeded:
 		base["type"] = EventSubmissionCreated
 		base["kind"] = ""
 		base["detail"] = "submitted"
+		switch item.Type {
+		case InboxTypeReviewOverdue:
+			base["type"] = EventSubmissionReviewOverdue
+			base["detail"] = "review overdue"
+		case InboxTypePeerReviewNeeded:
+			base["type"] = EventPeerReviewNeeded
+			base["detail"] = "submitted; needs a peer reviewer before the lead can review it"
+		}
 		base["submission_id"] = item.RefID
 		// Load submission artifacts
 		var sub Submission
@@ -337,6 +435,27 @@ func (s *IssueService) materializeInboxItem(issueID string, item *InboxItem) map
 			base["submission_artifacts"] = sub.Artifacts
 			base["timestamp"] = sub.CreatedAt
 		}
+	case InboxTypeSubmissionComment:
+		base["type"] = EventSubmissionComment
+		base["kind"] = ""
+		base["comment_id"] = item.RefID
+		if c, err := s.findSubmissionComment(issueID, item.RefID); err == nil {
+			base["submission_id"] = c.SubmissionID
+			base["parent_id"] = c.ParentID
+			base["detail"] = c.Content
+			base["refs"] = commentRef(c)
+			base["timestamp"] = c.CreatedAt
+		}
+	case InboxTypeEscalation:
+		base["type"] = EventIssueTaskEscalated
+		base["kind"] = ""
+		base["submission_id"] = item.RefID
+		_ = s.store.WithLock(func() error {
+			if t, err := s.loadTaskLocked(issueID, item.TaskID); err == nil {
+				base["detail"] = s.rejectionSummaryLocked(issueID, t, len(s.rejectedSubmissionsLocked(issueID, t)))
+			}
+			return nil
+		})
 	}
 	return base
 }
@@ -351,7 +470,7 @@ func listJSONOrEmpty(store *Store, dir string) []string {
 func (s *IssueService) sweepInboxClaims(issueID string) {
 	dir := s.store.Path("issues", issueID, "inbox", "lead")
 	files, _ := s.store.ListJSONFiles(dir)
-	nowMs := time.Now().UnixMilli()
+	nowMs := LeaseNowMs()
 	_ = s.store.WithLock(func() error {
 		for _, f := range files {
 			var item InboxItem
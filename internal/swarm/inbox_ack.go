@@ -0,0 +1,217 @@
+package swarm
+
+import (
+	"strings"
+)
+
+// inboxItemPath resolves an item in the lead inbox (workerID empty) or in a worker's inbox.
+func (s *IssueService) inboxItemPath(issueID, workerID, inboxID string) (string, error) {
+	issueID, inboxID = strings.TrimSpace(issueID), strings.TrimSpace(inboxID)
+	if issueID == "" || inboxID == "" {
+		return "", Errorf(CodeInvalidArgument, "issue_id and inbox_id are required")
+	}
+	if !safePathSegment(issueID) {
+		return "", Errorf(CodeInvalidArgument, "invalid issue_id '%s'", issueID)
+	}
+	if !safePathSegment(inboxID) {
+		return "", Errorf(CodeInvalidArgument, "invalid inbox_id '%s'", inboxID)
+	}
+	if workerID = strings.TrimSpace(workerID); workerID != "" {
+		if !safePathSegment(workerID) {
+			return "", Errorf(CodeInvalidArgument, "invalid worker_id '%s'", workerID)
+		}
+		return s.store.Path("issues", issueID, "inbox", "workers", workerID, inboxID+".json"), nil
+	}
+	return s.store.Path("issues", issueID, "inbox", "lead", inboxID+".json"), nil
+}
+
+// safePathSegment reports whether a caller-supplied id can be used as one path element under the store:
+// no separators and no "..", so it cannot reach another inbox or issue file.
+func safePathSegment(id string) bool {
+	return !strings.ContainsAny(id, `/\`) && !strings.Contains(id, "..")
+}
+
+// checkLeadInboxCallerLocked lets caller (a lead's session id) act on a lead inbox item: it must be a
+// registered lead when the issue has a lead list, and the lead holding the item's claim while it runs.
+// An empty caller skips the check, for admin repairs. Call under store lock.
+func (s *IssueService) checkLeadInboxCallerLocked(item *InboxItem, caller string) error {
+	if caller == "" {
+		return nil
+	}
+	var issue Issue
+	if err := s.store.ReadJSON(s.store.Path("issues", item.IssueID, "issue.json"), &issue); err == nil {
+		if err := checkIssueLead(&issue, caller); err != nil {
+			return err
+		}
+	}
+	claimLive := item.ClaimExpiresAtMs == 0 || LeaseNowMs() <= item.ClaimExpiresAtMs
+	if item.Status == InboxProcessing && item.ClaimedBy != "" && claimLive && item.ClaimedBy != LeadKey(caller) {
+		return Errorf(CodeNotOwner, "inbox item '%s' is claimed by another lead", item.ID)
+	}
+	return nil
+}
+
+// AckInboxItem marks an inbox item done: a lead inbox item (workerID empty) the lead handled or chose to
+// drop, or an item in the given worker's inbox such as a review_result. Acking a done item is a no-op.
+// caller is the acking lead's session id, checked against a lead inbox item's claim; empty for admins.
+func (s *IssueService) AckInboxItem(issueID, workerID, inboxID, caller string) (*InboxItem, error) {
+	path, err := s.inboxItemPath(issueID, workerID, inboxID)
+	if err != nil {
+		return nil, err
+	}
+	var item InboxItem
+	err = s.store.WithLock(func() error {
+		if err := s.store.ReadJSON(path, &item); err != nil {
+			return Errorf(CodeNotFound, "inbox item '%s' not found", inboxID)
+		}
+		if item.Status == InboxDone {
+			return nil
+		}
+		if strings.TrimSpace(workerID) == "" {
+			if err := s.checkLeadInboxCallerLocked(&item, caller); err != nil {
+				return err
+			}
+		}
+		item.Status = InboxDone
+		item.ClaimExpiresAtMs = 0
+		item.UpdatedAt = NowStr()
+		return s.store.WriteJSON(path, &item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// NackInboxItem releases a lead inbox item claimed by waitIssueTaskEvents (processing) back to pending
+// right away instead of after the claim TTL, so the next wait serves it again. caller is the session id
+// of the lead holding the claim; empty for admins.
+func (s *IssueService) NackInboxItem(issueID, inboxID, caller string) (*InboxItem, error) {
+	path, err := s.inboxItemPath(issueID, "", inboxID)
+	if err != nil {
+		return nil, err
+	}
+	var item InboxItem
+	err = s.store.WithLock(func() error {
+		if err := s.store.ReadJSON(path, &item); err != nil {
+			return Errorf(CodeNotFound, "inbox item '%s' not found", inboxID)
+		}
+		switch item.Status {
+		case InboxPending:
+			return nil
+		case InboxDone:
+			return Errorf(CodeInvalidState, "inbox item '%s' is already done", inboxID)
+		}
+		if err := s.checkLeadInboxCallerLocked(&item, caller); err != nil {
+			return err
+		}
+		item.Status = InboxPending
+		item.ClaimedBy = ""
+		item.ClaimExpiresAtMs = 0
+		item.UpdatedAt = NowStr()
+		return s.store.WriteJSON(path, &item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.bump(item.IssueID)
+	return &item, nil
+}
+
+// ExtendInboxClaim renews the lead's claim on a processing lead inbox item to extendSec from now (default:
+// the lead claim TTL), so a long review is not handed to another lead when the claim lapses. Fails when
+// the item is no longer claimed, e.g. because the claim already lapsed and it was served again, and when
+// caller (the lead's session id) does not hold the claim.
+func (s *IssueService) ExtendInboxClaim(issueID, inboxID, caller string, extendSec int) (*InboxItem, error) {
+	path, err := s.inboxItemPath(issueID, "", inboxID)
+	if err != nil {
+		return nil, err
+	}
+	var item InboxItem
+	err = s.store.WithLock(func() error {
+		if err := s.store.ReadJSON(path, &item); err != nil {
+			return Errorf(CodeNotFound, "inbox item '%s' not found", inboxID)
+		}
+		if item.Status != InboxProcessing {
+			return Errorf(CodeInvalidState, "inbox item '%s' is not claimed (status: %s)", inboxID, item.Status)
+		}
+		if item.ClaimedBy != LeadKey(caller) {
+			return Errorf(CodeNotOwner, "inbox item '%s' is claimed by another lead", inboxID)
+		}
+		ttlMs := s.inboxClaimTTLMs("lead")
+		if extendSec > 0 {
+			ttlMs = int64(extendSec) * 1000
+		}
+		item.ClaimExpiresAtMs = LeaseNowMs() + ttlMs
+		item.UpdatedAt = NowStr()
+		return s.store.WriteJSON(path, &item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// RequeueInboxItem puts a stuck item in the lead inbox (workerID empty) or in a worker's inbox back to
+// pending, dropping any claim. With toWorkerID a worker item is moved to that worker's inbox; a pending
+// assignment is then re-reserved for the new worker, which invalidates the old worker's token.
+func (s *IssueService) RequeueInboxItem(issueID, workerID, inboxID, toWorkerID, actor string) (*InboxItem, error) {
+	path, err := s.inboxItemPath(issueID, workerID, inboxID)
+	if err != nil {
+		return nil, err
+	}
+	workerID, toWorkerID = strings.TrimSpace(workerID), strings.TrimSpace(toWorkerID)
+	if toWorkerID == workerID {
+		toWorkerID = ""
+	}
+	if toWorkerID != "" {
+		if workerID == "" {
+			return nil, Errorf(CodeInvalidArgument, "only worker inbox items can be retargeted (worker_id is required)")
+		}
+		if !safePathSegment(toWorkerID) {
+			return nil, Errorf(CodeInvalidArgument, "invalid to_worker_id '%s'", toWorkerID)
+		}
+	}
+	var item InboxItem
+	err = s.store.WithLock(func() error {
+		if err := s.store.ReadJSON(path, &item); err != nil {
+			return Errorf(CodeNotFound, "inbox item '%s' not found", inboxID)
+		}
+		if item.Status == InboxDone {
+			return Errorf(CodeInvalidState, "inbox item '%s' is already done", inboxID)
+		}
+		item.Status = InboxPending
+		item.ClaimedBy = ""
+		item.ClaimExpiresAtMs = 0
+		item.UpdatedAt = NowStr()
+		if toWorkerID == "" {
+			return s.store.WriteJSON(path, &item)
+		}
+
+		if item.Type == InboxTypeAssigned {
+			t, err := s.loadTaskLocked(item.IssueID, item.TaskID)
+			if err != nil {
+				return err
+			}
+			if t.Status != IssueTaskOpen || t.ReservedToken != item.RefID || (t.ReservedUntilMs > 0 && LeaseNowMs() > t.ReservedUntilMs) {
+				return Errorf(CodeInvalidState, "assignment of task '%s' is no longer reserved", item.TaskID)
+			}
+			live, err := s.reserveForWorkerLocked(item.IssueID, t.ID, toWorkerID, actor, "reassigned from "+workerID+" to "+toWorkerID, t.ReservedUntilMs)
+			if err != nil {
+				return err
+			}
+			item.RefID = live.ReservedToken
+		}
+		item.Target = toWorkerID
+		s.store.EnsureDir("issues", item.IssueID, "inbox", "workers", toWorkerID)
+		if err := s.store.WriteJSON(s.store.Path("issues", item.IssueID, "inbox", "workers", toWorkerID, item.ID+".json"), &item); err != nil {
+			return err
+		}
+		return s.store.Remove(path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.bump(item.IssueID)
+	return &item, nil
+}
@@ -0,0 +1,259 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNackInboxItem_ReleasesClaimImmediately(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	pushed, err := svc.pushToLeadInboxLocked(issueID, "task-1", InboxTypeSubmission, "sub-1", "w1")
+	if err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	claimed, err := svc.claimLeadInboxItem(issueID, "sess-lead")
+	if err != nil || claimed == nil || claimed.ID != pushed.ID {
+		t.Fatalf("claim: %+v %v", claimed, err)
+	}
+	if again, _ := svc.claimLeadInboxItem(issueID, "sess-lead"); again != nil {
+		t.Fatalf("expected nothing pending while the item is claimed, got %+v", again)
+	}
+
+	item, err := svc.NackInboxItem(issueID, pushed.ID, "sess-lead")
+	if err != nil || item.Status != InboxPending || item.ClaimedBy != "" {
+		t.Fatalf("nack: %+v %v", item, err)
+	}
+	if again, _ := svc.claimLeadInboxItem(issueID, "sess-lead"); again == nil || again.ID != pushed.ID {
+		t.Fatalf("expected the released item to be served again, got %+v", again)
+	}
+
+	if item, err := svc.AckInboxItem(issueID, "", pushed.ID, "sess-lead"); err != nil || item.Status != InboxDone {
+		t.Fatalf("ack: %+v %v", item, err)
+	}
+	if _, err := svc.NackInboxItem(issueID, pushed.ID, "sess-lead"); err == nil {
+		t.Fatalf("expected nack of a done item to fail")
+	}
+	if _, err := svc.AckInboxItem(issueID, "", "../x", "sess-lead"); err == nil {
+		t.Fatalf("expected an invalid inbox_id to be rejected")
+	}
+}
+
+func TestAckInboxItem_WorkerInbox(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	pushed, err := svc.pushToWorkerInboxLocked("issue-1", "w1", "task-1", InboxTypeReviewResult, "sub-1", "lead")
+	if err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if _, err := svc.AckInboxItem("issue-1", "w2", pushed.ID, ""); err == nil {
+		t.Fatalf("expected another worker's inbox not to contain the item")
+	}
+	if item, err := svc.AckInboxItem("issue-1", "w1", pushed.ID, ""); err != nil || item.Status != InboxDone {
+		t.Fatalf("ack: %+v %v", item, err)
+	}
+}
+
+func TestAckInboxItem_RejectsPathTraversal(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	lead, err := svc.pushToLeadInboxLocked(issueID, "task-1", InboxTypeSubmission, "sub-1", "w1")
+	if err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	task := IssueTask{ID: "task-1", IssueID: issueID, Subject: "keep me", Status: IssueTaskOpen}
+	if err := svc.saveTaskLocked(issueID, &task); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+
+	for _, c := range []struct{ issueID, workerID, inboxID string }{
+		{issueID, "../lead", lead.ID},
+		{issueID, "../../tasks", task.ID},
+		{issueID, `..\lead`, lead.ID},
+		{"../issue-1", "", lead.ID},
+	} {
+		if _, err := svc.AckInboxItem(c.issueID, c.workerID, c.inboxID, ""); ErrorCode(err) != CodeInvalidArgument {
+			t.Fatalf("ack %+v: got %v, want invalid_argument", c, err)
+		}
+	}
+	var item InboxItem
+	if err := store.ReadJSON(store.Path("issues", issueID, "inbox", "lead", lead.ID+".json"), &item); err != nil || item.Status != InboxPending {
+		t.Fatalf("expected the lead inbox item untouched, got %+v %v", item, err)
+	}
+	if got, err := svc.loadTaskLocked(issueID, task.ID); err != nil || got.Subject != "keep me" {
+		t.Fatalf("expected the task untouched, got %+v %v", got, err)
+	}
+}
+
+func TestPeekLeadInbox_DoesNotClaim(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueInProgress}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if _, err := svc.pushToLeadInboxLocked(issueID, "task-1", InboxTypeSubmission, "sub-1", "w1"); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	escalation, err := svc.pushToLeadInboxLocked(issueID, "task-2", InboxTypeQuestion, "msg-1", "w2")
+	if err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	escalation.Priority = InboxPriorityHigh
+	if err := store.WriteJSON(store.Path("issues", issueID, "inbox", "lead", escalation.ID+".json"), escalation); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	peek, err := svc.PeekLeadInbox(issueID)
+	if err != nil || peek.Pending != 2 || peek.ByType[InboxTypeSubmission] != 1 || peek.Head["inbox_id"] != escalation.ID {
+		t.Fatalf("unexpected peek %+v %v", peek, err)
+	}
+	if claimed, _ := svc.claimLeadInboxItem(issueID, "lead"); claimed == nil || claimed.ID != escalation.ID {
+		t.Fatalf("expected the peeked head to still be claimable, got %+v", claimed)
+	}
+	if peek, _ := svc.PeekLeadInbox(issueID); peek.Pending != 1 || peek.Processing != 1 {
+		t.Fatalf("expected one pending and one processing, got %+v", peek)
+	}
+}
+
+func TestExtendInboxClaim_KeepsItemClaimed(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+	svc.SetInboxClaimTTL(1, 0)
+
+	issueID := "issue-1"
+	pushed, err := svc.pushToLeadInboxLocked(issueID, "task-1", InboxTypeSubmission, "sub-1", "w1")
+	if err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if _, err := svc.ExtendInboxClaim(issueID, pushed.ID, "sess-lead", 0); err == nil {
+		t.Fatalf("expected extending an unclaimed item to fail")
+	}
+	claimed, err := svc.claimLeadInboxItem(issueID, "sess-lead")
+	if err != nil || claimed == nil {
+		t.Fatalf("claim: %+v %v", claimed, err)
+	}
+	if ttl := claimed.ClaimExpiresAtMs - time.Now().UnixMilli(); ttl > 1000 {
+		t.Fatalf("expected the configured 1s claim TTL, got %dms", ttl)
+	}
+
+	if _, err := svc.ExtendInboxClaim(issueID, pushed.ID, "sess-other", 60); ErrorCode(err) != CodeNotOwner {
+		t.Fatalf("extend by another lead: got %v, want not_owner", err)
+	}
+	item, err := svc.ExtendInboxClaim(issueID, pushed.ID, "sess-lead", 60)
+	if err != nil || item.Status != InboxProcessing || item.ClaimExpiresAtMs-time.Now().UnixMilli() < 59000 {
+		t.Fatalf("extend: %+v %v", item, err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if again, _ := svc.claimLeadInboxItem(issueID, "sess-other"); again != nil {
+		t.Fatalf("expected the extended claim to outlive the TTL, got %+v", again)
+	}
+}
+
+func TestRequeueInboxItem_RetargetsAssignment(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 1, 1)
+	svc.SetScheduler(func() ([]Worker, error) { return []Worker{{ID: "w1"}}, nil }, func(string) int { return 1 }, 600, 0)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen, CreatedAt: NowStr()}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 2}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	task := IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskOpen, Difficulty: "easy", Points: 1}
+	if err := svc.saveTaskLocked(issueID, &task); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	got, err := svc.AssignTasks()
+	if err != nil || len(got) != 1 {
+		t.Fatalf("assign: %+v %v", got, err)
+	}
+	items, err := svc.GetWorkerInbox("w1", issueID, false, false)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("w1 inbox: %+v %v", items, err)
+	}
+	inboxID := items[0]["inbox_id"].(string)
+
+	if _, err := svc.RequeueInboxItem(issueID, "", inboxID, "w2", "lead"); err == nil {
+		t.Fatalf("expected retargeting a lead inbox item to fail")
+	}
+	item, err := svc.RequeueInboxItem(issueID, "w1", inboxID, "w2", "lead")
+	if err != nil || item.Target != "w2" || item.Status != InboxPending || item.RefID == got[0].NextStepToken {
+		t.Fatalf("requeue: %+v %v", item, err)
+	}
+	if left, _ := svc.GetWorkerInbox("w1", issueID, true, false); len(left) != 0 {
+		t.Fatalf("expected the item to leave w1's inbox, got %+v", left)
+	}
+	if _, err := svc.ClaimTask(issueID, task.ID, "w1", got[0].NextStepToken, 0); err == nil {
+		t.Fatalf("expected w1's old token to be invalid")
+	}
+	a, err := svc.WaitAssignment(context.Background(), "w2", 1)
+	if err != nil || a == nil || a.NextStepToken != item.RefID {
+		t.Fatalf("expected w2's assignment, got %+v (%v)", a, err)
+	}
+	if _, err := svc.ClaimTask(issueID, task.ID, "w2", a.NextStepToken, 0); err != nil {
+		t.Fatalf("claim by new assignee: %v", err)
+	}
+}
+
+func TestAckNackInboxItem_OnlyTheClaimingLead(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 1}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	pushed, err := svc.pushToLeadInboxLocked(issueID, "task-1", InboxTypeSubmission, "sub-1", "w1")
+	if err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if _, err := svc.claimLeadInboxItem(issueID, "sess-a"); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	if _, err := svc.AckInboxItem(issueID, "", pushed.ID, "sess-b"); ErrorCode(err) != CodeNotOwner {
+		t.Fatalf("ack by another lead: got %v, want not_owner", err)
+	}
+	if _, err := svc.NackInboxItem(issueID, pushed.ID, "sess-b"); ErrorCode(err) != CodeNotOwner {
+		t.Fatalf("nack by another lead: got %v, want not_owner", err)
+	}
+	if item, err := svc.NackInboxItem(issueID, pushed.ID, "sess-a"); err != nil || item.Status != InboxPending {
+		t.Fatalf("nack by the claimer: %+v %v", item, err)
+	}
+
+	// With a lead list, an unregistered lead cannot even ack a pending item.
+	if _, err := svc.AddIssueLead("m1", issueID, "sess-a", "sess-c", 0); err != nil {
+		t.Fatalf("add lead: %v", err)
+	}
+	if _, err := svc.AckInboxItem(issueID, "", pushed.ID, "sess-b"); ErrorCode(err) != CodeNotOwner {
+		t.Fatalf("ack by an unregistered lead: got %v, want not_owner", err)
+	}
+	if item, err := svc.AckInboxItem(issueID, "", pushed.ID, "sess-c"); err != nil || item.Status != InboxDone {
+		t.Fatalf("ack by a registered lead: %+v %v", item, err)
+	}
+
+	// Admin repairs (no caller) ignore claims.
+	other, err := svc.pushToLeadInboxLocked(issueID, "task-2", InboxTypeSubmission, "sub-2", "w1")
+	if err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if _, err := svc.claimLeadInboxItem(issueID, "sess-a"); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if item, err := svc.AckInboxItem(issueID, "", other.ID, ""); err != nil || item.Status != InboxDone {
+		t.Fatalf("admin ack: %+v %v", item, err)
+	}
+}
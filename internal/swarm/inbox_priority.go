@@ -0,0 +1,98 @@
+package swarm
+
+import (
+	"strings"
+)
+
+// DefaultInboxPriorities orders lead inbox item types, most urgent first: an item that stops work
+// (rejection-limit escalation, blocker) preempts a question, which preempts routine review work.
+// Items marked InboxPriorityHigh (worker escalations) always come first; unlisted types come last.
+var DefaultInboxPriorities = []string{
+	InboxTypeEscalation,
+	InboxTypeBlocker,
+	InboxTypeQuestion,
+	InboxTypeReviewOverdue,
+	InboxTypeSubmissionComment,
+	InboxTypeSubmission,
+	InboxTypePeerReviewNeeded,
+}
+
+// leadInboxTypes are the item types that can appear in a lead inbox.
+var leadInboxTypes = map[string]bool{
+	InboxTypeWorkerEscalation:  true,
+	InboxTypeEscalation:        true,
+	InboxTypeBlocker:           true,
+	InboxTypeQuestion:          true,
+	InboxTypeReviewOverdue:     true,
+	InboxTypeSubmissionComment: true,
+	InboxTypeSubmission:        true,
+	InboxTypePeerReviewNeeded:  true,
+}
+
+// ValidateInboxPriorities checks that order lists known lead inbox types at most once each.
+func ValidateInboxPriorities(order []string) error {
+	seen := map[string]bool{}
+	for _, t := range order {
+		t = strings.TrimSpace(t)
+		if !leadInboxTypes[t] {
+			return Errorf(CodeInvalidArgument, "unknown inbox item type %q", t)
+		}
+		if seen[t] {
+			return Errorf(CodeInvalidArgument, "inbox item type %q listed twice", t)
+		}
+		seen[t] = true
+	}
+	return nil
+}
+
+// SetInboxPriorities sets the order in which the lead inbox serves item types, most urgent first.
+// Empty restores DefaultInboxPriorities.
+func (s *IssueService) SetInboxPriorities(order []string) error {
+	if err := ValidateInboxPriorities(order); err != nil {
+		return err
+	}
+	if len(order) == 0 {
+		order = DefaultInboxPriorities
+	}
+	ranks := make(map[string]int, len(order))
+	for i, t := range order {
+		ranks[strings.TrimSpace(t)] = i + 1
+	}
+	s.inboxRanks = ranks
+	return nil
+}
+
+// inboxRank is the serving rank of item: lower is served first.
+func (s *IssueService) inboxRank(item *InboxItem) int {
+	if item.Priority == InboxPriorityHigh {
+		return 0
+	}
+	ranks := s.inboxRanks
+	if ranks == nil {
+		ranks = defaultInboxRanks
+	}
+	if r, ok := ranks[item.Type]; ok {
+		return r
+	}
+	return len(ranks) + 1
+}
+
+var defaultInboxRanks = func() map[string]int {
+	m := map[string]int{}
+	for i, t := range DefaultInboxPriorities {
+		m[t] = i + 1
+	}
+	return m
+}()
+
+// servedBefore reports whether lead inbox item a is served before b: by rank, then oldest first. Item
+// IDs carry the creation time in milliseconds, so they break ties within a second.
+func (s *IssueService) servedBefore(a, b *InboxItem) bool {
+	if ra, rb := s.inboxRank(a), s.inboxRank(b); ra != rb {
+		return ra < rb
+	}
+	if a.CreatedAt != b.CreatedAt {
+		return a.CreatedAt < b.CreatedAt
+	}
+	return idLess(a.ID, b.ID)
+}
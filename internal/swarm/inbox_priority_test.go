@@ -0,0 +1,55 @@
+package swarm
+
+import "testing"
+
+func TestClaimLeadInboxItem_ServesByPriorityThenAge(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	for _, item := range []InboxItem{
+		{ID: "inb_1_a", Type: InboxTypeSubmission, RefID: "sub-1", CreatedAt: "2024-01-01T09:00:00Z"},
+		{ID: "inb_2_a", Type: InboxTypeQuestion, RefID: "msg-1", CreatedAt: "2024-01-01T09:05:00Z"},
+		{ID: "inb_3_a", Type: InboxTypeBlocker, RefID: "msg-2", CreatedAt: "2024-01-01T09:10:00Z"},
+		{ID: "inb_4_a", Type: InboxTypeQuestion, RefID: "msg-3", CreatedAt: "2024-01-01T09:01:00Z"},
+	} {
+		item.IssueID, item.Target, item.Status = issueID, "lead", InboxPending
+		if err := store.WriteJSON(store.Path("issues", issueID, "inbox", "lead", item.ID+".json"), &item); err != nil {
+			t.Fatalf("write item: %v", err)
+		}
+	}
+	claimOrder := func() []string {
+		var refs []string
+		for {
+			item, err := svc.claimLeadInboxItem(issueID, "lead")
+			if err != nil {
+				t.Fatalf("claim: %v", err)
+			}
+			if item == nil {
+				return refs
+			}
+			refs = append(refs, item.RefID)
+			if _, err := svc.AckInboxItem(issueID, "", item.ID, "lead"); err != nil {
+				t.Fatalf("ack: %v", err)
+			}
+		}
+	}
+	if got := claimOrder(); len(got) != 4 || got[0] != "msg-2" || got[1] != "msg-3" || got[2] != "msg-1" || got[3] != "sub-1" {
+		t.Fatalf("expected blocker, questions oldest first, then the submission; got %v", got)
+	}
+
+	if err := svc.SetInboxPriorities([]string{"lunch"}); err == nil {
+		t.Fatalf("expected an unknown type to be rejected")
+	}
+	if err := svc.SetInboxPriorities([]string{InboxTypeSubmission}); err != nil {
+		t.Fatalf("set priorities: %v", err)
+	}
+	sub := &InboxItem{Type: InboxTypeSubmission, CreatedAt: "2024-01-01T10:00:00Z"}
+	blocker := &InboxItem{Type: InboxTypeBlocker, CreatedAt: "2024-01-01T09:00:00Z"}
+	if !svc.servedBefore(sub, blocker) {
+		t.Fatalf("expected a configured submission-first order to serve the submission before an unlisted blocker")
+	}
+	if !svc.servedBefore(&InboxItem{Type: InboxTypeWorkerEscalation, Priority: InboxPriorityHigh}, sub) {
+		t.Fatalf("expected high-priority items to stay first")
+	}
+}
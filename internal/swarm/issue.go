@@ -1,6 +1,7 @@
 package swarm
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -13,15 +14,27 @@ func NewIssueService(store *Store, trace *TraceService, issueTTLSec, taskTTLSec,
 	if minTimeoutSec <= 0 {
 		minTimeoutSec = defaultTimeoutSec
 	}
-	s := &IssueService{store: store, trace: trace, versions: map[string]int64{}, issueTTLSec: issueTTLSec, taskTTLSec: taskTTLSec, defaultTimeoutSec: defaultTimeoutSec, minTimeoutSec: minTimeoutSec}
+	s := &IssueService{store: store, trace: trace, versions: map[string]int64{}, issueTTLSec: issueTTLSec, taskTTLSec: taskTTLSec, defaultTimeoutSec: defaultTimeoutSec, minTimeoutSec: minTimeoutSec, policy: DefaultProgressionPolicy()}
 	s.cond = sync.NewCond(&s.mu)
 	return s
 }
 
+// SetTimeouts replaces the TTLs and long-poll timeouts given to NewIssueService (e.g. when a role
+// profile is selected after startup).
+func (s *IssueService) SetTimeouts(issueTTLSec, taskTTLSec, defaultTimeoutSec, minTimeoutSec int) {
+	if minTimeoutSec <= 0 {
+		minTimeoutSec = defaultTimeoutSec
+	}
+	s.issueTTLSec = issueTTLSec
+	s.taskTTLSec = taskTTLSec
+	s.defaultTimeoutSec = defaultTimeoutSec
+	s.minTimeoutSec = minTimeoutSec
+}
+
 func trimRequired(name, v string) (string, error) {
 	v = strings.TrimSpace(v)
 	if v == "" {
-		return "", fmt.Errorf("%s is required", name)
+		return "", Errorf(CodeInvalidArgument, "%s is required", name)
 	}
 	return v, nil
 }
@@ -29,13 +42,13 @@ func trimRequired(name, v string) (string, error) {
 func cleanDocName(name string) (string, error) {
 	name = strings.TrimSpace(name)
 	if name == "" {
-		return "", fmt.Errorf("doc name is required")
+		return "", Errorf(CodeInvalidArgument, "doc name is required")
 	}
 	name = filepath.Clean(name)
 	name = strings.TrimPrefix(name, "/")
 	name = strings.TrimSuffix(name, ".md")
 	if name == "." || name == ".." {
-		return "", fmt.Errorf("invalid doc name")
+		return "", Errorf(CodeInvalidArgument, "invalid doc name")
 	}
 	return name, nil
 }
@@ -50,6 +63,7 @@ func writeDocFile(dir, filename, content string) error {
 func (s *IssueService) bump(issueID string) {
 	s.mu.Lock()
 	s.versions[issueID]++
+	s.gen++
 	s.cond.Broadcast()
 	s.mu.Unlock()
 }
@@ -62,13 +76,18 @@ func (s *IssueService) calcLeaseExpiryMs(extendSec int, defaultSec int) int64 {
 	if sec <= 0 {
 		return 0
 	}
-	return time.Now().UnixMilli() + int64(sec)*1000
+	return LeaseNowMs() + int64(sec)*1000
 }
 
-func (s *IssueService) normalizeTimeoutSec(timeoutSec int) int {
+// normalizeTimeoutSec applies the default to an unset timeout and raises one below the minimum, unless
+// ctx allows short timeouts (WithShortTimeouts).
+func (s *IssueService) normalizeTimeoutSec(ctx context.Context, timeoutSec int) int {
 	if timeoutSec <= 0 {
 		return s.defaultTimeoutSec
 	}
+	if shortTimeoutsAllowed(ctx) {
+		return timeoutSec
+	}
 	if s.minTimeoutSec > 0 && timeoutSec < s.minTimeoutSec {
 		return s.minTimeoutSec
 	}
@@ -77,7 +96,7 @@ func (s *IssueService) normalizeTimeoutSec(timeoutSec int) int {
 
 func (s *IssueService) ExtendIssueLease(actor, issueID string, extendSec int) (*Issue, error) {
 	if issueID == "" {
-		return nil, fmt.Errorf("issue_id is required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
 	}
 	if actor == "" {
 		actor = "lead"
@@ -90,11 +109,11 @@ func (s *IssueService) ExtendIssueLease(actor, issueID string, extendSec int) (*
 			return err
 		}
 		if issue.Status != IssueOpen && issue.Status != IssueInProgress {
-			return fmt.Errorf("issue '%s' is not open/in_progress (status: %s)", issueID, issue.Status)
+			return Errorf(CodeInvalidState, "issue '%s' is not open/in_progress (status: %s)", issueID, issue.Status)
 		}
 		issue.LeaseExpiresAtMs = s.calcLeaseExpiryMs(extendSec, s.issueTTLSec)
 		issue.UpdatedAt = NowStr()
-		if err := s.store.WriteJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+		if err := s.saveIssueLocked(&issue); err != nil {
 			return err
 		}
 		result = &issue
@@ -109,7 +128,7 @@ func (s *IssueService) ExtendIssueLease(actor, issueID string, extendSec int) (*
 
 func (s *IssueService) ExtendIssueTaskLease(actor, issueID, taskID string, extendSec int) (*IssueTask, error) {
 	if issueID == "" || taskID == "" {
-		return nil, fmt.Errorf("issue_id and task_id are required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id and task_id are required")
 	}
 	if actor == "" {
 		actor = "worker"
@@ -122,14 +141,14 @@ func (s *IssueService) ExtendIssueTaskLease(actor, issueID, taskID string, exten
 			return err
 		}
 		if task.ClaimedBy != actor {
-			return fmt.Errorf("task '%s' is not claimed by actor", taskID)
+			return Errorf(CodeNotOwner, "task '%s' is not claimed by actor", taskID)
 		}
 		if task.Status != IssueTaskInProgress && task.Status != IssueTaskBlocked {
-			return fmt.Errorf("task '%s' is not in progress/blocked (status: %s)", taskID, task.Status)
+			return Errorf(CodeInvalidState, "task '%s' is not in progress/blocked (status: %s)", taskID, task.Status)
 		}
 		task.LeaseExpiresAtMs = s.calcLeaseExpiryMs(extendSec, s.taskTTLSec)
 		task.UpdatedAt = NowStr()
-		if err := s.store.WriteJSON(s.store.Path("issues", issueID, "tasks", task.ID+".json"), task); err != nil {
+		if err := s.saveTaskLocked(issueID, task); err != nil {
 			return err
 		}
 		result = task
@@ -142,93 +161,206 @@ func (s *IssueService) ExtendIssueTaskLease(actor, issueID, taskID string, exten
 	return result, nil
 }
 
+// SweepExpired cancels issues and reopens tasks whose lease ran out, escalates overdue reviews, hands
+// back deliveries whose review lease expired and warns owners of task and file leases about to expire.
+// The server runs it on a timer (SWARM_MCP_SWEEP_INTERVAL_SEC); only objects due in the expiry index
+// are read. Waiters on a changed issue are woken.
 func (s *IssueService) SweepExpired() {
-	nowMs := time.Now().UnixMilli()
+	now := time.Now()
+	nowMs := LeaseNowMs()
+	changed := map[string]bool{}
+	defer func() {
+		for issueID := range changed {
+			s.bump(issueID)
+		}
+	}()
 	_ = s.store.WithLock(func() error {
-		issuesDir := s.store.Path("issues")
-		entries, err := os.ReadDir(issuesDir)
-		if err != nil {
-			if os.IsNotExist(err) {
+		s.scanExpiryLocked()
+		for {
+			e, ok := s.expiry.popDue(nowMs)
+			if !ok {
 				return nil
 			}
-			return err
-		}
-		for _, e := range entries {
-			if !e.IsDir() {
-				continue
+			var issueID string
+			switch e.Kind {
+			case expiryIssue:
+				issueID = s.expireIssueLocked(e.IssueID, nowMs)
+			case expiryTask:
+				issueID = s.expireTaskLocked(e.IssueID, e.ID, nowMs)
+			case expiryReview:
+				s.escalateDueReviewsLocked(e.IssueID, e.ID, now)
+			case expiryDelivery:
+				issueID = s.expireDeliveryLocked(e.ID, nowMs)
+			case expiryWarning:
+				issueID = s.warnTaskLeaseLocked(e.IssueID, e.ID, nowMs)
+			case expiryLockWarn:
+				issueID = s.warnLockLeaseLocked(e.ID, nowMs)
 			}
-			issueID := e.Name()
-			var issue Issue
-			if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
-				continue
+			if issueID != "" {
+				changed[issueID] = true
 			}
+		}
+	})
+}
 
-			if (issue.Status == IssueOpen || issue.Status == IssueInProgress) && issue.LeaseExpiresAtMs > 0 && nowMs > issue.LeaseExpiresAtMs {
-				issue.Status = IssueCanceled
-				issue.UpdatedAt = NowStr()
-				_ = s.store.WriteJSON(s.store.Path("issues", issueID, "issue.json"), &issue)
-				_ = s.appendEventLocked(issueID, IssueEvent{Type: EventIssueExpired, IssueID: issueID, Actor: "system", Detail: "expired", Timestamp: NowStr()})
-			}
+// expireIssueLocked cancels the issue if its lease ran out and returns its ID when it did. Must be called
+// under store lock.
+func (s *IssueService) expireIssueLocked(issueID string, nowMs int64) string {
+	var issue Issue
+	if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+		return ""
+	}
+	if issue.PausedAt != "" {
+		// Paused issues keep their leases until resumed; resumeIssue indexes them again.
+		return ""
+	}
+	if (issue.Status != IssueOpen && issue.Status != IssueInProgress) || issue.LeaseExpiresAtMs <= 0 {
+		return ""
+	}
+	if nowMs <= issue.LeaseExpiresAtMs {
+		s.trackIssueExpiry(&issue)
+		return ""
+	}
+	issue.Status = IssueCanceled
+	issue.UpdatedAt = NowStr()
+	_ = s.saveIssueLocked(&issue)
+	_ = s.appendEventLocked(issueID, IssueEvent{Type: EventIssueExpired, IssueID: issueID, Actor: "system", Detail: "expired", Timestamp: NowStr()})
+	return issueID
+}
 
-			taskFiles, _ := s.store.ListJSONFiles(s.store.Path("issues", issueID, "tasks"))
-			for _, p := range taskFiles {
-				var task IssueTask
-				if err := s.store.ReadJSON(p, &task); err != nil {
-					continue
-				}
-				if (task.Status == IssueTaskInProgress || task.Status == IssueTaskBlocked) && task.LeaseExpiresAtMs > 0 && nowMs > task.LeaseExpiresAtMs {
-					prevStatus := task.Status
-					prevOwner := task.ClaimedBy
-					task.Status = IssueTaskOpen
-					task.ReservedToken = ""
-					task.ReservedUntilMs = 0
-					task.ClaimedBy = ""
-					task.Submitter = ""
-					task.Submission = ""
-					task.Refs = ""
-					task.SubmissionArtifacts = SubmissionArtifacts{}
-					task.Verdict = ""
-					task.Feedback = ""
-					task.CompletionScore = 0
-					task.ReviewArtifacts = ReviewArtifacts{}
-					task.FeedbackDetails = nil
-					task.UpdatedAt = NowStr()
-					_ = s.store.WriteJSON(p, &task)
-					_ = s.appendEventLocked(issueID, IssueEvent{Type: EventIssueTaskExpired, IssueID: issueID, TaskID: task.ID, Actor: "system", Detail: fmt.Sprintf("expired: %s claimed_by=%s", prevStatus, prevOwner), Timestamp: NowStr()})
-				}
-			}
-		}
+// expireTaskLocked reopens the task if its lease ran out and returns the issue ID when it did. Must be
+// called under store lock.
+func (s *IssueService) expireTaskLocked(issueID, taskID string, nowMs int64) string {
+	var issue Issue
+	if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil || issue.PausedAt != "" {
+		// Paused issues keep their tasks' leases until resumed.
+		return ""
+	}
+	task, err := s.loadTaskLocked(issueID, taskID)
+	if err != nil || (task.Status != IssueTaskInProgress && task.Status != IssueTaskBlocked) || task.LeaseFrozenAt != "" || task.LeaseExpiresAtMs <= 0 {
+		return ""
+	}
+	if nowMs <= task.LeaseExpiresAtMs {
+		s.trackTaskExpiry(issueID, task)
+		return ""
+	}
+	prevStatus := task.Status
+	prevOwner := task.ClaimedBy
+	task.Status = IssueTaskOpen
+	task.ReservedToken = ""
+	task.ReservedUntilMs = 0
+	task.ClaimedBy = ""
+	task.Submitter = ""
+	task.Submission = ""
+	task.Refs = ""
+	task.SubmissionArtifacts = SubmissionArtifacts{}
+	task.Verdict = ""
+	task.Feedback = ""
+	task.CompletionScore = 0
+	task.ReviewArtifacts = ReviewArtifacts{}
+	task.FeedbackDetails = nil
+	task.EscalatedAt = ""
+	task.UpdatedAt = NowStr()
+	_ = s.saveTaskLocked(issueID, task)
+	_ = s.appendEventLocked(issueID, IssueEvent{Type: EventIssueTaskExpired, IssueID: issueID, TaskID: task.ID, Actor: "system", Detail: fmt.Sprintf("expired: %s claimed_by=%s", prevStatus, prevOwner), Timestamp: NowStr()})
+	return issueID
+}
 
-		// Sweep expired in_review deliveries
-		deliveriesDir := s.store.Path("deliveries")
-		deliveryFiles, _ := s.store.ListJSONFiles(deliveriesDir)
-		for _, p := range deliveryFiles {
-			var d Delivery
-			if err := s.store.ReadJSON(p, &d); err != nil {
-				continue
-			}
-			if d.Status == DeliveryInReview && d.LeaseExpiresAtMs > 0 && nowMs > d.LeaseExpiresAtMs {
-				prevClaimedBy := d.ClaimedBy
-				d.Status = DeliveryOpen
-				d.ClaimedBy = ""
-				d.ClaimedAt = ""
-				d.LeaseExpiresAtMs = 0
-				d.UpdatedAt = NowStr()
-				_ = s.store.WriteJSON(p, &d)
-				// Note: deliveries don't have event log, so no event append
-				_ = prevClaimedBy // silence unused
-			}
-		}
+// escalateDueReviewsLocked escalates the overdue submissions of a claimed task and queues the next review
+// deadline. Must be called under store lock.
+func (s *IssueService) escalateDueReviewsLocked(issueID, taskID string, now time.Time) {
+	var issue Issue
+	if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil || issue.PausedAt != "" {
+		return
+	}
+	task, err := s.loadTaskLocked(issueID, taskID)
+	if err != nil || (task.Status != IssueTaskInProgress && task.Status != IssueTaskBlocked) {
+		return
+	}
+	if next := s.escalateOverdueReviewsLocked(issueID, taskID, now); next > 0 {
+		s.trackReviewDeadline(issueID, taskID, next)
+	}
+}
 
-		return nil
-	})
+// expireDeliveryLocked hands an in-review delivery back if its review lease ran out and returns its issue
+// ID when it did. Must be called under store lock.
+func (s *IssueService) expireDeliveryLocked(deliveryID string, nowMs int64) string {
+	p := s.store.Path("deliveries", deliveryID+".json")
+	var d Delivery
+	if err := s.store.ReadJSON(p, &d); err != nil || d.Status != DeliveryInReview || d.LeaseExpiresAtMs <= 0 {
+		return ""
+	}
+	if nowMs <= d.LeaseExpiresAtMs {
+		s.trackDeliveryExpiry(&d)
+		return ""
+	}
+	d.Status = DeliveryOpen
+	d.ClaimedBy = ""
+	d.ClaimedAt = ""
+	d.LeaseExpiresAtMs = 0
+	d.UpdatedAt = NowStr()
+	// Deliveries have no event log, so there is no event to append.
+	_ = s.store.WriteJSON(p, &d)
+	return d.IssueID
+}
+
+// saveIssueLocked writes issue.json, bumping the issue's revision.
+func (s *IssueService) saveIssueLocked(issue *Issue) error {
+	issue.SchemaVersion = IssueSchemaVersion
+	issue.Rev++
+	if err := s.store.WriteJSON(s.store.Path("issues", issue.ID, "issue.json"), issue); err != nil {
+		return err
+	}
+	s.trackIssueExpiry(issue)
+	return nil
+}
+
+// saveTaskLocked writes a task file, bumping the task's revision.
+func (s *IssueService) saveTaskLocked(issueID string, task *IssueTask) error {
+	task.SchemaVersion = TaskSchemaVersion
+	task.Rev++
+	if err := s.store.WriteJSON(s.store.Path("issues", issueID, "tasks", task.ID+".json"), task); err != nil {
+		return err
+	}
+	s.trackTaskExpiry(issueID, task)
+	return nil
+}
+
+// RevisionConflictError is returned when an update carries an expected_rev that no longer matches, i.e.
+// the issue or task was written by someone else since the caller read it.
+type RevisionConflictError struct {
+	Kind        string // "issue" or "task"
+	ID          string
+	ExpectedRev int64
+	CurrentRev  int64
+}
+
+func (e *RevisionConflictError) Error() string {
+	return fmt.Sprintf("%s '%s' changed: expected_rev %d but current rev is %d; re-read and retry", e.Kind, e.ID, e.ExpectedRev, e.CurrentRev)
+}
+
+func (e *RevisionConflictError) ErrorDetails() any {
+	return map[string]any{"rev_conflict": map[string]any{
+		"kind":         e.Kind,
+		"id":           e.ID,
+		"expected_rev": e.ExpectedRev,
+		"current_rev":  e.CurrentRev,
+	}}
+}
+
+// checkRev enforces expected_rev; 0 means the caller did not ask for a conditional write.
+func checkRev(kind, id string, expected, current int64) error {
+	if expected > 0 && expected != current {
+		return &RevisionConflictError{Kind: kind, ID: id, ExpectedRev: expected, CurrentRev: current}
+	}
+	return nil
 }
 
 func (s *IssueService) loadTaskLocked(issueID, taskID string) (*IssueTask, error) {
 	path := s.store.Path("issues", issueID, "tasks", taskID+".json")
 	var task IssueTask
 	if err := s.store.ReadJSON(path, &task); err != nil {
-		return nil, fmt.Errorf("task '%s' not found in issue '%s'", taskID, issueID)
+		return nil, Errorf(CodeNotFound, "task '%s' not found in issue '%s'", taskID, issueID)
 	}
 	return &task, nil
 }
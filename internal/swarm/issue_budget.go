@@ -0,0 +1,64 @@
+package swarm
+
+// PointsBudget is an issue's points budget against the points of its non-canceled tasks.
+type PointsBudget struct {
+	IssueID   string `json:"issue_id"`
+	Budget    int    `json:"budget"` // 0 = no budget
+	Strict    bool   `json:"strict"`
+	Used      int    `json:"used"`
+	Remaining int    `json:"remaining"` // negative once over budget
+	Exceeded  bool   `json:"exceeded"`
+}
+
+// GetPointsBudget reports how much of the issue's points budget its tasks use.
+func (s *IssueService) GetPointsBudget(issueID string) (*PointsBudget, error) {
+	if issueID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
+	}
+	var out *PointsBudget
+	err := s.store.WithLock(func() error {
+		var issue Issue
+		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+			return Errorf(CodeNotFound, "issue '%s' not found", issueID)
+		}
+		used := s.pointsUsedLocked(issueID)
+		out = &PointsBudget{
+			IssueID:   issueID,
+			Budget:    issue.PointsBudget,
+			Strict:    issue.BudgetStrict,
+			Used:      used,
+			Remaining: issue.PointsBudget - used,
+			Exceeded:  issue.PointsBudget > 0 && used > issue.PointsBudget,
+		}
+		return nil
+	})
+	return out, err
+}
+
+// checkPointsBudgetLocked rejects adding a task worth points when the issue has a strict budget that
+// it would exceed. Call under store lock.
+func (s *IssueService) checkPointsBudgetLocked(issueID string, points int) error {
+	var issue Issue
+	if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+		return err
+	}
+	if issue.PointsBudget <= 0 || !issue.BudgetStrict {
+		return nil
+	}
+	if used := s.pointsUsedLocked(issueID); used+points > issue.PointsBudget {
+		return Errorf(CodeLimitExceeded, "points budget exceeded: tasks already use %d of %d points, this task adds %d", used, issue.PointsBudget, points)
+	}
+	return nil
+}
+
+func (s *IssueService) pointsUsedLocked(issueID string) int {
+	used := 0
+	for _, f := range listJSONOrEmpty(s.store, s.store.Path("issues", issueID, "tasks")) {
+		var t IssueTask
+		if err := s.store.ReadJSON(f, &t); err != nil || t.Status == IssueTaskCanceled {
+			continue
+		}
+		used += t.Points
+	}
+	return used
+}
@@ -0,0 +1,47 @@
+package swarm
+
+import (
+	"strings"
+	"testing"
+)
+
+func createBudgetTask(svc *IssueService, issueID string, points int) (*IssueTask, error) {
+	return svc.CreateTask("lead", issueID, "task", "desc", "easy", nil, nil, nil, points, nil,
+		"spec", "issue", "split", "scope", nil, "goal", "rules", "constraints", "conventions", "acceptance")
+}
+
+func TestCreateTask_PointsBudget(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	strict, err := svc.CreateIssue("lead", "strict", "desc", nil, nil, "user", "u", "lead", "l", nil, 10, true)
+	if err != nil {
+		t.Fatalf("create issue: %v", err)
+	}
+	if _, err := createBudgetTask(svc, strict.ID, 6); err != nil {
+		t.Fatalf("task within budget: %v", err)
+	}
+	if _, err := createBudgetTask(svc, strict.ID, 5); err == nil || !strings.Contains(err.Error(), "points budget exceeded") {
+		t.Fatalf("expected strict budget to reject the task, got %v", err)
+	}
+	if tasks, _ := svc.ListTasks(strict.ID, ""); len(tasks) != 1 {
+		t.Fatalf("expected the rejected task not to be created, got %d tasks", len(tasks))
+	}
+
+	loose, err := svc.CreateIssue("lead", "loose", "desc", nil, nil, "user", "u", "lead", "l", nil, 10, false)
+	if err != nil {
+		t.Fatalf("create issue: %v", err)
+	}
+	for _, p := range []int{6, 5} {
+		if _, err := createBudgetTask(svc, loose.ID, p); err != nil {
+			t.Fatalf("non-strict budget must not reject: %v", err)
+		}
+	}
+	b, err := svc.GetPointsBudget(loose.ID)
+	if err != nil {
+		t.Fatalf("budget: %v", err)
+	}
+	if b.Used != 11 || b.Remaining != -1 || !b.Exceeded {
+		t.Fatalf("expected 11 of 10 points used and exceeded, got %+v", b)
+	}
+}
@@ -0,0 +1,169 @@
+package swarm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CloneIssue copies an issue into a fresh one: the issue docs and doc paths, plus every task still open
+// (neither done nor canceled), with its required (spec) docs. Tasks get new IDs in the same order and
+// start over as open and unclaimed; context/split references between them are rewritten to the new IDs
+// and references to tasks that were not cloned are dropped. Submissions, messages, deliveries and
+// work-product docs are not copied. subject overrides the source subject when non-empty.
+func (s *IssueService) CloneIssue(actor, issueID, subject string) (*Issue, []IssueTask, error) {
+	if issueID == "" {
+		return nil, nil, Errorf(CodeInvalidArgument, "issue_id is required")
+	}
+	if actor == "" {
+		actor = "lead"
+	}
+
+	var clone *Issue
+	var cloned []IssueTask
+	err := s.store.WithLock(func() error {
+		var src Issue
+		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &src); err != nil {
+			return Errorf(CodeNotFound, "issue '%s' not found", issueID)
+		}
+		var tasks []IssueTask
+		for _, f := range listJSONOrEmpty(s.store, s.store.Path("issues", issueID, "tasks")) {
+			var t IssueTask
+			if err := s.store.ReadJSON(f, &t); err != nil {
+				continue
+			}
+			if t.Status == IssueTaskCanceled || t.Status == IssueTaskDone {
+				continue
+			}
+			tasks = append(tasks, t)
+		}
+		sort.SliceStable(tasks, func(i, j int) bool { return taskNum(tasks[i].ID) < taskNum(tasks[j].ID) })
+
+		now := NowStr()
+		clone = &Issue{
+			ID:               GenID("issue"),
+			Subject:          src.Subject,
+			Description:      src.Description,
+			SharedDocPaths:   src.SharedDocPaths,
+			ProjectDocPaths:  src.ProjectDocPaths,
+			Status:           IssueOpen,
+			LeaseExpiresAtMs: s.calcLeaseExpiryMs(0, s.issueTTLSec),
+			PointsBudget:     src.PointsBudget,
+			BudgetStrict:     src.BudgetStrict,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		}
+		if strings.TrimSpace(subject) != "" {
+			clone.Subject = strings.TrimSpace(subject)
+		}
+
+		s.store.EnsureDir("issues", clone.ID, "tasks")
+		docsDir := s.store.EnsureDir("issues", clone.ID, "docs")
+		for _, d := range src.Docs {
+			if err := copyDocFile(s.store.Path("issues", issueID, "docs", d.Name+".md"), docsDir, d.Name+".md"); err != nil {
+				return fmt.Errorf("copy issue doc %s: %w", d.Name, err)
+			}
+			clone.Docs = append(clone.Docs, DocRef{Name: d.Name, Path: filepath.Join(docsDir, d.Name+".md")})
+		}
+
+		newIDs := make(map[string]string, len(tasks))
+		for i, t := range tasks {
+			newIDs[t.ID] = fmt.Sprintf("task-%d", i+1)
+		}
+
+		if err := s.saveIssueLocked(clone); err != nil {
+			return err
+		}
+		meta := s.newIssueMeta(int64(len(tasks) + 1))
+		if err := s.store.WriteJSON(s.store.Path("issues", clone.ID, "meta.json"), meta); err != nil {
+			return err
+		}
+		if err := s.appendEventLocked(clone.ID, IssueEvent{
+			Type:      EventIssueCreated,
+			IssueID:   clone.ID,
+			Actor:     actor,
+			Detail:    clone.Subject + " (cloned from " + issueID + ")",
+			Timestamp: now,
+		}); err != nil {
+			return err
+		}
+
+		for _, t := range tasks {
+			task := IssueTask{
+				ID:                newIDs[t.ID],
+				IssueID:           clone.ID,
+				Subject:           t.Subject,
+				Description:       t.Description,
+				Difficulty:        t.Difficulty,
+				SplitFrom:         newIDs[t.SplitFrom],
+				SplitReason:       t.SplitReason,
+				ImpactScope:       t.ImpactScope,
+				SuggestedFiles:    t.SuggestedFiles,
+				Labels:            t.Labels,
+				DocPaths:          t.DocPaths,
+				RequiredIssueDocs: t.RequiredIssueDocs,
+				RequiredTaskDocs:  t.RequiredTaskDocs,
+				Points:            t.Points,
+				Status:            IssueTaskOpen,
+				CreatedAt:         now,
+				UpdatedAt:         now,
+			}
+			for _, id := range t.ContextTaskIDs {
+				if n, ok := newIDs[id]; ok {
+					task.ContextTaskIDs = append(task.ContextTaskIDs, n)
+				}
+			}
+			srcDocs := s.store.Path("issues", issueID, "tasks", t.ID+".docs")
+			dstDocs := s.store.Path("issues", clone.ID, "tasks", task.ID+".docs")
+			for _, name := range t.RequiredTaskDocs {
+				if err := copyDocFile(filepath.Join(srcDocs, name+".md"), dstDocs, name+".md"); err != nil {
+					return fmt.Errorf("copy doc %s of %s: %w", name, t.ID, err)
+				}
+			}
+			if err := s.saveTaskLocked(clone.ID, &task); err != nil {
+				return err
+			}
+			cloned = append(cloned, task)
+			if err := s.appendEventLocked(clone.ID, IssueEvent{
+				Type:      EventIssueTaskCreated,
+				IssueID:   clone.ID,
+				TaskID:    task.ID,
+				Actor:     actor,
+				Detail:    task.Subject + " (cloned from " + issueID + "/" + t.ID + ")",
+				Timestamp: now,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.bump(clone.ID)
+	return clone, cloned, nil
+}
+
+// copyDocFile copies a markdown doc into dir; a missing source is skipped.
+func copyDocFile(src, dir, filename string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return writeDocFile(dir, filename, string(data))
+}
+
+// taskNum extracts N from a "task-N" id (0 if the id has another shape).
+func taskNum(id string) int {
+	var n int
+	if _, err := fmt.Sscanf(id, "task-%d", &n); err != nil {
+		return 0
+	}
+	return n
+}
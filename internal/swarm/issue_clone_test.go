@@ -0,0 +1,112 @@
+package swarm
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCloneIssue_CopiesDocsAndResetsTasks(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	src, err := svc.CreateIssue("lead", "billing", "desc", nil, []string{"README.md"}, "user", "user doc", "plan", "lead doc", nil, 0, false)
+	if err != nil {
+		t.Fatalf("create issue: %v", err)
+	}
+	mk := func(issueID, subject string, ctx []string) *IssueTask {
+		task, err := svc.CreateTask("lead", issueID, subject, "d", "easy", nil, nil, nil, 1, ctx,
+			"spec", "root", "reason", "scope", nil, "goal", "rules", "constraints", "conventions", "acceptance")
+		if err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+		return task
+	}
+	t1 := mk(src.ID, "first", nil)
+	t2 := mk(src.ID, "second", []string{t1.ID})
+	t2.Status = IssueTaskInProgress
+	t2.ClaimedBy = "w1"
+	if err := svc.saveTaskLocked(src.ID, t2); err != nil {
+		t.Fatalf("save task: %v", err)
+	}
+	if err := writeDocFile(store.Path("issues", src.ID, "tasks", t2.ID+".docs"), "notes.md", "work product"); err != nil {
+		t.Fatalf("write doc: %v", err)
+	}
+
+	clone, tasks, err := svc.CloneIssue("lead", src.ID, "billing (eu)")
+	if err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	if clone.ID == src.ID || clone.Subject != "billing (eu)" || clone.Status != IssueOpen || len(clone.ProjectDocPaths) != 1 {
+		t.Fatalf("unexpected clone: %+v", clone)
+	}
+	if data, err := os.ReadFile(store.Path("issues", clone.ID, "docs", "plan.md")); err != nil || string(data) != "lead doc" {
+		t.Fatalf("expected lead doc copied, got %q (%v)", data, err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 cloned tasks, got %+v", tasks)
+	}
+	second := tasks[1]
+	if second.Status != IssueTaskOpen || second.ClaimedBy != "" || second.IssueID != clone.ID {
+		t.Fatalf("expected reset task, got %+v", second)
+	}
+	if len(second.ContextTaskIDs) != 1 || second.ContextTaskIDs[0] != tasks[0].ID {
+		t.Fatalf("expected context remapped to %s, got %v", tasks[0].ID, second.ContextTaskIDs)
+	}
+	if !store.Exists("issues", clone.ID, "tasks", second.ID+".docs", "spec.md") {
+		t.Fatalf("expected spec doc copied")
+	}
+	if store.Exists("issues", clone.ID, "tasks", second.ID+".docs", "notes.md") {
+		t.Fatalf("work-product docs must not be copied")
+	}
+
+	next := mk(clone.ID, "extra", nil)
+	if next.ID != "task-3" {
+		t.Fatalf("expected numbering to continue at task-3, got %s", next.ID)
+	}
+}
+
+func TestCloneIssue_SkipsFinishedTasksAndDropsTheirReferences(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	src, err := svc.CreateIssue("lead", "billing", "desc", nil, nil, "user", "user doc", "plan", "lead doc", nil, 0, false)
+	if err != nil {
+		t.Fatalf("create issue: %v", err)
+	}
+	mk := func(subject, status string, ctx []string) *IssueTask {
+		task, err := svc.CreateTask("lead", src.ID, subject, "d", "easy", nil, nil, nil, 1, ctx,
+			"spec", "root", "reason", "scope", nil, "goal", "rules", "constraints", "conventions", "acceptance")
+		if err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+		if status != IssueTaskOpen {
+			task.Status = status
+			if err := svc.saveTaskLocked(src.ID, task); err != nil {
+				t.Fatalf("save task: %v", err)
+			}
+		}
+		return task
+	}
+	done := mk("done", IssueTaskDone, nil)
+	canceled := mk("canceled", IssueTaskCanceled, nil)
+	kept := mk("kept", IssueTaskOpen, nil)
+	last := mk("last", IssueTaskOpen, []string{done.ID, canceled.ID, kept.ID})
+	last.SplitFrom = canceled.ID
+	if err := svc.saveTaskLocked(src.ID, last); err != nil {
+		t.Fatalf("save task: %v", err)
+	}
+
+	_, tasks, err := svc.CloneIssue("lead", src.ID, "")
+	if err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].Subject != "kept" || tasks[1].Subject != "last" {
+		t.Fatalf("expected only the open tasks cloned, got %+v", tasks)
+	}
+	if got := tasks[1].ContextTaskIDs; len(got) != 1 || got[0] != tasks[0].ID {
+		t.Fatalf("expected context [%s], got %v", tasks[0].ID, got)
+	}
+	if tasks[1].SplitFrom != "" {
+		t.Fatalf("expected split_from to a canceled task dropped, got %q", tasks[1].SplitFrom)
+	}
+}
@@ -0,0 +1,136 @@
+package swarm
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Per-task event shards. With sharding on, issues created afterwards keep each task's events in
+// events/<task_id>.jsonl and only issue-level events in events.jsonl; the choice is recorded in the
+// issue's meta.json so an issue never mixes both layouts. ReadAllEvents merges the files by seq, while
+// task-scoped reads (and resetIssueTask's log rewrite) touch only the task's own shard.
+
+// SetShardTaskEvents turns on per-task event files for issues created from now on.
+func (s *IssueService) SetShardTaskEvents(enabled bool) {
+	s.shardEvents = enabled
+}
+
+// newIssueMeta returns the meta.json of a new issue.
+func (s *IssueService) newIssueMeta(nextTaskNum int64) *issueMeta {
+	return &issueMeta{NextSeq: 1, NextTaskNum: nextTaskNum, ShardedEvents: s.shardEvents}
+}
+
+// eventLogPath returns the file an event of taskID goes to.
+func (s *IssueService) eventLogPath(issueID string, meta *issueMeta, taskID string) string {
+	if meta.ShardedEvents && taskID != "" {
+		return s.store.Path("issues", issueID, "events", taskID+".jsonl")
+	}
+	return s.store.Path("issues", issueID, "events.jsonl")
+}
+
+// eventsSharded reports whether the issue keeps per-task event files.
+func (s *IssueService) eventsSharded(issueID string) bool {
+	var meta issueMeta
+	return s.store.ReadJSON(s.store.Path("issues", issueID, "meta.json"), &meta) == nil && meta.ShardedEvents
+}
+
+// readTaskEvents returns the events of one task in seq order, reading only its shard when the issue is
+// sharded.
+func (s *IssueService) readTaskEvents(issueID, taskID string) ([]IssueEvent, error) {
+	if !s.eventsSharded(issueID) {
+		events, err := s.ReadAllEvents(issueID)
+		if err != nil {
+			return nil, err
+		}
+		out := events[:0]
+		for _, ev := range events {
+			if ev.TaskID == taskID {
+				out = append(out, ev)
+			}
+		}
+		return out, nil
+	}
+	if !s.store.Exists("issues", issueID, "issue.json") {
+		return nil, Errorf(CodeNotFound, "issue '%s' not found", issueID)
+	}
+	return readEventFile(s.store.Path("issues", issueID, "events", taskID+".jsonl"))
+}
+
+// readShardedEvents merges events.jsonl and every task shard of the issue by seq.
+func (s *IssueService) readShardedEvents(issueID string) ([]IssueEvent, error) {
+	out, err := readEventFile(s.store.Path("issues", issueID, "events.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(s.store.Path("issues", issueID, "events"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".jsonl") {
+			continue
+		}
+		events, err := readEventFile(s.store.Path("issues", issueID, "events", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, events...)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Seq < out[j].Seq })
+	return out, nil
+}
+
+// readEventFile parses one JSONL event log; a missing file is empty and malformed lines are skipped.
+func readEventFile(path string) ([]IssueEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []IssueEvent{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 1024*1024)
+	scanner.Buffer(buf, 16*1024*1024)
+
+	out := make([]IssueEvent, 0, 64)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var ev IssueEvent
+		if err := json.Unmarshal(line, &ev); err != nil {
+			continue
+		}
+		out = append(out, ev)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// writeEventLocked appends ev to its log file. Must be called under store lock.
+func (s *IssueService) writeEventLocked(issueID string, meta *issueMeta, ev *IssueEvent) error {
+	eventsPath := s.eventLogPath(issueID, meta, ev.TaskID)
+	_ = os.MkdirAll(filepath.Dir(eventsPath), 0755)
+	f, err := os.OpenFile(eventsPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
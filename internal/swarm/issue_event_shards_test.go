@@ -0,0 +1,79 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestShardedEvents_TaskReadsAndResetTouchOnlyTheShard(t *testing.T) {
+	store := NewStore(t.TempDir())
+	store.EnsureDir()
+	store.EnsureDir("issues")
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 1, 1)
+	svc.SetShardTaskEvents(true)
+
+	issue, err := svc.CreateIssue("lead", "subject", "desc", nil, nil, "user", "u", "lead", "l", nil, 0, false)
+	if err != nil {
+		t.Fatalf("create issue: %v", err)
+	}
+	if err := store.WithLock(func() error {
+		for _, ev := range []IssueEvent{
+			{Type: EventIssueTaskMessage, IssueID: issue.ID, TaskID: "task-1", Timestamp: NowStr()},
+			{Type: EventIssueTaskResolved, IssueID: issue.ID, TaskID: "task-2", Timestamp: NowStr()},
+			{Type: EventIssueTaskResolved, IssueID: issue.ID, TaskID: "task-1", Timestamp: NowStr()},
+		} {
+			if err := svc.appendEventLocked(issue.ID, ev); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("append events: %v", err)
+	}
+	if !store.Exists("issues", issue.ID, "events", "task-1.jsonl") || !store.Exists("issues", issue.ID, "events", "task-2.jsonl") {
+		t.Fatalf("expected one event file per task")
+	}
+
+	all, err := svc.ReadAllEvents(issue.ID)
+	if err != nil {
+		t.Fatalf("read all: %v", err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("expected issue_created plus 3 task events, got %+v", all)
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].Seq <= all[i-1].Seq {
+			t.Fatalf("merged view not ordered by seq: %+v", all)
+		}
+	}
+
+	events, _, err := svc.SubscribeIssueEvents(context.Background(), issue.ID, nil, "task-1", 0, 1, 10)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if len(events) != 2 || events[0].Seq != 2 || events[1].Seq != 4 {
+		t.Fatalf("expected task-1's two events, got %+v", events)
+	}
+
+	if err := store.WithLock(func() error {
+		return svc.saveTaskLocked(issue.ID, &IssueTask{ID: "task-1", IssueID: issue.ID, Status: IssueTaskOpen})
+	}); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	if _, err := svc.ResetTask("lead", issue.ID, "task-1", "redo", 0); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	events, err = svc.readTaskEvents(issue.ID, "task-2")
+	if err != nil || len(events) != 1 {
+		t.Fatalf("task-2's shard should be untouched, got %+v err=%v", events, err)
+	}
+	events, err = svc.readTaskEvents(issue.ID, "task-1")
+	if err != nil {
+		t.Fatalf("read task-1: %v", err)
+	}
+	for _, ev := range events {
+		if ev.Seq <= 4 {
+			t.Fatalf("reset should drop task-1's old events, got %+v", events)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package swarm
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// SubscribeIssueEvents blocks until events with seq > afterSeq matching types/taskID exist (or timeout) and returns
+// up to limit of them. Unlike WaitIssueTaskEvents it does not consume the lead inbox, so any number of observers can
+// follow the same issue. nextAfterSeq is the cursor for the next call; it also skips past non-matching events.
+func (s *IssueService) SubscribeIssueEvents(ctx context.Context, issueID string, types []string, taskID string, afterSeq int64, timeoutSec, limit int) ([]IssueEvent, int64, error) {
+	if issueID == "" {
+		return nil, afterSeq, Errorf(CodeInvalidArgument, "issue_id is required")
+	}
+	if !s.store.Exists("issues", issueID, "issue.json") {
+		return nil, afterSeq, Errorf(CodeNotFound, "issue '%s' not found", issueID)
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	taskID = strings.TrimSpace(taskID)
+	wanted := map[string]bool{}
+	for _, t := range types {
+		if t = strings.TrimSpace(t); t != "" {
+			wanted[t] = true
+		}
+	}
+	timeoutSec = s.normalizeTimeoutSec(ctx, timeoutSec)
+
+	deadline := time.Now().Add(time.Duration(timeoutSec) * time.Second)
+	for {
+		since := s.changeGen()
+		var events []IssueEvent
+		var err error
+		if taskID != "" {
+			events, err = s.readTaskEvents(issueID, taskID)
+		} else {
+			events, err = s.ReadAllEvents(issueID)
+		}
+		if err != nil {
+			return nil, afterSeq, err
+		}
+		next := afterSeq
+		out := make([]IssueEvent, 0, limit)
+		for _, ev := range events {
+			if ev.Seq <= afterSeq {
+				continue
+			}
+			if len(out) >= limit {
+				break
+			}
+			next = ev.Seq
+			if len(wanted) > 0 && !wanted[ev.Type] {
+				continue
+			}
+			if taskID != "" && ev.TaskID != taskID {
+				continue
+			}
+			out = append(out, ev)
+		}
+		if len(out) > 0 {
+			return out, next, nil
+		}
+
+		// Nothing new will arrive once the issue is finished.
+		var issue Issue
+		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err == nil &&
+			(issue.Status == IssueDone || issue.Status == IssueCanceled) {
+			return out, next, nil
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return out, next, nil
+		}
+		if err := s.waitChange(ctx, since, min(remaining, pollInterval)); err != nil {
+			return nil, afterSeq, err
+		}
+	}
+}
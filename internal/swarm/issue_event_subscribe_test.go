@@ -0,0 +1,52 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSubscribeIssueEvents_FiltersAndAdvancesCursor(t *testing.T) {
+	store := NewStore(t.TempDir())
+	store.EnsureDir()
+	store.EnsureDir("issues")
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 1, 1)
+
+	issue, err := svc.CreateIssue("lead", "subject", "desc", nil, nil, "user", "u", "lead", "l", nil, 0, false)
+	if err != nil {
+		t.Fatalf("create issue: %v", err)
+	}
+	if err := store.WithLock(func() error {
+		for _, ev := range []IssueEvent{
+			{Type: EventIssueTaskMessage, IssueID: issue.ID, TaskID: "task-1", Timestamp: NowStr()},
+			{Type: EventIssueTaskResolved, IssueID: issue.ID, TaskID: "task-2", Timestamp: NowStr()},
+			{Type: EventIssueTaskResolved, IssueID: issue.ID, TaskID: "task-1", Timestamp: NowStr()},
+		} {
+			if err := svc.appendEventLocked(issue.ID, ev); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("append events: %v", err)
+	}
+
+	events, next, err := svc.SubscribeIssueEvents(context.Background(), issue.ID, []string{EventIssueTaskResolved}, "task-1", 0, 1, 10)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if len(events) != 1 || events[0].TaskID != "task-1" || events[0].Type != EventIssueTaskResolved {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	if next != events[0].Seq {
+		t.Fatalf("cursor should point at last scanned event: next=%d seq=%d", next, events[0].Seq)
+	}
+
+	// Nothing newer: the call times out with an empty batch and keeps the cursor.
+	events, next2, err := svc.SubscribeIssueEvents(context.Background(), issue.ID, nil, "", next, 1, 10)
+	if err != nil {
+		t.Fatalf("subscribe again: %v", err)
+	}
+	if len(events) != 0 || next2 != next {
+		t.Fatalf("expected empty batch, got %+v next=%d", events, next2)
+	}
+}
@@ -0,0 +1,185 @@
+package swarm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IssueExportFormat identifies the archives written by ExportIssue.
+const IssueExportFormat = "swarm-mcp-issue-export/1"
+
+// IssueExportFile is one file in an export archive.
+type IssueExportFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// IssueExportManifest is written as manifest.json at the root of an export archive.
+type IssueExportManifest struct {
+	Format      string            `json:"format"`
+	IssueID     string            `json:"issue_id"`
+	Subject     string            `json:"subject"`
+	Status      string            `json:"status"`
+	ExportedAt  string            `json:"exported_at"`
+	Tasks       int               `json:"tasks"`
+	Submissions int               `json:"submissions"`
+	Events      int               `json:"events"`
+	Docs        int               `json:"docs"`
+	Deliveries  int               `json:"deliveries"`
+	Files       []IssueExportFile `json:"files"`
+}
+
+// ExportIssue writes a gzipped tar of the issue to w: the whole issue directory (issue, tasks, docs,
+// submissions, messages, events, artifacts) under issue/, the issue's deliveries under deliveries/, and a
+// manifest.json listing every file with its size and sha256. The store is read under its lock, so the
+// archive is a consistent snapshot.
+func (s *IssueService) ExportIssue(issueID string, w io.Writer) (*IssueExportManifest, error) {
+	issueID = strings.TrimSpace(issueID)
+	if issueID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
+	}
+
+	type entry struct {
+		name string
+		data []byte
+		mode os.FileMode
+		mod  time.Time
+	}
+	var entries []entry
+	var manifest *IssueExportManifest
+	err := s.store.WithLock(func() error {
+		var issue Issue
+		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+			return Errorf(CodeNotFound, "issue '%s' not found", issueID)
+		}
+		m := &IssueExportManifest{
+			Format:     IssueExportFormat,
+			IssueID:    issueID,
+			Subject:    issue.Subject,
+			Status:     issue.Status,
+			ExportedAt: NowStr(),
+			Files:      []IssueExportFile{},
+		}
+		add := func(name, path string) error {
+			info, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256(data)
+			entries = append(entries, entry{name: name, data: data, mode: info.Mode().Perm(), mod: info.ModTime()})
+			m.Files = append(m.Files, IssueExportFile{Path: name, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])})
+			return nil
+		}
+
+		issueDir := s.store.Path("issues", issueID)
+		err := filepath.Walk(issueDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || strings.HasSuffix(path, ".tmp") {
+				return nil
+			}
+			rel, err := filepath.Rel(issueDir, path)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			switch {
+			case strings.HasPrefix(rel, "tasks/") && strings.HasSuffix(rel, ".json") && !strings.Contains(rel, ".docs/"):
+				m.Tasks++
+			case strings.HasPrefix(rel, "submissions/"):
+				m.Submissions++
+			case strings.HasPrefix(rel, "docs/") || strings.Contains(rel, ".docs/"):
+				m.Docs++
+			}
+			return add("issue/"+rel, path)
+		})
+		if err != nil {
+			return err
+		}
+		if events, err := s.ReadAllEvents(issueID); err == nil {
+			m.Events = len(events)
+		}
+
+		for _, f := range listJSONOrEmpty(s.store, s.store.Path("deliveries")) {
+			var d Delivery
+			if err := s.store.ReadJSON(f, &d); err != nil || d.IssueID != issueID {
+				continue
+			}
+			if err := add("deliveries/"+filepath.Base(f), f); err != nil {
+				return err
+			}
+			m.Deliveries++
+		}
+		sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+		manifest = m
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	mb, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	entries = append([]entry{{name: "manifest.json", data: mb, mode: 0o644, mod: time.Now()}}, entries...)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: int64(e.mode), Size: int64(len(e.data)), ModTime: e.mod, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(e.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// ExportIssueToFile writes the export archive to <root>/exports/<issue_id>-<ulid>.tar.gz and returns
+// its path with the manifest.
+func (s *IssueService) ExportIssueToFile(issueID string) (string, *IssueExportManifest, error) {
+	return s.ExportIssueToDir(issueID, "exports")
+}
+
+// ExportIssueToDir writes the export archive to <root>/<dir>/<issue_id>-<ulid>.tar.gz. The ULID keeps the
+// name time-ordered and unique, so two processes exporting the same issue never share a file.
+func (s *IssueService) ExportIssueToDir(issueID, dir string) (string, *IssueExportManifest, error) {
+	path := filepath.Join(s.store.EnsureDir(dir), fmt.Sprintf("%s-%s.tar.gz", strings.TrimSpace(issueID), newULID(time.Now())))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", nil, err
+	}
+	manifest, err := s.ExportIssue(issueID, f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return "", nil, err
+	}
+	return path, manifest, nil
+}
@@ -0,0 +1,90 @@
+package swarm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestExportIssue_ArchiveWithManifest(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Subject: "Fix login", Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 2}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	if err := svc.saveTaskLocked(issueID, &IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskOpen}); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	store.EnsureDir("issues", issueID, "docs")
+	if err := os.WriteFile(store.Path("issues", issueID, "docs", "spec.md"), []byte("# spec"), 0o644); err != nil {
+		t.Fatalf("write doc: %v", err)
+	}
+	if err := svc.appendEventLocked(issueID, IssueEvent{Type: EventIssueCreated, IssueID: issueID, Timestamp: NowStr()}); err != nil {
+		t.Fatalf("append event: %v", err)
+	}
+	for _, d := range []Delivery{{ID: "dlv-1", IssueID: issueID}, {ID: "dlv-2", IssueID: "other"}} {
+		if err := store.WriteJSON(store.Path("deliveries", d.ID+".json"), &d); err != nil {
+			t.Fatalf("write delivery: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	manifest, err := svc.ExportIssue(issueID, &buf)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if manifest.Tasks != 1 || manifest.Docs != 1 || manifest.Events != 1 || manifest.Deliveries != 1 || manifest.Subject != "Fix login" {
+		t.Fatalf("unexpected manifest counts: %+v", manifest)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar: %v", err)
+		}
+		b, _ := io.ReadAll(tr)
+		files[hdr.Name] = b
+	}
+	var archived IssueExportManifest
+	if err := json.Unmarshal(files["manifest.json"], &archived); err != nil || archived.Format != IssueExportFormat {
+		t.Fatalf("bad manifest.json: %v %+v", err, archived)
+	}
+	for _, want := range []string{"issue/issue.json", "issue/tasks/task-1.json", "issue/docs/spec.md", "issue/events.jsonl", "deliveries/dlv-1.json"} {
+		if _, ok := files[want]; !ok {
+			t.Fatalf("archive is missing %s (has %d files)", want, len(files))
+		}
+	}
+	if _, ok := files["deliveries/dlv-2.json"]; ok {
+		t.Fatalf("archive includes another issue's delivery")
+	}
+	for _, f := range archived.Files {
+		sum := sha256.Sum256(files[f.Path])
+		if hex.EncodeToString(sum[:]) != f.SHA256 {
+			t.Fatalf("sha256 mismatch for %s", f.Path)
+		}
+	}
+
+	if _, err := svc.ExportIssue("missing", io.Discard); err == nil {
+		t.Fatalf("expected an unknown issue to fail")
+	}
+}
@@ -0,0 +1,274 @@
+package swarm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IssueImportResult describes an issue unpacked by ImportIssue.
+type IssueImportResult struct {
+	IssueID       string            `json:"issue_id"`
+	SourceIssueID string            `json:"source_issue_id"`
+	Files         int               `json:"files"`
+	Tasks         int               `json:"tasks"`
+	Deliveries    int               `json:"deliveries"`
+	DeliveryIDs   map[string]string `json:"delivery_ids,omitempty"` // source delivery id -> imported id, when renamed
+}
+
+// maxImportBytes bounds the unpacked size of an archive so a bad upload cannot fill the disk.
+const maxImportBytes = 512 << 20
+
+// ImportIssue unpacks an archive written by ExportIssue into the store. With preserveID the issue and
+// its deliveries keep their IDs (it fails if any of them already exists); otherwise the issue gets a
+// fresh ID and its deliveries fresh ones, and every reference to the old IDs in the unpacked files is
+// rewritten. The manifest's sha256 sums are checked, issue and task files are migrated to the current
+// schema (files from a newer schema are refused), open deliveries are queued for the acceptor again and
+// an issue_imported event is logged.
+func (s *IssueService) ImportIssue(r io.Reader, preserveID bool, actor string) (*IssueImportResult, error) {
+	if actor == "" {
+		actor = "lead"
+	}
+	manifest, files, err := readIssueArchive(r)
+	if err != nil {
+		return nil, err
+	}
+
+	srcID := manifest.IssueID
+	renames := map[string]string{}
+	result := &IssueImportResult{IssueID: srcID, SourceIssueID: srcID}
+	if !preserveID {
+		result.IssueID = GenID("issue")
+		renames[srcID] = result.IssueID
+		result.DeliveryIDs = map[string]string{}
+		for name := range files {
+			if strings.HasPrefix(name, "deliveries/") {
+				id := strings.TrimSuffix(strings.TrimPrefix(name, "deliveries/"), ".json")
+				renames[id] = GenID("delivery")
+				result.DeliveryIDs[id] = renames[id]
+			}
+		}
+	}
+	rewrite := func(data []byte) []byte {
+		for from, to := range renames {
+			data = bytes.ReplaceAll(data, []byte(from), []byte(to))
+		}
+		return data
+	}
+
+	err = s.store.WithLock(func() error {
+		if s.store.Exists("issues", result.IssueID) {
+			return Errorf(CodeAlreadyExists, "issue '%s' already exists; import without preserve_id to get a fresh ID", result.IssueID)
+		}
+		// Everything is unpacked into a staging dir first and renamed into place only once all of it is
+		// written, so a failed import leaves nothing behind.
+		if err := os.MkdirAll(s.store.Root, 0o755); err != nil {
+			return err
+		}
+		staging, err := os.MkdirTemp(s.store.Root, ".import-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(staging)
+
+		var deliveryIDs []string
+		var openDeliveries []string
+		written := 0
+		for name, data := range files {
+			rel, isIssue := strings.CutPrefix(name, "issue/")
+			var dst string
+			if isIssue {
+				dst = filepath.Join(append([]string{staging, "issue"}, strings.Split(rel, "/")...)...)
+			} else {
+				// The delivery is stored under the ID its (validated) entry name carries, and must belong
+				// to the exported issue.
+				id := strings.TrimSuffix(strings.TrimPrefix(name, "deliveries/"), ".json")
+				var d Delivery
+				if err := json.Unmarshal(data, &d); err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+				if d.ID != id || d.IssueID != srcID {
+					return Errorf(CodeInvalidArgument, "%s: delivery '%s' of issue '%s' does not match the archive", name, d.ID, d.IssueID)
+				}
+				if to, ok := renames[id]; ok {
+					id = to
+				}
+				if s.store.Exists("deliveries", id+".json") {
+					return Errorf(CodeAlreadyExists, "delivery '%s' already exists; import without preserve_id to get fresh IDs", id)
+				}
+				if d.Status == DeliveryOpen {
+					openDeliveries = append(openDeliveries, id)
+				}
+				deliveryIDs = append(deliveryIDs, id)
+				result.Deliveries++
+				dst = filepath.Join(staging, "deliveries", id+".json")
+			}
+			data = rewrite(data)
+			switch {
+			case isIssue && rel == "issue.json":
+				migrated, err := migrateSchema("issue", data, issueMigrations, IssueSchemaVersion)
+				if err != nil {
+					return err
+				}
+				data = migrated
+			case isIssue && strings.HasPrefix(rel, "tasks/") && strings.HasSuffix(rel, ".json") && !strings.Contains(rel, ".docs/"):
+				migrated, err := migrateSchema("task", data, taskMigrations, TaskSchemaVersion)
+				if err != nil {
+					return err
+				}
+				data = migrated
+				result.Tasks++
+			}
+			if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(dst, data, 0o644); err != nil {
+				return err
+			}
+			written++
+		}
+		if err := s.moveImportLocked(staging, result.IssueID, deliveryIDs); err != nil {
+			return err
+		}
+		for _, id := range openDeliveries {
+			if _, err := s.pushToAcceptorInboxLocked(result.IssueID, id, actor); err != nil {
+				return err
+			}
+		}
+		result.Files = written
+		return s.appendEventLocked(result.IssueID, IssueEvent{
+			Type:      EventIssueImported,
+			IssueID:   result.IssueID,
+			Actor:     actor,
+			Detail:    fmt.Sprintf("imported from %s (%d files, exported %s)", srcID, written, manifest.ExportedAt),
+			Timestamp: NowStr(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.bump(result.IssueID)
+	return result, nil
+}
+
+// moveImportLocked renames the unpacked deliveries and issue dir from staging into the store. If a rename
+// fails, the deliveries already moved are taken out again. Must be called under store lock.
+func (s *IssueService) moveImportLocked(staging, issueID string, deliveryIDs []string) error {
+	if err := os.MkdirAll(s.store.Path("deliveries"), 0o755); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.store.Path("issues"), 0o755); err != nil {
+		return err
+	}
+	var moved []string
+	undo := func() {
+		for _, p := range moved {
+			_ = os.Remove(p)
+		}
+	}
+	for _, id := range deliveryIDs {
+		dst := s.store.Path("deliveries", id+".json")
+		if err := os.Rename(filepath.Join(staging, "deliveries", id+".json"), dst); err != nil {
+			undo()
+			return err
+		}
+		moved = append(moved, dst)
+	}
+	if err := os.Rename(filepath.Join(staging, "issue"), s.store.Path("issues", issueID)); err != nil {
+		undo()
+		return err
+	}
+	return nil
+}
+
+// readIssueArchive reads an export archive into memory and checks it against its manifest: the format,
+// that every listed file is present with the recorded sha256, that no entry escapes the issue/ tree and
+// that deliveries are deliveries/<delivery id>.json.
+func readIssueArchive(r io.Reader) (*IssueExportManifest, map[string][]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, Errorf(CodeInvalidArgument, "not a gzip archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	files := map[string][]byte{}
+	var manifest *IssueExportManifest
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, Errorf(CodeInvalidArgument, "read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		total += hdr.Size
+		if total > maxImportBytes {
+			return nil, nil, Errorf(CodeLimitExceeded, "archive too large: more than %d bytes unpacked", maxImportBytes)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read %s: %w", hdr.Name, err)
+		}
+		if hdr.Name == "manifest.json" {
+			manifest = &IssueExportManifest{}
+			if err := json.Unmarshal(data, manifest); err != nil {
+				return nil, nil, Errorf(CodeInvalidArgument, "manifest.json: %w", err)
+			}
+			continue
+		}
+		name := path.Clean(hdr.Name)
+		if name != hdr.Name || (!strings.HasPrefix(name, "issue/") && !isDeliveryEntry(name)) {
+			return nil, nil, Errorf(CodeInvalidArgument, "unexpected archive entry %q", hdr.Name)
+		}
+		files[name] = data
+	}
+	if manifest == nil {
+		return nil, nil, Errorf(CodeInvalidArgument, "archive has no manifest.json")
+	}
+	if manifest.Format != IssueExportFormat {
+		return nil, nil, Errorf(CodeInvalidArgument, "unsupported archive format %q (want %s)", manifest.Format, IssueExportFormat)
+	}
+	if !ValidID("issue", manifest.IssueID) {
+		return nil, nil, Errorf(CodeInvalidArgument, "manifest.json has no valid issue_id (got %q)", manifest.IssueID)
+	}
+	if len(manifest.Files) != len(files) {
+		return nil, nil, Errorf(CodeInvalidArgument, "archive has %d files but the manifest lists %d", len(files), len(manifest.Files))
+	}
+	for _, f := range manifest.Files {
+		data, ok := files[f.Path]
+		if !ok {
+			return nil, nil, Errorf(CodeInvalidArgument, "archive is missing %s", f.Path)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != f.SHA256 {
+			return nil, nil, Errorf(CodeInvalidArgument, "checksum mismatch for %s", f.Path)
+		}
+	}
+	if _, ok := files["issue/issue.json"]; !ok {
+		return nil, nil, Errorf(CodeInvalidArgument, "archive has no issue/issue.json")
+	}
+	return manifest, files, nil
+}
+
+// isDeliveryEntry reports whether an archive entry is deliveries/<id>.json with a well-formed delivery ID.
+func isDeliveryEntry(name string) bool {
+	rest, ok := strings.CutPrefix(name, "deliveries/")
+	if !ok {
+		return false
+	}
+	id, ok := strings.CutSuffix(rest, ".json")
+	return ok && ValidID("delivery", id)
+}
@@ -0,0 +1,160 @@
+package swarm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func exportFixture(t *testing.T, taskSchema int) (*IssueService, []byte) {
+	t.Helper()
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+	issueID := "issue_1_aaaa"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Subject: "Fix login", Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 2}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	task := map[string]any{"id": "task-1", "issue_id": issueID, "status": IssueTaskOpen, "schema_version": taskSchema}
+	if err := store.WriteJSON(store.Path("issues", issueID, "tasks", "task-1.json"), task); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("deliveries", "delivery_1_bbbb.json"), &Delivery{ID: "delivery_1_bbbb", IssueID: issueID, Status: DeliveryOpen}); err != nil {
+		t.Fatalf("write delivery: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := svc.ExportIssue(issueID, &buf); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	return svc, buf.Bytes()
+}
+
+func TestImportIssue_FreshIDsRewriteReferences(t *testing.T) {
+	svc, archive := exportFixture(t, TaskSchemaVersion)
+
+	if _, err := svc.ImportIssue(bytes.NewReader(archive), true, "lead"); err == nil {
+		t.Fatalf("expected preserve_id import over the source to fail")
+	}
+	res, err := svc.ImportIssue(bytes.NewReader(archive), false, "lead")
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if res.IssueID == res.SourceIssueID || res.Tasks != 1 || res.Deliveries != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	task, err := svc.GetTask(res.IssueID, "task-1")
+	if err != nil || task.IssueID != res.IssueID {
+		t.Fatalf("expected the task to point at the new issue, got %+v %v", task, err)
+	}
+	newDelivery := res.DeliveryIDs["delivery_1_bbbb"]
+	var d Delivery
+	if err := svc.store.ReadJSON(svc.store.Path("deliveries", newDelivery+".json"), &d); err != nil || d.IssueID != res.IssueID {
+		t.Fatalf("expected a renamed delivery for the new issue, got %+v %v", d, err)
+	}
+	queued := false
+	for _, f := range listJSONOrEmpty(svc.store, svc.store.Path("deliveries", "inbox", "acceptor")) {
+		var item InboxItem
+		if err := svc.store.ReadJSON(f, &item); err == nil && item.RefID == newDelivery {
+			queued = true
+		}
+	}
+	if !queued {
+		t.Fatalf("expected the open delivery to be queued for the acceptor")
+	}
+	events, err := svc.ReadAllEvents(res.IssueID)
+	if err != nil || len(events) == 0 || events[len(events)-1].Type != EventIssueImported {
+		t.Fatalf("expected an issue_imported event, got %+v %v", events, err)
+	}
+
+	// Preserving IDs works in a store that does not have them yet.
+	other := NewStore(t.TempDir())
+	otherSvc := NewIssueService(other, NewTraceService(other), 7200, 3600, 3600, 3600)
+	res, err = otherSvc.ImportIssue(bytes.NewReader(archive), true, "lead")
+	if err != nil || res.IssueID != "issue_1_aaaa" {
+		t.Fatalf("preserve import: %+v %v", res, err)
+	}
+}
+
+func TestImportIssue_RejectsNewerSchemaAndGarbage(t *testing.T) {
+	_, archive := exportFixture(t, TaskSchemaVersion+1)
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	var verr *SchemaVersionError
+	if _, err := svc.ImportIssue(bytes.NewReader(archive), false, "lead"); !errors.As(err, &verr) {
+		t.Fatalf("expected a schema version error, got %v", err)
+	}
+	if _, err := svc.ImportIssue(bytes.NewReader([]byte("not an archive")), false, "lead"); err == nil {
+		t.Fatalf("expected garbage input to fail")
+	}
+}
+
+func craftArchive(t *testing.T, issueID string, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("tar header: %v", err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatalf("tar write: %v", err)
+		}
+	}
+	manifest := IssueExportManifest{Format: IssueExportFormat, IssueID: issueID}
+	for name, data := range files {
+		sum := sha256.Sum256([]byte(data))
+		manifest.Files = append(manifest.Files, IssueExportFile{Path: name, SHA256: hex.EncodeToString(sum[:])})
+	}
+	m, _ := json.Marshal(manifest)
+	add("manifest.json", m)
+	for name, data := range files {
+		add(name, []byte(data))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("tar close: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestImportIssue_RejectsPathTraversal(t *testing.T) {
+	root := t.TempDir()
+	store := NewStore(filepath.Join(root, "store"))
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+	issue := `{"id":"issue_1_aaaa","status":"open"}`
+
+	cases := map[string][]byte{
+		"issue id":          craftArchive(t, "../../evil", map[string]string{"issue/issue.json": issue}),
+		"delivery body id":  craftArchive(t, "issue_1_aaaa", map[string]string{"issue/issue.json": issue, "deliveries/delivery_1_bbbb.json": `{"id":"../../evil","issue_id":"issue_1_aaaa"}`}),
+		"delivery entry":    craftArchive(t, "issue_1_aaaa", map[string]string{"issue/issue.json": issue, "deliveries/../evil.json": `{"id":"delivery_1_bbbb","issue_id":"issue_1_aaaa"}`}),
+		"foreign delivery":  craftArchive(t, "issue_1_aaaa", map[string]string{"issue/issue.json": issue, "deliveries/delivery_1_bbbb.json": `{"id":"delivery_1_bbbb","issue_id":"issue_2_cccc"}`}),
+		"bad task mid-list": craftArchive(t, "issue_1_aaaa", map[string]string{"issue/issue.json": issue, "issue/tasks/task-1.json": `{"id":"task-1","schema_version":999}`, "deliveries/delivery_1_bbbb.json": `{"id":"delivery_1_bbbb","issue_id":"issue_1_aaaa"}`}),
+	}
+	for name, archive := range cases {
+		if _, err := svc.ImportIssue(bytes.NewReader(archive), true, "lead"); err == nil {
+			t.Fatalf("%s: expected the import to fail", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "evil")); err == nil {
+		t.Fatalf("import wrote outside the store")
+	}
+	if store.Exists("issues", "issue_1_aaaa") || store.Exists("deliveries", "delivery_1_bbbb.json") {
+		t.Fatalf("a failed import left files behind")
+	}
+	if leftovers, _ := filepath.Glob(store.Path(".import-*")); len(leftovers) != 0 {
+		t.Fatalf("staging dirs left behind: %v", leftovers)
+	}
+}
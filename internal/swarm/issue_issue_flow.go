@@ -1,15 +1,21 @@
 package swarm
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 	"time"
 )
 
-func (s *IssueService) CreateIssue(actor, subject, description string, sharedDocPaths, projectDocPaths []string, userName, userContent, leadName, leadContent string, otherDocs []map[string]any) (*Issue, error) {
+// pointsBudget > 0 caps the summed points of the issue's tasks: createIssueTask rejects a task that
+// would exceed it when budgetStrict is set, and only warns otherwise.
+func (s *IssueService) CreateIssue(actor, subject, description string, sharedDocPaths, projectDocPaths []string, userName, userContent, leadName, leadContent string, otherDocs []map[string]any, pointsBudget int, budgetStrict bool) (*Issue, error) {
 	if subject == "" {
-		return nil, fmt.Errorf("subject is required")
+		return nil, Errorf(CodeInvalidArgument, "subject is required")
+	}
+	if pointsBudget < 0 {
+		return nil, Errorf(CodeInvalidArgument, "points_budget must be >= 0")
 	}
 	if actor == "" {
 		actor = "lead"
@@ -41,6 +47,8 @@ func (s *IssueService) CreateIssue(actor, subject, description string, sharedDoc
 		Docs:             nil,
 		Status:           IssueOpen,
 		LeaseExpiresAtMs: s.calcLeaseExpiryMs(0, s.issueTTLSec),
+		PointsBudget:     pointsBudget,
+		BudgetStrict:     budgetStrict && pointsBudget > 0,
 		CreatedAt:        NowStr(),
 		UpdatedAt:        NowStr(),
 	}
@@ -79,11 +87,11 @@ func (s *IssueService) CreateIssue(actor, subject, description string, sharedDoc
 			issue.Docs = append(issue.Docs, DocRef{Name: n, Path: p})
 		}
 
-		if err := s.store.WriteJSON(s.store.Path("issues", issue.ID, "issue.json"), issue); err != nil {
+		if err := s.saveIssueLocked(issue); err != nil {
 			return err
 		}
 		// Init meta
-		meta := &issueMeta{NextSeq: 1, NextTaskNum: 1}
+		meta := s.newIssueMeta(1)
 		if err := s.store.WriteJSON(s.store.Path("issues", issue.ID, "meta.json"), meta); err != nil {
 			return err
 		}
@@ -104,9 +112,10 @@ func (s *IssueService) CreateIssue(actor, subject, description string, sharedDoc
 	return issue, nil
 }
 
-func (s *IssueService) UpdateIssueDocPaths(actor, issueID string, sharedDocPaths, projectDocPaths []string) (*Issue, error) {
+// expectedRev > 0 makes the update conditional on the issue still being at that revision.
+func (s *IssueService) UpdateIssueDocPaths(actor, issueID string, sharedDocPaths, projectDocPaths []string, expectedRev int64) (*Issue, error) {
 	if issueID == "" {
-		return nil, fmt.Errorf("issue_id is required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
 	}
 	if actor == "" {
 		actor = "lead"
@@ -118,6 +127,9 @@ func (s *IssueService) UpdateIssueDocPaths(actor, issueID string, sharedDocPaths
 		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
 			return err
 		}
+		if err := checkRev("issue", issueID, expectedRev, issue.Rev); err != nil {
+			return err
+		}
 		if sharedDocPaths != nil {
 			issue.SharedDocPaths = sharedDocPaths
 		}
@@ -125,7 +137,7 @@ func (s *IssueService) UpdateIssueDocPaths(actor, issueID string, sharedDocPaths
 			issue.ProjectDocPaths = projectDocPaths
 		}
 		issue.UpdatedAt = NowStr()
-		if err := s.store.WriteJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+		if err := s.saveIssueLocked(&issue); err != nil {
 			return err
 		}
 		result = &issue
@@ -144,11 +156,10 @@ func (s *IssueService) UpdateIssueDocPaths(actor, issueID string, sharedDocPaths
 	return result, nil
 }
 
-func (s *IssueService) ReopenIssue(actor, issueID, summary string) (*Issue, error) {
+func (s *IssueService) ReopenIssue(actor, issueID, summary string, expectedRev int64) (*Issue, error) {
 	if issueID == "" {
-		return nil, fmt.Errorf("issue_id is required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
 	}
-	s.SweepExpired()
 	if actor == "" {
 		actor = "lead"
 	}
@@ -159,13 +170,16 @@ func (s *IssueService) ReopenIssue(actor, issueID, summary string) (*Issue, erro
 		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
 			return err
 		}
+		if err := checkRev("issue", issueID, expectedRev, issue.Rev); err != nil {
+			return err
+		}
 		if issue.Status != IssueDone && issue.Status != IssueCanceled {
-			return fmt.Errorf("cannot reopen issue: status must be done/canceled (status: %s)", issue.Status)
+			return Errorf(CodeInvalidState, "cannot reopen issue: status must be done/canceled (status: %s)", issue.Status)
 		}
 		issue.Status = IssueOpen
 		issue.LeaseExpiresAtMs = s.calcLeaseExpiryMs(0, s.issueTTLSec)
 		issue.UpdatedAt = NowStr()
-		if err := s.store.WriteJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+		if err := s.saveIssueLocked(&issue); err != nil {
 			return err
 		}
 		result = &issue
@@ -185,7 +199,6 @@ func (s *IssueService) ReopenIssue(actor, issueID, summary string) (*Issue, erro
 }
 
 func (s *IssueService) ListIssues() ([]Issue, error) {
-	s.SweepExpired()
 	dir := s.store.Path("issues")
 	entries, err := os.ReadDir(dir)
 	if err != nil {
@@ -210,13 +223,14 @@ func (s *IssueService) ListIssues() ([]Issue, error) {
 	return out, nil
 }
 
+// filterIssuesByStatus keeps issues in status, leaving out paused ones (nothing can be claimed there).
 func filterIssuesByStatus(issues []Issue, status string) []Issue {
 	if status == "" {
 		return issues
 	}
 	out := make([]Issue, 0, len(issues))
 	for _, it := range issues {
-		if it.Status != status {
+		if it.Status != status || it.PausedAt != "" {
 			continue
 		}
 		out = append(out, it)
@@ -228,20 +242,18 @@ func filterIssuesByStatus(issues []Issue, status string) []Issue {
 // - If issues exist immediately, returns them without waiting.
 // - status defaults to "open" if empty.
 // - If timeoutSec <= 0, defaults to 3600.
-func (s *IssueService) WaitIssues(status string, timeoutSec, limit int) ([]Issue, error) {
-	s.SweepExpired()
+func (s *IssueService) WaitIssues(ctx context.Context, status string, timeoutSec, limit int) ([]Issue, error) {
 	if strings.TrimSpace(status) == "" {
 		status = IssueOpen
 	}
-	timeoutSec = s.normalizeTimeoutSec(timeoutSec)
+	timeoutSec = s.normalizeTimeoutSec(ctx, timeoutSec)
 	if limit <= 0 {
 		limit = 50
 	}
 
 	deadline := time.Now().Add(time.Duration(timeoutSec) * time.Second)
-	poll := 200 * time.Millisecond
 	for {
-		s.SweepExpired()
+		since := s.changeGen()
 		issues, err := s.ListIssues()
 		if err != nil {
 			return nil, err
@@ -257,19 +269,16 @@ func (s *IssueService) WaitIssues(status string, timeoutSec, limit int) ([]Issue
 		if remaining <= 0 {
 			return []Issue{}, nil
 		}
-		if remaining < poll {
-			time.Sleep(remaining)
-		} else {
-			time.Sleep(poll)
+		if err := s.waitChange(ctx, since, min(remaining, pollInterval)); err != nil {
+			return nil, err
 		}
 	}
 }
 
 func (s *IssueService) GetIssue(issueID string) (*Issue, error) {
 	if issueID == "" {
-		return nil, fmt.Errorf("issue_id is required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
 	}
-	s.SweepExpired()
 	var issue Issue
 	if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
 		return nil, err
@@ -277,11 +286,10 @@ func (s *IssueService) GetIssue(issueID string) (*Issue, error) {
 	return &issue, nil
 }
 
-func (s *IssueService) CloseIssue(actor, issueID, summary string) (*Issue, error) {
+func (s *IssueService) CloseIssue(actor, issueID, summary string, expectedRev int64) (*Issue, error) {
 	if issueID == "" {
-		return nil, fmt.Errorf("issue_id is required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
 	}
-	s.SweepExpired()
 	if actor == "" {
 		actor = "lead"
 	}
@@ -298,18 +306,24 @@ func (s *IssueService) CloseIssue(actor, issueID, summary string) (*Issue, error
 		}
 	}
 	if len(notDone) > 0 {
-		return nil, fmt.Errorf("cannot close issue: tasks not done: %s", strings.Join(notDone, ", "))
+		return nil, Errorf(CodeInvalidState, "cannot close issue: tasks not done: %s", strings.Join(notDone, ", "))
 	}
 
 	var result *Issue
 	err = s.store.WithLock(func() error {
+		if uncovered := s.tasksWithoutApprovedDeliveryLocked(issueID, tasks); len(uncovered) > 0 {
+			return Errorf(CodeInvalidState, "cannot close issue: tasks not covered by an approved delivery: %s", strings.Join(uncovered, ", "))
+		}
 		var issue Issue
 		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
 			return err
 		}
+		if err := checkRev("issue", issueID, expectedRev, issue.Rev); err != nil {
+			return err
+		}
 		issue.Status = IssueDone
 		issue.UpdatedAt = NowStr()
-		if err := s.store.WriteJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+		if err := s.saveIssueLocked(&issue); err != nil {
 			return err
 		}
 		result = &issue
@@ -0,0 +1,167 @@
+package swarm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+)
+
+// Co-leading. An issue's Leads list names the leads allowed to claim its lead inbox, by the lead key of
+// the session id they call with (member ids are per process and change on restart). The list starts
+// empty, which lets any lead claim, as before; the first AddIssueLead registers the caller as owner next
+// to the added lead. From then on only registered leads are served by waitIssueTaskEvents and may change
+// the list. Session ids are the gateway credential, so only their keys are stored, logged or returned.
+
+// LeadKey derives the non-secret identity a lead is registered under from its session id.
+func LeadKey(sessionID string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(sessionID)))
+	return "lead-" + hex.EncodeToString(sum[:16])
+}
+
+// leadKeyArg accepts a lead's key as is, or derives it from the lead's session id.
+func leadKeyArg(id string) string {
+	if k, ok := strings.CutPrefix(id, "lead-"); ok && len(k) == 32 {
+		if _, err := hex.DecodeString(k); err == nil {
+			return id
+		}
+	}
+	return LeadKey(id)
+}
+
+// AddIssueLead registers leadID, a lead's session id or lead key, as a lead of issueID. caller is the
+// session id of the lead making the call; it must be registered already, unless the issue has no leads
+// yet, in which case it becomes the owner. Adding a registered lead is a no-op.
+func (s *IssueService) AddIssueLead(actor, issueID, caller, leadID string, expectedRev int64) (*Issue, error) {
+	caller, leadID = strings.TrimSpace(caller), strings.TrimSpace(leadID)
+	if issueID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
+	}
+	if leadID == "" {
+		return nil, Errorf(CodeInvalidArgument, "lead_id is required")
+	}
+	leadKey := leadKeyArg(leadID)
+	if actor == "" {
+		actor = "lead"
+	}
+
+	var result *Issue
+	changed := false
+	err := s.store.WithLock(func() error {
+		var issue Issue
+		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+			return Errorf(CodeNotFound, "issue '%s' not found", issueID)
+		}
+		if err := checkRev("issue", issueID, expectedRev, issue.Rev); err != nil {
+			return err
+		}
+		if len(issue.Leads) == 0 {
+			if caller == "" {
+				return Errorf(CodeInvalidArgument, "session_id is required to become the issue's owner")
+			}
+			issue.Leads = []string{LeadKey(caller)}
+			changed = true
+		} else if err := checkIssueLead(&issue, caller); err != nil {
+			return err
+		}
+		if !slices.Contains(issue.Leads, leadKey) {
+			issue.Leads = append(issue.Leads, leadKey)
+			changed = true
+		}
+		result = &issue
+		if !changed {
+			return nil
+		}
+		issue.UpdatedAt = NowStr()
+		if err := s.saveIssueLocked(&issue); err != nil {
+			return err
+		}
+		return s.appendEventLocked(issueID, IssueEvent{Type: EventIssueLeadAdded, IssueID: issueID, Actor: actor, Detail: leadKey, Timestamp: issue.UpdatedAt})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		s.bump(issueID)
+	}
+	return result, nil
+}
+
+// RemoveIssueLead unregisters leadID (a session id or lead key) and hands the lead inbox items it holds back to pending. The owner
+// can only be removed as the last lead, which opens the issue to any lead again.
+func (s *IssueService) RemoveIssueLead(actor, issueID, caller, leadID string, expectedRev int64) (*Issue, error) {
+	caller, leadID = strings.TrimSpace(caller), strings.TrimSpace(leadID)
+	if issueID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
+	}
+	if leadID == "" {
+		return nil, Errorf(CodeInvalidArgument, "lead_id is required")
+	}
+	leadKey := leadKeyArg(leadID)
+	if actor == "" {
+		actor = "lead"
+	}
+
+	var result *Issue
+	err := s.store.WithLock(func() error {
+		var issue Issue
+		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+			return Errorf(CodeNotFound, "issue '%s' not found", issueID)
+		}
+		if err := checkRev("issue", issueID, expectedRev, issue.Rev); err != nil {
+			return err
+		}
+		if err := checkIssueLead(&issue, caller); err != nil {
+			return err
+		}
+		i := slices.Index(issue.Leads, leadKey)
+		if i < 0 {
+			return Errorf(CodeNotFound, "'%s' is not a lead of issue '%s'", leadKey, issueID)
+		}
+		if i == 0 && len(issue.Leads) > 1 {
+			return Errorf(CodeInvalidState, "'%s' owns issue '%s'; remove the other leads first", leadKey, issueID)
+		}
+		issue.Leads = slices.Delete(issue.Leads, i, i+1)
+		issue.UpdatedAt = NowStr()
+		if err := s.saveIssueLocked(&issue); err != nil {
+			return err
+		}
+		s.releaseLeadInboxClaimsLocked(issueID, leadKey)
+		result = &issue
+		return s.appendEventLocked(issueID, IssueEvent{Type: EventIssueLeadRemoved, IssueID: issueID, Actor: actor, Detail: leadKey, Timestamp: issue.UpdatedAt})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.bump(issueID)
+	return result, nil
+}
+
+// checkIssueLead rejects callers (by session id) that are not registered leads of an issue that has a
+// lead list. The error does not name the registered leads.
+func checkIssueLead(issue *Issue, caller string) error {
+	if len(issue.Leads) == 0 || slices.Contains(issue.Leads, LeadKey(caller)) {
+		return nil
+	}
+	return Errorf(CodeNotOwner, "the calling session is not a registered lead of issue '%s'", issue.ID).
+		With("lead_key", LeadKey(caller))
+}
+
+// releaseLeadInboxClaimsLocked puts the lead inbox items claimed by the lead with leadKey back to
+// pending. Call under store lock.
+func (s *IssueService) releaseLeadInboxClaimsLocked(issueID, leadKey string) {
+	for _, f := range listJSONOrEmpty(s.store, s.store.Path("issues", issueID, "inbox", "lead")) {
+		var item InboxItem
+		if err := s.store.ReadJSON(f, &item); err != nil {
+			continue
+		}
+		if item.Status != InboxProcessing || item.ClaimedBy != leadKey {
+			continue
+		}
+		item.Status = InboxPending
+		item.ClaimedBy = ""
+		item.ClaimExpiresAtMs = 0
+		item.UpdatedAt = NowStr()
+		_ = s.store.WriteJSON(f, &item)
+	}
+}
@@ -0,0 +1,80 @@
+package swarm
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestIssueLeads_ScopeLeadInboxClaims(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 1}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	if _, err := svc.pushToLeadInboxLocked(issueID, "task-1", InboxTypeSubmission, "sub-1", "w1"); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+
+	owner, co := LeadKey("sess-owner"), LeadKey("sess-co")
+	issue, err := svc.AddIssueLead("m1", issueID, "sess-owner", co, 0)
+	if err != nil || !slices.Equal(issue.Leads, []string{owner, co}) {
+		t.Fatalf("add: %+v %v", issue, err)
+	}
+	if issue, err = svc.AddIssueLead("m1", issueID, "sess-owner", "sess-co", 0); err != nil || len(issue.Leads) != 2 {
+		t.Fatalf("adding by session id must find the same lead: %+v %v", issue, err)
+	}
+	_, err = svc.AddIssueLead("m3", issueID, "sess-stranger", "sess-stranger", 0)
+	if ErrorCode(err) != CodeNotOwner {
+		t.Fatalf("unregistered caller: got %v, want not_owner", err)
+	}
+	if msg := err.Error(); strings.Contains(msg, owner) || strings.Contains(msg, co) || strings.Contains(msg, "sess-owner") {
+		t.Fatalf("the rejection must not name the registered leads: %s", msg)
+	}
+	if se, ok := err.(*Error); !ok || se.Details["leads"] != nil {
+		t.Fatalf("the rejection must not list the leads: %#v", err)
+	}
+	events, err := svc.ReadAllEvents(issueID)
+	if err != nil {
+		t.Fatalf("events: %v", err)
+	}
+	for _, e := range events {
+		if strings.Contains(e.Detail, "sess-") {
+			t.Fatalf("events must not record session ids: %+v", e)
+		}
+	}
+	if _, err := svc.claimLeadInboxItem(issueID, "sess-stranger"); ErrorCode(err) != CodeNotOwner {
+		t.Fatalf("unregistered claim: got %v, want not_owner", err)
+	}
+	claimed, err := svc.claimLeadInboxItem(issueID, "sess-co")
+	if err != nil || claimed == nil {
+		t.Fatalf("co-lead claim: %+v %v", claimed, err)
+	}
+
+	if _, err := svc.RemoveIssueLead("m1", issueID, "sess-co", "sess-owner", 0); ErrorCode(err) != CodeInvalidState {
+		t.Fatalf("removing the owner: got %v, want invalid_state", err)
+	}
+	issue, err = svc.RemoveIssueLead("m1", issueID, "sess-owner", "sess-co", 0)
+	if err != nil || !slices.Equal(issue.Leads, []string{owner}) {
+		t.Fatalf("remove: %+v %v", issue, err)
+	}
+	// The removed lead's claim is released, so the owner is served the item right away.
+	if again, err := svc.claimLeadInboxItem(issueID, "sess-owner"); err != nil || again == nil || again.ID != claimed.ID {
+		t.Fatalf("expected the released item for the owner, got %+v %v", again, err)
+	}
+
+	if issue, err = svc.RemoveIssueLead("m1", issueID, "sess-owner", "sess-owner", 0); err != nil || len(issue.Leads) != 0 {
+		t.Fatalf("removing the last lead: %+v %v", issue, err)
+	}
+	if _, err := svc.NackInboxItem(issueID, claimed.ID, "sess-owner"); err != nil {
+		t.Fatalf("nack: %v", err)
+	}
+	if again, err := svc.claimLeadInboxItem(issueID, "sess-anyone"); err != nil || again == nil {
+		t.Fatalf("expected any lead to be served once the list is empty, got %+v %v", again, err)
+	}
+}
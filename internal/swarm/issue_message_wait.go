@@ -1,16 +1,16 @@
 package swarm
 
 import (
+	"context"
 	"fmt"
 	"strings"
-	"time"
 )
 
 // PostTaskMessage creates a TaskMessage entity and pushes it to the lead inbox.
 // kind must be "question" or "blocker". Returns a synthetic IssueEvent for API compat.
 func (s *IssueService) PostTaskMessage(issueID, taskID, actor, kind, content, refs string) (*IssueEvent, error) {
 	if issueID == "" || taskID == "" {
-		return nil, fmt.Errorf("issue_id and task_id are required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id and task_id are required")
 	}
 	if actor == "" {
 		actor = "worker"
@@ -27,10 +27,10 @@ func (s *IssueService) PostTaskMessage(issueID, taskID, actor, kind, content, re
 		}
 		if kind != "reply" {
 			if task.ClaimedBy == "" {
-				return fmt.Errorf("task '%s' is not claimed", taskID)
+				return Errorf(CodeInvalidState, "task '%s' is not claimed", taskID)
 			}
 			if strings.TrimSpace(task.ClaimedBy) != strings.TrimSpace(actor) {
-				return fmt.Errorf("task '%s' is not claimed by actor", taskID)
+				return Errorf(CodeNotOwner, "task '%s' is not claimed by actor", taskID)
 			}
 		}
 
@@ -44,7 +44,7 @@ func (s *IssueService) PostTaskMessage(issueID, taskID, actor, kind, content, re
 		if (kind == "question" || kind == "blocker") && task.Status == IssueTaskInProgress {
 			task.Status = IssueTaskBlocked
 			task.UpdatedAt = NowStr()
-			if err := s.store.WriteJSON(s.store.Path("issues", issueID, "tasks", task.ID+".json"), task); err != nil {
+			if err := s.saveTaskLocked(issueID, task); err != nil {
 				return err
 			}
 		}
@@ -85,7 +85,7 @@ func (s *IssueService) PostTaskMessage(issueID, taskID, actor, kind, content, re
 // This is the lead→worker reply path.
 func (s *IssueService) ReplyTaskMessage(issueID, taskID, actor, messageID, content, refs string) (*IssueEvent, error) {
 	if issueID == "" || taskID == "" {
-		return nil, fmt.Errorf("issue_id and task_id are required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id and task_id are required")
 	}
 	if actor == "" {
 		actor = "lead"
@@ -112,17 +112,23 @@ func (s *IssueService) ReplyTaskMessage(issueID, taskID, actor, messageID, conte
 
 		// Ack the lead inbox item for this message.
 		s.ackLeadInboxByRefLocked(issueID, msg.ID)
+		if msg.Kind == InboxTypeWorkerEscalation {
+			s.ackAcceptorEscalationLocked(msg.ID)
+		}
 
 		// Push reply to worker inbox.
 		if task.ClaimedBy != "" {
 			_, _ = s.pushToWorkerInboxLocked(issueID, task.ClaimedBy, taskID, InboxTypeReply, msg.ID, actor)
 		}
 
-		// State machine: reply → unblock back to in_progress.
-		if task.Status == IssueTaskBlocked {
-			task.Status = IssueTaskInProgress
+		// State machine: reply → unblock back to in_progress; an escalation reply also restarts the lease clock.
+		thawed := msg.Kind == InboxTypeWorkerEscalation && thawLeaseLocked(task)
+		if task.Status == IssueTaskBlocked || thawed {
+			if task.Status == IssueTaskBlocked {
+				task.Status = IssueTaskInProgress
+			}
 			task.UpdatedAt = NowStr()
-			if err := s.store.WriteJSON(s.store.Path("issues", issueID, "tasks", task.ID+".json"), task); err != nil {
+			if err := s.saveTaskLocked(issueID, task); err != nil {
 				return err
 			}
 		}
@@ -156,14 +162,14 @@ func (s *IssueService) ReplyTaskMessage(issueID, taskID, actor, messageID, conte
 
 // AskIssueTask creates a TaskMessage entity and blocks until the lead replies.
 // Returns a map with "question" (event) and "reply" (event) on success.
-func (s *IssueService) AskIssueTask(issueID, taskID, actor, kind, content, refs string, timeoutSec int) (map[string]any, error) {
+func (s *IssueService) AskIssueTask(ctx context.Context, issueID, taskID, actor, kind, content, refs string, timeoutSec int) (map[string]any, error) {
 	if kind == "" {
 		kind = "question"
 	}
 	if kind != "question" && kind != "blocker" {
-		return nil, fmt.Errorf("kind must be question or blocker")
+		return nil, Errorf(CodeInvalidArgument, "kind must be question or blocker")
 	}
-	timeoutSec = s.normalizeTimeoutSec(timeoutSec)
+	timeoutSec = s.normalizeTimeoutSec(ctx, timeoutSec)
 
 	qEvent, err := s.PostTaskMessage(issueID, taskID, actor, kind, content, refs)
 	if err != nil {
@@ -178,19 +184,21 @@ func (s *IssueService) AskIssueTask(issueID, taskID, actor, kind, content, refs
 			return nil
 		}
 		if actor != "" && task.ClaimedBy == actor {
-			nowMs := time.Now().UnixMilli()
+			nowMs := LeaseNowMs()
 			minLeaseMs := nowMs + int64(s.defaultTimeoutSec)*1000
 			if task.LeaseExpiresAtMs < minLeaseMs {
 				task.LeaseExpiresAtMs = minLeaseMs
 				task.UpdatedAt = NowStr()
-				_ = s.store.WriteJSON(s.store.Path("issues", issueID, "tasks", task.ID+".json"), task)
+				_ = s.saveTaskLocked(issueID, task)
 			}
 		}
 		return nil
 	})
 
 	// Poll the TaskMessage entity until it has a reply (entity-based, not event-scanning).
-	repliedMsg, err := s.pollMessageReply(issueID, messageID, timeoutSec)
+	stop := s.keepTaskLeaseAlive(ctx, issueID, taskID, actor)
+	defer stop()
+	repliedMsg, err := s.pollMessageReply(ctx, issueID, messageID, timeoutSec)
 	if err != nil {
 		return nil, err
 	}
@@ -217,14 +225,13 @@ func (s *IssueService) AskIssueTask(issueID, taskID, actor, kind, content, refs
 // WaitIssueTaskEvents blocks until a lead inbox item is available (submission or question/blocker).
 // Uses the inbox queue for reliable single-consumer delivery instead of event cursor scanning.
 // Returns up to 1 signal event. timeoutSec <= 0 defaults to service default.
-func (s *IssueService) WaitIssueTaskEvents(issueID, actor string, afterSeq int64, timeoutSec, limit int) ([]IssueEvent, int64, error) {
+func (s *IssueService) WaitIssueTaskEvents(ctx context.Context, issueID, actor string, afterSeq int64, timeoutSec, limit int) ([]IssueEvent, int64, error) {
 	if issueID == "" {
-		return nil, afterSeq, fmt.Errorf("issue_id is required")
+		return nil, afterSeq, Errorf(CodeInvalidArgument, "issue_id is required")
 	}
 	if !s.store.Exists("issues", issueID, "issue.json") {
-		return nil, afterSeq, fmt.Errorf("issue '%s' not found", issueID)
+		return nil, afterSeq, Errorf(CodeNotFound, "issue '%s' not found", issueID)
 	}
-	s.SweepExpired()
 	if actor == "" {
 		actor = "lead"
 	}
@@ -252,13 +259,13 @@ func (s *IssueService) WaitIssueTaskEvents(issueID, actor string, afterSeq int64
 	if allDone {
 		return []IssueEvent{}, afterSeq, nil
 	}
-	timeoutSec = s.normalizeTimeoutSec(timeoutSec)
+	timeoutSec = s.normalizeTimeoutSec(ctx, timeoutSec)
 
 	// Sweep stale inbox claims before polling.
 	s.sweepInboxClaims(issueID)
 
 	// Claim the oldest pending inbox item (blocks until found or timeout).
-	item, err := s.claimLeadInboxBlocking(issueID, actor, timeoutSec)
+	item, err := s.claimLeadInboxBlocking(ctx, issueID, actor, timeoutSec)
 	if err != nil {
 		return nil, afterSeq, err
 	}
@@ -280,6 +287,7 @@ func (s *IssueService) WaitIssueTaskEvents(issueID, actor string, afterSeq int64
 		Timestamp:    fmt.Sprint(mat["timestamp"]),
 		SubmissionID: fmt.Sprint(mat["submission_id"]),
 		MessageID:    fmt.Sprint(mat["message_id"]),
+		InboxID:      item.ID,
 	}
 	if sa, ok := mat["submission_artifacts"]; ok {
 		if saTyped, ok2 := sa.(SubmissionArtifacts); ok2 {
@@ -0,0 +1,208 @@
+package swarm
+
+import (
+	"sort"
+	"time"
+)
+
+// TaskMetrics is the timing and rework summary of one task. Durations are in seconds; zero means the
+// transition has not happened (yet).
+type TaskMetrics struct {
+	TaskID           string `json:"task_id"`
+	Subject          string `json:"subject"`
+	Status           string `json:"status"`
+	ClaimedBy        string `json:"claimed_by,omitempty"`
+	ClaimedAt        string `json:"claimed_at,omitempty"`
+	SubmittedAt      string `json:"submitted_at,omitempty"`
+	ReviewedAt       string `json:"reviewed_at,omitempty"`
+	Claims           int    `json:"claims"`
+	Submissions      int    `json:"submissions"`
+	Rejections       int    `json:"rejections"`
+	CycleTimeSec     int64  `json:"cycle_time_sec,omitempty"`     // last claim → approval
+	ReviewLatencySec int64  `json:"review_latency_sec,omitempty"` // mean submission → verdict
+	PendingReviewSec int64  `json:"pending_review_sec,omitempty"` // age of the submission awaiting review
+	SLABreaches      int    `json:"sla_breaches,omitempty"`       // submissions reviewed late or still waiting past the SLA
+}
+
+// ReviewSLAMetrics reports compliance with the configured review SLA. Compliance is the share of
+// submissions reviewed within the SLA among those reviewed or already overdue; 1 when there are none.
+type ReviewSLAMetrics struct {
+	SLASec       int     `json:"sla_sec"`
+	WithinSLA    int     `json:"within_sla"`
+	ReviewedLate int     `json:"reviewed_late"`
+	Overdue      int     `json:"overdue"` // still waiting for review past the SLA
+	Compliance   float64 `json:"compliance"`
+}
+
+// IssueMetrics aggregates TaskMetrics over an issue. Canceled tasks are left out.
+type IssueMetrics struct {
+	IssueID             string            `json:"issue_id"`
+	Tasks               []TaskMetrics     `json:"tasks"`
+	TasksDone           int               `json:"tasks_done"`
+	TotalSubmissions    int               `json:"total_submissions"`
+	TotalRejections     int               `json:"total_rejections"`
+	ReworkRate          float64           `json:"rework_rate"` // rejections per submission
+	PendingReviews      int               `json:"pending_reviews"`
+	AvgCycleTimeSec     int64             `json:"avg_cycle_time_sec"`
+	MaxCycleTimeSec     int64             `json:"max_cycle_time_sec"`
+	AvgReviewLatencySec int64             `json:"avg_review_latency_sec"`
+	MaxReviewLatencySec int64             `json:"max_review_latency_sec"`
+	ReviewSLA           *ReviewSLAMetrics `json:"review_sla,omitempty"` // nil when no SLA is configured
+	GeneratedAt         string            `json:"generated_at"`
+}
+
+// GetIssueMetrics reports per-task cycle time, review latency and rework counts for an issue, so a lead
+// can see where work stalls: waiting for review, bouncing between worker and reviewer, or sitting claimed.
+// Tasks written before transition timestamps were recorded fall back to the event log.
+func (s *IssueService) GetIssueMetrics(issueID string) (*IssueMetrics, error) {
+	if issueID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
+	}
+	if !s.store.Exists("issues", issueID, "issue.json") {
+		return nil, Errorf(CodeNotFound, "issue '%s' not found", issueID)
+	}
+	tasks, err := s.ListTasks(issueID, "")
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.ReadAllEvents(issueID)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := map[string]int{}
+	lastClaim := map[string]string{}
+	lastResolved := map[string]string{}
+	for _, ev := range events {
+		switch ev.Type {
+		case EventIssueTaskClaimed:
+			claims[ev.TaskID]++
+			lastClaim[ev.TaskID] = ev.Timestamp
+		case EventIssueTaskResolved:
+			lastResolved[ev.TaskID] = ev.Timestamp
+		}
+	}
+
+	now := time.Now().UTC()
+	m := &IssueMetrics{IssueID: issueID, Tasks: []TaskMetrics{}, GeneratedAt: now.Format(time.RFC3339)}
+	var cycleSum, latencySum int64
+	var cycleN, latencyN int
+	sla := int64(s.reviewSLASec)
+	if sla > 0 {
+		m.ReviewSLA = &ReviewSLAMetrics{SLASec: s.reviewSLASec}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool { return taskNum(tasks[i].ID) < taskNum(tasks[j].ID) })
+	for _, t := range tasks {
+		if t.Status == IssueTaskCanceled {
+			continue
+		}
+		tm := TaskMetrics{
+			TaskID:      t.ID,
+			Subject:     t.Subject,
+			Status:      t.Status,
+			ClaimedBy:   t.ClaimedBy,
+			ClaimedAt:   firstNonEmpty(t.ClaimedAt, lastClaim[t.ID]),
+			SubmittedAt: t.SubmittedAt,
+			ReviewedAt:  t.ReviewedAt,
+			Claims:      claims[t.ID],
+		}
+
+		subs, err := s.ListSubmissions(issueID, t.ID)
+		if err != nil {
+			return nil, err
+		}
+		var taskLatencySum int64
+		var taskLatencyN int
+		for _, sub := range subs {
+			tm.Submissions++
+			switch sub.Status {
+			case SubmissionRejected:
+				tm.Rejections++
+			case SubmissionOpen:
+				m.PendingReviews++
+				d := secondsBetween(sub.CreatedAt, now.Format(time.RFC3339))
+				tm.PendingReviewSec = max(tm.PendingReviewSec, d)
+				if sla > 0 && d > sla {
+					m.ReviewSLA.Overdue++
+					tm.SLABreaches++
+				}
+				continue
+			}
+			// Submissions reviewed before reviewed_at existed were last written by the review.
+			if d := secondsBetween(sub.CreatedAt, firstNonEmpty(sub.ReviewedAt, sub.UpdatedAt)); d >= 0 {
+				taskLatencySum += d
+				taskLatencyN++
+				latencySum += d
+				latencyN++
+				m.MaxReviewLatencySec = max(m.MaxReviewLatencySec, d)
+				switch {
+				case sla <= 0:
+				case d > sla:
+					m.ReviewSLA.ReviewedLate++
+					tm.SLABreaches++
+				default:
+					m.ReviewSLA.WithinSLA++
+				}
+			}
+		}
+		if taskLatencyN > 0 {
+			tm.ReviewLatencySec = taskLatencySum / int64(taskLatencyN)
+		}
+
+		if t.Status == IssueTaskDone {
+			m.TasksDone++
+			doneAt := lastResolved[t.ID]
+			if t.Verdict == VerdictApproved && t.ReviewedAt != "" {
+				doneAt = t.ReviewedAt
+			}
+			if d := secondsBetween(tm.ClaimedAt, doneAt); d > 0 {
+				tm.CycleTimeSec = d
+				cycleSum += d
+				cycleN++
+				m.MaxCycleTimeSec = max(m.MaxCycleTimeSec, d)
+			}
+		}
+
+		m.TotalSubmissions += tm.Submissions
+		m.TotalRejections += tm.Rejections
+		m.Tasks = append(m.Tasks, tm)
+	}
+	if cycleN > 0 {
+		m.AvgCycleTimeSec = cycleSum / int64(cycleN)
+	}
+	if latencyN > 0 {
+		m.AvgReviewLatencySec = latencySum / int64(latencyN)
+	}
+	if r := m.ReviewSLA; r != nil {
+		r.Compliance = 1
+		if total := r.WithinSLA + r.ReviewedLate + r.Overdue; total > 0 {
+			r.Compliance = float64(r.WithinSLA) / float64(total)
+		}
+	}
+	if m.TotalSubmissions > 0 {
+		m.ReworkRate = float64(m.TotalRejections) / float64(m.TotalSubmissions)
+	}
+	return m, nil
+}
+
+// secondsBetween returns to-from in whole seconds, or -1 if either timestamp is missing or unparsable.
+func secondsBetween(from, to string) int64 {
+	a, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		return -1
+	}
+	b, err := time.Parse(time.RFC3339, to)
+	if err != nil {
+		return -1
+	}
+	return int64(b.Sub(a) / time.Second)
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
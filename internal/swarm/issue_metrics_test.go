@@ -0,0 +1,67 @@
+package swarm
+
+import "testing"
+
+func TestGetIssueMetrics_CycleTimeReviewLatencyAndRework(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 4}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	tasks := []*IssueTask{
+		{ID: "task-1", IssueID: issueID, Status: IssueTaskDone, ClaimedBy: "w1", Verdict: VerdictApproved,
+			ClaimedAt: "2024-01-01T10:00:00Z", SubmittedAt: "2024-01-01T11:00:00Z", ReviewedAt: "2024-01-01T11:30:00Z"},
+		{ID: "task-2", IssueID: issueID, Status: IssueTaskInProgress, ClaimedBy: "w2", ClaimedAt: "2024-01-01T10:00:00Z"},
+		{ID: "task-3", IssueID: issueID, Status: IssueTaskCanceled},
+	}
+	for _, task := range tasks {
+		if err := svc.saveTaskLocked(issueID, task); err != nil {
+			t.Fatalf("write task: %v", err)
+		}
+	}
+	subs := []Submission{
+		{ID: "sub-1", TaskID: "task-1", Status: SubmissionRejected, CreatedAt: "2024-01-01T10:30:00Z", ReviewedAt: "2024-01-01T10:40:00Z"},
+		{ID: "sub-2", TaskID: "task-1", Status: SubmissionApproved, CreatedAt: "2024-01-01T11:00:00Z", ReviewedAt: "2024-01-01T11:30:00Z"},
+		{ID: "sub-3", TaskID: "task-2", Status: SubmissionOpen, CreatedAt: "2024-01-01T10:30:00Z"},
+	}
+	for _, sub := range subs {
+		sub.IssueID = issueID
+		if err := store.WriteJSON(store.Path("issues", issueID, "submissions", sub.TaskID, sub.ID+".json"), &sub); err != nil {
+			t.Fatalf("write submission: %v", err)
+		}
+	}
+	for _, taskID := range []string{"task-1", "task-2", "task-2"} {
+		if err := svc.appendEventLocked(issueID, IssueEvent{Type: EventIssueTaskClaimed, IssueID: issueID, TaskID: taskID, Timestamp: "2024-01-01T10:00:00Z"}); err != nil {
+			t.Fatalf("append event: %v", err)
+		}
+	}
+
+	m, err := svc.GetIssueMetrics(issueID)
+	if err != nil {
+		t.Fatalf("metrics: %v", err)
+	}
+	if len(m.Tasks) != 2 {
+		t.Fatalf("expected canceled task left out, got %+v", m.Tasks)
+	}
+	t1, t2 := m.Tasks[0], m.Tasks[1]
+	if t1.CycleTimeSec != 5400 || t1.Submissions != 2 || t1.Rejections != 1 || t1.Claims != 1 {
+		t.Fatalf("unexpected task-1 metrics: %+v", t1)
+	}
+	if t1.ReviewLatencySec != 1200 { // (600 + 1800) / 2
+		t.Fatalf("expected mean review latency 1200, got %d", t1.ReviewLatencySec)
+	}
+	if t2.CycleTimeSec != 0 || t2.Claims != 2 || t2.PendingReviewSec <= 0 {
+		t.Fatalf("unexpected task-2 metrics: %+v", t2)
+	}
+	if m.TasksDone != 1 || m.TotalSubmissions != 3 || m.TotalRejections != 1 || m.PendingReviews != 1 {
+		t.Fatalf("unexpected totals: %+v", m)
+	}
+	if m.AvgCycleTimeSec != 5400 || m.MaxReviewLatencySec != 1800 || m.AvgReviewLatencySec != 1200 {
+		t.Fatalf("unexpected aggregates: %+v", m)
+	}
+}
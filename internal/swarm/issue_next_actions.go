@@ -0,0 +1,83 @@
+package swarm
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Issue-specific next_actions. A lead can replace the coaching lines of any next_actions key for one
+// issue, so an unusual issue can steer its workers differently without touching the server-wide config
+// files. The server prefers these over the global files for calls about the issue. They are stored in
+// issues/<id>/config/next_actions.json as key -> lines.
+
+var nextActionsKeyPattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+func (s *IssueService) issueNextActionsPath(issueID string) string {
+	return s.store.Path("issues", issueID, "config", "next_actions.json")
+}
+
+// SetIssueNextActions sets the lines of key for issueID; no (non-blank) lines remove the override.
+// Returns all overrides of the issue afterwards.
+func (s *IssueService) SetIssueNextActions(issueID, key string, lines []string) (map[string][]string, error) {
+	if issueID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
+	}
+	key = strings.TrimSpace(key)
+	if !nextActionsKeyPattern.MatchString(key) {
+		return nil, Errorf(CodeInvalidArgument, "invalid next_actions key %q: use lowercase letters, digits and _ (e.g. worker_after_claim)", key)
+	}
+	var kept []string
+	for _, ln := range lines {
+		if ln = strings.TrimSpace(ln); ln != "" {
+			kept = append(kept, ln)
+		}
+	}
+
+	var out map[string][]string
+	err := s.store.WithLock(func() error {
+		var issue Issue
+		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+			return Errorf(CodeNotFound, "issue '%s' not found", issueID)
+		}
+		overrides, err := s.readIssueNextActions(issueID)
+		if err != nil {
+			return err
+		}
+		if len(kept) == 0 {
+			delete(overrides, key)
+		} else {
+			overrides[key] = kept
+		}
+		out = overrides
+		if len(overrides) == 0 {
+			if err := s.store.Remove(s.issueNextActionsPath(issueID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			return nil
+		}
+		return s.store.WriteJSON(s.issueNextActionsPath(issueID), overrides)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IssueNextActions returns the next_actions overrides of issueID; empty when it has none.
+func (s *IssueService) IssueNextActions(issueID string) (map[string][]string, error) {
+	if issueID == "" {
+		return map[string][]string{}, nil
+	}
+	return s.readIssueNextActions(issueID)
+}
+
+func (s *IssueService) readIssueNextActions(issueID string) (map[string][]string, error) {
+	overrides := map[string][]string{}
+	err := s.store.ReadJSON(s.issueNextActionsPath(issueID), &overrides)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, Errorf(CodeInternal, "read next_actions of issue '%s': %w", issueID, err)
+	}
+	return overrides, nil
+}
@@ -0,0 +1,45 @@
+package swarm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIssueNextActions_SetAndRemove(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 1, 1)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+
+	if _, err := svc.SetIssueNextActions(issueID, "Worker After Claim", []string{"x"}); ErrorCode(err) != CodeInvalidArgument {
+		t.Fatalf("bad key: got %v, want invalid_argument", err)
+	}
+	if _, err := svc.SetIssueNextActions("issue-missing", "worker_after_claim", []string{"x"}); ErrorCode(err) != CodeNotFound {
+		t.Fatalf("missing issue: got %v, want not_found", err)
+	}
+
+	got, err := svc.SetIssueNextActions(issueID, "worker_after_claim", []string{" Run the migration dry-run first. ", "", "Then implement {{task_id}}."})
+	if err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	want := map[string][]string{"worker_after_claim": {"Run the migration dry-run first.", "Then implement {{task_id}}."}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("set returned %v, want %v", got, want)
+	}
+	if got, _ := svc.IssueNextActions(issueID); !reflect.DeepEqual(got, want) {
+		t.Fatalf("stored %v, want %v", got, want)
+	}
+
+	if got, err = svc.SetIssueNextActions(issueID, "worker_after_claim", nil); err != nil || len(got) != 0 {
+		t.Fatalf("remove: got %v, %v", got, err)
+	}
+	if store.Exists("issues", issueID, "config", "next_actions.json") {
+		t.Fatalf("override file left behind after removing the last key")
+	}
+	if _, err := svc.SetIssueNextActions(issueID, "worker_after_claim", nil); err != nil {
+		t.Fatalf("removing an absent override: %v", err)
+	}
+}
@@ -3,12 +3,11 @@ package swarm
 import (
 	"fmt"
 	"sort"
-	"time"
 )
 
 func (s *IssueService) loadIssueWorkerStateLocked(issueID, workerID string) (*IssueWorkerState, error) {
 	if issueID == "" || workerID == "" {
-		return nil, fmt.Errorf("issue_id and worker_id are required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id and worker_id are required")
 	}
 	path := s.store.Path("issues", issueID, "workers", workerID+".json")
 	var st IssueWorkerState
@@ -39,14 +38,13 @@ func downgradeDifficulty(d string) string {
 	}
 }
 
-func baseDifficultyByPoints(total int) string {
-	if total >= 30 {
-		return "focus"
-	}
-	if total >= 10 {
+func upgradeDifficulty(d string) string {
+	switch d {
+	case "easy":
 		return "medium"
+	default:
+		return "focus"
 	}
-	return "easy"
 }
 
 func difficultyFallbackOrder(d string) []string {
@@ -60,7 +58,7 @@ func difficultyFallbackOrder(d string) []string {
 	}
 }
 
-func pickTaskByTier(tasks []*IssueTask, totalPoints int) *IssueTask {
+func pickTaskByTier(tasks []*IssueTask, totalPoints int, p ProgressionPolicy) *IssueTask {
 	if len(tasks) == 0 {
 		return nil
 	}
@@ -70,10 +68,10 @@ func pickTaskByTier(tasks []*IssueTask, totalPoints int) *IssueTask {
 		}
 		return tasks[i].ID < tasks[j].ID
 	})
-	if totalPoints >= 100 {
+	if totalPoints >= p.PickHighestMinPoints {
 		return tasks[0]
 	}
-	if totalPoints >= 30 && totalPoints < 50 {
+	if totalPoints >= p.PickLowestMinPoints && totalPoints < p.PickLowestMaxPoints {
 		return tasks[len(tasks)-1]
 	}
 	return tasks[len(tasks)/2]
@@ -81,20 +79,16 @@ func pickTaskByTier(tasks []*IssueTask, totalPoints int) *IssueTask {
 
 func (s *IssueService) GetNextStepToken(issueID, actor, justFinishedTaskID, workerID string, completionScore int) (map[string]any, error) {
 	if issueID == "" || workerID == "" || justFinishedTaskID == "" {
-		return nil, fmt.Errorf("issue_id, task_id and worker_id are required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id, task_id and worker_id are required")
 	}
 	if actor == "" {
 		actor = "lead"
 	}
-	if completionScore != 1 && completionScore != 2 && completionScore != 5 {
-		return nil, fmt.Errorf("invalid completion_score: %d", completionScore)
+	policy := s.policy
+	if err := policy.checkCompletionScore(completionScore); err != nil {
+		return nil, err
 	}
 
-	const (
-		bufferLevel1 = 50
-		bufferLevel2 = 100
-	)
-
 	var out map[string]any
 	err := s.store.WithLock(func() error {
 		st, err := s.loadIssueWorkerStateLocked(issueID, workerID)
@@ -107,18 +101,12 @@ func (s *IssueService) GetNextStepToken(issueID, actor, justFinishedTaskID, work
 		}
 		st.TotalPoints += finished.Points
 
-		base := baseDifficultyByPoints(st.TotalPoints)
+		base := policy.baseDifficulty(st.TotalPoints)
 		nextDifficulty := base
 
-		if completionScore < 2 {
+		if completionScore < policy.LowScoreBelow {
 			st.ConsecutiveLowScores++
-			allowedFailures := 0
-			if st.TotalPoints >= bufferLevel2 {
-				allowedFailures = 2
-			} else if st.TotalPoints >= bufferLevel1 {
-				allowedFailures = 1
-			}
-			if st.ConsecutiveLowScores > allowedFailures {
+			if st.ConsecutiveLowScores > policy.allowedFailures(st.TotalPoints) {
 				nextDifficulty = downgradeDifficulty(base)
 			}
 		} else {
@@ -129,6 +117,7 @@ func (s *IssueService) GetNextStepToken(issueID, actor, justFinishedTaskID, work
 			return err
 		}
 
+		nowMs := LeaseNowMs()
 		var chosen *IssueTask
 		for _, d := range difficultyFallbackOrder(nextDifficulty) {
 			tasksDir := s.store.Path("issues", issueID, "tasks")
@@ -145,9 +134,12 @@ func (s *IssueService) GetNextStepToken(issueID, actor, justFinishedTaskID, work
 				if t.Status != IssueTaskOpen || t.Difficulty != d {
 					continue
 				}
+				if t.ReservedToken != "" && (t.ReservedUntilMs == 0 || nowMs <= t.ReservedUntilMs) {
+					continue // e.g. already assigned by the scheduler
+				}
 				candidates = append(candidates, &t)
 			}
-			chosen = pickTaskByTier(candidates, st.TotalPoints)
+			chosen = pickTaskByTier(candidates, st.TotalPoints, policy)
 			if chosen != nil {
 				break
 			}
@@ -164,17 +156,16 @@ func (s *IssueService) GetNextStepToken(issueID, actor, justFinishedTaskID, work
 			return nil
 		}
 
-		nowMs := time.Now().UnixMilli()
 		const reserveTTL = int64(2 * 60 * 1000)
 		live, err := s.loadTaskLocked(issueID, chosen.ID)
 		if err != nil {
 			return err
 		}
 		if live.Status != IssueTaskOpen {
-			return fmt.Errorf("next_step task '%s' is not open (status: %s)", live.ID, live.Status)
+			return Errorf(CodeInvalidState, "next_step task '%s' is not open (status: %s)", live.ID, live.Status)
 		}
 		if live.ReservedToken != "" && live.ReservedUntilMs > 0 && nowMs <= live.ReservedUntilMs {
-			return fmt.Errorf("next_step task '%s' is reserved", live.ID)
+			return Errorf(CodeReserved, "next_step task '%s' is reserved", live.ID)
 		}
 
 		tok.NextStep = NextStep{Type: "claim_task", TaskID: live.ID}
@@ -186,7 +177,7 @@ func (s *IssueService) GetNextStepToken(issueID, actor, justFinishedTaskID, work
 		live.ReservedToken = tok.Token
 		live.ReservedUntilMs = nowMs + reserveTTL
 		live.UpdatedAt = NowStr()
-		if err := s.store.WriteJSON(s.store.Path("issues", issueID, "tasks", live.ID+".json"), live); err != nil {
+		if err := s.saveTaskLocked(issueID, live); err != nil {
 			return err
 		}
 
@@ -201,7 +192,7 @@ func (s *IssueService) GetNextStepToken(issueID, actor, justFinishedTaskID, work
 
 func (s *IssueService) ReadNextStepToken(issueID, token string) (*NextStepToken, error) {
 	if issueID == "" || token == "" {
-		return nil, fmt.Errorf("issue_id and token are required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id and token are required")
 	}
 	var out *NextStepToken
 	err := s.store.WithLock(func() error {
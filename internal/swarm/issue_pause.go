@@ -0,0 +1,122 @@
+package swarm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PauseIssue freezes an open issue, e.g. during a production incident: its tasks stop showing up in
+// WaitIssueTasks/WaitIssues, new claims are refused and the expiry sweep leaves the issue and its task
+// leases alone. Work already claimed is not touched. ResumeIssue undoes it.
+func (s *IssueService) PauseIssue(actor, issueID, reason string, expectedRev int64) (*Issue, error) {
+	if issueID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
+	}
+	if actor == "" {
+		actor = "lead"
+	}
+	reason = strings.TrimSpace(reason)
+
+	var result *Issue
+	err := s.store.WithLock(func() error {
+		var issue Issue
+		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+			return err
+		}
+		if err := checkRev("issue", issueID, expectedRev, issue.Rev); err != nil {
+			return err
+		}
+		if issue.Status != IssueOpen && issue.Status != IssueInProgress {
+			return Errorf(CodeInvalidState, "cannot pause issue: status must be open/in_progress (status: %s)", issue.Status)
+		}
+		if issue.PausedAt != "" {
+			return Errorf(CodeInvalidState, "issue '%s' is already paused since %s", issueID, issue.PausedAt)
+		}
+		issue.PausedAt = leaseNow().Format(time.RFC3339)
+		issue.PauseReason = reason
+		issue.UpdatedAt = issue.PausedAt
+		if err := s.saveIssueLocked(&issue); err != nil {
+			return err
+		}
+		result = &issue
+		return s.appendEventLocked(issueID, IssueEvent{Type: EventIssuePaused, IssueID: issueID, Actor: actor, Detail: reason, Timestamp: issue.PausedAt})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.bump(issueID)
+	return result, nil
+}
+
+// ResumeIssue lifts a pause. The issue lease and the leases of claimed tasks are pushed back by the time
+// spent paused, so nobody loses their claim because of the freeze.
+func (s *IssueService) ResumeIssue(actor, issueID string, expectedRev int64) (*Issue, error) {
+	if issueID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
+	}
+	if actor == "" {
+		actor = "lead"
+	}
+
+	var result *Issue
+	err := s.store.WithLock(func() error {
+		var issue Issue
+		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+			return err
+		}
+		if err := checkRev("issue", issueID, expectedRev, issue.Rev); err != nil {
+			return err
+		}
+		if issue.PausedAt == "" {
+			return Errorf(CodeInvalidState, "issue '%s' is not paused", issueID)
+		}
+		var pausedMs int64
+		if t, err := time.Parse(time.RFC3339, issue.PausedAt); err == nil {
+			pausedMs = max(LeaseNowMs()-t.UnixMilli(), 0)
+		}
+		detail := fmt.Sprintf("paused for %s", (time.Duration(pausedMs) * time.Millisecond).Round(time.Second))
+
+		if issue.LeaseExpiresAtMs > 0 {
+			issue.LeaseExpiresAtMs += pausedMs
+		}
+		issue.PausedAt = ""
+		issue.PauseReason = ""
+		issue.UpdatedAt = NowStr()
+		if err := s.saveIssueLocked(&issue); err != nil {
+			return err
+		}
+		for _, f := range listJSONOrEmpty(s.store, s.store.Path("issues", issueID, "tasks")) {
+			var task IssueTask
+			if err := s.store.ReadJSON(f, &task); err != nil {
+				continue
+			}
+			// A frozen lease gets the whole frozen time back when the escalation is answered.
+			if (task.Status != IssueTaskInProgress && task.Status != IssueTaskBlocked) || task.LeaseExpiresAtMs <= 0 || task.LeaseFrozenAt != "" {
+				continue
+			}
+			task.LeaseExpiresAtMs += pausedMs
+			task.UpdatedAt = issue.UpdatedAt
+			if err := s.saveTaskLocked(issueID, &task); err != nil {
+				return err
+			}
+			s.trackReviewDeadline(issueID, task.ID, 0)
+		}
+		result = &issue
+		return s.appendEventLocked(issueID, IssueEvent{Type: EventIssueResumed, IssueID: issueID, Actor: actor, Detail: detail, Timestamp: issue.UpdatedAt})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.bump(issueID)
+	return result, nil
+}
+
+// issuePaused reports whether issueID is currently paused (false if it cannot be read).
+func (s *IssueService) issuePaused(issueID string) bool {
+	var issue Issue
+	if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+		return false
+	}
+	return issue.PausedAt != ""
+}
@@ -0,0 +1,70 @@
+package swarm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPauseIssue_FreezesClaimsAndExpiry(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 1, 1)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 3}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	expiredMs := time.Now().Add(-time.Minute).UnixMilli()
+	claimed := &IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskInProgress, ClaimedBy: "w1", LeaseExpiresAtMs: expiredMs}
+	open := &IssueTask{ID: "task-2", IssueID: issueID, Status: IssueTaskOpen}
+	for _, task := range []*IssueTask{claimed, open} {
+		if err := svc.saveTaskLocked(issueID, task); err != nil {
+			t.Fatalf("write task: %v", err)
+		}
+	}
+
+	// Pausing must not run into the expired lease first: write the pause directly, as if it happened
+	// before the lease ran out.
+	if err := svc.saveIssueLocked(&Issue{ID: issueID, Status: IssueOpen, PausedAt: time.Now().Add(-2 * time.Minute).UTC().Format(time.RFC3339), Rev: 1}); err != nil {
+		t.Fatalf("pause issue: %v", err)
+	}
+	svc.SweepExpired()
+	task, err := svc.GetTask(issueID, "task-1")
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if task.Status != IssueTaskInProgress || task.ClaimedBy != "w1" {
+		t.Fatalf("expected claimed task to survive the sweep while paused, got %+v", task)
+	}
+	if _, err := svc.ClaimTask(issueID, "task-2", "w2", "", 0); err == nil || !strings.Contains(err.Error(), "paused") {
+		t.Fatalf("expected claim to be refused, got %v", err)
+	}
+	if tasks, err := svc.WaitIssueTasks(context.Background(), issueID, IssueTaskOpen, TaskFilter{}, 1, 10); err != nil || len(tasks) != 0 {
+		t.Fatalf("expected no tasks while paused, got %v (%v)", tasks, err)
+	}
+	if _, err := svc.PauseIssue("lead", issueID, "again", 0); err == nil {
+		t.Fatalf("expected error pausing a paused issue")
+	}
+
+	issue, err := svc.ResumeIssue("lead", issueID, 0)
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if issue.PausedAt != "" {
+		t.Fatalf("expected pause cleared, got %+v", issue)
+	}
+	task, err = svc.GetTask(issueID, "task-1")
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if task.Status != IssueTaskInProgress || task.LeaseExpiresAtMs <= time.Now().UnixMilli() {
+		t.Fatalf("expected lease pushed back by the pause, got %+v", task)
+	}
+	if _, err := svc.ClaimTask(issueID, "task-2", "w2", "", 0); err != nil {
+		t.Fatalf("claim after resume: %v", err)
+	}
+}
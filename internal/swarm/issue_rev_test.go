@@ -0,0 +1,31 @@
+package swarm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIssueService_ExpectedRev(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 3600, 3600, 3600, 3600)
+	if err := store.WriteJSON(store.Path("issues", "i1", "issue.json"), &Issue{ID: "i1", Status: IssueOpen}); err != nil {
+		t.Fatalf("seed issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", "i1", "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 1}); err != nil {
+		t.Fatalf("seed meta: %v", err)
+	}
+
+	issue, err := svc.UpdateIssueDocPaths("lead", "i1", []string{"a.md"}, nil, 0)
+	if err != nil || issue.Rev != 1 {
+		t.Fatalf("unconditional update: %+v %v", issue, err)
+	}
+	_, err = svc.UpdateIssueDocPaths("lead", "i1", []string{"b.md"}, nil, 5)
+	var conflict *RevisionConflictError
+	if !errors.As(err, &conflict) || conflict.CurrentRev != 1 || conflict.Kind != "issue" {
+		t.Fatalf("expected rev conflict, got %v", err)
+	}
+	issue, err = svc.UpdateIssueDocPaths("lead", "i1", []string{"c.md"}, nil, 1)
+	if err != nil || issue.Rev != 2 || issue.SharedDocPaths[0] != "c.md" {
+		t.Fatalf("conditional update: %+v %v", issue, err)
+	}
+}
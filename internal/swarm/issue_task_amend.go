@@ -0,0 +1,142 @@
+package swarm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Spec amendments. Once a task is out, its spec doc is only appended to: AmendTaskSpec adds a
+// timestamped entry under a "## Changelog" heading at the end of the doc, naming the spec section it
+// clarifies, and writes it as a new doc version. Each amendment is also kept as a record in
+// issues/<id>/amendments/<amendment_id>.json, logged as an issue_task_spec_amended event and pushed to
+// the worker holding the task, so a clarification is never a silent rewrite.
+
+const specChangelogHeading = "## Changelog"
+
+// SpecAmendment is one clarification appended to a task's spec doc.
+type SpecAmendment struct {
+	ID         string `json:"id"`
+	IssueID    string `json:"issue_id"`
+	TaskID     string `json:"task_id"`
+	Doc        string `json:"doc"`
+	DocVersion int    `json:"doc_version"`
+	Section    string `json:"section"`
+	Content    string `json:"content"`
+	Actor      string `json:"actor"`
+	NotifiedTo string `json:"notified_to,omitempty"` // the claimant the amendment was pushed to
+	CreatedAt  string `json:"created_at"`
+}
+
+// AmendTaskSpec appends content as an amendment of section to the spec doc of a task that is not done or
+// canceled yet. section must name one of the spec's "## " headings (case-insensitive).
+func (s *IssueService) AmendTaskSpec(actor, issueID, taskID, section, content string) (*SpecAmendment, error) {
+	section, content = strings.TrimSpace(section), strings.TrimSpace(content)
+	if issueID == "" || taskID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id and task_id are required")
+	}
+	if section == "" || content == "" {
+		return nil, Errorf(CodeInvalidArgument, "section and content are required")
+	}
+	if actor == "" {
+		actor = "lead"
+	}
+
+	var result *SpecAmendment
+	err := s.store.WithLock(func() error {
+		task, err := s.loadTaskLocked(issueID, taskID)
+		if err != nil {
+			return err
+		}
+		if task.Status == IssueTaskDone || task.Status == IssueTaskCanceled {
+			return Errorf(CodeInvalidState, "cannot amend the spec of a %s task; reopen it first", task.Status)
+		}
+		if len(task.RequiredTaskDocs) == 0 {
+			return Errorf(CodeNotFound, "task '%s' has no spec doc", taskID)
+		}
+		docName := task.RequiredTaskDocs[0]
+		dir := s.store.Path("issues", issueID, "tasks", taskID+".docs")
+		b, err := os.ReadFile(filepath.Join(dir, filepath.Clean(docName)+".md"))
+		if err != nil {
+			return Errorf(CodeNotFound, "spec doc '%s' of task '%s' not found", docName, taskID)
+		}
+		spec := string(b)
+		heading, ok := specSection(spec, section)
+		if !ok {
+			return Errorf(CodeInvalidArgument, "spec of task '%s' has no section '%s'", taskID, section).
+				With("sections", specSections(spec))
+		}
+
+		now := NowStr()
+		var sb strings.Builder
+		sb.WriteString(strings.TrimRight(spec, "\n"))
+		sb.WriteString("\n\n")
+		if !strings.Contains(spec, "\n"+specChangelogHeading+"\n") {
+			sb.WriteString(specChangelogHeading + "\n\n")
+		}
+		fmt.Fprintf(&sb, "### %s · %s (by %s)\n\n%s\n", now, heading, actor, content)
+		written, err := NewDocsService(s.store).writeVersionedLocked(dir, docName, sb.String(), 0, 0)
+		if err != nil {
+			return err
+		}
+
+		a := &SpecAmendment{
+			ID:         GenID("amd"),
+			IssueID:    issueID,
+			TaskID:     taskID,
+			Doc:        docName,
+			DocVersion: written.Version,
+			Section:    heading,
+			Content:    content,
+			Actor:      actor,
+			NotifiedTo: task.ClaimedBy,
+			CreatedAt:  now,
+		}
+		if err := s.store.WriteJSON(s.store.Path("issues", issueID, "amendments", a.ID+".json"), a); err != nil {
+			return err
+		}
+		if task.ClaimedBy != "" {
+			if _, err := s.pushToWorkerInboxLocked(issueID, task.ClaimedBy, taskID, InboxTypeSpecAmended, a.ID, actor); err != nil {
+				return err
+			}
+		}
+		result = a
+		return s.appendEventLocked(issueID, IssueEvent{
+			Type:      EventIssueTaskSpecAmended,
+			IssueID:   issueID,
+			TaskID:    taskID,
+			Actor:     actor,
+			Kind:      heading,
+			Detail:    content,
+			Refs:      fmt.Sprintf("task_doc:%s@v%d", docName, written.Version),
+			Timestamp: now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.bump(issueID)
+	return result, nil
+}
+
+// specSections lists the "## " headings of a spec doc, without the changelog.
+func specSections(spec string) []string {
+	var out []string
+	for _, line := range strings.Split(spec, "\n") {
+		if name, ok := strings.CutPrefix(line, "## "); ok && line != specChangelogHeading {
+			out = append(out, strings.TrimSpace(name))
+		}
+	}
+	return out
+}
+
+// specSection returns the spec heading that matches section.
+func specSection(spec, section string) (string, bool) {
+	for _, name := range specSections(spec) {
+		if strings.EqualFold(name, section) {
+			return name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,76 @@
+package swarm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAmendTaskSpec(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 2}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	task := &IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskInProgress, ClaimedBy: "w1", RequiredTaskDocs: []string{"spec"}}
+	if err := svc.saveTaskLocked(issueID, task); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	docsDir := store.Path("issues", issueID, "tasks", "task-1.docs")
+	if err := writeDocFile(docsDir, "spec.md", "# Spec\n\n## Goal\nship it\n\n## Acceptance Criteria\ntests pass\n"); err != nil {
+		t.Fatalf("write spec: %v", err)
+	}
+
+	if _, err := svc.AmendTaskSpec("lead", issueID, "task-1", "Deadline", "friday"); ErrorCode(err) != CodeInvalidArgument {
+		t.Fatalf("expected unknown section to be rejected, got %v", err)
+	}
+	first, err := svc.AmendTaskSpec("lead", issueID, "task-1", "acceptance criteria", "also lint")
+	if err != nil {
+		t.Fatalf("amend: %v", err)
+	}
+	if first.Section != "Acceptance Criteria" || first.DocVersion != 2 || first.NotifiedTo != "w1" {
+		t.Fatalf("unexpected amendment: %+v", first)
+	}
+	second, err := svc.AmendTaskSpec("lead", issueID, "task-1", "Goal", "and document it")
+	if err != nil {
+		t.Fatalf("amend again: %v", err)
+	}
+
+	docs := NewDocsService(store)
+	spec, err := docs.ReadTaskDoc(issueID, "task-1", "spec")
+	if err != nil {
+		t.Fatalf("read spec: %v", err)
+	}
+	if !strings.HasPrefix(spec, "# Spec\n\n## Goal\nship it\n") || strings.Count(spec, specChangelogHeading) != 1 ||
+		!strings.Contains(spec, "· Acceptance Criteria (by lead)\n\nalso lint\n") || !strings.HasSuffix(spec, "· Goal (by lead)\n\nand document it\n") {
+		t.Fatalf("unexpected spec after amendments:\n%s", spec)
+	}
+	versions, err := docs.ListDocVersions(DocTarget{Scope: DocScopeTask, IssueID: issueID, TaskID: "task-1", Name: "spec"})
+	if err != nil || len(versions) != 3 {
+		t.Fatalf("expected the original and two amended versions, got %v (%v)", versions, err)
+	}
+
+	items, err := svc.GetWorkerInbox("w1", issueID, false, true)
+	if err != nil {
+		t.Fatalf("worker inbox: %v", err)
+	}
+	got := map[any]any{}
+	for _, item := range items {
+		got[item["amendment_id"]] = item["content"]
+	}
+	if len(items) != 2 || got[first.ID] != "also lint" || got[second.ID] != "and document it" {
+		t.Fatalf("unexpected worker inbox: %+v", items)
+	}
+
+	task.Status = IssueTaskDone
+	if err := svc.saveTaskLocked(issueID, task); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	if _, err := svc.AmendTaskSpec("lead", issueID, "task-1", "Goal", "too late"); ErrorCode(err) != CodeInvalidState {
+		t.Fatalf("expected done task to be rejected, got %v", err)
+	}
+}
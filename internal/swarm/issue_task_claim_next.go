@@ -0,0 +1,90 @@
+package swarm
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// ClaimNextTask blocks until an open task of issueID matching filter can be claimed, and claims it for
+// actor in the same step, so workers woken by the same task do not race each other to claimIssueTask.
+// Tasks the scheduler reserved for actor come first and are claimed with their token; tasks reserved for
+// someone else (next-step tokens, dispatch, assignment) are skipped, and a task lost to another worker
+// meanwhile makes it try the next one. While the issue is paused nothing is claimable and the call keeps
+// waiting. Returns nil on timeout, or a CodeInvalidArgument error listing the matching tasks the last
+// scan had to skip because their claim was refused as invalid (e.g. required docs missing), since
+// waiting alone will not make those claimable. A worker at the claim cap (maxClaimed, as in ClaimTask)
+// is refused right away.
+func (s *IssueService) ClaimNextTask(ctx context.Context, issueID, actor string, filter TaskFilter, maxClaimed, timeoutSec int) (*IssueTask, error) {
+	if issueID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
+	}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	if !s.store.Exists("issues", issueID, "issue.json") {
+		return nil, Errorf(CodeNotFound, "issue '%s' not found", issueID)
+	}
+	// A worker at its cap would only wait to be refused.
+	if err := s.store.WithLock(func() error { return s.checkClaimCapLocked(actor, maxClaimed) }); err != nil {
+		return nil, err
+	}
+	timeoutSec = s.normalizeTimeoutSec(ctx, timeoutSec)
+
+	deadline := time.Now().Add(time.Duration(timeoutSec) * time.Second)
+	for {
+		since := s.changeGen()
+		tasks, err := s.ListTasks(issueID, IssueTaskOpen)
+		if err != nil {
+			return nil, err
+		}
+		nowMs := LeaseNowMs()
+		var mine, free []IssueTask
+		for _, t := range filterTasks(tasks, filter) {
+			if t.ReservedToken != "" && (t.ReservedUntilMs == 0 || nowMs <= t.ReservedUntilMs) {
+				if s.reservedFor(issueID, &t, actor) {
+					mine = append(mine, t)
+				}
+				continue
+			}
+			t.ReservedToken = ""
+			free = append(free, t)
+		}
+		var skipped []string
+		for _, t := range append(mine, free...) {
+			task, err := s.ClaimTask(issueID, t.ID, actor, t.ReservedToken, maxClaimed)
+			switch ErrorCode(err) {
+			case "":
+				return task, nil
+			case CodeInvalidArgument:
+				skipped = append(skipped, t.ID+": "+err.Error())
+				continue
+			case CodeReserved, CodeInvalidState:
+				// Reserved or claimed meanwhile, issue paused, or a claim check not passing yet.
+				continue
+			}
+			return nil, err
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			if len(skipped) > 0 {
+				return nil, Errorf(CodeInvalidArgument, "no matching task became claimable; skipped: %s", strings.Join(skipped, "; ")).
+					With("skipped", skipped)
+			}
+			return nil, nil
+		}
+		if err := s.waitChange(ctx, since, min(remaining, pollInterval)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// reservedFor reports whether task is reserved by a scheduler token assigned to actor. ClaimTask checks
+// the token again under the store lock.
+func (s *IssueService) reservedFor(issueID string, task *IssueTask, actor string) bool {
+	var tok NextStepToken
+	if err := s.store.ReadJSON(s.store.Path("issues", issueID, "next_steps", task.ReservedToken+".json"), &tok); err != nil {
+		return false
+	}
+	return tok.Assignee == actor && tok.Attached && !tok.Used && tok.NextStep.Type == "claim_task" && tok.NextStep.TaskID == task.ID
+}
@@ -0,0 +1,112 @@
+package swarm
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClaimNextTask_OneWinnerPerTask(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 4}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	for _, task := range []*IssueTask{
+		{ID: "task-1", IssueID: issueID, Status: IssueTaskOpen, Labels: []string{"backend"}},
+		{ID: "task-2", IssueID: issueID, Status: IssueTaskOpen, Labels: []string{"frontend"}, ReservedToken: "tok", ReservedUntilMs: time.Now().Add(time.Hour).UnixMilli()},
+		{ID: "task-3", IssueID: issueID, Status: IssueTaskOpen, Labels: []string{"frontend"}},
+	} {
+		if err := svc.saveTaskLocked(issueID, task); err != nil {
+			t.Fatalf("write task: %v", err)
+		}
+	}
+
+	short := WithShortTimeouts(context.Background())
+	filter := TaskFilter{Labels: []string{"frontend"}}
+	var wg sync.WaitGroup
+	got := make([]*IssueTask, 3)
+	for i, worker := range []string{"w1", "w2", "w3"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			task, err := svc.ClaimNextTask(short, issueID, worker, filter, 0, 1)
+			if err != nil {
+				t.Errorf("%s: %v", worker, err)
+			}
+			got[i] = task
+		}()
+	}
+	wg.Wait()
+
+	winners := 0
+	for _, task := range got {
+		if task == nil {
+			continue
+		}
+		winners++
+		// task-2 is reserved and task-1 is filtered out, so only task-3 can be claimed.
+		if task.ID != "task-3" || task.Status != IssueTaskInProgress {
+			t.Fatalf("unexpected claim: %+v", task)
+		}
+	}
+	if winners != 1 {
+		t.Fatalf("expected exactly one worker to claim task-3, got %d", winners)
+	}
+
+	if _, err := svc.ClaimNextTask(short, issueID, "w4", TaskFilter{}, 1, 1); err != nil {
+		t.Fatalf("claim any: %v", err)
+	}
+	if _, err := svc.ClaimNextTask(short, issueID, "w4", TaskFilter{}, 1, 1); ErrorCode(err) != CodeLimitExceeded {
+		t.Fatalf("claim cap: got %v, want limit_exceeded", err)
+	}
+}
+
+func TestClaimNextTask_PrefersTasksReservedForTheCaller(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 4}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	until := time.Now().Add(time.Hour).UnixMilli()
+	for _, task := range []*IssueTask{
+		{ID: "task-1", IssueID: issueID, Status: IssueTaskOpen},
+		{ID: "task-2", IssueID: issueID, Status: IssueTaskOpen, ReservedToken: "ns_w1", ReservedUntilMs: until},
+		{ID: "task-3", IssueID: issueID, Status: IssueTaskOpen, RequiredIssueDocs: []string{"design"}},
+	} {
+		if err := svc.saveTaskLocked(issueID, task); err != nil {
+			t.Fatalf("write task: %v", err)
+		}
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "next_steps", "ns_w1.json"), &NextStepToken{
+		Token: "ns_w1", IssueID: issueID, Assignee: "w1", Attached: true, NextStep: NextStep{Type: "claim_task", TaskID: "task-2"},
+	}); err != nil {
+		t.Fatalf("write token: %v", err)
+	}
+
+	short := WithShortTimeouts(context.Background())
+	if task, err := svc.ClaimNextTask(short, issueID, "w1", TaskFilter{}, 0, 1); err != nil || task == nil || task.ID != "task-2" {
+		t.Fatalf("expected w1 to claim the task reserved for it, got %+v %v", task, err)
+	}
+	if task, err := svc.ClaimNextTask(short, issueID, "w2", TaskFilter{}, 0, 1); err != nil || task == nil || task.ID != "task-1" {
+		t.Fatalf("expected w2 to claim the unreserved task, got %+v %v", task, err)
+	}
+
+	// Only task-3 is left, and it misses a required doc: waiting reports it instead of returning nothing.
+	_, err := svc.ClaimNextTask(short, issueID, "w2", TaskFilter{}, 0, 1)
+	if ErrorCode(err) != CodeInvalidArgument || !strings.Contains(err.Error(), "task-3") {
+		t.Fatalf("expected invalid_argument naming task-3, got %v", err)
+	}
+}
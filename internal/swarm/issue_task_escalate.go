@@ -0,0 +1,217 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ValidEscalationReason reports whether reason is one of the Escalation* constants.
+func ValidEscalationReason(reason string) bool {
+	switch reason {
+	case EscalationSpecConflict, EscalationMissingAccess, EscalationScopeTooLarge, EscalationOther:
+		return true
+	}
+	return false
+}
+
+// EscalateTask is the worker's escalation path for problems a question or blocker cannot express: the
+// spec contradicts itself, the worker lacks access, or the task is too large for one lease. It records
+// a worker_escalation message, pushes a high-priority item to the lead inbox (claimed ahead of other
+// items) and, with notifyAcceptor, to the acceptor inbox. The task is blocked and its lease clock is
+// frozen until the lead replies to the message; the reply adds the frozen time back to the lease.
+func (s *IssueService) EscalateTask(issueID, taskID, actor, reason, content, refs string, notifyAcceptor bool) (*IssueEvent, error) {
+	if issueID == "" || taskID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id and task_id are required")
+	}
+	reason = strings.TrimSpace(reason)
+	if !ValidEscalationReason(reason) {
+		return nil, Errorf(CodeInvalidArgument, "reason must be one of %s, %s, %s, %s", EscalationSpecConflict, EscalationMissingAccess, EscalationScopeTooLarge, EscalationOther)
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, Errorf(CodeInvalidArgument, "content is required")
+	}
+	if actor == "" {
+		actor = "worker"
+	}
+
+	var ev *IssueEvent
+	err := s.store.WithLock(func() error {
+		task, err := s.loadTaskLocked(issueID, taskID)
+		if err != nil {
+			return err
+		}
+		if task.ClaimedBy == "" {
+			return Errorf(CodeInvalidState, "task '%s' is not claimed", taskID)
+		}
+		if strings.TrimSpace(task.ClaimedBy) != strings.TrimSpace(actor) {
+			return Errorf(CodeNotOwner, "task '%s' is not claimed by actor", taskID)
+		}
+		if task.Status != IssueTaskInProgress && task.Status != IssueTaskBlocked {
+			return Errorf(CodeInvalidState, "task '%s' is not in progress (status: %s)", taskID, task.Status)
+		}
+		if task.LeaseFrozenAt != "" {
+			return Errorf(CodeInvalidState, "task '%s' is already escalated since %s; wait for the lead's reply", taskID, task.LeaseFrozenAt)
+		}
+
+		msg, err := s.createTaskMessageLocked(issueID, taskID, actor, InboxTypeWorkerEscalation, content, refs)
+		if err != nil {
+			return err
+		}
+		msg.Reason = reason
+		if err := s.store.WriteJSON(s.store.Path("issues", issueID, "messages", msg.ID+".json"), msg); err != nil {
+			return err
+		}
+
+		task.Status = IssueTaskBlocked
+		task.LeaseFrozenAt = leaseNow().Format(time.RFC3339)
+		task.UpdatedAt = task.LeaseFrozenAt
+		if err := s.saveTaskLocked(issueID, task); err != nil {
+			return err
+		}
+
+		item, err := s.pushToLeadInboxLocked(issueID, taskID, InboxTypeWorkerEscalation, msg.ID, actor)
+		if err != nil {
+			return err
+		}
+		item.Priority = InboxPriorityHigh
+		if err := s.store.WriteJSON(s.store.Path("issues", issueID, "inbox", "lead", item.ID+".json"), item); err != nil {
+			return err
+		}
+		if notifyAcceptor {
+			if err := s.pushEscalationToAcceptorLocked(issueID, taskID, msg.ID, actor); err != nil {
+				return err
+			}
+		}
+
+		e := IssueEvent{
+			Type:      EventIssueTaskMessage,
+			IssueID:   issueID,
+			TaskID:    taskID,
+			Actor:     actor,
+			Kind:      InboxTypeWorkerEscalation,
+			Detail:    fmt.Sprintf("[%s] %s", reason, content),
+			Refs:      refs,
+			MessageID: msg.ID,
+			Timestamp: NowStr(),
+		}
+		seq, err := s.appendEventLockedWithSeq(issueID, &e)
+		if err != nil {
+			return err
+		}
+		e.Seq = seq
+		ev = &e
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.bump(issueID)
+	return ev, nil
+}
+
+// thawLeaseLocked ends a lease freeze: the time spent frozen is added to the lease so the worker gets
+// back what the escalation cost. Reports whether the task was frozen. Call under store lock.
+func thawLeaseLocked(task *IssueTask) bool {
+	if task.LeaseFrozenAt == "" {
+		return false
+	}
+	if t, err := time.Parse(time.RFC3339, task.LeaseFrozenAt); err == nil && task.LeaseExpiresAtMs > 0 {
+		task.LeaseExpiresAtMs += max(LeaseNowMs()-t.UnixMilli(), 0)
+	}
+	task.LeaseFrozenAt = ""
+	return true
+}
+
+// pushEscalationToAcceptorLocked adds a high-priority worker_escalation item to the acceptor inbox.
+// Call under store lock.
+func (s *IssueService) pushEscalationToAcceptorLocked(issueID, taskID, messageID, senderID string) error {
+	item := &InboxItem{
+		ID:        GenID("inb"),
+		IssueID:   issueID,
+		TaskID:    taskID,
+		Type:      InboxTypeWorkerEscalation,
+		RefID:     messageID,
+		SenderID:  senderID,
+		Target:    "acceptor",
+		Status:    InboxPending,
+		Priority:  InboxPriorityHigh,
+		CreatedAt: NowStr(),
+		UpdatedAt: NowStr(),
+	}
+	s.store.EnsureDir("deliveries", "inbox", "acceptor")
+	return s.store.WriteJSON(s.store.Path("deliveries", "inbox", "acceptor", item.ID+".json"), item)
+}
+
+// ackAcceptorEscalationLocked marks the acceptor inbox items for the escalation message as done.
+// Call under store lock.
+func (s *IssueService) ackAcceptorEscalationLocked(messageID string) {
+	for _, f := range listJSONOrEmpty(s.store, s.store.Path("deliveries", "inbox", "acceptor")) {
+		var item InboxItem
+		if err := s.store.ReadJSON(f, &item); err != nil {
+			continue
+		}
+		if item.Type != InboxTypeWorkerEscalation || item.RefID != messageID || item.Status == InboxDone {
+			continue
+		}
+		item.Status = InboxDone
+		item.UpdatedAt = NowStr()
+		_ = s.store.WriteJSON(f, &item)
+	}
+}
+
+// WaitAcceptorEscalation blocks until a worker escalation was copied to the acceptor, acks it and
+// returns the escalation message; (nil, nil) on timeout. Escalations the lead already answered are
+// acked and skipped.
+func (s *IssueService) WaitAcceptorEscalation(ctx context.Context, timeoutSec int) (*TaskMessage, error) {
+	deadline := s.deadline(s.normalizeTimeoutSec(ctx, timeoutSec))
+	for {
+		since := s.changeGen()
+		var found *TaskMessage
+		err := s.store.WithLock(func() error {
+			dir := s.store.Path("deliveries", "inbox", "acceptor")
+			files, err := s.store.ListJSONFiles(dir)
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			var items []InboxItem
+			for _, f := range files {
+				var item InboxItem
+				if err := s.store.ReadJSON(f, &item); err != nil || item.Type != InboxTypeWorkerEscalation || item.Status == InboxDone {
+					continue
+				}
+				items = append(items, item)
+			}
+			sort.Slice(items, func(i, j int) bool { return items[i].CreatedAt < items[j].CreatedAt })
+			for _, item := range items {
+				item.Status = InboxDone
+				item.UpdatedAt = NowStr()
+				if err := s.store.WriteJSON(s.store.Path("deliveries", "inbox", "acceptor", item.ID+".json"), &item); err != nil {
+					return err
+				}
+				msg, err := s.getTaskMessageLocked(item.IssueID, item.RefID)
+				if err != nil || msg.Status != MessageOpen {
+					continue
+				}
+				found = msg
+				return nil
+			}
+			return nil
+		})
+		if err != nil || found != nil {
+			return found, err
+		}
+		if timeExpired(deadline) {
+			return nil, nil
+		}
+		if err := s.waitChange(ctx, since, pollInterval); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEscalateTask_HighPriorityAndFrozenLease(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 1, 1)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 2}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	lease := time.Now().Add(time.Second).UnixMilli()
+	if err := svc.saveTaskLocked(issueID, &IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskInProgress, ClaimedBy: "w1", LeaseExpiresAtMs: lease}); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	// An older ordinary item that would be claimed first without priority.
+	if err := store.WithLock(func() error {
+		_, err := svc.pushToLeadInboxLocked(issueID, "task-1", InboxTypeQuestion, "msg-x", "w1")
+		return err
+	}); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+
+	if _, err := svc.EscalateTask(issueID, "task-1", "w1", "bogus", "x", "", false); err == nil {
+		t.Fatalf("expected unknown reason to be rejected")
+	}
+	if _, err := svc.EscalateTask(issueID, "task-1", "w2", EscalationSpecConflict, "x", "", false); err == nil {
+		t.Fatalf("expected escalation by a non-owner to be rejected")
+	}
+	ev, err := svc.EscalateTask(issueID, "task-1", "w1", EscalationScopeTooLarge, "needs splitting", "", true)
+	if err != nil {
+		t.Fatalf("escalate: %v", err)
+	}
+	if _, err := svc.EscalateTask(issueID, "task-1", "w1", EscalationOther, "again", "", false); err == nil {
+		t.Fatalf("expected a second escalation to be rejected while frozen")
+	}
+
+	// The lease runs out while frozen, but a frozen lease does not expire.
+	time.Sleep(1500 * time.Millisecond)
+	svc.SweepExpired()
+	task, err := svc.GetTask(issueID, "task-1")
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if task.Status != IssueTaskBlocked || task.ClaimedBy != "w1" || task.LeaseFrozenAt == "" {
+		t.Fatalf("expected blocked, still claimed, frozen task, got %+v", task)
+	}
+
+	item, err := svc.claimLeadInboxItem(issueID, "lead")
+	if err != nil || item == nil {
+		t.Fatalf("claim lead inbox: %v %+v", err, item)
+	}
+	if item.Type != InboxTypeWorkerEscalation || item.Priority != InboxPriorityHigh || item.RefID != ev.MessageID {
+		t.Fatalf("expected the escalation to be claimed first, got %+v", item)
+	}
+
+	if _, err := svc.ReplyTaskMessage(issueID, "task-1", "lead", ev.MessageID, "split it", ""); err != nil {
+		t.Fatalf("reply: %v", err)
+	}
+	task, err = svc.GetTask(issueID, "task-1")
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if task.Status != IssueTaskInProgress || task.LeaseFrozenAt != "" || task.LeaseExpiresAtMs <= time.Now().UnixMilli() {
+		t.Fatalf("expected in_progress, thawed task, got %+v", task)
+	}
+
+	// The acceptor copy was answered by the lead, so there is nothing left to wait for.
+	msg, err := svc.WaitAcceptorEscalation(context.Background(), 1)
+	if err != nil || msg != nil {
+		t.Fatalf("expected no pending acceptor escalation, got %+v %v", msg, err)
+	}
+}
@@ -0,0 +1,52 @@
+package swarm
+
+import (
+	"slices"
+	"strings"
+)
+
+// TaskFilter narrows the tasks WaitIssueTasks returns and wakes up for, e.g. to the labels a worker
+// specializes in. Zero values match every task.
+type TaskFilter struct {
+	Labels     []string `json:"labels,omitempty"`     // the task carries at least one of these labels (case-insensitive)
+	Difficulty string   `json:"difficulty,omitempty"` // easy, medium or focus
+	MinPoints  int      `json:"min_points,omitempty"`
+	MaxPoints  int      `json:"max_points,omitempty"` // 0 = no upper bound
+}
+
+func (f TaskFilter) validate() error {
+	if f.MinPoints < 0 || f.MaxPoints < 0 {
+		return Errorf(CodeInvalidArgument, "min_points and max_points must be >= 0")
+	}
+	if f.MaxPoints > 0 && f.MinPoints > f.MaxPoints {
+		return Errorf(CodeInvalidArgument, "min_points (%d) is above max_points (%d)", f.MinPoints, f.MaxPoints)
+	}
+	return nil
+}
+
+func (f TaskFilter) match(t *IssueTask) bool {
+	if d := strings.TrimSpace(f.Difficulty); d != "" && !strings.EqualFold(d, t.Difficulty) {
+		return false
+	}
+	if t.Points < f.MinPoints || (f.MaxPoints > 0 && t.Points > f.MaxPoints) {
+		return false
+	}
+	if len(f.Labels) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(f.Labels, func(want string) bool {
+		return slices.ContainsFunc(t.Labels, func(have string) bool {
+			return strings.EqualFold(strings.TrimSpace(have), strings.TrimSpace(want))
+		})
+	})
+}
+
+func filterTasks(tasks []IssueTask, f TaskFilter) []IssueTask {
+	out := tasks[:0:0]
+	for i := range tasks {
+		if f.match(&tasks[i]) {
+			out = append(out, tasks[i])
+		}
+	}
+	return out
+}
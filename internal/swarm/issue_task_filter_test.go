@@ -0,0 +1,60 @@
+package swarm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestWaitIssueTasks_Filters(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	for _, task := range []*IssueTask{
+		{ID: "task-1", IssueID: issueID, Status: IssueTaskOpen, Labels: []string{"backend"}, Difficulty: "focus", Points: 8},
+		{ID: "task-2", IssueID: issueID, Status: IssueTaskOpen, Labels: []string{"Frontend", "css"}, Difficulty: "easy", Points: 2},
+		{ID: "task-3", IssueID: issueID, Status: IssueTaskOpen, Labels: []string{"frontend"}, Difficulty: "medium", Points: 5},
+	} {
+		if err := svc.saveTaskLocked(issueID, task); err != nil {
+			t.Fatalf("write task: %v", err)
+		}
+	}
+	short := WithShortTimeouts(context.Background())
+	ids := func(f TaskFilter) []string {
+		t.Helper()
+		tasks, err := svc.WaitIssueTasks(short, issueID, IssueTaskOpen, f, 1, 0)
+		if err != nil {
+			t.Fatalf("wait %+v: %v", f, err)
+		}
+		out := []string{}
+		for _, task := range tasks {
+			out = append(out, task.ID)
+		}
+		return out
+	}
+
+	cases := []struct {
+		filter TaskFilter
+		want   string
+	}{
+		{TaskFilter{}, "task-1,task-2,task-3"},
+		{TaskFilter{Labels: []string{"frontend"}}, "task-2,task-3"},
+		{TaskFilter{Labels: []string{"frontend"}, Difficulty: "Easy"}, "task-2"},
+		{TaskFilter{MinPoints: 3, MaxPoints: 6}, "task-3"},
+		{TaskFilter{MinPoints: 5}, "task-1,task-3"},
+		{TaskFilter{Labels: []string{"mobile"}}, ""},
+	}
+	for _, c := range cases {
+		if got := strings.Join(ids(c.filter), ","); got != c.want {
+			t.Errorf("filter %+v: got %q, want %q", c.filter, got, c.want)
+		}
+	}
+
+	if _, err := svc.WaitIssueTasks(short, issueID, IssueTaskOpen, TaskFilter{MinPoints: 5, MaxPoints: 2}, 1, 0); ErrorCode(err) != CodeInvalidArgument {
+		t.Fatalf("min above max: got %v, want invalid_argument", err)
+	}
+}
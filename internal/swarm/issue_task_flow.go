@@ -1,7 +1,9 @@
 package swarm
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 )
@@ -15,13 +17,13 @@ func (s *IssueService) CreateTask(
 	specGoal, specRules, specConstraints, specConventions, specAcceptance string,
 ) (*IssueTask, error) {
 	if issueID == "" || subject == "" {
-		return nil, fmt.Errorf("issue_id and subject are required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id and subject are required")
 	}
 	if actor == "" {
 		actor = "lead"
 	}
 	if difficulty != "easy" && difficulty != "medium" && difficulty != "focus" {
-		return nil, fmt.Errorf("invalid difficulty: %s", difficulty)
+		return nil, Errorf(CodeInvalidArgument, "invalid difficulty: %s", difficulty)
 	}
 	var err error
 	specName, err = cleanDocName(specName)
@@ -78,7 +80,10 @@ func (s *IssueService) CreateTask(
 	var result *IssueTask
 	err = s.store.WithLock(func() error {
 		if !s.store.Exists("issues", issueID, "issue.json") {
-			return fmt.Errorf("issue '%s' not found", issueID)
+			return Errorf(CodeNotFound, "issue '%s' not found", issueID)
+		}
+		if err := s.checkPointsBudgetLocked(issueID, points); err != nil {
+			return err
 		}
 
 		metaPath := s.store.Path("issues", issueID, "meta.json")
@@ -176,7 +181,7 @@ func (s *IssueService) CreateTask(
 			return err
 		}
 		task.TaskDocs = append(task.TaskDocs, DocRef{Name: specName, Path: specPath})
-		if err := s.store.WriteJSON(s.store.Path("issues", issueID, "tasks", task.ID+".json"), task); err != nil {
+		if err := s.saveTaskLocked(issueID, task); err != nil {
 			return err
 		}
 
@@ -198,22 +203,30 @@ func (s *IssueService) CreateTask(
 	return result, nil
 }
 
-func (s *IssueService) ClaimTask(issueID, taskID, actor, nextStepToken string) (*IssueTask, error) {
+// ClaimTask claims an open task for actor. maxClaimed > 0 caps how many tasks the
+// actor may hold (in_progress/blocked) across all issues at once.
+func (s *IssueService) ClaimTask(issueID, taskID, actor, nextStepToken string, maxClaimed int) (*IssueTask, error) {
 	if issueID == "" || taskID == "" {
-		return nil, fmt.Errorf("issue_id and task_id are required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id and task_id are required")
 	}
-	s.SweepExpired()
 	if actor == "" {
 		actor = "worker"
 	}
-	nowMs := time.Now().UnixMilli()
+	nowMs := LeaseNowMs()
 
 	var result *IssueTask
 	err := s.store.WithLock(func() error {
+		if s.issuePaused(issueID) {
+			return Errorf(CodeInvalidState, "issue '%s' is paused; tasks cannot be claimed until it is resumed", issueID)
+		}
 		task, err := s.loadTaskLocked(issueID, taskID)
 		if err != nil {
 			return err
 		}
+		// Check the cap before consuming any reservation token.
+		if err := s.checkClaimCapLocked(actor, maxClaimed); err != nil {
+			return err
+		}
 
 		if task.ReservedToken != "" {
 			if task.ReservedUntilMs > 0 && nowMs > task.ReservedUntilMs {
@@ -221,18 +234,21 @@ func (s *IssueService) ClaimTask(issueID, taskID, actor, nextStepToken string) (
 				task.ReservedUntilMs = 0
 			} else {
 				if _, err := trimRequired("next_step_token", nextStepToken); err != nil {
-					return fmt.Errorf("task '%s' is reserved", taskID)
+					return Errorf(CodeReserved, "task '%s' is reserved", taskID)
 				}
 				if nextStepToken != task.ReservedToken {
-					return fmt.Errorf("task '%s' is reserved", taskID)
+					return Errorf(CodeReserved, "task '%s' is reserved", taskID)
 				}
 				tokPath := s.store.Path("issues", issueID, "next_steps", nextStepToken+".json")
 				var tok NextStepToken
 				if err := s.store.ReadJSON(tokPath, &tok); err != nil {
-					return fmt.Errorf("task '%s' is reserved", taskID)
+					return Errorf(CodeReserved, "task '%s' is reserved", taskID)
 				}
 				if tok.IssueID != issueID || tok.Used || !tok.Attached || tok.NextStep.Type != "claim_task" || tok.NextStep.TaskID != taskID {
-					return fmt.Errorf("task '%s' is reserved", taskID)
+					return Errorf(CodeReserved, "task '%s' is reserved", taskID)
+				}
+				if tok.Assignee != "" && tok.Assignee != actor {
+					return Errorf(CodeReserved, "task '%s' is reserved for worker '%s'", taskID, tok.Assignee)
 				}
 				tok.Used = true
 				tok.UsedAt = NowStr()
@@ -244,25 +260,19 @@ func (s *IssueService) ClaimTask(issueID, taskID, actor, nextStepToken string) (
 			}
 		}
 
-		for _, n := range task.RequiredIssueDocs {
-			if !s.store.Exists("issues", issueID, "docs", n+".md") {
-				return fmt.Errorf("missing required issue doc: %s", n)
-			}
-		}
-		for _, n := range task.RequiredTaskDocs {
-			if !s.store.Exists("issues", issueID, "tasks", task.ID+".docs", n+".md") {
-				return fmt.Errorf("missing required task doc: %s", n)
-			}
+		if err := s.checkClaimReadyLocked(issueID, task); err != nil {
+			return err
 		}
 
 		if task.Status != IssueTaskOpen {
-			return fmt.Errorf("task '%s' is not open (status: %s)", taskID, task.Status)
+			return Errorf(CodeInvalidState, "task '%s' is not open (status: %s)", taskID, task.Status)
 		}
 		task.ClaimedBy = actor
 		task.Status = IssueTaskInProgress
 		task.LeaseExpiresAtMs = s.calcLeaseExpiryMs(0, s.taskTTLSec)
-		task.UpdatedAt = NowStr()
-		if err := s.store.WriteJSON(s.store.Path("issues", issueID, "tasks", task.ID+".json"), task); err != nil {
+		task.ClaimedAt = NowStr()
+		task.UpdatedAt = task.ClaimedAt
+		if err := s.saveTaskLocked(issueID, task); err != nil {
 			return err
 		}
 		result = task
@@ -276,27 +286,61 @@ func (s *IssueService) ClaimTask(issueID, taskID, actor, nextStepToken string) (
 	return result, nil
 }
 
-func (s *IssueService) SubmitTask(issueID, taskID, actor string, artifacts SubmissionArtifacts) (*IssueTask, error) {
+// checkClaimCapLocked fails when actor already holds maxClaimed tasks (0 = no cap). Must be called under
+// store lock.
+func (s *IssueService) checkClaimCapLocked(actor string, maxClaimed int) error {
+	if maxClaimed <= 0 {
+		return nil
+	}
+	if held := s.claimedTaskIDsLocked(actor); len(held) >= maxClaimed {
+		return Errorf(CodeLimitExceeded, "worker '%s' already holds %d claimed task(s) (max %d): %s", actor, len(held), maxClaimed, strings.Join(held, ", "))
+	}
+	return nil
+}
+
+// claimedTaskIDsLocked lists "issue_id/task_id" for every in_progress/blocked task claimed by workerID.
+// Must be called under store lock.
+func (s *IssueService) claimedTaskIDsLocked(workerID string) []string {
+	var out []string
+	entries, err := os.ReadDir(s.store.Path("issues"))
+	if err != nil {
+		return out
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		for _, f := range listJSONOrEmpty(s.store, s.store.Path("issues", e.Name(), "tasks")) {
+			var t IssueTask
+			if err := s.store.ReadJSON(f, &t); err != nil {
+				continue
+			}
+			if t.ClaimedBy != workerID {
+				continue
+			}
+			if t.Status != IssueTaskInProgress && t.Status != IssueTaskBlocked {
+				continue
+			}
+			out = append(out, e.Name()+"/"+t.ID)
+		}
+	}
+	return out
+}
+
+func (s *IssueService) SubmitTask(ctx context.Context, issueID, taskID, actor string, artifacts SubmissionArtifacts) (*IssueTask, error) {
 	if issueID == "" || taskID == "" {
-		return nil, fmt.Errorf("issue_id and task_id are required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id and task_id are required")
 	}
-	s.SweepExpired()
 	if actor == "" {
 		actor = "worker"
 	}
 	if _, err := trimRequired("artifacts.summary", artifacts.Summary); err != nil {
 		return nil, err
 	}
-	if len(artifacts.ChangedFiles) == 0 {
-		return nil, fmt.Errorf("artifacts.changed_files is required")
-	}
-	if len(artifacts.TestCases) == 0 {
-		return nil, fmt.Errorf("artifacts.test_cases is required")
-	}
-	if _, err := trimRequired("artifacts.test_result", artifacts.TestResult); err != nil {
+	if err := s.verifyChangedFiles(artifacts); err != nil {
 		return nil, err
 	}
-	if _, err := trimRequired("artifacts.test_output", artifacts.TestOutput); err != nil {
+	if err := s.runValidationHooks(ctx, ValidationPayload{Event: HookOnSubmission, IssueID: issueID, TaskID: taskID, Actor: actor, Summary: artifacts.Summary, Artifacts: artifacts}); err != nil {
 		return nil, err
 	}
 
@@ -308,24 +352,35 @@ func (s *IssueService) SubmitTask(issueID, taskID, actor string, artifacts Submi
 			return err
 		}
 		if strings.TrimSpace(task.ClaimedBy) == "" {
-			return fmt.Errorf("task '%s' is not claimed", taskID)
+			return Errorf(CodeInvalidState, "task '%s' is not claimed", taskID)
 		}
 		if strings.TrimSpace(task.ClaimedBy) != strings.TrimSpace(actor) {
-			return fmt.Errorf("task '%s' is not claimed by actor", taskID)
+			return Errorf(CodeNotOwner, "task '%s' is not claimed by actor", taskID)
 		}
 		if task.Status != IssueTaskInProgress && task.Status != IssueTaskBlocked {
-			return fmt.Errorf("task '%s' is not in progress (status: %s)", taskID, task.Status)
+			return Errorf(CodeInvalidState, "task '%s' is not in progress (status: %s)", taskID, task.Status)
+		}
+		if task.EscalatedAt != "" {
+			return Errorf(CodeInvalidState, "task '%s' was escalated to the lead after %d rejected submissions; wait for it to be reset or reassigned", taskID, s.maxRejections)
+		}
+		if err := s.checkSubmissionArtifacts(task, artifacts); err != nil {
+			return err
+		}
+		if err := s.checkArtifactLinksLocked(issueID, artifacts.Links); err != nil {
+			return err
 		}
 
-		// Extend lease to cover the review wait period.
-		nowMs := time.Now().UnixMilli()
+		// Submitting settles an open escalation; extend lease to cover the review wait period.
+		thawLeaseLocked(task)
+		nowMs := LeaseNowMs()
 		minLeaseMs := nowMs + int64(s.defaultTimeoutSec)*1000
 		if task.LeaseExpiresAtMs < minLeaseMs {
 			task.LeaseExpiresAtMs = minLeaseMs
-			task.UpdatedAt = NowStr()
-			if err := s.store.WriteJSON(s.store.Path("issues", issueID, "tasks", task.ID+".json"), task); err != nil {
-				return err
-			}
+		}
+		task.SubmittedAt = NowStr()
+		task.UpdatedAt = task.SubmittedAt
+		if err := s.saveTaskLocked(issueID, task); err != nil {
+			return err
 		}
 
 		// Create the Submission entity.
@@ -335,8 +390,8 @@ func (s *IssueService) SubmitTask(issueID, taskID, actor string, artifacts Submi
 		}
 		submissionID = sub.ID
 
-		// Push to lead inbox.
-		if _, err := s.pushToLeadInboxLocked(issueID, taskID, InboxTypeSubmission, sub.ID, actor); err != nil {
+		// Push to lead inbox (or the peer reviewer first).
+		if err := s.routeSubmissionLocked(issueID, task, sub, actor); err != nil {
 			return err
 		}
 
@@ -359,8 +414,10 @@ func (s *IssueService) SubmitTask(issueID, taskID, actor string, artifacts Submi
 
 	s.bump(issueID)
 
-	// Block until the Submission is reviewed (approved or rejected).
-	sub, err := s.pollSubmissionStatus(issueID, submissionID, s.defaultTimeoutSec)
+	// Block until the Submission is reviewed (approved or rejected), keeping the lease alive meanwhile.
+	stop := s.keepTaskLeaseAlive(ctx, issueID, taskID, actor)
+	defer stop()
+	sub, err := s.pollSubmissionStatus(ctx, issueID, submissionID, s.defaultTimeoutSec)
 	if err != nil {
 		return nil, err
 	}
@@ -372,21 +429,21 @@ func (s *IssueService) SubmitTask(issueID, taskID, actor string, artifacts Submi
 
 // ReviewTask reviews the latest open Submission for a task (or a specific submission_id).
 // Task status: approved→done, rejected→in_progress (worker can resubmit).
-func (s *IssueService) ReviewTask(actor, issueID, taskID, submissionID, verdict, feedback string, completionScore int, artifacts ReviewArtifacts, feedbackDetails []FeedbackDetail, nextStepToken string) (*IssueTask, error) {
+func (s *IssueService) ReviewTask(actor, issueID, taskID, submissionID, verdict, feedback string, completionScore int, artifacts ReviewArtifacts, feedbackDetails []FeedbackDetail, nextStepToken string, expectedRev int64) (*IssueTask, error) {
 	if issueID == "" || taskID == "" {
-		return nil, fmt.Errorf("issue_id and task_id are required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id and task_id are required")
 	}
 	if verdict != VerdictApproved && verdict != VerdictRejected {
-		return nil, fmt.Errorf("invalid verdict: %s", verdict)
+		return nil, Errorf(CodeInvalidArgument, "invalid verdict: %s", verdict)
 	}
-	if completionScore != 1 && completionScore != 2 && completionScore != 5 {
-		return nil, fmt.Errorf("invalid completion_score: %d", completionScore)
+	if err := s.policy.checkCompletionScore(completionScore); err != nil {
+		return nil, err
 	}
 	if _, err := trimRequired("artifacts.review_summary", artifacts.ReviewSummary); err != nil {
 		return nil, err
 	}
 	if len(artifacts.ReviewedRefs) == 0 || len(feedbackDetails) == 0 {
-		return nil, fmt.Errorf("artifacts.reviewed_refs and feedback_details are required")
+		return nil, Errorf(CodeInvalidArgument, "artifacts.reviewed_refs and feedback_details are required")
 	}
 	for i, fd := range feedbackDetails {
 		if _, err := trimRequired(fmt.Sprintf("feedback_details[%d].dimension", i), fd.Dimension); err != nil {
@@ -399,6 +456,9 @@ func (s *IssueService) ReviewTask(actor, issueID, taskID, submissionID, verdict,
 			return nil, err
 		}
 	}
+	if err := s.checkSeverityGate(verdict, feedbackDetails); err != nil {
+		return nil, err
+	}
 	if _, err := trimRequired("next_step_token", nextStepToken); err != nil {
 		return nil, err
 	}
@@ -412,19 +472,19 @@ func (s *IssueService) ReviewTask(actor, issueID, taskID, submissionID, verdict,
 		tokPath := s.store.Path("issues", issueID, "next_steps", nextStepToken+".json")
 		var tok NextStepToken
 		if err := s.store.ReadJSON(tokPath, &tok); err != nil {
-			return fmt.Errorf("invalid next_step_token")
+			return Errorf(CodeInvalidArgument, "invalid next_step_token")
 		}
 		if tok.IssueID != issueID || tok.Actor != actor || tok.Used {
-			return fmt.Errorf("invalid next_step_token")
+			return Errorf(CodeInvalidArgument, "invalid next_step_token")
 		}
 		if tok.NextStep.Type == "claim_task" {
 			t, err := s.loadTaskLocked(issueID, tok.NextStep.TaskID)
 			if err != nil {
 				return err
 			}
-			nowMs := time.Now().UnixMilli()
+			nowMs := LeaseNowMs()
 			if t.Status != IssueTaskOpen || t.ReservedToken != tok.Token || (t.ReservedUntilMs > 0 && nowMs > t.ReservedUntilMs) {
-				return fmt.Errorf("next_step task '%s' is not reserved", tok.NextStep.TaskID)
+				return Errorf(CodeInvalidState, "next_step task '%s' is not reserved", tok.NextStep.TaskID)
 			}
 		}
 
@@ -432,12 +492,21 @@ func (s *IssueService) ReviewTask(actor, issueID, taskID, submissionID, verdict,
 		if err != nil {
 			return err
 		}
+		if err := checkRev("task", taskID, expectedRev, task.Rev); err != nil {
+			return err
+		}
+		if err := s.checkReviewChecklist(task, verdict, artifacts.Checklist); err != nil {
+			return err
+		}
 
 		// Resolve which submission to review.
 		sub, err := s.resolveSubmissionForReview(issueID, taskID, submissionID)
 		if err != nil {
 			return err
 		}
+		if awaitingPeerReview(sub) {
+			return Errorf(CodeInvalidState, "submission '%s' is waiting for its peer review first (assignPeerReviewer if no reviewer is set)", sub.ID)
+		}
 
 		_, err = s.reviewSubmissionLocked(issueID, sub.ID, actor, verdict, feedback, completionScore, artifacts, feedbackDetails, nextStepToken)
 		if err != nil {
@@ -466,6 +535,7 @@ func (s *IssueService) ReviewTask(actor, issueID, taskID, submissionID, verdict,
 		task.ReviewArtifacts = artifacts
 		task.FeedbackDetails = feedbackDetails
 		task.NextStepToken = nextStepToken
+		task.ReviewedAt = NowStr()
 		if verdict == VerdictApproved {
 			task.Status = IssueTaskDone
 			// Cache approved artifacts on task for delivery computation.
@@ -476,8 +546,15 @@ func (s *IssueService) ReviewTask(actor, issueID, taskID, submissionID, verdict,
 		} else {
 			task.Status = IssueTaskInProgress
 		}
+		rejections := 0
+		if verdict == VerdictRejected && s.maxRejections > 0 {
+			if rejections = s.rejectionsSinceClaimLocked(issueID, task); rejections >= s.maxRejections {
+				task.Status = IssueTaskBlocked
+				task.EscalatedAt = NowStr()
+			}
+		}
 		task.UpdatedAt = NowStr()
-		if err := s.store.WriteJSON(s.store.Path("issues", issueID, "tasks", task.ID+".json"), task); err != nil {
+		if err := s.saveTaskLocked(issueID, task); err != nil {
 			return err
 		}
 		result = task
@@ -496,7 +573,7 @@ func (s *IssueService) ReviewTask(actor, issueID, taskID, submissionID, verdict,
 		if sub != nil {
 			subID = sub.ID
 		}
-		return s.appendEventLocked(issueID, IssueEvent{
+		if err := s.appendEventLocked(issueID, IssueEvent{
 			Type:            eventType,
 			IssueID:         issueID,
 			TaskID:          task.ID,
@@ -509,22 +586,30 @@ func (s *IssueService) ReviewTask(actor, issueID, taskID, submissionID, verdict,
 			NextStep:        &tok.NextStep,
 			NextStepToken:   nextStepToken,
 			Timestamp:       NowStr(),
-		})
+		}); err != nil {
+			return err
+		}
+		if task.EscalatedAt != "" && task.Status == IssueTaskBlocked && verdict == VerdictRejected {
+			return s.escalateRejectionsLocked(issueID, task, subID, rejections)
+		}
+		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
 
 	s.bump(issueID)
+	if verdict == VerdictApproved {
+		// The worker is free again: hand out work to idle workers (no-op unless the scheduler is on).
+		_, _ = s.AssignTasks()
+	}
 	return result, nil
 }
 
 func (s *IssueService) GetTask(issueID, taskID string) (*IssueTask, error) {
 	if issueID == "" || taskID == "" {
-		return nil, fmt.Errorf("issue_id and task_id are required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id and task_id are required")
 	}
-	s.SweepExpired()
-
 	var result *IssueTask
 	err := s.store.WithLock(func() error {
 		t, err := s.loadTaskLocked(issueID, taskID)
@@ -539,10 +624,8 @@ func (s *IssueService) GetTask(issueID, taskID string) (*IssueTask, error) {
 
 func (s *IssueService) ListTasks(issueID, status string) ([]IssueTask, error) {
 	if issueID == "" {
-		return nil, fmt.Errorf("issue_id is required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
 	}
-	s.SweepExpired()
-
 	dir := s.store.Path("issues", issueID, "tasks")
 	files, err := s.store.ListJSONFiles(dir)
 	if err != nil {
@@ -565,10 +648,10 @@ func (s *IssueService) ListTasks(issueID, status string) ([]IssueTask, error) {
 
 func (s *IssueService) CountTasks(issueID string) (int, error) {
 	if issueID == "" {
-		return 0, fmt.Errorf("issue_id is required")
+		return 0, Errorf(CodeInvalidArgument, "issue_id is required")
 	}
 	if !s.store.Exists("issues", issueID, "issue.json") {
-		return 0, fmt.Errorf("issue '%s' not found", issueID)
+		return 0, Errorf(CodeNotFound, "issue '%s' not found", issueID)
 	}
 	dir := s.store.Path("issues", issueID, "tasks")
 	files, err := s.store.ListJSONFiles(dir)
@@ -578,31 +661,36 @@ func (s *IssueService) CountTasks(issueID string) (int, error) {
 	return len(files), nil
 }
 
-// WaitIssueTasks blocks until at least one task matching status exists under an issue.
+// WaitIssueTasks blocks until at least one task matching status and filter exists under an issue.
 // - If tasks exist immediately, returns them without waiting.
 // - status defaults to "open" if empty.
 // - If timeoutSec <= 0, defaults to 3600.
-func (s *IssueService) WaitIssueTasks(issueID, status string, timeoutSec, limit int) ([]IssueTask, error) {
+func (s *IssueService) WaitIssueTasks(ctx context.Context, issueID, status string, filter TaskFilter, timeoutSec, limit int) ([]IssueTask, error) {
 	if issueID == "" {
-		return nil, fmt.Errorf("issue_id is required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
+	}
+	if err := filter.validate(); err != nil {
+		return nil, err
 	}
-	s.SweepExpired()
 	if strings.TrimSpace(status) == "" {
 		status = IssueTaskOpen
 	}
-	timeoutSec = s.normalizeTimeoutSec(timeoutSec)
+	timeoutSec = s.normalizeTimeoutSec(ctx, timeoutSec)
 	if limit <= 0 {
 		limit = 50
 	}
 
 	deadline := time.Now().Add(time.Duration(timeoutSec) * time.Second)
-	poll := 200 * time.Millisecond
 	for {
-		s.SweepExpired()
+		since := s.changeGen()
 		tasks, err := s.ListTasks(issueID, status)
 		if err != nil {
 			return nil, err
 		}
+		tasks = filterTasks(tasks, filter)
+		if s.issuePaused(issueID) {
+			tasks = nil
+		}
 		if len(tasks) > 0 {
 			if len(tasks) > limit {
 				tasks = tasks[:limit]
@@ -613,10 +701,8 @@ func (s *IssueService) WaitIssueTasks(issueID, status string, timeoutSec, limit
 		if remaining <= 0 {
 			return []IssueTask{}, nil
 		}
-		if remaining < poll {
-			time.Sleep(remaining)
-		} else {
-			time.Sleep(poll)
+		if err := s.waitChange(ctx, since, min(remaining, pollInterval)); err != nil {
+			return nil, err
 		}
 	}
 }
@@ -0,0 +1,96 @@
+package swarm
+
+import (
+	"os"
+)
+
+// PreviewDoc is a required doc as shown by PreviewTask; Missing marks a doc the task requires but that
+// has not been written yet (the task cannot be claimed until it is).
+type PreviewDoc struct {
+	Name    string `json:"name"`
+	Content string `json:"content,omitempty"`
+	Missing bool   `json:"missing,omitempty"`
+}
+
+// TaskPreview is the read-only view of a task a worker gets before claiming it.
+type TaskPreview struct {
+	IssueID        string       `json:"issue_id"`
+	IssueSubject   string       `json:"issue_subject"`
+	TaskID         string       `json:"task_id"`
+	Subject        string       `json:"subject"`
+	Description    string       `json:"description"`
+	Difficulty     string       `json:"difficulty"`
+	Points         int          `json:"points"`
+	Status         string       `json:"status"`
+	Claimable      bool         `json:"claimable"`
+	NotReady       string       `json:"not_ready,omitempty"` // the failing claim check, when the issue's claim_checks block the claim
+	Labels         []string     `json:"labels,omitempty"`
+	ImpactScope    string       `json:"impact_scope,omitempty"`
+	SuggestedFiles []string     `json:"suggested_files,omitempty"`
+	ContextTaskIDs []string     `json:"context_task_ids,omitempty"`
+	IssueDocs      []PreviewDoc `json:"issue_docs"`
+	TaskDocs       []PreviewDoc `json:"task_docs"`
+}
+
+// PreviewTask returns a task's spec and the content of its required issue and task docs without
+// claiming it, so a worker can judge whether to take the lease. Nothing is written.
+func (s *IssueService) PreviewTask(issueID, taskID string) (*TaskPreview, error) {
+	if issueID == "" || taskID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id and task_id are required")
+	}
+	var result *TaskPreview
+	err := s.store.WithLock(func() error {
+		var issue Issue
+		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+			return Errorf(CodeNotFound, "issue '%s' not found", issueID)
+		}
+		t, err := s.loadTaskLocked(issueID, taskID)
+		if err != nil {
+			return err
+		}
+		p := &TaskPreview{
+			IssueID:        issueID,
+			IssueSubject:   issue.Subject,
+			TaskID:         t.ID,
+			Subject:        t.Subject,
+			Description:    t.Description,
+			Difficulty:     t.Difficulty,
+			Points:         t.Points,
+			Status:         t.Status,
+			Claimable:      t.Status == IssueTaskOpen && t.ReservedToken == "" && issue.PausedAt == "",
+			Labels:         t.Labels,
+			ImpactScope:    t.ImpactScope,
+			SuggestedFiles: t.SuggestedFiles,
+			ContextTaskIDs: t.ContextTaskIDs,
+			IssueDocs:      []PreviewDoc{},
+			TaskDocs:       []PreviewDoc{},
+		}
+		for _, n := range t.RequiredIssueDocs {
+			p.IssueDocs = append(p.IssueDocs, readPreviewDoc(s.store.Path("issues", issueID, "docs", n+".md"), n))
+		}
+		for _, n := range t.RequiredTaskDocs {
+			p.TaskDocs = append(p.TaskDocs, readPreviewDoc(s.store.Path("issues", issueID, "tasks", t.ID+".docs", n+".md"), n))
+		}
+		for _, d := range p.IssueDocs {
+			p.Claimable = p.Claimable && !d.Missing
+		}
+		for _, d := range p.TaskDocs {
+			p.Claimable = p.Claimable && !d.Missing
+		}
+		if err := s.checkClaimReadyLocked(issueID, t); err != nil && p.Claimable {
+			p.Claimable = false
+			p.NotReady = err.Error()
+		}
+		result = p
+		return nil
+	})
+	return result, err
+}
+
+func readPreviewDoc(path, name string) PreviewDoc {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return PreviewDoc{Name: name, Missing: true}
+	}
+	return PreviewDoc{Name: name, Content: string(b)}
+}
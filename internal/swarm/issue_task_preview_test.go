@@ -0,0 +1,52 @@
+package swarm
+
+import "testing"
+
+func TestPreviewTask_ReturnsDocsWithoutClaiming(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Subject: "billing", Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := writeDocFile(store.Path("issues", issueID, "docs"), "user.md", "user story"); err != nil {
+		t.Fatalf("write issue doc: %v", err)
+	}
+	if err := writeDocFile(store.Path("issues", issueID, "tasks", "task-1.docs"), "spec.md", "do the thing"); err != nil {
+		t.Fatalf("write task doc: %v", err)
+	}
+	task := &IssueTask{ID: "task-1", IssueID: issueID, Subject: "invoice", Status: IssueTaskOpen, RequiredIssueDocs: []string{"user"}, RequiredTaskDocs: []string{"spec"}}
+	if err := svc.saveTaskLocked(issueID, task); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+
+	p, err := svc.PreviewTask(issueID, "task-1")
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if !p.Claimable || p.IssueSubject != "billing" {
+		t.Fatalf("unexpected preview: %+v", p)
+	}
+	if len(p.IssueDocs) != 1 || p.IssueDocs[0].Content != "user story" || len(p.TaskDocs) != 1 || p.TaskDocs[0].Content != "do the thing" {
+		t.Fatalf("expected doc contents, got %+v / %+v", p.IssueDocs, p.TaskDocs)
+	}
+	after, err := svc.GetTask(issueID, "task-1")
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if after.Status != IssueTaskOpen || after.ClaimedBy != "" || after.Rev != task.Rev {
+		t.Fatalf("preview must not change the task, got %+v", after)
+	}
+
+	if err := store.Remove(store.Path("issues", issueID, "tasks", "task-1.docs", "spec.md")); err != nil {
+		t.Fatalf("remove doc: %v", err)
+	}
+	p, err = svc.PreviewTask(issueID, "task-1")
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if p.Claimable || !p.TaskDocs[0].Missing {
+		t.Fatalf("expected missing spec to make the task unclaimable, got %+v", p)
+	}
+}
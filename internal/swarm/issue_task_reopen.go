@@ -0,0 +1,66 @@
+package swarm
+
+import (
+	"strings"
+)
+
+// ReopenTask moves a done task back to open for follow-up work. Unlike ResetTask nothing is deleted:
+// submissions, messages, review fields, docs and events stay as history. The claim, lease and any
+// reservation are cleared so the task can be claimed again, and ReopenedAt is recorded so deliveries
+// approved before the reopen no longer cover it. The issue must still be open.
+func (s *IssueService) ReopenTask(actor, issueID, taskID, reason string, expectedRev int64) (*IssueTask, error) {
+	if issueID == "" || taskID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id and task_id are required")
+	}
+	if actor == "" {
+		actor = "lead"
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		reason = "reopened for follow-up"
+	}
+
+	var result *IssueTask
+	err := s.store.WithLock(func() error {
+		var issue Issue
+		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+			return Errorf(CodeNotFound, "issue '%s' not found", issueID)
+		}
+		if issue.Status == IssueDone || issue.Status == IssueCanceled {
+			return Errorf(CodeInvalidState, "cannot reopen task: issue is %s; reopen the issue first", issue.Status)
+		}
+		task, err := s.loadTaskLocked(issueID, taskID)
+		if err != nil {
+			return err
+		}
+		if err := checkRev("task", taskID, expectedRev, task.Rev); err != nil {
+			return err
+		}
+		if task.Status != IssueTaskDone {
+			return Errorf(CodeInvalidState, "cannot reopen task: status must be done (status: %s)", task.Status)
+		}
+		if tok := strings.TrimSpace(task.ReservedToken); tok != "" {
+			_ = s.store.Remove(s.store.Path("issues", issueID, "next_steps", tok+".json"))
+		}
+
+		task.Status = IssueTaskOpen
+		task.ClaimedBy = ""
+		task.LeaseExpiresAtMs = 0
+		task.ReservedToken = ""
+		task.ReservedUntilMs = 0
+		task.NextStepToken = ""
+		task.ReopenedAt = NowStr()
+		task.UpdatedAt = task.ReopenedAt
+		if err := s.saveTaskLocked(issueID, task); err != nil {
+			return err
+		}
+		result = task
+		return s.appendEventLocked(issueID, IssueEvent{Type: EventIssueTaskReopened, IssueID: issueID, TaskID: task.ID, Actor: actor, Detail: reason, Timestamp: task.ReopenedAt})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.bump(issueID)
+	return result, nil
+}
@@ -0,0 +1,60 @@
+package swarm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReopenTask_KeepsHistoryAndDropsStaleDeliveryCoverage(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 2}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	done := &IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskDone, ClaimedBy: "w1", Verdict: VerdictApproved, Feedback: "lgtm"}
+	if err := svc.saveTaskLocked(issueID, done); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "submissions", "task-1", "sub-1.json"), &Submission{ID: "sub-1", IssueID: issueID, TaskID: "task-1", Status: SubmissionApproved}); err != nil {
+		t.Fatalf("write submission: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("deliveries", "d1.json"), &Delivery{ID: "d1", IssueID: issueID, Status: DeliveryApproved, ReviewedAt: "2020-01-01T00:00:00Z"}); err != nil {
+		t.Fatalf("write delivery: %v", err)
+	}
+	if got := svc.tasksWithoutApprovedDeliveryLocked(issueID, []IssueTask{*done}); len(got) != 0 {
+		t.Fatalf("expected task covered before reopen, got %v", got)
+	}
+
+	task, err := svc.ReopenTask("lead", issueID, "task-1", "handle currencies", done.Rev)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if task.Status != IssueTaskOpen || task.ClaimedBy != "" || task.ReopenedAt == "" {
+		t.Fatalf("expected open unclaimed task, got %+v", task)
+	}
+	if task.Verdict != VerdictApproved || task.Feedback != "lgtm" {
+		t.Fatalf("expected review history kept, got %+v", task)
+	}
+	if !store.Exists("issues", issueID, "submissions", "task-1", "sub-1.json") {
+		t.Fatalf("expected submission kept")
+	}
+	if got := svc.tasksWithoutApprovedDeliveryLocked(issueID, []IssueTask{*task}); len(got) != 1 {
+		t.Fatalf("expected stale delivery to no longer cover the task, got %v", got)
+	}
+	events, err := svc.ReadAllEvents(issueID)
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	if len(events) == 0 || events[len(events)-1].Type != EventIssueTaskReopened {
+		t.Fatalf("expected reopen event, got %+v", events)
+	}
+
+	if _, err := svc.ReopenTask("lead", issueID, "task-1", "", 0); err == nil || !strings.Contains(err.Error(), "must be done") {
+		t.Fatalf("expected error reopening an open task, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package swarm
+
+import (
+	"strings"
+)
+
+// defaultReserveSec is how long ReserveTask holds a task when the lead gives no ttl and the scheduler is
+// off; with the scheduler on, its reservation window applies.
+const defaultReserveSec = 600
+
+// ReserveTask holds an open task for workerID for ttlSec seconds (<= 0 = default), outside the review
+// flow of GetNextStepToken: e.g. to hand a newcomer a first task mid-issue. Like a scheduler assignment,
+// it mints a claim_task token only workerID can claim with and drops an assigned item in the worker's
+// inbox, so waitAssignment and waitWorkerInbox pick it up.
+func (s *IssueService) ReserveTask(actor, issueID, taskID, workerID string, ttlSec int) (*TaskAssignment, error) {
+	workerID = strings.TrimSpace(workerID)
+	if issueID == "" || taskID == "" || workerID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id, task_id and worker_id are required")
+	}
+	if actor == "" {
+		actor = "lead"
+	}
+	if ttlSec <= 0 {
+		ttlSec = defaultReserveSec
+		if s.scheduler != nil {
+			ttlSec = s.scheduler.reserveSec
+		}
+	}
+
+	var out *TaskAssignment
+	err := s.store.WithLock(func() error {
+		var issue Issue
+		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil {
+			return Errorf(CodeNotFound, "issue '%s' not found", issueID)
+		}
+		task, err := s.loadTaskLocked(issueID, taskID)
+		if err != nil {
+			return err
+		}
+		if task.Status != IssueTaskOpen {
+			return Errorf(CodeInvalidState, "task '%s' is not open (status: %s)", task.ID, task.Status)
+		}
+		nowMs := LeaseNowMs()
+		if task.ReservedToken != "" && (task.ReservedUntilMs == 0 || nowMs <= task.ReservedUntilMs) {
+			return Errorf(CodeReserved, "task '%s' is already reserved", task.ID).
+				With("reserved_until_ms", task.ReservedUntilMs)
+		}
+		until := nowMs + int64(ttlSec)*1000
+		live, err := s.reserveForWorkerLocked(issueID, task.ID, workerID, actor, "reserved for "+workerID, until)
+		if err != nil {
+			return err
+		}
+		if _, err := s.pushToWorkerInboxLocked(issueID, workerID, live.ID, InboxTypeAssigned, live.ReservedToken, actor); err != nil {
+			return err
+		}
+		out = &TaskAssignment{IssueID: issueID, TaskID: live.ID, WorkerID: workerID, NextStepToken: live.ReservedToken, ReservedUntilMs: until}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.bump(issueID)
+	return out, nil
+}
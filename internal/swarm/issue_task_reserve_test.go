@@ -0,0 +1,56 @@
+package swarm
+
+import (
+	"testing"
+)
+
+func TestReserveTask_HoldsTaskForWorker(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 2}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	if err := svc.saveTaskLocked(issueID, &IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskOpen}); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+
+	a, err := svc.ReserveTask("lead", issueID, "task-1", "newbie", 60)
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	if a.WorkerID != "newbie" || a.NextStepToken == "" || a.ReservedUntilMs == 0 {
+		t.Fatalf("unexpected assignment: %+v", a)
+	}
+
+	if _, err := svc.ReserveTask("lead", issueID, "task-1", "other", 60); ErrorCode(err) != CodeReserved {
+		t.Fatalf("expected reserved error on second reservation, got %v", err)
+	}
+	if _, err := svc.ClaimTask(issueID, "task-1", "other", "", 0); ErrorCode(err) != CodeReserved {
+		t.Fatalf("expected another worker to be turned away, got %v", err)
+	}
+
+	files := listJSONOrEmpty(store, store.Path("issues", issueID, "inbox", "workers", "newbie"))
+	if len(files) != 1 {
+		t.Fatalf("expected one worker inbox item, got %d", len(files))
+	}
+	var item InboxItem
+	if err := store.ReadJSON(files[0], &item); err != nil {
+		t.Fatalf("read inbox item: %v", err)
+	}
+	if item.Type != InboxTypeAssigned || item.RefID != a.NextStepToken {
+		t.Fatalf("unexpected inbox item: %+v", item)
+	}
+
+	task, err := svc.ClaimTask(issueID, "task-1", "newbie", a.NextStepToken, 0)
+	if err != nil {
+		t.Fatalf("claim with reserved token: %v", err)
+	}
+	if task.Status != IssueTaskInProgress || task.ClaimedBy != "newbie" {
+		t.Fatalf("unexpected task after claim: %+v", task)
+	}
+}
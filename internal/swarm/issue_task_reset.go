@@ -3,15 +3,14 @@ package swarm
 import (
 	"bufio"
 	"encoding/json"
-	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
-func (s *IssueService) ResetTask(actor, issueID, taskID, reason string) (*IssueTask, error) {
+func (s *IssueService) ResetTask(actor, issueID, taskID, reason string, expectedRev int64) (*IssueTask, error) {
 	if issueID == "" || taskID == "" {
-		return nil, fmt.Errorf("issue_id and task_id are required")
+		return nil, Errorf(CodeInvalidArgument, "issue_id and task_id are required")
 	}
 	if actor == "" {
 		actor = "lead"
@@ -24,13 +23,16 @@ func (s *IssueService) ResetTask(actor, issueID, taskID, reason string) (*IssueT
 	var result *IssueTask
 	err := s.store.WithLock(func() error {
 		if !s.store.Exists("issues", issueID, "issue.json") {
-			return fmt.Errorf("issue '%s' not found", issueID)
+			return Errorf(CodeNotFound, "issue '%s' not found", issueID)
 		}
 
 		task, err := s.loadTaskLocked(issueID, taskID)
 		if err != nil {
 			return err
 		}
+		if err := checkRev("task", taskID, expectedRev, task.Rev); err != nil {
+			return err
+		}
 		prevOwner := strings.TrimSpace(task.ClaimedBy)
 
 		// 1) Clear task reservation / tokens
@@ -65,7 +67,7 @@ func (s *IssueService) ResetTask(actor, issueID, taskID, reason string) (*IssueT
 				continue
 			}
 			for _, file := range lease.Files {
-				hash := PathHash(file)
+				hash := LockKey(lease.Scope, file)
 				lockPath := s.store.Path("locks", "files", hash+".json")
 				var fl FileLock
 				if err := s.store.ReadJSON(lockPath, &fl); err == nil && fl.LeaseID == lease.LeaseID {
@@ -104,6 +106,11 @@ func (s *IssueService) ResetTask(actor, issueID, taskID, reason string) (*IssueT
 		task.CompletionScore = 0
 		task.ReviewArtifacts = ReviewArtifacts{}
 		task.FeedbackDetails = nil
+		task.ClaimedAt = ""
+		task.SubmittedAt = ""
+		task.ReviewedAt = ""
+		task.EscalatedAt = ""
+		task.LeaseFrozenAt = ""
 		task.UpdatedAt = NowStr()
 
 		// 3b) Clean up Submission entities, TaskMessages, and inbox items for this task.
@@ -112,7 +119,12 @@ func (s *IssueService) ResetTask(actor, issueID, taskID, reason string) (*IssueT
 		s.deleteInboxForTaskLocked(issueID, taskID)
 
 		eventsPath := s.store.Path("issues", issueID, "events.jsonl")
-		if f, err := os.Open(eventsPath); err == nil {
+		if s.eventsSharded(issueID) {
+			// The task's events are all in its own shard.
+			if err := os.Remove(s.store.Path("issues", issueID, "events", taskID+".jsonl")); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		} else if f, err := os.Open(eventsPath); err == nil {
 			tmp := eventsPath + ".tmp"
 			out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
 			if err == nil {
@@ -185,7 +197,7 @@ func (s *IssueService) ResetTask(actor, issueID, taskID, reason string) (*IssueT
 			return nil
 		})
 
-		if err := s.store.WriteJSON(s.store.Path("issues", issueID, "tasks", task.ID+".json"), task); err != nil {
+		if err := s.saveTaskLocked(issueID, task); err != nil {
 			return err
 		}
 		result = task
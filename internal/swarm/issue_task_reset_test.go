@@ -127,7 +127,7 @@ func TestResetTask_ClearsAllProgressAndCleansLocksAndDocs(t *testing.T) {
 		t.Fatalf("write file lock: %v", err)
 	}
 
-	out, err := svc.ResetTask("lead", issueID, taskID, "because")
+	out, err := svc.ResetTask("lead", issueID, taskID, "because", 0)
 	if err != nil {
 		t.Fatalf("reset: %v", err)
 	}
@@ -0,0 +1,111 @@
+package swarm
+
+import (
+	"slices"
+	"strings"
+)
+
+// TaskUpdate lists the task fields UpdateTask changes. Nil fields are kept; a non-nil empty slice clears
+// the list.
+type TaskUpdate struct {
+	Subject        *string
+	Description    *string
+	Points         *int
+	Difficulty     *string
+	Labels         []string
+	SuggestedFiles []string
+}
+
+// UpdateTask edits the metadata of an open task, e.g. when a worker's question shows the spec needs
+// adjusting before anyone claims it. Raising the points is checked against a strict points budget.
+// Logs an issue_task_updated event naming the changed fields; an update that changes nothing is a no-op.
+func (s *IssueService) UpdateTask(actor, issueID, taskID string, upd TaskUpdate, expectedRev int64) (*IssueTask, error) {
+	if issueID == "" || taskID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id and task_id are required")
+	}
+	if actor == "" {
+		actor = "lead"
+	}
+	if upd.Subject != nil && strings.TrimSpace(*upd.Subject) == "" {
+		return nil, Errorf(CodeInvalidArgument, "subject must not be empty")
+	}
+	if d := upd.Difficulty; d != nil && *d != "easy" && *d != "medium" && *d != "focus" {
+		return nil, Errorf(CodeInvalidArgument, "invalid difficulty: %s", *d)
+	}
+	if upd.Points != nil && *upd.Points < 0 {
+		return nil, Errorf(CodeInvalidArgument, "points must not be negative")
+	}
+
+	var result *IssueTask
+	changed := false
+	err := s.store.WithLock(func() error {
+		if !s.store.Exists("issues", issueID, "issue.json") {
+			return Errorf(CodeNotFound, "issue '%s' not found", issueID)
+		}
+		task, err := s.loadTaskLocked(issueID, taskID)
+		if err != nil {
+			return err
+		}
+		if err := checkRev("task", taskID, expectedRev, task.Rev); err != nil {
+			return err
+		}
+		if task.Status != IssueTaskOpen {
+			return Errorf(CodeInvalidState, "cannot update task: status must be open (status: %s)", task.Status)
+		}
+
+		var fields []string
+		if upd.Subject != nil && *upd.Subject != task.Subject {
+			task.Subject = *upd.Subject
+			fields = append(fields, "subject")
+		}
+		if upd.Description != nil && *upd.Description != task.Description {
+			task.Description = *upd.Description
+			fields = append(fields, "description")
+		}
+		if upd.Points != nil && *upd.Points != task.Points {
+			if added := *upd.Points - task.Points; added > 0 {
+				if err := s.checkPointsBudgetLocked(issueID, added); err != nil {
+					return err
+				}
+			}
+			task.Points = *upd.Points
+			fields = append(fields, "points")
+		}
+		if upd.Difficulty != nil && *upd.Difficulty != task.Difficulty {
+			task.Difficulty = *upd.Difficulty
+			fields = append(fields, "difficulty")
+		}
+		if upd.Labels != nil && !slices.Equal(upd.Labels, task.Labels) {
+			task.Labels = upd.Labels
+			fields = append(fields, "labels")
+		}
+		if upd.SuggestedFiles != nil && !slices.Equal(upd.SuggestedFiles, task.SuggestedFiles) {
+			task.SuggestedFiles = upd.SuggestedFiles
+			fields = append(fields, "suggested_files")
+		}
+		result = task
+		if len(fields) == 0 {
+			return nil
+		}
+		changed = true
+		task.UpdatedAt = NowStr()
+		if err := s.saveTaskLocked(issueID, task); err != nil {
+			return err
+		}
+		return s.appendEventLocked(issueID, IssueEvent{
+			Type:      EventIssueTaskUpdated,
+			IssueID:   issueID,
+			TaskID:    task.ID,
+			Actor:     actor,
+			Detail:    strings.Join(fields, ", "),
+			Timestamp: task.UpdatedAt,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		s.bump(issueID)
+	}
+	return result, nil
+}
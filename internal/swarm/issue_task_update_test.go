@@ -0,0 +1,61 @@
+package swarm
+
+import (
+	"testing"
+)
+
+func TestUpdateTask(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen, PointsBudget: 10, BudgetStrict: true}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 3}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	for _, task := range []*IssueTask{
+		{ID: "task-1", IssueID: issueID, Subject: "old", Difficulty: "easy", Points: 3, Status: IssueTaskOpen, Labels: []string{"backend"}},
+		{ID: "task-2", IssueID: issueID, Subject: "busy", Difficulty: "easy", Points: 3, Status: IssueTaskInProgress},
+	} {
+		if err := svc.saveTaskLocked(issueID, task); err != nil {
+			t.Fatalf("write task: %v", err)
+		}
+	}
+
+	subject, points, difficulty := "new", 5, "medium"
+	task, err := svc.UpdateTask("lead", issueID, "task-1", TaskUpdate{
+		Subject:        &subject,
+		Points:         &points,
+		Difficulty:     &difficulty,
+		Labels:         []string{},
+		SuggestedFiles: []string{"a.go"},
+	}, 1)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if task.Subject != "new" || task.Points != 5 || task.Difficulty != "medium" || len(task.Labels) != 0 || len(task.SuggestedFiles) != 1 || task.Rev != 2 {
+		t.Fatalf("unexpected task after update: %+v", task)
+	}
+
+	events, err := svc.ReadAllEvents(issueID)
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	last := events[len(events)-1]
+	if last.Type != EventIssueTaskUpdated || last.Detail != "subject, points, difficulty, labels, suggested_files" {
+		t.Fatalf("unexpected event: %+v", last)
+	}
+
+	if _, err := svc.UpdateTask("lead", issueID, "task-1", TaskUpdate{Subject: &subject}, 1); ErrorCode(err) != CodeVersionConflict {
+		t.Fatalf("expected version conflict for a stale rev, got %v", err)
+	}
+	tooMany := 8
+	if _, err := svc.UpdateTask("lead", issueID, "task-1", TaskUpdate{Points: &tooMany}, 0); ErrorCode(err) != CodeLimitExceeded {
+		t.Fatalf("expected the strict budget to reject the raise, got %v", err)
+	}
+	if _, err := svc.UpdateTask("lead", issueID, "task-2", TaskUpdate{Subject: &subject}, 0); ErrorCode(err) != CodeInvalidState {
+		t.Fatalf("expected in-progress task to be rejected, got %v", err)
+	}
+}
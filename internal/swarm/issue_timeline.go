@@ -0,0 +1,166 @@
+package swarm
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Timeline entry kinds.
+const (
+	TimelineEvent      = "event"
+	TimelineMessage    = "message"
+	TimelineSubmission = "submission"
+	TimelineDelivery   = "delivery"
+)
+
+const timelineSummaryMax = 200
+
+// TimelineEntry is one dated record in an issue's timeline. Messages, submissions and deliveries
+// contribute one entry when created and another when replied to or reviewed (ID gets a "#reply" or
+// "#review" suffix), so the timeline reads as a sequence of things that happened.
+type TimelineEntry struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"` // TimelineEvent/TimelineMessage/TimelineSubmission/TimelineDelivery
+	Type      string `json:"type"` // event type, message kind, or created/replied/reviewed
+	At        string `json:"at"`
+	Actor     string `json:"actor,omitempty"`
+	TaskID    string `json:"task_id,omitempty"`
+	RefID     string `json:"ref_id,omitempty"` // submission/message/delivery the entry is about
+	Status    string `json:"status,omitempty"`
+	Summary   string `json:"summary,omitempty"`
+	Artifacts string `json:"artifacts,omitempty"` // e.g. "3 changed file(s); tests: pass"
+}
+
+// GetIssueTimeline merges the issue's event log, task messages, submissions and deliveries into one
+// list ordered by time (oldest first; ties by ID). A non-empty taskID keeps only that task's entries
+// and drops deliveries that do not cover it.
+func (s *IssueService) GetIssueTimeline(issueID, taskID string) ([]TimelineEntry, error) {
+	if issueID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id is required")
+	}
+	if !s.store.Exists("issues", issueID, "issue.json") {
+		return nil, Errorf(CodeNotFound, "issue '%s' not found", issueID)
+	}
+	var out []TimelineEntry
+	add := func(e TimelineEntry) {
+		if e.At == "" || taskID != "" && e.TaskID != taskID && e.Kind != TimelineDelivery {
+			return
+		}
+		e.Summary = clipSummary(e.Summary)
+		out = append(out, e)
+	}
+
+	var events []IssueEvent
+	var err error
+	if taskID != "" {
+		events, err = s.readTaskEvents(issueID, taskID)
+	} else {
+		events, err = s.ReadAllEvents(issueID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, ev := range events {
+		e := TimelineEntry{
+			ID:      fmt.Sprintf("ev-%d", ev.Seq),
+			Kind:    TimelineEvent,
+			Type:    ev.Type,
+			At:      ev.Timestamp,
+			Actor:   ev.Actor,
+			TaskID:  ev.TaskID,
+			RefID:   firstNonEmpty(ev.SubmissionID, ev.MessageID),
+			Summary: ev.Detail,
+		}
+		if ev.SubmissionArtifacts != nil {
+			e.Artifacts = submissionArtifactSummary(*ev.SubmissionArtifacts)
+		} else if ev.DeliveryArtifacts != nil {
+			e.Artifacts = deliveryArtifactSummary(*ev.DeliveryArtifacts)
+		}
+		add(e)
+	}
+
+	msgs, err := s.ListTaskMessages(issueID, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range msgs {
+		add(TimelineEntry{ID: m.ID, Kind: TimelineMessage, Type: m.Kind, At: m.CreatedAt, Actor: m.SenderID, TaskID: m.TaskID, RefID: m.ID, Status: m.Status, Summary: m.Content})
+		if m.RepliedAt != "" {
+			add(TimelineEntry{ID: m.ID + "#reply", Kind: TimelineMessage, Type: "replied", At: m.RepliedAt, Actor: m.ReplyBy, TaskID: m.TaskID, RefID: m.ID, Status: m.Status, Summary: m.ReplyContent})
+		}
+	}
+
+	taskDirs, _ := os.ReadDir(s.store.Path("issues", issueID, "submissions"))
+	for _, d := range taskDirs {
+		if !d.IsDir() {
+			continue
+		}
+		subs, err := s.ListSubmissions(issueID, d.Name())
+		if err != nil {
+			return nil, err
+		}
+		for _, sub := range subs {
+			add(TimelineEntry{ID: sub.ID, Kind: TimelineSubmission, Type: "created", At: sub.CreatedAt, Actor: sub.WorkerID, TaskID: sub.TaskID, RefID: sub.ID, Status: sub.Status,
+				Summary: sub.Artifacts.Summary, Artifacts: submissionArtifactSummary(sub.Artifacts)})
+			if sub.ReviewedAt != "" {
+				add(TimelineEntry{ID: sub.ID + "#review", Kind: TimelineSubmission, Type: "reviewed", At: sub.ReviewedAt, Actor: sub.ReviewedBy, TaskID: sub.TaskID, RefID: sub.ID, Status: sub.Status,
+					Summary: firstNonEmpty(sub.Feedback, sub.ReviewArtifacts.ReviewSummary)})
+			}
+		}
+	}
+
+	deliveries, err := s.ListDeliveries("", issueID, "", "")
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deliveries {
+		if taskID != "" && len(d.TaskIDs) > 0 && !slices.Contains(d.TaskIDs, taskID) {
+			continue
+		}
+		add(TimelineEntry{ID: d.ID, Kind: TimelineDelivery, Type: "created", At: d.DeliveredAt, Actor: d.DeliveredBy, RefID: d.ID, Status: d.Status,
+			Summary: d.Summary, Artifacts: deliveryArtifactSummary(d.Artifacts)})
+		if d.ReviewedAt != "" {
+			add(TimelineEntry{ID: d.ID + "#review", Kind: TimelineDelivery, Type: "reviewed", At: d.ReviewedAt, Actor: d.ReviewedBy, RefID: d.ID, Status: d.Status, Summary: d.Feedback})
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].At != out[j].At {
+			return out[i].At < out[j].At
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+func submissionArtifactSummary(a SubmissionArtifacts) string {
+	var parts []string
+	if n := len(a.ChangedFiles); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d changed file(s)", n))
+	}
+	if n := len(a.TestCases); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d test case(s)", n))
+	}
+	if a.TestResult != "" {
+		parts = append(parts, "tests: "+a.TestResult)
+	}
+	if n := len(a.Links); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d link(s)", n))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func deliveryArtifactSummary(a DeliveryArtifacts) string {
+	return submissionArtifactSummary(SubmissionArtifacts{ChangedFiles: a.ChangedFiles, TestCases: a.TestCases, TestResult: a.TestResult, Links: a.Links})
+}
+
+func clipSummary(s string) string {
+	s = strings.TrimSpace(s)
+	if r := []rune(s); len(r) > timelineSummaryMax {
+		return string(r[:timelineSummaryMax]) + "…"
+	}
+	return s
+}
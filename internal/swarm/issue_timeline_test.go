@@ -0,0 +1,61 @@
+package swarm
+
+import "testing"
+
+func TestGetIssueTimeline_MergesRecordsInOrder(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueInProgress}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 3}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	for _, ev := range []IssueEvent{
+		{Seq: 1, Type: EventIssueCreated, Actor: "lead", Timestamp: "2024-01-01T09:00:00Z"},
+		{Seq: 2, Type: EventIssueTaskClaimed, Actor: "w1", TaskID: "task-1", Timestamp: "2024-01-01T09:10:00Z"},
+		{Seq: 3, Type: EventIssueTaskClaimed, Actor: "w2", TaskID: "task-2", Timestamp: "2024-01-01T09:20:00Z"},
+	} {
+		ev.IssueID = issueID
+		if err := svc.appendEventLocked(issueID, ev); err != nil {
+			t.Fatalf("append event: %v", err)
+		}
+	}
+	msg := TaskMessage{ID: "msg-1", IssueID: issueID, TaskID: "task-1", SenderID: "w1", Kind: "question", Content: "which API?", Status: "replied",
+		ReplyContent: "v2", ReplyBy: "lead", CreatedAt: "2024-01-01T09:30:00Z", RepliedAt: "2024-01-01T09:40:00Z"}
+	if err := store.WriteJSON(store.Path("issues", issueID, "messages", msg.ID+".json"), &msg); err != nil {
+		t.Fatalf("write message: %v", err)
+	}
+	sub := Submission{ID: "sub-1", IssueID: issueID, TaskID: "task-1", WorkerID: "w1", Status: SubmissionApproved,
+		Artifacts:  SubmissionArtifacts{Summary: "done", ChangedFiles: []string{"a.go", "b.go"}, TestResult: "pass"},
+		ReviewedBy: "lead", ReviewedAt: "2024-01-01T10:30:00Z", CreatedAt: "2024-01-01T10:00:00Z"}
+	if err := store.WriteJSON(store.Path("issues", issueID, "submissions", "task-1", sub.ID+".json"), &sub); err != nil {
+		t.Fatalf("write submission: %v", err)
+	}
+	dlv := Delivery{ID: "dlv-1", IssueID: issueID, Summary: "ship it", Status: DeliveryOpen, DeliveredBy: "lead", DeliveredAt: "2024-01-01T11:00:00Z"}
+	if err := store.WriteJSON(store.Path("deliveries", dlv.ID+".json"), &dlv); err != nil {
+		t.Fatalf("write delivery: %v", err)
+	}
+
+	entries, err := svc.GetIssueTimeline(issueID, "task-1")
+	if err != nil {
+		t.Fatalf("timeline: %v", err)
+	}
+	want := []string{"ev-2", "msg-1", "msg-1#reply", "sub-1", "sub-1#review", "dlv-1"}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %v, got %+v", want, entries)
+	}
+	for i, id := range want {
+		if entries[i].ID != id {
+			t.Fatalf("entry %d: expected %s, got %+v", i, id, entries[i])
+		}
+	}
+	if entries[3].Artifacts != "2 changed file(s); tests: pass" || entries[4].Actor != "lead" {
+		t.Fatalf("unexpected submission entries %+v %+v", entries[3], entries[4])
+	}
+	if all, _ := svc.GetIssueTimeline(issueID, ""); len(all) != 8 {
+		t.Fatalf("expected 8 entries for the whole issue, got %d", len(all))
+	}
+}
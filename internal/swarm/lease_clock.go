@@ -0,0 +1,187 @@
+package swarm
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Lease clock. Lease expiries are stored as wall-clock times so every process sharing the store can read
+// them, but comparing them against time.Now() lets an NTP step expire every lease at once (clock jumps
+// forward) or keep them alive far too long (jumps back). Lease arithmetic therefore reads "now" from
+// leaseNow: the wall clock plus an offset. When the wall clock moves away from where the monotonic clock
+// says it should be, the offset absorbs the step so the lease clock keeps running, and then decays toward
+// zero by at most 1/leaseClockSlewRatio of the elapsed time, the way adjtime absorbs a correction.
+//
+// Every process sees the step, but one started after it has nothing to compare against; so the offset is
+// published to <root>/clock.json (ShareLeaseClock) and adopted by the other processes on that store,
+// which all then decay the same offset from the same moment and agree on the lease time.
+
+// leaseClockSlewRatio: per this many ms of elapsed time, the lease clock moves at most 1 ms toward the
+// wall clock.
+const leaseClockSlewRatio = 10
+
+// leaseClockStepMs is how far the wall clock must jump against the monotonic clock to count as a step;
+// smaller differences are ordinary drift correction.
+const leaseClockStepMs = 1000
+
+// leaseClockMaxOffsetMs bounds the offset. The monotonic clock stops while the host is suspended, so a
+// forward jump beyond this is taken as time that really passed during a sleep, not as a step; any offset
+// kept, published or adopted is clamped to it, so no process runs leases hours off the wall clock.
+const leaseClockMaxOffsetMs = int64(5 * 60 * 1000)
+
+// leaseClockCheckInterval is how often a process looks for an offset published by another one.
+const leaseClockCheckInterval = time.Second
+
+// ClockSkewWarnMs is the difference between the wall clock and the lease clock above which responses
+// carrying leases include a clock_skew_warning.
+const ClockSkewWarnMs = 2000
+
+// leaseClockState is the shared offset: the lease clock ran OffsetMs ahead of the wall clock at wall time
+// AtMs, decaying from there.
+type leaseClockState struct {
+	OffsetMs int64 `json:"offset_ms"`
+	AtMs     int64 `json:"at_ms"`
+}
+
+type leaseClock struct {
+	mu       sync.Mutex
+	read     func() (wallMs int64, mono time.Duration)
+	lastWall int64
+	lastMono time.Duration
+	started  bool
+	state    leaseClockState
+	shared   string        // clock.json of the store the offset is shared through, "" when not shared
+	checked  time.Duration // mono time the shared file was last looked at
+	sharedAt time.Time     // its mtime then
+}
+
+var clockStart = time.Now()
+
+func systemClock() (int64, time.Duration) {
+	now := time.Now()
+	return now.UnixMilli(), now.Sub(clockStart)
+}
+
+var leaseClk = &leaseClock{read: systemClock}
+
+// ShareLeaseClock makes this process share its lease clock offset through store, so processes started
+// before and after a clock step agree on lease expiry.
+func ShareLeaseClock(store *Store) {
+	leaseClk.mu.Lock()
+	defer leaseClk.mu.Unlock()
+	leaseClk.shared = store.Path("clock.json")
+	leaseClk.sharedAt = time.Time{}
+	leaseClk.adoptLocked()
+}
+
+// offsetAt returns the offset at wall time wallMs, decayed from the last step.
+func (st leaseClockState) offsetAt(wallMs int64) int64 {
+	decay := max(wallMs-st.AtMs, 0) / leaseClockSlewRatio
+	if st.OffsetMs > 0 {
+		return max(st.OffsetMs-decay, 0)
+	}
+	return min(st.OffsetMs+decay, 0)
+}
+
+func (c *leaseClock) nowMs() (lease, wall int64) {
+	wall, mono := c.read()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started {
+		expected := c.lastWall + (mono - c.lastMono).Milliseconds()
+		switch step := wall - expected; {
+		case step > leaseClockMaxOffsetMs:
+			// A resume from suspend: the wall clock is right and leases did run out meanwhile.
+		case step > leaseClockStepMs || step < -leaseClockStepMs:
+			// Keep the lease clock where it was: the offset takes the step, then decays from now.
+			c.state = leaseClockState{OffsetMs: clampLeaseOffset(c.state.offsetAt(expected) - step), AtMs: wall}
+			c.publishLocked(wall)
+		}
+	}
+	c.started = true
+	c.lastWall, c.lastMono = wall, mono
+	if c.shared != "" && mono-c.checked >= leaseClockCheckInterval {
+		c.checked = mono
+		c.adoptLocked()
+	}
+	return wall + c.state.offsetAt(wall), wall
+}
+
+func clampLeaseOffset(ms int64) int64 {
+	return min(max(ms, -leaseClockMaxOffsetMs), leaseClockMaxOffsetMs)
+}
+
+// readSharedLocked returns the offset published in the shared file, if it changed since the last look.
+func (c *leaseClock) readSharedLocked() (leaseClockState, bool) {
+	info, err := os.Stat(c.shared)
+	if err != nil || info.ModTime().Equal(c.sharedAt) {
+		return leaseClockState{}, false
+	}
+	data, err := os.ReadFile(c.shared)
+	if err != nil {
+		return leaseClockState{}, false
+	}
+	var st leaseClockState
+	if json.Unmarshal(data, &st) != nil {
+		return leaseClockState{}, false
+	}
+	st.OffsetMs = clampLeaseOffset(st.OffsetMs)
+	c.sharedAt = info.ModTime()
+	return st, true
+}
+
+// adoptLocked takes over an offset another process published after our own last step.
+func (c *leaseClock) adoptLocked() {
+	if st, ok := c.readSharedLocked(); ok && st.AtMs > c.state.AtMs {
+		c.state = st
+	}
+}
+
+// publishLocked shares the offset of a step this process just saw. If another process already published
+// the same step, its offset is adopted instead, so every process decays from the same moment.
+func (c *leaseClock) publishLocked(wall int64) {
+	if c.shared == "" {
+		return
+	}
+	c.sharedAt = time.Time{}
+	if st, ok := c.readSharedLocked(); ok {
+		if d := st.offsetAt(wall) - c.state.offsetAt(wall); d <= leaseClockStepMs && d >= -leaseClockStepMs {
+			c.state = st
+			return
+		}
+	}
+	data, err := json.Marshal(c.state)
+	if err != nil {
+		return
+	}
+	// A unique temp file: other processes may publish at the same moment, outside the store lock.
+	f, err := os.CreateTemp(filepath.Dir(c.shared), ".clock-*.tmp")
+	if err != nil {
+		return
+	}
+	_, werr := f.Write(data)
+	if cerr := f.Close(); werr != nil || cerr != nil || os.Rename(f.Name(), c.shared) != nil {
+		_ = os.Remove(f.Name())
+	}
+}
+
+// LeaseNowMs returns the current time in unix ms as used for lease expiry.
+func LeaseNowMs() int64 {
+	ms, _ := leaseClk.nowMs()
+	return ms
+}
+
+// leaseNow returns LeaseNowMs as a UTC time, for leases stored as RFC 3339 strings.
+func leaseNow() time.Time {
+	return time.UnixMilli(LeaseNowMs()).UTC()
+}
+
+// ClockSkewMs returns how far the wall clock is ahead of the lease clock (negative: behind). It is 0
+// unless the wall clock was stepped recently and the lease clock has not caught up yet.
+func ClockSkewMs() int64 {
+	lease, wall := leaseClk.nowMs()
+	return wall - lease
+}
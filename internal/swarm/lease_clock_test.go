@@ -0,0 +1,123 @@
+package swarm
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a wall and a monotonic clock that tests move separately.
+type fakeClock struct {
+	wall int64
+	mono time.Duration
+}
+
+func (f *fakeClock) advance(d time.Duration) {
+	f.wall += d.Milliseconds()
+	f.mono += d
+}
+
+func (f *fakeClock) read() (int64, time.Duration) { return f.wall, f.mono }
+
+func TestLeaseClock_AbsorbsStepAndSlews(t *testing.T) {
+	f := &fakeClock{wall: time.Now().UnixMilli()}
+	c := &leaseClock{read: f.read}
+	before, _ := c.nowMs()
+
+	// The wall clock steps two minutes ahead.
+	f.advance(time.Second)
+	f.wall += (2 * time.Minute).Milliseconds()
+	first, wall := c.nowMs()
+	if first-before != 1000 {
+		t.Fatalf("expected the lease clock to ignore the step, advanced %dms", first-before)
+	}
+	skew := wall - first
+	if skew != (2 * time.Minute).Milliseconds() {
+		t.Fatalf("expected two minutes of skew, got %dms", skew)
+	}
+
+	// Leases run at most 1/leaseClockSlewRatio fast: no mass expiry.
+	f.advance(10 * time.Second)
+	second, wall := c.nowMs()
+	if elapsed := second - first; elapsed != 11000 {
+		t.Fatalf("lease clock advanced %dms over 10s", elapsed)
+	}
+	if wall-second != skew-1000 {
+		t.Fatalf("skew should shrink by 1s, got %dms", wall-second)
+	}
+
+	// Ten times the step later the clocks agree again.
+	f.advance(20 * time.Minute)
+	if lease, wall := c.nowMs(); lease != wall {
+		t.Fatalf("expected the skew gone, got %dms", wall-lease)
+	}
+}
+
+func TestLeaseClock_FollowsSteadyWallClock(t *testing.T) {
+	f := &fakeClock{wall: time.Now().UnixMilli()}
+	c := &leaseClock{read: f.read}
+	for i := 0; i < 5; i++ {
+		f.advance(time.Minute)
+		f.wall += 200 // drift correction below the step threshold
+		if lease, wall := c.nowMs(); lease != wall {
+			t.Fatalf("expected no skew, got %dms", wall-lease)
+		}
+	}
+}
+
+func TestLeaseClock_SharedAcrossProcesses(t *testing.T) {
+	store := NewStore(t.TempDir())
+	f := &fakeClock{wall: time.Now().UnixMilli()}
+	old := &leaseClock{read: f.read, shared: store.Path("clock.json")}
+	old.nowMs()
+
+	// The wall clock steps back two minutes; a process started after the step knows nothing about it.
+	f.advance(time.Second)
+	f.wall -= (2 * time.Minute).Milliseconds()
+	oldLease, _ := old.nowMs()
+
+	fresh := &leaseClock{read: f.read, shared: store.Path("clock.json")}
+	fresh.adoptLocked()
+	freshLease, _ := fresh.nowMs()
+	if oldLease != freshLease {
+		t.Fatalf("processes disagree by %dms after the step", oldLease-freshLease)
+	}
+
+	// Both decay the same offset from the same moment.
+	f.advance(30 * time.Minute)
+	a, _ := old.nowMs()
+	b, _ := fresh.nowMs()
+	if a != b {
+		t.Fatalf("processes drifted apart by %dms while slewing", a-b)
+	}
+}
+
+func TestLeaseClock_ResumeFromSuspendIsNotAStep(t *testing.T) {
+	store := NewStore(t.TempDir())
+	f := &fakeClock{wall: time.Now().UnixMilli()}
+	c := &leaseClock{read: f.read, shared: store.Path("clock.json")}
+	c.nowMs()
+
+	// The host sleeps an hour: the monotonic clock stands still while the wall clock moves on.
+	f.wall += time.Hour.Milliseconds()
+	if lease, wall := c.nowMs(); lease != wall {
+		t.Fatalf("expected leases to follow the wall clock after a suspend, got %dms of skew", wall-lease)
+	}
+	if store.Exists("clock.json") {
+		t.Fatalf("a suspend must not be published to the other processes")
+	}
+
+	// A huge backward step is absorbed only up to the cap, and so is a published offset.
+	f.advance(time.Second)
+	f.wall -= time.Hour.Milliseconds()
+	if lease, wall := c.nowMs(); lease-wall != leaseClockMaxOffsetMs {
+		t.Fatalf("expected the offset capped at %dms, got %dms", leaseClockMaxOffsetMs, lease-wall)
+	}
+	if err := store.WriteJSON(store.Path("clock.json"), &leaseClockState{OffsetMs: time.Hour.Milliseconds(), AtMs: f.wall}); err != nil {
+		t.Fatalf("write clock: %v", err)
+	}
+	fresh := &leaseClock{read: f.read, shared: store.Path("clock.json")}
+	fresh.adoptLocked()
+	if lease, wall := fresh.nowMs(); lease-wall != leaseClockMaxOffsetMs {
+		t.Fatalf("expected an adopted offset capped at %dms, got %dms", leaseClockMaxOffsetMs, lease-wall)
+	}
+}
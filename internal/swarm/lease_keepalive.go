@@ -0,0 +1,74 @@
+package swarm
+
+import (
+	"context"
+	"time"
+)
+
+// Lease keepalive. SubmitTask, AskIssueTask and WaitSubmissionAnswer block the worker until the lead
+// answers, which can take longer than the task lease; the task must not expire and reopen while its
+// owner is still waiting inside the call. keepTaskLeaseAlive keeps pushing the lease out for as long as
+// the blocking call runs.
+
+// leaseKeepaliveInterval is how often a blocked owner's task lease is refreshed; each refresh makes the
+// lease last at least leaseKeepaliveMargin from then.
+const (
+	leaseKeepaliveInterval = 30 * time.Second
+	leaseKeepaliveMargin   = 3 * leaseKeepaliveInterval
+)
+
+// keepTaskLeaseAlive refreshes the lease of taskID while it stays claimed by actor, until the returned
+// stop func is called or ctx is done.
+func (s *IssueService) keepTaskLeaseAlive(ctx context.Context, issueID, taskID, actor string) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t := time.NewTicker(leaseKeepaliveInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if !s.refreshBlockedLease(issueID, taskID, actor) {
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// refreshBlockedLease makes the task lease last at least leaseKeepaliveMargin. It reports false once the
+// task is no longer claimed by actor, so the keepalive stops.
+func (s *IssueService) refreshBlockedLease(issueID, taskID, actor string) bool {
+	claimed := false
+	extended := false
+	_ = s.store.WithLock(func() error {
+		task, err := s.loadTaskLocked(issueID, taskID)
+		if err != nil || actor == "" || task.ClaimedBy != actor || (task.Status != IssueTaskInProgress && task.Status != IssueTaskBlocked) {
+			return nil
+		}
+		claimed = true
+		if task.LeaseFrozenAt != "" {
+			// An escalated task's lease is frozen already.
+			return nil
+		}
+		minLeaseMs := LeaseNowMs() + leaseKeepaliveMargin.Milliseconds()
+		if task.LeaseExpiresAtMs <= 0 || task.LeaseExpiresAtMs >= minLeaseMs {
+			return nil
+		}
+		task.LeaseExpiresAtMs = minLeaseMs
+		task.UpdatedAt = NowStr()
+		extended = s.saveTaskLocked(issueID, task) == nil
+		return nil
+	})
+	if extended {
+		s.bump(issueID)
+	}
+	return claimed
+}
@@ -0,0 +1,62 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRefreshBlockedLease_ExtendsOnlyTheOwnersShortLease(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	soon := LeaseNowMs() + 1000
+	if err := svc.saveTaskLocked(issueID, &IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskInProgress, ClaimedBy: "w1", LeaseExpiresAtMs: soon}); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+
+	if svc.refreshBlockedLease(issueID, "task-1", "w2") {
+		t.Fatalf("w2 does not own task-1")
+	}
+	if task, _ := svc.loadTaskLocked(issueID, "task-1"); task.LeaseExpiresAtMs != soon {
+		t.Fatalf("lease changed for a non-owner: %d", task.LeaseExpiresAtMs)
+	}
+
+	if !svc.refreshBlockedLease(issueID, "task-1", "w1") {
+		t.Fatalf("w1 owns task-1")
+	}
+	task, _ := svc.loadTaskLocked(issueID, "task-1")
+	if task.LeaseExpiresAtMs < LeaseNowMs()+leaseKeepaliveMargin.Milliseconds()-1000 {
+		t.Fatalf("lease not extended: %d", task.LeaseExpiresAtMs)
+	}
+
+	// Once the task is reopened the keepalive stops.
+	task.Status, task.ClaimedBy = IssueTaskOpen, ""
+	if err := svc.saveTaskLocked(issueID, task); err != nil {
+		t.Fatalf("save task: %v", err)
+	}
+	if svc.refreshBlockedLease(issueID, "task-1", "w1") {
+		t.Fatalf("keepalive should stop for a reopened task")
+	}
+}
+
+func TestKeepTaskLeaseAlive_StopReturnsPromptly(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	stop := svc.keepTaskLeaseAlive(context.Background(), "issue-1", "task-1", "w1")
+	done := make(chan struct{})
+	go func() {
+		stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("stop did not return")
+	}
+}
@@ -0,0 +1,137 @@
+package swarm
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetLeaseWarning sets how long before a task lease or file lock lease expires its owner is warned: a
+// lease_expiring item in the worker's inbox and an issue_task_lease_expiring event (lock_expiring in the
+// trace for file locks), once per expiry so an extended lease is warned about again. 0 disables it.
+func (s *IssueService) SetLeaseWarning(sec int) {
+	if sec < 0 {
+		sec = 0
+	}
+	s.leaseWarnSec = sec
+}
+
+// warnTaskLeaseLocked warns the owner of a claimed task whose lease runs out within the warning window
+// and returns the issue ID when it did. Must be called under store lock.
+func (s *IssueService) warnTaskLeaseLocked(issueID, taskID string, nowMs int64) string {
+	if s.leaseWarnSec <= 0 {
+		return ""
+	}
+	var issue Issue
+	if err := s.store.ReadJSON(s.store.Path("issues", issueID, "issue.json"), &issue); err != nil || issue.PausedAt != "" {
+		return ""
+	}
+	task, err := s.loadTaskLocked(issueID, taskID)
+	if err != nil || task.ClaimedBy == "" || (task.Status != IssueTaskInProgress && task.Status != IssueTaskBlocked) {
+		return ""
+	}
+	if task.LeaseFrozenAt != "" || task.LeaseExpiresAtMs <= 0 || task.LeaseWarnedForMs == task.LeaseExpiresAtMs {
+		return ""
+	}
+	remaining := task.LeaseExpiresAtMs - nowMs
+	if remaining <= 0 {
+		// Too late to warn; the expiry itself is due.
+		return ""
+	}
+	if remaining > int64(s.leaseWarnSec)*1000 {
+		s.trackTaskExpiry(issueID, task)
+		return ""
+	}
+	if _, err := s.pushToWorkerInboxLocked(issueID, task.ClaimedBy, task.ID, InboxTypeLeaseExpiring, "", "system"); err != nil {
+		return ""
+	}
+	task.LeaseWarnedForMs = task.LeaseExpiresAtMs
+	_ = s.saveTaskLocked(issueID, task)
+	_ = s.appendEventLocked(issueID, IssueEvent{
+		Type:      EventIssueTaskLeaseExpiring,
+		IssueID:   issueID,
+		TaskID:    task.ID,
+		Actor:     "system",
+		Detail:    fmt.Sprintf("task lease of %s expires in %s; extendIssueTaskLease to keep it", task.ClaimedBy, leaseRemaining(remaining)),
+		Timestamp: NowStr(),
+	})
+	return issueID
+}
+
+// trackLockWarning indexes the expiry warning due for a file lock lease (SetLeaseWarning).
+func (s *IssueService) trackLockWarning(l *Lease) {
+	exp, err := time.Parse(time.RFC3339, l.ExpiresAt)
+	if s.leaseWarnSec <= 0 || err != nil || l.WarnedFor == l.ExpiresAt {
+		s.expiry.forget(expiryLockWarn, "", l.LeaseID)
+		return
+	}
+	s.expiry.track(expiryEntry{DueMs: exp.UnixMilli() - int64(s.leaseWarnSec)*1000, Kind: expiryLockWarn, ID: l.LeaseID})
+}
+
+// warnLockLeaseLocked warns the owner of a file lock lease that runs out within the warning window and
+// returns the issue whose log changed, if any. A lease scoped to an issue that has its task also gets the
+// inbox item and event; every warning is traced as lock_expiring. Must be called under store lock.
+func (s *IssueService) warnLockLeaseLocked(leaseID string, nowMs int64) string {
+	if s.leaseWarnSec <= 0 {
+		return ""
+	}
+	path := s.store.Path("locks", "leases", leaseID+".json")
+	var l Lease
+	if err := s.store.ReadJSON(path, &l); err != nil || l.WarnedFor == l.ExpiresAt {
+		return ""
+	}
+	exp, err := time.Parse(time.RFC3339, l.ExpiresAt)
+	if err != nil {
+		return ""
+	}
+	remaining := exp.UnixMilli() - nowMs
+	if remaining <= 0 {
+		return ""
+	}
+	if remaining > int64(s.leaseWarnSec)*1000 {
+		s.trackLockWarning(&l)
+		return ""
+	}
+	l.WarnedFor = l.ExpiresAt
+	if err := s.store.WriteJSON(path, &l); err != nil {
+		return ""
+	}
+	detail := fmt.Sprintf("file lock lease %s of %s expires in %s; heartbeat to keep it", l.LeaseID, l.Owner, leaseRemaining(remaining))
+	s.trace.Log(TraceEvent{Type: EventLockExpiring, Actor: l.Owner, Subject: l.LeaseID, Detail: detail})
+	if l.Scope == "" || l.TaskID == "" || !s.store.Exists("issues", l.Scope, "tasks", l.TaskID+".json") {
+		return ""
+	}
+	if _, err := s.pushToWorkerInboxLocked(l.Scope, l.Owner, l.TaskID, InboxTypeLeaseExpiring, l.LeaseID, "system"); err != nil {
+		return ""
+	}
+	_ = s.appendEventLocked(l.Scope, IssueEvent{Type: EventIssueTaskLeaseExpiring, IssueID: l.Scope, TaskID: l.TaskID, Actor: "system", Detail: detail, Timestamp: NowStr()})
+	return l.Scope
+}
+
+// leaseExpiringDetails describes the lease a lease_expiring item is about, as it stands now.
+func (s *IssueService) leaseExpiringDetails(item *InboxItem, m map[string]any) {
+	nowMs := LeaseNowMs()
+	if item.RefID == "" {
+		m["lease_kind"] = "task"
+		if task, err := s.loadTaskLocked(item.IssueID, item.TaskID); err == nil {
+			m["lease_expires_at_ms"] = task.LeaseExpiresAtMs
+			m["lease_remaining_ms"] = max(task.LeaseExpiresAtMs-nowMs, 0)
+		}
+		return
+	}
+	m["lease_kind"] = "file_lock"
+	m["lease_id"] = item.RefID
+	var l Lease
+	if err := s.store.ReadJSON(s.store.Path("locks", "leases", item.RefID+".json"), &l); err != nil {
+		m["lease_remaining_ms"] = 0 // already released or expired
+		return
+	}
+	m["files"] = l.Files
+	m["expires_at"] = l.ExpiresAt
+	if exp, err := time.Parse(time.RFC3339, l.ExpiresAt); err == nil {
+		m["lease_remaining_ms"] = max(exp.UnixMilli()-nowMs, 0)
+	}
+}
+
+func leaseRemaining(ms int64) time.Duration {
+	return (time.Duration(ms) * time.Millisecond).Round(time.Second)
+}
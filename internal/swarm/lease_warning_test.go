@@ -0,0 +1,75 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSweepExpired_WarnsLeaseOwnersOncePerExpiry(t *testing.T) {
+	store := NewStore(t.TempDir())
+	trace := NewTraceService(store)
+	svc := NewIssueService(store, trace, 7200, 3600, 3600, 3600)
+	svc.SetLeaseWarning(120)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 2}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	if err := svc.saveTaskLocked(issueID, &IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskInProgress, ClaimedBy: "w1", LeaseExpiresAtMs: LeaseNowMs() + 60_000}); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	locks := NewLockService(store, trace)
+	lease, err := locks.LockFiles(context.Background(), "task-1", "w1", issueID, []string{"a.go"}, 90, 0)
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+
+	svc.SweepExpired()
+	svc.SweepExpired()
+
+	items, err := svc.GetWorkerInbox("w1", issueID, false, false)
+	if err != nil {
+		t.Fatalf("inbox: %v", err)
+	}
+	kinds := map[string]bool{}
+	for _, it := range items {
+		if it["type"] != InboxTypeLeaseExpiring {
+			t.Fatalf("unexpected item %+v", it)
+		}
+		kinds[it["lease_kind"].(string)] = true
+		if it["lease_kind"] == "file_lock" && it["lease_id"] != lease.LeaseID {
+			t.Fatalf("file lock warning names the wrong lease: %+v", it)
+		}
+	}
+	if len(items) != 2 || !kinds["task"] || !kinds["file_lock"] {
+		t.Fatalf("expected one task and one file lock warning, got %+v", items)
+	}
+	events, _ := svc.ReadAllEvents(issueID)
+	if len(events) != 2 || events[0].Type != EventIssueTaskLeaseExpiring {
+		t.Fatalf("expected two lease_expiring events, got %+v", events)
+	}
+
+	// An extension that still ends inside the window is warned about again.
+	if _, err := svc.ExtendIssueTaskLease("w1", issueID, "task-1", 100); err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	svc.SweepExpired()
+	items, _ = svc.GetWorkerInbox("w1", issueID, false, false)
+	if len(items) != 3 {
+		t.Fatalf("expected a new warning after the extension, got %d items", len(items))
+	}
+
+	// So is a file lock heartbeat, which the index picks up from the lease file on the next sweep.
+	if _, err := locks.Heartbeat(lease.LeaseID, 100); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	svc.SweepExpired()
+	svc.SweepExpired()
+	items, _ = svc.GetWorkerInbox("w1", issueID, false, false)
+	if len(items) != 4 {
+		t.Fatalf("expected a new file lock warning after the heartbeat, got %d items", len(items))
+	}
+}
@@ -1,6 +1,7 @@
 package swarm
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -19,12 +20,19 @@ func NewLockService(store *Store, trace *TraceService) *LockService {
 }
 
 // LockFiles acquires lease-based locks on multiple files atomically.
+// Entries may also be directories ("pkg/") or glob patterns ("src/payments/**"); they conflict with any
+// overlapping path or pattern held by another owner.
+// A non-empty scope (issue_id or project key) puts the locks in their own namespace: they only conflict
+// with locks of the same scope.
 // Files are sorted to avoid deadlock. On partial failure, all acquired locks are released.
-// If wait_sec > 0, retries with backoff until timeout.
-func (s *LockService) LockFiles(taskID, owner string, files []string, ttlSec, waitSec int) (*Lease, error) {
+// If wait_sec > 0, the caller queues on each contended file and retries until timeout; locks are handed
+// over first-come-first-served, so a later caller cannot take a file ahead of an earlier waiter. The wait
+// ends early, leaving the queue, when ctx is done.
+func (s *LockService) LockFiles(ctx context.Context, taskID, owner, scope string, files []string, ttlSec, waitSec int) (*Lease, error) {
 	if owner == "" || len(files) == 0 {
-		return nil, fmt.Errorf("owner and files are required")
+		return nil, Errorf(CodeInvalidArgument, "owner and files are required")
 	}
+	scope = strings.TrimSpace(scope)
 	if ttlSec <= 0 {
 		ttlSec = 120
 	}
@@ -35,20 +43,32 @@ func (s *LockService) LockFiles(taskID, owner string, files []string, ttlSec, wa
 	// Normalize and sort files to prevent deadlock
 	normalized := make([]string, len(files))
 	for i, f := range files {
-		normalized[i] = filepath.Clean(f)
+		spec, err := normalizeLockSpec(f)
+		if err != nil {
+			return nil, err
+		}
+		normalized[i] = spec
 	}
 	sort.Strings(normalized)
 
 	deadline := time.Now().Add(time.Duration(waitSec) * time.Second)
 	backoff := 500 * time.Millisecond
+	ticket := GenID("wait")
+	queued := false
 
 	for {
-		lease, err := s.tryLockFiles(taskID, owner, normalized, ttlSec)
+		lease, err := s.tryLockFiles(taskID, owner, scope, normalized, ttlSec, ticket)
 		if err == nil {
 			return lease, nil
 		}
 
 		if time.Now().After(deadline) {
+			if queued {
+				_ = s.store.WithLock(func() error {
+					s.dequeueWaiterLocked(scope, ticket, normalized)
+					return nil
+				})
+			}
 			s.trace.Log(TraceEvent{
 				Type:    EventLockFailed,
 				Actor:   owner,
@@ -57,26 +77,45 @@ func (s *LockService) LockFiles(taskID, owner string, files []string, ttlSec, wa
 			})
 			return nil, err
 		}
+		if err := s.enqueueWaiter(scope, ticket, owner, taskID, normalized); err == nil {
+			queued = true
+		}
 
-		time.Sleep(backoff)
+		select {
+		case <-ctx.Done():
+			if queued {
+				_ = s.store.WithLock(func() error {
+					s.dequeueWaiterLocked(scope, ticket, normalized)
+					return nil
+				})
+			}
+			s.trace.Log(TraceEvent{
+				Type:    EventLockFailed,
+				Actor:   owner,
+				Subject: strings.Join(normalized, ", "),
+				Detail:  fmt.Sprintf("wait cancelled (request %s): %v", RequestIDFromContext(ctx), ctx.Err()),
+			})
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
 		if backoff < 4*time.Second {
 			backoff = backoff * 3 / 2
 		}
 	}
 }
 
-func (s *LockService) tryLockFiles(taskID, owner string, files []string, ttlSec int) (*Lease, error) {
+func (s *LockService) tryLockFiles(taskID, owner, scope string, files []string, ttlSec int, ticket string) (*Lease, error) {
 	var acquired []string
 	leaseID := ""
 
 	err := s.store.WithLock(func() error {
-		now := time.Now().UTC()
+		now := leaseNow()
 		expiresAt := now.Add(time.Duration(ttlSec) * time.Second)
 
 		leaseID = GenID("l")
 
 		for _, file := range files {
-			hash := PathHash(file)
+			hash := LockKey(scope, file)
 			lockPath := s.store.Path("locks", "files", hash+".json")
 
 			// Check existing lock
@@ -89,11 +128,11 @@ func (s *LockService) tryLockFiles(taskID, owner string, files []string, ttlSec
 					if existing.Owner != owner {
 						// Release all acquired locks
 						for _, af := range acquired {
-							ah := PathHash(af)
+							ah := LockKey(scope, af)
 							_ = s.store.Remove(s.store.Path("locks", "files", ah+".json"))
 						}
-						return fmt.Errorf("file '%s' locked by '%s' (task: %s, expires: %s)",
-							file, existing.Owner, existing.TaskID, existing.ExpiresAt)
+						holder := existing
+						return s.lockConflictLocked(LockConflictHeld, scope, file, &holder, now)
 					}
 					// Same owner - reentrant, update the lock
 				} else {
@@ -107,10 +146,29 @@ func (s *LockService) tryLockFiles(taskID, owner string, files []string, ttlSec
 				}
 			}
 
+			if w := s.queuedAheadLocked(scope, file, owner, ticket, now); w != nil {
+				for _, af := range acquired {
+					ah := LockKey(scope, af)
+					_ = s.store.Remove(s.store.Path("locks", "files", ah+".json"))
+				}
+				conflict := s.lockConflictLocked(LockConflictQueued, scope, file, nil, now)
+				conflict.blockedBy = w
+				return conflict
+			}
+
+			if other := s.overlappingLockLocked(scope, file, owner, now); other != nil {
+				for _, af := range acquired {
+					ah := LockKey(scope, af)
+					_ = s.store.Remove(s.store.Path("locks", "files", ah+".json"))
+				}
+				return s.lockConflictLocked(LockConflictOverlap, scope, file, other, now)
+			}
+
 			lock := FileLock{
 				LeaseID:       leaseID,
 				Owner:         owner,
 				TaskID:        taskID,
+				Scope:         scope,
 				File:          file,
 				AcquiredAt:    now.Format(time.RFC3339),
 				ExpiresAt:     expiresAt.Format(time.RFC3339),
@@ -121,7 +179,7 @@ func (s *LockService) tryLockFiles(taskID, owner string, files []string, ttlSec
 			if err := s.store.WriteJSON(lockPath, &lock); err != nil {
 				// Rollback
 				for _, af := range acquired {
-					ah := PathHash(af)
+					ah := LockKey(scope, af)
 					_ = s.store.Remove(s.store.Path("locks", "files", ah+".json"))
 				}
 				return err
@@ -129,11 +187,14 @@ func (s *LockService) tryLockFiles(taskID, owner string, files []string, ttlSec
 			acquired = append(acquired, file)
 		}
 
+		s.dequeueWaiterLocked(scope, ticket, files)
+
 		// Write lease index
 		lease := &Lease{
 			LeaseID:       leaseID,
 			Owner:         owner,
 			TaskID:        taskID,
+			Scope:         scope,
 			Files:         files,
 			AcquiredAt:    now.Format(time.RFC3339),
 			ExpiresAt:     expiresAt.Format(time.RFC3339),
@@ -191,7 +252,7 @@ func (s *LockService) tryLockFiles(taskID, owner string, files []string, ttlSec
 // Heartbeat extends the TTL of a lease.
 func (s *LockService) Heartbeat(leaseID string, extendSec int) (*Lease, error) {
 	if leaseID == "" {
-		return nil, fmt.Errorf("lease_id is required")
+		return nil, Errorf(CodeInvalidArgument, "lease_id is required")
 	}
 	if extendSec <= 0 {
 		extendSec = 120
@@ -202,31 +263,12 @@ func (s *LockService) Heartbeat(leaseID string, extendSec int) (*Lease, error) {
 		leasePath := s.store.Path("locks", "leases", leaseID+".json")
 		var lease Lease
 		if err := s.store.ReadJSON(leasePath, &lease); err != nil {
-			return fmt.Errorf("lease '%s' not found", leaseID)
+			return Errorf(CodeNotFound, "lease '%s' not found", leaseID)
 		}
 
-		now := time.Now().UTC()
-		newExpires := now.Add(time.Duration(extendSec) * time.Second)
-		lease.ExpiresAt = newExpires.Format(time.RFC3339)
-		lease.LastHeartbeat = now.Format(time.RFC3339)
-
-		// Update lease file
-		if err := s.store.WriteJSON(leasePath, &lease); err != nil {
+		if err := s.extendLeaseLocked(leasePath, &lease, leaseNow(), extendSec); err != nil {
 			return err
 		}
-
-		// Update individual file locks
-		for _, file := range lease.Files {
-			hash := PathHash(file)
-			lockPath := s.store.Path("locks", "files", hash+".json")
-			var fl FileLock
-			if err := s.store.ReadJSON(lockPath, &fl); err == nil && fl.LeaseID == leaseID {
-				fl.ExpiresAt = lease.ExpiresAt
-				fl.LastHeartbeat = lease.LastHeartbeat
-				_ = s.store.WriteJSON(lockPath, &fl)
-			}
-		}
-
 		result = &lease
 		return nil
 	})
@@ -242,14 +284,85 @@ func (s *LockService) Heartbeat(leaseID string, extendSec int) (*Lease, error) {
 	return result, err
 }
 
+// HeartbeatAll extends every unexpired lease owned by owner in one step and returns them with their new
+// expiries, sorted by lease ID. Expired leases are left to the sweep.
+func (s *LockService) HeartbeatAll(owner string, extendSec int) ([]Lease, error) {
+	owner = strings.TrimSpace(owner)
+	if owner == "" {
+		return nil, Errorf(CodeInvalidArgument, "owner is required")
+	}
+	if extendSec <= 0 {
+		extendSec = 120
+	}
+
+	result := []Lease{}
+	err := s.store.WithLock(func() error {
+		now := leaseNow()
+		leaseFiles, _ := s.store.ListJSONFiles(s.store.Path("locks", "leases"))
+		for _, lf := range leaseFiles {
+			var lease Lease
+			if err := s.store.ReadJSON(lf, &lease); err != nil || lease.Owner != owner {
+				continue
+			}
+			if expTime, err := time.Parse(time.RFC3339, lease.ExpiresAt); err == nil && now.After(expTime) {
+				continue
+			}
+			if err := s.extendLeaseLocked(lf, &lease, now, extendSec); err != nil {
+				return err
+			}
+			result = append(result, lease)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].LeaseID < result[j].LeaseID })
+	for _, lease := range result {
+		s.trace.Log(TraceEvent{
+			Type:    EventLockHeartbeat,
+			Actor:   owner,
+			Subject: lease.LeaseID,
+		})
+	}
+	return result, nil
+}
+
+// extendLeaseLocked moves the lease and its file locks to expire extendSec after now. Must be called
+// under store lock.
+func (s *LockService) extendLeaseLocked(leasePath string, lease *Lease, now time.Time, extendSec int) error {
+	newExpires := now.Add(time.Duration(extendSec) * time.Second)
+	lease.ExpiresAt = newExpires.Format(time.RFC3339)
+	lease.LastHeartbeat = now.Format(time.RFC3339)
+
+	// Update lease file
+	if err := s.store.WriteJSON(leasePath, lease); err != nil {
+		return err
+	}
+
+	// Update individual file locks
+	for _, file := range lease.Files {
+		hash := LockKey(lease.Scope, file)
+		lockPath := s.store.Path("locks", "files", hash+".json")
+		var fl FileLock
+		if err := s.store.ReadJSON(lockPath, &fl); err == nil && fl.LeaseID == lease.LeaseID {
+			fl.ExpiresAt = lease.ExpiresAt
+			fl.LastHeartbeat = lease.LastHeartbeat
+			_ = s.store.WriteJSON(lockPath, &fl)
+		}
+	}
+	return nil
+}
+
 func (s *LockService) GetLease(leaseID string) (*Lease, error) {
 	if leaseID == "" {
-		return nil, fmt.Errorf("lease_id is required")
+		return nil, Errorf(CodeInvalidArgument, "lease_id is required")
 	}
 	leasePath := s.store.Path("locks", "leases", leaseID+".json")
 	var lease Lease
 	if err := s.store.ReadJSON(leasePath, &lease); err != nil {
-		return nil, fmt.Errorf("lease '%s' not found", leaseID)
+		return nil, Errorf(CodeNotFound, "lease '%s' not found", leaseID)
 	}
 	return &lease, nil
 }
@@ -257,19 +370,19 @@ func (s *LockService) GetLease(leaseID string) (*Lease, error) {
 // Unlock releases a lease and all its file locks.
 func (s *LockService) Unlock(leaseID string) error {
 	if leaseID == "" {
-		return fmt.Errorf("lease_id is required")
+		return Errorf(CodeInvalidArgument, "lease_id is required")
 	}
 
 	var lease Lease
 	err := s.store.WithLock(func() error {
 		leasePath := s.store.Path("locks", "leases", leaseID+".json")
 		if err := s.store.ReadJSON(leasePath, &lease); err != nil {
-			return fmt.Errorf("lease '%s' not found", leaseID)
+			return Errorf(CodeNotFound, "lease '%s' not found", leaseID)
 		}
 
 		// Remove file locks
 		for _, file := range lease.Files {
-			hash := PathHash(file)
+			hash := LockKey(lease.Scope, file)
 			lockPath := s.store.Path("locks", "files", hash+".json")
 			var fl FileLock
 			if err := s.store.ReadJSON(lockPath, &fl); err == nil && fl.LeaseID == leaseID {
@@ -296,18 +409,18 @@ func (s *LockService) Unlock(leaseID string) error {
 // ForceUnlock forcefully removes a lease (Leader only).
 func (s *LockService) ForceUnlock(leaseID, reason string) error {
 	if leaseID == "" {
-		return fmt.Errorf("lease_id is required")
+		return Errorf(CodeInvalidArgument, "lease_id is required")
 	}
 
 	var lease Lease
 	err := s.store.WithLock(func() error {
 		leasePath := s.store.Path("locks", "leases", leaseID+".json")
 		if err := s.store.ReadJSON(leasePath, &lease); err != nil {
-			return fmt.Errorf("lease '%s' not found", leaseID)
+			return Errorf(CodeNotFound, "lease '%s' not found", leaseID)
 		}
 
 		for _, file := range lease.Files {
-			hash := PathHash(file)
+			hash := LockKey(lease.Scope, file)
 			lockPath := s.store.Path("locks", "files", hash+".json")
 			_ = s.store.Remove(lockPath)
 		}
@@ -328,14 +441,15 @@ func (s *LockService) ForceUnlock(leaseID, reason string) error {
 }
 
 // ListLocks returns all active locks, optionally filtered.
-func (s *LockService) ListLocks(owner string, files []string) ([]Lease, error) {
+// A non-empty scope restricts results to that lock namespace.
+func (s *LockService) ListLocks(owner, scope string, files []string) ([]Lease, error) {
 	dir := s.store.Path("locks", "leases")
 	leaseFiles, err := s.store.ListJSONFiles(dir)
 	if err != nil {
 		return []Lease{}, nil
 	}
 
-	now := time.Now().UTC()
+	now := leaseNow()
 	var result []Lease
 
 	for _, lf := range leaseFiles {
@@ -355,12 +469,21 @@ func (s *LockService) ListLocks(owner string, files []string) ([]Lease, error) {
 			continue
 		}
 
+		// Filter by scope
+		if scope = strings.TrimSpace(scope); scope != "" && lease.Scope != scope {
+			continue
+		}
+
 		// Filter by files
 		if len(files) > 0 {
 			match := false
 			for _, f := range files {
+				spec, err := normalizeLockSpec(f)
+				if err != nil {
+					continue
+				}
 				for _, lf := range lease.Files {
-					if filepath.Clean(f) == filepath.Clean(lf) {
+					if lockSpecsOverlap(spec, filepath.ToSlash(lf)) {
 						match = true
 						break
 					}
@@ -385,7 +508,7 @@ func (s *LockService) CleanExpired() (int, error) {
 	cleaned := 0
 
 	err := s.store.WithLock(func() error {
-		now := time.Now().UTC()
+		now := leaseNow()
 
 		// Clean expired leases
 		dir := s.store.Path("locks", "leases")
@@ -401,7 +524,7 @@ func (s *LockService) CleanExpired() (int, error) {
 			if now.After(expTime) {
 				// Remove file locks
 				for _, file := range lease.Files {
-					hash := PathHash(file)
+					hash := LockKey(lease.Scope, file)
 					lockPath := s.store.Path("locks", "files", hash+".json")
 					_ = s.store.Remove(lockPath)
 				}
@@ -0,0 +1,103 @@
+package swarm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	LockConflictHeld    = "held"    // the exact entry is locked by another owner
+	LockConflictOverlap = "overlap" // an overlapping directory/glob entry is locked by another owner
+	LockConflictQueued  = "queued"  // another owner is queued ahead for the entry
+)
+
+// LockConflictError is returned by LockFiles when a file cannot be locked. It carries the holder's lease
+// details and the waiters ahead so callers can decide whether to wait, ask the lead or pick other work.
+type LockConflictError struct {
+	Reason  string       `json:"reason"`
+	File    string       `json:"file"`
+	Scope   string       `json:"scope,omitempty"`
+	Holder  *FileLock    `json:"holder,omitempty"`
+	Waiters []LockWaiter `json:"waiters"`
+
+	blockedBy *LockWaiter
+}
+
+func (e *LockConflictError) Error() string {
+	switch {
+	case e.Reason == LockConflictQueued && e.blockedBy != nil:
+		w := e.blockedBy
+		return fmt.Sprintf("file '%s' has queued waiter '%s' (task: %s, since: %s)", e.File, w.Owner, w.TaskID, w.EnqueuedAt)
+	case e.Reason == LockConflictOverlap && e.Holder != nil:
+		return fmt.Sprintf("file '%s' overlaps '%s' locked by '%s' (task: %s, expires: %s)",
+			e.File, e.Holder.File, e.Holder.Owner, e.Holder.TaskID, e.Holder.ExpiresAt)
+	case e.Holder != nil:
+		return fmt.Sprintf("file '%s' locked by '%s' (task: %s, expires: %s)",
+			e.File, e.Holder.Owner, e.Holder.TaskID, e.Holder.ExpiresAt)
+	}
+	return fmt.Sprintf("file '%s' is not available", e.File)
+}
+
+// ErrorDetails exposes the conflict as structured data for tool responses.
+func (e *LockConflictError) ErrorDetails() any {
+	return map[string]any{"lock_conflict": e}
+}
+
+// lockConflictLocked builds a conflict error including the current wait queue for file.
+// Must be called under store lock.
+func (s *LockService) lockConflictLocked(reason, scope, file string, holder *FileLock, now time.Time) *LockConflictError {
+	return &LockConflictError{
+		Reason:  reason,
+		File:    file,
+		Scope:   scope,
+		Holder:  holder,
+		Waiters: s.loadWaitQueueLocked(scope, file, now).Waiters,
+	}
+}
+
+// ListLockWaiters returns non-empty wait queues, optionally filtered by scope and files.
+func (s *LockService) ListLockWaiters(scope string, files []string) ([]LockWaitQueue, error) {
+	scope = strings.TrimSpace(scope)
+	var specs []string
+	for _, f := range files {
+		spec, err := normalizeLockSpec(f)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+
+	out := []LockWaitQueue{}
+	err := s.store.WithLock(func() error {
+		now := leaseNow()
+		queueFiles, _ := s.store.ListJSONFiles(s.store.Path("locks", "waiters"))
+		for _, qf := range queueFiles {
+			var raw LockWaitQueue
+			if err := s.store.ReadJSON(qf, &raw); err != nil {
+				continue
+			}
+			if scope != "" && raw.Scope != scope {
+				continue
+			}
+			if len(specs) > 0 {
+				match := false
+				for _, spec := range specs {
+					if lockSpecsOverlap(spec, raw.File) {
+						match = true
+						break
+					}
+				}
+				if !match {
+					continue
+				}
+			}
+			q := s.loadWaitQueueLocked(raw.Scope, raw.File, now)
+			if len(q.Waiters) > 0 {
+				out = append(out, *q)
+			}
+		}
+		return nil
+	})
+	return out, err
+}
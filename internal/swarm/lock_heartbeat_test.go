@@ -0,0 +1,56 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatAll_ExtendsOnlyTheOwnersLiveLeases(t *testing.T) {
+	store := NewStore(t.TempDir())
+	locks := NewLockService(store, NewTraceService(store))
+	ctx := context.Background()
+
+	a, err := locks.LockFiles(ctx, "task-1", "w1", "", []string{"a.go"}, 10, 0)
+	if err != nil {
+		t.Fatalf("lock a: %v", err)
+	}
+	b, err := locks.LockFiles(ctx, "task-2", "w1", "", []string{"b.go"}, 10, 0)
+	if err != nil {
+		t.Fatalf("lock b: %v", err)
+	}
+	other, err := locks.LockFiles(ctx, "task-3", "w2", "", []string{"c.go"}, 10, 0)
+	if err != nil {
+		t.Fatalf("lock c: %v", err)
+	}
+
+	leases, err := locks.HeartbeatAll("w1", 600)
+	if err != nil {
+		t.Fatalf("heartbeatAll: %v", err)
+	}
+	if len(leases) != 2 {
+		t.Fatalf("expected w1's two leases, got %+v", leases)
+	}
+	minExp := leaseNow().Add(590 * time.Second)
+	for _, id := range []string{a.LeaseID, b.LeaseID} {
+		l, err := locks.GetLease(id)
+		if err != nil {
+			t.Fatalf("get lease: %v", err)
+		}
+		exp, _ := time.Parse(time.RFC3339, l.ExpiresAt)
+		if exp.Before(minExp) {
+			t.Fatalf("lease %s not extended: %s", id, l.ExpiresAt)
+		}
+		var fl FileLock
+		if err := store.ReadJSON(store.Path("locks", "files", LockKey("", l.Files[0])+".json"), &fl); err != nil || fl.ExpiresAt != l.ExpiresAt {
+			t.Fatalf("file lock of %s not extended: %+v err=%v", id, fl, err)
+		}
+	}
+	if l, _ := locks.GetLease(other.LeaseID); l.ExpiresAt != other.ExpiresAt {
+		t.Fatalf("w2's lease should be untouched: %s", l.ExpiresAt)
+	}
+
+	if _, err := locks.HeartbeatAll(" ", 60); err == nil {
+		t.Fatalf("expected an error without owner")
+	}
+}
@@ -0,0 +1,109 @@
+package swarm
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// normalizeLockSpec cleans a lockFiles entry. Entries may be concrete paths, directories
+// ("src/payments/" locks everything below it, stored as "src/payments/**") or glob patterns
+// using * ? [...] within a segment and ** across segments.
+func normalizeLockSpec(spec string) (string, error) {
+	spec = filepath.ToSlash(strings.TrimSpace(spec))
+	if spec == "" {
+		return "", Errorf(CodeInvalidArgument, "empty file entry")
+	}
+	dir := strings.HasSuffix(spec, "/")
+	spec = path.Clean(spec)
+	if dir && spec != "/" && !strings.HasSuffix(spec, "/**") {
+		spec += "/**"
+	}
+	for _, seg := range strings.Split(spec, "/") {
+		if seg == "**" {
+			continue
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return "", Errorf(CodeInvalidArgument, "invalid lock pattern '%s': %w", spec, err)
+		}
+	}
+	return spec, nil
+}
+
+func isLockPatternSegment(seg string) bool {
+	return strings.ContainsAny(seg, "*?[")
+}
+
+// lockSpecsOverlap reports whether two lock entries (concrete paths or patterns) can cover a common path.
+// Two wildcard segments are conservatively treated as overlapping.
+func lockSpecsOverlap(a, b string) bool {
+	if a == b {
+		return true
+	}
+	return segmentsOverlap(strings.Split(a, "/"), strings.Split(b, "/"))
+}
+
+func segmentsOverlap(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return onlyDoubleStars(a) && onlyDoubleStars(b)
+	}
+	if a[0] == "**" {
+		return segmentsOverlap(a[1:], b) || segmentsOverlap(a, b[1:])
+	}
+	if b[0] == "**" {
+		return segmentsOverlap(a, b[1:]) || segmentsOverlap(a[1:], b)
+	}
+	if !segmentOverlap(a[0], b[0]) {
+		return false
+	}
+	return segmentsOverlap(a[1:], b[1:])
+}
+
+func segmentOverlap(a, b string) bool {
+	pa, pb := isLockPatternSegment(a), isLockPatternSegment(b)
+	switch {
+	case !pa && !pb:
+		return a == b
+	case pa && !pb:
+		ok, _ := path.Match(a, b)
+		return ok
+	case !pa && pb:
+		ok, _ := path.Match(b, a)
+		return ok
+	default:
+		return true
+	}
+}
+
+func onlyDoubleStars(segs []string) bool {
+	for _, s := range segs {
+		if s != "**" {
+			return false
+		}
+	}
+	return true
+}
+
+// overlappingLockLocked returns an active lock in scope held by another owner that overlaps spec, if any.
+// Exact matches are handled by the per-path lock file itself. Must be called under store lock.
+func (s *LockService) overlappingLockLocked(scope, spec, owner string, now time.Time) *FileLock {
+	lockFiles, _ := s.store.ListJSONFiles(s.store.Path("locks", "files"))
+	for _, lf := range lockFiles {
+		var existing FileLock
+		if err := s.store.ReadJSON(lf, &existing); err != nil {
+			continue
+		}
+		if existing.Scope != scope || existing.Owner == owner || existing.File == spec {
+			continue
+		}
+		expTime, _ := time.Parse(time.RFC3339, existing.ExpiresAt)
+		if !now.Before(expTime) {
+			continue
+		}
+		if lockSpecsOverlap(spec, filepath.ToSlash(existing.File)) {
+			return &existing
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLockSpecsOverlap(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"src/a.go", "src/a.go", true},
+		{"src/a.go", "src/b.go", false},
+		{"src/payments/**", "src/payments/x/y.go", true},
+		{"src/payments/**", "src/payments", true},
+		{"src/payments/**", "src/billing/x.go", false},
+		{"src/*.go", "src/a.go", true},
+		{"src/*.go", "src/sub/a.go", false},
+		{"src/**", "src/payments/*.go", true},
+		{"src/payments/**", "src/billing/**", false},
+		{"**/*.go", "cmd/main.go", true},
+	}
+	for _, c := range cases {
+		if got := lockSpecsOverlap(c.a, c.b); got != c.want {
+			t.Errorf("lockSpecsOverlap(%q, %q) = %v, want %v", c.a, c.b, got, c.want)
+		}
+		if got := lockSpecsOverlap(c.b, c.a); got != c.want {
+			t.Errorf("lockSpecsOverlap(%q, %q) = %v, want %v", c.b, c.a, got, c.want)
+		}
+	}
+}
+
+func TestLockFiles_DirectoryConflictsWithContainedFile(t *testing.T) {
+	store := NewStore(t.TempDir())
+	store.EnsureDir()
+	svc := NewLockService(store, NewTraceService(store))
+
+	if _, err := svc.LockFiles(context.Background(), "t1", "w1", "", []string{"src/payments/"}, 60, 0); err != nil {
+		t.Fatalf("lock dir: %v", err)
+	}
+	if _, err := svc.LockFiles(context.Background(), "t2", "w2", "", []string{"src/payments/charge.go"}, 60, 0); err == nil {
+		t.Fatalf("expected conflict with directory lock")
+	}
+	if _, err := svc.LockFiles(context.Background(), "t2", "w2", "", []string{"src/billing/invoice.go"}, 60, 0); err != nil {
+		t.Fatalf("unrelated file should lock: %v", err)
+	}
+	if _, err := svc.LockFiles(context.Background(), "t3", "w3", "", []string{"src/**"}, 60, 0); err == nil {
+		t.Fatalf("expected conflict between src/** and held locks")
+	}
+}
+
+func TestLockFiles_ScopesDoNotConflict(t *testing.T) {
+	store := NewStore(t.TempDir())
+	store.EnsureDir()
+	svc := NewLockService(store, NewTraceService(store))
+
+	if _, err := svc.LockFiles(context.Background(), "t1", "w1", "issue-a", []string{"main.go"}, 60, 0); err != nil {
+		t.Fatalf("lock issue-a: %v", err)
+	}
+	if _, err := svc.LockFiles(context.Background(), "t1", "w2", "issue-b", []string{"main.go"}, 60, 0); err != nil {
+		t.Fatalf("same file in another scope should lock: %v", err)
+	}
+	if _, err := svc.LockFiles(context.Background(), "t2", "w3", "issue-a", []string{"main.go"}, 60, 0); err == nil {
+		t.Fatalf("expected conflict within the same scope")
+	}
+	leases, err := svc.ListLocks("", "issue-b", nil)
+	if err != nil {
+		t.Fatalf("list locks: %v", err)
+	}
+	if len(leases) != 1 || leases[0].Owner != "w2" {
+		t.Fatalf("unexpected scoped leases: %+v", leases)
+	}
+}
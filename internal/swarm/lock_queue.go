@@ -0,0 +1,118 @@
+package swarm
+
+import (
+	"time"
+)
+
+// lockWaiterTTL bounds how long a queued waiter stays at the head without retrying (e.g. its process died).
+// LockFiles refreshes its entries on every retry, well within this window.
+const lockWaiterTTL = 15 * time.Second
+
+// LockWaiter is a queued lockFiles request waiting for a contended file.
+type LockWaiter struct {
+	Ticket     string `json:"ticket"`
+	Owner      string `json:"owner"`
+	TaskID     string `json:"task_id"`
+	EnqueuedAt string `json:"enqueued_at"`
+	ExpiresAt  string `json:"expires_at"`
+}
+
+// LockWaitQueue is the FIFO of waiters for one lock entry, stored at locks/waiters/<hash>.json.
+type LockWaitQueue struct {
+	Scope   string       `json:"scope,omitempty"`
+	File    string       `json:"file"`
+	Waiters []LockWaiter `json:"waiters"`
+}
+
+func (s *LockService) waitQueuePath(scope, file string) string {
+	return s.store.Path("locks", "waiters", LockKey(scope, file)+".json")
+}
+
+// loadWaitQueueLocked reads the queue for file with expired waiters dropped. Must be called under store lock.
+func (s *LockService) loadWaitQueueLocked(scope, file string, now time.Time) *LockWaitQueue {
+	q := &LockWaitQueue{Scope: scope, File: file}
+	if err := s.store.ReadJSON(s.waitQueuePath(scope, file), q); err != nil {
+		return &LockWaitQueue{Scope: scope, File: file}
+	}
+	live := q.Waiters[:0]
+	for _, w := range q.Waiters {
+		exp, _ := time.Parse(time.RFC3339, w.ExpiresAt)
+		if now.Before(exp) {
+			live = append(live, w)
+		}
+	}
+	q.Waiters = live
+	return q
+}
+
+func (s *LockService) saveWaitQueueLocked(q *LockWaitQueue) error {
+	if len(q.Waiters) == 0 {
+		_ = s.store.Remove(s.waitQueuePath(q.Scope, q.File))
+		return nil
+	}
+	s.store.EnsureDir("locks", "waiters")
+	return s.store.WriteJSON(s.waitQueuePath(q.Scope, q.File), q)
+}
+
+// queuedAheadLocked returns the first waiter for file that must be served before ticket, or nil.
+// Waiters of the same owner never block each other. Must be called under store lock.
+func (s *LockService) queuedAheadLocked(scope, file, owner, ticket string, now time.Time) *LockWaiter {
+	q := s.loadWaitQueueLocked(scope, file, now)
+	for _, w := range q.Waiters {
+		if w.Ticket == ticket {
+			return nil
+		}
+		if w.Owner != owner {
+			wc := w
+			return &wc
+		}
+	}
+	return nil
+}
+
+// enqueueWaiter appends ticket to the queue of every file (or refreshes its expiry if already queued).
+func (s *LockService) enqueueWaiter(scope, ticket, owner, taskID string, files []string) error {
+	return s.store.WithLock(func() error {
+		now := leaseNow()
+		expires := now.Add(lockWaiterTTL).Format(time.RFC3339)
+		for _, file := range files {
+			q := s.loadWaitQueueLocked(scope, file, now)
+			found := false
+			for i := range q.Waiters {
+				if q.Waiters[i].Ticket == ticket {
+					q.Waiters[i].ExpiresAt = expires
+					found = true
+				}
+			}
+			if !found {
+				q.Waiters = append(q.Waiters, LockWaiter{
+					Ticket:     ticket,
+					Owner:      owner,
+					TaskID:     taskID,
+					EnqueuedAt: now.Format(time.RFC3339),
+					ExpiresAt:  expires,
+				})
+			}
+			if err := s.saveWaitQueueLocked(q); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// dequeueWaiterLocked removes ticket from the queues of files. Must be called under store lock.
+func (s *LockService) dequeueWaiterLocked(scope, ticket string, files []string) {
+	now := leaseNow()
+	for _, file := range files {
+		q := s.loadWaitQueueLocked(scope, file, now)
+		kept := q.Waiters[:0]
+		for _, w := range q.Waiters {
+			if w.Ticket != ticket {
+				kept = append(kept, w)
+			}
+		}
+		q.Waiters = kept
+		_ = s.saveWaitQueueLocked(q)
+	}
+}
@@ -0,0 +1,86 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLockFiles_QueuedWaiterIsServedBeforeNewcomer(t *testing.T) {
+	store := NewStore(t.TempDir())
+	store.EnsureDir()
+	svc := NewLockService(store, NewTraceService(store))
+
+	held, err := svc.LockFiles(context.Background(), "t1", "w1", "", []string{"a.go"}, 60, 0)
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := svc.LockFiles(context.Background(), "t2", "w2", "", []string{"a.go"}, 60, 10)
+		done <- err
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !store.Exists("locks", "waiters", PathHash("a.go")+".json") {
+		if time.Now().After(deadline) {
+			t.Fatalf("waiter was never queued")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if err := svc.Unlock(held.LeaseID); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if _, err := svc.LockFiles(context.Background(), "t3", "w3", "", []string{"a.go"}, 60, 0); err == nil {
+		t.Fatalf("newcomer should not jump ahead of queued waiter")
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("queued waiter failed: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timeout waiting for queued waiter")
+	}
+	if store.Exists("locks", "waiters", PathHash("a.go")+".json") {
+		t.Fatalf("queue should be empty after handoff")
+	}
+}
+
+func TestLockFiles_ConflictErrorCarriesHolderAndWaiters(t *testing.T) {
+	store := NewStore(t.TempDir())
+	store.EnsureDir()
+	svc := NewLockService(store, NewTraceService(store))
+
+	held, err := svc.LockFiles(context.Background(), "t1", "w1", "", []string{"a.go"}, 60, 0)
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if err := svc.enqueueWaiter("", "ticket-1", "w2", "t2", []string{"a.go"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	_, err = svc.LockFiles(context.Background(), "t3", "w3", "", []string{"a.go"}, 60, 0)
+	var conflict *LockConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected LockConflictError, got %v", err)
+	}
+	if conflict.Reason != LockConflictHeld || conflict.Holder == nil || conflict.Holder.LeaseID != held.LeaseID || conflict.Holder.TaskID != "t1" {
+		t.Fatalf("unexpected conflict: %+v", conflict)
+	}
+	if len(conflict.Waiters) != 1 || conflict.Waiters[0].Owner != "w2" {
+		t.Fatalf("unexpected waiters: %+v", conflict.Waiters)
+	}
+
+	queues, err := svc.ListLockWaiters("", []string{"a.go"})
+	if err != nil {
+		t.Fatalf("list waiters: %v", err)
+	}
+	if len(queues) != 1 || queues[0].File != "a.go" || len(queues[0].Waiters) != 1 {
+		t.Fatalf("unexpected queues: %+v", queues)
+	}
+}
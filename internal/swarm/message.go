@@ -1,7 +1,7 @@
 package swarm
 
 import (
-	"fmt"
+	"context"
 	"os"
 	"strings"
 )
@@ -33,7 +33,7 @@ func (s *IssueService) getTaskMessageLocked(issueID, messageID string) (*TaskMes
 	path := s.store.Path("issues", issueID, "messages", messageID+".json")
 	var msg TaskMessage
 	if err := s.store.ReadJSON(path, &msg); err != nil {
-		return nil, fmt.Errorf("message '%s' not found", messageID)
+		return nil, Errorf(CodeNotFound, "message '%s' not found", messageID)
 	}
 	return &msg, nil
 }
@@ -45,7 +45,7 @@ func (s *IssueService) replyTaskMessageLocked(issueID, messageID, actor, content
 		return nil, err
 	}
 	if msg.Status == MessageReplied || msg.Status == MessageResolved {
-		return nil, fmt.Errorf("message '%s' already has a reply (status: %s)", messageID, msg.Status)
+		return nil, Errorf(CodeInvalidState, "message '%s' already has a reply (status: %s)", messageID, msg.Status)
 	}
 	msg.Status = MessageReplied
 	msg.ReplyContent = content
@@ -117,9 +117,10 @@ func (s *IssueService) deleteMessagesForTaskLocked(issueID, taskID string) {
 }
 
 // pollMessageReply polls until the message has a reply. Used by AskIssueTask blocking wait.
-func (s *IssueService) pollMessageReply(issueID, messageID string, timeoutSec int) (*TaskMessage, error) {
+func (s *IssueService) pollMessageReply(ctx context.Context, issueID, messageID string, timeoutSec int) (*TaskMessage, error) {
 	deadline := s.deadline(timeoutSec)
 	for {
+		since := s.changeGen()
 		var msg *TaskMessage
 		_ = s.store.WithLock(func() error {
 			found, err := s.getTaskMessageLocked(issueID, messageID)
@@ -132,9 +133,11 @@ func (s *IssueService) pollMessageReply(issueID, messageID string, timeoutSec in
 			return msg, nil
 		}
 		if timeExpired(deadline) {
-			return nil, fmt.Errorf("timeout waiting for reply to message '%s'", messageID)
+			return nil, Errorf(CodeTimeout, "timeout waiting for reply to message '%s'", messageID)
+		}
+		if err := s.waitChange(ctx, since, pollInterval); err != nil {
+			return nil, err
 		}
-		sleepPoll()
 	}
 }
 
@@ -161,7 +164,7 @@ func (s *IssueService) resolveMessageForReply(issueID, taskID, messageID string)
 		}
 	}
 	if oldest == nil {
-		return nil, fmt.Errorf("no open message found for task '%s'", taskID)
+		return nil, Errorf(CodeNotFound, "no open message found for task '%s'", taskID)
 	}
 	return oldest, nil
 }
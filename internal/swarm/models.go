@@ -1,16 +1,11 @@
 package swarm
 
 import (
-	"fmt"
-	"math/rand"
+	"log"
 	"sync"
 	"time"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
 // Trace event types
 const (
 	EventWorkerRegistered = "worker_registered"
@@ -18,6 +13,7 @@ const (
 	EventLockReleased     = "lock_released"
 	EventLockHeartbeat    = "lock_heartbeat"
 	EventLockExpired      = "lock_expired"
+	EventLockExpiring     = "lock_expiring"
 	EventLockForced       = "lock_forced"
 	EventLockFailed       = "lock_failed"
 )
@@ -51,7 +47,13 @@ const (
 	EventIssueDelivered    = "issue_delivered"
 	EventIssueClosed       = "issue_closed"
 	EventIssueReopened     = "issue_reopened"
+	EventIssuePaused       = "issue_paused"
+	EventIssueResumed      = "issue_resumed"
 	EventIssueExpired      = "issue_expired"
+	EventIssueImported     = "issue_imported"
+	EventIssueLeadAdded    = "issue_lead_added"
+	EventIssueLeadRemoved  = "issue_lead_removed"
+	EventIssueClaimChecks  = "issue_claim_checks_set"
 	EventIssueTaskCreated  = "issue_task_created"
 	EventIssueTaskClaimed  = "issue_task_claimed"
 	EventIssueTaskExpired  = "issue_task_expired"
@@ -59,6 +61,18 @@ const (
 	EventIssueTaskResolved = "issue_task_resolved"
 	EventIssueTaskMessage  = "issue_task_message"
 	EventIssueTaskReset    = "issue_task_reset"
+	EventIssueTaskReopened = "issue_task_reopened"
+	EventIssueTaskAssigned = "issue_task_assigned"
+	EventIssueTaskUpdated  = "issue_task_updated"
+
+	// The lead appended a clarification to a task's spec doc (amendTaskSpec).
+	EventIssueTaskSpecAmended = "issue_task_spec_amended"
+
+	// A task hit the rejection limit and was handed back to the lead (see SetMaxRejections).
+	EventIssueTaskEscalated = "issue_task_escalated"
+
+	// A claimed task's lease (or a file lock taken for it) is about to run out (see SetLeaseWarning).
+	EventIssueTaskLeaseExpiring = "issue_task_lease_expiring"
 )
 
 // Delivery statuses
@@ -74,6 +88,16 @@ const (
 	SubmissionOpen     = "open"
 	SubmissionApproved = "approved"
 	SubmissionRejected = "rejected"
+
+	// Sent back by the peer reviewer; never reached the lead (see SetPeerReview).
+	SubmissionPeerRejected = "peer_rejected"
+)
+
+// PeerReview statuses
+const (
+	PeerReviewPending  = "pending"
+	PeerReviewApproved = "approved"
+	PeerReviewRejected = "rejected"
 )
 
 // TaskMessage statuses
@@ -85,12 +109,40 @@ const (
 
 // InboxItem types
 const (
-	InboxTypeSubmission   = "submission"
-	InboxTypeQuestion     = "question"
-	InboxTypeBlocker      = "blocker"
-	InboxTypeDelivery     = "delivery"
-	InboxTypeReply        = "reply"
-	InboxTypeReviewResult = "review_result"
+	InboxTypeSubmission    = "submission"
+	InboxTypeQuestion      = "question"
+	InboxTypeBlocker       = "blocker"
+	InboxTypeDelivery      = "delivery"
+	InboxTypeReply         = "reply"
+	InboxTypeReviewResult  = "review_result"
+	InboxTypeReviewOverdue = "review_overdue"
+	InboxTypeAssigned      = "assigned"
+	InboxTypeEscalation    = "escalation"
+	InboxTypePeerReview    = "peer_review"
+
+	// Raised by the worker via escalateIssueTask; also the TaskMessage kind.
+	InboxTypeWorkerEscalation = "worker_escalation"
+	// Lead inbox: a submission waits for a peer reviewer (assignPeerReviewer).
+	InboxTypePeerReviewNeeded = "peer_review_needed"
+	// Worker inbox: the spec of the claimed task was amended; RefID is the SpecAmendment.
+	InboxTypeSpecAmended = "spec_amended"
+	// Lead or worker inbox: the other side commented on an open submission; RefID is the comment.
+	InboxTypeSubmissionComment = "submission_comment"
+	// Worker inbox: a lease of the worker is about to expire; RefID is the file lock lease, empty for the
+	// task lease.
+	InboxTypeLeaseExpiring = "lease_expiring"
+)
+
+// InboxItem priorities; high-priority items are claimed from the lead inbox first. Other items are
+// served by type (SetInboxPriorities), then oldest first.
+const InboxPriorityHigh = "high"
+
+// Escalation reasons accepted by EscalateTask.
+const (
+	EscalationSpecConflict  = "spec_conflict"
+	EscalationMissingAccess = "missing_access"
+	EscalationScopeTooLarge = "scope_too_large"
+	EscalationOther         = "other"
 )
 
 // InboxItem statuses
@@ -102,10 +154,15 @@ const (
 
 // New event types for entities
 const (
-	EventSubmissionCreated  = "submission_created"
-	EventSubmissionReviewed = "submission_reviewed"
-	EventMessageCreated     = "message_created"
-	EventMessageReplied     = "message_replied"
+	EventSubmissionCreated       = "submission_created"
+	EventSubmissionReviewed      = "submission_reviewed"
+	EventSubmissionReviewOverdue = "submission_review_overdue"
+	EventSubmissionPeerReviewed  = "submission_peer_reviewed"
+	EventPeerReviewerAssigned    = "peer_reviewer_assigned"
+	EventPeerReviewNeeded        = "peer_review_needed"
+	EventSubmissionComment       = "submission_comment"
+	EventMessageCreated          = "message_created"
+	EventMessageReplied          = "message_replied"
 )
 
 // Submission is a first-class entity created when a worker submits work.
@@ -123,10 +180,22 @@ type Submission struct {
 	CompletionScore int                 `json:"completion_score,omitempty"`
 	NextStepToken   string              `json:"next_step_token,omitempty"`
 	ReviewedBy      string              `json:"reviewed_by,omitempty"`
+	ReviewedAt      string              `json:"reviewed_at,omitempty"`
+	PeerReview      *PeerReview         `json:"peer_review,omitempty"`
+	EscalatedAt     string              `json:"escalated_at,omitempty"` // review SLA breached; lead inbox escalated
 	CreatedAt       string              `json:"created_at"`
 	UpdatedAt       string              `json:"updated_at"`
 }
 
+// PeerReview is the second worker's check of a submission, made before the submission reaches the lead.
+type PeerReview struct {
+	ReviewerID string `json:"reviewer_id,omitempty"`
+	Status     string `json:"status"` // pending/approved/rejected
+	Comment    string `json:"comment,omitempty"`
+	AssignedAt string `json:"assigned_at,omitempty"`
+	ReviewedAt string `json:"reviewed_at,omitempty"`
+}
+
 // TaskMessage is a first-class entity for worker↔lead Q&A threads.
 // It has its own state machine so both sides can track resolution.
 type TaskMessage struct {
@@ -134,7 +203,8 @@ type TaskMessage struct {
 	IssueID      string `json:"issue_id"`
 	TaskID       string `json:"task_id"`
 	SenderID     string `json:"sender_id"`
-	Kind         string `json:"kind"` // question/blocker
+	Kind         string `json:"kind"` // question/blocker/worker_escalation
+	Reason       string `json:"reason,omitempty"`
 	Content      string `json:"content"`
 	Refs         string `json:"refs"`
 	Status       string `json:"status"` // open/replied/resolved
@@ -158,20 +228,24 @@ type InboxItem struct {
 	Status           string `json:"status"` // pending/processing/done
 	ClaimedBy        string `json:"claimed_by,omitempty"`
 	ClaimExpiresAtMs int64  `json:"claim_expires_at_ms,omitempty"`
+	Priority         string `json:"priority,omitempty"` // InboxPriorityHigh or empty
 	CreatedAt        string `json:"created_at"`
 	UpdatedAt        string `json:"updated_at"`
 }
 
 type Worker struct {
-	ID        string `json:"id"`
-	JoinedAt  string `json:"joined_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID         string   `json:"id"`
+	Skills     []string `json:"skills,omitempty"` // matched against task labels by the scheduler
+	JoinedAt   string   `json:"joined_at"`
+	UpdatedAt  string   `json:"updated_at"`
+	LastSeenAt string   `json:"last_seen_at,omitempty"` // last tool call by the worker (WorkerService.Touch)
 }
 
 type FileLock struct {
 	LeaseID       string `json:"lease_id"`
 	Owner         string `json:"owner"`
 	TaskID        string `json:"task_id"`
+	Scope         string `json:"scope,omitempty"`
 	File          string `json:"file"`
 	AcquiredAt    string `json:"acquired_at"`
 	ExpiresAt     string `json:"expires_at"`
@@ -182,10 +256,12 @@ type Lease struct {
 	LeaseID       string   `json:"lease_id"`
 	Owner         string   `json:"owner"`
 	TaskID        string   `json:"task_id"`
+	Scope         string   `json:"scope,omitempty"`
 	Files         []string `json:"files"`
 	AcquiredAt    string   `json:"acquired_at"`
 	ExpiresAt     string   `json:"expires_at"`
 	LastHeartbeat string   `json:"last_heartbeat"`
+	WarnedFor     string   `json:"warned_for,omitempty"` // expires_at the owner was last warned about
 }
 
 type TraceEvent struct {
@@ -198,16 +274,24 @@ type TraceEvent struct {
 }
 
 type Issue struct {
-	ID               string   `json:"id"`
-	Subject          string   `json:"subject"`
-	Description      string   `json:"description"`
-	SharedDocPaths   []string `json:"shared_doc_paths"`
-	ProjectDocPaths  []string `json:"project_doc_paths"`
-	Docs             []DocRef `json:"docs"`
-	Status           string   `json:"status"`
-	LeaseExpiresAtMs int64    `json:"lease_expires_at_ms"`
-	CreatedAt        string   `json:"created_at"`
-	UpdatedAt        string   `json:"updated_at"`
+	SchemaVersion    int          `json:"schema_version"`
+	ID               string       `json:"id"`
+	Subject          string       `json:"subject"`
+	Description      string       `json:"description"`
+	SharedDocPaths   []string     `json:"shared_doc_paths"`
+	ProjectDocPaths  []string     `json:"project_doc_paths"`
+	Docs             []DocRef     `json:"docs"`
+	Status           string       `json:"status"`
+	LeaseExpiresAtMs int64        `json:"lease_expires_at_ms"`
+	PausedAt         string       `json:"paused_at,omitempty"` // set while paused: no claims, no lease expiry
+	PauseReason      string       `json:"pause_reason,omitempty"`
+	PointsBudget     int          `json:"points_budget,omitempty"`
+	BudgetStrict     bool         `json:"budget_strict,omitempty"`
+	Leads            []string     `json:"leads,omitempty"`        // lead keys (LeadKey of the session id) allowed to claim the lead inbox, owner first; empty = any lead
+	ClaimChecks      []ClaimCheck `json:"claim_checks,omitempty"` // readiness validators run before a task is claimed
+	Rev              int64        `json:"rev"`                    // incremented on every write
+	CreatedAt        string       `json:"created_at"`
+	UpdatedAt        string       `json:"updated_at"`
 }
 
 type DocRef struct {
@@ -223,6 +307,7 @@ type SubmissionArtifacts struct {
 	TestCases    []string `json:"test_cases"`
 	TestResult   string   `json:"test_result"`
 	TestOutput   string   `json:"test_output"`
+	BaseRef      string   `json:"base_ref,omitempty"`
 }
 
 type DeliveryArtifacts struct {
@@ -232,6 +317,7 @@ type DeliveryArtifacts struct {
 	ReviewedRefs []string `json:"reviewed_refs"`
 	TestOutput   string   `json:"test_output"`
 	KnownRisks   string   `json:"known_risks"`
+	Links        []string `json:"links,omitempty"`
 }
 
 type CommandResult struct {
@@ -259,31 +345,46 @@ type Verification struct {
 
 	DocPassed  bool            `json:"doc_passed"`
 	DocResults []CommandResult `json:"doc_results"`
+
+	CI *CICheck `json:"ci,omitempty"`
 }
 
 type Delivery struct {
-	ID               string            `json:"id"`
-	IssueID          string            `json:"issue_id"`
-	Summary          string            `json:"summary"`
-	Refs             string            `json:"refs"`
-	Artifacts        DeliveryArtifacts `json:"artifacts"`
-	TestEvidence     TestEvidence      `json:"test_evidence"`
-	Verification     Verification      `json:"verification"`
-	Status           string            `json:"status"`
-	DeliveredBy      string            `json:"delivered_by"`
-	ClaimedBy        string            `json:"claimed_by"`
-	ReviewedBy       string            `json:"reviewed_by"`
-	Feedback         string            `json:"feedback"`
-	DeliveredAt      string            `json:"delivered_at"`
-	ClaimedAt        string            `json:"claimed_at"`
-	ReviewedAt       string            `json:"reviewed_at"`
-	LeaseExpiresAtMs int64             `json:"lease_expires_at_ms"`
-	UpdatedAt        string            `json:"updated_at"`
+	ID                 string            `json:"id"`
+	IssueID            string            `json:"issue_id"`
+	PreviousDeliveryID string            `json:"previous_delivery_id,omitempty"`
+	Revision           int               `json:"revision"`
+	Milestone          string            `json:"milestone,omitempty"`
+	TaskIDs            []string          `json:"task_ids,omitempty"`
+	Summary            string            `json:"summary"`
+	Refs               string            `json:"refs"`
+	Artifacts          DeliveryArtifacts `json:"artifacts"`
+	TestEvidence       TestEvidence      `json:"test_evidence"`
+	ServerRun          *EvidenceRun      `json:"server_run,omitempty"`
+	Verification       Verification      `json:"verification"`
+	Status             string            `json:"status"`
+	DeliveredBy        string            `json:"delivered_by"`
+	ClaimedBy          string            `json:"claimed_by"`
+	ReviewedBy         string            `json:"reviewed_by"`
+	Feedback           string            `json:"feedback"`
+	DeliveredAt        string            `json:"delivered_at"`
+	ClaimedAt          string            `json:"claimed_at"`
+	ReviewedAt         string            `json:"reviewed_at"`
+	LeaseExpiresAtMs   int64             `json:"lease_expires_at_ms"`
+	UpdatedAt          string            `json:"updated_at"`
+}
+
+// DeliveryScope limits a delivery to a named subset of an issue's tasks.
+// The zero value covers every task in the issue.
+type DeliveryScope struct {
+	Milestone string
+	TaskIDs   []string
 }
 
 type ReviewArtifacts struct {
-	ReviewSummary string   `json:"review_summary"`
-	ReviewedRefs  []string `json:"reviewed_refs"`
+	ReviewSummary string            `json:"review_summary"`
+	ReviewedRefs  []string          `json:"reviewed_refs"`
+	Checklist     []ChecklistResult `json:"checklist,omitempty"`
 }
 
 type FeedbackDetail struct {
@@ -293,6 +394,9 @@ type FeedbackDetail struct {
 	LineRange  string `json:"line_range"`
 	Content    string `json:"content"`
 	Suggestion string `json:"suggestion"`
+
+	Status        string `json:"status,omitempty"` // open (default)/resolved/waived
+	Justification string `json:"justification,omitempty"`
 }
 
 type IssueWorkerState struct {
@@ -312,6 +416,7 @@ type NextStepToken struct {
 	Token      string   `json:"token"`
 	IssueID    string   `json:"issue_id"`
 	Actor      string   `json:"actor"`
+	Assignee   string   `json:"assignee,omitempty"` // set by the scheduler: only this worker may redeem the token
 	NextStep   NextStep `json:"next_step"`
 	Attached   bool     `json:"attached"`
 	AttachedAt string   `json:"attached_at"`
@@ -321,6 +426,7 @@ type NextStepToken struct {
 }
 
 type IssueTask struct {
+	SchemaVersion       int                 `json:"schema_version"`
 	ID                  string              `json:"id"`
 	IssueID             string              `json:"issue_id"`
 	Subject             string              `json:"subject"`
@@ -352,6 +458,15 @@ type IssueTask struct {
 	ReviewArtifacts     ReviewArtifacts     `json:"review_artifacts"`
 	FeedbackDetails     []FeedbackDetail    `json:"feedback_details"`
 	NextStepToken       string              `json:"next_step_token"`
+	LeaseFrozenAt       string              `json:"lease_frozen_at,omitempty"`
+	LeaseWarnedForMs    int64               `json:"lease_warned_for_ms,omitempty"` // lease expiry the owner was last warned about
+	PeerReviewer        string              `json:"peer_reviewer,omitempty"`
+	ClaimedAt           string              `json:"claimed_at,omitempty"`   // last claim
+	SubmittedAt         string              `json:"submitted_at,omitempty"` // last submission
+	ReviewedAt          string              `json:"reviewed_at,omitempty"`  // last review verdict
+	ReopenedAt          string              `json:"reopened_at,omitempty"`  // last reopen after done; earlier deliveries don't cover the task
+	EscalatedAt         string              `json:"escalated_at,omitempty"` // rejection limit reached: blocked until reset
+	Rev                 int64               `json:"rev"`                    // incremented on every write
 	CreatedAt           string              `json:"created_at"`
 	UpdatedAt           string              `json:"updated_at"`
 }
@@ -376,12 +491,15 @@ type IssueEvent struct {
 	CompletionScore     int                  `json:"completion_score,omitempty"`
 	NextStep            *NextStep            `json:"next_step,omitempty"`
 	NextStepToken       string               `json:"next_step_token,omitempty"`
+	InboxID             string               `json:"inbox_id,omitempty"` // set when served from the lead inbox (ackInboxItem/nackInboxItem)
 	Timestamp           string               `json:"timestamp"`
 }
 
 type issueMeta struct {
 	NextSeq     int64 `json:"next_seq"`
 	NextTaskNum int64 `json:"next_task_num"`
+
+	ShardedEvents bool `json:"sharded_events,omitempty"` // task events live in events/<task_id>.jsonl
 }
 
 type IssueService struct {
@@ -392,16 +510,43 @@ type IssueService struct {
 	taskTTLSec        int
 	defaultTimeoutSec int
 	minTimeoutSec     int
+	reviewSLASec      int // 0 = no review SLA
+	leaseWarnSec      int // 0 = no lease expiry warnings
+	maxRejections     int // 0 = no rejection limit
+	peerReview        bool
+	criticalGate      bool
+	shardEvents       bool           // new issues keep per-task event files
+	inboxRanks        map[string]int // lead inbox serving order by item type; nil = DefaultInboxPriorities
+	checklists        []ReviewChecklist
+	submissionRules   []SubmissionRule
+
+	leadClaimTTLSec     int // 0 = defaultInboxClaimTTLSec
+	acceptorClaimTTLSec int
+
+	policy ProgressionPolicy
+	runner *evidenceRunner
+	hooks  []ValidationHook
+	git    *gitVerifier
+
+	hookLogger *log.Logger
+
+	ciProviders map[string]CIProvider
+
+	claimValidators map[string]ClaimValidator
+
+	sinks     []EventSink
+	scheduler *schedulerConfig
+	dispatch  *dispatcher
+
+	expiry      expiryIndex      // upcoming lease and review deadlines, see SweepExpired
+	calibration calibrationCache // recent difficulty calibration, see CalibrateDifficulty
 
 	mu       sync.Mutex
 	cond     *sync.Cond
 	versions map[string]int64
+	gen      int64 // bumped on every change; waitChange waits for it to move
 }
 
 func NowStr() string {
 	return time.Now().UTC().Format(time.RFC3339)
 }
-
-func GenID(prefix string) string {
-	return fmt.Sprintf("%s_%d_%04x", prefix, time.Now().UnixMilli(), rand.Intn(0xFFFF))
-}
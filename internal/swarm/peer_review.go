@@ -0,0 +1,248 @@
+package swarm
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+)
+
+// SetPeerReview turns on peer review: every submission must be approved by a second worker (the task's
+// peer reviewer) before it reaches the lead's inbox.
+func (s *IssueService) SetPeerReview(enabled bool) {
+	s.peerReview = enabled
+}
+
+// PeerReviewEnabled reports whether SetPeerReview turned peer review on.
+func (s *IssueService) PeerReviewEnabled() bool {
+	return s.peerReview
+}
+
+// routeSubmissionLocked hands a new submission to its first reviewer: the lead inbox, or with peer review
+// on the task's peer reviewer (a peer_review item in their inbox). Without a peer reviewer the lead gets
+// a peer_review_needed item asking for one instead. Call under store lock.
+func (s *IssueService) routeSubmissionLocked(issueID string, task *IssueTask, sub *Submission, actor string) error {
+	if !s.peerReview {
+		_, err := s.pushToLeadInboxLocked(issueID, task.ID, InboxTypeSubmission, sub.ID, actor)
+		return err
+	}
+	sub.PeerReview = &PeerReview{Status: PeerReviewPending}
+	reviewer := strings.TrimSpace(task.PeerReviewer)
+	if reviewer == "" || reviewer == strings.TrimSpace(actor) {
+		if err := s.store.WriteJSON(s.submissionPath(issueID, task.ID, sub.ID), sub); err != nil {
+			return err
+		}
+		if _, err := s.pushToLeadInboxLocked(issueID, task.ID, InboxTypePeerReviewNeeded, sub.ID, actor); err != nil {
+			return err
+		}
+		return s.appendEventLocked(issueID, IssueEvent{
+			Type:         EventPeerReviewNeeded,
+			IssueID:      issueID,
+			TaskID:       task.ID,
+			Actor:        "system",
+			Detail:       "submission waits for a peer reviewer",
+			SubmissionID: sub.ID,
+			Timestamp:    NowStr(),
+		})
+	}
+	return s.requestPeerReviewLocked(issueID, sub, reviewer, actor)
+}
+
+// requestPeerReviewLocked assigns reviewer to the pending peer review of sub, saves it and pushes a
+// peer_review item to the reviewer's inbox. Call under store lock.
+func (s *IssueService) requestPeerReviewLocked(issueID string, sub *Submission, reviewer, sender string) error {
+	sub.PeerReview.ReviewerID = reviewer
+	sub.PeerReview.AssignedAt = NowStr()
+	sub.UpdatedAt = sub.PeerReview.AssignedAt
+	if err := s.store.WriteJSON(s.submissionPath(issueID, sub.TaskID, sub.ID), sub); err != nil {
+		return err
+	}
+	_, err := s.pushToWorkerInboxLocked(issueID, reviewer, sub.TaskID, InboxTypePeerReview, sub.ID, sender)
+	return err
+}
+
+// AssignPeerReviewer pairs a second worker with a task: that worker reviews the task's submissions before
+// the lead sees them. The reviewer cannot be the worker holding the task. A submission already waiting
+// for a peer review is handed to the new reviewer at once.
+func (s *IssueService) AssignPeerReviewer(issueID, taskID, reviewerID, actor string) (*IssueTask, error) {
+	if issueID == "" || taskID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id and task_id are required")
+	}
+	reviewerID = strings.TrimSpace(reviewerID)
+	if reviewerID == "" {
+		return nil, Errorf(CodeInvalidArgument, "reviewer_id is required")
+	}
+	if actor == "" {
+		actor = "lead"
+	}
+
+	var result *IssueTask
+	err := s.store.WithLock(func() error {
+		task, err := s.loadTaskLocked(issueID, taskID)
+		if err != nil {
+			return err
+		}
+		if reviewerID == strings.TrimSpace(task.ClaimedBy) {
+			return Errorf(CodeNotOwner, "worker '%s' holds task '%s' and cannot peer review it", reviewerID, taskID)
+		}
+		task.PeerReviewer = reviewerID
+		task.UpdatedAt = NowStr()
+		if err := s.saveTaskLocked(issueID, task); err != nil {
+			return err
+		}
+		if sub, err := s.getLatestOpenSubmissionLocked(issueID, taskID); err == nil && awaitingPeerReview(sub) && sub.PeerReview.ReviewerID != reviewerID && sub.WorkerID != reviewerID {
+			if err := s.requestPeerReviewLocked(issueID, sub, reviewerID, actor); err != nil {
+				return err
+			}
+			s.ackLeadInboxByRefLocked(issueID, sub.ID)
+		}
+		result = task
+		return s.appendEventLocked(issueID, IssueEvent{
+			Type:      EventPeerReviewerAssigned,
+			IssueID:   issueID,
+			TaskID:    taskID,
+			Actor:     actor,
+			Detail:    "peer reviewer " + reviewerID,
+			Timestamp: task.UpdatedAt,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.bump(issueID)
+	return result, nil
+}
+
+// PeerReviewSubmission records the assigned peer reviewer's verdict. Approved passes the submission on to
+// the lead inbox; rejected sends it back to the worker as peer_rejected (the blocked submitIssueTask
+// returns and the worker can resubmit). Peer rejections do not count toward the rejection limit.
+func (s *IssueService) PeerReviewSubmission(issueID, submissionID, reviewerID, verdict, comment string) (*Submission, error) {
+	if issueID == "" || submissionID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id and submission_id are required")
+	}
+	if verdict != VerdictApproved && verdict != VerdictRejected {
+		return nil, Errorf(CodeInvalidArgument, "invalid verdict: %s", verdict)
+	}
+	if verdict == VerdictRejected && strings.TrimSpace(comment) == "" {
+		return nil, Errorf(CodeInvalidArgument, "comment is required when rejecting")
+	}
+	reviewerID = strings.TrimSpace(reviewerID)
+
+	var result *Submission
+	err := s.store.WithLock(func() error {
+		sub, err := s.getSubmissionLocked(issueID, submissionID)
+		if err != nil {
+			return err
+		}
+		if !awaitingPeerReview(sub) {
+			return Errorf(CodeInvalidState, "submission '%s' is not waiting for a peer review", submissionID)
+		}
+		if sub.PeerReview.ReviewerID != reviewerID {
+			return Errorf(CodeNotOwner, "submission '%s' is assigned to peer reviewer '%s'", submissionID, sub.PeerReview.ReviewerID)
+		}
+		now := NowStr()
+		sub.PeerReview.Comment = comment
+		sub.PeerReview.ReviewedAt = now
+		sub.UpdatedAt = now
+		if verdict == VerdictApproved {
+			sub.PeerReview.Status = PeerReviewApproved
+		} else {
+			sub.PeerReview.Status = PeerReviewRejected
+			sub.Status = SubmissionPeerRejected
+			sub.Feedback = comment
+		}
+		if err := s.store.WriteJSON(s.submissionPath(issueID, sub.TaskID, sub.ID), sub); err != nil {
+			return err
+		}
+		if verdict == VerdictApproved {
+			// The review SLA restarts at the peer approval.
+			s.trackReviewDeadline(issueID, sub.TaskID, 0)
+			if _, err := s.pushToLeadInboxLocked(issueID, sub.TaskID, InboxTypeSubmission, sub.ID, sub.WorkerID); err != nil {
+				return err
+			}
+		}
+		result = sub
+		return s.appendEventLocked(issueID, IssueEvent{
+			Type:         EventSubmissionPeerReviewed,
+			IssueID:      issueID,
+			TaskID:       sub.TaskID,
+			Actor:        reviewerID,
+			Kind:         verdict,
+			Detail:       comment,
+			SubmissionID: sub.ID,
+			Timestamp:    now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.bump(issueID)
+	return result, nil
+}
+
+// WaitPeerReviews blocks until a submission is waiting for workerID's peer review, acks the inbox item
+// and returns the submission; (nil, nil) on timeout. Items whose submission is no longer waiting for
+// this reviewer are acked and skipped.
+func (s *IssueService) WaitPeerReviews(ctx context.Context, workerID string, timeoutSec int) (*Submission, error) {
+	workerID = strings.TrimSpace(workerID)
+	if workerID == "" {
+		return nil, Errorf(CodeInvalidArgument, "worker_id is required")
+	}
+	deadline := s.deadline(s.normalizeTimeoutSec(ctx, timeoutSec))
+	for {
+		since := s.changeGen()
+		var found *Submission
+		err := s.store.WithLock(func() error {
+			entries, _ := os.ReadDir(s.store.Path("issues"))
+			for _, e := range entries {
+				dir := s.store.Path("issues", e.Name(), "inbox", "workers", workerID)
+				for _, f := range listJSONOrEmpty(s.store, dir) {
+					var item InboxItem
+					if err := s.store.ReadJSON(f, &item); err != nil || item.Type != InboxTypePeerReview || item.Status == InboxDone {
+						continue
+					}
+					item.Status = InboxDone
+					item.UpdatedAt = NowStr()
+					if err := s.store.WriteJSON(f, &item); err != nil {
+						return err
+					}
+					sub, err := s.getSubmissionLocked(item.IssueID, item.RefID)
+					if err != nil || !awaitingPeerReview(sub) || sub.PeerReview.ReviewerID != workerID {
+						continue
+					}
+					found = sub
+					return nil
+				}
+			}
+			return nil
+		})
+		if err != nil || found != nil {
+			return found, err
+		}
+		if timeExpired(deadline) {
+			return nil, nil
+		}
+		if err := s.waitChange(ctx, since, pollInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// awaitingPeerReview reports whether sub still waits for its peer reviewer (so not yet for the lead).
+func awaitingPeerReview(sub *Submission) bool {
+	return sub.Status == SubmissionOpen && sub.PeerReview != nil && sub.PeerReview.Status == PeerReviewPending
+}
+
+// leadReviewWait is how long sub has waited for the lead: since the peer approval when it was peer
+// reviewed, otherwise since it was submitted.
+func leadReviewWait(sub *Submission, now time.Time) (time.Duration, bool) {
+	start := sub.CreatedAt
+	if sub.PeerReview != nil && sub.PeerReview.ReviewedAt != "" {
+		start = sub.PeerReview.ReviewedAt
+	}
+	t, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return 0, false
+	}
+	return now.Sub(t), true
+}
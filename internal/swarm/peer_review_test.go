@@ -0,0 +1,103 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPeerReview_GatesSubmissionsBeforeLead(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 1, 1)
+	svc.SetPeerReview(true)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 2}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	task := &IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskInProgress, ClaimedBy: "w1"}
+	if err := svc.saveTaskLocked(issueID, task); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	submit := func() *Submission {
+		t.Helper()
+		var sub *Submission
+		if err := store.WithLock(func() error {
+			var err error
+			if sub, err = svc.createSubmissionLocked(issueID, "task-1", "w1", SubmissionArtifacts{Summary: "done"}); err != nil {
+				return err
+			}
+			live, err := svc.loadTaskLocked(issueID, "task-1")
+			if err != nil {
+				return err
+			}
+			return svc.routeSubmissionLocked(issueID, live, sub, "w1")
+		}); err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+		return sub
+	}
+	leadItems := func() map[string]string {
+		out := map[string]string{}
+		for _, f := range listJSONOrEmpty(store, store.Path("issues", issueID, "inbox", "lead")) {
+			var item InboxItem
+			if err := store.ReadJSON(f, &item); err == nil && item.Status != InboxDone {
+				out[item.RefID] = item.Type
+			}
+		}
+		return out
+	}
+
+	// No reviewer yet: the lead is asked for one, not for a review.
+	first := submit()
+	if got := leadItems(); len(got) != 1 || got[first.ID] != InboxTypePeerReviewNeeded {
+		t.Fatalf("expected one peer_review_needed item, got %v", got)
+	}
+	if _, err := svc.AssignPeerReviewer(issueID, "task-1", "w1", "lead"); err == nil {
+		t.Fatalf("expected the task holder to be refused as its own reviewer")
+	}
+	if _, err := svc.AssignPeerReviewer(issueID, "task-1", "w2", "lead"); err != nil {
+		t.Fatalf("assign: %v", err)
+	}
+	if got := leadItems(); len(got) != 0 {
+		t.Fatalf("expected the peer_review_needed item to be acked, got %v", got)
+	}
+
+	sub, err := svc.WaitPeerReviews(context.Background(), "w2", 1)
+	if err != nil || sub == nil || sub.ID != first.ID {
+		t.Fatalf("expected w2 to receive %s, got %+v %v", first.ID, sub, err)
+	}
+	if _, err := svc.PeerReviewSubmission(issueID, first.ID, "w3", VerdictApproved, ""); err == nil {
+		t.Fatalf("expected a non-assigned reviewer to be refused")
+	}
+	if _, err := svc.PeerReviewSubmission(issueID, first.ID, "w2", VerdictRejected, "tests missing"); err != nil {
+		t.Fatalf("peer reject: %v", err)
+	}
+	got, err := svc.GetSubmission(issueID, first.ID)
+	if err != nil {
+		t.Fatalf("get submission: %v", err)
+	}
+	if got.Status != SubmissionPeerRejected || got.PeerReview.Status != PeerReviewRejected || got.Feedback != "tests missing" {
+		t.Fatalf("expected a peer_rejected submission, got %+v", got)
+	}
+	if items := leadItems(); len(items) != 0 {
+		t.Fatalf("expected nothing for the lead after a peer rejection, got %v", items)
+	}
+	if n := svc.rejectionsSinceClaimLocked(issueID, task); n != 0 {
+		t.Fatalf("expected peer rejections not to count toward the rejection limit, got %d", n)
+	}
+
+	// With the reviewer paired, the next submission goes straight to w2; approval reaches the lead.
+	second := submit()
+	if items := leadItems(); len(items) != 0 {
+		t.Fatalf("expected the resubmission to skip the lead inbox, got %v", items)
+	}
+	if _, err := svc.PeerReviewSubmission(issueID, second.ID, "w2", VerdictApproved, "lgtm"); err != nil {
+		t.Fatalf("peer approve: %v", err)
+	}
+	if items := leadItems(); len(items) != 1 || items[second.ID] != InboxTypeSubmission {
+		t.Fatalf("expected the approved submission in the lead inbox, got %v", items)
+	}
+}
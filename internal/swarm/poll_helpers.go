@@ -1,6 +1,9 @@
 package swarm
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 func (s *IssueService) deadline(timeoutSec int) time.Time {
 	sec := timeoutSec
@@ -17,6 +20,38 @@ func timeExpired(dl time.Time) bool {
 	return time.Now().After(dl)
 }
 
-func sleepPoll() {
-	time.Sleep(200 * time.Millisecond)
+// pollInterval bounds how long a long-poll waits between checks of the store. Changes made through
+// this IssueService wake waiters at once (see bump); the interval covers other processes sharing the root.
+const pollInterval = 200 * time.Millisecond
+
+// changeGen returns the current change generation. Read it before checking the store, then pass it to
+// waitChange so a change made in between is not missed.
+func (s *IssueService) changeGen() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.gen
+}
+
+// waitChange blocks until bump has been called since gen was read, d has elapsed or ctx is done. It
+// returns ctx.Err() once ctx is done, so long-polls stop as soon as their request is cancelled.
+func (s *IssueService) waitChange(ctx context.Context, gen int64, d time.Duration) error {
+	if err := ctx.Err(); err != nil || d <= 0 {
+		return err
+	}
+	timedOut := false
+	wake := func() {
+		s.mu.Lock()
+		timedOut = true
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	}
+	t := time.AfterFunc(d, wake)
+	defer t.Stop()
+	defer context.AfterFunc(ctx, wake)()
+	s.mu.Lock()
+	for s.gen == gen && !timedOut {
+		s.cond.Wait()
+	}
+	s.mu.Unlock()
+	return ctx.Err()
 }
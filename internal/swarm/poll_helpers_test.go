@@ -0,0 +1,83 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitChange_WakesOnBump(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+	ctx := context.Background()
+
+	gen := svc.changeGen()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		svc.bump("issue-1")
+	}()
+	start := time.Now()
+	if err := svc.waitChange(ctx, gen, 5*time.Second); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Fatalf("expected bump to wake the waiter, waited %s", waited)
+	}
+
+	// A bump between reading the generation and waiting is not lost.
+	gen = svc.changeGen()
+	svc.bump("issue-1")
+	start = time.Now()
+	_ = svc.waitChange(ctx, gen, 5*time.Second)
+	if waited := time.Since(start); waited > 100*time.Millisecond {
+		t.Fatalf("expected an earlier bump to return at once, waited %s", waited)
+	}
+
+	start = time.Now()
+	_ = svc.waitChange(ctx, svc.changeGen(), 50*time.Millisecond)
+	if waited := time.Since(start); waited < 50*time.Millisecond {
+		t.Fatalf("expected waitChange to time out after 50ms, returned after %s", waited)
+	}
+}
+
+func TestWaitIssueTasks_StopsWhenCancelled(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := svc.WaitIssueTasks(ctx, "issue-1", IssueTaskOpen, TaskFilter{}, 3600, 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Fatalf("expected the wait to end on cancel, waited %s", waited)
+	}
+}
+
+func TestNormalizeTimeoutSec_ShortTimeoutsOnRequest(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	if got := svc.normalizeTimeoutSec(context.Background(), 10); got != 3600 {
+		t.Fatalf("expected a 10s timeout to be raised to the 3600s minimum, got %d", got)
+	}
+	short := WithShortTimeouts(context.Background())
+	if got := svc.normalizeTimeoutSec(short, 10); got != 10 {
+		t.Fatalf("expected the requested 10s, got %d", got)
+	}
+	if got := svc.normalizeTimeoutSec(short, 0); got != 3600 {
+		t.Fatalf("expected an unset timeout to use the default, got %d", got)
+	}
+
+	start := time.Now()
+	tasks, err := svc.WaitIssueTasks(short, "issue-1", IssueTaskOpen, TaskFilter{}, 1, 0)
+	if err != nil || len(tasks) != 0 {
+		t.Fatalf("wait: %+v %v", tasks, err)
+	}
+	if waited := time.Since(start); waited > 3*time.Second {
+		t.Fatalf("expected a 1s wait, waited %s", waited)
+	}
+}
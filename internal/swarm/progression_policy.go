@@ -0,0 +1,166 @@
+package swarm
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProgressionPolicy controls how workers graduate between task difficulties in
+// GetNextStepToken. Zero-valued fields in a loaded policy fall back to defaults.
+type ProgressionPolicy struct {
+	// Minimum accumulated points to be offered medium/focus tasks.
+	MediumMinPoints int `json:"medium_min_points"`
+	FocusMinPoints  int `json:"focus_min_points"`
+
+	// Scores strictly below this count as a low score.
+	LowScoreBelow int `json:"low_score_below"`
+
+	// Allowed completion_score values for reviewIssueTask/getNextStepToken, with their meaning.
+	CompletionScores []CompletionScore `json:"completion_scores"`
+
+	// Consecutive low scores tolerated before downgrading, keyed by point level.
+	FailureBuffers []FailureBuffer `json:"failure_buffers"`
+
+	// Task picking within a difficulty tier (tasks are sorted by points desc):
+	// >= PickHighestMinPoints picks the highest-point task,
+	// [PickLowestMinPoints, PickLowestMaxPoints) picks the lowest, otherwise the median.
+	PickHighestMinPoints int `json:"pick_highest_min_points"`
+	PickLowestMinPoints  int `json:"pick_lowest_min_points"`
+	PickLowestMaxPoints  int `json:"pick_lowest_max_points"`
+}
+
+type FailureBuffer struct {
+	MinPoints       int `json:"min_points"`
+	AllowedFailures int `json:"allowed_failures"`
+}
+
+type CompletionScore struct {
+	Value int    `json:"value"`
+	Label string `json:"label,omitempty"`
+}
+
+func DefaultProgressionPolicy() ProgressionPolicy {
+	return ProgressionPolicy{
+		MediumMinPoints: 10,
+		FocusMinPoints:  30,
+		LowScoreBelow:   2,
+		CompletionScores: []CompletionScore{
+			{Value: 1, Label: "poor"},
+			{Value: 2, Label: "acceptable"},
+			{Value: 5, Label: "excellent"},
+		},
+		FailureBuffers: []FailureBuffer{
+			{MinPoints: 50, AllowedFailures: 1},
+			{MinPoints: 100, AllowedFailures: 2},
+		},
+		PickHighestMinPoints: 100,
+		PickLowestMinPoints:  30,
+		PickLowestMaxPoints:  50,
+	}
+}
+
+// ParseProgressionPolicy decodes a JSON policy on top of the defaults and validates it.
+func ParseProgressionPolicy(data []byte) (ProgressionPolicy, error) {
+	def := DefaultProgressionPolicy()
+	var p ProgressionPolicy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return def, fmt.Errorf("parse progression policy: %w", err)
+	}
+	if p.MediumMinPoints <= 0 {
+		p.MediumMinPoints = def.MediumMinPoints
+	}
+	if p.FocusMinPoints <= 0 {
+		p.FocusMinPoints = def.FocusMinPoints
+	}
+	if p.LowScoreBelow <= 0 {
+		p.LowScoreBelow = def.LowScoreBelow
+	}
+	if p.FailureBuffers == nil {
+		p.FailureBuffers = def.FailureBuffers
+	}
+	if len(p.CompletionScores) == 0 {
+		p.CompletionScores = def.CompletionScores
+	}
+	if p.PickHighestMinPoints <= 0 {
+		p.PickHighestMinPoints = def.PickHighestMinPoints
+	}
+	if p.PickLowestMinPoints <= 0 {
+		p.PickLowestMinPoints = def.PickLowestMinPoints
+	}
+	if p.PickLowestMaxPoints <= 0 {
+		p.PickLowestMaxPoints = def.PickLowestMaxPoints
+	}
+	if p.FocusMinPoints < p.MediumMinPoints {
+		return def, Errorf(CodeInvalidArgument, "progression policy: focus_min_points (%d) must be >= medium_min_points (%d)", p.FocusMinPoints, p.MediumMinPoints)
+	}
+	if p.PickLowestMaxPoints < p.PickLowestMinPoints {
+		return def, Errorf(CodeInvalidArgument, "progression policy: pick_lowest_max_points (%d) must be >= pick_lowest_min_points (%d)", p.PickLowestMaxPoints, p.PickLowestMinPoints)
+	}
+	for i, b := range p.FailureBuffers {
+		if b.MinPoints < 0 || b.AllowedFailures < 0 {
+			return def, Errorf(CodeInvalidArgument, "progression policy: failure_buffers[%d] must be non-negative", i)
+		}
+	}
+	sort.SliceStable(p.FailureBuffers, func(i, j int) bool {
+		return p.FailureBuffers[i].MinPoints < p.FailureBuffers[j].MinPoints
+	})
+	seen := map[int]bool{}
+	for i, c := range p.CompletionScores {
+		if c.Value <= 0 || seen[c.Value] {
+			return def, Errorf(CodeInvalidArgument, "progression policy: completion_scores[%d].value must be positive and unique (got %d)", i, c.Value)
+		}
+		seen[c.Value] = true
+	}
+	sort.SliceStable(p.CompletionScores, func(i, j int) bool {
+		return p.CompletionScores[i].Value < p.CompletionScores[j].Value
+	})
+	if top := p.CompletionScores[len(p.CompletionScores)-1].Value; p.LowScoreBelow > top {
+		return def, Errorf(CodeInvalidArgument, "progression policy: low_score_below (%d) must be <= the highest completion score (%d)", p.LowScoreBelow, top)
+	}
+	return p, nil
+}
+
+// checkCompletionScore rejects a completion_score outside the policy's scale.
+func (p ProgressionPolicy) checkCompletionScore(v int) error {
+	allowed := make([]string, 0, len(p.CompletionScores))
+	for _, c := range p.CompletionScores {
+		if c.Value == v {
+			return nil
+		}
+		allowed = append(allowed, strconv.Itoa(c.Value))
+	}
+	return Errorf(CodeInvalidArgument, "invalid completion_score: %d (allowed: %s)", v, strings.Join(allowed, "|"))
+}
+
+func (p ProgressionPolicy) baseDifficulty(total int) string {
+	if total >= p.FocusMinPoints {
+		return "focus"
+	}
+	if total >= p.MediumMinPoints {
+		return "medium"
+	}
+	return "easy"
+}
+
+func (p ProgressionPolicy) allowedFailures(total int) int {
+	allowed := 0
+	for _, b := range p.FailureBuffers {
+		if total >= b.MinPoints {
+			allowed = b.AllowedFailures
+		}
+	}
+	return allowed
+}
+
+// SetProgressionPolicy replaces the difficulty progression policy. Call before serving requests.
+func (s *IssueService) SetProgressionPolicy(p ProgressionPolicy) {
+	s.policy = p
+}
+
+// CompletionScores returns the completion_score scale in effect, lowest first.
+func (s *IssueService) CompletionScores() []CompletionScore {
+	return s.policy.CompletionScores
+}
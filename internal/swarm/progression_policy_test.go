@@ -0,0 +1,71 @@
+package swarm
+
+import "testing"
+
+func TestParseProgressionPolicy_FillsDefaultsAndValidates(t *testing.T) {
+	p, err := ParseProgressionPolicy([]byte(`{"medium_min_points": 5, "failure_buffers": [{"min_points": 20, "allowed_failures": 3}]}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if p.MediumMinPoints != 5 || p.FocusMinPoints != 30 {
+		t.Fatalf("unexpected thresholds: %+v", p)
+	}
+	if got := p.baseDifficulty(4); got != "easy" {
+		t.Fatalf("baseDifficulty(4) = %s", got)
+	}
+	if got := p.baseDifficulty(5); got != "medium" {
+		t.Fatalf("baseDifficulty(5) = %s", got)
+	}
+	if got := p.allowedFailures(19); got != 0 {
+		t.Fatalf("allowedFailures(19) = %d", got)
+	}
+	if got := p.allowedFailures(20); got != 3 {
+		t.Fatalf("allowedFailures(20) = %d", got)
+	}
+
+	if _, err := ParseProgressionPolicy([]byte(`{"medium_min_points": 40, "focus_min_points": 20}`)); err == nil {
+		t.Fatalf("expected error for focus < medium")
+	}
+}
+
+func TestDefaultProgressionPolicy_MatchesLegacyThresholds(t *testing.T) {
+	p := DefaultProgressionPolicy()
+	cases := map[int]string{0: "easy", 9: "easy", 10: "medium", 29: "medium", 30: "focus"}
+	for pts, want := range cases {
+		if got := p.baseDifficulty(pts); got != want {
+			t.Errorf("baseDifficulty(%d) = %s, want %s", pts, got, want)
+		}
+	}
+	if p.allowedFailures(49) != 0 || p.allowedFailures(50) != 1 || p.allowedFailures(100) != 2 {
+		t.Fatalf("unexpected failure buffers: %+v", p.FailureBuffers)
+	}
+}
+
+func TestParseProgressionPolicy_CompletionScoreScale(t *testing.T) {
+	if err := DefaultProgressionPolicy().checkCompletionScore(3); err == nil {
+		t.Fatalf("expected 3 to be outside the default 1|2|5 scale")
+	}
+
+	p, err := ParseProgressionPolicy([]byte(`{"low_score_below": 3, "completion_scores": [{"value": 5}, {"value": 4}, {"value": 3}, {"value": 2}, {"value": 1, "label": "missing"}]}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if p.CompletionScores[0].Value != 1 || p.CompletionScores[0].Label != "missing" {
+		t.Fatalf("expected scores sorted ascending, got %+v", p.CompletionScores)
+	}
+	for _, v := range []int{1, 3, 4} {
+		if err := p.checkCompletionScore(v); err != nil {
+			t.Fatalf("score %d: %v", v, err)
+		}
+	}
+	if err := p.checkCompletionScore(6); err == nil {
+		t.Fatalf("expected 6 to be rejected")
+	}
+
+	if _, err := ParseProgressionPolicy([]byte(`{"completion_scores": [{"value": 1}, {"value": 1}]}`)); err == nil {
+		t.Fatalf("expected error for duplicate scores")
+	}
+	if _, err := ParseProgressionPolicy([]byte(`{"low_score_below": 4, "completion_scores": [{"value": 1}, {"value": 3}]}`)); err == nil {
+		t.Fatalf("expected error for low_score_below above the scale")
+	}
+}
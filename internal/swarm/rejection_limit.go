@@ -0,0 +1,73 @@
+package swarm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SetMaxRejections sets how many rejected submissions a task may collect under one claim before it is
+// escalated to the lead; 0 disables the limit.
+func (s *IssueService) SetMaxRejections(n int) {
+	if n < 0 {
+		n = 0
+	}
+	s.maxRejections = n
+}
+
+// rejectionsSinceClaimLocked counts the task's rejected submissions made under its current claim.
+// Call under store lock.
+func (s *IssueService) rejectionsSinceClaimLocked(issueID string, task *IssueTask) int {
+	return len(s.rejectedSubmissionsLocked(issueID, task))
+}
+
+// rejectedSubmissionsLocked returns the task's rejected submissions under its current claim, oldest
+// first. Call under store lock.
+func (s *IssueService) rejectedSubmissionsLocked(issueID string, task *IssueTask) []Submission {
+	var out []Submission
+	for _, f := range listJSONOrEmpty(s.store, s.store.Path("issues", issueID, "submissions", task.ID)) {
+		var sub Submission
+		if err := s.store.ReadJSON(f, &sub); err != nil || sub.Status != SubmissionRejected {
+			continue
+		}
+		if task.ClaimedAt != "" && sub.CreatedAt < task.ClaimedAt {
+			continue
+		}
+		out = append(out, sub)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt < out[j].CreatedAt })
+	return out
+}
+
+// escalateRejectionsLocked hands a task that reached the rejection limit back to the lead: an
+// escalation item (ref: the last rejected submission) in the lead inbox and an issue_task_escalated
+// event summarizing every rejected round. The task is already blocked by the caller and further
+// submissions are refused until the lead resets it. Call under store lock.
+func (s *IssueService) escalateRejectionsLocked(issueID string, task *IssueTask, submissionID string, rejections int) error {
+	if _, err := s.pushToLeadInboxLocked(issueID, task.ID, InboxTypeEscalation, submissionID, "system"); err != nil {
+		return err
+	}
+	return s.appendEventLocked(issueID, IssueEvent{
+		Type:         EventIssueTaskEscalated,
+		IssueID:      issueID,
+		TaskID:       task.ID,
+		Actor:        "system",
+		Detail:       s.rejectionSummaryLocked(issueID, task, rejections),
+		SubmissionID: submissionID,
+		Timestamp:    task.EscalatedAt,
+	})
+}
+
+// rejectionSummaryLocked lists the feedback of each rejected round and suggests the way out.
+func (s *IssueService) rejectionSummaryLocked(issueID string, task *IssueTask, rejections int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "task %s rejected %d times (limit %d) for worker %s; blocked.\n", task.ID, rejections, s.maxRejections, task.ClaimedBy)
+	for i, sub := range s.rejectedSubmissionsLocked(issueID, task) {
+		fmt.Fprintf(&b, "round %d (%s): %s\n", i+1, sub.ID, strings.TrimSpace(sub.Feedback))
+		for _, fd := range sub.FeedbackDetails {
+			fmt.Fprintf(&b, "  - [%s/%s] %s\n", fd.Dimension, fd.Severity, strings.TrimSpace(fd.Content))
+		}
+	}
+	b.WriteString("Suggested: have an admin resetIssueTask to reassign it (rework the spec first if the rounds show it is unclear).")
+	return b.String()
+}
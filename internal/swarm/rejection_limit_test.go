@@ -0,0 +1,67 @@
+package swarm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestReviewTask_EscalatesAtRejectionLimit(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+	svc.SetMaxRejections(2)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueInProgress}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 2}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	if err := svc.saveTaskLocked(issueID, &IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskInProgress, ClaimedBy: "w1", ClaimedAt: "2024-01-01T10:00:00Z"}); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	for _, sub := range []Submission{
+		{ID: "sub-0", Status: SubmissionRejected, Feedback: "from an earlier claim", CreatedAt: "2024-01-01T09:00:00Z"},
+		{ID: "sub-1", Status: SubmissionRejected, Feedback: "tests missing", CreatedAt: "2024-01-01T10:30:00Z"},
+		{ID: "sub-2", Status: SubmissionOpen, CreatedAt: "2024-01-01T11:00:00Z"},
+	} {
+		sub.IssueID, sub.TaskID, sub.WorkerID = issueID, "task-1", "w1"
+		if err := store.WriteJSON(store.Path("issues", issueID, "submissions", "task-1", sub.ID+".json"), &sub); err != nil {
+			t.Fatalf("write submission: %v", err)
+		}
+	}
+	tok := NextStepToken{Token: "ns-1", IssueID: issueID, Actor: "lead", NextStep: NextStep{Type: "end"}}
+	if err := store.WriteJSON(store.Path("issues", issueID, "next_steps", tok.Token+".json"), tok); err != nil {
+		t.Fatalf("write token: %v", err)
+	}
+
+	task, err := svc.ReviewTask("lead", issueID, "task-1", "sub-2", VerdictRejected, "still no tests", 1,
+		ReviewArtifacts{ReviewSummary: "no tests", ReviewedRefs: []string{"main.go"}},
+		[]FeedbackDetail{{Dimension: "tests", Severity: "high", Content: "add tests"}}, tok.Token, 0)
+	if err != nil {
+		t.Fatalf("review: %v", err)
+	}
+	if task.Status != IssueTaskBlocked || task.EscalatedAt == "" {
+		t.Fatalf("expected task blocked and escalated after 2 rejections under this claim, got %s %q", task.Status, task.EscalatedAt)
+	}
+
+	var escalation *InboxItem
+	for _, f := range listJSONOrEmpty(store, store.Path("issues", issueID, "inbox", "lead")) {
+		var item InboxItem
+		if err := store.ReadJSON(f, &item); err == nil && item.Type == InboxTypeEscalation {
+			escalation = &item
+		}
+	}
+	if escalation == nil || escalation.RefID != "sub-2" {
+		t.Fatalf("expected an escalation item for sub-2 in the lead inbox, got %+v", escalation)
+	}
+	detail, _ := svc.materializeInboxItem(issueID, escalation)["detail"].(string)
+	if !strings.Contains(detail, "tests missing") || !strings.Contains(detail, "still no tests") || strings.Contains(detail, "earlier claim") {
+		t.Fatalf("expected both rounds of this claim in the summary, got %q", detail)
+	}
+
+	if _, err := svc.SubmitTask(context.Background(), issueID, "task-1", "w1", SubmissionArtifacts{Summary: "s", ChangedFiles: []string{"a"}, TestCases: []string{"t"}, TestResult: "pass", TestOutput: "ok"}); err == nil || !strings.Contains(err.Error(), "escalated") {
+		t.Fatalf("expected submissions to be refused while escalated, got %v", err)
+	}
+}
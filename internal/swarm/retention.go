@@ -0,0 +1,168 @@
+package swarm
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Retention actions for closed issues.
+const (
+	RetentionArchive = "archive" // export to <root>/archive/ first, then remove
+	RetentionDelete  = "delete"
+)
+
+// Trace events logged when retention removes an issue.
+const (
+	TraceIssueArchived = "issue_archived"
+	TraceIssuePurged   = "issue_purged"
+)
+
+// RetentionPolicy bounds how much history the store keeps. Zero values disable a rule.
+type RetentionPolicy struct {
+	ClosedIssueDays int    // remove done/canceled issues not updated for this many days
+	ClosedAction    string // RetentionArchive (default) or RetentionDelete
+	TraceMaxBytes   int64  // rotate trace/events.jsonl into a compressed segment once it grows past this
+}
+
+// Enabled reports whether any rule is set.
+func (p RetentionPolicy) Enabled() bool {
+	return p.ClosedIssueDays > 0 || p.TraceMaxBytes > 0
+}
+
+// ValidRetentionAction reports whether action is a known closed-issue action ("" means archive).
+func ValidRetentionAction(action string) bool {
+	return action == "" || action == RetentionArchive || action == RetentionDelete
+}
+
+// RetentionReport lists what one ApplyRetention pass removed.
+type RetentionReport struct {
+	Archived     []string `json:"archived,omitempty"`
+	Deleted      []string `json:"deleted,omitempty"`
+	ArchivePaths []string `json:"archive_paths,omitempty"`
+	TraceRotated bool     `json:"trace_rotated,omitempty"`
+	Skipped      bool     `json:"skipped,omitempty"` // another process is applying retention to this store
+}
+
+// ApplyRetention enforces p on this store: done/canceled issues older than ClosedIssueDays are archived
+// (the ExportIssue archive is written to <root>/archive/ first) or deleted, together with their
+// deliveries and acceptor inbox items, and an oversized trace log is rotated. Each removal is recorded in
+// the trace log, since the issue's own event log goes with it. Only one process applies retention to a
+// store at a time; a pass that finds another one running reports Skipped.
+func (s *IssueService) ApplyRetention(p RetentionPolicy) (*RetentionReport, error) {
+	report := &RetentionReport{}
+	release, ok, err := s.store.TryLock(".retention.lock")
+	if err != nil {
+		return report, err
+	}
+	if !ok {
+		report.Skipped = true
+		return report, nil
+	}
+	defer release()
+	if p.ClosedIssueDays > 0 {
+		if err := s.expireClosedIssues(p, report); err != nil {
+			return report, err
+		}
+	}
+	if p.TraceMaxBytes > 0 {
+		rotated, err := s.rotateTrace(p.TraceMaxBytes)
+		if err != nil {
+			return report, err
+		}
+		report.TraceRotated = rotated
+	}
+	return report, nil
+}
+
+func (s *IssueService) expireClosedIssues(p RetentionPolicy, report *RetentionReport) error {
+	cutoff := time.Now().Add(-time.Duration(p.ClosedIssueDays) * 24 * time.Hour)
+	issues, err := s.ListIssues()
+	if err != nil {
+		return err
+	}
+	for _, is := range issues {
+		if !retentionExpired(&is, cutoff) {
+			continue
+		}
+		archive := ""
+		if p.ClosedAction != RetentionDelete {
+			path, _, err := s.ExportIssueToDir(is.ID, "archive")
+			if err != nil {
+				return Errorf(CodeInvalidArgument, "archive %s: %w", is.ID, err)
+			}
+			archive = path
+		}
+		removed := false
+		err := s.store.WithLock(func() error {
+			// Re-check under the lock: the issue may have been reopened since it was listed.
+			var cur Issue
+			if err := s.store.ReadJSON(s.store.Path("issues", is.ID, "issue.json"), &cur); err != nil || !retentionExpired(&cur, cutoff) {
+				return nil
+			}
+			if err := s.removeIssueLocked(is.ID); err != nil {
+				return err
+			}
+			removed = true
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if !removed {
+			if archive != "" {
+				_ = os.Remove(archive)
+			}
+			continue
+		}
+		event := TraceEvent{Type: TraceIssuePurged, Actor: "retention", Subject: is.ID, Detail: fmt.Sprintf("%s issue %q, last updated %s", is.Status, is.Subject, is.UpdatedAt)}
+		if archive != "" {
+			event.Type = TraceIssueArchived
+			event.Detail += "; archive " + archive
+			report.Archived = append(report.Archived, is.ID)
+			report.ArchivePaths = append(report.ArchivePaths, archive)
+		} else {
+			report.Deleted = append(report.Deleted, is.ID)
+		}
+		if s.trace != nil {
+			s.trace.Log(event)
+		}
+	}
+	return nil
+}
+
+func retentionExpired(is *Issue, cutoff time.Time) bool {
+	if is.Status != IssueDone && is.Status != IssueCanceled {
+		return false
+	}
+	updated, err := time.Parse(time.RFC3339, is.UpdatedAt)
+	return err == nil && updated.Before(cutoff)
+}
+
+// removeIssueLocked deletes an issue directory with its deliveries and acceptor inbox items. Must be
+// called under store lock.
+func (s *IssueService) removeIssueLocked(issueID string) error {
+	for _, dir := range []string{s.store.Path("deliveries"), s.store.Path("deliveries", "inbox", "acceptor")} {
+		for _, f := range listJSONOrEmpty(s.store, dir) {
+			var ref struct {
+				IssueID string `json:"issue_id"`
+			}
+			if err := s.store.ReadJSON(f, &ref); err == nil && ref.IssueID == issueID {
+				if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+			}
+		}
+	}
+	return os.RemoveAll(s.store.Path("issues", issueID))
+}
+
+// rotateTrace moves trace/events.jsonl into a compressed segment once it is larger than maxBytes.
+func (s *IssueService) rotateTrace(maxBytes int64) (bool, error) {
+	info, err := os.Stat(s.store.Path("trace", "events.jsonl"))
+	if err != nil || info.Size() <= maxBytes || s.trace == nil {
+		return false, nil
+	}
+	seg, err := s.trace.Rotate()
+	return seg != "", err
+}
@@ -0,0 +1,106 @@
+package swarm
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyRetention_ArchivesOldClosedIssues(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+	old := time.Now().Add(-100 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	for _, is := range []Issue{
+		{ID: "issue-old", Status: IssueDone, UpdatedAt: old},
+		{ID: "issue-open", Status: IssueOpen, UpdatedAt: old},
+		{ID: "issue-recent", Status: IssueCanceled, UpdatedAt: NowStr()},
+	} {
+		if err := store.WriteJSON(store.Path("issues", is.ID, "issue.json"), &is); err != nil {
+			t.Fatalf("write issue: %v", err)
+		}
+	}
+	if err := store.WriteJSON(store.Path("deliveries", "dlv-1.json"), &Delivery{ID: "dlv-1", IssueID: "issue-old"}); err != nil {
+		t.Fatalf("write delivery: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("deliveries", "dlv-2.json"), &Delivery{ID: "dlv-2", IssueID: "issue-open"}); err != nil {
+		t.Fatalf("write delivery: %v", err)
+	}
+
+	report, err := svc.ApplyRetention(RetentionPolicy{ClosedIssueDays: 90})
+	if err != nil {
+		t.Fatalf("retention: %v", err)
+	}
+	if len(report.Archived) != 1 || report.Archived[0] != "issue-old" || len(report.Deleted) != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if _, err := os.Stat(report.ArchivePaths[0]); err != nil {
+		t.Fatalf("expected an archive: %v", err)
+	}
+	if store.Exists("issues", "issue-old") || store.Exists("deliveries", "dlv-1.json") {
+		t.Fatalf("expected the old issue and its delivery removed")
+	}
+	if !store.Exists("issues", "issue-open") || !store.Exists("issues", "issue-recent") || !store.Exists("deliveries", "dlv-2.json") {
+		t.Fatalf("expected open and recent issues kept")
+	}
+	trace, _ := os.ReadFile(store.Path("trace", "events.jsonl"))
+	if !strings.Contains(string(trace), TraceIssueArchived) {
+		t.Fatalf("expected an issue_archived trace event, got %s", trace)
+	}
+}
+
+func TestApplyRetention_RotatesTrace(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+	store.EnsureDir("trace")
+	if err := os.WriteFile(store.Path("trace", "events.jsonl"), []byte(strings.Repeat("x", 2048)), 0o644); err != nil {
+		t.Fatalf("write trace: %v", err)
+	}
+	report, err := svc.ApplyRetention(RetentionPolicy{TraceMaxBytes: 1024})
+	if err != nil || !report.TraceRotated {
+		t.Fatalf("expected the trace rotated, got %+v %v", report, err)
+	}
+	if store.Exists("trace", "events.jsonl") || len(traceSegments(store.Path("trace"))) != 1 {
+		t.Fatalf("expected events.jsonl moved into a segment")
+	}
+	if report, _ := svc.ApplyRetention(RetentionPolicy{TraceMaxBytes: 1024}); report.TraceRotated {
+		t.Fatalf("expected no rotation below the cap")
+	}
+}
+
+func TestApplyRetention_SkipsWhileAnotherProcessRuns(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+	old := time.Now().Add(-100 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	if err := store.WriteJSON(store.Path("issues", "issue-old", "issue.json"), &Issue{ID: "issue-old", Status: IssueDone, UpdatedAt: old}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	release, ok, err := store.TryLock(".retention.lock")
+	if err != nil || !ok {
+		t.Fatalf("take retention lock: %v %v", ok, err)
+	}
+	report, err := svc.ApplyRetention(RetentionPolicy{ClosedIssueDays: 90})
+	if err != nil || !report.Skipped || len(report.Archived) != 0 {
+		t.Fatalf("expected a skipped pass, got %+v %v", report, err)
+	}
+	release()
+	report, err = svc.ApplyRetention(RetentionPolicy{ClosedIssueDays: 90})
+	if err != nil || report.Skipped || len(report.Archived) != 1 {
+		t.Fatalf("expected the issue archived once the lock is free, got %+v %v", report, err)
+	}
+}
+
+func TestExportIssueToDir_UniqueNames(t *testing.T) {
+	svc, _ := exportFixture(t, TaskSchemaVersion)
+	a, _, err := svc.ExportIssueToDir("issue_1_aaaa", "archive")
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	b, _, err := svc.ExportIssueToDir("issue_1_aaaa", "archive")
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if a == b {
+		t.Fatalf("two exports in the same second share %s", a)
+	}
+}
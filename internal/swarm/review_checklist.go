@@ -0,0 +1,162 @@
+package swarm
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// Review checklists. Operators list checklists (security, tests, docs, ...) in a JSON file; a checklist
+// applies to tasks carrying one of its labels or having one of its difficulties, and to every task when
+// it names neither. reviewIssueTask must then report a pass or fail for every item of every applicable
+// checklist in artifacts.checklist, which is stored with the review. An approval with a failed item is
+// refused.
+
+const (
+	ChecklistPass = "pass"
+	ChecklistFail = "fail"
+)
+
+// ReviewChecklistConfig is the checklist file: {"checklists": [...]}.
+type ReviewChecklistConfig struct {
+	Checklists []ReviewChecklist `json:"checklists"`
+}
+
+type ReviewChecklist struct {
+	Name         string   `json:"name"`
+	Labels       []string `json:"labels,omitempty"`
+	Difficulties []string `json:"difficulties,omitempty"`
+	Items        []string `json:"items"`
+}
+
+// ChecklistItem names one item of an applicable checklist.
+type ChecklistItem struct {
+	Checklist string `json:"checklist"`
+	Item      string `json:"item"`
+}
+
+// ChecklistResult is the reviewer's verdict on one checklist item.
+type ChecklistResult struct {
+	Checklist string `json:"checklist"`
+	Item      string `json:"item"`
+	Result    string `json:"result"` // pass/fail
+	Note      string `json:"note,omitempty"`
+}
+
+// ParseReviewChecklists parses and validates a review checklist file.
+func ParseReviewChecklists(bs []byte) (ReviewChecklistConfig, error) {
+	var cfg ReviewChecklistConfig
+	if err := json.Unmarshal(bs, &cfg); err != nil {
+		return ReviewChecklistConfig{}, Errorf(CodeInvalidArgument, "invalid review checklists: %w", err)
+	}
+	seen := map[string]bool{}
+	for i, c := range cfg.Checklists {
+		name := strings.TrimSpace(c.Name)
+		if name == "" {
+			return ReviewChecklistConfig{}, Errorf(CodeInvalidArgument, "invalid review checklists: checklists[%d].name is required", i)
+		}
+		if seen[strings.ToLower(name)] {
+			return ReviewChecklistConfig{}, Errorf(CodeInvalidArgument, "invalid review checklists: checklist %q listed twice", name)
+		}
+		seen[strings.ToLower(name)] = true
+		for _, d := range c.Difficulties {
+			if d != "easy" && d != "medium" && d != "focus" {
+				return ReviewChecklistConfig{}, Errorf(CodeInvalidArgument, "invalid review checklists: %s: invalid difficulty %q", name, d)
+			}
+		}
+		var items []string
+		for _, it := range c.Items {
+			if it = strings.TrimSpace(it); it != "" {
+				items = append(items, it)
+			}
+		}
+		if len(items) == 0 {
+			return ReviewChecklistConfig{}, Errorf(CodeInvalidArgument, "invalid review checklists: %s has no items", name)
+		}
+		cfg.Checklists[i].Name = name
+		cfg.Checklists[i].Items = items
+	}
+	return cfg, nil
+}
+
+// SetReviewChecklists sets the checklists reviewIssueTask enforces; an empty config turns them off.
+func (s *IssueService) SetReviewChecklists(cfg ReviewChecklistConfig) {
+	s.checklists = cfg.Checklists
+}
+
+// ReviewChecklistFor lists the checklist items a review of task must report on.
+func (s *IssueService) ReviewChecklistFor(task *IssueTask) []ChecklistItem {
+	var out []ChecklistItem
+	for _, c := range s.checklists {
+		if !c.applies(task) {
+			continue
+		}
+		for _, it := range c.Items {
+			out = append(out, ChecklistItem{Checklist: c.Name, Item: it})
+		}
+	}
+	return out
+}
+
+func (c ReviewChecklist) applies(task *IssueTask) bool {
+	if len(c.Labels) == 0 && len(c.Difficulties) == 0 {
+		return true
+	}
+	if slices.Contains(c.Difficulties, task.Difficulty) {
+		return true
+	}
+	for _, l := range c.Labels {
+		for _, tl := range task.Labels {
+			if strings.EqualFold(strings.TrimSpace(l), strings.TrimSpace(tl)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkReviewChecklist requires one pass/fail result per applicable checklist item and no results for
+// other items, and refuses an approval with a failed item.
+func (s *IssueService) checkReviewChecklist(task *IssueTask, verdict string, results []ChecklistResult) error {
+	required := s.ReviewChecklistFor(task)
+	key := func(checklist, item string) string {
+		return strings.ToLower(strings.TrimSpace(checklist)) + "\x00" + strings.ToLower(strings.TrimSpace(item))
+	}
+	want := map[string]bool{}
+	for _, it := range required {
+		want[key(it.Checklist, it.Item)] = true
+	}
+	got := map[string]string{}
+	for i, r := range results {
+		k := key(r.Checklist, r.Item)
+		if !want[k] {
+			return Errorf(CodeInvalidArgument, "artifacts.checklist[%d]: '%s / %s' is not on this task's review checklist", i, r.Checklist, r.Item).
+				With("checklist", required)
+		}
+		if r.Result != ChecklistPass && r.Result != ChecklistFail {
+			return Errorf(CodeInvalidArgument, "artifacts.checklist[%d].result must be pass or fail", i)
+		}
+		if _, dup := got[k]; dup {
+			return Errorf(CodeInvalidArgument, "artifacts.checklist[%d]: '%s / %s' reported twice", i, r.Checklist, r.Item)
+		}
+		got[k] = r.Result
+	}
+	var missing []ChecklistItem
+	failed := 0
+	for _, it := range required {
+		switch got[key(it.Checklist, it.Item)] {
+		case "":
+			missing = append(missing, it)
+		case ChecklistFail:
+			failed++
+		}
+	}
+	if len(missing) > 0 {
+		return Errorf(CodeInvalidArgument, "artifacts.checklist is missing %d of %d review checklist items", len(missing), len(required)).
+			With("missing_checklist_items", missing)
+	}
+	if verdict == VerdictApproved && failed > 0 {
+		return Errorf(CodeInvalidArgument, "cannot approve: %d review checklist item(s) failed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package swarm
+
+import (
+	"testing"
+)
+
+func TestParseReviewChecklists_Validates(t *testing.T) {
+	for _, bad := range []string{
+		`{"checklists":[{"items":["x"]}]}`,
+		`{"checklists":[{"name":"tests","items":[" "]}]}`,
+		`{"checklists":[{"name":"tests","difficulties":["hard"],"items":["x"]}]}`,
+		`{"checklists":[{"name":"tests","items":["x"]},{"name":"Tests","items":["y"]}]}`,
+	} {
+		if _, err := ParseReviewChecklists([]byte(bad)); ErrorCode(err) != CodeInvalidArgument {
+			t.Fatalf("expected %s to be rejected, got %v", bad, err)
+		}
+	}
+}
+
+func TestReviewChecklist_Enforced(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	cfg, err := ParseReviewChecklists([]byte(`{"checklists":[
+		{"name":"security","labels":["auth"],"items":["no secrets logged","input validated"]},
+		{"name":"tests","difficulties":["focus"],"items":["new code covered"]},
+		{"name":"docs","labels":["api"],"items":["README updated"]}
+	]}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	svc.SetReviewChecklists(cfg)
+
+	task := &IssueTask{ID: "task-1", Labels: []string{"Auth"}, Difficulty: "focus"}
+	if items := svc.ReviewChecklistFor(task); len(items) != 3 {
+		t.Fatalf("expected the security and tests checklists to apply, got %+v", items)
+	}
+	if items := svc.ReviewChecklistFor(&IssueTask{ID: "task-2", Difficulty: "easy"}); len(items) != 0 {
+		t.Fatalf("expected no checklist for an unlabeled easy task, got %+v", items)
+	}
+
+	pass := []ChecklistResult{
+		{Checklist: "security", Item: "no secrets logged", Result: ChecklistPass},
+		{Checklist: "security", Item: "input validated", Result: ChecklistPass},
+		{Checklist: "tests", Item: "new code covered", Result: ChecklistPass},
+	}
+	if err := svc.checkReviewChecklist(task, VerdictApproved, pass[:2]); ErrorCode(err) != CodeInvalidArgument {
+		t.Fatalf("expected a missing item to be rejected, got %v", err)
+	}
+	if err := svc.checkReviewChecklist(task, VerdictApproved, append(pass, ChecklistResult{Checklist: "docs", Item: "README updated", Result: ChecklistPass})); ErrorCode(err) != CodeInvalidArgument {
+		t.Fatalf("expected an item of another checklist to be rejected, got %v", err)
+	}
+	if err := svc.checkReviewChecklist(task, VerdictApproved, append(pass, pass[0])); ErrorCode(err) != CodeInvalidArgument {
+		t.Fatalf("expected a duplicate item to be rejected, got %v", err)
+	}
+
+	failed := append([]ChecklistResult(nil), pass...)
+	failed[1].Result = ChecklistFail
+	if err := svc.checkReviewChecklist(task, VerdictApproved, failed); ErrorCode(err) != CodeInvalidArgument {
+		t.Fatalf("expected an approval with a failed item to be rejected, got %v", err)
+	}
+	if err := svc.checkReviewChecklist(task, VerdictRejected, failed); err != nil {
+		t.Fatalf("expected a rejection with a failed item to pass, got %v", err)
+	}
+	if err := svc.checkReviewChecklist(task, VerdictApproved, pass); err != nil {
+		t.Fatalf("expected a complete passing checklist to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package swarm
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetReviewSLA sets how long a submission may wait for review before it is escalated; 0 disables it.
+func (s *IssueService) SetReviewSLA(sec int) {
+	if sec < 0 {
+		sec = 0
+	}
+	s.reviewSLASec = sec
+}
+
+// escalateOverdueReviewsLocked escalates the task's open submissions that have waited longer than the
+// review SLA: each gets a review_overdue item in the lead inbox and a submission_review_overdue event,
+// once. Reviewing the submission acks the escalation together with the original submission item.
+// Returns the unix ms at which the next open submission becomes overdue (0 = none). Call under store lock.
+func (s *IssueService) escalateOverdueReviewsLocked(issueID, taskID string, now time.Time) int64 {
+	if s.reviewSLASec <= 0 {
+		return 0
+	}
+	sla := time.Duration(s.reviewSLASec) * time.Second
+	var next int64
+	dir := s.store.Path("issues", issueID, "submissions", taskID)
+	for _, f := range listJSONOrEmpty(s.store, dir) {
+		var sub Submission
+		if err := s.store.ReadJSON(f, &sub); err != nil {
+			continue
+		}
+		if sub.Status != SubmissionOpen || sub.EscalatedAt != "" || awaitingPeerReview(&sub) {
+			continue
+		}
+		waited, ok := leadReviewWait(&sub, now)
+		if !ok {
+			continue
+		}
+		if waited <= sla {
+			if due := now.Add(sla - waited).UnixMilli(); next == 0 || due < next {
+				next = due
+			}
+			continue
+		}
+		sub.EscalatedAt = now.UTC().Format(time.RFC3339)
+		if err := s.store.WriteJSON(f, &sub); err != nil {
+			continue
+		}
+		_, _ = s.pushToLeadInboxLocked(issueID, taskID, InboxTypeReviewOverdue, sub.ID, "system")
+		_ = s.appendEventLocked(issueID, IssueEvent{
+			Type:         EventSubmissionReviewOverdue,
+			IssueID:      issueID,
+			TaskID:       taskID,
+			Actor:        "system",
+			Detail:       fmt.Sprintf("submission waiting for review %s (sla %ds)", waited.Truncate(time.Second), s.reviewSLASec),
+			SubmissionID: sub.ID,
+			Timestamp:    sub.EscalatedAt,
+		})
+	}
+	return next
+}
@@ -0,0 +1,62 @@
+package swarm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSweepExpired_EscalatesOverdueReviewsOnce(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+	svc.SetReviewSLA(600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 2}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	if err := svc.saveTaskLocked(issueID, &IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskInProgress, ClaimedBy: "w1"}); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	old := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	for _, sub := range []Submission{
+		{ID: "sub-old", Status: SubmissionOpen, CreatedAt: old},
+		{ID: "sub-new", Status: SubmissionOpen, CreatedAt: NowStr()},
+	} {
+		sub.IssueID, sub.TaskID = issueID, "task-1"
+		if err := store.WriteJSON(store.Path("issues", issueID, "submissions", "task-1", sub.ID+".json"), &sub); err != nil {
+			t.Fatalf("write submission: %v", err)
+		}
+	}
+
+	svc.SweepExpired()
+	svc.SweepExpired()
+
+	var items []InboxItem
+	for _, f := range listJSONOrEmpty(store, store.Path("issues", issueID, "inbox", "lead")) {
+		var item InboxItem
+		if err := store.ReadJSON(f, &item); err == nil {
+			items = append(items, item)
+		}
+	}
+	if len(items) != 1 || items[0].Type != InboxTypeReviewOverdue || items[0].RefID != "sub-old" {
+		t.Fatalf("expected one review_overdue item for sub-old, got %+v", items)
+	}
+	events, err := svc.ReadAllEvents(issueID)
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != EventSubmissionReviewOverdue || events[0].SubmissionID != "sub-old" {
+		t.Fatalf("expected one overdue event, got %+v", events)
+	}
+
+	m, err := svc.GetIssueMetrics(issueID)
+	if err != nil {
+		t.Fatalf("metrics: %v", err)
+	}
+	if m.ReviewSLA == nil || m.ReviewSLA.Overdue != 1 || m.ReviewSLA.Compliance != 0 {
+		t.Fatalf("expected one overdue review and 0 compliance, got %+v", m.ReviewSLA)
+	}
+}
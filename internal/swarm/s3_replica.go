@@ -0,0 +1,460 @@
+package swarm
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// S3ReplicaConfig configures the S3 mirror of the store. Endpoint defaults to AWS
+// (https://s3.<region>.amazonaws.com); any S3-compatible service works with path-style addressing.
+type S3ReplicaConfig struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	Prefix    string
+	AccessKey string
+	SecretKey string
+	FlushSec  int
+}
+
+// s3ReplicaState remembers what was uploaded (stored at replica/s3.json) so restarts do not re-upload the
+// whole store.
+type s3ReplicaState struct {
+	Objects map[string]s3ObjectState `json:"objects"`
+}
+
+type s3ObjectState struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mod_time_ns"`
+	SHA256  string `json:"sha256"`
+}
+
+// S3SyncResult counts the work done by one replica sync.
+type S3SyncResult struct {
+	Uploaded int  `json:"uploaded"`
+	Deleted  int  `json:"deleted"`
+	Skipped  bool `json:"skipped,omitempty"` // another process holds the replica lock
+}
+
+// S3Replica mirrors issue, task, submission and delivery JSON plus event logs to an S3 bucket,
+// write-behind: issue events only wake the sync loop, which uploads files that changed since the last
+// pass (and deletes objects whose file is gone) after a short debounce and every FlushSec seconds.
+// Files of every project namespace are included; object keys are <prefix>/<path under the root>.
+type S3Replica struct {
+	cfg    S3ReplicaConfig
+	store  *Store
+	logger *log.Logger
+	client *http.Client
+	kick   chan struct{}
+	leader *LeaderLock
+}
+
+// s3ReplicaDebounce batches the writes of one tool call into a single sync pass.
+const s3ReplicaDebounce = 2 * time.Second
+
+// NewS3Replica validates cfg and returns the replica without starting it. Returns nil when no bucket is
+// configured.
+func NewS3Replica(cfg S3ReplicaConfig, store *Store, logger *log.Logger) (*S3Replica, error) {
+	cfg.Bucket = strings.TrimSpace(cfg.Bucket)
+	if cfg.Bucket == "" {
+		return nil, nil
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, Errorf(CodeInvalidArgument, "s3 replica: access key and secret key are required")
+	}
+	if strings.TrimSpace(cfg.Region) == "" {
+		cfg.Region = "us-east-1"
+	}
+	if strings.TrimSpace(cfg.Endpoint) == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	cfg.Endpoint = strings.TrimRight(strings.TrimSpace(cfg.Endpoint), "/")
+	cfg.Prefix = strings.Trim(strings.TrimSpace(cfg.Prefix), "/")
+	if cfg.FlushSec <= 0 {
+		cfg.FlushSec = 60
+	}
+	return &S3Replica{
+		cfg:    cfg,
+		store:  store,
+		logger: logger,
+		client: &http.Client{Timeout: 60 * time.Second},
+		kick:   make(chan struct{}, 1),
+		leader: store.Leader("s3"),
+	}, nil
+}
+
+// Start runs the sync loop in the background. Only the process holding the store's s3 leader lock syncs;
+// writes made by the others are picked up by its FlushSec pass.
+func (r *S3Replica) Start() {
+	go r.loop()
+}
+
+// Notify wakes the sync loop without blocking (implements EventSink).
+func (r *S3Replica) Notify(event, issueID string, data any) {
+	select {
+	case r.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (r *S3Replica) loop() {
+	ticker := time.NewTicker(time.Duration(r.cfg.FlushSec) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.kick:
+			time.Sleep(s3ReplicaDebounce)
+			select {
+			case <-r.kick:
+			default:
+			}
+		case <-ticker.C:
+		}
+		if !r.leader.Held() {
+			continue
+		}
+		if _, err := r.Sync(); err != nil {
+			r.logf("WARNING: s3 replica: %v", err)
+		}
+	}
+}
+
+// s3ReplicatedPath reports whether a file (slash path under the root) is mirrored: JSON and JSONL under
+// issues/ and deliveries/, in the root store or a project namespace.
+func s3ReplicatedPath(rel string) bool {
+	if strings.HasPrefix(rel, "projects/") {
+		parts := strings.SplitN(rel, "/", 3)
+		if len(parts) < 3 {
+			return false
+		}
+		rel = parts[2]
+	}
+	if !strings.HasPrefix(rel, "issues/") && !strings.HasPrefix(rel, "deliveries/") {
+		return false
+	}
+	return strings.HasSuffix(rel, ".json") || strings.HasSuffix(rel, ".jsonl")
+}
+
+// Sync uploads mirrored files that changed since the last pass and deletes objects whose file is gone.
+// Only one process per root syncs at a time; the others report Skipped.
+func (r *S3Replica) Sync() (*S3SyncResult, error) {
+	dir := r.store.EnsureDir("replica")
+	lf, err := os.OpenFile(filepath.Join(dir, ".s3.lock"), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer lf.Close()
+	if err := syscall.Flock(int(lf.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		return &S3SyncResult{Skipped: true}, nil
+	}
+	defer syscall.Flock(int(lf.Fd()), syscall.LOCK_UN)
+
+	statePath := filepath.Join(dir, "s3.json")
+	state := s3ReplicaState{}
+	_ = r.store.ReadJSON(statePath, &state)
+	if state.Objects == nil {
+		state.Objects = map[string]s3ObjectState{}
+	}
+
+	res := &S3SyncResult{}
+	seen := map[string]bool{}
+	var firstErr error
+	walkErr := filepath.Walk(r.store.Root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(r.store.Root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !s3ReplicatedPath(rel) {
+			return nil
+		}
+		seen[rel] = true
+		prev, ok := state.Objects[rel]
+		if ok && prev.Size == info.Size() && prev.ModTime == info.ModTime().UnixNano() {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			if os.IsNotExist(err) {
+				delete(seen, rel)
+				return nil
+			}
+			return err
+		}
+		sum := sha256.Sum256(data)
+		cur := s3ObjectState{Size: info.Size(), ModTime: info.ModTime().UnixNano(), SHA256: hex.EncodeToString(sum[:])}
+		if ok && prev.SHA256 == cur.SHA256 {
+			state.Objects[rel] = cur
+			return nil
+		}
+		if err := r.putObject(rel, data); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return nil
+		}
+		state.Objects[rel] = cur
+		res.Uploaded++
+		return nil
+	})
+	if walkErr != nil && firstErr == nil {
+		firstErr = walkErr
+	}
+	if walkErr == nil {
+		for rel := range state.Objects {
+			if seen[rel] {
+				continue
+			}
+			if err := r.deleteObject(rel); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			delete(state.Objects, rel)
+			res.Deleted++
+		}
+	}
+	if err := r.store.WriteJSON(statePath, &state); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return res, firstErr
+}
+
+// Pull downloads every mirrored object into dir, recreating the store layout, e.g. to run a read replica
+// on another machine or recover after disk loss. dir must not hold data unless force is set; objects
+// then overwrite existing files. Returns the number of files written.
+func (r *S3Replica) Pull(dir string, force bool) (int, error) {
+	hasData, err := dirHasFiles(dir)
+	if err != nil {
+		return 0, err
+	}
+	if hasData && !force {
+		return 0, Errorf(CodeAlreadyExists, "%s already holds data; pull with force to overwrite", dir)
+	}
+	keys, err := r.listObjects()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, key := range keys {
+		rel := key
+		if r.cfg.Prefix != "" {
+			rel = strings.TrimPrefix(key, r.cfg.Prefix+"/")
+		}
+		if filepath.ToSlash(filepath.Clean(rel)) != rel || strings.HasPrefix(rel, "../") || !s3ReplicatedPath(rel) {
+			continue
+		}
+		data, err := r.getObject(rel)
+		if err != nil {
+			return n, err
+		}
+		dst := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return n, err
+		}
+		if err := os.WriteFile(dst+".tmp", data, 0o644); err != nil {
+			return n, err
+		}
+		if err := os.Rename(dst+".tmp", dst); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (r *S3Replica) objectKey(rel string) string {
+	if r.cfg.Prefix == "" {
+		return rel
+	}
+	return r.cfg.Prefix + "/" + rel
+}
+
+func (r *S3Replica) putObject(rel string, data []byte) error {
+	_, err := r.do(http.MethodPut, r.objectKey(rel), nil, data)
+	return err
+}
+
+func (r *S3Replica) getObject(rel string) ([]byte, error) {
+	return r.do(http.MethodGet, r.objectKey(rel), nil, nil)
+}
+
+func (r *S3Replica) deleteObject(rel string) error {
+	_, err := r.do(http.MethodDelete, r.objectKey(rel), nil, nil)
+	return err
+}
+
+// listObjects returns every key under the prefix (ListObjectsV2, following continuation tokens).
+func (r *S3Replica) listObjects() ([]string, error) {
+	var keys []string
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}}
+		if r.cfg.Prefix != "" {
+			q.Set("prefix", r.cfg.Prefix+"/")
+		}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		body, err := r.do(http.MethodGet, "", q, nil)
+		if err != nil {
+			return nil, err
+		}
+		var out struct {
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if err := xml.Unmarshal(body, &out); err != nil {
+			return nil, fmt.Errorf("list objects: %w", err)
+		}
+		for _, c := range out.Contents {
+			keys = append(keys, c.Key)
+		}
+		if !out.IsTruncated || out.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = out.NextContinuationToken
+	}
+}
+
+// do sends a path-style request for bucket/key signed with AWS Signature Version 4.
+func (r *S3Replica) do(method, key string, query url.Values, body []byte) ([]byte, error) {
+	u, err := url.Parse(r.cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + "/" + r.cfg.Bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	u.RawPath = s3EscapePath(u.Path)
+	u.RawQuery = s3CanonicalQuery(query)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		req.Body = http.NoBody
+		req.ContentLength = 0
+	}
+	r.sign(req, body, time.Now().UTC())
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 && !(method == http.MethodDelete && resp.StatusCode == http.StatusNotFound) {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s %s: status %d: %s", method, u.Path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (r *S3Replica) sign(req *http.Request, body []byte, now time.Time) {
+	payload := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(payload[:])
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signed := "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\nx-amz-content-sha256:" + payloadHash + "\nx-amz-date:" + amzDate + "\n",
+		signed,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + r.cfg.Region + "/s3/aws4_request"
+	crHash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(crHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+r.cfg.SecretKey), day)
+	key = hmacSHA256(key, r.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", r.cfg.AccessKey, scope, signed, sig))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3Escape percent-encodes everything but RFC 3986 unreserved characters, as SigV4 requires.
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func s3EscapePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = s3Escape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+func s3CanonicalQuery(q url.Values) string {
+	if len(q) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range q[k] {
+			parts = append(parts, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func (r *S3Replica) logf(format string, args ...any) {
+	if r.logger != nil {
+		r.logger.Printf(format, args...)
+	}
+}
@@ -0,0 +1,129 @@
+package swarm
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 is a path-style bucket in memory that checks every request is SigV4-signed.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AK/") || r.Header.Get("x-amz-content-sha256") == "" {
+		http.Error(w, "unsigned", http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key, ok := strings.CutPrefix(r.URL.Path, "/bucket/")
+	switch {
+	case r.Method == http.MethodGet && !ok:
+		var out struct {
+			XMLName  xml.Name `xml:"ListBucketResult"`
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+		}
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			out.Contents = append(out.Contents, struct {
+				Key string `xml:"Key"`
+			}{k})
+		}
+		_ = xml.NewEncoder(w).Encode(out)
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = data
+	case r.Method == http.MethodGet:
+		data, found := f.objects[key]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3Replica_SyncAndPull(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	store := NewStore(t.TempDir())
+	cfg := S3ReplicaConfig{Endpoint: srv.URL, Bucket: "bucket", Prefix: "team-a", AccessKey: "AK", SecretKey: "SK"}
+	replica, err := NewS3Replica(cfg, store, nil)
+	if err != nil || replica == nil {
+		t.Fatalf("new replica: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", "issue-1", "issue.json"), &Issue{ID: "issue-1", Subject: "Fix login"}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Project("web").Path("deliveries", "dlv-1.json"), &Delivery{ID: "dlv-1"}); err != nil {
+		t.Fatalf("write delivery: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("workers", "w1.json"), map[string]string{"id": "w1"}); err != nil {
+		t.Fatalf("write worker: %v", err)
+	}
+
+	res, err := replica.Sync()
+	if err != nil || res.Uploaded != 2 {
+		t.Fatalf("first sync: %+v %v", res, err)
+	}
+	for _, key := range []string{"team-a/issues/issue-1/issue.json", "team-a/projects/web/deliveries/dlv-1.json"} {
+		if _, ok := fake.objects[key]; !ok {
+			t.Fatalf("expected %s in the bucket, have %d objects", key, len(fake.objects))
+		}
+	}
+	if res, err := replica.Sync(); err != nil || res.Uploaded != 0 || res.Deleted != 0 {
+		t.Fatalf("expected an idle second sync, got %+v %v", res, err)
+	}
+
+	if err := os.Remove(store.Project("web").Path("deliveries", "dlv-1.json")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if res, err := replica.Sync(); err != nil || res.Deleted != 1 {
+		t.Fatalf("expected the removed delivery deleted, got %+v %v", res, err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "replica")
+	n, err := replica.Pull(dir, false)
+	if err != nil || n != 1 {
+		t.Fatalf("pull: %d %v", n, err)
+	}
+	var is Issue
+	if err := store.ReadJSON(filepath.Join(dir, "issues", "issue-1", "issue.json"), &is); err != nil || is.Subject != "Fix login" {
+		t.Fatalf("expected the issue in the pulled copy, got %+v %v", is, err)
+	}
+	if _, err := replica.Pull(dir, false); err == nil {
+		t.Fatalf("expected pull into a populated directory to fail without force")
+	}
+}
+
+func TestNewS3Replica_Disabled(t *testing.T) {
+	if r, err := NewS3Replica(S3ReplicaConfig{}, NewStore(t.TempDir()), nil); r != nil || err != nil {
+		t.Fatalf("expected no replica without a bucket, got %v %v", r, err)
+	}
+	if _, err := NewS3Replica(S3ReplicaConfig{Bucket: "b"}, NewStore(t.TempDir()), nil); err == nil {
+		t.Fatalf("expected missing credentials to fail")
+	}
+}
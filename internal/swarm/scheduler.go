@@ -0,0 +1,341 @@
+package swarm
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TaskAssignment is one task the scheduler reserved for a worker. The worker redeems it by calling
+// claimIssueTask with the next_step_token before ReservedUntilMs.
+type TaskAssignment struct {
+	IssueID         string `json:"issue_id"`
+	TaskID          string `json:"task_id"`
+	WorkerID        string `json:"worker_id"`
+	NextStepToken   string `json:"next_step_token"`
+	ReservedUntilMs int64  `json:"reserved_until_ms"`
+}
+
+// schedulerConfig is set by SetScheduler; a nil workers source means the scheduler is off.
+type schedulerConfig struct {
+	workers    func() ([]Worker, error)
+	maxClaimed func(workerID string) int
+	reserveSec int
+	staleSec   int
+}
+
+// SetScheduler turns on automatic task assignment. workers lists the registered workers; maxClaimed
+// gives a worker's claim limit (0 = one task at a time for scheduling purposes); reserveSec is how long
+// an assigned task stays reserved for the worker; workers not seen for staleSec (0 = 600) get no new
+// assignments. Passing a nil workers source turns it off.
+func (s *IssueService) SetScheduler(workers func() ([]Worker, error), maxClaimed func(workerID string) int, reserveSec, staleSec int) {
+	if workers == nil {
+		s.scheduler = nil
+		return
+	}
+	if reserveSec <= 0 {
+		reserveSec = 600
+	}
+	if staleSec <= 0 {
+		staleSec = 600
+	}
+	s.scheduler = &schedulerConfig{workers: workers, maxClaimed: maxClaimed, reserveSec: reserveSec, staleSec: staleSec}
+}
+
+// SchedulerEnabled reports whether SetScheduler configured a workers source.
+func (s *IssueService) SchedulerEnabled() bool {
+	return s.scheduler != nil
+}
+
+// AssignTasks matches open, unreserved tasks of open (unpaused) issues to workers with spare capacity and
+// reserves them: each assignment mints a next_step_token bound to the worker, pushes an "assigned" item
+// to the worker's inbox and logs an issue_task_assigned event. Workers with the lowest load go first;
+// a worker whose skills overlap a task's labels prefers those tasks, and within the candidates the
+// difficulty and pick follow the progression policy on the worker's points in that issue. Workers not
+// seen within the stale window (see WorkerSeenAt) are skipped, so tasks are not reserved for dead agents.
+// It is a no-op when the scheduler is off.
+func (s *IssueService) AssignTasks() ([]TaskAssignment, error) {
+	sched := s.scheduler
+	if sched == nil {
+		return nil, nil
+	}
+	all, err := sched.workers()
+	if err != nil {
+		return nil, err
+	}
+	var workers []Worker
+	for _, w := range all {
+		if seen, ok := WorkerSeenAt(w); !ok || time.Since(seen) <= time.Duration(sched.staleSec)*time.Second {
+			workers = append(workers, w)
+		}
+	}
+	if len(workers) == 0 {
+		return nil, nil
+	}
+	s.SweepExpired()
+
+	var out []TaskAssignment
+	touched := map[string]bool{}
+	err = s.store.WithLock(func() error {
+		nowMs := LeaseNowMs()
+		issues := s.schedulableIssuesLocked()
+		open := map[string][]*IssueTask{}
+		load := map[string]int{}
+		for _, issue := range issues {
+			var tasks []IssueTask
+			tokenOwner := map[string]string{} // next_step_token attached by a review -> reviewed worker
+			for _, f := range listJSONOrEmpty(s.store, s.store.Path("issues", issue.ID, "tasks")) {
+				var t IssueTask
+				if err := s.store.ReadJSON(f, &t); err != nil {
+					continue
+				}
+				tasks = append(tasks, t)
+				if t.NextStepToken != "" {
+					tokenOwner[t.NextStepToken] = t.ClaimedBy
+				}
+			}
+			for _, t := range tasks {
+				switch {
+				case t.Status == IssueTaskInProgress || t.Status == IssueTaskBlocked:
+					load[t.ClaimedBy]++
+				case t.Status != IssueTaskOpen:
+				case t.ReservedToken != "" && (t.ReservedUntilMs == 0 || nowMs <= t.ReservedUntilMs):
+					// Reserved for someone: by the scheduler (assignee) or by a lead's next step.
+					var tok NextStepToken
+					if err := s.store.ReadJSON(s.store.Path("issues", issue.ID, "next_steps", t.ReservedToken+".json"), &tok); err == nil && tok.Assignee != "" {
+						load[tok.Assignee]++
+					} else if w := tokenOwner[t.ReservedToken]; w != "" {
+						load[w]++
+					}
+				case s.readyToClaimLocked(issue.ID, &t):
+					t := t
+					open[issue.ID] = append(open[issue.ID], &t)
+				}
+			}
+		}
+
+		sort.SliceStable(workers, func(i, j int) bool {
+			if load[workers[i].ID] != load[workers[j].ID] {
+				return load[workers[i].ID] < load[workers[j].ID]
+			}
+			return workers[i].ID < workers[j].ID
+		})
+		for _, w := range workers {
+			limit := 1
+			if sched.maxClaimed != nil {
+				if n := sched.maxClaimed(w.ID); n > 0 {
+					limit = n
+				}
+			}
+			for load[w.ID] < limit {
+				issueID, task := s.pickForWorkerLocked(w, issues, open)
+				if task == nil {
+					break
+				}
+				a, err := s.assignLocked(issueID, task, w.ID, nowMs+int64(sched.reserveSec)*1000)
+				if err != nil {
+					return err
+				}
+				out = append(out, *a)
+				touched[issueID] = true
+				load[w.ID]++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for issueID := range touched {
+		s.bump(issueID)
+	}
+	return out, nil
+}
+
+// schedulableIssuesLocked returns open/in_progress, unpaused issues, oldest first.
+func (s *IssueService) schedulableIssuesLocked() []Issue {
+	var out []Issue
+	entries, _ := os.ReadDir(s.store.Path("issues"))
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		var issue Issue
+		if err := s.store.ReadJSON(s.store.Path("issues", e.Name(), "issue.json"), &issue); err != nil {
+			continue
+		}
+		if (issue.Status != IssueOpen && issue.Status != IssueInProgress) || issue.PausedAt != "" {
+			continue
+		}
+		out = append(out, issue)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt < out[j].CreatedAt })
+	return out
+}
+
+// pickForWorkerLocked chooses the next task for w and removes it from open.
+func (s *IssueService) pickForWorkerLocked(w Worker, issues []Issue, open map[string][]*IssueTask) (string, *IssueTask) {
+	for _, issue := range issues {
+		tasks := open[issue.ID]
+		if len(tasks) == 0 {
+			continue
+		}
+		var skilled, unlabeled []*IssueTask
+		for _, t := range tasks {
+			switch {
+			case len(t.Labels) == 0 || len(w.Skills) == 0:
+				unlabeled = append(unlabeled, t)
+			case overlaps(w.Skills, t.Labels):
+				skilled = append(skilled, t)
+			}
+		}
+		candidates := skilled
+		if len(candidates) == 0 {
+			candidates = unlabeled
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		st, _ := s.loadIssueWorkerStateLocked(issue.ID, w.ID)
+		var chosen *IssueTask
+		for _, d := range difficultyFallbackOrder(s.policy.baseDifficulty(st.TotalPoints)) {
+			var tier []*IssueTask
+			for _, t := range candidates {
+				if t.Difficulty == d {
+					tier = append(tier, t)
+				}
+			}
+			if chosen = pickTaskByTier(tier, st.TotalPoints, s.policy); chosen != nil {
+				break
+			}
+		}
+		if chosen == nil {
+			// No task at or below the worker's level: any candidate beats leaving the worker idle.
+			chosen = pickTaskByTier(candidates, st.TotalPoints, s.policy)
+		}
+		for i, t := range tasks {
+			if t == chosen {
+				open[issue.ID] = append(tasks[:i:i], tasks[i+1:]...)
+				break
+			}
+		}
+		return issue.ID, chosen
+	}
+	return "", nil
+}
+
+func (s *IssueService) assignLocked(issueID string, task *IssueTask, workerID string, reservedUntilMs int64) (*TaskAssignment, error) {
+	live, err := s.reserveForWorkerLocked(issueID, task.ID, workerID, "scheduler", "assigned to "+workerID, reservedUntilMs)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.pushToWorkerInboxLocked(issueID, workerID, live.ID, InboxTypeAssigned, live.ReservedToken, "scheduler"); err != nil {
+		return nil, err
+	}
+	return &TaskAssignment{IssueID: issueID, TaskID: live.ID, WorkerID: workerID, NextStepToken: live.ReservedToken, ReservedUntilMs: reservedUntilMs}, nil
+}
+
+// reserveForWorkerLocked mints an attached claim_task token bound to workerID, reserves the task with
+// it until reservedUntilMs and logs an issue_task_assigned event. Returns the saved task. Call under
+// store lock.
+func (s *IssueService) reserveForWorkerLocked(issueID, taskID, workerID, actor, detail string, reservedUntilMs int64) (*IssueTask, error) {
+	live, err := s.loadTaskLocked(issueID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	now := NowStr()
+	tok := NextStepToken{
+		Token:      GenID("ns"),
+		IssueID:    issueID,
+		Actor:      actor,
+		Assignee:   workerID,
+		NextStep:   NextStep{Type: "claim_task", TaskID: live.ID},
+		Attached:   true,
+		AttachedAt: now,
+		CreatedAt:  now,
+	}
+	if err := s.store.WriteJSON(s.store.Path("issues", issueID, "next_steps", tok.Token+".json"), tok); err != nil {
+		return nil, err
+	}
+	live.ReservedToken = tok.Token
+	live.ReservedUntilMs = reservedUntilMs
+	live.UpdatedAt = now
+	if err := s.saveTaskLocked(issueID, live); err != nil {
+		return nil, err
+	}
+	if err := s.appendEventLocked(issueID, IssueEvent{
+		Type:          EventIssueTaskAssigned,
+		IssueID:       issueID,
+		TaskID:        live.ID,
+		Actor:         actor,
+		Detail:        detail,
+		NextStepToken: tok.Token,
+		Timestamp:     now,
+	}); err != nil {
+		return nil, err
+	}
+	return live, nil
+}
+
+// WaitAssignment blocks until the scheduler has assigned workerID a task, acks the inbox item and
+// returns the assignment; (nil, nil) on timeout. Assignments whose reservation lapsed or whose task was
+// taken meanwhile are acked and skipped.
+func (s *IssueService) WaitAssignment(ctx context.Context, workerID string, timeoutSec int) (*TaskAssignment, error) {
+	workerID = strings.TrimSpace(workerID)
+	if workerID == "" {
+		return nil, Errorf(CodeInvalidArgument, "worker_id is required")
+	}
+	deadline := s.deadline(s.normalizeTimeoutSec(ctx, timeoutSec))
+	for {
+		since := s.changeGen()
+		var found *TaskAssignment
+		err := s.store.WithLock(func() error {
+			nowMs := LeaseNowMs()
+			entries, _ := os.ReadDir(s.store.Path("issues"))
+			for _, e := range entries {
+				dir := s.store.Path("issues", e.Name(), "inbox", "workers", workerID)
+				for _, f := range listJSONOrEmpty(s.store, dir) {
+					var item InboxItem
+					if err := s.store.ReadJSON(f, &item); err != nil || item.Type != InboxTypeAssigned || item.Status == InboxDone {
+						continue
+					}
+					item.Status = InboxDone
+					item.UpdatedAt = NowStr()
+					if err := s.store.WriteJSON(f, &item); err != nil {
+						return err
+					}
+					t, err := s.loadTaskLocked(item.IssueID, item.TaskID)
+					if err != nil || t.Status != IssueTaskOpen || t.ReservedToken != item.RefID || (t.ReservedUntilMs > 0 && nowMs > t.ReservedUntilMs) {
+						continue
+					}
+					found = &TaskAssignment{IssueID: item.IssueID, TaskID: t.ID, WorkerID: workerID, NextStepToken: item.RefID, ReservedUntilMs: t.ReservedUntilMs}
+					return nil
+				}
+			}
+			return nil
+		})
+		if err != nil || found != nil {
+			return found, err
+		}
+		if timeExpired(deadline) {
+			return nil, nil
+		}
+		if err := s.waitChange(ctx, since, pollInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func overlaps(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if strings.EqualFold(strings.TrimSpace(x), strings.TrimSpace(y)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package swarm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAssignTasks_MatchesSkillsAndReservesForAssignee(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 1, 1)
+	workers := []Worker{{ID: "w-go", Skills: []string{"go"}}, {ID: "w-any"}}
+	svc.SetScheduler(func() ([]Worker, error) { return workers, nil }, func(string) int { return 1 }, 600, 0)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen, CreatedAt: NowStr()}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 4}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	for _, task := range []IssueTask{
+		{ID: "task-ui", Labels: []string{"ui"}},
+		{ID: "task-go", Labels: []string{"Go"}},
+		{ID: "task-plain"},
+	} {
+		task.IssueID, task.Status, task.Difficulty, task.Points = issueID, IssueTaskOpen, "easy", 1
+		if err := svc.saveTaskLocked(issueID, &task); err != nil {
+			t.Fatalf("write task: %v", err)
+		}
+	}
+
+	got, err := svc.AssignTasks()
+	if err != nil {
+		t.Fatalf("assign: %v", err)
+	}
+	byWorker := map[string]TaskAssignment{}
+	for _, a := range got {
+		byWorker[a.WorkerID] = a
+	}
+	if len(got) != 2 || byWorker["w-go"].TaskID != "task-go" || byWorker["w-any"].TaskID == "" {
+		t.Fatalf("expected task-go for w-go and one task for w-any, got %+v", got)
+	}
+
+	// Each worker is at its limit: a second round assigns nothing.
+	if again, _ := svc.AssignTasks(); len(again) != 0 {
+		t.Fatalf("expected no assignments while workers are busy, got %+v", again)
+	}
+
+	a := byWorker["w-go"]
+	if _, err := svc.ClaimTask(issueID, a.TaskID, "w-any", a.NextStepToken, 0); err == nil || !strings.Contains(err.Error(), "reserved for worker 'w-go'") {
+		t.Fatalf("expected claim by another worker to be refused, got %v", err)
+	}
+	w, err := svc.WaitAssignment(context.Background(), "w-go", 1)
+	if err != nil || w == nil || w.NextStepToken != a.NextStepToken {
+		t.Fatalf("expected w-go's assignment from the inbox, got %+v (%v)", w, err)
+	}
+	if _, err := svc.ClaimTask(issueID, a.TaskID, "w-go", a.NextStepToken, 0); err != nil {
+		t.Fatalf("claim by assignee: %v", err)
+	}
+}
+
+func TestAssignTasks_SkipsWorkersNotSeenRecently(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 1, 1)
+	stale := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	workers := []Worker{
+		{ID: "w-dead", JoinedAt: stale, UpdatedAt: stale, LastSeenAt: stale},
+		{ID: "w-live", JoinedAt: stale, UpdatedAt: stale, LastSeenAt: NowStr()},
+	}
+	svc.SetScheduler(func() ([]Worker, error) { return workers, nil }, func(string) int { return 1 }, 600, 300)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen, CreatedAt: NowStr()}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 3}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	for _, id := range []string{"task-1", "task-2"} {
+		task := IssueTask{ID: id, IssueID: issueID, Status: IssueTaskOpen, Difficulty: "easy", Points: 1}
+		if err := svc.saveTaskLocked(issueID, &task); err != nil {
+			t.Fatalf("write task: %v", err)
+		}
+	}
+
+	got, err := svc.AssignTasks()
+	if err != nil {
+		t.Fatalf("assign: %v", err)
+	}
+	if len(got) != 1 || got[0].WorkerID != "w-live" {
+		t.Fatalf("expected one assignment for w-live only, got %+v", got)
+	}
+}
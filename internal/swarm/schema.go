@@ -0,0 +1,104 @@
+package swarm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Current on-disk schema versions. Bump the constant and append a migration whenever the layout of
+// issue.json or a task file changes in a way older data would be misread.
+const (
+	IssueSchemaVersion = 1
+	TaskSchemaVersion  = 1
+)
+
+// schemaMigration upgrades a decoded JSON object by one version, in place.
+type schemaMigration func(doc map[string]any) error
+
+// issueMigrations[v] upgrades an issue from version v to v+1.
+var issueMigrations = []schemaMigration{
+	// 0 -> 1: unversioned issues already match the v1 layout.
+	func(doc map[string]any) error { return nil },
+}
+
+// taskMigrations[v] upgrades a task from version v to v+1.
+var taskMigrations = []schemaMigration{
+	// 0 -> 1: before submissions became their own entity, submitting moved the task to "submitted".
+	// The pending submission now carries that state and the task stays in progress.
+	func(doc map[string]any) error {
+		if doc["status"] == "submitted" {
+			doc["status"] = IssueTaskInProgress
+		}
+		return nil
+	},
+}
+
+// SchemaVersionError is returned when a file was written by a newer binary than this one.
+type SchemaVersionError struct {
+	Kind      string // "issue" or "task"
+	ID        string
+	Version   int
+	Supported int
+}
+
+func (e *SchemaVersionError) Error() string {
+	return fmt.Sprintf("%s '%s' has schema_version %d but this swarm-mcp only understands up to %d; upgrade the binary", e.Kind, e.ID, e.Version, e.Supported)
+}
+
+// migrateSchema runs migrations over data, starting at its recorded schema_version, and returns the
+// upgraded JSON stamped with current.
+func migrateSchema(kind string, data []byte, migrations []schemaMigration, current int) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	version := 0
+	if v, ok := doc["schema_version"].(float64); ok {
+		version = int(v)
+	}
+	if version > current {
+		id, _ := doc["id"].(string)
+		return nil, &SchemaVersionError{Kind: kind, ID: id, Version: version, Supported: current}
+	}
+	for v := version; v < current; v++ {
+		if err := migrations[v](doc); err != nil {
+			return nil, fmt.Errorf("migrate %s from schema_version %d: %w", kind, v, err)
+		}
+	}
+	doc["schema_version"] = current
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON decodes an issue, migrating files written under an older schema.
+func (i *Issue) UnmarshalJSON(data []byte) error {
+	type plain Issue
+	if err := json.Unmarshal(data, (*plain)(i)); err != nil {
+		return err
+	}
+	if i.SchemaVersion == IssueSchemaVersion {
+		return nil
+	}
+	migrated, err := migrateSchema("issue", data, issueMigrations, IssueSchemaVersion)
+	if err != nil {
+		return err
+	}
+	*i = Issue{}
+	return json.Unmarshal(migrated, (*plain)(i))
+}
+
+// UnmarshalJSON decodes a task, migrating files written under an older schema.
+func (t *IssueTask) UnmarshalJSON(data []byte) error {
+	type plain IssueTask
+	if err := json.Unmarshal(data, (*plain)(t)); err != nil {
+		return err
+	}
+	if t.SchemaVersion == TaskSchemaVersion {
+		return nil
+	}
+	migrated, err := migrateSchema("task", data, taskMigrations, TaskSchemaVersion)
+	if err != nil {
+		return err
+	}
+	*t = IssueTask{}
+	return json.Unmarshal(migrated, (*plain)(t))
+}
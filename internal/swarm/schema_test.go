@@ -0,0 +1,59 @@
+package swarm
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestSchema_MigratesLegacyTaskOnRead(t *testing.T) {
+	store := NewStore(t.TempDir())
+	legacy := `{"id":"t1","issue_id":"i1","status":"submitted","claimed_by":"w1","rev":4}`
+	path := store.Path("issues", "i1", "tasks", "t1.json")
+	store.EnsureDir("issues", "i1", "tasks")
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatalf("write legacy task: %v", err)
+	}
+
+	var task IssueTask
+	if err := store.ReadJSON(path, &task); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if task.Status != IssueTaskInProgress || task.SchemaVersion != TaskSchemaVersion {
+		t.Fatalf("expected migrated in_progress task at v%d, got %+v", TaskSchemaVersion, task)
+	}
+	if task.ClaimedBy != "w1" || task.Rev != 4 {
+		t.Fatalf("migration lost fields: %+v", task)
+	}
+}
+
+func TestSchema_RejectsNewerVersion(t *testing.T) {
+	store := NewStore(t.TempDir())
+	path := store.Path("issues", "i1", "issue.json")
+	store.EnsureDir("issues", "i1")
+	if err := os.WriteFile(path, []byte(`{"schema_version":99,"id":"i1","status":"open"}`), 0644); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+
+	var issue Issue
+	err := store.ReadJSON(path, &issue)
+	var verr *SchemaVersionError
+	if !errors.As(err, &verr) || verr.Version != 99 || verr.Supported != IssueSchemaVersion {
+		t.Fatalf("expected SchemaVersionError, got %v", err)
+	}
+}
+
+func TestSchema_SaveStampsCurrentVersion(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, nil, 0, 0, 0, 0)
+	if err := svc.saveIssueLocked(&Issue{ID: "i1", Status: IssueOpen}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	var raw map[string]any
+	if err := store.ReadJSON(store.Path("issues", "i1", "issue.json"), &raw); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if raw["schema_version"] != float64(IssueSchemaVersion) {
+		t.Fatalf("expected schema_version %d on disk, got %v", IssueSchemaVersion, raw["schema_version"])
+	}
+}
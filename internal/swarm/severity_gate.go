@@ -0,0 +1,50 @@
+package swarm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity gate. A feedback detail may carry a status: open (the default), resolved (fixed in the
+// reviewed submission) or waived (accepted as is, with a justification). With the gate on,
+// reviewIssueTask refuses to approve while any critical finding is still open, so a critical finding
+// cannot slip through an approval unnoticed.
+
+const (
+	SeverityCritical = "critical"
+
+	FindingOpen     = "open"
+	FindingResolved = "resolved"
+	FindingWaived   = "waived"
+)
+
+// SetCriticalGate turns the severity gate on: approvals need every critical finding resolved or waived.
+func (s *IssueService) SetCriticalGate(enabled bool) {
+	s.criticalGate = enabled
+}
+
+// checkSeverityGate validates finding statuses (a waiver always needs a justification) and, with the
+// gate on, refuses an approval while a critical finding is open.
+func (s *IssueService) checkSeverityGate(verdict string, details []FeedbackDetail) error {
+	var open []string
+	for i, fd := range details {
+		status := strings.TrimSpace(fd.Status)
+		switch status {
+		case "", FindingOpen, FindingResolved:
+		case FindingWaived:
+			if strings.TrimSpace(fd.Justification) == "" {
+				return Errorf(CodeInvalidArgument, "feedback_details[%d].justification is required to waive a finding", i)
+			}
+		default:
+			return Errorf(CodeInvalidArgument, "feedback_details[%d].status must be open, resolved or waived", i)
+		}
+		if strings.TrimSpace(fd.Severity) == SeverityCritical && (status == "" || status == FindingOpen) {
+			open = append(open, fmt.Sprintf("feedback_details[%d]", i))
+		}
+	}
+	if s.criticalGate && verdict == VerdictApproved && len(open) > 0 {
+		return Errorf(CodeInvalidArgument, "cannot approve with %d open critical finding(s); resolve or waive them (with a justification), or reject", len(open)).
+			With("open_critical_findings", open)
+	}
+	return nil
+}
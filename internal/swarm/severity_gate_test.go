@@ -0,0 +1,45 @@
+package swarm
+
+import (
+	"testing"
+)
+
+func TestReviewTask_CriticalGate(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+	art := ReviewArtifacts{ReviewSummary: "ok", ReviewedRefs: []string{"main.go"}}
+	review := func(verdict string, fds ...FeedbackDetail) error {
+		_, err := svc.ReviewTask("lead", "issue-1", "task-1", "", verdict, "fb", 5, art, fds, "tok_missing", 0)
+		return err
+	}
+	critical := FeedbackDetail{Dimension: "security", Severity: SeverityCritical, Content: "token logged"}
+
+	// The gate is off by default; the review then fails later on the unknown token.
+	if err := review(VerdictApproved, critical); err == nil || err.Error() != "invalid next_step_token" {
+		t.Fatalf("expected the gate to be off, got %v", err)
+	}
+
+	svc.SetCriticalGate(true)
+	if err := review(VerdictApproved, critical); ErrorCode(err) != CodeInvalidArgument {
+		t.Fatalf("expected an open critical finding to block approval, got %v", err)
+	}
+	if err := review(VerdictRejected, critical); err == nil || err.Error() != "invalid next_step_token" {
+		t.Fatalf("expected a rejection to pass the gate, got %v", err)
+	}
+	waived := critical
+	waived.Status = FindingWaived
+	if err := review(VerdictApproved, waived); ErrorCode(err) != CodeInvalidArgument {
+		t.Fatalf("expected a waiver without justification to be rejected, got %v", err)
+	}
+	waived.Justification = "test fixture token only"
+	resolved := critical
+	resolved.Status = FindingResolved
+	if err := review(VerdictApproved, waived, resolved, FeedbackDetail{Dimension: "style", Severity: "minor", Content: "naming"}); err == nil || err.Error() != "invalid next_step_token" {
+		t.Fatalf("expected settled critical findings to pass the gate, got %v", err)
+	}
+	bogus := critical
+	bogus.Status = "ignored"
+	if err := review(VerdictRejected, bogus); ErrorCode(err) != CodeInvalidArgument {
+		t.Fatalf("expected an unknown status to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package swarm
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// InboxBacklog counts inbox items not yet done (pending or claimed but unprocessed).
+type InboxBacklog struct {
+	Lead     int `json:"lead"`
+	Workers  int `json:"workers"`
+	Acceptor int `json:"acceptor"`
+}
+
+// SwarmStats is a point-in-time summary of the whole swarm, for situational awareness in one call.
+type SwarmStats struct {
+	Issues                  map[string]int `json:"issues"`     // by status
+	Tasks                   map[string]int `json:"tasks"`      // by status
+	Deliveries              map[string]int `json:"deliveries"` // by status
+	Workers                 int            `json:"workers"`
+	ActiveLeases            int            `json:"active_leases"`
+	LockedFiles             int            `json:"locked_files"`
+	Inboxes                 InboxBacklog   `json:"inboxes"`
+	PendingSubmissions      int            `json:"pending_submissions"`
+	OldestPendingSubmission string         `json:"oldest_pending_submission,omitempty"` // submission id
+	OldestPendingAgeSec     int64          `json:"oldest_pending_age_sec,omitempty"`
+	GeneratedAt             string         `json:"generated_at"`
+}
+
+// GetSwarmStats counts issues, tasks and deliveries by status, registered workers, active leases and
+// inbox backlogs, and finds the oldest submission still waiting for review. Inboxes and submissions are
+// only scanned for issues that are not closed.
+func (s *IssueService) GetSwarmStats(workers *WorkerService, locks *LockService) (*SwarmStats, error) {
+	now := time.Now().UTC()
+	st := &SwarmStats{
+		Issues:      map[string]int{},
+		Tasks:       map[string]int{},
+		Deliveries:  map[string]int{},
+		GeneratedAt: now.Format(time.RFC3339),
+	}
+
+	issues, err := s.ListIssues()
+	if err != nil {
+		return nil, err
+	}
+	for _, is := range issues {
+		st.Issues[is.Status]++
+		tasks, err := s.ListTasks(is.ID, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tasks {
+			st.Tasks[t.Status]++
+		}
+		if is.Status == IssueDone || is.Status == IssueCanceled {
+			continue
+		}
+
+		st.Inboxes.Lead += s.inboxBacklog(s.store.Path("issues", is.ID, "inbox", "lead"))
+		workerDirs, _ := os.ReadDir(s.store.Path("issues", is.ID, "inbox", "workers"))
+		for _, d := range workerDirs {
+			if d.IsDir() {
+				st.Inboxes.Workers += s.inboxBacklog(filepath.Join(s.store.Path("issues", is.ID, "inbox", "workers"), d.Name()))
+			}
+		}
+
+		for _, t := range tasks {
+			subs, err := s.ListSubmissions(is.ID, t.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, sub := range subs {
+				if sub.Status != SubmissionOpen {
+					continue
+				}
+				st.PendingSubmissions++
+				if age := secondsBetween(sub.CreatedAt, st.GeneratedAt); age >= 0 && (st.OldestPendingSubmission == "" || age > st.OldestPendingAgeSec) {
+					st.OldestPendingSubmission = sub.ID
+					st.OldestPendingAgeSec = age
+				}
+			}
+		}
+	}
+	st.Inboxes.Acceptor = s.inboxBacklog(s.store.Path("deliveries", "inbox", "acceptor"))
+
+	deliveries, err := s.ListDeliveries("", "", "", "")
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deliveries {
+		st.Deliveries[d.Status]++
+	}
+
+	if workers != nil {
+		list, err := workers.List()
+		if err != nil {
+			return nil, err
+		}
+		st.Workers = len(list)
+	}
+	if locks != nil {
+		leases, err := locks.ListLocks("", "", nil)
+		if err != nil {
+			return nil, err
+		}
+		st.ActiveLeases = len(leases)
+		for _, l := range leases {
+			st.LockedFiles += len(l.Files)
+		}
+	}
+	return st, nil
+}
+
+func (s *IssueService) inboxBacklog(dir string) int {
+	n := 0
+	for _, f := range listJSONOrEmpty(s.store, dir) {
+		var item InboxItem
+		if err := s.store.ReadJSON(f, &item); err == nil && item.Status != InboxDone {
+			n++
+		}
+	}
+	return n
+}
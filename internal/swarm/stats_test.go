@@ -0,0 +1,74 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetSwarmStats(t *testing.T) {
+	store := NewStore(t.TempDir())
+	trace := NewTraceService(store)
+	svc := NewIssueService(store, trace, 7200, 3600, 3600, 3600)
+	workers := NewWorkerService(store, trace)
+	locks := NewLockService(store, trace)
+
+	for _, is := range []Issue{{ID: "issue-1", Status: IssueInProgress}, {ID: "issue-2", Status: IssueDone}} {
+		if err := store.WriteJSON(store.Path("issues", is.ID, "issue.json"), &is); err != nil {
+			t.Fatalf("write issue: %v", err)
+		}
+	}
+	for _, task := range []IssueTask{
+		{ID: "task-1", IssueID: "issue-1", Status: IssueTaskInProgress, ClaimedBy: "w1"},
+		{ID: "task-2", IssueID: "issue-1", Status: IssueTaskOpen},
+		{ID: "task-1", IssueID: "issue-2", Status: IssueTaskDone},
+	} {
+		if err := svc.saveTaskLocked(task.IssueID, &task); err != nil {
+			t.Fatalf("write task: %v", err)
+		}
+	}
+	for _, sub := range []Submission{
+		{ID: "sub-1", Status: SubmissionOpen, CreatedAt: "2024-01-01T10:00:00Z"},
+		{ID: "sub-2", Status: SubmissionOpen, CreatedAt: "2024-01-01T11:00:00Z"},
+		{ID: "sub-3", Status: SubmissionRejected, CreatedAt: "2024-01-01T09:00:00Z"},
+	} {
+		sub.IssueID, sub.TaskID = "issue-1", "task-1"
+		if err := store.WriteJSON(store.Path("issues", "issue-1", "submissions", "task-1", sub.ID+".json"), &sub); err != nil {
+			t.Fatalf("write submission: %v", err)
+		}
+	}
+	if _, err := svc.pushToLeadInboxLocked("issue-1", "task-1", InboxTypeSubmission, "sub-1", "w1"); err != nil {
+		t.Fatalf("push lead inbox: %v", err)
+	}
+	if _, err := svc.pushToWorkerInboxLocked("issue-1", "w1", "task-1", InboxTypeReply, "msg-1", "lead"); err != nil {
+		t.Fatalf("push worker inbox: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("deliveries", "dlv-1.json"), &Delivery{ID: "dlv-1", IssueID: "issue-2", Status: DeliveryApproved}); err != nil {
+		t.Fatalf("write delivery: %v", err)
+	}
+	if _, err := workers.Register("w1", nil); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, err := locks.LockFiles(context.Background(), "task-1", "w1", "", []string{"a.go", "b.go"}, 60, 0); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+
+	st, err := svc.GetSwarmStats(workers, locks)
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if st.Issues[IssueInProgress] != 1 || st.Issues[IssueDone] != 1 {
+		t.Fatalf("unexpected issue counts %v", st.Issues)
+	}
+	if st.Tasks[IssueTaskInProgress] != 1 || st.Tasks[IssueTaskOpen] != 1 || st.Tasks[IssueTaskDone] != 1 {
+		t.Fatalf("unexpected task counts %v", st.Tasks)
+	}
+	if st.Deliveries[DeliveryApproved] != 1 || st.Workers != 1 || st.ActiveLeases != 1 || st.LockedFiles != 2 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+	if st.Inboxes.Lead != 1 || st.Inboxes.Workers != 1 || st.Inboxes.Acceptor != 0 {
+		t.Fatalf("unexpected inbox backlog %+v", st.Inboxes)
+	}
+	if st.PendingSubmissions != 2 || st.OldestPendingSubmission != "sub-1" || st.OldestPendingAgeSec <= 0 {
+		t.Fatalf("expected sub-1 as the oldest of 2 pending submissions, got %+v", st)
+	}
+}
@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 )
 
 type Store struct {
@@ -19,6 +22,26 @@ func NewStore(root string) *Store {
 	return &Store{Root: root}
 }
 
+// projectKeyPattern keeps project keys usable as a single directory name.
+var projectKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// ValidateProjectKey checks that key can name a project namespace.
+func ValidateProjectKey(key string) error {
+	if !projectKeyPattern.MatchString(key) {
+		return Errorf(CodeInvalidArgument, "invalid project key %q: use lowercase letters, digits, '.', '_' or '-' (max 64)", key)
+	}
+	return nil
+}
+
+// Project returns the store of a project namespace, rooted at <root>/projects/<key> with its own global
+// lock. The empty key is the root store itself.
+func (s *Store) Project(key string) *Store {
+	if key == "" {
+		return s
+	}
+	return &Store{Root: filepath.Join(s.Root, "projects", key)}
+}
+
 func (s *Store) EnsureDir(parts ...string) string {
 	p := filepath.Join(append([]string{s.Root}, parts...)...)
 	_ = os.MkdirAll(p, 0755)
@@ -96,7 +119,135 @@ func (s *Store) WithLock(fn func() error) error {
 	return fn()
 }
 
+// TryLock takes the non-blocking flock <root>/<name> for one cross-process job. ok is false while another
+// process holds it; release frees it.
+func (s *Store) TryLock(name string) (release func(), ok bool, err error) {
+	f, err := openLockFile(s.Path(name))
+	if err != nil {
+		return nil, false, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("flock: %w", err)
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, true, nil
+}
+
+// LeaderLock elects one process per store to run a store-wide background job (backups, replication,
+// retention). The first process to take the flock keeps it until it exits; the others keep asking, so
+// the job moves to another process when the leader goes away.
+type LeaderLock struct {
+	store *Store
+	name  string
+	mu    sync.Mutex
+	f     *os.File
+}
+
+// Leader returns the leader election for the job called name; nothing is locked until Held is called.
+func (s *Store) Leader(name string) *LeaderLock {
+	return &LeaderLock{store: s, name: name}
+}
+
+// Held reports whether this process is the job's leader, taking the lock if it is free.
+func (l *LeaderLock) Held() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f != nil {
+		return true
+	}
+	f, err := openLockFile(l.store.Path("locks", "leader", l.name+".lock"))
+	if err != nil {
+		return false
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		return false
+	}
+	l.f = f
+	return true
+}
+
+// Release gives up the leadership.
+func (l *LeaderLock) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f != nil {
+		_ = syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN)
+		l.f.Close()
+		l.f = nil
+	}
+}
+
+func openLockFile(path string) (*os.File, error) {
+	_ = os.MkdirAll(filepath.Dir(path), 0755)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock %s: %w", filepath.Base(path), err)
+	}
+	return f, nil
+}
+
+// ProbeWritable checks that the store root accepts new files.
+func (s *Store) ProbeWritable() error {
+	if err := os.MkdirAll(s.Root, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(s.Root, ".healthz-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	_, werr := f.WriteString("ok")
+	cerr := f.Close()
+	_ = os.Remove(name)
+	if werr != nil {
+		return werr
+	}
+	return cerr
+}
+
+// ProbeLock checks that the global lock can be acquired within timeout, without blocking past it.
+func (s *Store) ProbeLock(timeout time.Duration) error {
+	lockPath := s.Path(".global.lock")
+	_ = os.MkdirAll(filepath.Dir(lockPath), 0755)
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("open global lock: %w", err)
+	}
+	defer f.Close()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		}
+		if err != syscall.EWOULDBLOCK {
+			return fmt.Errorf("flock: %w", err)
+		}
+		if time.Now().After(deadline) {
+			return Errorf(CodeUnavailable, "global lock not acquired within %s", timeout)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
 func PathHash(file string) string {
 	h := sha256.Sum256([]byte(filepath.Clean(file)))
 	return fmt.Sprintf("%x", h[:8])
 }
+
+// LockKey is the lock file key for file within scope. The empty scope is the global namespace.
+func LockKey(scope, file string) string {
+	if scope == "" {
+		return PathHash(file)
+	}
+	h := sha256.Sum256([]byte(scope + "\x00" + filepath.Clean(file)))
+	return fmt.Sprintf("%x", h[:8])
+}
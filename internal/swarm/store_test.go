@@ -0,0 +1,53 @@
+package swarm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStore_ProbeLockTimesOutWhileHeld(t *testing.T) {
+	store := NewStore(t.TempDir())
+	if err := store.ProbeWritable(); err != nil {
+		t.Fatalf("probe writable: %v", err)
+	}
+	if err := store.ProbeLock(time.Second); err != nil {
+		t.Fatalf("probe free lock: %v", err)
+	}
+
+	held := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		_ = store.WithLock(func() error {
+			close(held)
+			<-release
+			return nil
+		})
+	}()
+	<-held
+	if err := store.ProbeLock(100 * time.Millisecond); err == nil {
+		t.Fatalf("expected probe to time out while lock is held")
+	}
+	close(release)
+}
+
+func TestLeaderLock_OneHolderUntilReleased(t *testing.T) {
+	store := NewStore(t.TempDir())
+	// Separate LeaderLocks open the lock file separately, as separate processes would.
+	a, b := store.Leader("backup"), store.Leader("backup")
+	if !a.Held() {
+		t.Fatalf("expected the first process to become leader")
+	}
+	if b.Held() {
+		t.Fatalf("expected a second leader to be refused")
+	}
+	if !a.Held() {
+		t.Fatalf("expected the leader to keep its lock")
+	}
+	a.Release()
+	if !b.Held() {
+		t.Fatalf("expected leadership to move once released")
+	}
+	if other := store.Leader("retention"); !other.Held() {
+		t.Fatalf("expected jobs to elect leaders independently")
+	}
+}
@@ -1,8 +1,9 @@
 package swarm
 
 import (
-	"fmt"
+	"context"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -28,6 +29,7 @@ func (s *IssueService) createSubmissionLocked(issueID, taskID, workerID string,
 	if err := s.store.WriteJSON(path, sub); err != nil {
 		return nil, err
 	}
+	s.trackReviewDeadline(issueID, taskID, 0)
 	return sub, nil
 }
 
@@ -39,7 +41,7 @@ func (s *IssueService) getSubmissionLocked(issueID, submissionID string) (*Submi
 	entries, err := os.ReadDir(tasksDir)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("submission '%s' not found", submissionID)
+			return nil, Errorf(CodeNotFound, "submission '%s' not found", submissionID)
 		}
 		return nil, err
 	}
@@ -55,7 +57,7 @@ func (s *IssueService) getSubmissionLocked(issueID, submissionID string) (*Submi
 		}
 	}
 	if found == nil {
-		return nil, fmt.Errorf("submission '%s' not found", submissionID)
+		return nil, Errorf(CodeNotFound, "submission '%s' not found", submissionID)
 	}
 	return found, nil
 }
@@ -65,7 +67,7 @@ func (s *IssueService) getLatestOpenSubmissionLocked(issueID, taskID string) (*S
 	dir := s.store.Path("issues", issueID, "submissions", taskID)
 	files, err := s.store.ListJSONFiles(dir)
 	if err != nil || len(files) == 0 {
-		return nil, fmt.Errorf("no open submission for task '%s'", taskID)
+		return nil, Errorf(CodeInvalidState, "no open submission for task '%s'", taskID)
 	}
 	var latest *Submission
 	for _, f := range files {
@@ -81,7 +83,7 @@ func (s *IssueService) getLatestOpenSubmissionLocked(issueID, taskID string) (*S
 		}
 	}
 	if latest == nil {
-		return nil, fmt.Errorf("no open submission for task '%s'", taskID)
+		return nil, Errorf(CodeInvalidState, "no open submission for task '%s'", taskID)
 	}
 	return latest, nil
 }
@@ -96,7 +98,7 @@ func (s *IssueService) reviewSubmissionLocked(
 		return nil, err
 	}
 	if sub.Status != SubmissionOpen {
-		return nil, fmt.Errorf("submission '%s' is already %s", submissionID, sub.Status)
+		return nil, Errorf(CodeInvalidState, "submission '%s' is already %s", submissionID, sub.Status)
 	}
 	if verdict == VerdictApproved {
 		sub.Status = SubmissionApproved
@@ -109,12 +111,14 @@ func (s *IssueService) reviewSubmissionLocked(
 	sub.CompletionScore = completionScore
 	sub.NextStepToken = nextStepToken
 	sub.ReviewedBy = actor
-	sub.UpdatedAt = NowStr()
+	sub.ReviewedAt = NowStr()
+	sub.UpdatedAt = sub.ReviewedAt
 
 	path := s.store.Path("issues", issueID, "submissions", sub.TaskID, sub.ID+".json")
 	if err := s.store.WriteJSON(path, sub); err != nil {
 		return nil, err
 	}
+	s.ackSubmissionCommentItemsLocked(issueID, sub, true)
 	return sub, nil
 }
 
@@ -158,6 +162,7 @@ func (s *IssueService) deleteSubmissionsForTaskLocked(issueID, taskID string) {
 	dir := s.store.Path("issues", issueID, "submissions", taskID)
 	files, _ := s.store.ListJSONFiles(dir)
 	for _, f := range files {
+		_ = os.RemoveAll(s.submissionCommentsDir(issueID, strings.TrimSuffix(filepath.Base(f), ".json")))
 		_ = s.store.Remove(f)
 	}
 	_ = os.Remove(dir) // remove empty dir; ignore error if not empty
@@ -169,25 +174,31 @@ func (s *IssueService) deleteSubmissionsForTaskLocked(issueID, taskID string) {
 	}
 }
 
-// pollSubmissionStatus polls until the submission is no longer open. Used by SubmitTask blocking wait.
-func (s *IssueService) pollSubmissionStatus(issueID, submissionID string, timeoutSec int) (*Submission, error) {
+// pollSubmissionStatus polls until the submission is no longer open, or the lead has commented on it and
+// the worker has yet to answer. Used by SubmitTask blocking wait.
+func (s *IssueService) pollSubmissionStatus(ctx context.Context, issueID, submissionID string, timeoutSec int) (*Submission, error) {
 	deadline := s.deadline(timeoutSec)
 	for {
+		since := s.changeGen()
 		var sub *Submission
+		commented := false
 		_ = s.store.WithLock(func() error {
 			found, err := s.getSubmissionLocked(issueID, submissionID)
 			if err == nil {
 				sub = found
+				commented = len(s.pendingLeadCommentsLocked(issueID, found)) > 0
 			}
 			return nil
 		})
-		if sub != nil && sub.Status != SubmissionOpen {
+		if sub != nil && (sub.Status != SubmissionOpen || commented) {
 			return sub, nil
 		}
 		if timeExpired(deadline) {
-			return nil, fmt.Errorf("timeout waiting for review of submission '%s'", submissionID)
+			return nil, Errorf(CodeTimeout, "timeout waiting for review of submission '%s'", submissionID)
+		}
+		if err := s.waitChange(ctx, since, pollInterval); err != nil {
+			return nil, err
 		}
-		sleepPoll()
 	}
 }
 
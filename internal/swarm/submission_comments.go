@@ -0,0 +1,255 @@
+package swarm
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Submission comments. While a submission is open, the lead and the submitting worker can discuss a
+// specific finding before the verdict, instead of a full reject/resubmit cycle. Comments are stored in
+// issues/<id>/submission_comments/<submission_id>/<comment_id>.json; parent_id threads a reply under
+// an earlier comment. Each comment lands in the other side's inbox. A lead comment also ends the
+// worker's review wait in submitIssueTask, and the worker's own comment waits again for the next lead
+// comment or the verdict. A thread is bounded by maxSubmissionComments and ends with the review.
+
+const maxSubmissionComments = 20
+
+type SubmissionComment struct {
+	ID           string `json:"id"`
+	IssueID      string `json:"issue_id"`
+	TaskID       string `json:"task_id"`
+	SubmissionID string `json:"submission_id"`
+	ParentID     string `json:"parent_id,omitempty"`
+	AuthorID     string `json:"author_id"`
+	AuthorRole   string `json:"author_role"` // lead/worker
+	FilePath     string `json:"file_path,omitempty"`
+	LineRange    string `json:"line_range,omitempty"`
+	Content      string `json:"content"`
+	CreatedAt    string `json:"created_at"`
+}
+
+func (s *IssueService) submissionCommentsDir(issueID, submissionID string) string {
+	return s.store.Path("issues", issueID, "submission_comments", submissionID)
+}
+
+// AddSubmissionComment adds a comment by actor, acting as role (lead or worker), to an open submission.
+// Only the submitter can comment as worker. A reply (parentID) acknowledges the lead inbox item of the
+// comment it answers.
+func (s *IssueService) AddSubmissionComment(issueID, submissionID, actor, role, parentID, filePath, lineRange, content string) (*SubmissionComment, error) {
+	content = strings.TrimSpace(content)
+	if issueID == "" || submissionID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id and submission_id are required")
+	}
+	if content == "" {
+		return nil, Errorf(CodeInvalidArgument, "content is required")
+	}
+	if role != "lead" && role != "worker" {
+		return nil, Errorf(CodeInvalidArgument, "role must be lead or worker")
+	}
+	if actor == "" {
+		actor = role
+	}
+
+	var result *SubmissionComment
+	err := s.store.WithLock(func() error {
+		sub, err := s.getSubmissionLocked(issueID, submissionID)
+		if err != nil {
+			return err
+		}
+		if sub.Status != SubmissionOpen {
+			return Errorf(CodeInvalidState, "submission '%s' is already %s; comments close with the review", submissionID, sub.Status)
+		}
+		if role == "worker" && strings.TrimSpace(actor) != strings.TrimSpace(sub.WorkerID) {
+			return Errorf(CodeNotOwner, "submission '%s' was not submitted by '%s'", submissionID, actor)
+		}
+		comments := s.listSubmissionCommentsLocked(issueID, submissionID)
+		if len(comments) >= maxSubmissionComments {
+			return Errorf(CodeLimitExceeded, "submission '%s' already has %d comments; settle it with a review", submissionID, maxSubmissionComments)
+		}
+		if parentID != "" && !containsComment(comments, parentID) {
+			return Errorf(CodeNotFound, "comment '%s' not found on submission '%s'", parentID, submissionID)
+		}
+
+		c := &SubmissionComment{
+			ID:           GenID("cmt"),
+			IssueID:      issueID,
+			TaskID:       sub.TaskID,
+			SubmissionID: sub.ID,
+			ParentID:     parentID,
+			AuthorID:     actor,
+			AuthorRole:   role,
+			FilePath:     strings.TrimSpace(filePath),
+			LineRange:    strings.TrimSpace(lineRange),
+			Content:      content,
+			CreatedAt:    NowStr(),
+		}
+		if err := s.store.WriteJSON(s.store.Path("issues", issueID, "submission_comments", sub.ID, c.ID+".json"), c); err != nil {
+			return err
+		}
+		if role == "lead" {
+			if parentID != "" {
+				s.ackLeadInboxByRefLocked(issueID, parentID)
+			}
+			if _, err := s.pushToWorkerInboxLocked(issueID, sub.WorkerID, sub.TaskID, InboxTypeSubmissionComment, c.ID, actor); err != nil {
+				return err
+			}
+		} else {
+			// The worker has read the lead's comments it is answering.
+			s.ackSubmissionCommentItemsLocked(issueID, sub, false)
+			if _, err := s.pushToLeadInboxLocked(issueID, sub.TaskID, InboxTypeSubmissionComment, c.ID, actor); err != nil {
+				return err
+			}
+		}
+		result = c
+		return s.appendEventLocked(issueID, IssueEvent{
+			Type:         EventSubmissionComment,
+			IssueID:      issueID,
+			TaskID:       sub.TaskID,
+			Actor:        actor,
+			Kind:         role,
+			Detail:       content,
+			Refs:         commentRef(c),
+			SubmissionID: sub.ID,
+			Timestamp:    c.CreatedAt,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.bump(issueID)
+	return result, nil
+}
+
+// ListSubmissionComments returns the comments on a submission, oldest first.
+func (s *IssueService) ListSubmissionComments(issueID, submissionID string) ([]SubmissionComment, error) {
+	if issueID == "" || submissionID == "" {
+		return nil, Errorf(CodeInvalidArgument, "issue_id and submission_id are required")
+	}
+	var out []SubmissionComment
+	err := s.store.WithLock(func() error {
+		if _, err := s.getSubmissionLocked(issueID, submissionID); err != nil {
+			return err
+		}
+		out = s.listSubmissionCommentsLocked(issueID, submissionID)
+		return nil
+	})
+	return out, err
+}
+
+// PendingSubmissionComments returns the lead comments the worker has not answered yet on the open
+// submission of taskID; none when the task has no open submission.
+func (s *IssueService) PendingSubmissionComments(issueID, taskID string) ([]SubmissionComment, error) {
+	var out []SubmissionComment
+	err := s.store.WithLock(func() error {
+		sub, err := s.getLatestOpenSubmissionLocked(issueID, taskID)
+		if err != nil {
+			return nil
+		}
+		out = s.pendingLeadCommentsLocked(issueID, sub)
+		return nil
+	})
+	return out, err
+}
+
+// WaitSubmissionAnswer blocks the submitting worker until the lead comments again or reviews the
+// submission, like the review wait of SubmitTask (including its lease keepalive).
+func (s *IssueService) WaitSubmissionAnswer(ctx context.Context, issueID, submissionID string) (*Submission, error) {
+	var sub *Submission
+	_ = s.store.WithLock(func() error {
+		sub, _ = s.getSubmissionLocked(issueID, submissionID)
+		return nil
+	})
+	if sub != nil {
+		stop := s.keepTaskLeaseAlive(ctx, issueID, sub.TaskID, sub.WorkerID)
+		defer stop()
+	}
+	return s.pollSubmissionStatus(ctx, issueID, submissionID, s.defaultTimeoutSec)
+}
+
+// findSubmissionComment loads a comment by ID without knowing its submission.
+func (s *IssueService) findSubmissionComment(issueID, commentID string) (*SubmissionComment, error) {
+	matches, _ := filepath.Glob(s.store.Path("issues", issueID, "submission_comments", "*", filepath.Base(commentID)+".json"))
+	if len(matches) == 0 {
+		return nil, Errorf(CodeNotFound, "comment '%s' not found", commentID)
+	}
+	var c SubmissionComment
+	if err := s.store.ReadJSON(matches[0], &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (s *IssueService) listSubmissionCommentsLocked(issueID, submissionID string) []SubmissionComment {
+	var out []SubmissionComment
+	for _, f := range listJSONOrEmpty(s.store, s.submissionCommentsDir(issueID, submissionID)) {
+		var c SubmissionComment
+		if err := s.store.ReadJSON(f, &c); err == nil {
+			out = append(out, c)
+		}
+	}
+	// IDs carry the creation time in milliseconds.
+	sort.SliceStable(out, func(i, j int) bool { return idLess(out[i].ID, out[j].ID) })
+	return out
+}
+
+// pendingLeadCommentsLocked returns the lead comments on sub whose worker inbox items are not done yet.
+func (s *IssueService) pendingLeadCommentsLocked(issueID string, sub *Submission) []SubmissionComment {
+	var out []SubmissionComment
+	for _, f := range listJSONOrEmpty(s.store, s.store.Path("issues", issueID, "inbox", "workers", sub.WorkerID)) {
+		var item InboxItem
+		if err := s.store.ReadJSON(f, &item); err != nil || item.Type != InboxTypeSubmissionComment || item.Status == InboxDone {
+			continue
+		}
+		var c SubmissionComment
+		if err := s.store.ReadJSON(s.store.Path("issues", issueID, "submission_comments", sub.ID, item.RefID+".json"), &c); err == nil {
+			out = append(out, c)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return idLess(out[i].ID, out[j].ID) })
+	return out
+}
+
+// ackSubmissionCommentItemsLocked marks the worker's inbox items for lead comments on sub done. With
+// lead, the lead inbox items for the worker's comments are marked done too (the review settles the
+// thread).
+func (s *IssueService) ackSubmissionCommentItemsLocked(issueID string, sub *Submission, lead bool) {
+	comments := map[string]bool{}
+	for _, c := range s.listSubmissionCommentsLocked(issueID, sub.ID) {
+		comments[c.ID] = true
+	}
+	if len(comments) == 0 {
+		return
+	}
+	files := listJSONOrEmpty(s.store, s.store.Path("issues", issueID, "inbox", "workers", sub.WorkerID))
+	if lead {
+		files = append(files, listJSONOrEmpty(s.store, s.store.Path("issues", issueID, "inbox", "lead"))...)
+	}
+	for _, f := range files {
+		var item InboxItem
+		if err := s.store.ReadJSON(f, &item); err != nil || item.Type != InboxTypeSubmissionComment || item.Status == InboxDone || !comments[item.RefID] {
+			continue
+		}
+		item.Status = InboxDone
+		item.UpdatedAt = NowStr()
+		_ = s.store.WriteJSON(f, &item)
+	}
+}
+
+func containsComment(comments []SubmissionComment, id string) bool {
+	for _, c := range comments {
+		if c.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// commentRef renders the code location a comment is about, e.g. "main.go:10-12".
+func commentRef(c *SubmissionComment) string {
+	if c.FilePath == "" || c.LineRange == "" {
+		return c.FilePath
+	}
+	return c.FilePath + ":" + c.LineRange
+}
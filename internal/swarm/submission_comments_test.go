@@ -0,0 +1,82 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSubmissionComments_Thread(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 2}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	if err := svc.saveTaskLocked(issueID, &IssueTask{ID: "task-1", IssueID: issueID, Status: IssueTaskInProgress, ClaimedBy: "w1"}); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	sub, err := svc.createSubmissionLocked(issueID, "task-1", "w1", SubmissionArtifacts{Summary: "done"})
+	if err != nil {
+		t.Fatalf("create submission: %v", err)
+	}
+
+	question, err := svc.AddSubmissionComment(issueID, sub.ID, "lead-1", "lead", "", "main.go", "10-12", "why not reuse the helper?")
+	if err != nil {
+		t.Fatalf("lead comment: %v", err)
+	}
+	// A pending lead comment ends the worker's review wait at once.
+	got, err := svc.WaitSubmissionAnswer(WithShortTimeouts(context.Background()), issueID, sub.ID)
+	if err != nil || got.Status != SubmissionOpen {
+		t.Fatalf("expected the wait to return the open submission, got %+v (%v)", got, err)
+	}
+	pending, err := svc.PendingSubmissionComments(issueID, "task-1")
+	if err != nil || len(pending) != 1 || pending[0].ID != question.ID {
+		t.Fatalf("expected the lead comment to be pending, got %+v (%v)", pending, err)
+	}
+
+	if _, err := svc.AddSubmissionComment(issueID, sub.ID, "w2", "worker", question.ID, "", "", "not mine"); ErrorCode(err) != CodeNotOwner {
+		t.Fatalf("expected another worker to be rejected, got %v", err)
+	}
+	if _, err := svc.AddSubmissionComment(issueID, sub.ID, "w1", "worker", "cmt_missing", "", "", "?"); ErrorCode(err) != CodeNotFound {
+		t.Fatalf("expected unknown parent to be rejected, got %v", err)
+	}
+	answer, err := svc.AddSubmissionComment(issueID, sub.ID, "w1", "worker", question.ID, "", "", "it has a different contract")
+	if err != nil {
+		t.Fatalf("worker comment: %v", err)
+	}
+	if pending, _ := svc.PendingSubmissionComments(issueID, "task-1"); len(pending) != 0 {
+		t.Fatalf("expected the answer to settle the lead comment, got %+v", pending)
+	}
+	leadItems := listJSONOrEmpty(store, store.Path("issues", issueID, "inbox", "lead"))
+	if len(leadItems) != 1 {
+		t.Fatalf("expected one lead inbox item, got %d", len(leadItems))
+	}
+
+	comments, err := svc.ListSubmissionComments(issueID, sub.ID)
+	if err != nil || len(comments) != 2 {
+		t.Fatalf("unexpected thread: %+v (%v)", comments, err)
+	}
+	for _, c := range comments {
+		if c.ID == answer.ID && c.ParentID != question.ID {
+			t.Fatalf("expected the answer to reply to the question, got %+v", c)
+		}
+	}
+
+	if err := store.WithLock(func() error {
+		_, err := svc.reviewSubmissionLocked(issueID, sub.ID, "lead-1", VerdictApproved, "ok", 5, ReviewArtifacts{}, nil, "")
+		return err
+	}); err != nil {
+		t.Fatalf("review: %v", err)
+	}
+	var item InboxItem
+	if err := store.ReadJSON(leadItems[0], &item); err != nil || item.Status != InboxDone {
+		t.Fatalf("expected the review to settle the lead inbox item, got %+v (%v)", item, err)
+	}
+	if _, err := svc.AddSubmissionComment(issueID, sub.ID, "lead-1", "lead", "", "", "", "one more thing"); ErrorCode(err) != CodeInvalidState {
+		t.Fatalf("expected comments to close with the review, got %v", err)
+	}
+}
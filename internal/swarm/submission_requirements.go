@@ -0,0 +1,123 @@
+package swarm
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// Submission requirements. By default submitIssueTask needs a summary, changed_files, test_cases,
+// test_result and test_output, which does not fit research or spike tasks that change no code.
+// Operators can list rules in a JSON file; the first rule matching a task's labels or difficulty sets
+// the artifact fields its submissions must carry instead. summary is always required.
+
+// DefaultSubmissionArtifacts are the artifact fields a submission needs when no rule matches.
+var DefaultSubmissionArtifacts = []string{"summary", "changed_files", "test_cases", "test_result", "test_output"}
+
+// submissionArtifactFields are the artifact fields a rule may require.
+var submissionArtifactFields = []string{"summary", "changed_files", "diff", "links", "test_cases", "test_result", "test_output"}
+
+// SubmissionRequirementsConfig is the requirements file: {"rules": [...]}.
+type SubmissionRequirementsConfig struct {
+	Rules []SubmissionRule `json:"rules"`
+}
+
+type SubmissionRule struct {
+	Name         string   `json:"name,omitempty"`
+	Labels       []string `json:"labels,omitempty"`
+	Difficulties []string `json:"difficulties,omitempty"`
+	Required     []string `json:"required"`
+}
+
+// ParseSubmissionRequirements parses and validates a submission requirements file.
+func ParseSubmissionRequirements(bs []byte) (SubmissionRequirementsConfig, error) {
+	var cfg SubmissionRequirementsConfig
+	if err := json.Unmarshal(bs, &cfg); err != nil {
+		return SubmissionRequirementsConfig{}, Errorf(CodeInvalidArgument, "invalid submission requirements: %w", err)
+	}
+	for i, r := range cfg.Rules {
+		if len(r.Labels) == 0 && len(r.Difficulties) == 0 {
+			return SubmissionRequirementsConfig{}, Errorf(CodeInvalidArgument, "invalid submission requirements: rules[%d] needs labels or difficulties", i)
+		}
+		for _, d := range r.Difficulties {
+			if d != "easy" && d != "medium" && d != "focus" {
+				return SubmissionRequirementsConfig{}, Errorf(CodeInvalidArgument, "invalid submission requirements: rules[%d]: invalid difficulty %q", i, d)
+			}
+		}
+		required := []string{"summary"}
+		for _, f := range r.Required {
+			f = strings.TrimSpace(f)
+			if !slices.Contains(submissionArtifactFields, f) {
+				return SubmissionRequirementsConfig{}, Errorf(CodeInvalidArgument, "invalid submission requirements: rules[%d]: unknown artifact field %q (known: %s)", i, f, strings.Join(submissionArtifactFields, ", "))
+			}
+			if !slices.Contains(required, f) {
+				required = append(required, f)
+			}
+		}
+		cfg.Rules[i].Required = required
+	}
+	return cfg, nil
+}
+
+// SetSubmissionRequirements sets the per-label/difficulty artifact rules; an empty config restores the
+// strict default for every task.
+func (s *IssueService) SetSubmissionRequirements(cfg SubmissionRequirementsConfig) {
+	s.submissionRules = cfg.Rules
+}
+
+// RequiredSubmissionArtifacts lists the artifact fields a submission for task must carry.
+func (s *IssueService) RequiredSubmissionArtifacts(task *IssueTask) []string {
+	for _, r := range s.submissionRules {
+		if r.applies(task) {
+			return r.Required
+		}
+	}
+	return DefaultSubmissionArtifacts
+}
+
+// HasSubmissionRequirements reports whether any rule relaxes or changes the default requirements.
+func (s *IssueService) HasSubmissionRequirements() bool {
+	return len(s.submissionRules) > 0
+}
+
+func (r SubmissionRule) applies(task *IssueTask) bool {
+	if slices.Contains(r.Difficulties, task.Difficulty) {
+		return true
+	}
+	for _, l := range r.Labels {
+		for _, tl := range task.Labels {
+			if strings.EqualFold(strings.TrimSpace(l), strings.TrimSpace(tl)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkSubmissionArtifacts requires the artifact fields that apply to task.
+func (s *IssueService) checkSubmissionArtifacts(task *IssueTask, a SubmissionArtifacts) error {
+	for _, f := range s.RequiredSubmissionArtifacts(task) {
+		var present bool
+		switch f {
+		case "summary":
+			present = strings.TrimSpace(a.Summary) != ""
+		case "changed_files":
+			present = len(a.ChangedFiles) > 0
+		case "diff":
+			present = strings.TrimSpace(a.Diff) != ""
+		case "links":
+			present = len(a.Links) > 0
+		case "test_cases":
+			present = len(a.TestCases) > 0
+		case "test_result":
+			present = strings.TrimSpace(a.TestResult) != ""
+		case "test_output":
+			present = strings.TrimSpace(a.TestOutput) != ""
+		}
+		if !present {
+			return Errorf(CodeInvalidArgument, "artifacts.%s is required", f).
+				With("required_artifacts", s.RequiredSubmissionArtifacts(task))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package swarm
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseSubmissionRequirements_Validates(t *testing.T) {
+	for _, bad := range []string{
+		`{"rules":[{"required":["links"]}]}`,
+		`{"rules":[{"labels":["research"],"required":["screenshots"]}]}`,
+		`{"rules":[{"difficulties":["hard"],"required":["links"]}]}`,
+	} {
+		if _, err := ParseSubmissionRequirements([]byte(bad)); ErrorCode(err) != CodeInvalidArgument {
+			t.Fatalf("expected %s to be rejected, got %v", bad, err)
+		}
+	}
+	cfg, err := ParseSubmissionRequirements([]byte(`{"rules":[{"labels":["research"],"required":["links","links"]}]}`))
+	if err != nil || !slices.Equal(cfg.Rules[0].Required, []string{"summary", "links"}) {
+		t.Fatalf("expected summary to be implied and duplicates dropped, got %+v (%v)", cfg, err)
+	}
+}
+
+func TestSubmitTask_RequirementsPerLabel(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 3600, 3600)
+	cfg, err := ParseSubmissionRequirements([]byte(`{"rules":[{"name":"spike","labels":["Research"],"required":["links"]}]}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	svc.SetSubmissionRequirements(cfg)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueOpen}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	if err := store.WriteJSON(store.Path("issues", issueID, "meta.json"), &issueMeta{NextSeq: 1, NextTaskNum: 3}); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	for _, task := range []*IssueTask{
+		{ID: "task-1", IssueID: issueID, Status: IssueTaskInProgress, ClaimedBy: "w1"},
+		{ID: "task-2", IssueID: issueID, Status: IssueTaskInProgress, ClaimedBy: "w1", Labels: []string{"research"}},
+	} {
+		if err := svc.saveTaskLocked(issueID, task); err != nil {
+			t.Fatalf("write task: %v", err)
+		}
+	}
+
+	ctx := WithShortTimeouts(context.Background())
+	research := SubmissionArtifacts{Summary: "compared three libraries"}
+	if _, err := svc.SubmitTask(ctx, issueID, "task-1", "w1", research); ErrorCode(err) != CodeInvalidArgument {
+		t.Fatalf("expected the strict default for an unlabeled task, got %v", err)
+	}
+	if _, err := svc.SubmitTask(ctx, issueID, "task-2", "w1", research); ErrorCode(err) != CodeInvalidArgument {
+		t.Fatalf("expected the research rule to require links, got %v", err)
+	}
+	research.Links = []string{"https://example.com/notes"}
+	// The submission is accepted; the review wait then ends with the context.
+	waitCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	if _, err := svc.SubmitTask(waitCtx, issueID, "task-2", "w1", research); ErrorCode(err) == CodeInvalidArgument {
+		t.Fatalf("expected a research submission without code artifacts to be accepted, got %v", err)
+	}
+	if subs := listJSONOrEmpty(store, store.Path("issues", issueID, "submissions", "task-2")); len(subs) != 1 {
+		t.Fatalf("expected one submission, got %d", len(subs))
+	}
+}
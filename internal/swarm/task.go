@@ -19,7 +19,7 @@ func NewTaskService(store *Store, trace *TraceService) *TaskService {
 
 func (s *TaskService) CreateTask(team, subject, description string, suggestedFiles, labels, deps []string) (*Task, error) {
 	if team == "" || subject == "" {
-		return nil, fmt.Errorf("team and subject are required")
+		return nil, Errorf(CodeInvalidArgument, "team and subject are required")
 	}
 
 	var result *Task
@@ -61,7 +61,7 @@ func (s *TaskService) CreateTask(team, subject, description string, suggestedFil
 
 func (s *TaskService) AssignTask(team, taskID, to string) (*Task, error) {
 	if taskID == "" || to == "" {
-		return nil, fmt.Errorf("task_id and to are required")
+		return nil, Errorf(CodeInvalidArgument, "task_id and to are required")
 	}
 
 	var result *Task
@@ -92,7 +92,7 @@ func (s *TaskService) AssignTask(team, taskID, to string) (*Task, error) {
 
 func (s *TaskService) ClaimTask(team, taskID, member string) (*Task, error) {
 	if taskID == "" || member == "" {
-		return nil, fmt.Errorf("task_id and member are required")
+		return nil, Errorf(CodeInvalidArgument, "task_id and member are required")
 	}
 
 	var result *Task
@@ -102,7 +102,7 @@ func (s *TaskService) ClaimTask(team, taskID, member string) (*Task, error) {
 			return err
 		}
 		if task.Status != TaskOpen {
-			return fmt.Errorf("task '%s' is not open (status: %s)", taskID, task.Status)
+			return Errorf(CodeInvalidState, "task '%s' is not open (status: %s)", taskID, task.Status)
 		}
 		task.ClaimedBy = member
 		task.Status = TaskInProgress
@@ -125,7 +125,7 @@ func (s *TaskService) ClaimTask(team, taskID, member string) (*Task, error) {
 
 func (s *TaskService) UpdateTask(team, taskID, status, summary string, touchedFiles []string) (*Task, error) {
 	if taskID == "" {
-		return nil, fmt.Errorf("task_id is required")
+		return nil, Errorf(CodeInvalidArgument, "task_id is required")
 	}
 
 	validStatuses := map[string]bool{
@@ -133,7 +133,7 @@ func (s *TaskService) UpdateTask(team, taskID, status, summary string, touchedFi
 		TaskBlocked: true, TaskDone: true, TaskCanceled: true,
 	}
 	if status != "" && !validStatuses[status] {
-		return nil, fmt.Errorf("invalid status: %s", status)
+		return nil, Errorf(CodeInvalidArgument, "invalid status: %s", status)
 	}
 
 	var result *Task
@@ -208,7 +208,7 @@ func (s *TaskService) loadTask(team, taskID string) (*Task, error) {
 				}
 			}
 		}
-		return nil, fmt.Errorf("task '%s' not found in team '%s'", taskID, team)
+		return nil, Errorf(CodeNotFound, "task '%s' not found in team '%s'", taskID, team)
 	}
 	return &task, nil
 }
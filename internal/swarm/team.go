@@ -21,14 +21,14 @@ func NewTeamService(store *Store, trace *TraceService) *TeamService {
 
 func (s *TeamService) SpawnTeam(team string, members []string) (*TeamConfig, error) {
 	if team == "" {
-		return nil, fmt.Errorf("team name is required")
+		return nil, Errorf(CodeInvalidArgument, "team name is required")
 	}
 
 	var result *TeamConfig
 	err := s.store.WithLock(func() error {
 		cfgPath := s.store.Path("teams", team, "config.json")
 		if s.store.Exists("teams", team, "config.json") {
-			return fmt.Errorf("team '%s' already exists", team)
+			return Errorf(CodeAlreadyExists, "team '%s' already exists", team)
 		}
 
 		cfg := &TeamConfig{
@@ -92,14 +92,14 @@ func (s *TeamService) ListMembers(team string) ([]string, error) {
 	cfgPath := s.store.Path("teams", team, "config.json")
 	var cfg TeamConfig
 	if err := s.store.ReadJSON(cfgPath, &cfg); err != nil {
-		return nil, fmt.Errorf("team '%s' not found", team)
+		return nil, Errorf(CodeNotFound, "team '%s' not found", team)
 	}
 	return cfg.Members, nil
 }
 
 func (s *TeamService) JoinTeam(team, member string) (*TeamConfig, error) {
 	if team == "" || member == "" {
-		return nil, fmt.Errorf("team and member are required")
+		return nil, Errorf(CodeInvalidArgument, "team and member are required")
 	}
 
 	cfgPath := s.store.Path("teams", team, "config.json")
@@ -108,7 +108,7 @@ func (s *TeamService) JoinTeam(team, member string) (*TeamConfig, error) {
 	err := s.store.WithLock(func() error {
 		var cfg TeamConfig
 		if err := s.store.ReadJSON(cfgPath, &cfg); err != nil {
-			return fmt.Errorf("team '%s' not found", team)
+			return Errorf(CodeNotFound, "team '%s' not found", team)
 		}
 
 		found := false
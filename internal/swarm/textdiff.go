@@ -0,0 +1,205 @@
+package swarm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDiffLines bounds the O(n*m) time UnifiedDiff spends on the LCS (its memory is linear).
+const maxDiffLines = 4000
+
+type diffOp struct {
+	kind byte // ' ', '-', '+'
+	text string
+}
+
+// UnifiedDiff returns a unified diff (diff -u style, with `context` lines of context) turning a into b.
+// Returns "" when the texts are equal. A missing final newline is marked like diff does
+// (`\ No newline at end of file`), so texts that differ only there still produce a hunk.
+func UnifiedDiff(aName, bName, a, b string, context int) (string, error) {
+	if a == b {
+		return "", nil
+	}
+	al, bl := splitLines(a), splitLines(b)
+	if len(al) > maxDiffLines || len(bl) > maxDiffLines {
+		return "", Errorf(CodeLimitExceeded, "documents too large to diff (max %d lines)", maxDiffLines)
+	}
+	ops := diffLines(al, bl)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+
+	// Group ops into hunks separated by more than 2*context unchanged lines.
+	i := 0
+	for i < len(ops) {
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i >= len(ops) {
+			break
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end += min(context, run-end)
+				break
+			}
+			end = run
+		}
+
+		aStart, bStart := 1, 1
+		for _, op := range ops[:start] {
+			if op.kind != '+' {
+				aStart++
+			}
+			if op.kind != '-' {
+				bStart++
+			}
+		}
+		aCount, bCount := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		if aCount == 0 {
+			aStart--
+		}
+		if bCount == 0 {
+			bStart--
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.text)
+			if !strings.HasSuffix(op.text, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return out.String(), nil
+}
+
+// splitLines splits s into lines that keep their "\n", so a last line without one differs from the same
+// line with one.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a line-level edit script from a longest common subsequence, found with
+// Hirschberg's divide and conquer so memory stays linear in the input.
+func diffLines(a, b []string) []diffOp {
+	return appendDiff(make([]diffOp, 0, len(a)+len(b)), a, b)
+}
+
+func appendDiff(ops []diffOp, a, b []string) []diffOp {
+	// Common prefix and suffix go straight to the script.
+	p := 0
+	for p < len(a) && p < len(b) && a[p] == b[p] {
+		ops = append(ops, diffOp{' ', a[p]})
+		p++
+	}
+	a, b = a[p:], b[p:]
+	q := 0
+	for q < len(a) && q < len(b) && a[len(a)-1-q] == b[len(b)-1-q] {
+		q++
+	}
+	suffix := a[len(a)-q:]
+	a, b = a[:len(a)-q], b[:len(b)-q]
+
+	switch {
+	case len(a) == 0:
+		for _, l := range b {
+			ops = append(ops, diffOp{'+', l})
+		}
+	case len(b) == 0:
+		for _, l := range a {
+			ops = append(ops, diffOp{'-', l})
+		}
+	case len(a) == 1:
+		j := 0
+		for j < len(b) && b[j] != a[0] {
+			j++
+		}
+		if j == len(b) {
+			ops = append(ops, diffOp{'-', a[0]})
+		}
+		for k, l := range b {
+			if k == j {
+				ops = append(ops, diffOp{' ', l})
+			} else {
+				ops = append(ops, diffOp{'+', l})
+			}
+		}
+	default:
+		// Split a in half and b where the LCS of the halves is longest, then solve both parts.
+		mid := len(a) / 2
+		fwd, bwd := lcsPrefixLens(a[:mid], b), lcsSuffixLens(a[mid:], b)
+		cut, best := 0, -1
+		for j := range fwd {
+			if fwd[j]+bwd[j] > best {
+				cut, best = j, fwd[j]+bwd[j]
+			}
+		}
+		ops = appendDiff(ops, a[:mid], b[:cut])
+		ops = appendDiff(ops, a[mid:], b[cut:])
+	}
+
+	for _, l := range suffix {
+		ops = append(ops, diffOp{' ', l})
+	}
+	return ops
+}
+
+// lcsPrefixLens returns, for each j, the LCS length of a and b[:j].
+func lcsPrefixLens(a, b []string) []int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for _, x := range a {
+		for j, y := range b {
+			if x == y {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+// lcsSuffixLens returns, for each j, the LCS length of a and b[j:].
+func lcsSuffixLens(a, b []string) []int {
+	m := len(b)
+	prev, cur := make([]int, m+1), make([]int, m+1)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				cur[j] = prev[j+1] + 1
+			} else {
+				cur[j] = max(prev[j], cur[j+1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
@@ -0,0 +1,71 @@
+package swarm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDocsService_DiffDocs(t *testing.T) {
+	store := NewStore(t.TempDir())
+	docs := NewDocsService(store)
+
+	if _, err := docs.WriteSharedDoc("req", "a\nb\nc\nd\ne\nf\ng\nh\n", 0); err != nil {
+		t.Fatalf("write req: %v", err)
+	}
+	if _, err := docs.WriteIssueDoc("i1", "spec", "a\nb\nc\nD\ne\nf\ng\nh\ni\n", 0); err != nil {
+		t.Fatalf("write spec: %v", err)
+	}
+
+	from := DocTarget{Scope: DocScopeShared, Name: "req"}
+	to := DocTarget{Scope: DocScopeIssue, IssueID: "i1", Name: "spec"}
+	got, err := docs.DiffDocs(from, 0, to, 0, 1)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	want := "--- shared/req\n+++ issue/i1/spec\n@@ -3,3 +3,3 @@\n c\n-d\n+D\n e\n@@ -8,1 +8,2 @@\n h\n+i\n"
+	if got.Diff != want || got.Identical {
+		t.Fatalf("diff =\n%s\nwant\n%s", got.Diff, want)
+	}
+
+	if _, err := docs.WriteSharedDoc("req", "a\n", 0); err != nil {
+		t.Fatalf("rewrite req: %v", err)
+	}
+	got, err = docs.DiffDocs(from, 1, from, 1, 0)
+	if err != nil || !got.Identical || got.From != "shared/req@v1" {
+		t.Fatalf("same version: %+v %v", got, err)
+	}
+	if _, err := docs.DiffDocs(from, 9, from, 0, 0); err == nil {
+		t.Fatalf("expected error for missing version")
+	}
+}
+
+func TestUnifiedDiff_ReportsMissingFinalNewline(t *testing.T) {
+	got, err := UnifiedDiff("a", "b", "x\ny\n", "x\ny", 3)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	want := "--- a\n+++ b\n@@ -1,2 +1,2 @@\n x\n-y\n+y\n\\ No newline at end of file\n"
+	if got != want {
+		t.Fatalf("diff =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiff_LargeInputsKeepAMinimalScript(t *testing.T) {
+	var a, b []string
+	for i := 0; i < maxDiffLines; i++ {
+		a = append(a, fmt.Sprintf("line %d", i))
+		if i%100 != 0 {
+			b = append(b, fmt.Sprintf("line %d", i))
+		}
+	}
+	b = append(b, "tail")
+	got, err := UnifiedDiff("a", "b", strings.Join(a, "\n")+"\n", strings.Join(b, "\n")+"\n", 0)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	removed, added := strings.Count(got, "\n-line "), strings.Count(got, "\n+tail")
+	if removed != maxDiffLines/100 || added != 1 {
+		t.Fatalf("expected %d removals and 1 addition, got %d and %d", maxDiffLines/100, removed, added)
+	}
+}
@@ -5,10 +5,20 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 )
 
 type TraceService struct {
 	store *Store
+
+	rot      TraceRotation
+	disabled map[string]bool
+	mu       sync.Mutex // guards segInfo/segStart
+	segInfo  os.FileInfo
+	segStart string
+	rotating atomic.Bool
+	sinks    []TraceSink
 }
 
 func NewTraceService(store *Store) *TraceService {
@@ -16,6 +26,9 @@ func NewTraceService(store *Store) *TraceService {
 }
 
 func (t *TraceService) Log(event TraceEvent) {
+	if t.disabled[event.Type] {
+		return
+	}
 	if event.ID == "" {
 		event.ID = GenID("ev")
 	}
@@ -23,6 +36,10 @@ func (t *TraceService) Log(event TraceEvent) {
 		event.Timestamp = NowStr()
 	}
 
+	for _, sink := range t.sinks {
+		sink.Trace(event)
+	}
+
 	dir := t.store.EnsureDir("trace")
 	f, err := os.OpenFile(filepath.Join(dir, "events.jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
@@ -32,4 +49,5 @@ func (t *TraceService) Log(event TraceEvent) {
 
 	data, _ := json.Marshal(event)
 	fmt.Fprintln(f, string(data))
+	t.maybeRotate(f)
 }
@@ -0,0 +1,265 @@
+package swarm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Trace log queries. trace/events.jsonl is only ever appended to until it is rotated, so it gets a
+// sidecar index (events.jsonl.idx) that splits it into blocks of traceIndexBlockLines lines and records
+// each block's time range, event types and actors. A query reads only the blocks that can match; the
+// index is brought up to date incrementally before each query and rebuilt when the file was rotated or
+// truncated. Rotated segments are scanned newest first, skipping those rotated before Since.
+
+const (
+	traceDefaultLimit    = 100
+	traceMaxLimit        = 1000
+	traceIndexBlockLines = 512
+	traceIndexHeadBytes  = 64
+)
+
+// TraceQuery filters Query. Since/Until are RFC3339 and inclusive; empty fields match everything.
+type TraceQuery struct {
+	Type    string
+	Actor   string
+	Subject string
+	Since   string
+	Until   string
+	Limit   int
+}
+
+type traceIndex struct {
+	Size   int64             `json:"size"`
+	Head   []byte            `json:"head"` // first bytes of the file, to notice a rotation
+	Blocks []traceIndexBlock `json:"blocks"`
+}
+
+type traceIndexBlock struct {
+	Offset int64    `json:"offset"`
+	Length int64    `json:"length"`
+	Lines  int      `json:"lines"`
+	MinTS  string   `json:"min_ts"`
+	MaxTS  string   `json:"max_ts"`
+	Types  []string `json:"types"`
+	Actors []string `json:"actors"`
+}
+
+func (b *traceIndexBlock) mayMatch(q TraceQuery, since, until string) bool {
+	if since != "" && b.MaxTS < since || until != "" && b.MinTS > until {
+		return false
+	}
+	return (q.Type == "" || containsString(b.Types, q.Type)) && (q.Actor == "" || containsString(b.Actors, q.Actor))
+}
+
+func containsString(list []string, v string) bool {
+	i := sort.SearchStrings(list, v)
+	return i < len(list) && list[i] == v
+}
+
+// Query returns matching trace events, newest first, from trace/events.jsonl and the rotated segments.
+func (t *TraceService) Query(q TraceQuery) ([]TraceEvent, error) {
+	since, err := normalizeAuditTime("since", q.Since)
+	if err != nil {
+		return nil, err
+	}
+	until, err := normalizeAuditTime("until", q.Until)
+	if err != nil {
+		return nil, err
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = traceDefaultLimit
+	}
+	if limit > traceMaxLimit {
+		limit = traceMaxLimit
+	}
+
+	match := func(e TraceEvent) bool {
+		return (q.Type == "" || e.Type == q.Type) &&
+			(q.Actor == "" || e.Actor == q.Actor) &&
+			(q.Subject == "" || e.Subject == q.Subject) &&
+			(since == "" || e.Timestamp >= since) &&
+			(until == "" || e.Timestamp <= until)
+	}
+	out := []TraceEvent{}
+	takeNewestFirst := func(matched []TraceEvent) {
+		for j := len(matched) - 1; j >= 0 && len(out) < limit; j-- {
+			out = append(out, matched[j])
+		}
+	}
+
+	path := t.store.Path("trace", "events.jsonl")
+	idx, err := refreshTraceIndex(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		f, err := os.Open(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			defer f.Close()
+			for i := len(idx.Blocks) - 1; i >= 0 && len(out) < limit; i-- {
+				b := idx.Blocks[i]
+				if !b.mayMatch(q, since, until) {
+					continue
+				}
+				buf := make([]byte, b.Length)
+				if _, err := f.ReadAt(buf, b.Offset); err != nil && err != io.EOF {
+					return nil, err
+				}
+				var matched []TraceEvent
+				for _, line := range bytes.Split(buf, []byte("\n")) {
+					var e TraceEvent
+					if len(line) > 0 && json.Unmarshal(line, &e) == nil && match(e) {
+						matched = append(matched, e)
+					}
+				}
+				takeNewestFirst(matched)
+			}
+		}
+	}
+
+	segs := traceSegments(t.store.Path("trace"))
+	for i := len(segs) - 1; i >= 0 && len(out) < limit; i-- {
+		// Segment names carry the rotation time, which bounds their newest event.
+		if rotated, ok := traceSegmentTime(segs[i]); ok && since != "" && rotated < since {
+			break
+		}
+		var matched []TraceEvent
+		if err := readTraceSegment(segs[i], func(e TraceEvent) {
+			if match(e) {
+				matched = append(matched, e)
+			}
+		}); err != nil {
+			return nil, err
+		}
+		takeNewestFirst(matched)
+	}
+	return out, nil
+}
+
+// traceSegmentTime returns the rotation time (RFC3339) encoded in a segment's file name.
+func traceSegmentTime(path string) (string, bool) {
+	name := strings.TrimPrefix(filepath.Base(path), traceSegmentPrefix)
+	if len(name) < len("20060102T150405Z") {
+		return "", false
+	}
+	ts, err := time.Parse("20060102T150405Z", name[:len("20060102T150405Z")])
+	if err != nil {
+		return "", false
+	}
+	return ts.UTC().Format(time.RFC3339), true
+}
+
+// refreshTraceIndex loads path's index and extends it over lines appended since, rebuilding it when the
+// file no longer starts with the indexed bytes or got shorter. Only complete lines are indexed; the index
+// is saved best-effort (a concurrent writer just means the work is redone).
+func refreshTraceIndex(path string) (*traceIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	head := make([]byte, traceIndexHeadBytes)
+	n, _ := f.ReadAt(head, 0)
+	head = head[:n]
+
+	idxPath := path + ".idx"
+	idx := &traceIndex{}
+	if data, err := os.ReadFile(idxPath); err == nil {
+		_ = json.Unmarshal(data, idx)
+	}
+	if idx.Size > info.Size() || !bytes.HasPrefix(head, idx.Head) {
+		idx = &traceIndex{}
+	}
+	if idx.Size == info.Size() && len(idx.Head) == min(len(head), traceIndexHeadBytes) {
+		return idx, nil
+	}
+
+	// Re-open the last block if it is not full, so blocks keep growing to traceIndexBlockLines.
+	start := idx.Size
+	if k := len(idx.Blocks); k > 0 && idx.Blocks[k-1].Lines < traceIndexBlockLines {
+		start = idx.Blocks[k-1].Offset
+		idx.Blocks = idx.Blocks[:k-1]
+	}
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	offset := start
+	var cur *traceIndexBlock
+	types, actors := map[string]bool{}, map[string]bool{}
+	flush := func() {
+		if cur == nil {
+			return
+		}
+		cur.Types, cur.Actors = sortedSet(types), sortedSet(actors)
+		idx.Blocks = append(idx.Blocks, *cur)
+		cur = nil
+		types, actors = map[string]bool{}, map[string]bool{}
+	}
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			break // EOF, or a partial line still being written: index it next time
+		}
+		if cur == nil {
+			cur = &traceIndexBlock{Offset: offset}
+		}
+		offset += int64(len(line))
+		cur.Length += int64(len(line))
+		cur.Lines++
+		var e TraceEvent
+		if json.Unmarshal(line, &e) == nil {
+			types[e.Type] = true
+			actors[e.Actor] = true
+			if cur.MinTS == "" || e.Timestamp < cur.MinTS {
+				cur.MinTS = e.Timestamp
+			}
+			if e.Timestamp > cur.MaxTS {
+				cur.MaxTS = e.Timestamp
+			}
+		}
+		if cur.Lines >= traceIndexBlockLines {
+			flush()
+		}
+	}
+	flush()
+	idx.Size = offset
+	idx.Head = head
+	if int64(len(idx.Head)) > offset {
+		idx.Head = idx.Head[:offset]
+	}
+	if data, err := json.Marshal(idx); err == nil {
+		tmp := idxPath + "." + GenID("w") + ".tmp"
+		if os.WriteFile(tmp, data, 0o644) == nil {
+			if os.Rename(tmp, idxPath) != nil {
+				_ = os.Remove(tmp)
+			}
+		}
+	}
+	return idx, nil
+}
+
+func sortedSet(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,49 @@
+package swarm
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTraceQuery_FiltersWithIndex(t *testing.T) {
+	store := NewStore(t.TempDir())
+	trace := NewTraceService(store)
+	for i := 0; i < traceIndexBlockLines+10; i++ {
+		trace.Log(TraceEvent{Type: EventLockAcquired, Actor: "w1", Subject: fmt.Sprintf("lease-%d", i), Timestamp: "2024-05-01T10:00:00Z"})
+	}
+	trace.Log(TraceEvent{Type: EventLockForced, Actor: "w2", Subject: "lease-x", Timestamp: "2024-05-01T23:00:00Z"})
+
+	events, err := trace.Query(TraceQuery{Type: EventLockForced, Since: "2024-05-01T18:00:00Z"})
+	if err != nil || len(events) != 1 || events[0].Actor != "w2" {
+		t.Fatalf("expected the forced unlock, got %+v %v", events, err)
+	}
+	if !store.Exists("trace", "events.jsonl.idx") {
+		t.Fatalf("expected a sidecar index")
+	}
+
+	// The index is extended for new lines; newest first.
+	trace.Log(TraceEvent{Type: EventLockForced, Actor: "w3", Subject: "lease-y", Timestamp: "2024-05-02T01:00:00Z"})
+	events, err = trace.Query(TraceQuery{Type: EventLockForced})
+	if err != nil || len(events) != 2 || events[0].Actor != "w3" {
+		t.Fatalf("expected both forced unlocks newest first, got %+v %v", events, err)
+	}
+	if events, _ := trace.Query(TraceQuery{Actor: "w1", Limit: 5}); len(events) != 5 || events[0].Subject != fmt.Sprintf("lease-%d", traceIndexBlockLines+9) {
+		t.Fatalf("expected the newest five w1 events, got %+v", events)
+	}
+
+	// After a rotation the compressed segment is read too and the index starts over.
+	if seg, err := trace.Rotate(); err != nil || seg == "" {
+		t.Fatalf("rotate: %q %v", seg, err)
+	}
+	trace.Log(TraceEvent{Type: EventLockForced, Actor: "w4", Subject: "lease-z", Timestamp: "2024-05-03T01:00:00Z"})
+	events, err = trace.Query(TraceQuery{Type: EventLockForced})
+	if err != nil || len(events) != 3 || events[0].Actor != "w4" || events[2].Actor != "w2" {
+		t.Fatalf("expected events from both files, got %+v %v", events, err)
+	}
+	if events, _ := trace.Query(TraceQuery{Type: EventLockForced, Since: "2099-01-01T00:00:00Z"}); len(events) != 0 {
+		t.Fatalf("expected segments rotated before since to be skipped, got %+v", events)
+	}
+	if _, err := trace.Query(TraceQuery{Since: "yesterday"}); err == nil {
+		t.Fatalf("expected a bad since to fail")
+	}
+}
@@ -0,0 +1,244 @@
+package swarm
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Trace log rotation. Once trace/events.jsonl is larger than MaxBytes, or its first event is older than
+// MaxAgeSec, it is moved to trace/events-<time>.jsonl and compressed to events-<time>.jsonl.gz; only the
+// newest Keep segments are kept. Rotation runs in the background after a Log call and is serialized
+// across processes by trace/.rotate.lock.
+
+// TraceRotation configures trace log rotation. Zero values disable a rule.
+type TraceRotation struct {
+	MaxBytes      int64
+	MaxAgeSec     int
+	Keep          int      // compressed segments kept (0 = all)
+	DisabledTypes []string // event types that are not written at all (e.g. lock_heartbeat)
+}
+
+const traceSegmentPrefix = "events-"
+
+// SetRotation configures rotation and disabled event types. Call before serving requests.
+func (t *TraceService) SetRotation(r TraceRotation) {
+	t.rot = r
+	t.disabled = map[string]bool{}
+	for _, typ := range r.DisabledTypes {
+		if typ = strings.TrimSpace(typ); typ != "" {
+			t.disabled[typ] = true
+		}
+	}
+}
+
+// maybeRotate starts a background rotation when the open log f has outgrown the limits.
+func (t *TraceService) maybeRotate(f *os.File) {
+	if t.rot.MaxBytes <= 0 && t.rot.MaxAgeSec <= 0 {
+		return
+	}
+	info, err := f.Stat()
+	if err != nil || !t.needsRotation(info) {
+		return
+	}
+	if !t.rotating.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer t.rotating.Store(false)
+		_, _ = t.rotateIf(true)
+	}()
+}
+
+func (t *TraceService) needsRotation(info os.FileInfo) bool {
+	if t.rot.MaxBytes > 0 && info.Size() > t.rot.MaxBytes {
+		return true
+	}
+	if t.rot.MaxAgeSec <= 0 {
+		return false
+	}
+	start := t.segmentStart(info)
+	if start == "" {
+		return false
+	}
+	ts, err := time.Parse(time.RFC3339, start)
+	return err == nil && time.Since(ts) > time.Duration(t.rot.MaxAgeSec)*time.Second
+}
+
+// segmentStart returns the timestamp of the first event in the current log, cached per file.
+func (t *TraceService) segmentStart(info os.FileInfo) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.segInfo != nil && os.SameFile(t.segInfo, info) {
+		return t.segStart
+	}
+	f, err := os.Open(t.store.Path("trace", "events.jsonl"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil {
+		return ""
+	}
+	var e TraceEvent
+	if json.Unmarshal(line, &e) != nil {
+		return ""
+	}
+	t.segInfo, t.segStart = info, e.Timestamp
+	return e.Timestamp
+}
+
+// Rotate moves the current trace log into a compressed segment regardless of the limits and returns
+// the segment path ("" when there was nothing to rotate or another process is rotating).
+func (t *TraceService) Rotate() (string, error) {
+	return t.rotateIf(false)
+}
+
+func (t *TraceService) rotateIf(checkLimits bool) (string, error) {
+	dir := t.store.EnsureDir("trace")
+	lf, err := os.OpenFile(filepath.Join(dir, ".rotate.lock"), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return "", err
+	}
+	defer lf.Close()
+	if err := syscall.Flock(int(lf.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		return "", nil
+	}
+	defer syscall.Flock(int(lf.Fd()), syscall.LOCK_UN)
+
+	path := filepath.Join(dir, "events.jsonl")
+	info, err := os.Stat(path)
+	if err != nil || info.Size() == 0 {
+		return "", nil
+	}
+	// Another process may have rotated since the check that started us.
+	if checkLimits && !t.needsRotation(info) {
+		return "", nil
+	}
+	id := GenID("seg")
+	seg := filepath.Join(dir, traceSegmentPrefix+time.Now().UTC().Format("20060102T150405Z")+"-"+id[len(id)-4:]+".jsonl")
+	if err := os.Rename(path, seg); err != nil {
+		return "", err
+	}
+	_ = os.Remove(path + ".idx")
+	gz, err := gzipFile(seg)
+	if err != nil {
+		return seg, err
+	}
+	t.pruneSegments(dir)
+	return gz, nil
+}
+
+// gzipFile compresses path to path.gz and removes path.
+func gzipFile(path string) (string, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	tmp := path + ".gz.tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return "", err
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+".gz")
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	_ = os.Remove(path)
+	return path + ".gz", nil
+}
+
+func (t *TraceService) pruneSegments(dir string) {
+	if t.rot.Keep <= 0 {
+		return
+	}
+	segs := traceSegments(dir)
+	for len(segs) > t.rot.Keep {
+		_ = os.Remove(segs[0])
+		segs = segs[1:]
+	}
+}
+
+// traceSegments lists rotated segments (compressed, or not yet compressed), oldest first.
+func traceSegments(dir string) []string {
+	return logSegments(dir, traceSegmentPrefix)
+}
+
+// logSegments lists the rotated segments named prefix<time>...jsonl[.gz] in dir, oldest first.
+func logSegments(dir, prefix string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	names := map[string]bool{}
+	for _, e := range entries {
+		names[e.Name()] = true
+	}
+	var segs []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, prefix) || names[name+".gz"] {
+			continue // a plain segment next to its .gz was compressed but not yet removed
+		}
+		if strings.HasSuffix(name, ".jsonl") || strings.HasSuffix(name, ".jsonl.gz") {
+			segs = append(segs, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(segs)
+	return segs
+}
+
+// readTraceSegment streams a rotated segment's events to fn in file order.
+func readTraceSegment(path string, fn func(TraceEvent)) error {
+	return scanLogSegment(path, func(line []byte) {
+		var e TraceEvent
+		if json.Unmarshal(line, &e) == nil {
+			fn(e)
+		}
+	})
+}
+
+// scanLogSegment streams the lines of a JSONL log, gzip-compressed when path ends in .gz, to fn.
+func scanLogSegment(path string, fn func(line []byte)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		fn(sc.Bytes())
+	}
+	return sc.Err()
+}
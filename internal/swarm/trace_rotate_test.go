@@ -0,0 +1,52 @@
+package swarm
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTraceRotation_SizeCapGzipAndKeep(t *testing.T) {
+	store := NewStore(t.TempDir())
+	trace := NewTraceService(store)
+	trace.SetRotation(TraceRotation{MaxBytes: 1024, Keep: 2, DisabledTypes: []string{EventLockHeartbeat}})
+
+	trace.Log(TraceEvent{Type: EventLockHeartbeat, Actor: "w1"})
+	if store.Exists("trace", "events.jsonl") {
+		t.Fatalf("expected disabled event types not to be written")
+	}
+
+	for i := 0; i < 20; i++ {
+		trace.Log(TraceEvent{Type: EventLockAcquired, Actor: "w1", Subject: strings.Repeat("f", 100)})
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(traceSegments(store.Path("trace"))) == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	segs := traceSegments(store.Path("trace"))
+	if len(segs) == 0 || !strings.HasSuffix(segs[0], ".jsonl.gz") {
+		t.Fatalf("expected a compressed segment after passing the size cap, got %v", segs)
+	}
+	for trace.rotating.Load() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for i := 0; i < 3; i++ {
+		trace.Log(TraceEvent{Type: EventLockReleased, Actor: "w1"})
+		time.Sleep(1100 * time.Millisecond) // segment names have second resolution
+		if _, err := trace.Rotate(); err != nil {
+			t.Fatalf("rotate: %v", err)
+		}
+	}
+	if segs := traceSegments(store.Path("trace")); len(segs) != 2 {
+		t.Fatalf("expected only the newest two segments kept, got %v", segs)
+	}
+	events, err := trace.Query(TraceQuery{Type: EventLockReleased})
+	if err != nil || len(events) != 2 {
+		t.Fatalf("expected the kept segments to be queryable, got %+v %v", events, err)
+	}
+	if _, err := os.Stat(store.Path("trace", "events.jsonl")); !os.IsNotExist(err) {
+		t.Fatalf("expected no current log right after a rotation")
+	}
+}
@@ -0,0 +1,272 @@
+package swarm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"log/syslog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TraceSink receives every trace event besides the local trace/events.jsonl (syslog, an HTTP
+// collector, OTLP logs). Trace is called on the caller's goroutine, often under store lock, so
+// implementations must not block.
+type TraceSink interface {
+	Trace(event TraceEvent)
+}
+
+// AddSink registers sink. Nil sinks are ignored. Call before serving requests.
+func (t *TraceService) AddSink(sink TraceSink) {
+	if sink == nil {
+		return
+	}
+	t.sinks = append(t.sinks, sink)
+}
+
+// TraceSinkConfig configures the trace sinks. Empty fields disable a sink.
+type TraceSinkConfig struct {
+	SyslogAddr   string            // "local" for the local syslog daemon, or udp://host:514 / tcp://host:514
+	SyslogTag    string            // default "swarm-mcp"
+	HTTPURL      string            // POST {"events": [...]} batches here
+	HTTPHeaders  map[string]string // e.g. Authorization
+	OTLPEndpoint string            // OTLP/HTTP logs endpoint (JSON encoding); /v1/logs is appended to a bare host
+	OTLPHeaders  map[string]string
+	ServiceName  string // OTLP service.name (default "swarm-mcp")
+}
+
+const (
+	traceSinkBatch   = 100
+	traceSinkFlush   = 2 * time.Second
+	traceSinkQueue   = 1024
+	traceSinkTimeout = 10 * time.Second
+	traceSinkService = "swarm-mcp"
+)
+
+// NewTraceSinks builds (and starts) the configured sinks. A sink that cannot be set up (e.g. syslog is
+// unreachable) is reported as an error; the others are still returned.
+func NewTraceSinks(cfg TraceSinkConfig, logger *log.Logger) ([]TraceSink, error) {
+	var sinks []TraceSink
+	var errs []string
+	if addr := strings.TrimSpace(cfg.SyslogAddr); addr != "" {
+		s, err := newSyslogTraceSink(addr, cfg.SyslogTag, logger)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("syslog trace sink: %v", err))
+		} else {
+			sinks = append(sinks, s)
+		}
+	}
+	if u := strings.TrimSpace(cfg.HTTPURL); u != "" {
+		h := &httpTraceSink{url: u, headers: cfg.HTTPHeaders, client: &http.Client{Timeout: traceSinkTimeout}}
+		sinks = append(sinks, newBatchTraceSink("http", h.send, logger))
+	}
+	if u := strings.TrimSpace(cfg.OTLPEndpoint); u != "" {
+		o := &otlpTraceSink{url: otlpLogsURL(u), headers: cfg.OTLPHeaders, service: cfg.ServiceName, client: &http.Client{Timeout: traceSinkTimeout}}
+		if o.service == "" {
+			o.service = traceSinkService
+		}
+		sinks = append(sinks, newBatchTraceSink("otlp", o.send, logger))
+	}
+	if len(errs) > 0 {
+		return sinks, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return sinks, nil
+}
+
+// ParseHeaderList parses "k1=v1,k2=v2" (the OTEL_EXPORTER_OTLP_HEADERS format).
+func ParseHeaderList(s string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(part, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, Errorf(CodeInvalidArgument, "header %q must be key=value", part)
+		}
+		if dec, err := url.QueryUnescape(strings.TrimSpace(v)); err == nil {
+			v = dec
+		}
+		out[strings.TrimSpace(k)] = v
+	}
+	return out, nil
+}
+
+// batchTraceSink queues events and hands them to send in batches from a background goroutine, dropping
+// (and logging) events when the queue is full so a slow collector never blocks a lock operation.
+type batchTraceSink struct {
+	name   string
+	send   func([]TraceEvent) error
+	logger *log.Logger
+	queue  chan TraceEvent
+}
+
+func newBatchTraceSink(name string, send func([]TraceEvent) error, logger *log.Logger) *batchTraceSink {
+	b := &batchTraceSink{name: name, send: send, logger: logger, queue: make(chan TraceEvent, traceSinkQueue)}
+	go b.loop()
+	return b
+}
+
+func (b *batchTraceSink) Trace(event TraceEvent) {
+	select {
+	case b.queue <- event:
+	default:
+		if b.logger != nil {
+			b.logger.Printf("WARNING: %s trace sink queue full; dropping %s", b.name, event.Type)
+		}
+	}
+}
+
+func (b *batchTraceSink) loop() {
+	ticker := time.NewTicker(traceSinkFlush)
+	defer ticker.Stop()
+	var batch []TraceEvent
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := b.send(batch); err != nil && b.logger != nil {
+			b.logger.Printf("WARNING: %s trace sink: dropping %d event(s): %v", b.name, len(batch), err)
+		}
+		batch = nil
+	}
+	for {
+		select {
+		case e := <-b.queue:
+			batch = append(batch, e)
+			if len(batch) >= traceSinkBatch {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func newSyslogTraceSink(addr, tag string, logger *log.Logger) (TraceSink, error) {
+	if tag == "" {
+		tag = traceSinkService
+	}
+	network, raddr := "", ""
+	if addr != "local" {
+		u, err := url.Parse(addr)
+		if err != nil || (u.Scheme != "udp" && u.Scheme != "tcp") || u.Host == "" {
+			return nil, Errorf(CodeInvalidArgument, "address must be \"local\" or udp://host:port / tcp://host:port (got %q)", addr)
+		}
+		network, raddr = u.Scheme, u.Host
+	}
+	w, err := syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, err
+	}
+	return newBatchTraceSink("syslog", func(events []TraceEvent) error {
+		for _, e := range events {
+			line, _ := json.Marshal(e)
+			if err := w.Info(string(line)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, logger), nil
+}
+
+type httpTraceSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (h *httpTraceSink) send(events []TraceEvent) error {
+	body, err := json.Marshal(map[string]any{"events": events})
+	if err != nil {
+		return err
+	}
+	return postTraceBatch(h.client, h.url, h.headers, body)
+}
+
+// otlpTraceSink exports events as OTLP log records over HTTP with the JSON encoding.
+type otlpTraceSink struct {
+	url     string
+	headers map[string]string
+	service string
+	client  *http.Client
+}
+
+func otlpLogsURL(endpoint string) string {
+	endpoint = strings.TrimRight(endpoint, "/")
+	if u, err := url.Parse(endpoint); err == nil && (u.Path == "" || u.Path == "/") {
+		return endpoint + "/v1/logs"
+	}
+	return endpoint
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpAttr struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+func (o *otlpTraceSink) send(events []TraceEvent) error {
+	records := make([]map[string]any, 0, len(events))
+	for _, e := range events {
+		ts, err := time.Parse(time.RFC3339, e.Timestamp)
+		if err != nil {
+			ts = time.Now()
+		}
+		attrs := []otlpAttr{{"event.id", otlpValue{e.ID}}, {"event.type", otlpValue{e.Type}}}
+		for _, kv := range [][2]string{{"actor", e.Actor}, {"subject", e.Subject}, {"detail", e.Detail}} {
+			if kv[1] != "" {
+				attrs = append(attrs, otlpAttr{kv[0], otlpValue{kv[1]}})
+			}
+		}
+		records = append(records, map[string]any{
+			"timeUnixNano":   fmt.Sprintf("%d", ts.UnixNano()),
+			"severityNumber": 9, // INFO
+			"severityText":   "INFO",
+			"body":           otlpValue{e.Type},
+			"attributes":     attrs,
+		})
+	}
+	body, err := json.Marshal(map[string]any{
+		"resourceLogs": []any{map[string]any{
+			"resource": map[string]any{"attributes": []otlpAttr{{"service.name", otlpValue{o.service}}}},
+			"scopeLogs": []any{map[string]any{
+				"scope":      map[string]any{"name": "swarm-mcp/trace"},
+				"logRecords": records,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	return postTraceBatch(o.client, o.url, o.headers, body)
+}
+
+func postTraceBatch(client *http.Client, target string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: status %d: %s", target, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
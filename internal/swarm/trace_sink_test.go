@@ -0,0 +1,84 @@
+package swarm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTraceSinks_HTTPAndOTLP(t *testing.T) {
+	got := make(chan *http.Request, 4)
+	bodies := make(chan []byte, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var raw json.RawMessage
+		_ = json.NewDecoder(r.Body).Decode(&raw)
+		got <- r
+		bodies <- raw
+	}))
+	defer srv.Close()
+
+	sinks, err := NewTraceSinks(TraceSinkConfig{
+		HTTPURL:      srv.URL + "/events",
+		HTTPHeaders:  map[string]string{"Authorization": "Bearer t"},
+		OTLPEndpoint: srv.URL,
+		ServiceName:  "swarm-test",
+	}, nil)
+	if err != nil || len(sinks) != 2 {
+		t.Fatalf("new sinks: %d %v", len(sinks), err)
+	}
+	trace := NewTraceService(NewStore(t.TempDir()))
+	for _, s := range sinks {
+		trace.AddSink(s)
+	}
+	trace.Log(TraceEvent{Type: "lock_forced", Actor: "lead", Subject: "src/a.go"})
+
+	seen := map[string]string{}
+	for len(seen) < 2 {
+		select {
+		case r := <-got:
+			seen[r.URL.Path] = string(<-bodies)
+			if r.URL.Path == "/events" && r.Header.Get("Authorization") != "Bearer t" {
+				t.Fatalf("expected the configured header, got %q", r.Header.Get("Authorization"))
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for sink batches, got %v", seen)
+		}
+	}
+	var batch struct {
+		Events []TraceEvent `json:"events"`
+	}
+	if err := json.Unmarshal([]byte(seen["/events"]), &batch); err != nil || len(batch.Events) != 1 || batch.Events[0].Type != "lock_forced" {
+		t.Fatalf("unexpected http batch %s: %v", seen["/events"], err)
+	}
+	var otlp struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []otlpAttr `json:"attributes"`
+			} `json:"resource"`
+			ScopeLogs []struct {
+				LogRecords []struct {
+					Body otlpValue `json:"body"`
+				} `json:"logRecords"`
+			} `json:"scopeLogs"`
+		} `json:"resourceLogs"`
+	}
+	if err := json.Unmarshal([]byte(seen["/v1/logs"]), &otlp); err != nil || len(otlp.ResourceLogs) != 1 {
+		t.Fatalf("unexpected otlp payload %s: %v", seen["/v1/logs"], err)
+	}
+	rl := otlp.ResourceLogs[0]
+	if rl.Resource.Attributes[0].Value.StringValue != "swarm-test" || rl.ScopeLogs[0].LogRecords[0].Body.StringValue != "lock_forced" {
+		t.Fatalf("unexpected otlp payload %s", seen["/v1/logs"])
+	}
+}
+
+func TestParseHeaderList(t *testing.T) {
+	h, err := ParseHeaderList("Authorization=Bearer%20abc, x-team=a=b")
+	if err != nil || h["Authorization"] != "Bearer abc" || h["x-team"] != "a=b" {
+		t.Fatalf("unexpected headers %v %v", h, err)
+	}
+	if _, err := ParseHeaderList("novalue"); err == nil {
+		t.Fatalf("expected an entry without = to fail")
+	}
+}
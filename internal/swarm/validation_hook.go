@@ -0,0 +1,204 @@
+package swarm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validation hooks. Operators can enforce their own acceptance policies (licence checks, forbidden
+// paths, ...) without forking: each hook is a command (run via "sh -c", payload on stdin) or an HTTP
+// endpoint (payload POSTed as JSON), invoked before a submission or delivery is accepted. A command
+// blocks with a non-zero exit, an endpoint with a non-2xx status or {"allow": false}; the hook's
+// output or message is returned to the caller. Hooks run outside the store lock, in file order, and
+// the first one that blocks wins. A hook that cannot be run or reached blocks too (fail closed).
+
+const (
+	HookOnSubmission = "submission"
+	HookOnDelivery   = "delivery"
+)
+
+// maxHookMessageBytes caps the hook output quoted back to the caller (the tail is kept).
+const maxHookMessageBytes = 2048
+
+type ValidationHook struct {
+	Name       string   `json:"name"`
+	On         []string `json:"on,omitempty"` // submission/delivery; empty = both
+	Command    string   `json:"command,omitempty"`
+	Workdir    string   `json:"workdir,omitempty"`
+	URL        string   `json:"url,omitempty"`
+	TimeoutSec int      `json:"timeout_sec,omitempty"`
+}
+
+// ValidationHookConfig is the hook file: {"hooks": [...]}.
+type ValidationHookConfig struct {
+	Hooks []ValidationHook `json:"hooks"`
+}
+
+// ValidationPayload is what a hook receives.
+type ValidationPayload struct {
+	Event     string `json:"event"` // submission/delivery
+	IssueID   string `json:"issue_id"`
+	TaskID    string `json:"task_id,omitempty"`
+	Actor     string `json:"actor"`
+	Summary   string `json:"summary,omitempty"`
+	Artifacts any    `json:"artifacts"`
+	Timestamp string `json:"timestamp"`
+}
+
+// hookVerdict is the optional JSON body of an endpoint's response.
+type hookVerdict struct {
+	Allow   *bool  `json:"allow"`
+	Message string `json:"message"`
+}
+
+// ParseValidationHooks parses and validates a validation hook file.
+func ParseValidationHooks(bs []byte) (ValidationHookConfig, error) {
+	var cfg ValidationHookConfig
+	if err := json.Unmarshal(bs, &cfg); err != nil {
+		return ValidationHookConfig{}, Errorf(CodeInvalidArgument, "invalid validation hooks: %w", err)
+	}
+	for i, h := range cfg.Hooks {
+		h.Name = strings.TrimSpace(h.Name)
+		h.Command = strings.TrimSpace(h.Command)
+		h.URL = strings.TrimSpace(h.URL)
+		if h.Name == "" {
+			return ValidationHookConfig{}, Errorf(CodeInvalidArgument, "invalid validation hooks: hooks[%d].name is required", i)
+		}
+		if (h.Command == "") == (h.URL == "") {
+			return ValidationHookConfig{}, Errorf(CodeInvalidArgument, "invalid validation hooks: %s needs exactly one of command or url", h.Name)
+		}
+		if h.URL != "" && !strings.HasPrefix(h.URL, "http://") && !strings.HasPrefix(h.URL, "https://") {
+			return ValidationHookConfig{}, Errorf(CodeInvalidArgument, "invalid validation hooks: %s: url must be http(s)", h.Name)
+		}
+		for _, on := range h.On {
+			if on != HookOnSubmission && on != HookOnDelivery {
+				return ValidationHookConfig{}, Errorf(CodeInvalidArgument, "invalid validation hooks: %s: on must list %s or %s (got %q)", h.Name, HookOnSubmission, HookOnDelivery, on)
+			}
+		}
+		if h.TimeoutSec <= 0 {
+			h.TimeoutSec = 30
+		}
+		cfg.Hooks[i] = h
+	}
+	return cfg, nil
+}
+
+// SetValidationHooks sets the hooks run before submissions and deliveries are accepted; logger receives
+// hook failures. An empty config turns them off.
+func (s *IssueService) SetValidationHooks(cfg ValidationHookConfig, logger *log.Logger) {
+	s.hooks = cfg.Hooks
+	s.hookLogger = logger
+}
+
+func (h ValidationHook) runsOn(event string) bool {
+	if len(h.On) == 0 {
+		return true
+	}
+	for _, on := range h.On {
+		if on == event {
+			return true
+		}
+	}
+	return false
+}
+
+// runValidationHooks runs the hooks for p.Event and returns the first block as an error.
+// Must NOT be called under store lock.
+func (s *IssueService) runValidationHooks(ctx context.Context, p ValidationPayload) error {
+	var body []byte
+	for _, h := range s.hooks {
+		if !h.runsOn(p.Event) {
+			continue
+		}
+		if body == nil {
+			p.Timestamp = NowStr()
+			var err error
+			if body, err = json.Marshal(p); err != nil {
+				return err
+			}
+		}
+		hctx, cancel := context.WithTimeout(ctx, time.Duration(h.TimeoutSec)*time.Second)
+		var err error
+		if h.Command != "" {
+			err = h.runCommand(hctx, body)
+		} else {
+			err = h.post(hctx, body)
+		}
+		cancel()
+		if err != nil {
+			if ErrorCode(err) == CodeUnavailable && s.hookLogger != nil {
+				s.hookLogger.Printf("WARNING: validation hook %s: %v", h.Name, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (h ValidationHook) runCommand(ctx context.Context, body []byte) error {
+	var buf bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.Dir = h.Workdir
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return Errorf(CodeUnavailable, "validation hook '%s' timed out after %ds", h.Name, h.TimeoutSec).With("hook", h.Name)
+	case errors.As(err, &exitErr):
+		return h.blocked(buf.String(), "exit code "+strconv.Itoa(exitErr.ExitCode()))
+	case err != nil:
+		return Errorf(CodeUnavailable, "validation hook '%s' could not run: %w", h.Name, err).With("hook", h.Name)
+	}
+	return nil
+}
+
+func (h ValidationHook) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return Errorf(CodeUnavailable, "validation hook '%s': %w", h.Name, err).With("hook", h.Name)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Errorf(CodeUnavailable, "validation hook '%s' unreachable: %w", h.Name, err).With("hook", h.Name)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var v hookVerdict
+	_ = json.Unmarshal(respBody, &v)
+	msg := v.Message
+	if msg == "" {
+		msg = string(respBody)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return h.blocked(msg, "status "+strconv.Itoa(resp.StatusCode))
+	}
+	if v.Allow != nil && !*v.Allow {
+		return h.blocked(msg, "allow=false")
+	}
+	return nil
+}
+
+// blocked reports a hook's refusal with its message (or, without one, how it refused).
+func (h ValidationHook) blocked(msg, how string) error {
+	msg = strings.TrimSpace(msg)
+	if len(msg) > maxHookMessageBytes {
+		msg = "…" + msg[len(msg)-maxHookMessageBytes:]
+	}
+	if msg == "" {
+		msg = how
+	}
+	return Errorf(CodeInvalidArgument, "blocked by validation hook '%s': %s", h.Name, msg).With("hook", h.Name)
+}
@@ -0,0 +1,76 @@
+package swarm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidationHooks_CommandAndEndpoint(t *testing.T) {
+	var got ValidationPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		if strings.Contains(got.Summary, "gpl") {
+			_, _ = w.Write([]byte(`{"allow":false,"message":"GPL code is not allowed"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"allow":true}`))
+	}))
+	defer srv.Close()
+
+	cfg, err := ParseValidationHooks([]byte(`{"hooks":[
+		{"name":"paths","on":["submission"],"command":"if grep -q secrets/; then echo 'secrets/ is off limits'; exit 1; fi"},
+		{"name":"licence","url":"` + srv.URL + `"}
+	]}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	svc := NewIssueService(NewStore(t.TempDir()), nil, 7200, 3600, 3600, 3600)
+	svc.SetValidationHooks(cfg, nil)
+	ctx := context.Background()
+
+	sub := ValidationPayload{Event: HookOnSubmission, IssueID: "issue-1", TaskID: "task-1", Actor: "w1", Summary: "done",
+		Artifacts: SubmissionArtifacts{ChangedFiles: []string{"secrets/key.pem"}}}
+	err = svc.runValidationHooks(ctx, sub)
+	if ErrorCode(err) != CodeInvalidArgument || !strings.Contains(err.Error(), "secrets/ is off limits") {
+		t.Fatalf("expected the command hook to block with its output, got %v", err)
+	}
+	sub.Artifacts = SubmissionArtifacts{ChangedFiles: []string{"main.go"}}
+	if err := svc.runValidationHooks(ctx, sub); err != nil {
+		t.Fatalf("expected the submission to pass, got %v", err)
+	}
+	if got.TaskID != "task-1" || got.Event != HookOnSubmission {
+		t.Fatalf("unexpected payload at the endpoint: %+v", got)
+	}
+
+	// The command hook only runs on submissions; the endpoint blocks the delivery.
+	del := ValidationPayload{Event: HookOnDelivery, IssueID: "issue-1", Actor: "lead", Summary: "vendors a gpl lib",
+		Artifacts: DeliveryArtifacts{ChangedFiles: []string{"secrets/key.pem"}}}
+	err = svc.runValidationHooks(ctx, del)
+	if ErrorCode(err) != CodeInvalidArgument || !strings.Contains(err.Error(), "GPL code is not allowed") {
+		t.Fatalf("expected the endpoint to block the delivery, got %v", err)
+	}
+
+	srv.Close()
+	del.Summary = "clean"
+	if err := svc.runValidationHooks(ctx, del); ErrorCode(err) != CodeUnavailable {
+		t.Fatalf("expected an unreachable hook to block, got %v", err)
+	}
+}
+
+func TestParseValidationHooks_Validates(t *testing.T) {
+	for _, bad := range []string{
+		`{"hooks":[{"command":"true"}]}`,
+		`{"hooks":[{"name":"x"}]}`,
+		`{"hooks":[{"name":"x","command":"true","url":"http://h"}]}`,
+		`{"hooks":[{"name":"x","url":"ftp://h"}]}`,
+		`{"hooks":[{"name":"x","command":"true","on":["review"]}]}`,
+	} {
+		if _, err := ParseValidationHooks([]byte(bad)); ErrorCode(err) != CodeInvalidArgument {
+			t.Fatalf("expected %s to be rejected, got %v", bad, err)
+		}
+	}
+}
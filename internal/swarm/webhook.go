@@ -0,0 +1,155 @@
+package swarm
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	EventDeliveryCreated  = "delivery_created"
+	EventDeliveryReviewed = "delivery_reviewed"
+)
+
+// webhookQueueSize bounds pending deliveries; events are dropped (and logged) when the queue is full
+// so a slow receiver never blocks a tool call.
+const webhookQueueSize = 256
+
+// WebhookTarget is one receiver. Events lists the event types to send ("*" or empty = all).
+type WebhookTarget struct {
+	URL        string   `json:"url"`
+	Events     []string `json:"events"`
+	Secret     string   `json:"secret"`
+	TimeoutSec int      `json:"timeout_sec"`
+}
+
+type WebhookConfig struct {
+	Webhooks []WebhookTarget `json:"webhooks"`
+}
+
+// WebhookPayload is the JSON body POSTed to receivers.
+type WebhookPayload struct {
+	ID        string `json:"id"`
+	Event     string `json:"event"`
+	IssueID   string `json:"issue_id,omitempty"`
+	Timestamp string `json:"timestamp"`
+	Data      any    `json:"data"`
+}
+
+// ParseWebhookConfig parses and validates a webhook config file.
+func ParseWebhookConfig(bs []byte) (WebhookConfig, error) {
+	var cfg WebhookConfig
+	if err := json.Unmarshal(bs, &cfg); err != nil {
+		return WebhookConfig{}, Errorf(CodeInvalidArgument, "invalid webhook config: %w", err)
+	}
+	for i, t := range cfg.Webhooks {
+		u := strings.TrimSpace(t.URL)
+		if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+			return WebhookConfig{}, Errorf(CodeInvalidArgument, "invalid webhook config: webhooks[%d].url must be http(s)", i)
+		}
+		cfg.Webhooks[i].URL = u
+		if t.TimeoutSec <= 0 {
+			cfg.Webhooks[i].TimeoutSec = 5
+		}
+	}
+	return cfg, nil
+}
+
+func (t WebhookTarget) wants(event string) bool {
+	if len(t.Events) == 0 {
+		return true
+	}
+	for _, e := range t.Events {
+		e = strings.TrimSpace(e)
+		if e == "*" || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookService POSTs lifecycle events to configured receivers from a background goroutine.
+type WebhookService struct {
+	targets []WebhookTarget
+	logger  *log.Logger
+	queue   chan WebhookPayload
+}
+
+// NewWebhookService starts the delivery goroutine. Returns nil when cfg has no targets.
+func NewWebhookService(cfg WebhookConfig, logger *log.Logger) *WebhookService {
+	if len(cfg.Webhooks) == 0 {
+		return nil
+	}
+	w := &WebhookService{
+		targets: cfg.Webhooks,
+		logger:  logger,
+		queue:   make(chan WebhookPayload, webhookQueueSize),
+	}
+	go w.loop()
+	return w
+}
+
+// Notify enqueues an event without blocking. Safe to call on a nil service and under store lock.
+func (w *WebhookService) Notify(event, issueID string, data any) {
+	if w == nil {
+		return
+	}
+	p := WebhookPayload{ID: GenID("hook"), Event: event, IssueID: issueID, Timestamp: NowStr(), Data: data}
+	select {
+	case w.queue <- p:
+	default:
+		w.logf("WARNING: webhook queue full; dropping %s event", event)
+	}
+}
+
+func (w *WebhookService) loop() {
+	for p := range w.queue {
+		body, err := json.Marshal(p)
+		if err != nil {
+			w.logf("WARNING: webhook marshal %s: %v", p.Event, err)
+			continue
+		}
+		for _, t := range w.targets {
+			if t.wants(p.Event) {
+				w.post(t, p.Event, body)
+			}
+		}
+	}
+}
+
+func (w *WebhookService) post(t WebhookTarget, event string, body []byte) {
+	req, err := http.NewRequest(http.MethodPost, t.URL, bytes.NewReader(body))
+	if err != nil {
+		w.logf("WARNING: webhook %s: %v", t.URL, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Swarm-Event", event)
+	if t.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(t.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Swarm-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	client := &http.Client{Timeout: time.Duration(t.TimeoutSec) * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		w.logf("WARNING: webhook %s (%s): %v", t.URL, event, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		w.logf("WARNING: webhook %s (%s): status %d", t.URL, event, resp.StatusCode)
+	}
+}
+
+func (w *WebhookService) logf(format string, args ...any) {
+	if w.logger != nil {
+		w.logger.Printf(format, args...)
+	}
+}
@@ -0,0 +1,44 @@
+package swarm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookService_PostsFilteredEvents(t *testing.T) {
+	got := make(chan WebhookPayload, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p WebhookPayload
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		if r.Header.Get("X-Swarm-Signature") == "" {
+			t.Errorf("missing signature header")
+		}
+		got <- p
+	}))
+	defer srv.Close()
+
+	cfg, err := ParseWebhookConfig([]byte(`{"webhooks":[{"url":"` + srv.URL + `","events":["issue_created"],"secret":"s"}]}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	w := NewWebhookService(cfg, nil)
+	w.Notify(EventIssueTaskMessage, "issue-1", nil)
+	w.Notify(EventIssueCreated, "issue-1", map[string]string{"subject": "s"})
+
+	select {
+	case p := <-got:
+		if p.Event != EventIssueCreated || p.IssueID != "issue-1" {
+			t.Fatalf("unexpected payload: %+v", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for webhook")
+	}
+	select {
+	case p := <-got:
+		t.Fatalf("unexpected extra payload: %+v", p)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
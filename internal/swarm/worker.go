@@ -1,20 +1,28 @@
 package swarm
 
 import (
-	"fmt"
 	"strings"
+	"sync"
+	"time"
 )
 
+// workerSeenEvery throttles Touch: a worker's last_seen_at is rewritten at most this often.
+const workerSeenEvery = 30 * time.Second
+
 type WorkerService struct {
 	store *Store
 	trace *TraceService
+
+	mu      sync.Mutex
+	touched map[string]time.Time // worker ID -> last Touch write by this process
 }
 
 func NewWorkerService(store *Store, trace *TraceService) *WorkerService {
 	return &WorkerService{store: store, trace: trace}
 }
 
-func (w *WorkerService) Register(workerID string) (*Worker, error) {
+// Register creates the worker (or refreshes an existing one). Non-nil skills replace the stored ones.
+func (w *WorkerService) Register(workerID string, skills []string) (*Worker, error) {
 	workerID = strings.TrimSpace(workerID)
 	if workerID == "" {
 		workerID = GenID("w")
@@ -28,6 +36,10 @@ func (w *WorkerService) Register(workerID string) (*Worker, error) {
 		var existing Worker
 		if err := w.store.ReadJSON(path, &existing); err == nil {
 			existing.UpdatedAt = NowStr()
+			existing.LastSeenAt = existing.UpdatedAt
+			if skills != nil {
+				existing.Skills = skills
+			}
 			if err := w.store.WriteJSON(path, &existing); err != nil {
 				return err
 			}
@@ -35,7 +47,8 @@ func (w *WorkerService) Register(workerID string) (*Worker, error) {
 			return nil
 		}
 
-		worker := &Worker{ID: workerID, JoinedAt: NowStr(), UpdatedAt: NowStr()}
+		now := NowStr()
+		worker := &Worker{ID: workerID, Skills: skills, JoinedAt: now, UpdatedAt: now, LastSeenAt: now}
 		if err := w.store.WriteJSON(path, worker); err != nil {
 			return err
 		}
@@ -49,6 +62,67 @@ func (w *WorkerService) Register(workerID string) (*Worker, error) {
 	return result, err
 }
 
+// Touch records that a registered worker is alive (last_seen_at). Writes are throttled to one per
+// workerSeenEvery per worker; unknown workers are ignored.
+func (w *WorkerService) Touch(workerID string) {
+	workerID = strings.TrimSpace(workerID)
+	if workerID == "" {
+		return
+	}
+	now := time.Now()
+	w.mu.Lock()
+	if now.Sub(w.touched[workerID]) < workerSeenEvery {
+		w.mu.Unlock()
+		return
+	}
+	if w.touched == nil {
+		w.touched = map[string]time.Time{}
+	}
+	w.touched[workerID] = now
+	w.mu.Unlock()
+
+	_ = w.store.WithLock(func() error {
+		path := w.store.Path("workers", workerID+".json")
+		var worker Worker
+		if err := w.store.ReadJSON(path, &worker); err != nil {
+			return nil
+		}
+		worker.LastSeenAt = now.UTC().Format(time.RFC3339)
+		return w.store.WriteJSON(path, &worker)
+	})
+}
+
+// KeepSeen touches the worker now and every workerSeenEvery until stop is called, so a worker blocked
+// in a long-poll still counts as alive.
+func (w *WorkerService) KeepSeen(workerID string) (stop func()) {
+	w.Touch(workerID)
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(workerSeenEvery)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				w.Touch(workerID)
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// WorkerSeenAt returns when the worker was last seen: its last tool call, else its last registration.
+// ok is false for records without a usable timestamp.
+func WorkerSeenAt(worker Worker) (seen time.Time, ok bool) {
+	for _, v := range []string{worker.LastSeenAt, worker.UpdatedAt, worker.JoinedAt} {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
 func (w *WorkerService) Exists(workerID string) bool {
 	workerID = strings.TrimSpace(workerID)
 	if workerID == "" {
@@ -59,7 +133,7 @@ func (w *WorkerService) Exists(workerID string) bool {
 
 func (w *WorkerService) Get(workerID string) (*Worker, error) {
 	if workerID == "" {
-		return nil, fmt.Errorf("worker_id is required")
+		return nil, Errorf(CodeInvalidArgument, "worker_id is required")
 	}
 	path := w.store.Path("workers", workerID+".json")
 	var worker Worker
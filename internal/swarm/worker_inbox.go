@@ -0,0 +1,191 @@
+package swarm
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Worker inbox reads. Review results, message replies and spec amendments are pushed to
+// issues/{id}/inbox/workers/{wid}; these let a worker that reconnects find what it missed. Assignment,
+// peer-review and submission comment items are shown but only consumed by waitAssignment/waitPeerReviews
+// and addSubmissionComment.
+
+// workerInboxConsumable reports whether reading an item of this type may mark it done.
+func workerInboxConsumable(itemType string) bool {
+	return itemType == InboxTypeReviewResult || itemType == InboxTypeReply || itemType == InboxTypeSpecAmended
+}
+
+// GetWorkerInbox returns the worker's inbox items, oldest first, each with the review verdict or reply it
+// refers to. issueID narrows it to one issue; done items are included only with includeDone. With ack,
+// the returned review results, replies and spec amendments are marked done.
+func (s *IssueService) GetWorkerInbox(workerID, issueID string, includeDone, ack bool) ([]map[string]any, error) {
+	workerID = strings.TrimSpace(workerID)
+	if workerID == "" {
+		return nil, Errorf(CodeInvalidArgument, "worker_id is required")
+	}
+	var items []InboxItem
+	err := s.store.WithLock(func() error {
+		for _, f := range s.workerInboxFiles(workerID, issueID) {
+			var item InboxItem
+			if err := s.store.ReadJSON(f, &item); err != nil || (item.Status == InboxDone && !includeDone) {
+				continue
+			}
+			if ack && item.Status != InboxDone && workerInboxConsumable(item.Type) {
+				item.Status = InboxDone
+				item.UpdatedAt = NowStr()
+				if err := s.store.WriteJSON(f, &item); err != nil {
+					return err
+				}
+			}
+			items = append(items, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Item IDs carry the creation time in milliseconds, so they break ties within a second.
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].CreatedAt != items[j].CreatedAt {
+			return items[i].CreatedAt < items[j].CreatedAt
+		}
+		return idLess(items[i].ID, items[j].ID)
+	})
+	out := make([]map[string]any, 0, len(items))
+	for i := range items {
+		out = append(out, s.materializeWorkerInboxItem(&items[i]))
+	}
+	return out, nil
+}
+
+// WaitWorkerInbox blocks until the worker has a pending review result, reply or spec amendment, marks it
+// done and returns it. Returns (nil, nil) on timeout.
+func (s *IssueService) WaitWorkerInbox(ctx context.Context, workerID, issueID string, timeoutSec int) (map[string]any, error) {
+	workerID = strings.TrimSpace(workerID)
+	if workerID == "" {
+		return nil, Errorf(CodeInvalidArgument, "worker_id is required")
+	}
+	deadline := s.deadline(s.normalizeTimeoutSec(ctx, timeoutSec))
+	for {
+		since := s.changeGen()
+		var found *InboxItem
+		err := s.store.WithLock(func() error {
+			var oldest string
+			for _, f := range s.workerInboxFiles(workerID, issueID) {
+				var item InboxItem
+				if err := s.store.ReadJSON(f, &item); err != nil || item.Status == InboxDone || !workerInboxConsumable(item.Type) {
+					continue
+				}
+				if found == nil || item.CreatedAt < found.CreatedAt || item.CreatedAt == found.CreatedAt && idLess(item.ID, found.ID) {
+					found, oldest = &item, f
+				}
+			}
+			if found == nil {
+				return nil
+			}
+			found.Status = InboxDone
+			found.UpdatedAt = NowStr()
+			return s.store.WriteJSON(oldest, found)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if found != nil {
+			return s.materializeWorkerInboxItem(found), nil
+		}
+		if timeExpired(deadline) {
+			return nil, nil
+		}
+		if err := s.waitChange(ctx, since, pollInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *IssueService) workerInboxFiles(workerID, issueID string) []string {
+	issueIDs := []string{strings.TrimSpace(issueID)}
+	if issueIDs[0] == "" {
+		issueIDs = nil
+		entries, _ := os.ReadDir(s.store.Path("issues"))
+		for _, e := range entries {
+			if e.IsDir() {
+				issueIDs = append(issueIDs, e.Name())
+			}
+		}
+	}
+	var files []string
+	for _, id := range issueIDs {
+		files = append(files, listJSONOrEmpty(s.store, s.store.Path("issues", id, "inbox", "workers", workerID))...)
+	}
+	return files
+}
+
+// materializeWorkerInboxItem loads the entity an item refers to: the reviewed submission's verdict and
+// feedback, the replied message, the spec amendment, the submission comment, or the assignment's
+// next-step token.
+func (s *IssueService) materializeWorkerInboxItem(item *InboxItem) map[string]any {
+	m := map[string]any{
+		"inbox_id":   item.ID,
+		"type":       item.Type,
+		"issue_id":   item.IssueID,
+		"task_id":    item.TaskID,
+		"ref_id":     item.RefID,
+		"sender_id":  item.SenderID,
+		"status":     item.Status,
+		"created_at": item.CreatedAt,
+	}
+	switch item.Type {
+	case InboxTypeReviewResult, InboxTypePeerReview:
+		sub, err := s.GetSubmission(item.IssueID, item.RefID)
+		if err != nil {
+			return m
+		}
+		m["submission_id"] = sub.ID
+		m["submission_status"] = sub.Status
+		if item.Type == InboxTypePeerReview {
+			m["submission_artifacts"] = sub.Artifacts
+			return m
+		}
+		m["verdict"] = sub.Status
+		m["feedback"] = sub.Feedback
+		m["completion_score"] = sub.CompletionScore
+		m["review_artifacts"] = sub.ReviewArtifacts
+		m["feedback_details"] = sub.FeedbackDetails
+		m["reviewed_by"] = sub.ReviewedBy
+		m["reviewed_at"] = sub.ReviewedAt
+		if sub.NextStepToken != "" {
+			m["next_step_token"] = sub.NextStepToken
+		}
+	case InboxTypeReply:
+		var msg TaskMessage
+		if err := s.store.ReadJSON(s.store.Path("issues", item.IssueID, "messages", item.RefID+".json"), &msg); err == nil {
+			m["message_id"] = msg.ID
+			m["kind"] = msg.Kind
+			m["question"] = msg.Content
+			m["reply"] = msg.ReplyContent
+			m["reply_by"] = msg.ReplyBy
+			m["replied_at"] = msg.RepliedAt
+		}
+	case InboxTypeSpecAmended:
+		var a SpecAmendment
+		if err := s.store.ReadJSON(s.store.Path("issues", item.IssueID, "amendments", item.RefID+".json"), &a); err == nil {
+			m["amendment_id"] = a.ID
+			m["doc"] = a.Doc
+			m["doc_version"] = a.DocVersion
+			m["section"] = a.Section
+			m["content"] = a.Content
+			m["amended_at"] = a.CreatedAt
+		}
+	case InboxTypeSubmissionComment:
+		if c, err := s.findSubmissionComment(item.IssueID, item.RefID); err == nil {
+			m["comment"] = c
+		}
+	case InboxTypeAssigned:
+		m["next_step_token"] = item.RefID
+	case InboxTypeLeaseExpiring:
+		s.leaseExpiringDetails(item, m)
+	}
+	return m
+}
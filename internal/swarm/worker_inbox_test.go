@@ -0,0 +1,73 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWorkerInbox_ListsAndConsumesReviewResults(t *testing.T) {
+	store := NewStore(t.TempDir())
+	svc := NewIssueService(store, NewTraceService(store), 7200, 3600, 1, 1)
+
+	issueID := "issue-1"
+	if err := store.WriteJSON(store.Path("issues", issueID, "issue.json"), &Issue{ID: issueID, Status: IssueInProgress}); err != nil {
+		t.Fatalf("write issue: %v", err)
+	}
+	sub := Submission{ID: "sub-1", IssueID: issueID, TaskID: "task-1", WorkerID: "w1", Status: SubmissionRejected, Feedback: "add tests", CompletionScore: 2, ReviewedBy: "lead"}
+	if err := store.WriteJSON(store.Path("issues", issueID, "submissions", "task-1", sub.ID+".json"), &sub); err != nil {
+		t.Fatalf("write submission: %v", err)
+	}
+	msg := TaskMessage{ID: "msg-1", IssueID: issueID, TaskID: "task-1", Kind: "question", Content: "which API?", ReplyContent: "v2", Status: "replied"}
+	if err := store.WriteJSON(store.Path("issues", issueID, "messages", msg.ID+".json"), &msg); err != nil {
+		t.Fatalf("write message: %v", err)
+	}
+	if _, err := svc.pushToWorkerInboxLocked(issueID, "w1", "task-1", InboxTypeReviewResult, sub.ID, "lead"); err != nil {
+		t.Fatalf("push review result: %v", err)
+	}
+	if _, err := svc.pushToWorkerInboxLocked(issueID, "w1", "task-1", InboxTypeAssigned, "ns-1", "scheduler"); err != nil {
+		t.Fatalf("push assignment: %v", err)
+	}
+
+	items, err := svc.GetWorkerInbox("w1", "", false, false)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("expected 2 items, got %v %v", items, err)
+	}
+	if r := inboxItemOfType(items, InboxTypeReviewResult); r == nil || r["verdict"] != SubmissionRejected || r["feedback"] != "add tests" {
+		t.Fatalf("expected the rejection with its feedback, got %v", items)
+	}
+
+	got, err := svc.WaitWorkerInbox(context.Background(), "w1", issueID, 1)
+	if err != nil || got == nil || got["submission_id"] != "sub-1" {
+		t.Fatalf("expected the review result, got %v %v", got, err)
+	}
+	if got, err := svc.WaitWorkerInbox(context.Background(), "w1", issueID, 1); err != nil || got != nil {
+		t.Fatalf("expected the assignment to be left for waitAssignment, got %v %v", got, err)
+	}
+
+	if _, err := svc.pushToWorkerInboxLocked(issueID, "w1", "task-1", InboxTypeReply, msg.ID, "lead"); err != nil {
+		t.Fatalf("push reply: %v", err)
+	}
+	items, err = svc.GetWorkerInbox("w1", issueID, false, true)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("expected the assignment and the reply, got %v %v", items, err)
+	}
+	if r := inboxItemOfType(items, InboxTypeReply); r == nil || r["reply"] != "v2" {
+		t.Fatalf("expected the reply text, got %v", items)
+	}
+	items, _ = svc.GetWorkerInbox("w1", issueID, false, false)
+	if len(items) != 1 || items[0]["type"] != InboxTypeAssigned {
+		t.Fatalf("expected only the assignment left after ack, got %v", items)
+	}
+	if all, _ := svc.GetWorkerInbox("w1", issueID, true, false); len(all) != 3 {
+		t.Fatalf("expected 3 items with include_done, got %d", len(all))
+	}
+}
+
+func inboxItemOfType(items []map[string]any, itemType string) map[string]any {
+	for _, it := range items {
+		if it["type"] == itemType {
+			return it
+		}
+	}
+	return nil
+}